@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+
+	"winshot/internal/config"
+	winEnum "winshot/internal/windows"
+)
+
+// findAnchorWindow returns the topmost visible window of an anchor's app
+// whose title contains the anchor's title
+func findAnchorWindow(a config.AnchorConfig) (uintptr, error) {
+	wins, err := winEnum.EnumWindows()
+	if err != nil {
+		return 0, err
+	}
+	minimized := false
+	for _, w := range wins {
+		if a.Title != "" && !strings.Contains(strings.ToLower(w.Title), strings.ToLower(a.Title)) {
+			continue
+		}
+		if a.App != "" && !strings.EqualFold(winEnum.ProcessImageName(winEnum.WindowProcessID(w.Handle)), a.App) {
+			continue
+		}
+		if winEnum.IsMinimized(w.Handle) {
+			minimized = true
+			continue
+		}
+		return w.Handle, nil
+	}
+	if minimized {
+		return 0, fmt.Errorf("the window for anchor %q is minimized", a.Name)
+	}
+	return 0, fmt.Errorf("no window found for anchor %q", a.Name)
+}
+
+// anchorRect returns where an anchored region is on screen now
+func anchorRect(a config.AnchorConfig) (image.Rectangle, error) {
+	hwnd, err := findAnchorWindow(a)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	client, err := winEnum.ClientBounds(hwnd)
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("failed to read the window of anchor %q: %w", a.Name, err)
+	}
+	return a.Rect(client, winEnum.WindowDPI(hwnd))
+}
+
+// anchorAt returns an anchor for the screen region r, in the client area of
+// the topmost window under its center that isn't WinShot's own
+func anchorAt(name string, r image.Rectangle) (config.AnchorConfig, error) {
+	wins, err := winEnum.EnumWindows()
+	if err != nil {
+		return config.AnchorConfig{}, err
+	}
+	center := image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+	for _, w := range wins {
+		pid := winEnum.WindowProcessID(w.Handle)
+		if pid == uint32(os.Getpid()) || winEnum.IsMinimized(w.Handle) {
+			continue
+		}
+		client, err := winEnum.ClientBounds(w.Handle)
+		if err != nil || !center.In(client) {
+			continue
+		}
+		a := config.NewAnchor(name, r, client, winEnum.WindowDPI(w.Handle))
+		a.App = winEnum.ProcessImageName(pid)
+		if a.App == "" {
+			a.Title = w.Title
+		}
+		return a, a.Validate()
+	}
+	return config.AnchorConfig{}, fmt.Errorf("no window under %v", center)
+}
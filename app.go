@@ -4,27 +4,53 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"mime"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"golang.org/x/image/bmp"
 	"golang.org/x/image/webp"
+	"golang.org/x/sys/windows/registry"
+	"winshot/internal/annotate"
 	"winshot/internal/config"
 	"winshot/internal/hotkeys"
+	"winshot/internal/history"
+	"winshot/internal/imaging"
+	"winshot/internal/layout"
 	"winshot/internal/library"
+	"winshot/internal/notes"
+	"winshot/internal/ocr"
 	"winshot/internal/overlay"
+	"winshot/internal/plugin"
+	"winshot/internal/project"
+	"winshot/internal/provenance"
+	"winshot/internal/scheduler"
 	"winshot/internal/screenshot"
+	"winshot/internal/scrollcapture"
+	"winshot/internal/script"
+	"winshot/internal/server"
 	"winshot/internal/tray"
+	"winshot/internal/triggers"
 	"winshot/internal/updater"
 	"winshot/internal/upload"
+	"winshot/internal/watchdog"
 	winEnum "winshot/internal/windows"
 )
 
@@ -36,7 +62,21 @@ type App struct {
 	ctx              context.Context
 	hotkeyManager    *hotkeys.HotkeyManager
 	overlayManager   *overlay.Manager
+	elementsBusy     atomic.Bool // UI elements are being read for the overlay to snap to
+	cropGuide        *overlay.Guide
+	statusWidget     *overlay.Status
+	triggerWatcher   *triggers.Watcher
+	watchdogStop     chan struct{} // Closed to stop watching the current process
+	scheduleStop     chan struct{} // Closed to stop the task scheduler
+	shareMu          sync.Mutex                // Guards shareServer
+	shareServer      *server.Server            // Started on the first local share
+	pluginsMu        sync.Mutex                // Guards plugins
+	plugins          []*plugin.Plugin          // Loaded sandboxed modules
+	statusActions    chan overlay.StatusAction // Buttons clicked on the status widget
+	countingDown     atomic.Bool               // A delayed capture is counting down
+	scriptRunning    atomic.Bool               // A workflow script is running
 	trayIcon         *tray.TrayIcon
+	historyStore     *history.Store // Capture history, nil when turned off
 	config           *config.Config
 	lastWidth        int
 	lastHeight       int
@@ -46,11 +86,21 @@ type App struct {
 	preCaptureY      int  // Window Y position before capture
 	isCapturing      bool // Flag to prevent resize events during capture
 	isWindowHidden   bool // Track window visibility state
+	safeMode         bool // Started with --safe-mode: defaults, one hotkey, no integrations
+
+	// Last capture context, written by hotkey pipelines, triggers and
+	// scheduled tasks on their own goroutines
+	captureMu   sync.Mutex
+	lastCapture captureInfo
 
 	// Cloud upload
-	credManager    *upload.CredentialManager
-	r2Uploader     *upload.R2Uploader
-	gdriveUploader *upload.GDriveUploader
+	credManager        *upload.CredentialManager
+	r2Uploader         *upload.R2Uploader
+	s3Uploader         *upload.S3Uploader
+	gdriveUploader     *upload.GDriveUploader
+	confluenceUploader *upload.ConfluenceUploader
+	ephemeralUploader  *upload.EphemeralUploader
+	uploadQueue        *upload.Queue // Background uploads, reported as "upload:progress" events
 }
 
 // NewApp creates a new App application struct
@@ -63,11 +113,22 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
 	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		cfg = config.Default()
+	var cfg *config.Config
+	if a.safeMode {
+		cfg = safeModeConfig()
+		a.verbosef("Using default settings, hotkey %s only", cfg.Hotkeys.Fullscreen)
+	} else {
+		var err error
+		if cfg, err = config.Load(); err != nil {
+			cfg = config.Default()
+		}
 	}
 	a.config = cfg
+	applyEncoders(cfg.Export)
+	screenshot.SetIncludeCursor(cfg.Capture.IncludeCursor)
+
+	// Large captures show a quick preview in the editor while they encode
+	screenshot.BeforeEncode = a.emitPreview
 
 	// Initialize hotkey manager
 	a.hotkeyManager = hotkeys.NewHotkeyManager()
@@ -76,14 +137,48 @@ func (a *App) startup(ctx context.Context) {
 	// Register hotkeys from config
 	a.registerHotkeysFromConfig()
 	a.hotkeyManager.Start()
+	a.verbosef("Hotkey manager started")
 
 	// Initialize overlay manager for native region selection
 	a.overlayManager = overlay.NewManager()
 	if err := a.overlayManager.Start(); err != nil {
 		// Log warning but continue - will fall back to React overlay
 		println("Warning: failed to start overlay manager:", err.Error())
+		recordError("overlay", err)
+	}
+	a.verbosef("Overlay manager started")
+
+	// Restore the crop guide from the last session
+	a.cropGuide = overlay.NewGuide()
+	if a.safeMode {
+		a.verbosef("Skipped crop guide, triggers, watchdog, schedule, URL stamp, plugins and filters")
+	} else {
+		if err := a.applyCropGuide(); err != nil {
+			println("Warning: failed to show crop guide:", err.Error())
+		}
+
+		if err := a.applyTriggers(); err != nil {
+			println("Warning: failed to start capture triggers:", err.Error())
+			recordError("triggers", err)
+		}
+
+		a.applyWatchdog()
+		a.applySchedule()
+		a.applyURLStamp()
+		a.applyPlugins()
+		a.applyExternalFilters()
+		a.applyHistory()
 	}
 
+	// Status widget for delayed captures, created hidden
+	a.statusActions = make(chan overlay.StatusAction, 4)
+	a.statusWidget = overlay.NewStatus(func(action overlay.StatusAction) {
+		select {
+		case a.statusActions <- action:
+		default:
+		}
+	})
+
 	// Initialize system tray with version in tooltip
 	a.trayIcon = tray.NewTrayIcon(fmt.Sprintf("WinShot v%s", Version))
 	a.trayIcon.SetCallback(a.onTrayMenu)
@@ -104,20 +199,40 @@ func (a *App) startup(ctx context.Context) {
 		a.isWindowHidden = true
 	}
 
-	// Initialize cloud upload
-	a.credManager = upload.NewCredentialManager()
+	// Initialize cloud upload. Safe mode reads no stored credentials, so
+	// every provider reports itself unconfigured and nothing goes online
+	if a.safeMode {
+		a.credManager = upload.NewMemoryCredentialManager(nil)
+		a.verbosef("Cloud upload disabled")
+	} else {
+		a.credManager = upload.NewCredentialManager()
+	}
 	a.r2Uploader = upload.NewR2Uploader(a.credManager, &upload.R2Config{
 		AccountID: a.config.Cloud.R2.AccountID,
 		Bucket:    a.config.Cloud.R2.Bucket,
 		PublicURL: a.config.Cloud.R2.PublicURL,
 	})
+	a.s3Uploader = upload.NewS3Uploader(a.credManager, s3Config(a.config.Cloud.S3))
 	a.gdriveUploader = upload.NewGDriveUploader(a.credManager, &upload.GDriveConfig{
 		FolderID: a.config.Cloud.GDrive.FolderID,
 	})
+	a.confluenceUploader = upload.NewConfluenceUploader(a.credManager, confluenceConfig(a.config.Cloud.Confluence))
+	a.ephemeralUploader = upload.NewEphemeralUploader(ephemeralConfig(a.config.Cloud.Ephemeral))
+	if schedule, err := upload.ParseSchedule(a.config.Cloud.Limit.Schedule); err != nil {
+		println("Warning: ignoring upload limit:", err.Error())
+	} else {
+		upload.SetRateLimit(a.config.Cloud.Limit.KBps, schedule)
+	}
+	a.uploadQueue = upload.NewQueue(upload.QueueOptions{
+		OnProgress: func(p upload.Progress) { runtime.EventsEmit(a.ctx, "upload:progress", p) },
+	})
+	a.verbosef("Startup complete")
 }
 
 // shutdown is called when the app is closing
 func (a *App) shutdown(ctx context.Context) {
+	a.verbosef("Shutting down")
+
 	// Save window size before closing using tracked values
 	if a.config != nil && a.lastWidth >= 800 && a.lastHeight >= 600 {
 		a.config.Window.Width = a.lastWidth
@@ -133,6 +248,36 @@ func (a *App) shutdown(ctx context.Context) {
 	if a.overlayManager != nil {
 		a.overlayManager.Stop()
 	}
+	if a.cropGuide != nil {
+		a.cropGuide.Close()
+	}
+	if a.statusWidget != nil {
+		a.statusWidget.Close()
+	}
+	if a.triggerWatcher != nil {
+		a.triggerWatcher.Stop()
+	}
+	if a.watchdogStop != nil {
+		close(a.watchdogStop)
+	}
+	if a.scheduleStop != nil {
+		close(a.scheduleStop)
+	}
+	if a.uploadQueue != nil {
+		a.uploadQueue.Close()
+	}
+	a.pluginsMu.Lock()
+	plugins := a.plugins
+	a.plugins = nil
+	a.pluginsMu.Unlock()
+	for _, p := range plugins {
+		p.Close()
+	}
+	a.shareMu.Lock()
+	if a.shareServer != nil {
+		a.shareServer.Close()
+	}
+	a.shareMu.Unlock()
 	if a.trayIcon != nil {
 		a.trayIcon.Stop()
 	}
@@ -140,14 +285,56 @@ func (a *App) shutdown(ctx context.Context) {
 
 // onHotkey handles global hotkey events
 func (a *App) onHotkey(id int) {
+	a.verbosef("Hotkey %d pressed", id)
+	var action string
 	switch id {
 	case hotkeys.HotkeyFullscreen:
-		runtime.EventsEmit(a.ctx, "hotkey:fullscreen")
+		action = "fullscreen"
 	case hotkeys.HotkeyRegion:
-		runtime.EventsEmit(a.ctx, "hotkey:region")
+		action = "region"
 	case hotkeys.HotkeyWindow:
-		runtime.EventsEmit(a.ctx, "hotkey:window")
+		action = "window"
+	case hotkeys.HotkeyRepeat:
+		action = "repeat"
+	case hotkeys.HotkeyText:
+		go a.copyRegionText()
+		return
+	case hotkeys.HotkeyColor:
+		go a.copyScreenColor()
+		return
+	default:
+		if i := id - hotkeys.HotkeyScriptBase; a.config != nil && i >= 0 && i < len(a.config.Scripts) {
+			go a.runScript(a.config.Scripts[i])
+		}
+		return
+	}
+
+	if a.config == nil {
+		runtime.EventsEmit(a.ctx, "hotkey:"+action)
+		return
+	}
+
+	pipeline := a.config.Hotkeys.Action(action)
+	if pipeline.Delay > 0 {
+		go a.runDelayedHotkey(action, pipeline)
+		return
+	}
+	a.dispatchHotkey(action, pipeline)
+}
+
+// dispatchHotkey runs the configured pipeline for a hotkey action, or hands the
+// action to the frontend when its destination is the editor
+func (a *App) dispatchHotkey(action string, pipeline config.HotkeyActionConfig) {
+	// Actions with a configured pipeline are handled without opening the editor
+	if pipeline.Destination != config.DestinationEditor {
+		go a.runHotkeyPipeline(action, pipeline)
+		return
 	}
+	if action == "repeat" {
+		go a.repeatRegionToEditor()
+		return
+	}
+	runtime.EventsEmit(a.ctx, "hotkey:"+action)
 }
 
 // onTrayMenu handles tray menu selections
@@ -159,6 +346,13 @@ func (a *App) onTrayMenu(menuID int) {
 		runtime.EventsEmit(a.ctx, "hotkey:region")
 	case tray.MenuWindow:
 		runtime.EventsEmit(a.ctx, "hotkey:window")
+	case tray.MenuGuideSet:
+		a.SelectCropGuide()
+	case tray.MenuGuide:
+		_, visible := a.cropGuide.Visible()
+		if err := a.ShowCropGuide(!visible); err != nil {
+			a.trayIcon.ShowBalloon("Crop Guide", err.Error())
+		}
 	case tray.MenuLibrary:
 		// Show main window first so library modal has context
 		runtime.WindowShow(a.ctx)
@@ -271,6 +465,7 @@ func (a *App) PrepareRegionCapture() (*RegionCaptureData, error) {
 		a.isCapturing = false
 		return nil, err
 	}
+	cursor := screenshot.SnapshotCursor()
 
 	// Calculate scale ratio between physical screenshot and logical window size
 	scaleRatio := float64(rgbaImg.Bounds().Dx()) / float64(virtualWidth)
@@ -280,7 +475,7 @@ func (a *App) PrepareRegionCapture() (*RegionCaptureData, error) {
 
 	// Show native overlay and get result channel
 	bounds := image.Rect(screenX, screenY, screenX+virtualWidth, screenY+virtualHeight)
-	resultCh := a.overlayManager.Show(rgbaImg, bounds, scaleRatio)
+	resultCh := a.showOverlay(rgbaImg, bounds, scaleRatio)
 
 	// Wait for selection result in goroutine
 	go func() {
@@ -293,14 +488,24 @@ func (a *App) PrepareRegionCapture() (*RegionCaptureData, error) {
 			return
 		}
 
-		// Scale coordinates to physical pixels
-		scaledX := int(float64(selResult.X) * scaleRatio)
-		scaledY := int(float64(selResult.Y) * scaleRatio)
-		scaledW := int(float64(selResult.Width) * scaleRatio)
-		scaledH := int(float64(selResult.Height) * scaleRatio)
+		a.recordCapture(screenshot.GetMonitorAtPoint(
+			screenX+selResult.X+selResult.Width/2,
+			screenY+selResult.Y+selResult.Height/2,
+		))
+		a.rememberRegion(selResult, scaleRatio, image.Pt(screenX, screenY))
 
 		// Crop to selected region before encoding (much faster - smaller image)
-		croppedImg := rgbaImg.SubImage(image.Rect(scaledX, scaledY, scaledX+scaledW, scaledY+scaledH))
+		croppedImg, err := screenshot.ApplyFilters(cropSelection(rgbaImg, selResult, scaleRatio, cursor, image.Pt(screenX, screenY)))
+		if err != nil {
+			println("Warning: capture filter failed:", err.Error())
+			runtime.WindowShow(a.ctx)
+			a.isWindowHidden = false
+			a.isCapturing = false
+			return
+		}
+		scaledW := croppedImg.Bounds().Dx()
+		scaledH := croppedImg.Bounds().Dy()
+		a.emitPreview(croppedImg)
 
 		var buf bytes.Buffer
 		if err := png.Encode(&buf, croppedImg); err != nil {
@@ -332,6 +537,54 @@ func (a *App) PrepareRegionCapture() (*RegionCaptureData, error) {
 	}, nil
 }
 
+// Captures of at least previewMinPixels take long enough to encode as PNG
+// that a quick JPEG preview, at most previewMaxSide pixels across, is sent
+// to the editor first
+const (
+	previewMinPixels = 2560 * 1440
+	previewMaxSide   = 1280
+	previewQuality   = 70
+)
+
+// emitPreview sends a low resolution JPEG of a large capture to the editor,
+// which shows it until the full PNG arrives
+func (a *App) emitPreview(img *image.RGBA) {
+	size := img.Bounds().Size()
+	if a.ctx == nil || size.X*size.Y < previewMinPixels {
+		return
+	}
+	data, err := imaging.PreviewJPEG(img, previewMaxSide, previewQuality)
+	if err != nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, "capture:preview", map[string]interface{}{
+		"width":  size.X,
+		"height": size.Y,
+		"data":   base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+// cropSelection crops a virtual screen capture to an overlay selection,
+// scaling logical selection coordinates to physical pixels. Ellipse and freehand
+// selections are masked to their shape, and the cursor snapshot taken with the
+// capture (origin is its screen position) is drawn in when the user asked for it.
+func cropSelection(img *image.RGBA, sel overlay.Result, scaleRatio float64, cursor *screenshot.CursorSnapshot, origin image.Point) image.Image {
+	if sel.IncludeCursor {
+		cursor.DrawOn(img, origin)
+	}
+
+	x := int(float64(sel.X) * scaleRatio)
+	y := int(float64(sel.Y) * scaleRatio)
+	w := int(float64(sel.Width) * scaleRatio)
+	h := int(float64(sel.Height) * scaleRatio)
+	cropped := img.SubImage(image.Rect(x, y, x+w, y+h))
+
+	if mask := sel.Mask(scaleRatio); mask != nil {
+		return imaging.ApplyMask(cropped, mask)
+	}
+	return cropped
+}
+
 // imageToRGBA converts an image.Image to *image.RGBA
 func imageToRGBA(img image.Image) *image.RGBA {
 	if rgba, ok := img.(*image.RGBA); ok {
@@ -364,23 +617,131 @@ func (a *App) ShowWindow() {
 	runtime.WindowSetAlwaysOnTop(a.ctx, false)
 }
 
+// captureInfo is when and where a capture happened, recorded in
+// provenance manifests and the history index
+type captureInfo struct {
+	at      time.Time // Zero when unknown, so manifests leave it out
+	display int
+	windows []library.WindowRecord // Also recorded in the history index
+	url     string                 // Foreground browser tab, when enabled
+	app     string                 // Source app of a pasted image
+}
+
+// sourceApp returns the app a pasted image was copied from, or the
+// foreground app of the capture
+func (c captureInfo) sourceApp() string {
+	if c.app != "" {
+		return c.app
+	}
+	for _, w := range c.windows {
+		if w.Foreground {
+			return w.App
+		}
+	}
+	return ""
+}
+
+// foreground returns the window that had the focus at capture time
+func (c captureInfo) foreground() (library.WindowRecord, bool) {
+	for _, w := range c.windows {
+		if w.Foreground {
+			return w, true
+		}
+	}
+	return library.WindowRecord{}, false
+}
+
+// captureInfo returns the context of the latest capture
+func (a *App) captureInfo() captureInfo {
+	a.captureMu.Lock()
+	defer a.captureMu.Unlock()
+	return a.lastCapture
+}
+
+// setCaptureInfo replaces the context of the latest capture
+func (a *App) setCaptureInfo(c captureInfo) {
+	a.captureMu.Lock()
+	a.lastCapture = c
+	a.captureMu.Unlock()
+}
+
+// recordCapture remembers when and where the latest capture happened
+func (a *App) recordCapture(displayIndex int) {
+	c := captureInfo{at: time.Now(), display: displayIndex}
+	if a.config != nil && !a.config.QuickSave.NoWindowHistory {
+		c.windows = snapshotWindows()
+	}
+	if a.config != nil && (a.config.Browser.RecordURL || a.config.Browser.StampURL) {
+		c.url = foregroundBrowserURL()
+	}
+	a.setCaptureInfo(c)
+}
+
+// snapshotWindows lists the visible top-level windows in Z-order, topmost
+// first. Browsers and chat apps put the active tab or channel in the window
+// title, which is what makes a capture traceable later.
+func snapshotWindows() []library.WindowRecord {
+	wins, err := winEnum.EnumWindows()
+	if err != nil {
+		return nil
+	}
+	foreground := winEnum.GetForegroundWindow()
+	apps := make(map[uint32]string) // Executable names by process ID
+	records := make([]library.WindowRecord, 0, len(wins))
+	for _, w := range wins {
+		pid := winEnum.WindowProcessID(w.Handle)
+		app, ok := apps[pid]
+		if !ok {
+			app = winEnum.ProcessImageName(pid)
+			apps[pid] = app
+		}
+		records = append(records, library.WindowRecord{
+			Title:      w.Title,
+			App:        app,
+			Class:      w.ClassName,
+			X:          w.X,
+			Y:          w.Y,
+			Width:      w.Width,
+			Height:     w.Height,
+			Foreground: w.Handle == foreground,
+			Minimized:  winEnum.IsMinimized(w.Handle),
+		})
+	}
+	return records
+}
+
 // CaptureFullscreen captures the display where the cursor is currently located
 func (a *App) CaptureFullscreen() (*screenshot.CaptureResult, error) {
+	a.recordCapture(screenshot.GetMonitorAtCursor())
 	return screenshot.CaptureFullscreen()
 }
 
 // CaptureRegion captures a specific region of the screen
 func (a *App) CaptureRegion(x, y, width, height int) (*screenshot.CaptureResult, error) {
-	return screenshot.CaptureRegion(x, y, width, height)
+	a.recordCapture(screenshot.GetMonitorAtPoint(x+width/2, y+height/2))
+	return screenshot.CaptureRect(image.Rect(x, y, x+width, y+height), screenshot.CaptureOptions{Clamp: true})
 }
 
 // CaptureDisplay captures a specific display by index
 func (a *App) CaptureDisplay(displayIndex int) (*screenshot.CaptureResult, error) {
+	a.recordCapture(displayIndex)
 	return screenshot.CaptureDisplay(displayIndex)
 }
 
+// CaptureScrolling captures a window from its current scroll position to
+// the bottom as one long image
+func (a *App) CaptureScrolling(hwnd int) (*screenshot.CaptureResult, error) {
+	if info, _ := winEnum.GetWindowInfo(uintptr(hwnd)); info != nil {
+		a.recordCapture(screenshot.GetMonitorAtPoint(info.X+info.Width/2, info.Y+info.Height/2))
+	}
+	return scrollcapture.CaptureScrolling(uintptr(hwnd))
+}
+
 // CaptureWindow captures a specific window by handle
 func (a *App) CaptureWindow(hwnd int) (*screenshot.CaptureResult, error) {
+	if info, _ := winEnum.GetWindowInfo(uintptr(hwnd)); info != nil {
+		a.recordCapture(screenshot.GetMonitorAtPoint(info.X+info.Width/2, info.Y+info.Height/2))
+	}
 	result, err := screenshot.CaptureWindowByCoords(uintptr(hwnd))
 
 	// Bring WinShot back to front after capture
@@ -397,6 +758,12 @@ func (a *App) GetDisplayCount() int {
 	return screenshot.GetDisplayCount()
 }
 
+// IsSecureDesktopActive reports whether a UAC prompt or the lock screen has
+// input, in which case captures fail with screenshot.ErrSecureDesktop
+func (a *App) IsSecureDesktopActive() bool {
+	return screenshot.SecureDesktopActive()
+}
+
 // GetActiveDisplayIndex returns the index of the display where the cursor is located
 func (a *App) GetActiveDisplayIndex() int {
 	return screenshot.GetMonitorAtCursor()
@@ -492,41 +859,160 @@ func (a *App) SaveImage(imageData string, format string) SaveImageResult {
 		return SaveImageResult{Success: false, Error: "Failed to decode image data: " + err.Error()}
 	}
 
+	data = a.attachColorProfile(data, format)
+
 	// Write to file
 	err = os.WriteFile(filePath, data, 0644)
 	if err != nil {
 		return SaveImageResult{Success: false, Error: "Failed to save file: " + err.Error()}
 	}
+	a.writeManifest(filePath, data)
+	go a.recordHistoryData(data, history.Entry{Path: filePath, Source: "editor"})
 
 	return SaveImageResult{Success: true, FilePath: filePath}
 }
 
 // QuickSave saves a base64 encoded image to the configured directory
 func (a *App) QuickSave(imageData string, format string) SaveImageResult {
+	filePath, err := a.quickSavePath(format)
+	if err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
+	}
+
+	// Decode and save
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return SaveImageResult{Success: false, Error: "Failed to decode image data: " + err.Error()}
+	}
+
+	data = a.attachColorProfile(data, format)
+
+	err = a.writeHistoryFile(filePath, data)
+	if err != nil {
+		return SaveImageResult{Success: false, Error: "Failed to save file: " + err.Error()}
+	}
+	a.writeManifest(filePath, data)
+	go a.recordHistoryData(data, history.Entry{Path: filePath, Source: "editor"})
+
+	return SaveImageResult{Success: true, FilePath: filePath}
+}
+
+// ShareImage opens the Windows share sheet for an edited image so it can be
+// sent to Mail, OneNote, Nearby Share or another share target. The image is
+// written to a temporary folder that is cleared on the next share, since the
+// target reads the file after the sheet has closed
+func (a *App) ShareImage(imageData string, format string) error {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return fmt.Errorf("failed to decode image data: %w", err)
+	}
+
+	dir := filepath.Join(os.TempDir(), "winshot-share")
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := "winshot_" + time.Now().Format("2006-01-02_15-04-05") + imageExtension(format)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	win := winEnum.FindProcessWindow(uint32(os.Getpid()))
+	if win == nil {
+		return errors.New("no WinShot window to share from")
+	}
+	return winEnum.ShareImageFile(win.Handle, path, name)
+}
+
+// CopyFileToClipboard puts a saved screenshot on the clipboard as a file
+// along with its path, so it pastes into Explorer, Outlook or Teams as the
+// file itself rather than pixels
+func (a *App) CopyFileToClipboard(filePath string) error {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a folder", abs)
+	}
+	return screenshot.SetClipboardFiles(abs)
+}
+
+// writeHistoryFile writes a capture into the quick save folder, through the
+// deduplicated store when enabled
+func (a *App) writeHistoryFile(filePath string, data []byte) error {
+	var err error
+	if a.config != nil && a.config.QuickSave.Deduplicate {
+		err = library.WriteDeduplicated(filePath, data, time.Now())
+	} else {
+		err = os.WriteFile(filePath, data, 0644)
+	}
+	if c := a.captureInfo(); err == nil && (c.windows != nil || c.url != "" || c.app != "") {
+		if err := a.recordCaptureContext(filePath, c); err != nil {
+			println("Warning: failed to record capture context:", err.Error())
+		}
+	}
+	return err
+}
+
+// recordCaptureContext stores the window snapshot and browser URL of a
+// capture in the history index, with the foreground window as the capture's
+// source. Pasted images record the app and page they were copied from
+func (a *App) recordCaptureContext(filePath string, c captureInfo) error {
+	index, err := library.OpenIndex(filepath.Dir(filePath))
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(filePath)
+	if c.app != "" {
+		index.SetSource(name, c.app, "")
+	}
+	if w, ok := c.foreground(); ok {
+		index.SetSource(name, w.App, w.Title)
+	}
+	if c.windows != nil {
+		index.SetWindows(name, c.windows)
+	}
+	if c.url != "" && a.config.Browser.RecordURL {
+		index.SetURL(name, c.url)
+	}
+	return index.Save()
+}
+
+// quickSaveDir returns the quick save folder, creating it if needed
+func (a *App) quickSaveDir() (string, error) {
 	// Get save directory from config (fallback to default)
 	saveDir := a.config.QuickSave.Folder
 	if saveDir == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return SaveImageResult{Success: false, Error: "Failed to get home directory: " + err.Error()}
+			return "", fmt.Errorf("Failed to get home directory: %w", err)
 		}
 		saveDir = filepath.Join(homeDir, "Pictures", "WinShot")
 	}
 
 	// Create save directory if it doesn't exist
-	err := os.MkdirAll(saveDir, 0755)
+	if err := os.MkdirAll(saveDir, 0755); err != nil {
+		return "", fmt.Errorf("Failed to create save directory: %w", err)
+	}
+	return saveDir, nil
+}
+
+// quickSavePath returns the next file path in the quick save folder,
+// named according to the configured pattern
+func (a *App) quickSavePath(format string) (string, error) {
+	saveDir, err := a.quickSaveDir()
 	if err != nil {
-		return SaveImageResult{Success: false, Error: "Failed to create save directory: " + err.Error()}
+		return "", err
 	}
 
 	// Determine file extension
-	var ext string
-	switch strings.ToLower(format) {
-	case "jpeg", "jpg":
-		ext = ".jpg"
-	default:
-		ext = ".png"
-	}
+	ext := imageExtension(format)
 
 	// Generate filename based on configured pattern
 	var filename string
@@ -564,107 +1050,381 @@ func (a *App) QuickSave(imageData string, format string) SaveImageResult {
 			}
 			counter++
 		}
+	case "source":
+		// Source and timestamp: winshot_example.com_2024-01-15_14-30-45.png
+		filename = "winshot_" + now.Format("2006-01-02_15-04-05") + ext
+		c := a.captureInfo()
+		if source := captureSourceName(c.url, c.sourceApp()); source != "" {
+			filename = "winshot_" + source + "_" + now.Format("2006-01-02_15-04-05") + ext
+		}
 	default: // "timestamp"
 		// Full timestamp: winshot_2024-01-15_14-30-45.png
 		filename = "winshot_" + now.Format("2006-01-02_15-04-05") + ext
 	}
 
-	filePath := filepath.Join(saveDir, filename)
-
-	// Decode and save
-	data, err := base64.StdEncoding.DecodeString(imageData)
-	if err != nil {
-		return SaveImageResult{Success: false, Error: "Failed to decode image data: " + err.Error()}
-	}
-
-	err = os.WriteFile(filePath, data, 0644)
-	if err != nil {
-		return SaveImageResult{Success: false, Error: "Failed to save file: " + err.Error()}
-	}
-
-	return SaveImageResult{Success: true, FilePath: filePath}
+	return filepath.Join(saveDir, filename), nil
 }
 
-// HotkeyConfig represents a hotkey configuration
-type HotkeyConfig struct {
-	Fullscreen string `json:"fullscreen"`
-	Region     string `json:"region"`
-	Window     string `json:"window"`
-}
+// maxSourceName bounds the source part of quick save filenames
+const maxSourceName = 40
 
-// GetHotkeyConfig returns the current hotkey configuration
-func (a *App) GetHotkeyConfig() HotkeyConfig {
-	return HotkeyConfig{
-		Fullscreen: a.config.Hotkeys.Fullscreen,
-		Region:     a.config.Hotkeys.Region,
-		Window:     a.config.Hotkeys.Window,
+// captureSourceName returns a short filename-safe name for where a capture
+// came from: the host of its page, or else the app without ".exe"
+func captureSourceName(rawURL, app string) string {
+	name := strings.TrimSuffix(strings.ToLower(app), ".exe")
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		name = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
 	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	if len(name) > maxSourceName {
+		name = name[:maxSourceName]
+	}
+	return strings.Trim(name, ".-")
 }
 
-// GetConfig returns the current application configuration
-func (a *App) GetConfig() *config.Config {
-	return a.config
+// imageExtension returns the file extension for an export format
+func imageExtension(format string) string {
+	switch imaging.NormalizeFormat(format) {
+	case imaging.FormatJPEG:
+		return ".jpg"
+	case imaging.FormatWebP:
+		return ".webp"
+	case imaging.FormatAVIF:
+		return ".avif"
+	default:
+		return ".png"
+	}
 }
 
-// SaveConfig saves the application configuration
-func (a *App) SaveConfig(cfg *config.Config) error {
-	// Update startup setting if changed
-	if cfg.Startup.LaunchOnStartup != a.config.Startup.LaunchOnStartup {
-		if err := config.SetStartupEnabled(cfg.Startup.LaunchOnStartup); err != nil {
-			return err
-		}
+// writeManifest writes a provenance sidecar for a saved screenshot if enabled
+func (a *App) writeManifest(filePath string, data []byte) {
+	if a.config == nil || !a.config.Export.WriteManifest {
+		return
 	}
 
-	// Update hotkeys if changed
-	hotkeysChanged := cfg.Hotkeys.Fullscreen != a.config.Hotkeys.Fullscreen ||
-		cfg.Hotkeys.Region != a.config.Hotkeys.Region ||
-		cfg.Hotkeys.Window != a.config.Hotkeys.Window
-
-	// Store new config
-	a.config = cfg
-
-	// Save to disk
-	if err := cfg.Save(); err != nil {
-		return err
+	c := a.captureInfo()
+	opts := provenance.Options{
+		CapturedAt: c.at,
+		MachineID:  machineID(),
+		AppVersion: Version,
 	}
-
-	// Re-register hotkeys if they changed
-	if hotkeysChanged {
-		a.hotkeyManager.UnregisterAll()
-		a.registerHotkeysFromConfig()
+	if !c.at.IsZero() {
+		opts.Monitor = monitorInfo(c.display)
+		opts.Frame = lastFrameClock()
 	}
 
-	return nil
+	if _, err := provenance.Write(filePath, data, opts); err != nil {
+		println("Warning: failed to write provenance manifest:", err.Error())
+	}
 }
 
-// SelectFolder opens a folder selection dialog
-func (a *App) SelectFolder() (string, error) {
-	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Select Save Folder",
-	})
+// lastFrameClock returns when the latest capture read the screen, for
+// manifests. Nil before the first capture
+func lastFrameClock() *provenance.FrameClock {
+	t := screenshot.LastFrameTime()
+	if t.Frequency == 0 {
+		return nil
+	}
+	return &provenance.FrameClock{QPC: t.Start, QPCEnd: t.End, QPCFrequency: t.Frequency}
 }
 
-// registerHotkeysFromConfig registers hotkeys based on current config
-func (a *App) registerHotkeysFromConfig() {
-	// Parse and register fullscreen hotkey
-	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Fullscreen); ok {
-		a.hotkeyManager.Register(hotkeys.HotkeyFullscreen, mods, key)
+// monitorInfo describes a display for manifests
+func monitorInfo(index int) *provenance.MonitorInfo {
+	bounds := screenshot.GetDisplayBounds(index)
+	return &provenance.MonitorInfo{
+		Index:  index,
+		X:      bounds.Min.X,
+		Y:      bounds.Min.Y,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
 	}
+}
 
-	// Parse and register region hotkey
-	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Region); ok {
-		a.hotkeyManager.Register(hotkeys.HotkeyRegion, mods, key)
+// machineID returns the Windows machine GUID (hashed before it is stored)
+func machineID() string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Cryptography`, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return ""
 	}
+	defer key.Close()
 
-	// Parse and register window hotkey
-	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Window); ok {
-		a.hotkeyManager.Register(hotkeys.HotkeyWindow, mods, key)
+	guid, _, err := key.GetStringValue("MachineGuid")
+	if err != nil {
+		return ""
 	}
+	return guid
 }
 
-// GetBackgroundImages returns the list of saved background images (base64 data URLs)
-func (a *App) GetBackgroundImages() []string {
-	if a.config == nil || a.config.BackgroundImages == nil {
+// VerifyScreenshot checks a saved screenshot against its provenance manifest
+func (a *App) VerifyScreenshot(imagePath string) (*provenance.VerifyResult, error) {
+	return provenance.Verify(imagePath)
+}
+
+// attachColorProfile embeds the ICC profile of the display the last capture
+// came from when enabled. Failures leave the data as it is with a warning
+func (a *App) attachColorProfile(data []byte, format string) []byte {
+	if a.config == nil || !a.config.Export.Color.EmbedProfile {
+		return data
+	}
+	device, err := winEnum.DisplayDevice(screenshot.GetDisplayBounds(a.captureInfo().display))
+	if err != nil {
+		println("Warning: no color profile attached:", err.Error())
+		return data
+	}
+	system, err := winEnum.DisplayColorProfile(device)
+	if err != nil {
+		system = ""
+	}
+	path := a.config.Export.Color.ProfileFor(device, system)
+	if path == "" {
+		return data
+	}
+	profile, err := os.ReadFile(path)
+	if err != nil {
+		println("Warning: no color profile attached:", err.Error())
+		return data
+	}
+	out, err := imaging.EmbedICCProfile(data, format, profile)
+	if err != nil {
+		println("Warning: no color profile attached:", err.Error())
+		return data
+	}
+	return out
+}
+
+// encodeImageData encodes an image as PNG, JPEG, WebP or AVIF depending on
+// format. Unknown formats fall back to PNG
+func encodeImageData(img image.Image, format string, quality int) ([]byte, error) {
+	if imaging.NormalizeFormat(format) == "" {
+		format = imaging.FormatPNG
+	}
+	return imaging.Encode(img, imaging.EncodeOptions{Format: format, Quality: quality})
+}
+
+// applyEncoders sets the PNG compression and external WebP and AVIF encoders
+// from the export settings
+func applyEncoders(cfg config.ExportConfig) {
+	imaging.DefaultCompression = cfg.CompressionLevel()
+	imaging.WebPEncoder = cfg.WebPEncoder
+	if imaging.WebPEncoder == "" {
+		imaging.WebPEncoder = "cwebp"
+	}
+	imaging.AVIFEncoder = cfg.AVIFEncoder
+	if imaging.AVIFEncoder == "" {
+		imaging.AVIFEncoder = "avifenc"
+	}
+}
+
+// toImagingAdjustments converts config adjustments to imaging adjustments
+func toImagingAdjustments(cfg config.AdjustmentConfig) imaging.Adjustments {
+	return imaging.Adjustments{
+		Brightness: cfg.Brightness,
+		Contrast:   cfg.Contrast,
+		Gamma:      cfg.Gamma,
+		Grayscale:  cfg.Grayscale,
+	}
+}
+
+// AdjustImage applies tonal adjustments to a base64 PNG for the editor. The
+// editor draws the adjusted image, so its exports already carry them and
+// Export.Adjustments only sets where its controls start
+func (a *App) AdjustImage(imageData string, adj config.AdjustmentConfig) (*screenshot.CaptureResult, error) {
+	img, err := decodeBase64Image(imageData)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCaptureResult(imaging.Adjust(img, toImagingAdjustments(adj)))
+}
+
+// GetDeviceFrames returns the available device frame names (built-in and custom templates)
+func (a *App) GetDeviceFrames() []string {
+	return imaging.ListDeviceFrames(deviceFramesDir())
+}
+
+// ApplyDeviceFrame wraps a base64 PNG in a browser/phone mockup or custom 9-slice template
+func (a *App) ApplyDeviceFrame(imageData, frameName string) (*screenshot.CaptureResult, error) {
+	frame := imaging.BuiltinFrame(frameName)
+	if frame == nil {
+		var err error
+		frame, err = imaging.LoadDeviceFrame(deviceFramesDir(), frameName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	img, err := decodeBase64Image(imageData)
+	if err != nil {
+		return nil, err
+	}
+
+	framed, err := imaging.ApplyDeviceFrame(img, frame)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCaptureResult(framed)
+}
+
+// deviceFramesDir returns the folder holding custom device frame templates
+func deviceFramesDir() string {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(configPath), "frames")
+}
+
+// decodeBase64Image decodes base64 encoded image data (PNG, JPEG, GIF)
+func decodeBase64Image(imageData string) (image.Image, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image data: %w", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// encodeCaptureResult encodes an image as base64 PNG CaptureResult
+func encodeCaptureResult(img image.Image) (*screenshot.CaptureResult, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return &screenshot.CaptureResult{
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		Data:   base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// HotkeyConfig represents a hotkey configuration
+type HotkeyConfig struct {
+	Fullscreen string `json:"fullscreen"`
+	Region     string `json:"region"`
+	Window     string `json:"window"`
+}
+
+// GetHotkeyConfig returns the current hotkey configuration
+func (a *App) GetHotkeyConfig() HotkeyConfig {
+	return HotkeyConfig{
+		Fullscreen: a.config.Hotkeys.Fullscreen,
+		Region:     a.config.Hotkeys.Region,
+		Window:     a.config.Hotkeys.Window,
+	}
+}
+
+// GetConfig returns the current application configuration
+func (a *App) GetConfig() *config.Config {
+	return a.config
+}
+
+// SaveConfig saves the application configuration
+func (a *App) SaveConfig(cfg *config.Config) error {
+	// Update startup setting if changed
+	if cfg.Startup.LaunchOnStartup != a.config.Startup.LaunchOnStartup {
+		if err := config.SetStartupEnabled(cfg.Startup.LaunchOnStartup); err != nil {
+			return err
+		}
+	}
+
+	// Update hotkeys if changed
+	hotkeysChanged := cfg.Hotkeys.Fullscreen != a.config.Hotkeys.Fullscreen ||
+		cfg.Hotkeys.Region != a.config.Hotkeys.Region ||
+		cfg.Hotkeys.Window != a.config.Hotkeys.Window ||
+		cfg.Hotkeys.Text != a.config.Hotkeys.Text ||
+		cfg.Hotkeys.Color != a.config.Hotkeys.Color ||
+		cfg.Hotkeys.Repeat != a.config.Hotkeys.Repeat ||
+		!slices.Equal(cfg.Scripts, a.config.Scripts)
+
+	// Remembered regions and anchors change behind the settings window's
+	// back, so keep ours rather than the copy it loaded
+	cfg.Capture.LastRegions = a.config.Capture.LastRegions
+	cfg.Capture.LastRegionMonitor = a.config.Capture.LastRegionMonitor
+	cfg.Capture.Anchors = a.config.Capture.Anchors
+
+	historyChanged := cfg.History != a.config.History
+
+	// Store new config
+	a.config = cfg
+	applyEncoders(cfg.Export)
+	if historyChanged {
+		a.applyHistory()
+	}
+	screenshot.SetIncludeCursor(cfg.Capture.IncludeCursor)
+
+	// Save to disk
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	// Re-register hotkeys if they changed
+	if hotkeysChanged {
+		a.hotkeyManager.UnregisterAll()
+		a.registerHotkeysFromConfig()
+	}
+
+	return nil
+}
+
+// SelectFolder opens a folder selection dialog
+func (a *App) SelectFolder() (string, error) {
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Save Folder",
+	})
+}
+
+// registerHotkeysFromConfig registers hotkeys based on current config
+func (a *App) registerHotkeysFromConfig() {
+	// Parse and register fullscreen hotkey
+	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Fullscreen); ok {
+		a.hotkeyManager.Register(hotkeys.HotkeyFullscreen, mods, key)
+	}
+
+	// Parse and register region hotkey
+	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Region); ok {
+		a.hotkeyManager.Register(hotkeys.HotkeyRegion, mods, key)
+	}
+
+	// Parse and register window hotkey
+	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Window); ok {
+		a.hotkeyManager.Register(hotkeys.HotkeyWindow, mods, key)
+	}
+
+	// Parse and register copy text hotkey
+	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Text); ok {
+		a.hotkeyManager.Register(hotkeys.HotkeyText, mods, key)
+	}
+
+	// Parse and register color picker hotkey
+	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Color); ok {
+		a.hotkeyManager.Register(hotkeys.HotkeyColor, mods, key)
+	}
+
+	// Parse and register repeat last region hotkey
+	if mods, key, ok := hotkeys.ParseHotkeyString(a.config.Hotkeys.Repeat); ok {
+		a.hotkeyManager.Register(hotkeys.HotkeyRepeat, mods, key)
+	}
+
+	// Register script hotkeys
+	for i, s := range a.config.Scripts {
+		if mods, key, ok := hotkeys.ParseHotkeyString(s.Hotkey); ok {
+			a.hotkeyManager.Register(hotkeys.HotkeyScriptBase+i, mods, key)
+		}
+	}
+}
+
+// GetBackgroundImages returns the list of saved background images (base64 data URLs)
+func (a *App) GetBackgroundImages() []string {
+	if a.config == nil || a.config.BackgroundImages == nil {
 		return []string{}
 	}
 	return a.config.BackgroundImages
@@ -740,6 +1500,9 @@ func (a *App) OpenImage() (*screenshot.CaptureResult, error) {
 		return nil, err
 	}
 
+	// A file from disk has no capture context to record
+	a.setCaptureInfo(captureInfo{})
+
 	// Return as base64 encoded PNG
 	return &screenshot.CaptureResult{
 		Width:  bounds.Dx(),
@@ -750,7 +1513,23 @@ func (a *App) OpenImage() (*screenshot.CaptureResult, error) {
 
 // GetClipboardImage reads an image from the Windows clipboard
 func (a *App) GetClipboardImage() (*screenshot.CaptureResult, error) {
-	return screenshot.GetClipboardImage()
+	result, err := screenshot.GetClipboardImage()
+	if err == nil {
+		a.recordPaste(result.Metadata)
+	}
+	return result, err
+}
+
+// recordPaste replaces the capture context with what the clipboard told
+// about a pasted image, so saving it records where it was copied from rather
+// than the previous capture's windows
+func (a *App) recordPaste(meta *screenshot.ClipboardMetadata) {
+	var c captureInfo
+	if meta != nil {
+		c.url = meta.SourceURL
+		c.app = meta.SourceApp
+	}
+	a.setCaptureInfo(c)
 }
 
 // CheckForUpdate checks GitHub for a newer version
@@ -788,266 +1567,3253 @@ func (a *App) SaveEditorConfig(editor *config.EditorConfig) error {
 	return a.config.Save()
 }
 
-// ==================== Cloud Upload: R2 ====================
-
-// SaveR2Config saves R2 configuration (non-sensitive data)
-func (a *App) SaveR2Config(accountID, bucket, publicURL, directory string) error {
-	a.config.Cloud.R2.AccountID = accountID
-	a.config.Cloud.R2.Bucket = bucket
-	a.config.Cloud.R2.PublicURL = publicURL
-	a.config.Cloud.R2.Directory = directory
+// ==================== Capture History ====================
 
-	// Update uploader config
-	a.r2Uploader = upload.NewR2Uploader(a.credManager, &upload.R2Config{
-		AccountID: accountID,
-		Bucket:    bucket,
-		PublicURL: publicURL,
-		Directory: directory,
-	})
+// errHistoryOff is returned by history bindings when history is turned off
+var errHistoryOff = errors.New("capture history is turned off")
 
-	return a.config.Save()
+// applyHistory opens the capture history store, or drops it when history is
+// turned off
+func (a *App) applyHistory() {
+	a.historyStore = nil
+	if a.config.History.Disabled {
+		return
+	}
+	dir, err := config.DataDir()
+	if err != nil {
+		println("Warning: failed to open capture history:", err.Error())
+		return
+	}
+	store, err := history.Open(filepath.Join(dir, "history"), a.config.History.MaxEntries)
+	if err != nil {
+		println("Warning: failed to open capture history:", err.Error())
+		return
+	}
+	a.historyStore = store
 }
 
-// SaveR2Credentials saves R2 secrets to Windows Credential Manager
-func (a *App) SaveR2Credentials(accessKeyID, secretAccessKey string) error {
-	if err := a.credManager.Set(upload.CredR2AccessKeyID, accessKeyID); err != nil {
-		return err
+// recordHistory adds a delivered capture to the capture history, with the
+// foreground window of the capture as its app and title
+func (a *App) recordHistory(img image.Image, e history.Entry, c captureInfo) {
+	store := a.historyStore
+	if store == nil {
+		return
 	}
-	return a.credManager.Set(upload.CredR2SecretAccessKey, secretAccessKey)
+	if w, ok := c.foreground(); ok {
+		e.App, e.Title = w.App, w.Title
+	}
+	e, err := store.Add(img, e)
+	if err != nil {
+		println("Warning: failed to record capture history:", err.Error())
+		return
+	}
+	runtime.EventsEmit(a.ctx, "history:added", e)
 }
 
-// GetR2Config returns R2 configuration
-func (a *App) GetR2Config() config.R2Config {
-	return a.config.Cloud.R2
+// recordHistoryData is recordHistory for encoded image data from the editor
+func (a *App) recordHistoryData(data []byte, e history.Entry) {
+	if a.historyStore == nil {
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		println("Warning: failed to record capture history:", err.Error())
+		return
+	}
+	a.recordHistory(img, e, a.captureInfo())
 }
 
-// IsR2Configured checks if R2 is fully configured
-func (a *App) IsR2Configured() bool {
-	return a.r2Uploader.IsConfigured()
+// recordUpload records a successful upload from the editor
+func (a *App) recordUpload(data []byte, result *upload.UploadResult, err error) {
+	if err == nil && result != nil && result.Success {
+		go a.recordHistoryData(data, history.Entry{URL: result.PublicURL, Source: "editor"})
+	}
 }
 
-// TestR2Connection tests R2 connectivity
-func (a *App) TestR2Connection() error {
-	return a.r2Uploader.TestConnection()
+// ListHistory returns the captures in the history, newest first, matching
+// every word of query in their file name, link, source, app or window title.
+// An empty query lists everything
+func (a *App) ListHistory(query string) []history.Entry {
+	if a.historyStore == nil {
+		return []history.Entry{}
+	}
+	entries := a.historyStore.Search(query)
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	return entries
 }
 
-// UploadToR2 uploads image to Cloudflare R2
-func (a *App) UploadToR2(imageData, filename string) (*upload.UploadResult, error) {
-	data, err := base64.StdEncoding.DecodeString(imageData)
+// GetHistoryThumbnail returns the base64 JPEG thumbnail of a history entry
+func (a *App) GetHistoryThumbnail(id string) (string, error) {
+	if a.historyStore == nil {
+		return "", errHistoryOff
+	}
+	data, err := a.historyStore.Thumbnail(id)
 	if err != nil {
-		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+		return "", err
 	}
-	return a.r2Uploader.Upload(context.Background(), data, filename)
+	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-// ClearR2Credentials removes R2 credentials from Windows Credential Manager
-func (a *App) ClearR2Credentials() error {
-	a.credManager.Delete(upload.CredR2AccessKeyID)
-	a.credManager.Delete(upload.CredR2SecretAccessKey)
-	return nil
+// CopyHistoryEntry puts a capture from the history on the clipboard again
+func (a *App) CopyHistoryEntry(id string) error {
+	if a.historyStore == nil {
+		return errHistoryOff
+	}
+	img, err := a.historyStore.Image(id)
+	if err != nil {
+		return err
+	}
+	return screenshot.SetClipboardImage(imaging.ToRGBA(img))
 }
 
-// ==================== Cloud Upload: Google Drive ====================
+// UploadHistoryEntry uploads a capture from the history again, to provider
+// or the default one if empty, and returns the new link, which is also
+// copied to the clipboard and kept in the entry
+func (a *App) UploadHistoryEntry(id, provider string) (string, error) {
+	if a.historyStore == nil {
+		return "", errHistoryOff
+	}
+	img, err := a.historyStore.Image(id)
+	if err != nil {
+		return "", err
+	}
+	url, err := a.pipelineToUpload(img, a.config.Export.DefaultFormat, a.config.Export.JpegQuality, provider)
+	if err != nil {
+		return "", err
+	}
+	if err := a.historyStore.SetURL(id, url); err != nil {
+		println("Warning: failed to record upload link:", err.Error())
+	}
+	return url, nil
+}
 
-// SaveGDriveCredentials saves Google OAuth client credentials to Credential Manager
-func (a *App) SaveGDriveCredentials(clientID, clientSecret string) error {
-	if err := a.credManager.Set(upload.CredGDriveClientID, clientID); err != nil {
-		return err
+// DeleteHistoryEntry removes a capture from the history. A saved file is
+// kept; use DeleteScreenshot to remove it too
+func (a *App) DeleteHistoryEntry(id string) error {
+	if a.historyStore == nil {
+		return errHistoryOff
 	}
-	return a.credManager.Set(upload.CredGDriveClientSecret, clientSecret)
+	return a.historyStore.Delete(id)
 }
 
-// SaveGDriveConfig saves Google Drive configuration (non-sensitive)
-func (a *App) SaveGDriveConfig(folderID string) error {
-	a.config.Cloud.GDrive.FolderID = folderID
+// ==================== Hotkey Pipelines ====================
 
-	// Update uploader config
-	a.gdriveUploader = upload.NewGDriveUploader(a.credManager, &upload.GDriveConfig{
-		FolderID: folderID,
-	})
+// PipelineResult is emitted after a hotkey pipeline delivers a capture
+type PipelineResult struct {
+	Action      string `json:"action"`
+	Destination string `json:"destination"`
+	FilePath    string `json:"filePath,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Error       string `json:"error,omitempty"`
 
-	return a.config.Save()
+	// Manifest describes a delivered capture for scripts and bots
+	Manifest *provenance.Capture `json:"manifest,omitempty"`
 }
 
-// GetGDriveConfig returns Google Drive configuration
-func (a *App) GetGDriveConfig() config.GDriveConfig {
+// runHotkeyPipeline captures for a hotkey action and sends the image straight
+// to the action's configured destination instead of opening the editor
+func (a *App) runHotkeyPipeline(action string, pipeline config.HotkeyActionConfig) {
+	result := PipelineResult{Action: action, Destination: pipeline.Destination}
+
+	img, err := a.captureForAction(action)
+	if err != nil {
+		a.finishPipeline(result, err)
+		return
+	}
+	if img == nil {
+		return // Selection cancelled
+	}
+	a.deliverPipeline(img, pipeline, result)
+}
+
+// deliverPipeline sends a captured image to the pipeline destination and
+// reports the outcome
+func (a *App) deliverPipeline(img image.Image, pipeline config.HotkeyActionConfig, result PipelineResult) {
+	var err error
+	// Taken before the editor and upload, which give other pipelines time to
+	// capture
+	capture := a.captureInfo()
+
+	if pipeline.Annotate {
+		edited, err := annotate.Edit(imaging.ToRGBA(img))
+		if err != nil {
+			a.finishPipeline(result, err)
+			return
+		}
+		if edited == nil {
+			return // Editor cancelled
+		}
+		img = edited
+	}
+
+	// Export-time adjustments apply to every destination
+	if adj := toImagingAdjustments(a.config.Export.Adjustments); !adj.IsIdentity() {
+		img = imaging.Adjust(img, adj)
+	}
+
+	format := pipeline.Format
+	if format == "" {
+		format = a.config.Export.DefaultFormat
+	}
+	quality := pipeline.Quality
+	if quality == 0 {
+		quality = a.config.Export.JpegQuality
+	}
+
+	switch pipeline.Destination {
+	case config.DestinationClipboard:
+		// PNG and DIBV5 keep transparency for apps that understand them
+		err = screenshot.SetClipboardImage(imaging.ToRGBA(img))
+	case config.DestinationFile:
+		result.FilePath, err = a.pipelineToFile(img, format, quality)
+	case config.DestinationUpload:
+		result.URL, err = a.pipelineToUpload(img, format, quality, pipeline.Provider)
+	case config.DestinationObsidian:
+		result.FilePath, err = a.pipelineToObsidian(img, format, quality)
+	case config.DestinationOneNote:
+		result.URL, err = a.pipelineToOneNote(img, format, quality)
+	default:
+		err = fmt.Errorf("unknown hotkey destination: %s", pipeline.Destination)
+	}
+	if errors.Is(err, errUploadDeclined) {
+		return // Cancelled at review
+	}
+	if err == nil {
+		result.Manifest = a.pipelineManifest(img, format, result, capture)
+		entry := history.Entry{URL: result.URL, Source: result.Action}
+		if pipeline.Destination == config.DestinationFile {
+			entry.Path = result.FilePath
+		}
+		go a.recordHistory(img, entry, capture)
+	}
+
+	a.finishPipeline(result, err)
+}
+
+// pipelineManifest describes a capture a pipeline delivered. Quick saved
+// files are hashed as written, other destinations are not hashed
+func (a *App) pipelineManifest(img image.Image, format string, result PipelineResult, c captureInfo) *provenance.Capture {
+	opts := provenance.CaptureOptions{
+		Path:    result.FilePath,
+		URL:     result.URL,
+		Size:    img.Bounds().Size(),
+		Started: c.at,
+	}
+	if result.Destination != config.DestinationClipboard {
+		opts.Format = imaging.NormalizeFormat(format)
+	}
+	if !c.at.IsZero() {
+		opts.Monitor = monitorInfo(c.display)
+		opts.Frame = lastFrameClock()
+	}
+	if w, ok := c.foreground(); ok && result.Action == "window" {
+		opts.Window = &provenance.WindowInfo{Title: w.Title, App: w.App, X: w.X, Y: w.Y, Width: w.Width, Height: w.Height}
+	}
+
+	var data []byte
+	if result.Destination == config.DestinationFile {
+		data, _ = os.ReadFile(result.FilePath)
+	}
+	return provenance.NewCapture(data, opts)
+}
+
+// copyRegionText lets the user select a region and copies the text
+// recognized in it, for the copy text hotkey
+func (a *App) copyRegionText() {
+	img, err := a.captureForAction("region")
+	if err == nil && img == nil {
+		return // Selection cancelled
+	}
+	var text string
+	if err == nil {
+		text, err = ocr.Recognize(img)
+	}
+	if err == nil && text == "" {
+		a.notify("No text found in the selection")
+		return
+	}
+	if err == nil {
+		err = screenshot.SetClipboardText(text)
+	}
+	if err != nil {
+		recordError("copy text", err)
+		a.notify("Copy text failed: " + err.Error())
+		return
+	}
+	a.notify("Text copied to clipboard")
+	a.saveRecognized(img, text)
+}
+
+// saveRecognized keeps a region whose text was copied in the quick save
+// folder, with the text in the history index, so library searches find it
+// by what it said. Nothing is kept while history is turned off
+func (a *App) saveRecognized(img image.Image, text string) {
+	if a.config == nil || a.config.History.Disabled {
+		return
+	}
+	path, err := a.pipelineToFile(img, "png", 0)
+	if err == nil {
+		err = library.RecordText(path, text)
+	}
+	if err != nil {
+		println("Warning: failed to index recognized text:", err.Error())
+	}
+}
+
+// copyScreenColor shows the color picker over the screen and copies the
+// clicked pixel's color in the configured format, for the color hotkey
+func (a *App) copyScreenColor() {
+	defer a.hideForCapture()()
+
+	screenX, screenY, virtualWidth, virtualHeight := screenshot.GetVirtualScreenBounds()
+	img, err := screenshot.CaptureVirtualScreenRaw()
+	if err != nil {
+		recordError("color picker", err)
+		a.notify("Color picker failed: " + err.Error())
+		return
+	}
+	scaleRatio := max(float64(img.Bounds().Dx())/float64(virtualWidth), 1.0)
+	bounds := image.Rect(screenX, screenY, screenX+virtualWidth, screenY+virtualHeight)
+
+	picked := a.overlayManager.RunColorPicker(img, bounds, a.prepareOverlay(), scaleRatio)
+	if picked.Cancelled {
+		return
+	}
+	text := picked.Format(a.config.Overlay.ColorFormat)
+	if err := screenshot.SetClipboardText(text); err != nil {
+		recordError("color picker", err)
+		a.notify("Copy color failed: " + err.Error())
+		return
+	}
+	a.notify("Copied " + text)
+}
+
+// maxCaptureDelay caps hotkey countdowns in seconds
+const maxCaptureDelay = 60
+
+// runDelayedHotkey counts down before dispatching a hotkey action. Presses
+// while a countdown is running are ignored.
+func (a *App) runDelayedHotkey(action string, pipeline config.HotkeyActionConfig) {
+	if !a.countingDown.CompareAndSwap(false, true) {
+		return
+	}
+	defer a.countingDown.Store(false)
+
+	if a.countdown(min(pipeline.Delay, maxCaptureDelay)) {
+		a.dispatchHotkey(action, pipeline)
+	}
+}
+
+// CaptureCountdown hides the main window and counts down before a delayed
+// capture from the toolbar, so menus and tooltips that close when WinShot is
+// clicked can be opened in the meantime. Returns false, with the window shown
+// again, if the countdown was stopped or another one is already running
+func (a *App) CaptureCountdown(seconds int) bool {
+	if !a.countingDown.CompareAndSwap(false, true) {
+		return false
+	}
+	defer a.countingDown.Store(false)
+
+	if !a.isWindowHidden {
+		runtime.WindowHide(a.ctx)
+		a.isWindowHidden = true
+	}
+	if a.countdown(min(seconds, maxCaptureDelay)) {
+		return true
+	}
+	a.ShowWindow()
+	return false
+}
+
+// countdown shows the status widget counting down before a delayed capture.
+// Pause freezes the count and Stop cancels it. Returns false if cancelled.
+func (a *App) countdown(seconds int) bool {
+	// Drop clicks left over from an earlier countdown
+	for len(a.statusActions) > 0 {
+		<-a.statusActions
+	}
+
+	paused := false
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for seconds > 0 {
+		info := overlay.StatusInfo{Label: fmt.Sprintf("Capture in %d", seconds), Paused: paused}
+		if err := a.statusWidget.Show(info); err != nil {
+			// Capture without the widget rather than not at all
+			println("Warning: failed to show countdown:", err.Error())
+		}
+
+		select {
+		case <-ticker.C:
+			if !paused {
+				seconds--
+			}
+		case action := <-a.statusActions:
+			switch action {
+			case overlay.StatusPause:
+				paused = !paused
+			case overlay.StatusStop:
+				a.statusWidget.Hide()
+				return false
+			}
+		}
+	}
+
+	a.statusWidget.Hide()
+	// Give the widget time to disappear on systems that ignore capture exclusion
+	time.Sleep(100 * time.Millisecond)
+	return true
+}
+
+// captureTarget captures "display:N" or a hotkey action target without
+// involving the frontend. Returns nil image if the user cancelled a region
+// selection.
+func (a *App) captureTarget(target string) (image.Image, error) {
+	n, ok := strings.CutPrefix(target, "display:")
+	if !ok {
+		return a.captureForAction(target)
+	}
+	index, err := strconv.Atoi(n)
+	if err != nil || index < 0 || index >= screenshot.GetDisplayCount() {
+		return nil, fmt.Errorf("no display %s", n)
+	}
+	defer a.hideForCapture()()
+	a.recordCapture(index)
+	img, err := screenshot.CaptureDisplayRaw(index)
+	if err != nil {
+		return nil, err
+	}
+	return screenshot.ApplyFilters(img)
+}
+
+// captureForAction captures the screen for a hotkey action without involving the frontend
+// Returns nil image if the user cancelled region selection
+func (a *App) captureForAction(action string) (image.Image, error) {
+	defer a.hideForCapture()()
+
+	var img *image.RGBA
+	var err error
+	switch action {
+	case "fullscreen":
+		a.recordCapture(screenshot.GetMonitorAtCursor())
+		img, err = screenshot.CaptureFullscreenRaw()
+	case "region":
+		return a.selectRegion()
+	case "repeat":
+		img, err = a.captureLastRegion()
+	case "window":
+		hwnd := winEnum.GetForegroundWindow()
+		if hwnd == 0 {
+			return nil, fmt.Errorf("no foreground window")
+		}
+		if info, _ := winEnum.GetWindowInfo(hwnd); info != nil {
+			a.recordCapture(screenshot.GetMonitorAtPoint(info.X+info.Width/2, info.Y+info.Height/2))
+		}
+		img, err = screenshot.CaptureWindowRaw(hwnd)
+	default:
+		return nil, fmt.Errorf("unknown hotkey action: %s", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return screenshot.ApplyFilters(img)
+}
+
+// hideForCapture hides the main window to keep it out of a capture and returns
+// a function that shows it again. Does nothing if the window is already hidden.
+func (a *App) hideForCapture() func() {
+	if a.isWindowHidden {
+		return func() {}
+	}
+	a.isCapturing = true
+	runtime.WindowHide(a.ctx)
+	a.isWindowHidden = true
+	// Wait for window to fully hide (250ms for DWM compositor)
+	time.Sleep(250 * time.Millisecond)
+	return func() {
+		runtime.WindowShow(a.ctx)
+		a.isWindowHidden = false
+		a.isCapturing = false
+	}
+}
+
+// showOverlay shows the native selection overlay with the current overlay settings
+func (a *App) showOverlay(img *image.RGBA, bounds image.Rectangle, scaleRatio float64) <-chan overlay.Result {
+	if a.config == nil {
+		return a.overlayManager.Show(img, bounds, scaleRatio)
+	}
+
+	// Geometry copied with C resolves as a cancelled selection
+	results := make(chan overlay.Result, 1)
+	go func(ch <-chan overlay.Result) {
+		res := <-ch
+		if res.Copy != "" {
+			if err := screenshot.SetClipboardText(res.Copy); err != nil {
+				println("Warning: failed to copy selection geometry:", err.Error())
+			}
+		}
+		results <- res
+	}(a.overlayManager.ShowOnMonitors(img, bounds, a.prepareOverlay(), scaleRatio))
+	return results
+}
+
+// prepareOverlay applies the overlay settings and window list for the next
+// show and returns the display layout to show it on
+func (a *App) prepareOverlay() []image.Rectangle {
+	a.overlayManager.SetOptions(overlay.Options{
+		PerMonitor:  a.config.Overlay.PerMonitor,
+		DimInactive: a.config.Overlay.DimInactive,
+		InactiveDim: a.config.Overlay.DimAlpha(),
+		Suggest:     !a.config.Overlay.NoSuggestions,
+		ClientPos:   a.config.Overlay.ClientCoords,
+		Loupe:       a.config.Overlay.Loupe,
+		Adjust:      a.config.Overlay.Adjust,
+		Snap:        a.config.Overlay.Snap,
+		Cursor:      a.config.Capture.IncludeCursor,
+	})
+
+	var monitors []image.Rectangle
+	for i := 0; i < screenshot.GetDisplayCount(); i++ {
+		monitors = append(monitors, screenshot.GetDisplayBounds(i))
+	}
+
+	// Candidate windows for the overlay's window mode
+	var windows []overlay.Window
+	if wins, err := winEnum.EnumWindows(); err == nil {
+		for _, w := range wins {
+			windows = append(windows, overlay.Window{
+				Handle: w.Handle,
+				Bounds: image.Rect(w.X, w.Y, w.X+w.Width, w.Y+w.Height),
+			})
+		}
+	}
+	a.overlayManager.SetWindows(windows)
+	a.overlayManager.SetElements(nil)
+	if a.config.Overlay.Snap {
+		go a.detectElements(winEnum.GetForegroundWindow())
+	}
+	return monitors
+}
+
+// maxSnapElements bounds how many UI elements of a window are read for
+// snapping, which keeps huge trees such as long web pages quick
+const maxSnapElements = 2000
+
+// detectElements reads the UI elements of hwnd through UI Automation for the
+// overlay to snap selections to. It runs while the overlay is already
+// showing, and is skipped while an earlier lookup is still going
+func (a *App) detectElements(hwnd uintptr) {
+	if hwnd == 0 || !a.elementsBusy.CompareAndSwap(false, true) {
+		return
+	}
+	defer a.elementsBusy.Store(false)
+
+	rects, err := winEnum.ElementBounds(hwnd, maxSnapElements)
+	if err != nil {
+		println("Warning: failed to detect UI elements:", err.Error())
+		return
+	}
+	a.overlayManager.SetElements(rects)
+}
+
+// selectRegion shows the native overlay and blocks until a region is selected
+// Returns nil image if the selection was cancelled
+func (a *App) selectRegion() (image.Image, error) {
+	screenX, screenY, virtualWidth, virtualHeight := screenshot.GetVirtualScreenBounds()
+
+	rgbaImg, err := screenshot.CaptureVirtualScreenRaw()
+	if err != nil {
+		return nil, err
+	}
+	cursor := screenshot.SnapshotCursor()
+
+	scaleRatio := float64(rgbaImg.Bounds().Dx()) / float64(virtualWidth)
+	if scaleRatio < 1.0 {
+		scaleRatio = 1.0
+	}
+
+	bounds := image.Rect(screenX, screenY, screenX+virtualWidth, screenY+virtualHeight)
+	selResult := <-a.showOverlay(rgbaImg, bounds, scaleRatio)
+	if selResult.Cancelled {
+		return nil, nil
+	}
+
+	a.recordCapture(screenshot.GetMonitorAtPoint(
+		screenX+selResult.X+selResult.Width/2,
+		screenY+selResult.Y+selResult.Height/2,
+	))
+	a.rememberRegion(selResult, scaleRatio, image.Pt(screenX, screenY))
+	img, err := screenshot.ApplyFilters(cropSelection(rgbaImg, selResult, scaleRatio, cursor, image.Pt(screenX, screenY)))
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// errNoLastRegion is returned by the repeat action before any region was selected
+var errNoLastRegion = errors.New("no region has been selected yet")
+
+// rememberRegion saves a confirmed selection for the repeat action, on the
+// monitor recordCapture picked for it. Free-form selections are remembered
+// as their bounding rectangle
+func (a *App) rememberRegion(sel overlay.Result, scaleRatio float64, origin image.Point) {
+	if a.config == nil {
+		return
+	}
+	a.config.Capture.SetLastRegion(a.captureInfo().display, config.RegionConfig{
+		X:      origin.X + int(float64(sel.X)*scaleRatio),
+		Y:      origin.Y + int(float64(sel.Y)*scaleRatio),
+		Width:  int(float64(sel.Width) * scaleRatio),
+		Height: int(float64(sel.Height) * scaleRatio),
+	})
+	if err := a.config.Save(); err != nil {
+		println("Warning: failed to save last region:", err.Error())
+	}
+}
+
+// captureLastRegion captures the region last selected on the monitor under
+// the cursor, or the latest selection on any monitor if there is none there.
+// The screen is read live, without the overlay
+func (a *App) captureLastRegion() (*image.RGBA, error) {
+	region, ok := a.config.Capture.LastRegion(screenshot.GetMonitorAtCursor())
+	if !ok {
+		return nil, errNoLastRegion
+	}
+	r := image.Rect(region.X, region.Y, region.X+region.Width, region.Y+region.Height)
+	a.recordCapture(screenshot.GetMonitorAtPoint(r.Min.X+r.Dx()/2, r.Min.Y+r.Dy()/2))
+	return screenshot.CaptureRectRaw(r, screenshot.CaptureOptions{})
+}
+
+// repeatRegionToEditor captures the last region again and opens it in the
+// editor the same way a region selection does
+func (a *App) repeatRegionToEditor() {
+	img, err := a.captureForAction("repeat")
+	var buf bytes.Buffer
+	if err == nil {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		recordError("repeat region", err)
+		a.notify("Capture failed: " + err.Error())
+		return
+	}
+	runtime.EventsEmit(a.ctx, "region:selected", map[string]interface{}{
+		"width":      img.Bounds().Dx(),
+		"height":     img.Bounds().Dy(),
+		"screenshot": base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+}
+
+// pipelineToFile writes the image to the quick save folder and returns its path
+func (a *App) pipelineToFile(img image.Image, format string, quality int) (string, error) {
+	filePath, err := a.quickSavePath(format)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := encodeImageData(img, format, quality)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	data = a.attachColorProfile(data, format)
+	if err := a.writeHistoryFile(filePath, data); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	a.writeManifest(filePath, data)
+
+	return filePath, nil
+}
+
+// uploaderFor returns the configured uploader for a provider, R2 when it is
+// empty
+func (a *App) uploaderFor(provider string) (upload.Uploader, error) {
+	var uploader upload.Uploader
+	switch provider {
+	case "", string(upload.ProviderR2):
+		uploader = a.r2Uploader
+	case string(upload.ProviderS3):
+		uploader = a.s3Uploader
+	case string(upload.ProviderGDrive):
+		uploader = a.gdriveUploader
+	case string(upload.ProviderConfluence):
+		// Attachments are shown inline on the page, so they stay unencrypted
+		if !a.confluenceUploader.IsConfigured() {
+			return nil, fmt.Errorf("upload provider %q is not configured", provider)
+		}
+		return a.confluenceUploader, nil
+	case string(upload.ProviderEphemeral):
+		uploader = a.ephemeralUploader
+	default:
+		name, ok := strings.CutPrefix(provider, pluginProviderPrefix)
+		p := a.uploaderPlugin(name)
+		if !ok || p == nil {
+			return nil, fmt.Errorf("unknown upload provider %q", provider)
+		}
+		uploader = pluginUploader{plugin: p}
+	}
+	if !uploader.IsConfigured() {
+		return nil, fmt.Errorf("upload provider %q is not configured", provider)
+	}
+	return a.encrypted(uploader)
+}
+
+// errUploadDeclined is returned when an automatic upload is cancelled at
+// review, which is not a failure
+var errUploadDeclined = errors.New("upload cancelled at review")
+
+// reviewUpload shows img for approval before it is uploaded to provider.
+// Redact opens the annotation window with the blur tool and then shows the
+// result again. Returns nil if the upload was cancelled
+func reviewUpload(img image.Image, provider string) (image.Image, error) {
+	if provider == "" {
+		provider = string(upload.ProviderR2)
+	}
+	title := fmt.Sprintf("WinShot - Upload to %s?  (Enter approve, R redact, Esc cancel)", provider)
+	rgba := imaging.ToRGBA(img)
+	for {
+		decision, err := annotate.Review(rgba, title)
+		if err != nil {
+			return nil, err
+		}
+		switch decision {
+		case annotate.DecisionApprove:
+			return rgba, nil
+		case annotate.DecisionRedact:
+			edited, err := annotate.EditWith(rgba, annotate.ToolBlur)
+			if err != nil {
+				return nil, err
+			}
+			if edited != nil {
+				rgba = edited
+			}
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// pipelineToUpload uploads the image and copies the public URL to the clipboard
+func (a *App) pipelineToUpload(img image.Image, format string, quality int, provider string) (string, error) {
+	uploader, err := a.uploaderFor(provider)
+	if err != nil {
+		return "", err
+	}
+
+	if a.config.Cloud.NeedsReview(provider) {
+		reviewed, err := reviewUpload(img, provider)
+		if err != nil {
+			return "", err
+		}
+		if reviewed == nil {
+			return "", errUploadDeclined
+		}
+		img = reviewed
+	}
+
+	data, err := encodeImageData(img, format, quality)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	filename := "winshot_" + time.Now().Format("2006-01-02_15-04-05") + imageExtension(format)
+	a.waitForPower("upload " + filename)
+	result, err := a.uploadQueued(provider, uploader, data, filename)
+	if err != nil {
+		return "", err
+	}
+
+	if err := screenshot.SetClipboardText(result.PublicURL); err != nil {
+		println("Warning: failed to copy upload URL:", err.Error())
+	}
+	return result.PublicURL, nil
+}
+
+// finishPipeline reports a pipeline outcome to the frontend and tray
+func (a *App) finishPipeline(result PipelineResult, err error) {
+	var message string
+	if errors.Is(err, screenshot.ErrSecureDesktop) {
+		result.Error = err.Error()
+		message = "Capture blocked while a UAC prompt or the lock screen is showing"
+		runtime.EventsEmit(a.ctx, "pipeline:error", result)
+	} else if err != nil {
+		result.Error = err.Error()
+		message = "Capture failed: " + result.Error
+		recordError("pipeline "+result.Action, err)
+		runtime.EventsEmit(a.ctx, "pipeline:error", result)
+	} else {
+		switch result.Destination {
+		case config.DestinationClipboard:
+			message = "Screenshot copied to clipboard"
+		case config.DestinationFile:
+			message = "Saved to " + result.FilePath
+		case config.DestinationUpload:
+			message = "Uploaded, link copied to clipboard"
+		case config.DestinationObsidian:
+			message = "Added to Obsidian: " + filepath.Base(result.FilePath)
+		case config.DestinationOneNote:
+			message = "Added to OneNote"
+		}
+		runtime.EventsEmit(a.ctx, "pipeline:done", result)
+	}
+
+	a.notify(message)
+}
+
+// ==================== Cloud Upload: R2 ====================
+
+// SaveR2Config saves R2 configuration (non-sensitive data)
+func (a *App) SaveR2Config(accountID, bucket, publicURL, directory string) error {
+	a.config.Cloud.R2.AccountID = accountID
+	a.config.Cloud.R2.Bucket = bucket
+	a.config.Cloud.R2.PublicURL = publicURL
+	a.config.Cloud.R2.Directory = directory
+
+	// Update uploader config
+	a.r2Uploader = upload.NewR2Uploader(a.credManager, &upload.R2Config{
+		AccountID: accountID,
+		Bucket:    bucket,
+		PublicURL: publicURL,
+		Directory: directory,
+	})
+
+	return a.config.Save()
+}
+
+// SaveR2Credentials saves R2 secrets to Windows Credential Manager
+func (a *App) SaveR2Credentials(accessKeyID, secretAccessKey string) error {
+	if err := a.credManager.Set(upload.CredR2AccessKeyID, accessKeyID); err != nil {
+		return err
+	}
+	return a.credManager.Set(upload.CredR2SecretAccessKey, secretAccessKey)
+}
+
+// GetR2Config returns R2 configuration
+func (a *App) GetR2Config() config.R2Config {
+	return a.config.Cloud.R2
+}
+
+// IsR2Configured checks if R2 is fully configured
+func (a *App) IsR2Configured() bool {
+	return a.r2Uploader.IsConfigured()
+}
+
+// TestR2Connection tests R2 connectivity
+func (a *App) TestR2Connection() error {
+	return a.r2Uploader.TestConnection()
+}
+
+// UploadToR2 uploads image to Cloudflare R2
+func (a *App) UploadToR2(imageData, filename string) (*upload.UploadResult, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+	}
+	uploader, err := a.encrypted(a.r2Uploader)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	result, err := a.uploadQueued(string(upload.ProviderR2), uploader, data, filename)
+	a.recordUpload(data, result, err)
+	return result, err
+}
+
+// ClearR2Credentials removes R2 credentials from Windows Credential Manager
+func (a *App) ClearR2Credentials() error {
+	a.credManager.Delete(upload.CredR2AccessKeyID)
+	a.credManager.Delete(upload.CredR2SecretAccessKey)
+	return nil
+}
+
+// ==================== Cloud Upload: S3 ====================
+
+// s3Config converts the saved S3 settings for the uploader
+func s3Config(cfg config.S3Config) *upload.S3Config {
+	return &upload.S3Config{
+		Endpoint:    cfg.Endpoint,
+		Region:      cfg.Region,
+		Bucket:      cfg.Bucket,
+		PublicURL:   cfg.PublicURL,
+		KeyTemplate: cfg.KeyTemplate,
+		ACL:         cfg.ACL,
+		PathStyle:   cfg.PathStyle,
+	}
+}
+
+// SaveS3Config saves the S3-compatible service, bucket and key template (non-sensitive)
+func (a *App) SaveS3Config(cfg config.S3Config) error {
+	a.config.Cloud.S3 = cfg
+	a.s3Uploader = upload.NewS3Uploader(a.credManager, s3Config(cfg))
+	return a.config.Save()
+}
+
+// SaveS3Credentials saves S3 access keys to Windows Credential Manager
+func (a *App) SaveS3Credentials(accessKeyID, secretAccessKey string) error {
+	if err := a.credManager.Set(upload.CredS3AccessKeyID, accessKeyID); err != nil {
+		return err
+	}
+	return a.credManager.Set(upload.CredS3SecretAccessKey, secretAccessKey)
+}
+
+// GetS3Config returns S3 configuration
+func (a *App) GetS3Config() config.S3Config {
+	return a.config.Cloud.S3
+}
+
+// IsS3Configured checks if S3 is fully configured
+func (a *App) IsS3Configured() bool {
+	return a.s3Uploader.IsConfigured()
+}
+
+// TestS3Connection tests that the configured bucket can be reached
+func (a *App) TestS3Connection() error {
+	return a.s3Uploader.TestConnection()
+}
+
+// UploadToS3 uploads image to the configured S3-compatible bucket
+func (a *App) UploadToS3(imageData, filename string) (*upload.UploadResult, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+	}
+	uploader, err := a.encrypted(a.s3Uploader)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	result, err := a.uploadQueued(string(upload.ProviderS3), uploader, data, filename)
+	a.recordUpload(data, result, err)
+	return result, err
+}
+
+// ClearS3Credentials removes S3 credentials from Windows Credential Manager
+func (a *App) ClearS3Credentials() error {
+	a.credManager.Delete(upload.CredS3AccessKeyID)
+	a.credManager.Delete(upload.CredS3SecretAccessKey)
+	return nil
+}
+
+// ==================== Cloud Upload: Google Drive ====================
+
+// SaveGDriveCredentials saves Google OAuth client credentials to Credential Manager
+func (a *App) SaveGDriveCredentials(clientID, clientSecret string) error {
+	if err := a.credManager.Set(upload.CredGDriveClientID, clientID); err != nil {
+		return err
+	}
+	return a.credManager.Set(upload.CredGDriveClientSecret, clientSecret)
+}
+
+// SaveGDriveConfig saves Google Drive configuration (non-sensitive)
+func (a *App) SaveGDriveConfig(folderID string) error {
+	a.config.Cloud.GDrive.FolderID = folderID
+
+	// Update uploader config
+	a.gdriveUploader = upload.NewGDriveUploader(a.credManager, &upload.GDriveConfig{
+		FolderID: folderID,
+	})
+
+	return a.config.Save()
+}
+
+// GetGDriveConfig returns Google Drive configuration
+func (a *App) GetGDriveConfig() config.GDriveConfig {
 	return a.config.Cloud.GDrive
 }
 
-// HasGDriveCredentials checks if OAuth client credentials are configured
-func (a *App) HasGDriveCredentials() bool {
-	return a.credManager.Exists(upload.CredGDriveClientID) &&
-		a.credManager.Exists(upload.CredGDriveClientSecret)
+// HasGDriveCredentials checks if OAuth client credentials are configured
+func (a *App) HasGDriveCredentials() bool {
+	return a.credManager.Exists(upload.CredGDriveClientID) &&
+		a.credManager.Exists(upload.CredGDriveClientSecret)
+}
+
+// StartGDriveAuth initiates OAuth flow and returns auth URL
+func (a *App) StartGDriveAuth() (string, error) {
+	url, err := a.gdriveUploader.StartAuth()
+	if err != nil {
+		return "", err
+	}
+
+	// Open browser
+	runtime.BrowserOpenURL(a.ctx, url)
+
+	// Wait for callback in background
+	go func() {
+		if err := a.gdriveUploader.WaitForAuth(); err != nil {
+			runtime.EventsEmit(a.ctx, "gdrive:auth:error", err.Error())
+		} else {
+			runtime.EventsEmit(a.ctx, "gdrive:auth:success")
+		}
+	}()
+
+	return url, nil
+}
+
+// GDriveStatus represents the Google Drive connection status
+type GDriveStatus struct {
+	Connected bool   `json:"connected"`
+	Email     string `json:"email,omitempty"`
+}
+
+// IsGDriveConnected checks if Google Drive is connected and returns user email
+func (a *App) IsGDriveConnected() (bool, string, error) {
+	return a.gdriveUploader.IsConnected()
+}
+
+// GetGDriveStatus returns Google Drive connection status with email
+func (a *App) GetGDriveStatus() (*GDriveStatus, error) {
+	connected, email, err := a.gdriveUploader.IsConnected()
+	if err != nil {
+		return &GDriveStatus{Connected: false}, err
+	}
+	return &GDriveStatus{Connected: connected, Email: email}, nil
+}
+
+// DisconnectGDrive removes Google Drive authorization
+func (a *App) DisconnectGDrive() error {
+	return a.gdriveUploader.Disconnect()
+}
+
+// UploadToGDrive uploads image to Google Drive
+func (a *App) UploadToGDrive(imageData, filename string) (*upload.UploadResult, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+	}
+	uploader, err := a.encrypted(a.gdriveUploader)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	result, err := a.uploadQueued(string(upload.ProviderGDrive), uploader, data, filename)
+	a.recordUpload(data, result, err)
+	return result, err
+}
+
+// ClearGDriveCredentials removes all GDrive credentials from Windows Credential Manager
+func (a *App) ClearGDriveCredentials() error {
+	a.credManager.Delete(upload.CredGDriveClientID)
+	a.credManager.Delete(upload.CredGDriveClientSecret)
+	a.credManager.Delete(upload.CredGDriveToken)
+	return nil
+}
+
+// ==================== Cloud Upload: Confluence ====================
+
+// confluenceConfig converts the saved Confluence settings for the uploader
+func confluenceConfig(cfg config.ConfluenceConfig) *upload.ConfluenceConfig {
+	return &upload.ConfluenceConfig{
+		BaseURL: cfg.BaseURL,
+		PageID:  cfg.PageID,
+		Marker:  cfg.Marker,
+	}
+}
+
+// SaveConfluenceConfig saves the Confluence site, page and marker (non-sensitive)
+func (a *App) SaveConfluenceConfig(cfg config.ConfluenceConfig) error {
+	a.config.Cloud.Confluence = cfg
+	a.confluenceUploader = upload.NewConfluenceUploader(a.credManager, confluenceConfig(cfg))
+	return a.config.Save()
+}
+
+// SaveConfluenceCredentials saves the account email and API token to
+// Credential Manager. An empty user sends the token as a Data Center
+// personal access token
+func (a *App) SaveConfluenceCredentials(user, token string) error {
+	if user == "" {
+		a.credManager.Delete(upload.CredConfluenceUser)
+	} else if err := a.credManager.Set(upload.CredConfluenceUser, user); err != nil {
+		return err
+	}
+	return a.credManager.Set(upload.CredConfluenceToken, token)
+}
+
+// GetConfluenceConfig returns Confluence configuration
+func (a *App) GetConfluenceConfig() config.ConfluenceConfig {
+	return a.config.Cloud.Confluence
+}
+
+// IsConfluenceConfigured checks if Confluence is fully configured
+func (a *App) IsConfluenceConfigured() bool {
+	return a.confluenceUploader.IsConfigured()
+}
+
+// TestConfluenceConnection tests that the configured page can be read
+func (a *App) TestConfluenceConnection() error {
+	return a.confluenceUploader.TestConnection()
+}
+
+// UploadToConfluence attaches image to the configured Confluence page
+func (a *App) UploadToConfluence(imageData, filename string) (*upload.UploadResult, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+	}
+	result, err := a.uploadQueued(string(upload.ProviderConfluence), a.confluenceUploader, data, filename)
+	a.recordUpload(data, result, err)
+	return result, err
+}
+
+// ClearConfluenceCredentials removes Confluence credentials from Windows Credential Manager
+func (a *App) ClearConfluenceCredentials() error {
+	a.credManager.Delete(upload.CredConfluenceUser)
+	a.credManager.Delete(upload.CredConfluenceToken)
+	return nil
+}
+
+// ==================== Cloud Upload: Ephemeral Host ====================
+
+// ephemeralConfig converts the saved ephemeral host settings for the uploader
+func ephemeralConfig(cfg config.EphemeralConfig) *upload.EphemeralConfig {
+	return &upload.EphemeralConfig{
+		Enabled:     cfg.Enabled,
+		URL:         cfg.URL,
+		ExpiryHours: cfg.ExpiryHours,
+		Secret:      cfg.Secret,
+	}
+}
+
+// SaveEphemeralConfig saves the ephemeral host settings
+func (a *App) SaveEphemeralConfig(cfg config.EphemeralConfig) error {
+	if cfg.ExpiryHours < 0 {
+		return fmt.Errorf("expiry must not be negative")
+	}
+	a.config.Cloud.Ephemeral = cfg
+	a.ephemeralUploader = upload.NewEphemeralUploader(ephemeralConfig(cfg))
+	return a.config.Save()
+}
+
+// GetEphemeralConfig returns the ephemeral host settings
+func (a *App) GetEphemeralConfig() config.EphemeralConfig {
+	return a.config.Cloud.Ephemeral
+}
+
+// IsEphemeralConfigured checks if the ephemeral host is enabled
+func (a *App) IsEphemeralConfigured() bool {
+	return a.ephemeralUploader.IsConfigured()
+}
+
+// TestEphemeralConnection tests that the ephemeral host is reachable
+func (a *App) TestEphemeralConnection() error {
+	return a.ephemeralUploader.TestConnection()
+}
+
+// UploadToEphemeral uploads image to the ephemeral host
+func (a *App) UploadToEphemeral(imageData, filename string) (*upload.UploadResult, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+	}
+	uploader, err := a.encrypted(a.ephemeralUploader)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	result, err := a.uploadQueued(string(upload.ProviderEphemeral), uploader, data, filename)
+	a.recordUpload(data, result, err)
+	return result, err
+}
+
+// ==================== Cloud Upload: Encryption ====================
+
+// encrypted wraps uploader so captures are encrypted before upload when
+// encryption is enabled
+func (a *App) encrypted(uploader upload.Uploader) (upload.Uploader, error) {
+	cfg := a.config.Cloud.Encrypt
+	if !cfg.Enabled {
+		return uploader, nil
+	}
+	opts := upload.EncryptOptions{Viewer: cfg.Viewer}
+	if cfg.Passphrase {
+		passphrase, err := a.credManager.Get(upload.CredEncryptPassphrase)
+		if err != nil || passphrase == "" {
+			return nil, fmt.Errorf("no encryption passphrase saved. Please set one in Settings")
+		}
+		opts.Passphrase = passphrase
+	}
+	return upload.NewEncryptingUploader(uploader, opts), nil
+}
+
+// SaveEncryptionConfig saves the upload encryption settings
+func (a *App) SaveEncryptionConfig(cfg config.EncryptConfig) error {
+	if cfg.Enabled && cfg.Passphrase && !a.credManager.Exists(upload.CredEncryptPassphrase) {
+		return fmt.Errorf("save a passphrase before locking uploads with it")
+	}
+	a.config.Cloud.Encrypt = cfg
+	return a.config.Save()
+}
+
+// GetEncryptionConfig returns the upload encryption settings
+func (a *App) GetEncryptionConfig() config.EncryptConfig {
+	return a.config.Cloud.Encrypt
+}
+
+// SaveEncryptionPassphrase saves the passphrase uploads are locked with to
+// Credential Manager. An empty passphrase removes it
+func (a *App) SaveEncryptionPassphrase(passphrase string) error {
+	if passphrase == "" {
+		a.credManager.Delete(upload.CredEncryptPassphrase)
+		return nil
+	}
+	return a.credManager.Set(upload.CredEncryptPassphrase, passphrase)
+}
+
+// HasEncryptionPassphrase checks if an encryption passphrase is saved
+func (a *App) HasEncryptionPassphrase() bool {
+	return a.credManager.Exists(upload.CredEncryptPassphrase)
+}
+
+// ==================== Cloud Upload: Review ====================
+
+// GetUploadReview returns the providers whose automatic uploads are shown for
+// approval first
+func (a *App) GetUploadReview() []string {
+	return a.config.Cloud.Review
+}
+
+// SetUploadReview saves the providers whose automatic uploads are shown for
+// approval first, or "*" for all
+func (a *App) SetUploadReview(providers []string) error {
+	for _, p := range providers {
+		switch p {
+		case config.ReviewAll, string(upload.ProviderR2), string(upload.ProviderS3), string(upload.ProviderGDrive),
+			string(upload.ProviderConfluence), string(upload.ProviderEphemeral):
+		default:
+			return fmt.Errorf("unknown upload provider: %s", p)
+		}
+	}
+	a.config.Cloud.Review = providers
+	return a.config.Save()
+}
+
+// ==================== Cloud Upload: Rate Limit ====================
+
+// GetUploadLimit returns the upload rate limit settings
+func (a *App) GetUploadLimit() config.UploadLimitConfig {
+	return a.config.Cloud.Limit
+}
+
+// SetUploadLimit validates and saves the upload rate limit. It applies to
+// uploads already in progress too.
+func (a *App) SetUploadLimit(cfg config.UploadLimitConfig) error {
+	if cfg.KBps < 0 {
+		return fmt.Errorf("invalid upload limit: %d KB/s", cfg.KBps)
+	}
+	schedule, err := upload.ParseSchedule(cfg.Schedule)
+	if err != nil {
+		return err
+	}
+
+	upload.SetRateLimit(cfg.KBps, schedule)
+	a.config.Cloud.Limit = cfg
+	return a.config.Save()
+}
+
+// ==================== Cloud Upload: Queue ====================
+
+// QueueUpload uploads image data to provider, or the default one if empty,
+// in the background and returns the job ID at once. Failed uploads are
+// retried with growing waits; "upload:progress" events report each change,
+// with the link once it is done
+func (a *App) QueueUpload(imageData, filename, provider string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return "", errors.New("invalid image data")
+	}
+	uploader, err := a.uploaderFor(provider)
+	if err != nil {
+		return "", err
+	}
+	// The capture goes in the history now, so the job keeps only the entry
+	// ID and the data is released as soon as it is sent
+	entryID := a.addUploadEntry(data)
+	id, err := a.queueUpload(provider, uploader, data, filename, func(result *upload.UploadResult, err error) {
+		a.finishUploadEntry(entryID, result, err)
+	})
+	if err != nil {
+		a.finishUploadEntry(entryID, nil, err)
+	}
+	return id, err
+}
+
+// queueUpload adds an upload to the queue, to R2 when provider is empty.
+// done must not hold on to data, which the queue drops once it is sent
+func (a *App) queueUpload(provider string, uploader upload.Uploader, data []byte, filename string, done func(*upload.UploadResult, error)) (string, error) {
+	if provider == "" {
+		provider = string(upload.ProviderR2)
+	}
+	return a.uploadQueue.Add(upload.Job{
+		Provider: upload.UploadProvider(provider),
+		Uploader: uploader,
+		Data:     data,
+		Filename: filename,
+		Done:     done,
+	})
+}
+
+// uploadQueued sends data through the upload queue and waits for the
+// outcome, so uploads that need their link at once are still listed,
+// reported and retried like the others
+func (a *App) uploadQueued(provider string, uploader upload.Uploader, data []byte, filename string) (*upload.UploadResult, error) {
+	type outcome struct {
+		result *upload.UploadResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	_, err := a.queueUpload(provider, uploader, data, filename, func(result *upload.UploadResult, err error) {
+		done <- outcome{result, err}
+	})
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	o := <-done
+	return o.result, o.err
+}
+
+// addUploadEntry stores image data from the editor in the history before it
+// is uploaded and returns the entry ID, or "" when there is no entry
+func (a *App) addUploadEntry(data []byte) string {
+	store := a.historyStore
+	if store == nil {
+		return ""
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		println("Warning: failed to record capture history:", err.Error())
+		return ""
+	}
+	e := history.Entry{Source: "editor"}
+	if w, ok := a.captureInfo().foreground(); ok {
+		e.App, e.Title = w.App, w.Title
+	}
+	e, err = store.Add(img, e)
+	if err != nil {
+		println("Warning: failed to record capture history:", err.Error())
+		return ""
+	}
+	return e.ID
+}
+
+// finishUploadEntry adds the link to an entry from addUploadEntry once its
+// upload is done, or removes the entry if the upload failed or was cancelled
+func (a *App) finishUploadEntry(id string, result *upload.UploadResult, err error) {
+	store := a.historyStore
+	if id == "" || store == nil {
+		return
+	}
+	if err != nil || result == nil || !result.Success {
+		store.Delete(id)
+		return
+	}
+	if err := store.SetURL(id, result.PublicURL); err != nil {
+		println("Warning: failed to record upload link:", err.Error())
+		return
+	}
+	if e, err := store.Get(id); err == nil {
+		runtime.EventsEmit(a.ctx, "history:added", e)
+	}
+}
+
+// CancelUpload stops a queued upload. It returns false if the upload has
+// already finished
+func (a *App) CancelUpload(id string) bool {
+	return a.uploadQueue.Cancel(id)
+}
+
+// ListUploads returns the uploads that are queued, being sent or waiting to
+// be retried
+func (a *App) ListUploads() []upload.Progress {
+	return a.uploadQueue.Jobs()
+}
+
+// ==================== Notes: Obsidian and OneNote ====================
+
+// captureNote encodes a capture as a note titled after the window it was
+// taken from
+func (a *App) captureNote(img image.Image, format string, quality int) (notes.Note, error) {
+	data, err := encodeImageData(img, format, quality)
+	if err != nil {
+		return notes.Note{}, fmt.Errorf("failed to encode image: %w", err)
+	}
+	c := a.captureInfo()
+	taken := c.at
+	if taken.IsZero() {
+		taken = time.Now()
+	}
+	return notes.Note{
+		Title:    noteTitle(c.windows, taken),
+		Tags:     a.config.Notes.Tags,
+		Source:   c.url,
+		Time:     taken,
+		Image:    data,
+		Filename: "winshot_" + taken.Format("2006-01-02_15-04-05") + imageExtension(format),
+	}, nil
+}
+
+// noteTitle returns the title of the foreground window of a capture, or a
+// dated title when it is unknown
+func noteTitle(windows []library.WindowRecord, taken time.Time) string {
+	for _, w := range windows {
+		if w.Foreground && strings.TrimSpace(w.Title) != "" {
+			return w.Title
+		}
+	}
+	return "Screenshot " + taken.Format("2006-01-02 15:04")
+}
+
+// pipelineToObsidian saves the image and a note embedding it into the
+// configured Obsidian vault and returns the note's path
+func (a *App) pipelineToObsidian(img image.Image, format string, quality int) (string, error) {
+	note, err := a.captureNote(img, format, quality)
+	if err != nil {
+		return "", err
+	}
+	return notes.SaveToVault(a.config.Notes.Obsidian.Vault, a.config.Notes.Obsidian.Folder, note)
+}
+
+// pipelineToOneNote adds the image as a page in the configured OneNote
+// section and returns the page's link
+func (a *App) pipelineToOneNote(img image.Image, format string, quality int) (string, error) {
+	note, err := a.captureNote(img, format, quality)
+	if err != nil {
+		return "", err
+	}
+	a.waitForPower("OneNote page " + note.Filename)
+	return notes.NewOneNote(a.credManager, a.config.Notes.OneNote.SectionID).AppendPage(context.Background(), note)
+}
+
+// OneNoteStatus represents the OneNote connection status
+type OneNoteStatus struct {
+	Connected   bool `json:"connected"`
+	HasClientID bool `json:"hasClientId"`
+}
+
+// GetOneNoteStatus returns whether a Microsoft account is connected for OneNote
+func (a *App) GetOneNoteStatus() OneNoteStatus {
+	return OneNoteStatus{
+		Connected:   notes.NewOneNote(a.credManager, "").IsConnected(),
+		HasClientID: a.credManager.Exists(upload.CredOneNoteClientID),
+	}
+}
+
+// GetNotesConfig returns the Obsidian and OneNote settings
+func (a *App) GetNotesConfig() config.NotesConfig {
+	return a.config.Notes
+}
+
+// SaveNotesConfig saves the Obsidian and OneNote settings
+func (a *App) SaveNotesConfig(cfg config.NotesConfig) error {
+	a.config.Notes = cfg
+	return a.config.Save()
+}
+
+// StartOneNoteAuth stores the Microsoft app client ID, opens the sign-in page
+// and returns its URL. The outcome is reported with onenote:auth events
+func (a *App) StartOneNoteAuth(clientID string) (string, error) {
+	if clientID = strings.TrimSpace(clientID); clientID != "" {
+		if err := a.credManager.Set(upload.CredOneNoteClientID, clientID); err != nil {
+			return "", err
+		}
+	}
+	oneNote := notes.NewOneNote(a.credManager, a.config.Notes.OneNote.SectionID)
+	authURL, err := oneNote.StartAuth()
+	if err != nil {
+		return "", err
+	}
+
+	runtime.BrowserOpenURL(a.ctx, authURL)
+
+	go func() {
+		if err := oneNote.WaitForAuth(); err != nil {
+			runtime.EventsEmit(a.ctx, "onenote:auth:error", err.Error())
+		} else {
+			runtime.EventsEmit(a.ctx, "onenote:auth:success")
+		}
+	}()
+
+	return authURL, nil
+}
+
+// DisconnectOneNote removes the OneNote sign-in, keeping the client ID
+func (a *App) DisconnectOneNote() error {
+	return notes.NewOneNote(a.credManager, "").Disconnect()
+}
+
+// ==================== Screenshot Library ====================
+
+// GetLibraryImages returns all screenshots from QuickSave folder
+func (a *App) GetLibraryImages() ([]library.LibraryImage, error) {
+	folder := a.config.QuickSave.Folder
+	if folder == "" {
+		// Fallback to default location
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		folder = filepath.Join(homeDir, "Pictures", "WinShot")
+	}
+
+	opts := library.DefaultScanOptions()
+	return library.ScanFolder(folder, opts)
+}
+
+// OpenInEditor loads an image file into the editor
+// Security: validates path is within QuickSave folder
+func (a *App) OpenInEditor(imagePath string) (*screenshot.CaptureResult, error) {
+	// Validate path is within QuickSave folder (prevent directory traversal)
+	folder := a.config.QuickSave.Folder
+	if folder == "" {
+		homeDir, _ := os.UserHomeDir()
+		folder = filepath.Join(homeDir, "Pictures", "WinShot")
+	}
+
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	absFolder, err := filepath.Abs(folder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid folder path: %w", err)
+	}
+
+	// Security check: ensure file is within QuickSave folder
+	if !strings.HasPrefix(absPath, absFolder+string(filepath.Separator)) {
+		return nil, fmt.Errorf("access denied: file outside QuickSave folder")
+	}
+
+	// Read file
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Decode image
+	var img image.Image
+	ext := strings.ToLower(filepath.Ext(absPath))
+
+	switch ext {
+	case ".png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case ".jpg", ".jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	default:
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+
+	// Re-encode as PNG for consistent handling in frontend
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	// Saving it again must not claim the latest capture's context
+	a.setCaptureInfo(captureInfo{})
+
+	return &screenshot.CaptureResult{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Data:   base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// DeleteScreenshot removes a screenshot file from disk
+// Security: validates path is within QuickSave folder
+func (a *App) DeleteScreenshot(imagePath string) error {
+	absPath, err := a.libraryPath(imagePath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(absPath); err != nil {
+		return err
+	}
+
+	// Forget tags for the deleted file so a new capture with the same name
+	// doesn't inherit them
+	if index, err := library.OpenIndex(a.libraryFolder()); err == nil {
+		index.Remove(filepath.Base(absPath))
+		if err := index.Save(); err != nil {
+			println("Warning: failed to update history index:", err.Error())
+		}
+	}
+	return nil
+}
+
+// libraryFolder returns the QuickSave folder the library scans
+func (a *App) libraryFolder() string {
+	folder := a.config.QuickSave.Folder
+	if folder == "" {
+		homeDir, _ := os.UserHomeDir()
+		folder = filepath.Join(homeDir, "Pictures", "WinShot")
+	}
+	return folder
+}
+
+// libraryPath returns the absolute path of a screenshot after checking it is
+// within QuickSave folder (prevent directory traversal)
+func (a *App) libraryPath(imagePath string) (string, error) {
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+
+	absFolder, err := filepath.Abs(a.libraryFolder())
+	if err != nil {
+		return "", fmt.Errorf("invalid folder path: %w", err)
+	}
+
+	if !strings.HasPrefix(absPath, absFolder+string(filepath.Separator)) {
+		return "", fmt.Errorf("access denied: file outside QuickSave folder")
+	}
+	return absPath, nil
+}
+
+// ExportLibraryImages re-encodes the selected screenshots into outDir, as png
+// or jpeg (empty keeps each format), downscaling wider ones to maxWidth
+// Security: validates every path is within QuickSave folder
+func (a *App) ExportLibraryImages(paths []string, format string, quality, maxWidth int, outDir string) ([]library.ExportedFile, error) {
+	for _, p := range paths {
+		if _, err := a.libraryPath(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return library.Export(paths, library.ExportOptions{
+		Format:   format,
+		Quality:  quality,
+		MaxWidth: maxWidth,
+		OutDir:   outDir,
+	})
+}
+
+// TagScreenshots adds tags to the given screenshots
+func (a *App) TagScreenshots(paths []string, tags []string) error {
+	return a.updateLibraryIndex(paths, func(index *library.Index, names []string) error {
+		return index.AddTags(names, tags)
+	})
+}
+
+// UntagScreenshots removes tags from the given screenshots
+func (a *App) UntagScreenshots(paths []string, tags []string) error {
+	return a.updateLibraryIndex(paths, func(index *library.Index, names []string) error {
+		return index.RemoveTags(names, tags)
+	})
+}
+
+// SetScreenshotsFavorite marks or unmarks the given screenshots as favorites
+func (a *App) SetScreenshotsFavorite(paths []string, favorite bool) error {
+	return a.updateLibraryIndex(paths, func(index *library.Index, names []string) error {
+		index.SetFavorite(names, favorite)
+		return nil
+	})
+}
+
+// GetLibraryTags returns every tag used in the library, sorted
+func (a *App) GetLibraryTags() ([]string, error) {
+	index, err := library.OpenIndex(a.libraryFolder())
+	if err != nil {
+		return nil, err
+	}
+	return index.Tags(), nil
+}
+
+// FilterLibraryImages returns the screenshots carrying every given tag,
+// optionally only favorites
+func (a *App) FilterLibraryImages(tags []string, favoritesOnly bool) ([]library.LibraryImage, error) {
+	images, err := a.GetLibraryImages()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := library.Filter{Tags: tags, FavoritesOnly: favoritesOnly}
+	matched := []library.LibraryImage{}
+	for _, img := range images {
+		if filter.Matches(library.EntryMeta{Tags: img.Tags, Favorite: img.Favorite}) {
+			matched = append(matched, img)
+		}
+	}
+	return matched, nil
+}
+
+// SearchLibrary returns the screenshots matching a history query such as
+// `app:chrome before:2024-06-01 tag:bug text:"timeout"`, newest first
+func (a *App) SearchLibrary(query string) ([]library.LibraryImage, error) {
+	q, err := library.ParseQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	docs, err := library.Search(a.libraryFolder(), q)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool, len(docs))
+	for _, d := range docs {
+		matched[d.Name] = true
+	}
+
+	images, err := a.GetLibraryImages()
+	if err != nil {
+		return nil, err
+	}
+	results := []library.LibraryImage{}
+	for _, img := range images {
+		if matched[img.Filename] {
+			results = append(results, img)
+		}
+	}
+	return results, nil
+}
+
+// VacuumLibrary forgets metadata of deleted screenshots and frees store
+// objects nothing refers to. With dedupe, plain files are moved into the store
+// and duplicates replaced by links.
+func (a *App) VacuumLibrary(dedupe bool) (library.VacuumReport, error) {
+	return library.Vacuum(a.libraryFolder(), dedupe)
+}
+
+// LibraryUploadResult is the outcome of uploading one library screenshot
+type LibraryUploadResult struct {
+	Path  string `json:"path"`
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// UploadLibraryImages uploads the given screenshots as they are on disk, e.g.
+// everything tagged "release-notes", and copies the links to the clipboard
+// one per line. A failed upload doesn't stop the rest.
+func (a *App) UploadLibraryImages(paths []string, provider string) ([]LibraryUploadResult, error) {
+	uploader, err := a.uploaderFor(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]LibraryUploadResult, 0, len(paths))
+	var urls []string
+	for _, p := range paths {
+		result := LibraryUploadResult{Path: p}
+		if url, err := a.uploadLibraryFile(provider, uploader, p); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.URL = url
+			urls = append(urls, url)
+		}
+		results = append(results, result)
+	}
+
+	if len(urls) > 0 {
+		if err := screenshot.SetClipboardText(strings.Join(urls, "\r\n")); err != nil {
+			println("Warning: failed to copy upload URLs:", err.Error())
+		}
+	}
+	return results, nil
+}
+
+// uploadLibraryFile uploads one screenshot file and returns its public URL
+func (a *App) uploadLibraryFile(provider string, uploader upload.Uploader, imagePath string) (string, error) {
+	absPath, err := a.libraryPath(imagePath)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	result, err := a.uploadQueued(provider, uploader, data, filepath.Base(absPath))
+	if err != nil {
+		return "", err
+	}
+	if err := recordShared(absPath, result); err != nil {
+		println("Warning: failed to update history index:", err.Error())
+	}
+	return result.PublicURL, nil
+}
+
+// recordShared notes the uploaded link of a library screenshot in the
+// history index, with its expiry for ephemeral hosts
+func recordShared(absPath string, result *upload.UploadResult) error {
+	var expires time.Time
+	if result.ExpiresAt != "" {
+		var err error
+		if expires, err = time.Parse(time.RFC3339, result.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	index, err := library.OpenIndex(filepath.Dir(absPath))
+	if err != nil {
+		return err
+	}
+	index.SetShared(filepath.Base(absPath), result.PublicURL, expires)
+	return index.Save()
+}
+
+// updateLibraryIndex applies fn to the history index entries of the given
+// screenshots and saves the index
+func (a *App) updateLibraryIndex(paths []string, fn func(index *library.Index, names []string) error) error {
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		absPath, err := a.libraryPath(p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.Base(absPath))
+	}
+
+	index, err := library.OpenIndex(a.libraryFolder())
+	if err != nil {
+		return err
+	}
+	if err := fn(index, names); err != nil {
+		return err
+	}
+	return index.Save()
+}
+
+// ==================== Crop Guide ====================
+
+// applyCropGuide shows or hides the crop guide to match the saved settings
+func (a *App) applyCropGuide() error {
+	g := a.config.Guide
+	if !g.Enabled || g.Width <= 0 || g.Height <= 0 {
+		a.cropGuide.Hide()
+		return nil
+	}
+
+	c := overlay.DefaultGuideColor
+	if g.Color != "" {
+		parsed, err := overlay.ParseColor(g.Color)
+		if err != nil {
+			return err
+		}
+		c = parsed
+	}
+	return a.cropGuide.Show(image.Rect(g.X, g.Y, g.X+g.Width, g.Y+g.Height), c, g.Thickness)
+}
+
+// GetCropGuide returns the crop guide settings
+func (a *App) GetCropGuide() config.GuideConfig {
+	return a.config.Guide
+}
+
+// SetCropGuide outlines a region in screen coordinates and keeps it on screen
+func (a *App) SetCropGuide(x, y, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid crop guide size %dx%d", width, height)
+	}
+	a.config.Guide.X = x
+	a.config.Guide.Y = y
+	a.config.Guide.Width = width
+	a.config.Guide.Height = height
+	a.config.Guide.Enabled = true
+
+	if err := a.applyCropGuide(); err != nil {
+		return err
+	}
+	return a.config.Save()
+}
+
+// SetCropGuideStyle sets the guide border color ("#RRGGBB", empty for default)
+// and thickness in pixels (0 for default)
+func (a *App) SetCropGuideStyle(color string, thickness int) error {
+	if color != "" {
+		if _, err := overlay.ParseColor(color); err != nil {
+			return err
+		}
+	}
+	a.config.Guide.Color = color
+	a.config.Guide.Thickness = thickness
+
+	if err := a.applyCropGuide(); err != nil {
+		return err
+	}
+	return a.config.Save()
+}
+
+// ShowCropGuide shows or hides the saved crop guide region
+func (a *App) ShowCropGuide(show bool) error {
+	if show && (a.config.Guide.Width <= 0 || a.config.Guide.Height <= 0) {
+		return fmt.Errorf("no crop guide region set")
+	}
+	a.config.Guide.Enabled = show
+
+	if err := a.applyCropGuide(); err != nil {
+		return err
+	}
+	return a.config.Save()
+}
+
+// SelectCropGuide lets the user draw the crop guide region with the selection overlay
+func (a *App) SelectCropGuide() {
+	go func() {
+		rect, ok, err := a.pickScreenRect()
+		if err == nil && ok {
+			err = a.SetCropGuide(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+		}
+		if err != nil {
+			println("Warning: failed to set crop guide:", err.Error())
+		}
+	}()
+}
+
+// pickScreenRect shows the selection overlay and returns the selected bounds in
+// screen coordinates. ok is false if the selection was cancelled.
+func (a *App) pickScreenRect() (image.Rectangle, bool, error) {
+	defer a.hideForCapture()()
+
+	screenX, screenY, virtualWidth, virtualHeight := screenshot.GetVirtualScreenBounds()
+	rgbaImg, err := screenshot.CaptureVirtualScreenRaw()
+	if err != nil {
+		return image.Rectangle{}, false, err
+	}
+
+	scaleRatio := float64(rgbaImg.Bounds().Dx()) / float64(virtualWidth)
+	if scaleRatio < 1.0 {
+		scaleRatio = 1.0
+	}
+
+	bounds := image.Rect(screenX, screenY, screenX+virtualWidth, screenY+virtualHeight)
+	res := <-a.showOverlay(rgbaImg, bounds, scaleRatio)
+	if res.Cancelled {
+		return image.Rectangle{}, false, nil
+	}
+	return image.Rect(res.X, res.Y, res.X+res.Width, res.Y+res.Height).Add(bounds.Min), true, nil
+}
+
+// ==================== Capture Triggers ====================
+
+// triggerSettleDelay lets a window finish its open animation before capture
+const triggerSettleDelay = 300 * time.Millisecond
+
+// applyTriggers restarts the window event watcher with the saved rules.
+// Invalid rules are skipped so one typo doesn't disable the rest.
+func (a *App) applyTriggers() error {
+	if a.triggerWatcher != nil {
+		a.triggerWatcher.Stop()
+		a.triggerWatcher = nil
+	}
+
+	cfg := a.config.Triggers
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var rules []triggers.Rule
+	var sources []config.TriggerRule
+	for _, r := range cfg.Rules {
+		if !r.Enabled {
+			continue
+		}
+		rule, err := triggers.NewRule(r.Name, r.Event, r.Title, r.Class)
+		if err != nil {
+			println("Warning: skipping trigger rule", r.Name+":", err.Error())
+			continue
+		}
+		rules = append(rules, rule)
+		sources = append(sources, r)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	cooldown := time.Duration(cfg.Cooldown) * time.Second
+	watcher := triggers.NewWatcher(rules, cooldown, func(m triggers.Match) {
+		a.runTrigger(sources[m.Rule], m)
+	})
+	if err := watcher.Start(); err != nil {
+		return err
+	}
+	a.triggerWatcher = watcher
+	return nil
+}
+
+// runTrigger captures the window that fired a rule and sends it down the
+// rule's pipeline
+func (a *App) runTrigger(rule config.TriggerRule, m triggers.Match) {
+	pipeline := rule.Pipeline()
+	result := PipelineResult{Action: "trigger:" + rule.Name, Destination: pipeline.Destination}
+
+	time.Sleep(triggerSettleDelay)
+
+	var capture *image.RGBA
+	var err error
+	center := m.Window.Bounds.Min.Add(m.Window.Bounds.Size().Div(2))
+	display := screenshot.GetMonitorAtPoint(center.X, center.Y)
+	a.recordCapture(display)
+
+	// A closed window can't be captured, so its display is captured instead
+	if rule.Capture == config.TriggerCaptureFullscreen || m.Event == triggers.EventClose {
+		capture, err = screenshot.CaptureDisplayRaw(display)
+	} else {
+		capture, err = screenshot.CaptureWindowRaw(m.Window.Handle)
+	}
+	if err != nil {
+		a.finishPipeline(result, err)
+		return
+	}
+
+	img, err := screenshot.ApplyFilters(capture)
+	if err != nil {
+		a.finishPipeline(result, err)
+		return
+	}
+	a.deliverPipeline(img, pipeline, result)
+}
+
+// GetTriggers returns the capture trigger settings
+func (a *App) GetTriggers() config.TriggerConfig {
+	return a.config.Triggers
+}
+
+// SetTriggers validates and saves capture trigger rules and restarts the watcher
+func (a *App) SetTriggers(cfg config.TriggerConfig) error {
+	for _, r := range cfg.Rules {
+		if _, err := triggers.NewRule(r.Name, r.Event, r.Title, r.Class); err != nil {
+			return fmt.Errorf("trigger rule %q: %w", r.Name, err)
+		}
+		switch r.Capture {
+		case "", config.TriggerCaptureWindow, config.TriggerCaptureFullscreen:
+		default:
+			return fmt.Errorf("trigger rule %q: unknown capture %q", r.Name, r.Capture)
+		}
+	}
+
+	a.config.Triggers = cfg
+	if err := a.applyTriggers(); err != nil {
+		return err
+	}
+	return a.config.Save()
+}
+
+// ==================== Watchdog ====================
+
+// applyWatchdog restarts watching the configured process
+func (a *App) applyWatchdog() {
+	if a.watchdogStop != nil {
+		close(a.watchdogStop)
+		a.watchdogStop = nil
+	}
+
+	w := a.config.Watchdog
+	if !w.Enabled || w.Process == "" {
+		return
+	}
+	a.watchdogStop = make(chan struct{})
+	go watchProcess(w.Process, time.Duration(w.HangSeconds)*time.Second, a.watchdogStop, a.recordIncident)
+}
+
+// recordIncident captures the desktop, and the hung window if there is one,
+// then writes the incident report next to the captures
+func (a *App) recordIncident(inc watchdog.Incident, hwnd uintptr) {
+	dir := a.config.Watchdog.Folder
+	var err error
+	if dir == "" {
+		dir, err = a.quickSaveDir()
+	} else {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		println("Warning: failed to prepare watchdog folder:", err.Error())
+		return
+	}
+	stem := filepath.Join(dir, inc.FileStem())
+
+	// Desktop first, since crash dialogs and ghosted windows may not last
+	if img, err := screenshot.CaptureVirtualScreenRaw(); err != nil {
+		println("Warning: failed to capture desktop:", err.Error())
+	} else if err := a.writeIncidentImage(img, stem+"_desktop.png"); err != nil {
+		println("Warning:", err.Error())
+	} else {
+		inc.Desktop = stem + "_desktop.png"
+	}
+
+	if hwnd != 0 {
+		if capture, err := screenshot.CaptureWindowRaw(hwnd); err != nil {
+			println("Warning: failed to capture hung window:", err.Error())
+		} else if img, err := screenshot.ApplyFilters(capture); err != nil {
+			println("Warning:", err.Error())
+		} else if err := a.writeIncidentImage(img, stem+"_window.png"); err != nil {
+			println("Warning:", err.Error())
+		} else {
+			inc.WindowCapture = stem + "_window.png"
+		}
+	}
+
+	if report, err := watchdog.RenderReport(a.config.Watchdog.ReportTemplate, inc); err != nil {
+		println("Warning:", err.Error())
+	} else if err := os.WriteFile(stem+".md", []byte(report), 0644); err != nil {
+		println("Warning: failed to write incident report:", err.Error())
+	} else {
+		inc.Report = stem + ".md"
+	}
+
+	runtime.EventsEmit(a.ctx, "watchdog:incident", inc)
+	if a.trayIcon != nil && a.config.Startup.ShowNotification {
+		a.trayIcon.ShowBalloon("WinShot Watchdog", fmt.Sprintf("%s %s, captures saved to %s", inc.Process, inc.Reason, dir))
+	}
+}
+
+// writeIncidentImage saves an incident capture as PNG
+func (a *App) writeIncidentImage(img image.Image, path string) error {
+	data, err := encodeImageData(img, "png", 0)
+	if err != nil {
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	a.writeManifest(path, data)
+	return nil
+}
+
+// GetWatchdog returns the watchdog settings
+func (a *App) GetWatchdog() config.WatchdogConfig {
+	return a.config.Watchdog
+}
+
+// SetWatchdog validates and saves the watchdog settings and restarts watching
+func (a *App) SetWatchdog(cfg config.WatchdogConfig) error {
+	if cfg.Enabled && strings.TrimSpace(cfg.Process) == "" {
+		return fmt.Errorf("no process to watch")
+	}
+	if _, err := watchdog.ParseReportTemplate(cfg.ReportTemplate); err != nil {
+		return err
+	}
+	cfg.Process = strings.TrimSpace(cfg.Process)
+
+	a.config.Watchdog = cfg
+	a.applyWatchdog()
+	return a.config.Save()
+}
+
+// ==================== Local Share ====================
+
+// ShareLocal serves a base64 encoded image on the local HTTP server under a
+// short-lived link for someone on the same network, and copies the link to
+// the clipboard. Nothing leaves the LAN.
+func (a *App) ShareLocal(imageData, format, note string) (*server.Share, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image data: %w", err)
+	}
+
+	cfg := a.config.Share
+	srv := a.localShareServer(cfg.Port)
+	share, err := srv.Share(data, server.ShareOptions{
+		Name:        "screenshot_" + time.Now().Format("2006-01-02_15-04-05") + imageExtension(format),
+		ContentType: imageContentType(format),
+		TTL:         time.Duration(cfg.ExpiryMinutes) * time.Minute,
+		Reusable:    cfg.Reusable,
+		Viewer:      cfg.Viewer,
+		Note:        note,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := screenshot.SetClipboardText(share.URL); err != nil {
+		println("Warning: failed to copy share link:", err.Error())
+	}
+	return &share, nil
+}
+
+// imageContentType returns the MIME type of an image format. The share
+// server forbids sniffing, so a wrong type would break the image
+func imageContentType(format string) string {
+	if t := mime.TypeByExtension(imageExtension(format)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// localShareServer returns the local share server, creating it for port on
+// the first share. Bound methods run concurrently, so two shares can race
+// to create it
+func (a *App) localShareServer(port int) *server.Server {
+	a.shareMu.Lock()
+	defer a.shareMu.Unlock()
+	if a.shareServer == nil {
+		a.shareServer = server.New(fmt.Sprintf(":%d", port))
+	}
+	return a.shareServer
+}
+
+// RevokeLocalShare stops serving a shared image before its link expires
+func (a *App) RevokeLocalShare(token string) bool {
+	a.shareMu.Lock()
+	srv := a.shareServer
+	a.shareMu.Unlock()
+	return srv != nil && srv.Revoke(token)
+}
+
+// GetShareSettings returns the local share settings
+func (a *App) GetShareSettings() config.ShareConfig {
+	return a.config.Share
+}
+
+// SetShareSettings saves the local share settings. A new port applies after
+// a restart, so links already handed out keep working.
+func (a *App) SetShareSettings(cfg config.ShareConfig) error {
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", cfg.Port)
+	}
+	if cfg.ExpiryMinutes < 0 {
+		return fmt.Errorf("invalid expiry: %d minutes", cfg.ExpiryMinutes)
+	}
+	a.config.Share = cfg
+	return a.config.Save()
+}
+
+// ==================== External Filters ====================
+
+// externalFilterPrefix namespaces configured filters in the post-capture chain
+// so reapplying them leaves filters registered in code alone
+const externalFilterPrefix = "external:"
+
+// applyExternalFilters replaces the configured external filters in the
+// post-capture chain, keeping their configured order
+func (a *App) applyExternalFilters() {
+	for _, name := range screenshot.PostCapture.Names() {
+		if strings.HasPrefix(name, externalFilterPrefix) {
+			screenshot.PostCapture.Unregister(name)
+		}
+	}
+	for _, f := range a.config.Filters {
+		if !f.Enabled {
+			continue
+		}
+		screenshot.PostCapture.Register(externalFilterPrefix+f.Name, imaging.ExternalFilter(imaging.ExternalCommand{
+			Path:    f.Command,
+			Args:    f.Args,
+			Timeout: time.Duration(f.TimeoutSeconds) * time.Second,
+		}))
+	}
+	a.registerPlugins() // Keep plugins after the external filters
+}
+
+// GetExternalFilters returns the configured external filters
+func (a *App) GetExternalFilters() []config.ExternalFilterConfig {
+	return a.config.Filters
+}
+
+// SetExternalFilters validates and saves the external filters. Every capture
+// runs through the enabled ones from then on.
+func (a *App) SetExternalFilters(filters []config.ExternalFilterConfig) error {
+	seen := make(map[string]bool)
+	for i, f := range filters {
+		f.Name = strings.TrimSpace(f.Name)
+		if f.Name == "" {
+			return fmt.Errorf("filter %d has no name", i+1)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate filter name %q", f.Name)
+		}
+		seen[f.Name] = true
+		if f.TimeoutSeconds < 0 {
+			return fmt.Errorf("filter %q: invalid timeout", f.Name)
+		}
+		if f.Enabled {
+			if _, err := exec.LookPath(f.Command); err != nil {
+				return fmt.Errorf("filter %q: %w", f.Name, err)
+			}
+		}
+		filters[i] = f
+	}
+
+	a.config.Filters = filters
+	a.applyExternalFilters()
+	return a.config.Save()
+}
+
+// ==================== Sandboxed Plugins ====================
+
+// pluginFilterPrefix namespaces loaded plugins in the post-capture chain
+const pluginFilterPrefix = "plugin:"
+
+// pluginProviderPrefix names an uploader plugin as an upload provider
+const pluginProviderPrefix = "plugin:"
+
+// applyPlugins reloads the enabled plugin modules. A module that fails to
+// load is skipped with a warning rather than blocking startup. The old
+// modules are taken out of the post-capture chain before they are closed,
+// and closing waits for captures still running them
+func (a *App) applyPlugins() {
+	var loaded []*plugin.Plugin
+	for _, cfg := range a.config.Plugins {
+		if !cfg.Enabled {
+			continue
+		}
+		p, err := loadPlugin(cfg)
+		if err != nil {
+			println("Warning: failed to load plugin:", err.Error())
+			continue
+		}
+		loaded = append(loaded, p)
+	}
+
+	a.pluginsMu.Lock()
+	old := a.plugins
+	a.plugins = loaded
+	a.registerPlugins()
+	a.pluginsMu.Unlock()
+
+	for _, p := range old {
+		p.Close()
+	}
+}
+
+// registerPlugins replaces the plugins in the post-capture chain with the
+// loaded filters, appending them after every other filter. The caller holds
+// pluginsMu
+func (a *App) registerPlugins() {
+	for _, name := range screenshot.PostCapture.Names() {
+		if strings.HasPrefix(name, pluginFilterPrefix) {
+			screenshot.PostCapture.Unregister(name)
+		}
+	}
+	for _, p := range a.plugins {
+		if p.IsFilter() {
+			screenshot.PostCapture.Register(pluginFilterPrefix+p.Name(), p.Filter())
+		}
+	}
+}
+
+func loadPlugin(cfg config.PluginConfig) (*plugin.Plugin, error) {
+	return plugin.Load(cfg.Path, plugin.Options{
+		Timeout:     time.Duration(cfg.TimeoutSeconds) * time.Second,
+		MemoryMB:    cfg.MemoryMB,
+		UploadHosts: cfg.UploadHosts,
+	})
+}
+
+// uploaderPlugin returns the loaded uploader plugin called name, or nil
+func (a *App) uploaderPlugin(name string) *plugin.Plugin {
+	a.pluginsMu.Lock()
+	defer a.pluginsMu.Unlock()
+	for _, p := range a.plugins {
+		if p.Name() == name && p.IsUploader() {
+			return p
+		}
+	}
+	return nil
+}
+
+// pluginUploader uploads through a sandboxed uploader plugin
+type pluginUploader struct {
+	plugin *plugin.Plugin
+}
+
+func (u pluginUploader) Upload(ctx context.Context, data []byte, filename string) (*upload.UploadResult, error) {
+	url, err := u.plugin.Upload(ctx, data, filename)
+	if err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	return &upload.UploadResult{Success: true, PublicURL: url}, nil
+}
+
+// IsConfigured is always true, a plugin carries its own settings
+func (u pluginUploader) IsConfigured() bool {
+	return true
+}
+
+// TestConnection has nothing to check short of uploading
+func (u pluginUploader) TestConnection() error {
+	return nil
+}
+
+// GetPlugins returns the configured plugin modules
+func (a *App) GetPlugins() []config.PluginConfig {
+	return a.config.Plugins
+}
+
+// SetPlugins validates and saves the plugin modules. Enabled modules are
+// compiled up front so a broken one is reported here instead of failing
+// every capture.
+func (a *App) SetPlugins(plugins []config.PluginConfig) error {
+	seen := make(map[string]bool)
+	for i, cfg := range plugins {
+		if cfg.TimeoutSeconds < 0 || cfg.MemoryMB < 0 {
+			return fmt.Errorf("plugin %d: invalid limits", i+1)
+		}
+		for _, host := range cfg.UploadHosts {
+			if host == "" || strings.ContainsAny(host, "/ ") {
+				return fmt.Errorf("plugin %d: invalid upload host %q, want a host name such as img.example.com", i+1, host)
+			}
+		}
+		name := strings.TrimSuffix(filepath.Base(cfg.Path), filepath.Ext(cfg.Path))
+		if seen[name] {
+			return fmt.Errorf("duplicate plugin name %q", name)
+		}
+		seen[name] = true
+		if cfg.Enabled {
+			p, err := loadPlugin(cfg)
+			if err != nil {
+				return err
+			}
+			p.Close()
+		}
+	}
+
+	a.config.Plugins = plugins
+	a.applyPlugins()
+	return a.config.Save()
+}
+
+// ==================== Scripts ====================
+
+// defaultScriptTimeout bounds a script run, long enough for region selections
+const defaultScriptTimeout = 120 * time.Second
+
+// runScript runs a workflow script from a hotkey and reports failures in a
+// tray balloon and a "script:error" event
+func (a *App) runScript(s config.ScriptConfig) {
+	if err := a.RunScript(s.Name); err != nil {
+		a.notify("Script failed: " + err.Error())
+		runtime.EventsEmit(a.ctx, "script:error", s.Name, err.Error())
+	}
+}
+
+// RunScript runs the configured script with the given name. Only one script
+// runs at a time.
+func (a *App) RunScript(name string) error {
+	i := slices.IndexFunc(a.config.Scripts, func(s config.ScriptConfig) bool { return s.Name == name })
+	if i < 0 {
+		return fmt.Errorf("no script named %q", name)
+	}
+	s := a.config.Scripts[i]
+
+	if !a.scriptRunning.CompareAndSwap(false, true) {
+		return errors.New("another script is running")
+	}
+	defer a.scriptRunning.Store(false)
+
+	source, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	timeout := defaultScriptTimeout
+	if s.TimeoutSeconds > 0 {
+		timeout = time.Duration(s.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return script.Run(ctx, filepath.Base(s.Path), string(source), scriptHost{a})
+}
+
+// GetScripts returns the configured workflow scripts
+func (a *App) GetScripts() []config.ScriptConfig {
+	return a.config.Scripts
+}
+
+// SetScripts validates and saves the workflow scripts and registers their
+// hotkeys
+func (a *App) SetScripts(scripts []config.ScriptConfig) error {
+	seen := make(map[string]bool)
+	for i, s := range scripts {
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Name == "" {
+			return fmt.Errorf("script %d has no name", i+1)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate script name %q", s.Name)
+		}
+		seen[s.Name] = true
+		if _, err := os.Stat(s.Path); err != nil {
+			return fmt.Errorf("script %q: %w", s.Name, err)
+		}
+		if s.Hotkey != "" {
+			if _, _, ok := hotkeys.ParseHotkeyString(s.Hotkey); !ok {
+				return fmt.Errorf("script %q: invalid hotkey %q", s.Name, s.Hotkey)
+			}
+		}
+		if s.TimeoutSeconds < 0 {
+			return fmt.Errorf("script %q: invalid timeout", s.Name)
+		}
+		scripts[i] = s
+	}
+
+	a.config.Scripts = scripts
+	if err := a.config.Save(); err != nil {
+		return err
+	}
+	a.hotkeyManager.UnregisterAll()
+	a.registerHotkeysFromConfig()
+	return nil
+}
+
+// notify shows a tray balloon when notifications are enabled
+func (a *App) notify(message string) {
+	if a.trayIcon != nil && a.config.Startup.ShowNotification {
+		a.trayIcon.ShowBalloon("WinShot", message)
+	}
+}
+
+// scriptHost carries out script actions with the same code paths as hotkey
+// pipelines
+type scriptHost struct {
+	a *App
+}
+
+func (h scriptHost) Capture(target string) (image.Image, error) {
+	return h.a.captureTarget(target)
+}
+
+func (h scriptHost) Save(img image.Image, format string) (string, error) {
+	if format == "" {
+		format = h.a.config.Export.DefaultFormat
+	}
+	return h.a.pipelineToFile(img, format, h.a.config.Export.JpegQuality)
+}
+
+func (h scriptHost) Upload(img image.Image, provider, format string) (string, error) {
+	if format == "" {
+		format = h.a.config.Export.DefaultFormat
+	}
+	return h.a.pipelineToUpload(img, format, h.a.config.Export.JpegQuality, provider)
+}
+
+func (h scriptHost) CopyImage(img image.Image) error {
+	return screenshot.SetClipboardImage(imaging.ToRGBA(img))
+}
+
+func (h scriptHost) CopyText(text string) error {
+	return screenshot.SetClipboardText(text)
+}
+
+func (h scriptHost) Notify(message string) {
+	h.a.notify(message)
+}
+
+func (h scriptHost) Log(line string) {
+	runtime.EventsEmit(h.a.ctx, "script:log", line)
+}
+
+// ==================== Scheduled Tasks ====================
+
+// schedulePollInterval is how often the scheduler checks for due tasks. The
+// first check after sleep also catches up on runs slept through.
+const schedulePollInterval = 20 * time.Second
+
+// applySchedule restarts the scheduler with the enabled tasks. Tasks with an
+// invalid schedule are skipped with a warning.
+func (a *App) applySchedule() {
+	if a.scheduleStop != nil {
+		close(a.scheduleStop)
+		a.scheduleStop = nil
+	}
+
+	cfg := a.config.Schedule
+	if !cfg.Enabled {
+		return
+	}
+
+	var tasks []scheduler.Task
+	var sources []config.ScheduledTask
+	for _, t := range cfg.Tasks {
+		if !t.Enabled {
+			continue
+		}
+		cron, err := scheduler.ParseCron(t.Cron)
+		if err != nil {
+			println("Warning: skipping scheduled task", t.Name+":", err.Error())
+			continue
+		}
+		tasks = append(tasks, scheduler.Task{Name: t.Name, Cron: cron, SkipMissed: t.SkipMissed})
+		sources = append(sources, t)
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	a.scheduleStop = stop
+	go func() {
+		sched := scheduler.New(tasks, time.Now())
+		ticker := time.NewTicker(schedulePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				runs := sched.Due(now.Round(0))
+				if len(runs) == 0 {
+					continue
+				}
+				if reason := a.powerPauseReason(); reason != "" {
+					println("Skipping", len(runs), "scheduled task(s):", reason)
+					continue
+				}
+				// Tasks run one at a time so their captures don't overlap
+				for _, run := range runs {
+					a.runScheduledTask(sources[run.Task], run)
+				}
+			}
+		}
+	}()
+}
+
+// runScheduledTask runs a due task and reports it like a hotkey pipeline
+func (a *App) runScheduledTask(task config.ScheduledTask, run scheduler.Run) {
+	if run.Missed {
+		println("Catching up on scheduled task", task.Name, "due", run.At.Format(time.RFC3339))
+	}
+
+	if task.Script != "" {
+		if err := a.RunScript(task.Script); err != nil {
+			a.notify("Scheduled task " + task.Name + " failed: " + err.Error())
+			runtime.EventsEmit(a.ctx, "script:error", task.Script, err.Error())
+		}
+		return
+	}
+
+	pipeline := task.Pipeline()
+	result := PipelineResult{Action: "schedule:" + task.Name, Destination: pipeline.Destination}
+	target := task.Capture
+	if target == "" {
+		target = "fullscreen"
+	}
+	img, err := a.captureTarget(target)
+	if err != nil {
+		a.finishPipeline(result, err)
+		return
+	}
+	if img == nil {
+		return // Region selection cancelled
+	}
+	a.deliverPipeline(img, pipeline, result)
+}
+
+// GetSchedule returns the scheduled task settings
+func (a *App) GetSchedule() config.ScheduleConfig {
+	return a.config.Schedule
+}
+
+// SetSchedule validates and saves the scheduled tasks and restarts the
+// scheduler
+func (a *App) SetSchedule(cfg config.ScheduleConfig) error {
+	seen := make(map[string]bool)
+	for i, t := range cfg.Tasks {
+		t.Name = strings.TrimSpace(t.Name)
+		if t.Name == "" {
+			return fmt.Errorf("task %d has no name", i+1)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate task name %q", t.Name)
+		}
+		seen[t.Name] = true
+		if _, err := scheduler.ParseCron(t.Cron); err != nil {
+			return fmt.Errorf("task %q: %w", t.Name, err)
+		}
+		// Region selection would wait for a user who may not be there
+		switch n, isDisplay := strings.CutPrefix(t.Capture, "display:"); {
+		case t.Capture == "", t.Capture == "fullscreen", t.Capture == "window":
+		case isDisplay:
+			if _, err := strconv.Atoi(n); err != nil {
+				return fmt.Errorf("task %q: invalid display %q", t.Name, n)
+			}
+		default:
+			return fmt.Errorf("task %q: unknown capture %q", t.Name, t.Capture)
+		}
+		if t.Script != "" && !slices.ContainsFunc(a.config.Scripts, func(s config.ScriptConfig) bool { return s.Name == t.Script }) {
+			return fmt.Errorf("task %q: no script named %q", t.Name, t.Script)
+		}
+		cfg.Tasks[i] = t
+	}
+
+	a.config.Schedule = cfg
+	a.applySchedule()
+	return a.config.Save()
+}
+
+// ==================== Browser URL ====================
+
+// browserURLTimeout bounds the UI Automation lookup, which blocks while the
+// browser is busy
+const browserURLTimeout = time.Second
+
+// urlStampFilter names the post-capture filter that draws the URL
+const urlStampFilter = "builtin:url-stamp"
+
+// foregroundBrowserURL returns the address of the foreground browser tab, or
+// "" when the foreground window isn't a known browser or doesn't answer in
+// time
+func foregroundBrowserURL() string {
+	hwnd := winEnum.GetForegroundWindow()
+	if hwnd == 0 || !winEnum.IsBrowser(winEnum.ProcessImageName(winEnum.WindowProcessID(hwnd))) {
+		return ""
+	}
+
+	result := make(chan string, 1)
+	go func() {
+		url, err := winEnum.BrowserURL(hwnd)
+		if err != nil {
+			println("Warning: failed to read browser URL:", err.Error())
+		}
+		result <- url
+	}()
+	select {
+	case url := <-result:
+		return url
+	case <-time.After(browserURLTimeout):
+		return ""
+	}
+}
+
+// applyURLStamp adds or removes the filter that draws the browser URL along
+// the bottom of captures
+func (a *App) applyURLStamp() {
+	if !a.config.Browser.StampURL {
+		screenshot.PostCapture.Unregister(urlStampFilter)
+		return
+	}
+	screenshot.PostCapture.Register(urlStampFilter, func(img *image.RGBA) (*image.RGBA, error) {
+		url := a.captureInfo().url
+		if url == "" {
+			return img, nil
+		}
+		pt := image.Pt(img.Bounds().Min.X, img.Bounds().Max.Y-imaging.LabelSize(url).Y)
+		imaging.DrawLabel(img, url, pt, color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 176})
+		return img, nil
+	})
+}
+
+// GetBrowserSettings returns the browser URL settings
+func (a *App) GetBrowserSettings() config.BrowserConfig {
+	return a.config.Browser
+}
+
+// SetBrowserSettings saves the browser URL settings
+func (a *App) SetBrowserSettings(cfg config.BrowserConfig) error {
+	a.config.Browser = cfg
+	a.applyURLStamp()
+	return a.config.Save()
+}
+
+// ==================== Annotation Projects ====================
+
+// SaveProject saves the capture and its annotations as a .wshot project
+// using a save dialog, so the annotations can be rendered again later
+func (a *App) SaveProject(imageData string, annotations []project.Annotation) SaveImageResult {
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save Annotation Project",
+		DefaultFilename: "screenshot" + project.Extension,
+		Filters:         []runtime.FileFilter{{DisplayName: "WinShot Project", Pattern: "*" + project.Extension}},
+	})
+	if err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
+	}
+	if filePath == "" {
+		return SaveImageResult{Success: false, Error: "No file selected"}
+	}
+	if filepath.Ext(filePath) == "" {
+		filePath += project.Extension
+	}
+
+	p := &project.Project{Version: project.Version, Image: imageData, Annotations: annotations}
+	if err := p.Save(filePath); err != nil {
+		return SaveImageResult{Success: false, Error: "Failed to save project: " + err.Error()}
+	}
+	return SaveImageResult{Success: true, FilePath: filePath}
+}
+
+// RenderProject renders a .wshot project to outPath without opening it in the
+// editor. The format follows the extension of outPath: .png, .svg or .pdf.
+func (a *App) RenderProject(projectPath, outPath string) SaveImageResult {
+	if err := renderProjectFile(projectPath, outPath, ""); err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
+	}
+	return SaveImageResult{Success: true, FilePath: outPath}
 }
 
-// StartGDriveAuth initiates OAuth flow and returns auth URL
-func (a *App) StartGDriveAuth() (string, error) {
-	url, err := a.gdriveUploader.StartAuth()
+// renderProjectFile renders a project to outPath. An empty format is taken
+// from the extension of outPath, falling back to PNG.
+func renderProjectFile(projectPath, outPath, format string) error {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outPath)), ".")
+	}
+	if format == "" {
+		format = "png"
+	}
+	p, err := project.Load(projectPath)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := p.Export(&buf, format); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, buf.Bytes(), 0644)
+}
+
+// ==================== Composition Templates ====================
+
+// ComposeTemplate fills a layout template with fresh captures and saves the
+// finished image to the quick save folder. Variables fill {{name}}
+// placeholders in the template.
+func (a *App) ComposeTemplate(templatePath string, vars map[string]string) SaveImageResult {
+	tpl, err := layout.Load(templatePath)
+	if err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
+	}
+
+	restore := a.hideForCapture()
+	img, err := tpl.Render(layout.Options{Vars: vars, Capture: captureSource})
+	restore()
+	if err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
+	}
+
+	data, err := encodeImageData(img, "png", 0)
+	if err != nil {
+		return SaveImageResult{Success: false, Error: "Failed to encode image: " + err.Error()}
+	}
+	filePath, err := a.quickSavePath("png")
+	if err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
+	}
+	if err := a.writeHistoryFile(filePath, data); err != nil {
+		return SaveImageResult{Success: false, Error: "Failed to save file: " + err.Error()}
+	}
+	a.writeManifest(filePath, data)
+	return SaveImageResult{Success: true, FilePath: filePath}
+}
+
+// ==================== Video Frames ====================
+
+// Frame stepping defaults
+const (
+	defaultStepKey   = "."
+	defaultStepDelay = 250 * time.Millisecond
+	maxVideoFrames   = 1000
+)
+
+// errFrameStuck is returned when stepping the player no longer changes the
+// picture, usually because the video ended or the player missed the key
+var errFrameStuck = errors.New("the picture did not change after stepping, is the video paused and its player focused?")
+
+// CaptureVideoFrames captures count consecutive frames of a paused video.
+// Frames are read from the window with PrintWindow, so the overlay never
+// covers them and hardware-accelerated players are captured losslessly.
+// Between frames the configured step key is sent to the player. Frames are
+// saved as PNG into a new folder inside the quick save folder, whose path
+// is returned.
+func (a *App) CaptureVideoFrames(hwnd int, count int) (string, error) {
+	saveDir, err := a.quickSaveDir()
 	if err != nil {
 		return "", err
 	}
+	dir := filepath.Join(saveDir, "frames_"+time.Now().Format("2006-01-02_15-04-05"))
+	n, err := captureVideoFrames(uintptr(hwnd), count, a.config.Video, dir)
+	if n > 0 {
+		a.notify(fmt.Sprintf("Captured %d frames", n))
+	}
+	return dir, err
+}
 
-	// Open browser
-	runtime.BrowserOpenURL(a.ctx, url)
+// captureVideoFrames captures count frames of the video in hwnd into dir,
+// stepping the player between frames. Returns how many frames were saved.
+func captureVideoFrames(hwnd uintptr, count int, cfg config.VideoConfig, dir string) (int, error) {
+	if count < 1 || count > maxVideoFrames {
+		return 0, fmt.Errorf("frame count must be between 1 and %d", maxVideoFrames)
+	}
+	stepKey := cfg.StepKey
+	if stepKey == "" {
+		stepKey = defaultStepKey
+	}
+	mods, key, ok := hotkeys.ParseHotkeyString(stepKey)
+	if !ok {
+		return 0, fmt.Errorf("invalid step key: %s", stepKey)
+	}
+	delay := defaultStepDelay
+	if cfg.StepDelayMs > 0 {
+		delay = time.Duration(cfg.StepDelayMs) * time.Millisecond
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
 
-	// Wait for callback in background
-	go func() {
-		if err := a.gdriveUploader.WaitForAuth(); err != nil {
-			runtime.EventsEmit(a.ctx, "gdrive:auth:error", err.Error())
-		} else {
-			runtime.EventsEmit(a.ctx, "gdrive:auth:success")
+	var prev *image.RGBA
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			if err := winEnum.SendKey(hwnd, mods, key); err != nil {
+				return i, err
+			}
+		}
+		frame, err := nextFrame(hwnd, prev, delay)
+		if err != nil {
+			return i, err
 		}
-	}()
 
-	return url, nil
+		data, err := encodeImageData(frame, "png", 0)
+		if err != nil {
+			return i, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("frame_%04d.png", i+1)), data, 0644); err != nil {
+			return i, err
+		}
+		prev = frame
+	}
+	return count, nil
 }
 
-// GDriveStatus represents the Google Drive connection status
-type GDriveStatus struct {
-	Connected bool   `json:"connected"`
-	Email     string `json:"email,omitempty"`
+// nextFrame waits for the player to show a picture different from prev and
+// captures it. Players take a moment to decode the next frame, so it checks
+// again a few times before giving up.
+func nextFrame(hwnd uintptr, prev *image.RGBA, delay time.Duration) (*image.RGBA, error) {
+	if prev == nil {
+		return winEnum.CaptureWindowContent(hwnd)
+	}
+	for attempt := 0; attempt < 4; attempt++ {
+		time.Sleep(delay)
+		frame, err := winEnum.CaptureWindowContent(hwnd)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(frame.Pix, prev.Pix) {
+			return frame, nil
+		}
+	}
+	return nil, errFrameStuck
 }
 
-// IsGDriveConnected checks if Google Drive is connected and returns user email
-func (a *App) IsGDriveConnected() (bool, string, error) {
-	return a.gdriveUploader.IsConnected()
+// ==================== Burst ====================
+
+// burstIndex is the frames.json written into a burst archive, so frames can
+// be lined up with each other and with recordings stamped by the same clock
+type burstIndex struct {
+	X          int               `json:"x"`
+	Y          int               `json:"y"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	IntervalMs int64             `json:"intervalMs"` // 0 when frames were captured back to back
+	Best       string            `json:"best"`       // File of the sharpest settled frame
+	Frames     []burstIndexFrame `json:"frames"`
 }
 
-// GetGDriveStatus returns Google Drive connection status with email
-func (a *App) GetGDriveStatus() (*GDriveStatus, error) {
-	connected, email, err := a.gdriveUploader.IsConnected()
+// burstIndexFrame is one frame in a burstIndex
+type burstIndexFrame struct {
+	File     string  `json:"file"`
+	OffsetMs float64 `json:"offsetMs"` // Since the first frame was read
+	provenance.FrameClock
+	imaging.FrameScore
+}
+
+// CaptureBurst captures count frames of a region of the virtual screen, back
+// to back when intervalMs is 0 or one every intervalMs otherwise, to catch
+// flicker and rendering glitches a single shot misses. Frames are saved as
+// PNGs in a zip archive in the quick save folder, whose path is returned.
+// Frames captured before a failure are still saved
+func (a *App) CaptureBurst(x, y, width, height, count, intervalMs int) (string, error) {
+	saveDir, err := a.quickSaveDir()
 	if err != nil {
-		return &GDriveStatus{Connected: false}, err
+		return "", err
 	}
-	return &GDriveStatus{Connected: connected, Email: email}, nil
+	r := image.Rect(x, y, x+width, y+height)
+	interval := time.Duration(intervalMs) * time.Millisecond
+	frames, err := screenshot.CaptureBurst(r, count, interval, screenshot.CaptureOptions{Context: a.ctx})
+	if len(frames) == 0 {
+		return "", err
+	}
+
+	path := filepath.Join(saveDir, "burst_"+time.Now().Format("2006-01-02_15-04-05")+".zip")
+	if werr := writeBurstZip(path, r, interval, frames); werr != nil {
+		return "", werr
+	}
+	a.notify(fmt.Sprintf("Captured %d frames", len(frames)))
+	return path, err
 }
 
-// DisconnectGDrive removes Google Drive authorization
-func (a *App) DisconnectGDrive() error {
-	return a.gdriveUploader.Disconnect()
+// CaptureBestFrame captures a burst of count frames of a region like
+// CaptureBurst and returns only the best one: the sharpest frame that isn't
+// caught mid-animation, for capturing animated UIs without a half-drawn
+// tooltip or a fading menu
+func (a *App) CaptureBestFrame(x, y, width, height, count, intervalMs int) (*screenshot.CaptureResult, error) {
+	r := image.Rect(x, y, x+width, y+height)
+	frames, err := screenshot.CaptureBurst(r, count, time.Duration(intervalMs)*time.Millisecond, screenshot.CaptureOptions{Context: a.ctx})
+	if err != nil {
+		return nil, err
+	}
+	a.recordCapture(screenshot.GetMonitorAtPoint(x+width/2, y+height/2))
+	best := frames[imaging.BestFrame(imaging.ScoreFrames(burstImages(frames)))]
+	return screenshot.Encode(best.Image)
 }
 
-// UploadToGDrive uploads image to Google Drive
-func (a *App) UploadToGDrive(imageData, filename string) (*upload.UploadResult, error) {
-	data, err := base64.StdEncoding.DecodeString(imageData)
+// CaptureComposite captures a burst of count frames of a region like
+// CaptureBurst and blends them into one image: imaging.BlendAverage for a
+// noise-free still of noisy video, or imaging.BlendMax for the motion trails
+// of an animation
+func (a *App) CaptureComposite(x, y, width, height, count, intervalMs int, mode string) (*screenshot.CaptureResult, error) {
+	r := image.Rect(x, y, x+width, y+height)
+	frames, err := screenshot.CaptureBurst(r, count, time.Duration(intervalMs)*time.Millisecond, screenshot.CaptureOptions{Context: a.ctx})
 	if err != nil {
-		return &upload.UploadResult{Success: false, Error: "invalid image data"}, err
+		return nil, err
+	}
+	a.recordCapture(screenshot.GetMonitorAtPoint(x+width/2, y+height/2))
+	img, err := imaging.BlendFrames(burstImages(frames), mode)
+	if err != nil {
+		return nil, err
 	}
-	return a.gdriveUploader.Upload(context.Background(), data, filename)
+	return screenshot.Encode(img)
 }
 
-// ClearGDriveCredentials removes all GDrive credentials from Windows Credential Manager
-func (a *App) ClearGDriveCredentials() error {
-	a.credManager.Delete(upload.CredGDriveClientID)
-	a.credManager.Delete(upload.CredGDriveClientSecret)
-	a.credManager.Delete(upload.CredGDriveToken)
-	return nil
+// burstImages returns the images of burst frames
+func burstImages(frames []screenshot.BurstFrame) []image.Image {
+	imgs := make([]image.Image, len(frames))
+	for i, f := range frames {
+		imgs[i] = f.Image
+	}
+	return imgs
 }
 
-// ==================== Screenshot Library ====================
+// writeBurstZip saves burst frames of r, their timing and scores into a zip
+// archive
+func writeBurstZip(path string, r image.Rectangle, interval time.Duration, frames []screenshot.BurstFrame) error {
+	index := burstIndex{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy(), IntervalMs: interval.Milliseconds()}
+	imgs := burstImages(frames)
+	scores := imaging.ScoreFrames(imgs)
+	index.Best = imaging.BurstFrameName(imaging.BestFrame(scores))
+	for i, f := range frames {
+		entry := burstIndexFrame{
+			File:       imaging.BurstFrameName(i),
+			FrameClock: provenance.FrameClock{QPC: f.Time.Start, QPCEnd: f.Time.End, QPCFrequency: f.Time.Frequency},
+			FrameScore: scores[i],
+		}
+		if f.Time.Frequency > 0 {
+			entry.OffsetMs = float64(f.Time.Start-frames[0].Time.Start) * 1000 / float64(f.Time.Frequency)
+		}
+		index.Frames = append(index.Frames, entry)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
 
-// GetLibraryImages returns all screenshots from QuickSave folder
-func (a *App) GetLibraryImages() ([]library.LibraryImage, error) {
-	folder := a.config.QuickSave.Folder
-	if folder == "" {
-		// Fallback to default location
-		homeDir, err := os.UserHomeDir()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := imaging.ZipFrames(f, imgs, data, time.Now()); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// CaptureBurstAPNG captures a burst of count frames of a region like
+// CaptureBurst and saves it as an animated PNG in the quick save folder,
+// returning its path. Each frame shows for delayMs, or for as long as it
+// actually was on screen when delayMs is 0. Frames captured before a failure
+// are still saved
+func (a *App) CaptureBurstAPNG(x, y, width, height, count, intervalMs, delayMs int) (string, error) {
+	saveDir, err := a.quickSaveDir()
+	if err != nil {
+		return "", err
+	}
+	r := image.Rect(x, y, x+width, y+height)
+	frames, err := screenshot.CaptureBurst(r, count, time.Duration(intervalMs)*time.Millisecond, screenshot.CaptureOptions{Context: a.ctx})
+	if len(frames) == 0 {
+		return "", err
+	}
+
+	path := filepath.Join(saveDir, "burst_"+time.Now().Format("2006-01-02_15-04-05")+".png")
+	if werr := writeBurstAPNG(path, frames, burstAPNGOptions(frames, time.Duration(delayMs)*time.Millisecond)); werr != nil {
+		return "", werr
+	}
+	a.notify(fmt.Sprintf("Saved %d frames as an animated PNG", len(frames)))
+	return path, err
+}
+
+// burstAPNGOptions returns the APNG timing of burst frames: delay for every
+// frame when set, otherwise the time between the frames' screen reads so the
+// animation plays at the speed it was captured
+func burstAPNGOptions(frames []screenshot.BurstFrame, delay time.Duration) imaging.APNGOptions {
+	opts := imaging.APNGOptions{Delay: delay}
+	if delay > 0 || len(frames) < 2 || frames[0].Time.Frequency <= 0 {
+		return opts
+	}
+	elapsed := func(i, j int) time.Duration {
+		return time.Duration(float64(frames[j].Time.Start-frames[i].Time.Start) / float64(frames[i].Time.Frequency) * float64(time.Second))
+	}
+	opts.Delays = make([]time.Duration, len(frames))
+	for i := 0; i < len(frames)-1; i++ {
+		opts.Delays[i] = elapsed(i, i+1)
+	}
+	// The last frame has no successor, so it shows for the average
+	opts.Delays[len(frames)-1] = elapsed(0, len(frames)-1) / time.Duration(len(frames)-1)
+	return opts
+}
+
+// writeBurstAPNG saves burst frames as an animated PNG after the capture
+// filters
+func writeBurstAPNG(path string, frames []screenshot.BurstFrame, opts imaging.APNGOptions) error {
+	imgs := make([]image.Image, len(frames))
+	for i, f := range frames {
+		img, err := screenshot.ApplyFilters(f.Image)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+			return err
 		}
-		folder = filepath.Join(homeDir, "Pictures", "WinShot")
+		imgs[i] = img
 	}
-
-	opts := library.DefaultScanOptions()
-	return library.ScanFolder(folder, opts)
+	var buf bytes.Buffer
+	if err := imaging.EncodeAPNG(&buf, imgs, opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
 }
 
-// OpenInEditor loads an image file into the editor
-// Security: validates path is within QuickSave folder
-func (a *App) OpenInEditor(imagePath string) (*screenshot.CaptureResult, error) {
-	// Validate path is within QuickSave folder (prevent directory traversal)
-	folder := a.config.QuickSave.Folder
-	if folder == "" {
-		homeDir, _ := os.UserHomeDir()
-		folder = filepath.Join(homeDir, "Pictures", "WinShot")
+// ==================== Anchored Regions ====================
+
+// ListAnchors returns the saved regions anchored to app windows
+func (a *App) ListAnchors() []config.AnchorConfig {
+	if a.config.Capture.Anchors == nil {
+		return []config.AnchorConfig{}
 	}
+	return a.config.Capture.Anchors
+}
 
-	absPath, err := filepath.Abs(imagePath)
+// CreateAnchor saves the screen region x, y, width, height as an anchor
+// named name in the window under its center. Offsets are kept from the
+// nearest corner of the window's client area, so CaptureAnchor finds the same
+// part of the app after the window is moved or resized
+func (a *App) CreateAnchor(name string, x, y, width, height int) (config.AnchorConfig, error) {
+	anchor, err := anchorAt(name, image.Rect(x, y, x+width, y+height))
 	if err != nil {
-		return nil, fmt.Errorf("invalid path: %w", err)
+		return config.AnchorConfig{}, err
 	}
+	a.config.Capture.SetAnchor(anchor)
+	return anchor, a.config.Save()
+}
 
-	absFolder, err := filepath.Abs(folder)
-	if err != nil {
-		return nil, fmt.Errorf("invalid folder path: %w", err)
+// SaveAnchor adds or replaces an anchor, such as one edited by hand
+func (a *App) SaveAnchor(anchor config.AnchorConfig) error {
+	if err := anchor.Validate(); err != nil {
+		return err
 	}
+	a.config.Capture.SetAnchor(anchor)
+	return a.config.Save()
+}
 
-	// Security check: ensure file is within QuickSave folder
-	if !strings.HasPrefix(absPath, absFolder+string(filepath.Separator)) {
-		return nil, fmt.Errorf("access denied: file outside QuickSave folder")
+// DeleteAnchor removes an anchor
+func (a *App) DeleteAnchor(name string) error {
+	if !a.config.Capture.DeleteAnchor(name) {
+		return fmt.Errorf("no anchor named %q", name)
 	}
+	return a.config.Save()
+}
 
-	// Read file
-	data, err := os.ReadFile(absPath)
+// CaptureAnchor captures an anchored region where its window is now
+func (a *App) CaptureAnchor(name string) (*screenshot.CaptureResult, error) {
+	anchor, ok := a.config.Capture.Anchor(name)
+	if !ok {
+		return nil, fmt.Errorf("no anchor named %q", name)
+	}
+	r, err := anchorRect(anchor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, err
 	}
+	center := r.Min.Add(r.Size().Div(2))
+	a.recordCapture(screenshot.GetMonitorAtPoint(center.X, center.Y))
+	return screenshot.CaptureRect(r, screenshot.CaptureOptions{Clamp: true})
+}
 
-	// Decode image
-	var img image.Image
-	ext := strings.ToLower(filepath.Ext(absPath))
+// ==================== Power ====================
 
-	switch ext {
-	case ".png":
-		img, err = png.Decode(bytes.NewReader(data))
-	case ".jpg", ".jpeg":
-		img, err = jpeg.Decode(bytes.NewReader(data))
-	default:
-		img, _, err = image.Decode(bytes.NewReader(data))
+// powerPollInterval is how often deferred work checks whether it may run
+const powerPollInterval = time.Minute
+
+// powerPauseReason returns why background work should wait for the user to
+// come back or for power, or "" if it can run now. Readings that fail count
+// as active and on AC power
+func (a *App) powerPauseReason() string {
+	cfg := a.config.Power
+	if cfg == (config.PowerConfig{}) {
+		return ""
 	}
+	idle, err := winEnum.IdleTime()
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
+		idle = 0
 	}
+	status, err := winEnum.GetPowerStatus()
+	if err != nil {
+		status = winEnum.PowerStatus{BatteryPercent: -1}
+	}
+	return cfg.PauseReason(idle, status.OnBattery, status.BatterySaver, status.BatteryPercent)
+}
 
-	bounds := img.Bounds()
-
-	// Re-encode as PNG for consistent handling in frontend
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, img); err != nil {
-		return nil, fmt.Errorf("failed to encode image: %w", err)
+// waitForPower blocks while powerPauseReason asks background work to wait
+func (a *App) waitForPower(what string) {
+	reason := a.powerPauseReason()
+	if reason == "" {
+		return
 	}
+	println("Deferring", what+":", reason)
+	for a.powerPauseReason() != "" {
+		time.Sleep(powerPollInterval)
+	}
+}
 
-	return &screenshot.CaptureResult{
-		Width:  bounds.Dx(),
-		Height: bounds.Dy(),
-		Data:   base64.StdEncoding.EncodeToString(buf.Bytes()),
-	}, nil
+// GetPowerSettings returns the idle and battery settings
+func (a *App) GetPowerSettings() config.PowerConfig {
+	return a.config.Power
 }
 
-// DeleteScreenshot removes a screenshot file from disk
-// Security: validates path is within QuickSave folder
-func (a *App) DeleteScreenshot(imagePath string) error {
-	// Validate path is within QuickSave folder (prevent directory traversal)
-	folder := a.config.QuickSave.Folder
-	if folder == "" {
-		homeDir, _ := os.UserHomeDir()
-		folder = filepath.Join(homeDir, "Pictures", "WinShot")
+// SetPowerSettings saves the idle and battery settings. They apply from the
+// next scheduled task or upload
+func (a *App) SetPowerSettings(cfg config.PowerConfig) error {
+	if cfg.IdleMinutes < 0 {
+		return errors.New("idle minutes cannot be negative")
+	}
+	if cfg.BatteryPercent < 0 || cfg.BatteryPercent > 100 {
+		return errors.New("battery percent must be between 0 and 100")
 	}
+	a.config.Power = cfg
+	return a.config.Save()
+}
 
-	absPath, err := filepath.Abs(imagePath)
-	if err != nil {
-		return fmt.Errorf("invalid path: %w", err)
+// GetVideoSettings returns the frame stepping settings
+func (a *App) GetVideoSettings() config.VideoConfig {
+	return a.config.Video
+}
+
+// SetVideoSettings saves the frame stepping settings
+func (a *App) SetVideoSettings(cfg config.VideoConfig) error {
+	if cfg.StepKey != "" {
+		if _, _, ok := hotkeys.ParseHotkeyString(cfg.StepKey); !ok {
+			return fmt.Errorf("invalid step key: %s", cfg.StepKey)
+		}
+	}
+	if cfg.StepDelayMs < 0 {
+		return errors.New("step delay cannot be negative")
 	}
+	a.config.Video = cfg
+	return a.config.Save()
+}
 
-	absFolder, err := filepath.Abs(folder)
+// ==================== Diagnostics ====================
+
+// CreateDiagnosticsBundle writes a zip for bug reports to the quick save
+// folder: machine report, redacted config, recent errors and logs
+func (a *App) CreateDiagnosticsBundle() SaveImageResult {
+	dir, err := a.quickSaveDir()
 	if err != nil {
-		return fmt.Errorf("invalid folder path: %w", err)
+		return SaveImageResult{Success: false, Error: err.Error()}
 	}
-
-	// Security check: ensure file is within QuickSave folder
-	if !strings.HasPrefix(absPath, absFolder+string(filepath.Separator)) {
-		return fmt.Errorf("access denied: file outside QuickSave folder")
+	path := filepath.Join(dir, diagBundleName(time.Now()))
+	if err := saveDiagBundle(path); err != nil {
+		return SaveImageResult{Success: false, Error: err.Error()}
 	}
-
-	return os.Remove(absPath)
+	return SaveImageResult{Success: true, FilePath: path}
 }
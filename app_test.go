@@ -1,8 +1,14 @@
 package main
 
 import (
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	"winshot/internal/config"
+	"winshot/internal/library"
+	"winshot/internal/screenshot"
+	"winshot/internal/server"
 )
 
 // TestAppInitialization verifies App struct is created properly
@@ -244,3 +250,167 @@ func TestCapturingStateTracking(t *testing.T) {
 		t.Error("isCapturing should be false after clearing")
 	}
 }
+
+// TestSafeModeConfig verifies safe mode keeps a single hotkey and stays offline
+func TestSafeModeConfig(t *testing.T) {
+	cfg := safeModeConfig()
+	if cfg.Hotkeys.Fullscreen == "" {
+		t.Error("safe mode should keep the fullscreen hotkey")
+	}
+	if cfg.Hotkeys.Region != "" || cfg.Hotkeys.Window != "" {
+		t.Errorf("safe mode hotkeys = %+v, want fullscreen only", cfg.Hotkeys)
+	}
+	if cfg.Update.CheckOnStartup {
+		t.Error("safe mode should not check for updates")
+	}
+	if len(cfg.Plugins) > 0 || len(cfg.Filters) > 0 || len(cfg.Scripts) > 0 || cfg.Triggers.Enabled {
+		t.Error("safe mode config should have no integrations")
+	}
+}
+
+// TestSafeModeReadOnly verifies safe mode can't overwrite the saved config
+func TestSafeModeReadOnly(t *testing.T) {
+	config.SetReadOnly(true)
+	defer config.SetReadOnly(false)
+	if err := safeModeConfig().Save(); err != config.ErrReadOnly {
+		t.Errorf("Save() in safe mode = %v, want ErrReadOnly", err)
+	}
+}
+
+// TestCaptureSourceName verifies quick save filenames name the page host or app
+func TestCaptureSourceName(t *testing.T) {
+	tests := []struct {
+		url, app string
+		want     string
+	}{
+		{"https://www.Example.com/a?b=c", "chrome.exe", "example.com"},
+		{"", "Code.exe", "code"},
+		{"not a url", "Microsoft Teams.exe", "microsoft-teams"},
+		{"", "", ""},
+		{"", "..exe", ""},
+	}
+	for _, tt := range tests {
+		if got := captureSourceName(tt.url, tt.app); got != tt.want {
+			t.Errorf("captureSourceName(%q, %q) = %q, want %q", tt.url, tt.app, got, tt.want)
+		}
+	}
+}
+
+// TestRecordPaste verifies pasting replaces the previous capture's context
+func TestRecordPaste(t *testing.T) {
+	app := NewApp()
+	app.setCaptureInfo(captureInfo{at: time.Now(), url: "https://old.example"})
+	app.recordPaste(&screenshot.ClipboardMetadata{SourceApp: "firefox.exe"})
+	if c := app.captureInfo(); c.url != "" || c.sourceApp() != "firefox.exe" || !c.at.IsZero() {
+		t.Errorf("after paste url = %q, app = %q, at = %v", c.url, c.sourceApp(), c.at)
+	}
+	app.recordPaste(nil)
+	if c := app.captureInfo(); c.sourceApp() != "" {
+		t.Errorf("after paste without metadata app = %q, want empty", c.sourceApp())
+	}
+}
+
+// TestCaptureInfoRace verifies concurrent captures and pastes never leave
+// half of one context and half of another. Run it with -race
+func TestCaptureInfoRace(t *testing.T) {
+	app := NewApp()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				app.setCaptureInfo(captureInfo{at: time.Now(), display: 1, url: "https://capture.example"})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				app.recordPaste(&screenshot.ClipboardMetadata{SourceURL: "https://paste.example", SourceApp: "firefox.exe"})
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		c := app.captureInfo()
+		switch c.url {
+		case "https://capture.example":
+			if c.at.IsZero() || c.display != 1 || c.app != "" {
+				t.Fatalf("torn capture context: %+v", c)
+			}
+		case "https://paste.example":
+			if !c.at.IsZero() || c.display != 0 || c.app != "firefox.exe" {
+				t.Fatalf("torn paste context: %+v", c)
+			}
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+// TestNoteTitle verifies notes are titled after the captured window
+func TestNoteTitle(t *testing.T) {
+	taken := time.Date(2024, 1, 15, 14, 30, 45, 0, time.Local)
+	windows := []library.WindowRecord{
+		{Title: "Inbox - Mail"},
+		{Title: "Pull request #12 - Chrome", Foreground: true},
+	}
+	if got := noteTitle(windows, taken); got != "Pull request #12 - Chrome" {
+		t.Errorf("noteTitle = %q, want the foreground window", got)
+	}
+	if got := noteTitle([]library.WindowRecord{{Title: " ", Foreground: true}}, taken); got != "Screenshot 2024-01-15 14:30" {
+		t.Errorf("noteTitle without a title = %q, want a dated title", got)
+	}
+}
+
+// TestUploaderForUnknownProvider verifies a mistyped provider is an error
+// rather than a silent upload to R2
+func TestUploaderForUnknownProvider(t *testing.T) {
+	app := NewApp()
+	for _, provider := range []string{"dropbox", "plugin:missing"} {
+		if _, err := app.uploaderFor(provider); err == nil || !strings.Contains(err.Error(), "unknown upload provider") {
+			t.Errorf("uploaderFor(%s) error = %v, want unknown upload provider", provider, err)
+		}
+	}
+}
+
+// TestLocalShareServerOnce verifies concurrent shares start one server
+func TestLocalShareServerOnce(t *testing.T) {
+	app := NewApp()
+	servers := make([]*server.Server, 8)
+	var wg sync.WaitGroup
+	for i := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			servers[i] = app.localShareServer(0)
+		}()
+	}
+	wg.Wait()
+	for _, s := range servers[1:] {
+		if s != servers[0] {
+			t.Fatal("concurrent shares created more than one server")
+		}
+	}
+}
+
+// TestImageContentType verifies shared images are served with their own type
+func TestImageContentType(t *testing.T) {
+	for format, want := range map[string]string{
+		"png":  "image/png",
+		"jpeg": "image/jpeg",
+		"webp": "image/webp",
+		"avif": "image/avif",
+	} {
+		if got := imageContentType(format); got != want {
+			t.Errorf("imageContentType(%s) = %s, want %s", format, got, want)
+		}
+	}
+}
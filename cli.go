@@ -0,0 +1,1507 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"winshot/internal/annotate"
+	"winshot/internal/config"
+	"winshot/internal/hotkeys"
+	"winshot/internal/imaging"
+	"winshot/internal/layout"
+	"winshot/internal/library"
+	"winshot/internal/ocr"
+	"winshot/internal/provenance"
+	"winshot/internal/screenshot"
+	"winshot/internal/scrollcapture"
+	"winshot/internal/upload"
+	winEnum "winshot/internal/windows"
+)
+
+var (
+	kernel32CLI       = windows.NewLazySystemDLL("kernel32.dll")
+	procAttachConsole = kernel32CLI.NewProc("AttachConsole")
+)
+
+// attachParentProcess is ATTACH_PARENT_PROCESS ((DWORD)-1)
+const attachParentProcess = ^uint32(0)
+
+// runCLI handles command-line invocations such as "winshot diag soak".
+// Returns handled=false when args hold no CLI command and the GUI should start.
+func runCLI(args []string) (handled bool, exitCode int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	if strings.HasPrefix(strings.ToLower(args[0]), protocolScheme) {
+		cmdArgs, err := protocolArgs(args[0])
+		if err != nil {
+			attachConsole()
+			fmt.Fprintln(os.Stderr, "Error:", err.Error())
+			return true, 2
+		}
+		if !confirmLinkCapture(cmdArgs) {
+			return true, 1
+		}
+		return true, runCaptureCommand(cmdArgs[1:], os.Stdout, os.Stderr)
+	}
+
+	switch args[0] {
+	case "--uninstall-cleanup":
+		attachConsole()
+		return true, runUninstallCleanup(args[1:], os.Stdout, os.Stderr)
+	case "diag":
+		attachConsole()
+		return true, runDiagCommand(args[1:], os.Stdout, os.Stderr)
+	case "history":
+		attachConsole()
+		return true, runHistoryCommand(args[1:], os.Stdout, os.Stderr)
+	case "capture":
+		attachConsole()
+		return true, runCaptureCommand(args[1:], os.Stdout, os.Stderr)
+	case "render":
+		attachConsole()
+		return true, runRenderCommand(args[1:], os.Stdout, os.Stderr)
+	case "compose":
+		attachConsole()
+		return true, runComposeCommand(args[1:], os.Stdout, os.Stderr)
+	case "frames":
+		attachConsole()
+		return true, runFramesCommand(args[1:], os.Stdout, os.Stderr)
+	case "burst":
+		attachConsole()
+		return true, runBurstCommand(args[1:], os.Stdout, os.Stderr)
+	case "anchor":
+		attachConsole()
+		return true, runAnchorCommand(args[1:], os.Stdout, os.Stderr)
+	case "refresh":
+		attachConsole()
+		return true, runRefreshCommand(args[1:], os.Stdout, os.Stderr)
+	case "decrypt":
+		attachConsole()
+		return true, runDecryptCommand(args[1:], os.Stdout, os.Stderr)
+	}
+	return false, 0
+}
+
+// attachConsole reconnects stdout/stderr to the launching console.
+// WinShot is built as a GUI app, so it has no console of its own.
+func attachConsole() {
+	ret, _, _ := procAttachConsole.Call(uintptr(attachParentProcess))
+	if ret == 0 {
+		return
+	}
+	if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = f
+		os.Stderr = f
+	}
+}
+
+// runDiagCommand dispatches "winshot diag <subcommand>"
+func runDiagCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: winshot diag soak [flags]")
+		fmt.Fprintln(stderr, "       winshot diag bundle [--out <file.zip>]")
+		return 2
+	}
+
+	switch args[0] {
+	case "soak":
+		fs := flag.NewFlagSet("diag soak", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		iterations := fs.Int("iterations", 100, "measured open/capture/close cycles")
+		warmup := fs.Int("warmup", 10, "unmeasured cycles before the baseline sample")
+		withOverlay := fs.Bool("overlay", true, "open and close the selection overlay each cycle")
+		asJSON := fs.Bool("json", false, "print the report as JSON")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		report, err := runSoak(*iterations, *warmup, *withOverlay, func(i int) {
+			if !*asJSON && (i+1)%10 == 0 {
+				fmt.Fprintf(stderr, "  %d/%d\n", i+1, *iterations)
+			}
+		})
+		if report != nil {
+			if *asJSON {
+				enc := json.NewEncoder(stdout)
+				enc.SetIndent("", "  ")
+				enc.Encode(report)
+			} else {
+				fmt.Fprint(stdout, report.String())
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if report.Leaked() {
+			return 1
+		}
+		return 0
+	case "bundle":
+		fs := flag.NewFlagSet("diag bundle", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		out := fs.String("out", "", "zip file to write, a timestamped file in the current folder if empty")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if fs.NArg() > 0 {
+			fmt.Fprintf(stderr, "Error: unexpected argument %q\n", fs.Arg(0))
+			return 2
+		}
+		if *out == "" {
+			*out = diagBundleName(time.Now())
+		}
+		if err := saveDiagBundle(*out); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		fmt.Fprintln(stdout, *out)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown diag command: %s\n", args[0])
+		return 2
+	}
+}
+
+// runHistoryCommand dispatches "winshot history <subcommand>"
+func runHistoryCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: winshot history export --out <dir> [flags]")
+		fmt.Fprintln(stderr, "       winshot history search [flags] <query>")
+		fmt.Fprintln(stderr, "       winshot history vacuum [--dedupe]")
+		return 2
+	}
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("history export", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		since := fs.String("since", "", "only captures newer than an age (30m, 12h, 7d, 2w) or date (2024-01-15)")
+		format := fs.String("format", "", "re-encode as png or jpg, keeps each file's format if empty")
+		quality := fs.Int("quality", 90, "JPEG quality 1-100")
+		maxWidth := fs.Int("max-width", 0, "downscale wider captures to this width")
+		out := fs.String("out", "", "output folder (required)")
+		folder := fs.String("folder", "", "history folder, the quick save folder if empty")
+		asJSON := fs.Bool("json", false, "print the results as JSON")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *out == "" {
+			fmt.Fprintln(stderr, "Error: --out is required")
+			return 2
+		}
+		sinceTime, err := library.ParseSince(*since, time.Now())
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 2
+		}
+
+		if *folder == "" {
+			*folder = historyFolder()
+		}
+		paths, err := library.ListSince(*folder, sinceTime)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+
+		results, err := library.Export(paths, library.ExportOptions{
+			Format:   *format,
+			Quality:  *quality,
+			MaxWidth: *maxWidth,
+			OutDir:   *out,
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+
+		failed := 0
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(results)
+		} else {
+			for _, r := range results {
+				if r.Error != "" {
+					fmt.Fprintf(stdout, "  FAILED %s: %s\n", r.Source, r.Error)
+				} else {
+					fmt.Fprintf(stdout, "  %s -> %s\n", filepath.Base(r.Source), r.Output)
+				}
+			}
+			fmt.Fprintf(stdout, "Exported %d of %d captures to %s\n", len(results)-failed, len(results), *out)
+		}
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	case "search":
+		fs := flag.NewFlagSet("history search", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		folder := fs.String("folder", "", "history folder, the quick save folder if empty")
+		asJSON := fs.Bool("json", false, "print the matches as JSON")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			fmt.Fprintln(stderr, `usage: winshot history search [flags] <query>, e.g. 'app:chrome tag:bug text:"timeout"'`)
+			return 2
+		}
+		q, err := library.ParseQuery(strings.Join(fs.Args(), " "), time.Now())
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 2
+		}
+
+		if *folder == "" {
+			*folder = historyFolder()
+		}
+		docs, err := library.Search(*folder, q)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(docs)
+		} else {
+			for _, d := range docs {
+				fmt.Fprintln(stdout, d.Path)
+			}
+		}
+		return 0
+	case "vacuum":
+		fs := flag.NewFlagSet("history vacuum", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		dedupe := fs.Bool("dedupe", false, "also move plain captures into the store, linking duplicates")
+		folder := fs.String("folder", "", "history folder, the quick save folder if empty")
+		asJSON := fs.Bool("json", false, "print the report as JSON")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		if *folder == "" {
+			*folder = historyFolder()
+		}
+		report, err := library.Vacuum(*folder, *dedupe)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+		} else {
+			fmt.Fprintf(stdout, "Forgot %d deleted captures\n", report.Forgotten)
+			if *dedupe {
+				fmt.Fprintf(stdout, "Deduplicated %d captures, %.1f MB saved\n", report.Deduplicated, float64(report.SavedBytes)/(1<<20))
+			}
+			fmt.Fprintf(stdout, "Removed %d unused objects, %.1f MB freed\n", report.Removed, float64(report.FreedBytes)/(1<<20))
+		}
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown history command: %s\n", args[0])
+		return 2
+	}
+}
+
+// annotation is one --arrow, --highlight or --blur flag. Rectangles hold
+// x,y,w,h and arrows x1,y1,x2,y2, in pixels of the captured image.
+type annotation struct {
+	kind   string
+	coords [4]int
+}
+
+// rect returns the rectangle of a --highlight, --blur or --pixelate annotation
+func (a annotation) rect() image.Rectangle {
+	return image.Rect(a.coords[0], a.coords[1], a.coords[0]+a.coords[2], a.coords[1]+a.coords[3])
+}
+
+// annotationFlag collects a repeatable annotation flag into a list shared by
+// all kinds, so annotations are drawn in command-line order
+type annotationFlag struct {
+	kind string
+	list *[]annotation
+}
+
+func (f annotationFlag) String() string { return "" }
+
+func (f annotationFlag) Set(s string) error {
+	coords, err := parseCoords(s)
+	if err != nil {
+		return err
+	}
+	if f.kind != "arrow" && (coords[2] <= 0 || coords[3] <= 0) {
+		return fmt.Errorf("width and height must be positive in %q", s)
+	}
+	*f.list = append(*f.list, annotation{kind: f.kind, coords: coords})
+	return nil
+}
+
+// parseCoords parses four comma-separated integers such as "100,40,300,20"
+func parseCoords(s string) ([4]int, error) {
+	var coords [4]int
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return coords, fmt.Errorf("want 4 comma-separated numbers, got %q", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return coords, fmt.Errorf("invalid number %q in %q", p, s)
+		}
+		coords[i] = n
+	}
+	return coords, nil
+}
+
+// runCaptureCommand handles "winshot capture", which captures without the
+// GUI and draws annotations at fixed coordinates for use from scripts
+func runCaptureCommand(args []string, stdout, stderr io.Writer) int {
+	var annotations []annotation
+	fs := flag.NewFlagSet("capture", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	windowTitle := fs.String("window", "", "capture the first window whose title contains this text")
+	direct := fs.Bool("direct", false, "with --window, render the window itself so covered windows capture correctly")
+	scroll := fs.Bool("scroll", false, "with --window, scroll the window down and stitch one long capture")
+	deep := fs.Bool("deep", false, "capture 16 bits per channel with DXGI and save a 16-bit PNG")
+	display := fs.Int("display", -1, "capture a display by index")
+	region := fs.String("region", "", "capture x,y,w,h of the virtual screen")
+	anchorName := fs.String("anchor", "", "capture a saved region anchored to an app window, by name")
+	out := fs.String("out", "", "output file, a timestamped file in the quick save folder if empty")
+	format := fs.String("format", "", "png, jpg, webp or avif, from the --out extension if empty")
+	quality := fs.Int("quality", 95, "JPEG, WebP and AVIF quality 1-100")
+	clipboard := fs.Bool("clipboard", false, "copy the result to the clipboard instead of saving it")
+	copyFile := fs.Bool("copy-file", false, "after saving, copy the file to the clipboard for pasting into Explorer or chat apps")
+	caption := fs.String("caption", "", "text for a caption strip below the capture")
+	edit := fs.Bool("edit", false, "open the annotation window before saving or copying")
+	text := fs.Bool("text", false, "print the text recognized in the capture, or copy it with --clipboard; with --out the capture is also saved and its text indexed for library searches")
+	asJSON := fs.Bool("json", false, "print a JSON manifest of the capture instead of the path")
+	cursor := fs.Bool("cursor", false, "draw the mouse cursor into the capture, the capture.includeCursor setting if not given")
+	fs.Var(annotationFlag{"arrow", &annotations}, "arrow", "draw an arrow from x1,y1 to x2,y2 (repeatable)")
+	fs.Var(annotationFlag{"highlight", &annotations}, "highlight", "highlight x,y,w,h (repeatable)")
+	fs.Var(annotationFlag{"blur", &annotations}, "blur", "blur x,y,w,h beyond recognition (repeatable)")
+	fs.Var(annotationFlag{"pixelate", &annotations}, "pixelate", "pixelate x,y,w,h beyond recognition (repeatable)")
+	colorHex := fs.String("color", "", "arrow color as #RRGGBB, red if empty")
+	lineWidth := fs.Int("width", 4, "arrow line width")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: unexpected argument %q\n", fs.Arg(0))
+		return 2
+	}
+
+	targets := 0
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "window" || f.Name == "display" || f.Name == "region" || f.Name == "anchor" {
+			targets++
+		}
+	})
+	if targets > 1 {
+		fmt.Fprintln(stderr, "Error: use only one of --window, --display, --region and --anchor")
+		return 2
+	}
+	if *direct && *windowTitle == "" {
+		fmt.Fprintln(stderr, "Error: --direct needs --window")
+		return 2
+	}
+	if *clipboard && *copyFile {
+		fmt.Fprintln(stderr, "Error: use only one of --clipboard and --copy-file")
+		return 2
+	}
+	if *scroll && *windowTitle == "" {
+		fmt.Fprintln(stderr, "Error: --scroll needs --window")
+		return 2
+	}
+	if *text && (*copyFile || *deep || *asJSON) {
+		fmt.Fprintln(stderr, "Error: --text cannot be used with --copy-file, --deep or --json")
+		return 2
+	}
+	var regionRect image.Rectangle
+	if *region != "" {
+		coords, err := parseCoords(*region)
+		if err != nil || coords[2] <= 0 || coords[3] <= 0 {
+			fmt.Fprintf(stderr, "Error: invalid --region %q, want x,y,w,h\n", *region)
+			return 2
+		}
+		regionRect = image.Rect(coords[0], coords[1], coords[0]+coords[2], coords[1]+coords[3])
+	}
+	arrowColor := color.Color(imaging.DefaultArrowColor)
+	if *colorHex != "" {
+		c, err := imaging.ParseColor(*colorHex)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 2
+		}
+		arrowColor = c
+	}
+	if *format != "" && imaging.NormalizeFormat(*format) == "" {
+		fmt.Fprintf(stderr, "Error: unsupported format %q, want png, jpg, webp or avif\n", *format)
+		return 2
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	}
+	includeCursor := false
+	cfg, cfgErr := config.Load()
+	if cfgErr == nil {
+		applyEncoders(cfg.Export)
+		includeCursor = cfg.Capture.IncludeCursor
+	}
+	if *anchorName != "" {
+		if cfgErr != nil {
+			fmt.Fprintln(stderr, "Error:", cfgErr.Error())
+			return 1
+		}
+		anchor, ok := cfg.Capture.Anchor(*anchorName)
+		if !ok {
+			fmt.Fprintf(stderr, "Error: no anchor named %q\n", *anchorName)
+			return 2
+		}
+		var err error
+		if regionRect, err = anchorRect(anchor); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "cursor" {
+			includeCursor = *cursor
+		}
+	})
+	screenshot.SetIncludeCursor(includeCursor)
+
+	// The manifest is filled in as the capture goes along, nil without --json
+	var manifest *provenance.CaptureOptions
+	if *asJSON {
+		manifest = &provenance.CaptureOptions{Started: time.Now()}
+		manifest.Monitor, manifest.Window = captureContext(*windowTitle, *display, regionRect)
+	}
+
+	if *deep {
+		if *windowTitle != "" || *clipboard || len(annotations) > 0 || *caption != "" || *edit || (*format != "" && *format != "png") {
+			fmt.Fprintln(stderr, "Error: --deep saves a PNG file of a display or region, without annotations")
+			return 2
+		}
+		data, err := captureDeepPNG(*display, regionRect)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if manifest != nil {
+			manifest.Frame = lastFrameClock()
+			if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+				manifest.Size = image.Pt(cfg.Width, cfg.Height)
+			}
+		}
+		return writeCaptureFile(*out, "png", data, *copyFile, manifest, stdout, stderr)
+	}
+
+	var img *image.RGBA
+	var err error
+	switch {
+	case *scroll:
+		img, err = captureScrollingTitled(*windowTitle)
+	case *windowTitle != "":
+		img, err = captureWindowTitled(*windowTitle, *direct)
+	case *display >= 0:
+		img, err = filtered(screenshot.CaptureDisplayRaw(*display))
+	case *region != "":
+		img, err = screenshot.CaptureRectRaw(regionRect, screenshot.CaptureOptions{})
+	case *anchorName != "":
+		img, err = filtered(screenshot.CaptureRectRaw(regionRect, screenshot.CaptureOptions{Clamp: true}))
+	default:
+		img, err = filtered(screenshot.CaptureFullscreenRaw())
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if manifest != nil && !*direct {
+		manifest.Frame = lastFrameClock()
+	}
+
+	for _, a := range annotations {
+		switch a.kind {
+		case "arrow":
+			imaging.DrawArrow(img, image.Pt(a.coords[0], a.coords[1]), image.Pt(a.coords[2], a.coords[3]), *lineWidth, arrowColor)
+		case "highlight":
+			imaging.Highlight(img, a.rect(), imaging.DefaultHighlightColor)
+		case "blur":
+			annotate.Redact(img, []image.Rectangle{a.rect()}, annotate.RedactBlur)
+		case "pixelate":
+			annotate.Redact(img, []image.Rectangle{a.rect()}, annotate.RedactPixelate)
+		}
+	}
+	if *caption != "" {
+		img = imaging.AddCaption(img, *caption, color.White, color.RGBA{32, 32, 32, 255})
+	}
+	if *edit {
+		edited, err := annotate.Edit(img)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if edited == nil {
+			fmt.Fprintln(stderr, "Error: annotation cancelled")
+			return 1
+		}
+		img = edited
+	}
+
+	if *text {
+		if *out != "" {
+			data, err := encodeImageData(img, *format, *quality)
+			if err == nil {
+				err = os.MkdirAll(filepath.Dir(*out), 0755)
+			}
+			if err == nil {
+				err = os.WriteFile(*out, data, 0644)
+			}
+			if err != nil {
+				fmt.Fprintln(stderr, "Error:", err.Error())
+				return 1
+			}
+		}
+		return printCaptureText(img, *out, *clipboard, stdout, stderr)
+	}
+	if manifest != nil {
+		manifest.Size = img.Bounds().Size()
+	}
+	if *clipboard {
+		if err := screenshot.SetClipboardImage(img); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if manifest != nil {
+			return printCaptureManifest(nil, *manifest, stdout, stderr)
+		}
+		return 0
+	}
+	data, err := encodeImageData(img, *format, *quality)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error: failed to encode image:", err.Error())
+		return 1
+	}
+	return writeCaptureFile(*out, *format, data, *copyFile, manifest, stdout, stderr)
+}
+
+// writeCaptureFile saves encoded capture data to out, or to a timestamped
+// file in the quick save folder if out is empty, and prints the path, or
+// the manifest if there is one. copyFile then puts the saved file on the
+// clipboard
+func writeCaptureFile(out, format string, data []byte, copyFile bool, manifest *provenance.CaptureOptions, stdout, stderr io.Writer) int {
+	if out == "" {
+		out = filepath.Join(historyFolder(), "winshot_"+time.Now().Format("2006-01-02_15-04-05")+imageExtension(format))
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if copyFile {
+		abs, err := filepath.Abs(out)
+		if err == nil {
+			err = screenshot.SetClipboardFiles(abs)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+	}
+	if manifest != nil {
+		manifest.Format = imaging.NormalizeFormat(format)
+		manifest.Path = out
+		if abs, err := filepath.Abs(out); err == nil {
+			manifest.Path = abs
+		}
+		return printCaptureManifest(data, *manifest, stdout, stderr)
+	}
+	fmt.Fprintln(stdout, out)
+	return 0
+}
+
+// printCaptureManifest prints the JSON manifest of a finished capture
+func printCaptureManifest(data []byte, opts provenance.CaptureOptions, stdout, stderr io.Writer) int {
+	opts.Done = time.Now()
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(provenance.NewCapture(data, opts)); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// captureContext returns the display and window a CLI capture is taken
+// from, for its manifest. The display is the one under the window or region
+// center, or under the cursor for fullscreen captures
+func captureContext(windowTitle string, display int, region image.Rectangle) (*provenance.MonitorInfo, *provenance.WindowInfo) {
+	switch {
+	case windowTitle != "":
+		hwnd, err := findWindowTitled(windowTitle)
+		if err != nil {
+			return nil, nil
+		}
+		info, _ := winEnum.GetWindowInfo(hwnd)
+		if info == nil {
+			return nil, nil
+		}
+		window := &provenance.WindowInfo{
+			Title:  info.Title,
+			App:    winEnum.ProcessImageName(winEnum.WindowProcessID(hwnd)),
+			X:      info.X,
+			Y:      info.Y,
+			Width:  info.Width,
+			Height: info.Height,
+		}
+		return monitorInfo(screenshot.GetMonitorAtPoint(info.X+info.Width/2, info.Y+info.Height/2)), window
+	case display >= 0:
+		return monitorInfo(display), nil
+	case !region.Empty():
+		center := region.Min.Add(region.Size().Div(2))
+		return monitorInfo(screenshot.GetMonitorAtPoint(center.X, center.Y)), nil
+	}
+	return monitorInfo(screenshot.GetMonitorAtCursor()), nil
+}
+
+// printCaptureText recognizes the text in a capture and prints it, or copies
+// it to the clipboard. When the capture was saved to file, the text is also
+// recorded in the history index of its folder
+func printCaptureText(img image.Image, file string, clipboard bool, stdout, stderr io.Writer) int {
+	text, err := ocr.Recognize(img)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if text == "" {
+		fmt.Fprintln(stderr, "Error: no text found")
+		return 1
+	}
+	if file != "" {
+		if err := library.RecordText(file, text); err != nil {
+			fmt.Fprintln(stderr, "Warning: failed to index the text:", err.Error())
+		}
+	}
+	if clipboard {
+		if err := screenshot.SetClipboardText(text); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintln(stdout, text)
+	return 0
+}
+
+// captureDeepPNG captures a display, a region or the display under the
+// cursor with 16 bits per channel and encodes it as a 16-bit PNG
+func captureDeepPNG(display int, region image.Rectangle) ([]byte, error) {
+	r := region
+	switch {
+	case display >= 0:
+		if display >= screenshot.GetDisplayCount() {
+			return nil, fmt.Errorf("display %d not found", display)
+		}
+		r = screenshot.GetDisplayBounds(display)
+	case r.Empty():
+		r = screenshot.GetDisplayBounds(screenshot.GetMonitorAtCursor())
+	}
+	img, err := screenshot.CaptureRectDeep(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// captureWindowTitled captures the first visible window whose title contains
+// title, ignoring case. direct renders the window with PrintWindow instead of
+// bringing it to the front and reading the screen
+func captureWindowTitled(title string, direct bool) (*image.RGBA, error) {
+	hwnd, err := findWindowTitled(title)
+	if err != nil {
+		return nil, err
+	}
+	if direct {
+		return screenshot.CaptureWindowDirectRaw(hwnd)
+	}
+	return filtered(screenshot.CaptureWindowRaw(hwnd))
+}
+
+// captureScrollingTitled scrolls the first visible window whose title
+// contains title down from where it is and stitches one long capture
+func captureScrollingTitled(title string) (*image.RGBA, error) {
+	hwnd, err := findWindowTitled(title)
+	if err != nil {
+		return nil, err
+	}
+	return filtered(scrollcapture.CaptureScrollingRaw(hwnd))
+}
+
+// findWindowTitled returns the first visible window whose title contains
+// title, ignoring case
+func findWindowTitled(title string) (uintptr, error) {
+	wins, err := winEnum.EnumWindows()
+	if err != nil {
+		return 0, err
+	}
+	for _, w := range wins {
+		if strings.Contains(strings.ToLower(w.Title), strings.ToLower(title)) {
+			return w.Handle, nil
+		}
+	}
+	return 0, fmt.Errorf("no window titled %q", title)
+}
+
+// captureSource captures a template capture slot source: "fullscreen",
+// "display:N", "window:<title>" or "region:x,y,w,h"
+func captureSource(source string) (image.Image, error) {
+	kind, arg, _ := strings.Cut(source, ":")
+	switch kind {
+	case "fullscreen":
+		return filtered(screenshot.CaptureFullscreenRaw())
+	case "display":
+		index, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid display %q", arg)
+		}
+		return filtered(screenshot.CaptureDisplayRaw(index))
+	case "window":
+		return captureWindowTitled(arg, false)
+	case "region":
+		c, err := parseCoords(arg)
+		if err != nil {
+			return nil, err
+		}
+		return screenshot.CaptureRectRaw(image.Rect(c[0], c[1], c[0]+c[2], c[1]+c[3]), screenshot.CaptureOptions{})
+	default:
+		return nil, fmt.Errorf("unknown capture source %q", source)
+	}
+}
+
+// filtered runs a raw capture through the PostCapture filters, as the
+// encoding capture functions do
+func filtered(img *image.RGBA, err error) (*image.RGBA, error) {
+	if err != nil {
+		return nil, err
+	}
+	return screenshot.ApplyFilters(img)
+}
+
+// runRenderCommand handles "winshot render", which renders .wshot annotation
+// projects without opening the editor so documentation builds can regenerate
+// annotated screenshots
+func runRenderCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "", "png, svg or pdf, from the --out extension or png if empty")
+	out := fs.String("out", "", "output file, or folder when rendering several projects; next to each project if empty")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(stderr, "usage: winshot render [--format png|svg|pdf] [--out <path>] <project.wshot>...")
+		return 2
+	}
+	*format = strings.ToLower(*format)
+	switch *format {
+	case "", "png", "svg", "pdf":
+	default:
+		fmt.Fprintf(stderr, "Error: unsupported format %q, want png, svg or pdf\n", *format)
+		return 2
+	}
+
+	failed := 0
+	for _, src := range fs.Args() {
+		dst := *out
+		if dst == "" || fs.NArg() > 1 {
+			ext := *format
+			if ext == "" {
+				ext = "png"
+			}
+			name := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src)) + "." + ext
+			if dst == "" {
+				dst = filepath.Join(filepath.Dir(src), name)
+			} else {
+				dst = filepath.Join(dst, name)
+			}
+		}
+		if err := renderProjectFile(src, dst, *format); err != nil {
+			fmt.Fprintf(stdout, "  FAILED %s: %s\n", src, err.Error())
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "  %s -> %s\n", src, dst)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// varsFlag collects repeatable --var name=value flags
+type varsFlag map[string]string
+
+func (v varsFlag) String() string { return "" }
+
+func (v varsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("want name=value, got %q", s)
+	}
+	v[name] = value
+	return nil
+}
+
+// runComposeCommand handles "winshot compose", which fills a layout
+// template with captures, images and text and saves the finished image
+func runComposeCommand(args []string, stdout, stderr io.Writer) int {
+	vars := varsFlag{}
+	fs := flag.NewFlagSet("compose", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("out", "", "output file, a timestamped PNG in the quick save folder if empty")
+	quality := fs.Int("quality", 95, "JPEG quality 1-100")
+	fs.Var(vars, "var", "template variable as name=value (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: winshot compose [--out <file>] [--var name=value]... <template.json|.yaml>")
+		return 2
+	}
+
+	tpl, err := layout.Load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	img, err := tpl.Render(layout.Options{Vars: vars, Capture: captureSource})
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	data, err := encodeImageData(img, format, *quality)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error: failed to encode image:", err.Error())
+		return 1
+	}
+	if *out == "" {
+		*out = filepath.Join(historyFolder(), "winshot_"+time.Now().Format("2006-01-02_15-04-05")+imageExtension(format))
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	fmt.Fprintln(stdout, *out)
+	return 0
+}
+
+// runFramesCommand handles "winshot frames", which steps a paused video and
+// saves each frame losslessly
+func runFramesCommand(args []string, stdout, stderr io.Writer) int {
+	var video config.VideoConfig
+	if cfg, err := config.Load(); err == nil {
+		video = cfg.Video
+	}
+
+	fs := flag.NewFlagSet("frames", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	windowTitle := fs.String("window", "", "player window whose title contains this text (required)")
+	count := fs.Int("count", 10, "number of frames to capture")
+	fs.StringVar(&video.StepKey, "step-key", video.StepKey, `key that advances one frame, such as "." or "Ctrl+Right"`)
+	delay := fs.Duration("delay", time.Duration(video.StepDelayMs)*time.Millisecond, "wait after each step, 250ms if zero")
+	out := fs.String("out", "", "output folder, a new folder in the quick save folder if empty")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *windowTitle == "" || fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: winshot frames --window <title> [--count N] [--step-key KEY] [--delay D] [--out <dir>]")
+		return 2
+	}
+	if *count < 1 || *count > maxVideoFrames {
+		fmt.Fprintf(stderr, "Error: --count must be between 1 and %d\n", maxVideoFrames)
+		return 2
+	}
+	if video.StepKey != "" {
+		if _, _, ok := hotkeys.ParseHotkeyString(video.StepKey); !ok {
+			fmt.Fprintf(stderr, "Error: invalid --step-key %q\n", video.StepKey)
+			return 2
+		}
+	}
+	video.StepDelayMs = int(delay.Milliseconds())
+
+	hwnd, err := findWindowTitled(*windowTitle)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if *out == "" {
+		*out = filepath.Join(historyFolder(), "frames_"+time.Now().Format("2006-01-02_15-04-05"))
+	}
+	n, err := captureVideoFrames(hwnd, *count, video, *out)
+	fmt.Fprintf(stdout, "Captured %d of %d frames to %s\n", n, *count, *out)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// runBurstCommand handles "winshot burst", which captures a region many
+// times in quick succession into a zip archive of PNG frames, or saves only
+// the best of them with --best or a blend of them all with --blend
+func runBurstCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("burst", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	region := fs.String("region", "", "capture x,y,w,h of the virtual screen (required)")
+	count := fs.Int("count", 10, "number of frames to capture")
+	interval := fs.Duration("interval", 0, "time between frames, back to back if zero")
+	best := fs.Bool("best", false, "save only the sharpest settled frame as a PNG")
+	blend := fs.String("blend", "", `save the frames blended into one PNG, "average" or "max"`)
+	apng := fs.Bool("apng", false, "save the frames as an animated PNG")
+	delay := fs.Duration("delay", 0, "how long each frame of --apng shows, as captured if zero")
+	out := fs.String("out", "", "output .zip file, or .png with --best, --blend or --apng, new in the history folder if empty")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *region == "" || fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: winshot burst --region x,y,w,h [--count N] [--interval D] [--best | --blend average|max | --apng [--delay D]] [--out <file>]")
+		return 2
+	}
+	if (*best && *blend != "") || (*apng && (*best || *blend != "")) {
+		fmt.Fprintln(stderr, "Error: only one of --best, --blend and --apng can be used")
+		return 2
+	}
+	if *delay < 0 {
+		fmt.Fprintln(stderr, "Error: --delay must not be negative")
+		return 2
+	}
+	if *delay > 0 && !*apng {
+		fmt.Fprintln(stderr, "Error: --delay needs --apng")
+		return 2
+	}
+	if *blend != "" && *blend != imaging.BlendAverage && *blend != imaging.BlendMax {
+		fmt.Fprintf(stderr, "Error: invalid --blend %q, want average or max\n", *blend)
+		return 2
+	}
+	coords, err := parseCoords(*region)
+	if err != nil || coords[2] <= 0 || coords[3] <= 0 {
+		fmt.Fprintf(stderr, "Error: invalid --region %q, want x,y,w,h\n", *region)
+		return 2
+	}
+	if *count < 1 || *count > screenshot.MaxBurstFrames {
+		fmt.Fprintf(stderr, "Error: --count must be between 1 and %d\n", screenshot.MaxBurstFrames)
+		return 2
+	}
+	if *interval < 0 {
+		fmt.Fprintln(stderr, "Error: --interval must not be negative")
+		return 2
+	}
+
+	r := image.Rect(coords[0], coords[1], coords[0]+coords[2], coords[1]+coords[3])
+	frames, err := screenshot.CaptureBurst(r, *count, *interval, screenshot.CaptureOptions{})
+	single := *best || *blend != ""
+	if single && err == nil {
+		if *out == "" {
+			*out = filepath.Join(historyFolder(), "burst_"+time.Now().Format("2006-01-02_15-04-05")+".png")
+		}
+		var img *image.RGBA
+		if *best {
+			i := imaging.BestFrame(imaging.ScoreFrames(burstImages(frames)))
+			img = frames[i].Image
+			fmt.Fprintf(stdout, "Picked frame %d of %d\n", i+1, len(frames))
+		} else if img, err = imaging.BlendFrames(burstImages(frames), *blend); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if err := writeFrameImage(*out, img); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		fmt.Fprintf(stdout, "Saved to %s\n", *out)
+		return 0
+	}
+	if len(frames) > 0 && *apng {
+		if *out == "" {
+			*out = filepath.Join(historyFolder(), "burst_"+time.Now().Format("2006-01-02_15-04-05")+".png")
+		}
+		opts := burstAPNGOptions(frames, *delay)
+		if size, eerr := imaging.EstimateAPNGSize(burstImages(frames), opts, 8); eerr == nil {
+			fmt.Fprintf(stdout, "Encoding %d frames, about %.1f MB\n", len(frames), float64(size)/(1<<20))
+		}
+		if werr := writeBurstAPNG(*out, frames, opts); werr != nil {
+			fmt.Fprintln(stderr, "Error:", werr.Error())
+			return 1
+		}
+		fmt.Fprintf(stdout, "Captured %d of %d frames to %s\n", len(frames), *count, *out)
+	} else if len(frames) > 0 && !single {
+		if *out == "" {
+			*out = filepath.Join(historyFolder(), "burst_"+time.Now().Format("2006-01-02_15-04-05")+".zip")
+		}
+		if werr := writeBurstZip(*out, r, *interval, frames); werr != nil {
+			fmt.Fprintln(stderr, "Error:", werr.Error())
+			return 1
+		}
+		fmt.Fprintf(stdout, "Captured %d of %d frames to %s\n", len(frames), *count, *out)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// writeFrameImage saves an image made from a burst as a PNG after the
+// capture filters
+func writeFrameImage(path string, frame *image.RGBA) error {
+	img, err := screenshot.ApplyFilters(frame)
+	if err != nil {
+		return err
+	}
+	data, err := encodeImageData(img, "png", 0)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// runAnchorCommand handles "winshot anchor", which saves regions anchored to
+// an app window for "winshot capture --anchor"
+func runAnchorCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: winshot anchor add --region x,y,w,h [--app <exe>] [--title <text>] <name>")
+		fmt.Fprintln(stderr, "       winshot anchor list")
+		fmt.Fprintln(stderr, "       winshot anchor remove <name>")
+		return 2
+	}
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("anchor add", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		region := fs.String("region", "", "x,y,w,h of the virtual screen, inside the window to anchor to (required)")
+		app := fs.String("app", "", "executable of the window, the app under the region if empty")
+		title := fs.String("title", "", "text the window title must contain")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *region == "" || fs.NArg() != 1 {
+			fmt.Fprintln(stderr, "usage: winshot anchor add --region x,y,w,h [--app <exe>] [--title <text>] <name>")
+			return 2
+		}
+		coords, err := parseCoords(*region)
+		if err != nil || coords[2] <= 0 || coords[3] <= 0 {
+			fmt.Fprintf(stderr, "Error: invalid --region %q, want x,y,w,h\n", *region)
+			return 2
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+
+		anchor, err := anchorAt(fs.Arg(0), image.Rect(coords[0], coords[1], coords[0]+coords[2], coords[1]+coords[3]))
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if *app != "" {
+			anchor.App = *app
+		}
+		if *title != "" {
+			anchor.Title = *title
+		}
+		cfg.Capture.SetAnchor(anchor)
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		fmt.Fprintf(stdout, "Anchored %q to %s\n", anchor.Name, anchorTarget(anchor))
+		return 0
+
+	case "list":
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		for _, a := range cfg.Capture.Anchors {
+			corner := a.Corner
+			if corner == "" {
+				corner = config.CornerTopLeft
+			}
+			fmt.Fprintf(stdout, "%s\t%s\t%d,%d from %s, %dx%d\n", a.Name, anchorTarget(a), a.X, a.Y, corner, a.Width, a.Height)
+		}
+		return 0
+
+	case "remove":
+		if len(args) != 2 {
+			fmt.Fprintln(stderr, "usage: winshot anchor remove <name>")
+			return 2
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		if !cfg.Capture.DeleteAnchor(args[1]) {
+			fmt.Fprintf(stderr, "Error: no anchor named %q\n", args[1])
+			return 1
+		}
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "Error: unknown anchor command %q\n", args[0])
+	return 2
+}
+
+// anchorTarget describes the window an anchor finds, such as
+// code.exe "Settings"
+func anchorTarget(a config.AnchorConfig) string {
+	switch {
+	case a.App == "":
+		return fmt.Sprintf("%q", a.Title)
+	case a.Title == "":
+		return a.App
+	}
+	return fmt.Sprintf("%s %q", a.App, a.Title)
+}
+
+// runRefreshCommand handles "winshot refresh", which recaptures anchored
+// regions and rewrites the baseline images in a docs folder that changed
+// beyond a threshold, so screenshots in documentation stay current without
+// churning files over a blinking caret
+func runRefreshCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", ".", "folder of baseline images, one <anchor>.png per anchor")
+	threshold := fs.Float64("threshold", 0.1, "percent of pixels that must change before a baseline is rewritten")
+	tolerance := fs.Int("tolerance", 8, "how far a color channel may move, 0-255, before its pixel counts as changed")
+	reportFile := fs.String("report", "", "also write the report to this file, JSON for .json and Markdown otherwise")
+	dryRun := fs.Bool("dry-run", false, "compare and report without writing any images")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *threshold < 0 || *threshold > 100 {
+		fmt.Fprintf(stderr, "Error: invalid --threshold %g, want 0-100\n", *threshold)
+		return 2
+	}
+	if *tolerance < 0 || *tolerance > 255 {
+		fmt.Fprintf(stderr, "Error: invalid --tolerance %d, want 0-255\n", *tolerance)
+		return 2
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+
+	// Named anchors are refreshed even without a baseline, which creates it;
+	// otherwise every anchor that has one in the folder
+	var anchors []config.AnchorConfig
+	for _, name := range fs.Args() {
+		a, ok := cfg.Capture.Anchor(name)
+		if !ok {
+			fmt.Fprintf(stderr, "Error: no anchor named %q\n", name)
+			return 2
+		}
+		anchors = append(anchors, a)
+	}
+	if fs.NArg() == 0 {
+		for _, a := range cfg.Capture.Anchors {
+			if _, err := os.Stat(filepath.Join(*dir, a.Name+".png")); err == nil {
+				anchors = append(anchors, a)
+			}
+		}
+		if len(anchors) == 0 {
+			fmt.Fprintf(stderr, "Error: no baselines for saved anchors in %s, name the anchors to create them\n", *dir)
+			return 1
+		}
+	}
+
+	applyEncoders(cfg.Export)
+	screenshot.SetIncludeCursor(false)
+	report := refreshReport{Time: time.Now(), Threshold: *threshold, DryRun: *dryRun}
+	code := 0
+	for _, a := range anchors {
+		res := refreshAnchor(a, filepath.Join(*dir, a.Name+".png"), *threshold, *tolerance, *dryRun)
+		report.Results = append(report.Results, res)
+		if res.Status == refreshFailed {
+			fmt.Fprintf(stdout, "%s\t%s\t%s\n", res.Status, res.Anchor, res.Error)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "%s\t%s\t%.2f%%\t%s\n", res.Status, res.Anchor, res.Changed, res.File)
+	}
+	if *reportFile != "" {
+		if err := report.write(*reportFile); err != nil {
+			fmt.Fprintln(stderr, "Error:", err.Error())
+			return 1
+		}
+	}
+	return code
+}
+
+// runDecryptCommand handles "winshot decrypt", which opens an encrypted
+// upload from its share link or a downloaded .enc or viewer page file
+func runDecryptCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	passphrase := fs.String("passphrase", "", "passphrase the upload was locked with")
+	out := fs.String("out", "", "output file, named after the upload in the current folder if empty")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: winshot decrypt [--passphrase <text>] [--out <file>] <link|file>")
+		return 2
+	}
+
+	source, key, err := upload.ParseShareLink(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 2
+	}
+	var data []byte
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		data, err = downloadEncrypted(source)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+
+	plain, err := upload.Decrypt(data, key, *passphrase)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	if *out == "" {
+		*out = decryptedName(source, plain)
+	}
+	if err := os.WriteFile(*out, plain, 0644); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	fmt.Fprintln(stdout, *out)
+	return 0
+}
+
+// downloadEncrypted fetches an encrypted upload
+func downloadEncrypted(link string) ([]byte, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// decryptedName names a decrypted upload after its source, with the
+// extension of the image it holds
+func decryptedName(source string, plain []byte) string {
+	if u, err := url.Parse(source); err == nil && (u.Scheme == "https" || u.Scheme == "http") {
+		source = path.Base(u.Path)
+	}
+	name := strings.TrimSuffix(filepath.Base(source), ".enc")
+	if name != filepath.Base(source) {
+		return name // shot.png.enc still has its own extension
+	}
+	ext := ".png"
+	switch http.DetectContentType(plain) {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	case "image/gif":
+		ext = ".gif"
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext
+}
+
+// historyFolder returns the quick save folder from the saved settings
+func historyFolder() string {
+	if cfg, err := config.Load(); err == nil && cfg.QuickSave.Folder != "" {
+		return cfg.QuickSave.Folder
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Pictures", "WinShot")
+}
+
+// protocolScheme starts the links registered by config.SyncShellIntegration
+const protocolScheme = "winshot:"
+
+// protocolArgs turns a winshot:// link into capture command arguments, so
+// winshot://capture?window=Notes&clipboard runs
+// "capture --window=Notes --clipboard". Any web page can open a link, so
+// only capture is allowed and links can't choose where files are written.
+// Parameters keep their order, which annotations depend on
+func protocolArgs(link string) ([]string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link: %w", err)
+	}
+	command := u.Host
+	if command == "" {
+		command = strings.Trim(u.Opaque, "/")
+	}
+	if command != "capture" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("unsupported link %q", link)
+	}
+
+	args := []string{"capture"}
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(param, "=")
+		name, err1 := url.QueryUnescape(name)
+		value, err2 := url.QueryUnescape(value)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid link parameter %q", param)
+		}
+		// A NUL would cut the command short in the consent prompt
+		if strings.ContainsRune(name, 0) || strings.ContainsRune(value, 0) {
+			return nil, fmt.Errorf("invalid link parameter %q", param)
+		}
+		if name == "out" {
+			return nil, errors.New("links can't set the output file")
+		}
+		if hasValue {
+			args = append(args, "--"+name+"="+value)
+		} else {
+			args = append(args, "--"+name)
+		}
+	}
+	return args, nil
+}
+
+// idYes is the MessageBox result of the Yes button
+const idYes = 6
+
+// confirmLinkCapture asks the user before a link captures the screen, as
+// set by config.LinksConfig. Links run in a process of their own, so the
+// prompt is a plain message box
+func confirmLinkCapture(cmdArgs []string) bool {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	command := "winshot " + strings.Join(cmdArgs, " ")
+	if !cfg.Links.NeedsConsent(command) {
+		return true
+	}
+
+	text := "A link asked WinShot to capture your screen:\n\n" + command + "\n\nAllow this capture?"
+	if cfg.Links.Consent == config.LinkConsentRemember {
+		text += " WinShot won't ask again for this link."
+	}
+	prompt, err := windows.UTF16PtrFromString(text)
+	if err != nil {
+		return false // Can't show the command, so don't run it
+	}
+	ret, _ := windows.MessageBox(0, prompt, windows.StringToUTF16Ptr("WinShot"),
+		windows.MB_YESNO|windows.MB_ICONQUESTION|windows.MB_DEFBUTTON2|windows.MB_TOPMOST|windows.MB_SETFOREGROUND)
+	if ret != idYes {
+		return false
+	}
+	if cfg.Links.Consent == config.LinkConsentRemember {
+		cfg.Links.Approved = append(cfg.Links.Approved, command)
+		if err := cfg.Save(); err != nil {
+			println("Warning: failed to remember link approval:", err.Error())
+		}
+	}
+	return true
+}
+
+// runUninstallCleanup handles "winshot --uninstall-cleanup", which
+// installers run before deleting the program files
+func runUninstallCleanup(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("--uninstall-cleanup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	purge := fs.Bool("purge", false, "also delete settings and stored cloud credentials")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: unexpected argument %q\n", fs.Arg(0))
+		return 2
+	}
+
+	var errs []error
+	errs = append(errs, config.UninstallCleanup())
+	if *purge {
+		creds := upload.NewCredentialManager()
+		for _, key := range upload.AllCredentialKeys {
+			errs = append(errs, creds.Delete(key))
+		}
+		dir, err := config.DataDir()
+		if err == nil {
+			err = os.RemoveAll(dir)
+		}
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	fmt.Fprintln(stdout, "Removed WinShot registry entries")
+	if *purge {
+		fmt.Fprintln(stdout, "Removed settings and stored credentials")
+	}
+	return 0
+}
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"winshot/internal/upload"
+)
+
+// TestRunCLI_NoCommand verifies the GUI starts when no CLI command is given
+func TestRunCLI_NoCommand(t *testing.T) {
+	for _, args := range [][]string{nil, {}, {"--unknown-flag"}} {
+		if handled, _ := runCLI(args); handled {
+			t.Errorf("runCLI(%v) handled = true, want false", args)
+		}
+	}
+}
+
+// TestRunDiagCommand_Usage verifies usage errors return exit code 2
+func TestRunDiagCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing subcommand", nil},
+		{"unknown subcommand", []string{"bogus"}},
+		{"bad flag", []string{"soak", "-iterations=abc"}},
+		{"bundle argument", []string{"bundle", "report.zip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runDiagCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runDiagCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRunHistoryCommand_Usage verifies usage errors return exit code 2
+func TestRunHistoryCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing subcommand", nil},
+		{"unknown subcommand", []string{"bogus"}},
+		{"missing out", []string{"export", "--since", "7d"}},
+		{"bad since", []string{"export", "--since", "soon", "--out", t.TempDir()}},
+		{"missing query", []string{"search", "--folder", t.TempDir()}},
+		{"bad query", []string{"search", "--folder", t.TempDir(), `text:"open`}},
+		{"bad vacuum flag", []string{"vacuum", "--dedupe=maybe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runHistoryCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runHistoryCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRunHistoryCommand_EmptyFolder verifies exporting nothing succeeds
+func TestRunHistoryCommand_EmptyFolder(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"export", "--folder", t.TempDir(), "--out", t.TempDir()}
+	if code := runHistoryCommand(args, &stdout, &stderr); code != 0 {
+		t.Errorf("runHistoryCommand(%v) = %d, want 0 (stderr: %s)", args, code, stderr.String())
+	}
+}
+
+// TestRunCaptureCommand_Usage verifies bad flags are rejected before capturing
+func TestRunCaptureCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"short blur", []string{"--blur", "100,40,300"}},
+		{"empty pixelate", []string{"--pixelate", "100,40,0,20"}},
+		{"bad arrow", []string{"--arrow", "1,2,x,4"}},
+		{"empty highlight", []string{"--highlight", "10,10,0,5"}},
+		{"bad region", []string{"--region", "0,0,-5,10"}},
+		{"two targets", []string{"--window", "App", "--display", "0"}},
+		{"anchor and region", []string{"--anchor", "panel", "--region", "0,0,10,10"}},
+		{"direct without window", []string{"--direct"}},
+		{"scroll without window", []string{"--scroll"}},
+		{"deep window", []string{"--deep", "--window", "App"}},
+		{"deep jpeg", []string{"--deep", "--out", "shot.jpg"}},
+		{"deep edit", []string{"--deep", "--edit"}},
+		{"bad format", []string{"--format", "tiff"}},
+		{"clipboard and copy file", []string{"--clipboard", "--copy-file"}},
+		{"text to copied file", []string{"--text", "--out", "shot.png", "--copy-file"}},
+		{"text as json", []string{"--text", "--json"}},
+		{"bad color", []string{"--arrow", "0,0,10,10", "--color", "reddish"}},
+		{"stray argument", []string{"App"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runCaptureCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runCaptureCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestAnnotationFlag_Order verifies annotations keep command-line order
+func TestAnnotationFlag_Order(t *testing.T) {
+	var list []annotation
+	annotationFlag{"blur", &list}.Set("1,2,3,4")
+	annotationFlag{"arrow", &list}.Set("5, 6, 7, 8")
+
+	want := []annotation{{"blur", [4]int{1, 2, 3, 4}}, {"arrow", [4]int{5, 6, 7, 8}}}
+	if len(list) != len(want) || list[0] != want[0] || list[1] != want[1] {
+		t.Errorf("annotations = %v, want %v", list, want)
+	}
+}
+
+// TestRunRenderCommand_Usage verifies usage errors return exit code 2
+func TestRunRenderCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing project", nil},
+		{"bad format", []string{"--format", "gif", "doc.wshot"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runRenderCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runRenderCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRunRenderCommand_Render verifies projects render next to themselves
+func TestRunRenderCommand_Render(t *testing.T) {
+	dir := t.TempDir()
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	os.WriteFile(filepath.Join(dir, "shot.png"), img.Bytes(), 0644)
+	src := filepath.Join(dir, "doc.wshot")
+	os.WriteFile(src, []byte(`{"version":1,"imagePath":"shot.png","annotations":[{"type":"rectangle","width":4,"height":4,"stroke":"#ff0000","strokeWidth":1}]}`), 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runRenderCommand([]string{"--format", "svg", src}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runRenderCommand() = %d, want 0 (stdout: %s)", code, stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "doc.svg")); err != nil {
+		t.Errorf("rendered file: %v", err)
+	}
+
+	// Missing projects fail without stopping the others
+	stdout.Reset()
+	if code := runRenderCommand([]string{"--out", t.TempDir(), filepath.Join(dir, "missing.wshot"), src}, &stdout, &stderr); code != 1 {
+		t.Errorf("runRenderCommand() with a missing project = %d, want 1", code)
+	}
+	if !strings.Contains(stdout.String(), "doc.png") {
+		t.Errorf("output %q does not list the rendered project", stdout.String())
+	}
+}
+
+// TestRunComposeCommand verifies templates render and usage errors return 2
+func TestRunComposeCommand(t *testing.T) {
+	dir := t.TempDir()
+	tpl := filepath.Join(dir, "status.yaml")
+	os.WriteFile(tpl, []byte("width: 40\nheight: 20\nslots:\n  - {type: text, width: 40, height: 20, text: \"v{{version}}\"}\n"), 0644)
+
+	for _, args := range [][]string{nil, {"--var", "novalue", tpl}, {tpl, tpl}} {
+		var stdout, stderr bytes.Buffer
+		if code := runComposeCommand(args, &stdout, &stderr); code != 2 {
+			t.Errorf("runComposeCommand(%v) = %d, want 2", args, code)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	out := filepath.Join(dir, "status.png")
+	if code := runComposeCommand([]string{"--var", "version=2.1", "--out", out, tpl}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runComposeCommand() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("composed file: %v", err)
+	}
+
+	// An undefined variable fails rather than rendering the placeholder
+	if code := runComposeCommand([]string{"--out", out, tpl}, &stdout, &stderr); code != 1 {
+		t.Errorf("runComposeCommand() without the variable = %d, want 1", code)
+	}
+}
+
+// TestRunFramesCommand_Usage verifies usage errors return exit code 2
+func TestRunFramesCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing window", []string{"--count", "5"}},
+		{"zero count", []string{"--window", "Player", "--count", "0"}},
+		{"bad step key", []string{"--window", "Player", "--step-key", "Ctrl+Nope"}},
+		{"bad delay", []string{"--window", "Player", "--delay", "soon"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runFramesCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runFramesCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRunBurstCommand_Usage verifies usage errors return exit code 2
+func TestRunBurstCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing region", []string{"--count", "5"}},
+		{"bad region", []string{"--region", "0,0,0,10"}},
+		{"too many frames", []string{"--region", "0,0,10,10", "--count", "100000"}},
+		{"negative interval", []string{"--region", "0,0,10,10", "--interval", "-1s"}},
+		{"unknown blend", []string{"--region", "0,0,10,10", "--blend", "median"}},
+		{"best and blend", []string{"--region", "0,0,10,10", "--best", "--blend", "max"}},
+		{"apng and best", []string{"--region", "0,0,10,10", "--apng", "--best"}},
+		{"delay without apng", []string{"--region", "0,0,10,10", "--delay", "50ms"}},
+		{"negative delay", []string{"--region", "0,0,10,10", "--apng", "--delay", "-50ms"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runBurstCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runBurstCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRunAnchorCommand_Usage verifies usage errors return exit code 2
+func TestRunAnchorCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"move"}},
+		{"add without region", []string{"add", "panel"}},
+		{"add without name", []string{"add", "--region", "0,0,10,10"}},
+		{"add bad region", []string{"add", "--region", "0,0,10", "panel"}},
+		{"remove without name", []string{"remove"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runAnchorCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runAnchorCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRunRefreshCommand_Usage verifies usage errors return exit code 2
+func TestRunRefreshCommand_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"negative threshold", []string{"--threshold", "-1"}},
+		{"threshold over 100", []string{"--threshold", "150"}},
+		{"bad tolerance", []string{"--tolerance", "300"}},
+		{"unknown flag", []string{"--baseline", "docs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runRefreshCommand(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("runRefreshCommand(%v) = %d, want 2", tt.args, code)
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected usage message on stderr")
+			}
+		})
+	}
+}
+
+// TestRefreshReport_Markdown verifies the report table escapes its cells
+func TestRefreshReport_Markdown(t *testing.T) {
+	report := refreshReport{
+		Time:      time.Date(2024, 5, 7, 9, 30, 0, 0, time.UTC),
+		Threshold: 0.5,
+		DryRun:    true,
+		Results: []refreshResult{
+			{Anchor: "panel", File: "docs/panel.png", Status: refreshUpdated, Changed: 3.25, Region: "10,20,30,40"},
+			{Anchor: "a|b", File: "docs/a|b.png", Status: refreshFailed, Error: "no window found"},
+		},
+	}
+	got := report.markdown()
+	for _, want := range []string{
+		"2024-05-07 09:30, threshold 0.5%, dry run",
+		"| panel | docs/panel.png | updated | 3.25% | 10,20,30,40 |",
+		`| a\|b | docs/a\|b.png | failed: no window found | 0.00% |  |`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+// TestRunDecryptCommand verifies encrypted uploads open from a local file
+// with the key from their link
+func TestRunDecryptCommand(t *testing.T) {
+	dir := t.TempDir()
+	plain := []byte("\x89PNG\r\n\x1a\n screenshot")
+	blob, key, err := upload.Encrypt(plain, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := filepath.Join(dir, "shot.png.enc")
+	os.WriteFile(enc, blob, 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runDecryptCommand(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("runDecryptCommand() without a file = %d, want 2", code)
+	}
+	if code := runDecryptCommand([]string{enc}, &stdout, &stderr); code != 1 {
+		t.Errorf("runDecryptCommand() without the key = %d, want 1", code)
+	}
+
+	out := filepath.Join(dir, "shot.png")
+	link := enc + "#" + base64.RawURLEncoding.EncodeToString(key)
+	if code := runDecryptCommand([]string{"--out", out, link}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runDecryptCommand() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if got, _ := os.ReadFile(out); !bytes.Equal(got, plain) {
+		t.Errorf("decrypted file = %q, want %q", got, plain)
+	}
+}
+
+// TestDecryptedName verifies decrypted uploads are named after their source
+func TestDecryptedName(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"https://x.example/dir/shot.png.enc", "shot.png"},
+		{"https://x.example/shot.html", "shot.png"},
+		{"shot.html", "shot.png"},
+	}
+	for _, tt := range tests {
+		if got := decryptedName(tt.source, png); got != tt.want {
+			t.Errorf("decryptedName(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+// TestProtocolArgs verifies winshot:// links map to capture arguments
+func TestProtocolArgs(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    []string
+		wantErr bool
+	}{
+		{"winshot://capture", []string{"capture"}, false},
+		{"winshot://capture/?window=Release%20Notes&clipboard", []string{"capture", "--window=Release Notes", "--clipboard"}, false},
+		{"winshot:capture?display=1", []string{"capture", "--display=1"}, false},
+		{"winshot://capture?blur=0,0,9,9&arrow=1,1,5,5", []string{"capture", "--blur=0,0,9,9", "--arrow=1,1,5,5"}, false},
+		{"winshot://capture?out=C:%5Cevil.png", nil, true},
+		{"winshot://render?x", nil, true},
+		{"winshot://capture/other", nil, true},
+		{"winshot://capture?window=Notes%00--out=C:%5Cevil.png", nil, true},
+		{"winshot://capture?clip%00board", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := protocolArgs(tt.link)
+		if (err != nil) != tt.wantErr || strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("protocolArgs(%q) = %q, %v, want %q", tt.link, got, err, tt.want)
+		}
+	}
+}
+
+// TestRunUninstallCleanup_Usage verifies usage errors return exit code 2
+// without touching the registry
+func TestRunUninstallCleanup_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runUninstallCleanup([]string{"now"}, &stdout, &stderr); code != 2 {
+		t.Errorf("runUninstallCleanup() = %d, want 2", code)
+	}
+	if code := runUninstallCleanup([]string{"--force"}, &stdout, &stderr); code != 2 {
+		t.Errorf("runUninstallCleanup(--force) = %d, want 2", code)
+	}
+}
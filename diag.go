@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"winshot/internal/config"
+	"winshot/internal/diag"
+	"winshot/internal/overlay"
+	"winshot/internal/screenshot"
+)
+
+var (
+	user32Diag                 = windows.NewLazySystemDLL("user32.dll")
+	shcoreDiag                 = windows.NewLazySystemDLL("shcore.dll")
+	dwmapiDiag                 = windows.NewLazySystemDLL("dwmapi.dll")
+	procGetGuiResources        = user32Diag.NewProc("GetGuiResources")
+	procGetSystemMetrics       = user32Diag.NewProc("GetSystemMetrics")
+	procMonitorFromRect        = user32Diag.NewProc("MonitorFromRect")
+	procGetDpiForMonitor       = shcoreDiag.NewProc("GetDpiForMonitor")
+	procDwmIsComposition       = dwmapiDiag.NewProc("DwmIsCompositionEnabled")
+	procGetProcessHandleCount  = kernel32CLI.NewProc("GetProcessHandleCount")
+	procSetProcessDpiAwareness = shcoreDiag.NewProc("SetProcessDpiAwareness")
+)
+
+// GetGuiResources flags
+const (
+	grGDIObjects  = 0
+	grUserObjects = 1
+)
+
+const (
+	smRemoteSession        = 0x1000
+	monitorDefaultNearest  = 2
+	mdtEffectiveDPI        = 0
+	windowsBlueBuildNumber = 9600 // Windows 8.1, first with PW_RENDERFULLCONTENT
+)
+
+// errorLogName is the recent error history in the data folder
+const errorLogName = "errors.jsonl"
+
+// processResources samples GDI, USER and kernel handle counts for this process
+func processResources() diag.ResourceCounts {
+	return resourcesOf(uintptr(windows.CurrentProcess()))
+}
+
+// resourcesOf samples GDI, USER and kernel handle counts for a process handle
+// opened with at least PROCESS_QUERY_LIMITED_INFORMATION
+func resourcesOf(process uintptr) diag.ResourceCounts {
+	gdi, _, _ := procGetGuiResources.Call(process, grGDIObjects)
+	user, _, _ := procGetGuiResources.Call(process, grUserObjects)
+
+	var handles uint32
+	procGetProcessHandleCount.Call(process, uintptr(unsafe.Pointer(&handles)))
+
+	return diag.ResourceCounts{
+		GDIObjects:  int(gdi),
+		UserObjects: int(user),
+		Handles:     int(handles),
+	}
+}
+
+// runSoak repeatedly captures the screen and opens/closes the native overlay,
+// reporting whether GDI objects or handles grew over the run
+func runSoak(iterations, warmup int, withOverlay bool, progress func(i int)) (*diag.SoakReport, error) {
+	var mgr *overlay.Manager
+	if withOverlay {
+		mgr = overlay.NewManager()
+		if err := mgr.Start(); err != nil {
+			return nil, err
+		}
+		defer mgr.Stop()
+	}
+
+	step := func(i int) error {
+		// Virtual screen grab used as the overlay background
+		img, err := screenshot.CaptureVirtualScreenRaw()
+		if err != nil {
+			return err
+		}
+
+		if mgr != nil {
+			bounds := screenshot.VirtualScreenRect()
+			scaleRatio := float64(img.Bounds().Dx()) / float64(bounds.Dx())
+			if scaleRatio < 1.0 {
+				scaleRatio = 1.0
+			}
+			mgr.Show(img, bounds, scaleRatio)
+			time.Sleep(20 * time.Millisecond)
+			mgr.Hide()
+			if err := waitOverlayHidden(mgr, 2*time.Second); err != nil {
+				return err
+			}
+		}
+
+		// Encoded capture with cursor compositing, as used by hotkey pipelines
+		display := screenshot.GetDisplayBounds(screenshot.GetMonitorAtCursor())
+		_, err = screenshot.CaptureRect(display, screenshot.CaptureOptions{IncludeCursor: true})
+		return err
+	}
+
+	return diag.Soak(diag.SoakOptions{
+		Iterations: iterations,
+		Warmup:     warmup,
+		Tolerance:  diag.DefaultTolerance,
+		Step:       step,
+		Sample:     processResources,
+		Progress: func(i int, _ diag.ResourceCounts) {
+			if progress != nil {
+				progress(i)
+			}
+		},
+	})
+}
+
+// waitOverlayHidden polls until the overlay has processed a hide command
+func waitOverlayHidden(mgr *overlay.Manager, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for mgr.IsShowing() {
+		if time.Now().After(deadline) {
+			return errors.New("overlay did not close")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return nil
+}
+
+// errorLogPath returns the recent error history file, empty if the data
+// folder is unknown
+func errorLogPath() string {
+	dir, err := config.DataDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, errorLogName)
+}
+
+// recordError adds an error to the history included in diagnostics bundles
+func recordError(source string, err error) {
+	path := errorLogPath()
+	if err == nil || path == "" {
+		return
+	}
+	_ = diag.AppendError(path, diag.ErrorEntry{Time: time.Now(), Source: source, Message: err.Error()})
+}
+
+// writeDiagBundle zips a machine report, the redacted config, recent errors
+// and the safe mode log into w
+func writeDiagBundle(w io.Writer) error {
+	now := time.Now()
+	home, _ := os.UserHomeDir()
+	report := diag.Report{
+		Generated:    now,
+		Version:      Version,
+		Capabilities: capabilities(),
+		Displays:     displayTopology(),
+		Resources:    winshotResources(),
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := []diag.BundleFile{{Name: "report.json", Data: []byte(diag.RedactPaths(string(reportJSON), home))}}
+
+	if path, err := config.GetConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			if redacted, err := diag.RedactConfig(data, home); err == nil {
+				files = append(files, diag.BundleFile{Name: "config.json", Data: redacted})
+			} else {
+				files = append(files, diag.BundleFile{Name: "config-error.txt", Data: []byte(err.Error())})
+			}
+		}
+	}
+
+	if path := errorLogPath(); path != "" {
+		if entries, err := diag.ReadErrors(path); len(entries) > 0 || (err != nil && !errors.Is(err, os.ErrNotExist)) {
+			for i := range entries {
+				entries[i].Message = diag.RedactPaths(entries[i].Message, home)
+			}
+			data, _ := json.MarshalIndent(entries, "", "  ")
+			files = append(files, diag.BundleFile{Name: "errors.json", Data: data})
+		}
+		if data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "safe-mode.log")); err == nil {
+			files = append(files, diag.BundleFile{Name: "logs/safe-mode.log", Data: []byte(diag.RedactPaths(string(data), home))})
+		}
+	}
+
+	return diag.WriteBundle(w, files, now)
+}
+
+// saveDiagBundle writes a bundle to path through a buffer, so a failure
+// never leaves a truncated zip behind
+func saveDiagBundle(path string) error {
+	var buf bytes.Buffer
+	if err := writeDiagBundle(&buf); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// diagBundleName returns a timestamped bundle file name
+func diagBundleName(t time.Time) string {
+	return "winshot-diag_" + t.Format("20060102_150405") + ".zip"
+}
+
+// capabilities lists the platform features capture depends on
+func capabilities() []diag.Capability {
+	version := windows.RtlGetVersion()
+	build := version.BuildNumber
+	caps := []diag.Capability{
+		{Name: "Windows", Available: true, Detail: fmt.Sprintf("%d.%d.%d", version.MajorVersion, version.MinorVersion, build)},
+		{Name: "Per-monitor DPI awareness", Available: procSetProcessDpiAwareness.Find() == nil},
+		{Name: "Full-content window capture", Available: build >= windowsBlueBuildNumber, Detail: "PrintWindow PW_RENDERFULLCONTENT"},
+	}
+
+	// Availability only; a capture can still fall back to GDI at run time
+	dxgi := false
+	for _, b := range screenshot.AvailableBackends() {
+		dxgi = dxgi || b == screenshot.BackendDXGI
+	}
+	caps = append(caps, diag.Capability{Name: "DXGI Desktop Duplication", Available: dxgi})
+
+	composition := false
+	if procDwmIsComposition.Find() == nil {
+		var enabled int32
+		if ret, _, _ := procDwmIsComposition.Call(uintptr(unsafe.Pointer(&enabled))); ret == 0 {
+			composition = enabled != 0
+		}
+	}
+	caps = append(caps, diag.Capability{Name: "DWM composition", Available: composition})
+
+	desktop, err := screenshot.InputDesktop()
+	if err != nil {
+		desktop = err.Error()
+	}
+	caps = append(caps, diag.Capability{Name: "Interactive desktop", Available: err == nil && desktop == "Default", Detail: desktop})
+
+	webview := webView2Version()
+	caps = append(caps, diag.Capability{Name: "WebView2 runtime", Available: webview != "", Detail: webview})
+
+	remote, _, _ := procGetSystemMetrics.Call(smRemoteSession)
+	var session uint32
+	windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &session)
+	caps = append(caps,
+		diag.Capability{Name: "Remote desktop session", Available: remote != 0, Detail: fmt.Sprintf("session %d", session)},
+		diag.Capability{Name: "Elevated", Available: windows.GetCurrentProcessToken().IsElevated()},
+		diag.Capability{Name: "MSIX package", Available: config.IsPackaged()},
+	)
+	return caps
+}
+
+// webView2Version returns the installed WebView2 runtime version, empty if
+// there is none. The keys are the ones the installer checks
+func webView2Version() string {
+	const client = `Microsoft\EdgeUpdate\Clients\{F3017226-FE2A-4295-8BDF-00C3A9A7E4C5}`
+	for _, k := range []struct {
+		root registry.Key
+		path string
+	}{
+		{registry.LOCAL_MACHINE, `SOFTWARE\WOW6432Node\` + client},
+		{registry.LOCAL_MACHINE, `SOFTWARE\` + client},
+		{registry.CURRENT_USER, `Software\` + client},
+	} {
+		key, err := registry.OpenKey(k.root, k.path, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		v, _, err := key.GetStringValue("pv")
+		key.Close()
+		if err == nil && v != "" && v != "0.0.0.0" {
+			return v
+		}
+	}
+	return ""
+}
+
+// displayTopology lists each display's bounds and effective DPI
+func displayTopology() []diag.Display {
+	var displays []diag.Display
+	for i := 0; i < screenshot.GetDisplayCount(); i++ {
+		b := screenshot.GetDisplayBounds(i)
+		d := diag.Display{Index: i, X: b.Min.X, Y: b.Min.Y, Width: b.Dx(), Height: b.Dy(), Primary: b.Min == image.Point{}}
+		if procGetDpiForMonitor.Find() == nil {
+			rect := screenshot.RECT{Left: int32(b.Min.X), Top: int32(b.Min.Y), Right: int32(b.Max.X), Bottom: int32(b.Max.Y)}
+			monitor, _, _ := procMonitorFromRect.Call(uintptr(unsafe.Pointer(&rect)), monitorDefaultNearest)
+			var dpiX, dpiY uint32
+			if ret, _, _ := procGetDpiForMonitor.Call(monitor, mdtEffectiveDPI, uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY))); ret == 0 {
+				d.DPI = int(dpiX)
+				d.Scale = float64(dpiX) / 96
+			}
+		}
+		displays = append(displays, d)
+	}
+	return displays
+}
+
+// winshotResources samples this process and any other running WinShot, so a
+// bundle made from the command line still shows the tray app's counts
+func winshotResources() map[string]diag.ResourceCounts {
+	self := windows.GetCurrentProcessId()
+	resources := map[string]diag.ResourceCounts{
+		fmt.Sprintf("this process (pid %d)", self): processResources(),
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return resources
+	}
+	for _, pid := range findProcesses(filepath.Base(exe)) {
+		if pid == self {
+			continue
+		}
+		h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+		if err != nil {
+			continue
+		}
+		resources[fmt.Sprintf("winshot (pid %d)", pid)] = resourcesOf(uintptr(h))
+		windows.CloseHandle(h)
+	}
+	return resources
+}
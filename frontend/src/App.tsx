@@ -13,17 +13,23 @@ import { StatusBar } from './components/status-bar';
 import { AnnotationToolbar } from './components/annotation-toolbar';
 import { ExportToolbar } from './components/export-toolbar';
 import { CropToolbar } from './components/crop-toolbar';
-import { CaptureResult, CaptureMode, WindowInfo, Annotation, EditorTool, OutputRatio, CropArea, CropAspectRatio, CropState, BorderType, LibraryImage } from './types';
+import { CaptureResult, CaptureMode, WindowInfo, Annotation, EditorTool, OutputRatio, CropArea, CropAspectRatio, CropState, BorderType, LibraryImage, Adjustments } from './types';
 import {
+  AdjustImage,
+  ApplyDeviceFrame,
+  CaptureCountdown,
   CaptureFullscreen,
   CaptureWindow,
   SaveImage,
   QuickSave,
+  ShareImage,
+  ShareLocal,
   MinimizeToTray,
   PrepareRegionCapture,
   FinishRegionCapture,
   UpdateWindowSize,
   GetConfig,
+  GetDeviceFrames,
   GetEditorConfig,
   SaveEditorConfig,
   OpenImage,
@@ -31,12 +37,17 @@ import {
   CheckForUpdate,
   GetSkippedVersion,
   IsR2Configured,
+  IsS3Configured,
   GetGDriveStatus,
-  UploadToR2,
-  UploadToGDrive,
+  IsConfluenceConfigured,
+  IsEphemeralConfigured,
+  QueueUpload,
+  CancelUpload,
+  ListUploads,
   OpenInEditor,
+  ShowWindow,
 } from '../wailsjs/go/main/App';
-import { updater } from '../wailsjs/go/models';
+import { config, updater, upload } from '../wailsjs/go/models';
 import { EventsOn, EventsOff, WindowGetSize } from '../wailsjs/runtime/runtime';
 import { extractDominantEdgeColor } from './utils/extract-edge-color';
 
@@ -57,6 +68,18 @@ const DEFAULT_EDITOR_SETTINGS = {
   borderType: 'center' as BorderType,
 };
 
+// Tonal adjustments that leave the image unchanged
+const NO_ADJUSTMENTS: Adjustments = {
+  brightness: 0,
+  contrast: 0,
+  gamma: 1,
+  grayscale: false,
+};
+
+function isNoAdjustment(adj: Adjustments): boolean {
+  return adj.brightness === 0 && adj.contrast === 0 && adj.gamma === 1 && !adj.grayscale;
+}
+
 // Helper to parse ratio string into numeric ratio
 function parseRatio(ratio: OutputRatio): number | null {
   if (ratio === 'auto') return null;
@@ -134,7 +157,10 @@ function constrainToAspectRatio(area: CropArea, ratio: CropAspectRatio): CropAre
 function App() {
   const stageRef = useRef<Konva.Stage>(null);
   const [screenshot, setScreenshot] = useState<CaptureResult | null>(null);
+  // Low resolution JPEG of a large capture, shown while its PNG encodes
+  const [preview, setPreview] = useState<CaptureResult | null>(null);
   const [isCapturing, setIsCapturing] = useState(false);
+  const [captureDelay, setCaptureDelay] = useState(0); // Seconds before toolbar captures
   const [showWindowPicker, setShowWindowPicker] = useState(false);
   const [showLibrary, setShowLibrary] = useState(false);
   const [statusMessage, setStatusMessage] = useState<string | undefined>();
@@ -156,6 +182,14 @@ function App() {
   const [borderOpacity, setBorderOpacity] = useState(DEFAULT_EDITOR_SETTINGS.borderOpacity);
   const [borderType, setBorderType] = useState<BorderType>(DEFAULT_EDITOR_SETTINGS.borderType);
   const [editorSettingsLoaded, setEditorSettingsLoaded] = useState(false);
+  // Tonal adjustments start from the export defaults in settings
+  const [adjustments, setAdjustments] = useState<Adjustments>(NO_ADJUSTMENTS);
+  const [defaultAdjustments, setDefaultAdjustments] = useState<Adjustments>(NO_ADJUSTMENTS);
+  // Device mockup wrapped around the screenshot, empty for none
+  const [deviceFrame, setDeviceFrame] = useState('');
+  const [deviceFrames, setDeviceFrames] = useState<string[]>([]);
+  // Adjusted and framed copy of the screenshot the canvas shows, and the capture it came from
+  const [processed, setProcessed] = useState<{ source: CaptureResult; result: CaptureResult } | null>(null);
   // Stores settings when background is hidden, so they can be restored
   const [savedBackgroundSettings, setSavedBackgroundSettings] = useState<{
     padding: number;
@@ -228,10 +262,22 @@ function App() {
 
   // Cloud upload state
   const [isR2Configured, setIsR2Configured] = useState(false);
+  const [isS3Configured, setIsS3Configured] = useState(false);
   const [isGDriveConnected, setIsGDriveConnected] = useState(false);
-  const [isUploading, setIsUploading] = useState(false);
+  const [isConfluenceConfigured, setIsConfluenceConfigured] = useState(false);
+  const [isEphemeralConfigured, setIsEphemeralConfigured] = useState(false);
+  const [uploads, setUploads] = useState<upload.Progress[]>([]);
+  // Uploads queued from the editor, reported when they finish, and finished
+  // uploads not yet known to be the editor's
+  const editorUploadsRef = useRef(new Set<string>());
+  const finishedUploadsRef = useRef(new Map<string, upload.Progress>());
   const [toast, setToast] = useState<{ message: string; type: 'success' | 'error' } | null>(null);
 
+  // The full capture replaces its preview as soon as it arrives
+  useEffect(() => {
+    setPreview(null);
+  }, [screenshot]);
+
   // Load editor settings from Go config on startup
   useEffect(() => {
     const loadEditorSettings = async () => {
@@ -253,7 +299,9 @@ function App() {
           if (cfg.borderColor) setBorderColor(cfg.borderColor);
           if (cfg.borderOpacity !== undefined) setBorderOpacity(cfg.borderOpacity);
           if (cfg.borderType) setBorderType(cfg.borderType as BorderType);
+          if (cfg.deviceFrame) setDeviceFrame(cfg.deviceFrame);
         }
+        setDeviceFrames(await GetDeviceFrames());
       } catch (err) {
         console.error('Failed to load editor settings:', err);
       }
@@ -281,10 +329,11 @@ function App() {
       borderColor,
       borderOpacity,
       borderType,
+      deviceFrame: deviceFrame || undefined,
     }).catch(err => {
       console.error('Failed to save editor settings:', err);
     });
-  }, [padding, cornerRadius, shadowSize, backgroundColor, outputRatio, showBackground, inset, autoBackground, insetBackgroundColor, shapeCornerRadius, borderEnabled, borderWeight, borderColor, borderOpacity, borderType, editorSettingsLoaded]);
+  }, [padding, cornerRadius, shadowSize, backgroundColor, outputRatio, showBackground, inset, autoBackground, insetBackgroundColor, shapeCornerRadius, borderEnabled, borderWeight, borderColor, borderOpacity, borderType, deviceFrame, editorSettingsLoaded]);
 
   // Load export settings from config on startup
   useEffect(() => {
@@ -294,6 +343,10 @@ function App() {
         if (cfg.export?.jpegQuality) {
           setJpegQuality(cfg.export.jpegQuality);
         }
+        if (cfg.export?.adjustments) {
+          setAdjustments(cfg.export.adjustments);
+          setDefaultAdjustments(cfg.export.adjustments);
+        }
       } catch (err) {
         console.error('Failed to load export settings:', err);
       }
@@ -301,6 +354,40 @@ function App() {
     loadExportSettings();
   }, []);
 
+  // Preview tonal adjustments and the device frame on the screenshot. The
+  // canvas draws the processed copy, so exports carry them as well. Crop
+  // works on the unframed image, so the frame is left off while cropping
+  const frameName = cropMode ? '' : deviceFrame;
+  useEffect(() => {
+    if (!screenshot || (isNoAdjustment(adjustments) && !frameName)) {
+      setProcessed(null);
+      return;
+    }
+    let cancelled = false;
+    const timer = setTimeout(async () => {
+      try {
+        let result = screenshot;
+        if (!isNoAdjustment(adjustments)) {
+          result = (await AdjustImage(result.data, new config.AdjustmentConfig(adjustments))) as CaptureResult;
+        }
+        if (frameName) {
+          result = (await ApplyDeviceFrame(result.data, frameName)) as CaptureResult;
+        }
+        if (!cancelled) {
+          setProcessed({ source: screenshot, result: { ...result, metadata: screenshot.metadata } });
+        }
+      } catch (err) {
+        console.error('Failed to process image:', err);
+      }
+    }, 150);
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [screenshot, adjustments, frameName]);
+
+  const displayedScreenshot = processed && processed.source === screenshot ? processed.result : screenshot;
+
   // Check for updates on startup
   useEffect(() => {
     const checkForUpdates = async () => {
@@ -335,8 +422,17 @@ function App() {
       const r2 = await IsR2Configured();
       setIsR2Configured(r2);
 
+      const s3 = await IsS3Configured();
+      setIsS3Configured(s3);
+
       const status = await GetGDriveStatus();
       setIsGDriveConnected(status.connected);
+
+      const confluence = await IsConfluenceConfigured();
+      setIsConfluenceConfigured(confluence);
+
+      const ephemeral = await IsEphemeralConfigured();
+      setIsEphemeralConfigured(ephemeral);
     } catch (err) {
       console.error('Failed to check cloud config:', err);
     }
@@ -484,6 +580,7 @@ function App() {
       setPendingAutoCopy(true);
     } catch (error) {
       console.error('Capture failed:', error);
+      setPreview(null);
       setStatusMessage('Capture failed');
       setTimeout(() => setStatusMessage(undefined), 3000);
     }
@@ -491,6 +588,22 @@ function App() {
     setIsCapturing(false);
   }, [resetAnnotations]);
 
+  // Toolbar captures wait out the chosen delay first, hotkeys have their own
+  const handleToolbarCapture = useCallback(async (mode: CaptureMode) => {
+    if (captureDelay === 0 || mode === 'window') {
+      handleCapture(mode);
+      return;
+    }
+    // The window is hidden while counting down and stays hidden for the capture
+    if (!(await CaptureCountdown(captureDelay))) {
+      return;
+    }
+    await handleCapture(mode);
+    if (mode === 'fullscreen') {
+      ShowWindow();
+    }
+  }, [captureDelay, handleCapture]);
+
   const handleWindowSelect = async (window: WindowInfo) => {
     setShowWindowPicker(false);
     setIsCapturing(true);
@@ -513,6 +626,7 @@ function App() {
       setPendingAutoCopy(true);
     } catch (error) {
       console.error('Window capture failed:', error);
+      setPreview(null);
       setStatusMessage('Capture failed');
       setTimeout(() => setStatusMessage(undefined), 3000);
     }
@@ -744,11 +858,17 @@ function App() {
       setShowLibrary(true);
     };
 
+    // Show a quick preview of a large capture until the full image arrives
+    const handleCapturePreview = (data: CaptureResult) => {
+      setPreview(data);
+    };
+
     EventsOn('hotkey:fullscreen', handleFullscreen);
     EventsOn('hotkey:region', handleRegion);
     EventsOn('hotkey:window', handleWindow);
     EventsOn('region:selected', handleRegionSelected);
     EventsOn('tray:library', handleTrayLibrary);
+    EventsOn('capture:preview', handleCapturePreview);
 
     return () => {
       EventsOff('hotkey:fullscreen');
@@ -756,6 +876,7 @@ function App() {
       EventsOff('hotkey:window');
       EventsOff('region:selected');
       EventsOff('tray:library');
+      EventsOff('capture:preview');
     };
   }, [handleCapture, handleNativeRegionSelect]);
 
@@ -1189,7 +1310,7 @@ function App() {
   // Export helpers - simplified since cropped image is now the current screenshot
   const getCanvasDataUrl = useCallback((format: 'png' | 'jpeg'): string | null => {
     const stage = stageRef.current;
-    if (!stage || !screenshot) return null;
+    if (!stage || !displayedScreenshot) return null;
 
     const mimeType = format === 'jpeg' ? 'image/jpeg' : 'image/png';
     // Use configured quality for JPEG (0-100 from config, convert to 0-1 for canvas API)
@@ -1197,8 +1318,8 @@ function App() {
 
     // Calculate the actual output dimensions
     const { totalWidth, totalHeight } = calculateOutputDimensions(
-      screenshot.width,
-      screenshot.height,
+      displayedScreenshot.width,
+      displayedScreenshot.height,
       padding,
       outputRatio
     );
@@ -1240,7 +1361,7 @@ function App() {
     transformers.forEach((tr) => tr.show());
 
     return dataUrl;
-  }, [screenshot, padding, outputRatio, jpegQuality]);
+  }, [displayedScreenshot, padding, outputRatio, jpegQuality]);
 
   const getBase64FromDataUrl = (dataUrl: string): string => {
     return dataUrl.split(',')[1];
@@ -1311,6 +1432,50 @@ function App() {
     setTimeout(() => setStatusMessage(undefined), 3000);
   }, [getCanvasDataUrl]);
 
+  // Send the edited image to the Windows share sheet
+  const handleShare = useCallback(async (format: 'png' | 'jpeg') => {
+    const dataUrl = getCanvasDataUrl(format);
+    if (!dataUrl) {
+      setStatusMessage('Share failed: No canvas available');
+      return;
+    }
+
+    setIsExporting(true);
+    setStatusMessage('Opening share sheet...');
+
+    try {
+      await ShareImage(getBase64FromDataUrl(dataUrl), format);
+      setStatusMessage(undefined);
+    } catch (error) {
+      console.error('Share failed:', error);
+      setStatusMessage(`Share failed: ${error}`);
+      setTimeout(() => setStatusMessage(undefined), 3000);
+    }
+
+    setIsExporting(false);
+  }, [getCanvasDataUrl]);
+
+  // Serve the edited image on the local network and copy its link
+  const handleShareLocal = useCallback(async (format: 'png' | 'jpeg') => {
+    const dataUrl = getCanvasDataUrl(format);
+    if (!dataUrl) {
+      setStatusMessage('Share failed: No canvas available');
+      return;
+    }
+
+    setIsExporting(true);
+    try {
+      const share = await ShareLocal(getBase64FromDataUrl(dataUrl), format, '');
+      setStatusMessage(`Local link copied: ${share.url}`);
+    } catch (error) {
+      console.error('Local share failed:', error);
+      setStatusMessage(`Local share failed: ${error}`);
+    }
+
+    setIsExporting(false);
+    setTimeout(() => setStatusMessage(undefined), 3000);
+  }, [getCanvasDataUrl]);
+
   // Copy file path to clipboard
   const handleCopyPath = useCallback(async () => {
     if (!lastSavedPath) {
@@ -1330,8 +1495,59 @@ function App() {
     }
   }, [lastSavedPath]);
 
+  // Report the outcome of an upload queued from the editor
+  const reportUpload = useCallback(async (p: upload.Progress) => {
+    const providerNames: Record<string, string> = { r2: 'R2', s3: 'S3', gdrive: 'Google Drive', confluence: 'Confluence', ephemeral: 'temporary host' };
+    if (p.state === 'done' && p.url) {
+      // Copy URL to clipboard
+      try {
+        await navigator.clipboard.writeText(p.url);
+        setToast({ message: 'Uploaded! URL copied to clipboard', type: 'success' });
+      } catch {
+        // Clipboard failed, still show success with URL
+        setToast({ message: `Uploaded! ${p.url}`, type: 'success' });
+      }
+      const expiry = p.expiresAt
+        ? `, link expires ${new Date(p.expiresAt).toLocaleString(undefined, { month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit' })}`
+        : '';
+      setStatusMessage(`Uploaded to ${providerNames[p.provider] ?? p.provider}${expiry}`);
+    } else if (p.state === 'cancelled') {
+      setStatusMessage('Upload cancelled');
+    } else {
+      setToast({ message: `Upload failed: ${p.error}`, type: 'error' });
+      setStatusMessage('Upload failed');
+    }
+    setTimeout(() => setStatusMessage(undefined), 3000);
+  }, []);
+
+  // Track the upload queue, which also carries hotkey and library uploads
+  useEffect(() => {
+    ListUploads().then((list) => setUploads(list ?? [])).catch(() => {});
+
+    const handleProgress = (p: upload.Progress) => {
+      const finished = p.state === 'done' || p.state === 'failed' || p.state === 'cancelled';
+      setUploads((prev) => {
+        const rest = prev.filter((u) => u.id !== p.id);
+        return finished ? rest : [...rest, p];
+      });
+      if (!finished) return;
+      if (editorUploadsRef.current.delete(p.id)) {
+        reportUpload(p);
+      } else {
+        // QueueUpload may not have returned the ID yet
+        finishedUploadsRef.current.set(p.id, p);
+        setTimeout(() => finishedUploadsRef.current.delete(p.id), 5000);
+      }
+    };
+
+    EventsOn('upload:progress', handleProgress);
+    return () => {
+      EventsOff('upload:progress');
+    };
+  }, [reportUpload]);
+
   // Cloud upload handler
-  const handleCloudUpload = useCallback(async (provider: 'r2' | 'gdrive') => {
+  const handleCloudUpload = useCallback(async (provider: 'r2' | 's3' | 'gdrive' | 'confluence' | 'ephemeral') => {
     if (!screenshot) return;
 
     const dataUrl = getCanvasDataUrl('png');
@@ -1340,9 +1556,6 @@ function App() {
       return;
     }
 
-    setIsUploading(true);
-    setStatusMessage('Uploading...');
-
     try {
       // Generate filename
       const now = new Date();
@@ -1352,48 +1565,43 @@ function App() {
       // Get base64 data
       const base64Data = dataUrl.split(',')[1];
 
-      // Upload
-      let result;
-      if (provider === 'r2') {
-        result = await UploadToR2(base64Data, filename);
-      } else {
-        result = await UploadToGDrive(base64Data, filename);
-      }
-
-      if (result.success) {
-        // Copy URL to clipboard
-        try {
-          await navigator.clipboard.writeText(result.publicUrl);
-          setToast({ message: 'Uploaded! URL copied to clipboard', type: 'success' });
-        } catch {
-          // Clipboard failed, still show success with URL
-          setToast({ message: `Uploaded! ${result.publicUrl}`, type: 'success' });
-        }
-        setStatusMessage(`Uploaded to ${provider === 'r2' ? 'R2' : 'Google Drive'}`);
+      // Queue the upload; its progress and outcome arrive as events
+      const id = await QueueUpload(base64Data, filename, provider);
+      const finished = finishedUploadsRef.current.get(id);
+      if (finished) {
+        finishedUploadsRef.current.delete(id);
+        reportUpload(finished);
       } else {
-        setToast({ message: `Upload failed: ${result.error}`, type: 'error' });
-        setStatusMessage('Upload failed');
+        editorUploadsRef.current.add(id);
+        setStatusMessage('Uploading...');
       }
     } catch (err) {
-      const errorMsg = err instanceof Error ? err.message : 'Unknown error';
+      const errorMsg = err instanceof Error ? err.message : String(err);
       setToast({ message: `Upload error: ${errorMsg}`, type: 'error' });
       setStatusMessage('Upload failed');
+      setTimeout(() => setStatusMessage(undefined), 3000);
     }
+  }, [screenshot, getCanvasDataUrl, reportUpload]);
 
-    setIsUploading(false);
-    setTimeout(() => setStatusMessage(undefined), 3000);
-  }, [screenshot, getCanvasDataUrl]);
+  // Cancel a queued upload
+  const handleCancelUpload = useCallback(async (id: string) => {
+    try {
+      await CancelUpload(id);
+    } catch (err) {
+      console.error('Cancel upload failed:', err);
+    }
+  }, []);
 
   // Helper to copy styled canvas to clipboard (used by auto-copy and manual copy)
   const copyStyledCanvasToClipboard = useCallback(async (): Promise<boolean> => {
     const stage = stageRef.current;
-    if (!stage || !screenshot) return false;
+    if (!stage || !displayedScreenshot) return false;
 
     try {
       // Calculate the actual output dimensions
       const { totalWidth, totalHeight } = calculateOutputDimensions(
-        screenshot.width,
-        screenshot.height,
+        displayedScreenshot.width,
+        displayedScreenshot.height,
         padding,
         outputRatio
       );
@@ -1446,7 +1654,7 @@ function App() {
       console.error('Failed to copy styled canvas:', error);
       return false;
     }
-  }, [screenshot, padding, outputRatio]);
+  }, [displayedScreenshot, padding, outputRatio]);
 
   // Manual copy to clipboard handler (uses helper)
   const handleCopyToClipboard = useCallback(async () => {
@@ -1574,7 +1782,7 @@ function App() {
       )}
       <TitleBar onMinimize={handleMinimizeToTray} />
       <CaptureToolbar
-        onCapture={handleCapture}
+        onCapture={handleToolbarCapture}
         isCapturing={isCapturing}
         hasScreenshot={!!screenshot}
         onClear={handleClear}
@@ -1582,6 +1790,8 @@ function App() {
         onOpenSettings={() => setShowSettings(true)}
         onImportImage={handleImportImage}
         onClipboardCapture={handleClipboardCapture}
+        delay={captureDelay}
+        onDelayChange={setCaptureDelay}
       />
 
       {screenshot && !cropMode && (
@@ -1627,9 +1837,18 @@ function App() {
         </div>
       )}
 
-      <div className="flex flex-1 overflow-hidden">
+      <div className="relative flex flex-1 overflow-hidden">
+        {preview && (
+          <div className="absolute inset-0 z-10 flex items-center justify-center bg-slate-900/60 pointer-events-none">
+            <img
+              src={`data:image/jpeg;base64,${preview.data}`}
+              alt=""
+              className="max-w-[90%] max-h-[90%] object-contain rounded-lg shadow-2xl opacity-90"
+            />
+          </div>
+        )}
         <EditorCanvas
-          screenshot={screenshot}
+          screenshot={displayedScreenshot}
           padding={padding}
           cornerRadius={cornerRadius}
           shadowSize={shadowSize}
@@ -1675,8 +1894,8 @@ function App() {
             backgroundColor={backgroundColor}
             outputRatio={outputRatio}
             showBackground={showBackground}
-            imageWidth={screenshot.width}
-            imageHeight={screenshot.height}
+            imageWidth={displayedScreenshot?.width ?? screenshot.width}
+            imageHeight={displayedScreenshot?.height ?? screenshot.height}
             inset={inset}
             autoBackground={autoBackground}
             extractedColor={extractedColor}
@@ -1700,6 +1919,12 @@ function App() {
             onBorderColorChange={setBorderColor}
             onBorderOpacityChange={setBorderOpacity}
             onBorderTypeChange={setBorderType}
+            adjustments={adjustments}
+            onAdjustmentsChange={setAdjustments}
+            onAdjustmentsReset={() => setAdjustments(defaultAdjustments)}
+            deviceFrame={deviceFrame}
+            deviceFrames={deviceFrames}
+            onDeviceFrameChange={setDeviceFrame}
           />
         )}
       </div>
@@ -1708,6 +1933,8 @@ function App() {
         <ExportToolbar
           onSave={handleSave}
           onQuickSave={handleQuickSave}
+          onShare={handleShare}
+          onShareLocal={handleShareLocal}
           onCopyToClipboard={handleCopyToClipboard}
           onCopyPath={handleCopyPath}
           onOpenLibrary={() => setShowLibrary(true)}
@@ -1715,8 +1942,12 @@ function App() {
           lastSavedPath={lastSavedPath}
           isExporting={isExporting}
           isR2Configured={isR2Configured}
+          isS3Configured={isS3Configured}
           isGDriveConnected={isGDriveConnected}
-          isUploading={isUploading}
+          isConfluenceConfigured={isConfluenceConfigured}
+          isEphemeralConfigured={isEphemeralConfigured}
+          uploads={uploads}
+          onCancelUpload={handleCancelUpload}
         />
       )}
 
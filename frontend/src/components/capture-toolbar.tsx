@@ -1,5 +1,8 @@
 import { CaptureMode } from '../types';
-import { Monitor, Scan, AppWindow, Settings, ChevronDown, FolderOpen, Clipboard } from 'lucide-react';
+import { Monitor, Scan, AppWindow, Settings, ChevronDown, FolderOpen, Clipboard, Timer } from 'lucide-react';
+
+// Delays offered before fullscreen and region captures, in seconds
+const CAPTURE_DELAYS = [0, 3, 5, 10];
 
 interface CaptureToolbarProps {
   onCapture: (mode: CaptureMode) => void;
@@ -10,9 +13,11 @@ interface CaptureToolbarProps {
   onOpenSettings?: () => void;
   onImportImage?: () => void;
   onClipboardCapture?: () => void;
+  delay?: number;
+  onDelayChange?: (seconds: number) => void;
 }
 
-export function CaptureToolbar({ onCapture, isCapturing, hasScreenshot, onClear, onMinimize, onOpenSettings, onImportImage, onClipboardCapture }: CaptureToolbarProps) {
+export function CaptureToolbar({ onCapture, isCapturing, hasScreenshot, onClear, onMinimize, onOpenSettings, onImportImage, onClipboardCapture, delay = 0, onDelayChange }: CaptureToolbarProps) {
   return (
     <div className="flex items-center gap-4 px-4 py-3 glass">
       <div className="flex gap-2">
@@ -94,6 +99,25 @@ export function CaptureToolbar({ onCapture, isCapturing, hasScreenshot, onClear,
             Import
           </button>
         )}
+
+        {/* Delay before fullscreen and region captures */}
+        {onDelayChange && (
+          <div className="flex items-center gap-1.5 text-slate-400" title="Count down before fullscreen and region captures, to open menus and tooltips first">
+            <Timer className="w-4 h-4" />
+            <select
+              value={delay}
+              onChange={(e) => onDelayChange(Number(e.target.value))}
+              disabled={isCapturing}
+              className="px-2 py-1 rounded-lg bg-white/10 text-white text-sm border border-white/10 focus:outline-none focus:ring-2 focus:ring-violet-500/50 cursor-pointer"
+            >
+              {CAPTURE_DELAYS.map((seconds) => (
+                <option key={seconds} value={seconds} className="bg-slate-800">
+                  {seconds === 0 ? 'No delay' : `${seconds}s`}
+                </option>
+              ))}
+            </select>
+          </div>
+        )}
       </div>
 
       {hasScreenshot && (
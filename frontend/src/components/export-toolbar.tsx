@@ -1,23 +1,32 @@
 import { useState, useEffect } from 'react';
-import { ClipboardCopy, Download, Save, Link, Cloud, ChevronUp, Image } from 'lucide-react';
+import { ClipboardCopy, Download, Save, Link, Cloud, ChevronUp, Image, Share2, Wifi, X } from 'lucide-react';
+import { upload } from '../../wailsjs/go/models';
 
 interface ExportToolbarProps {
   onSave: (format: 'png' | 'jpeg') => void;
   onQuickSave: (format: 'png' | 'jpeg') => void;
+  onShare: (format: 'png' | 'jpeg') => void;
+  onShareLocal: (format: 'png' | 'jpeg') => void;
   onCopyToClipboard: () => void;
   onCopyPath: () => void;
   onOpenLibrary: () => void;
-  onCloudUpload: (provider: 'r2' | 'gdrive') => void;
+  onCloudUpload: (provider: 'r2' | 's3' | 'gdrive' | 'confluence' | 'ephemeral') => void;
   lastSavedPath: string | null;
   isExporting: boolean;
   isR2Configured: boolean;
+  isS3Configured: boolean;
   isGDriveConnected: boolean;
-  isUploading: boolean;
+  isConfluenceConfigured: boolean;
+  isEphemeralConfigured: boolean;
+  uploads: upload.Progress[];
+  onCancelUpload: (id: string) => void;
 }
 
 export function ExportToolbar({
   onSave,
   onQuickSave,
+  onShare,
+  onShareLocal,
   onCopyToClipboard,
   onCopyPath,
   onOpenLibrary,
@@ -25,8 +34,12 @@ export function ExportToolbar({
   lastSavedPath,
   isExporting,
   isR2Configured,
+  isS3Configured,
   isGDriveConnected,
-  isUploading,
+  isConfluenceConfigured,
+  isEphemeralConfigured,
+  uploads,
+  onCancelUpload,
 }: ExportToolbarProps) {
   const [format, setFormat] = useState<'png' | 'jpeg'>('png');
   const [showUploadMenu, setShowUploadMenu] = useState(false);
@@ -122,6 +135,34 @@ export function ExportToolbar({
           Quick Save
         </button>
 
+        {/* Windows share sheet */}
+        <button
+          onClick={() => onShare(format)}
+          disabled={isExporting}
+          className="flex items-center gap-1.5 px-3 py-1.5 text-sm rounded-lg font-medium transition-all duration-200
+                     bg-white/5 hover:bg-white/10 border border-white/10 hover:border-white/20
+                     text-slate-300 hover:text-white
+                     disabled:opacity-50 disabled:cursor-not-allowed"
+          title="Share to Mail, OneNote, Nearby Share..."
+        >
+          <Share2 className="w-4 h-4" />
+          Share
+        </button>
+
+        {/* Link on the local network */}
+        <button
+          onClick={() => onShareLocal(format)}
+          disabled={isExporting}
+          className="flex items-center gap-1.5 px-3 py-1.5 text-sm rounded-lg font-medium transition-all duration-200
+                     bg-white/5 hover:bg-white/10 border border-white/10 hover:border-white/20
+                     text-slate-300 hover:text-white
+                     disabled:opacity-50 disabled:cursor-not-allowed"
+          title="Copy a short-lived link for someone on the same network"
+        >
+          <Wifi className="w-4 h-4" />
+          Local Link
+        </button>
+
         {/* Library */}
         <button
           onClick={onOpenLibrary}
@@ -139,13 +180,13 @@ export function ExportToolbar({
         <div className="relative">
           <button
             onClick={() => setShowUploadMenu(!showUploadMenu)}
-            disabled={isExporting || isUploading || (!isR2Configured && !isGDriveConnected)}
+            disabled={isExporting || (!isR2Configured && !isS3Configured && !isGDriveConnected && !isConfluenceConfigured && !isEphemeralConfigured)}
             className="flex items-center gap-1.5 px-3 py-1.5 text-sm rounded-lg font-medium transition-all duration-200
                        bg-gradient-to-r from-sky-500/20 to-cyan-500/20 hover:from-sky-500/30 hover:to-cyan-500/30
                        border border-sky-500/30 hover:border-sky-500/50
                        text-sky-300 hover:text-sky-200
                        disabled:opacity-50 disabled:cursor-not-allowed"
-            title={!isR2Configured && !isGDriveConnected ? 'Configure cloud providers in Settings > Cloud' : 'Upload to Cloud'}
+            title={!isR2Configured && !isS3Configured && !isGDriveConnected && !isConfluenceConfigured && !isEphemeralConfigured ? 'Configure cloud providers in Settings > Cloud' : 'Upload to Cloud'}
           >
             <Cloud className="w-4 h-4" />
             Cloud
@@ -159,25 +200,61 @@ export function ExportToolbar({
                   onCloudUpload('r2');
                   setShowUploadMenu(false);
                 }}
-                disabled={!isR2Configured || isUploading}
+                disabled={!isR2Configured}
                 className="w-full px-3 py-2 text-sm text-left flex items-center gap-2 text-slate-200
                            hover:bg-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
               >
                 <span className={`w-1.5 h-1.5 rounded-full ${isR2Configured ? 'bg-emerald-400' : 'bg-slate-500'}`}></span>
                 Cloudflare R2
               </button>
+              <button
+                onClick={() => {
+                  onCloudUpload('s3');
+                  setShowUploadMenu(false);
+                }}
+                disabled={!isS3Configured}
+                className="w-full px-3 py-2 text-sm text-left flex items-center gap-2 text-slate-200
+                           hover:bg-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                <span className={`w-1.5 h-1.5 rounded-full ${isS3Configured ? 'bg-emerald-400' : 'bg-slate-500'}`}></span>
+                S3
+              </button>
               <button
                 onClick={() => {
                   onCloudUpload('gdrive');
                   setShowUploadMenu(false);
                 }}
-                disabled={!isGDriveConnected || isUploading}
+                disabled={!isGDriveConnected}
                 className="w-full px-3 py-2 text-sm text-left flex items-center gap-2 text-slate-200
                            hover:bg-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
               >
                 <span className={`w-1.5 h-1.5 rounded-full ${isGDriveConnected ? 'bg-emerald-400' : 'bg-slate-500'}`}></span>
                 Google Drive
               </button>
+              <button
+                onClick={() => {
+                  onCloudUpload('confluence');
+                  setShowUploadMenu(false);
+                }}
+                disabled={!isConfluenceConfigured}
+                className="w-full px-3 py-2 text-sm text-left flex items-center gap-2 text-slate-200
+                           hover:bg-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                <span className={`w-1.5 h-1.5 rounded-full ${isConfluenceConfigured ? 'bg-emerald-400' : 'bg-slate-500'}`}></span>
+                Confluence
+              </button>
+              <button
+                onClick={() => {
+                  onCloudUpload('ephemeral');
+                  setShowUploadMenu(false);
+                }}
+                disabled={!isEphemeralConfigured}
+                className="w-full px-3 py-2 text-sm text-left flex items-center gap-2 text-slate-200
+                           hover:bg-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                <span className={`w-1.5 h-1.5 rounded-full ${isEphemeralConfigured ? 'bg-emerald-400' : 'bg-slate-500'}`}></span>
+                Temporary link
+              </button>
             </div>
           )}
         </div>
@@ -206,13 +283,24 @@ export function ExportToolbar({
         </div>
       )}
 
-      {/* Uploading indicator */}
-      {isUploading && (
-        <div className="flex items-center gap-2 ml-2">
+      {/* Upload queue */}
+      {uploads.map((u) => (
+        <div key={u.id} className="flex items-center gap-2 ml-2" title={u.error ? `${u.filename}: ${u.error}` : u.filename}>
           <div className="w-2 h-2 rounded-full bg-sky-400 animate-pulse" />
-          <span className="text-sm text-sky-300 animate-pulse font-medium">Uploading...</span>
+          <span className="text-sm text-sky-300 font-medium">
+            {u.state === 'queued' && 'Queued'}
+            {u.state === 'uploading' && `Uploading ${u.total > 0 ? Math.floor((u.sent * 100) / u.total) : 0}%`}
+            {u.state === 'retrying' && `Retrying${u.retryAt ? ' at ' + new Date(u.retryAt).toLocaleTimeString() : ''}`}
+          </span>
+          <button
+            onClick={() => onCancelUpload(u.id)}
+            className="p-0.5 rounded text-slate-400 hover:text-slate-200 hover:bg-white/10 transition-colors"
+            title="Cancel upload"
+          >
+            <X className="w-3.5 h-3.5" />
+          </button>
         </div>
-      )}
+      ))}
     </div>
   );
 }
@@ -1,7 +1,19 @@
 import { useState, useEffect, useCallback, useRef } from 'react';
 import { LibraryImage } from '../types';
-import { GetLibraryImages, DeleteScreenshot } from '../../wailsjs/go/main/App';
-import { X, Camera, Edit, Trash2, RefreshCw, Image, Calendar } from 'lucide-react';
+import {
+  GetLibraryImages,
+  DeleteScreenshot,
+  FilterLibraryImages,
+  GetLibraryTags,
+  SetScreenshotsFavorite,
+  TagScreenshots,
+  UntagScreenshots,
+  UploadLibraryImages,
+} from '../../wailsjs/go/main/App';
+import { X, Camera, Edit, Trash2, RefreshCw, Image, Calendar, Link, Star, Tag, Upload } from 'lucide-react';
+
+// normalizeTag mirrors library.NormalizeTag so local updates match the index
+const normalizeTag = (tag: string) => tag.trim().split(/\s+/).join('-').toLowerCase();
 
 interface LibraryWindowProps {
   isOpen: boolean;
@@ -15,6 +27,11 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
   const [selectedIndex, setSelectedIndex] = useState<number>(-1);
   const [isLoading, setIsLoading] = useState(false);
   const [isDeleting, setIsDeleting] = useState(false);
+  const [tags, setTags] = useState<string[]>([]);
+  const [filterTags, setFilterTags] = useState<string[]>([]);
+  const [favoritesOnly, setFavoritesOnly] = useState(false);
+  const [isUploading, setIsUploading] = useState(false);
+  const [status, setStatus] = useState('');
   const containerRef = useRef<HTMLDivElement>(null);
 
   // Derived state for selected image
@@ -22,22 +39,29 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
     ? images[selectedIndex]
     : null;
 
-  // Load images when modal opens
+  const isFiltered = filterTags.length > 0 || favoritesOnly;
+
+  // Focus the window when it opens
   useEffect(() => {
     if (isOpen) {
-      loadImages();
       // Focus container for keyboard events
       setTimeout(() => containerRef.current?.focus(), 100);
     }
   }, [isOpen]);
 
-  // Reset selection when images change
+  // Reload when opened or the filter changes
   useEffect(() => {
-    if (images.length > 0) {
-      setSelectedIndex(0);
-    } else {
-      setSelectedIndex(-1);
+    if (isOpen) {
+      loadImages();
     }
+  }, [isOpen, filterTags, favoritesOnly]);
+
+  // Keep the selection when images are updated in place, reset it otherwise
+  useEffect(() => {
+    setSelectedIndex(prev => {
+      if (images.length === 0) return -1;
+      return prev >= 0 && prev < images.length ? prev : 0;
+    });
   }, [images]);
 
   // Keyboard navigation
@@ -114,16 +138,103 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
 
   const loadImages = async () => {
     setIsLoading(true);
+    setStatus('');
     try {
-      const list = await GetLibraryImages();
+      const list = isFiltered
+        ? await FilterLibraryImages(filterTags, favoritesOnly)
+        : await GetLibraryImages();
       setImages((list as LibraryImage[]) || []);
+      setSelectedIndex(-1);
     } catch (error) {
       console.error('Failed to load library images:', error);
       setImages([]);
     }
+    loadTags();
     setIsLoading(false);
   };
 
+  const loadTags = async () => {
+    try {
+      setTags((await GetLibraryTags()) || []);
+    } catch (error) {
+      console.error('Failed to load library tags:', error);
+    }
+  };
+
+  // Applies a change to one image, dropping it when it no longer matches
+  // the filter
+  const updateImage = (filepath: string, update: (image: LibraryImage) => LibraryImage) => {
+    setImages(prev => prev
+      .map(img => (img.filepath === filepath ? update(img) : img))
+      .filter(img => (!favoritesOnly || img.favorite) && filterTags.every(t => img.tags?.includes(t))));
+  };
+
+  const toggleFilterTag = (tag: string) => {
+    setFilterTags(prev => (prev.includes(tag) ? prev.filter(t => t !== tag) : [...prev, tag]));
+  };
+
+  const handleToggleFavorite = async () => {
+    if (!selectedImage) return;
+    const favorite = !selectedImage.favorite;
+    try {
+      await SetScreenshotsFavorite([selectedImage.filepath], favorite);
+      updateImage(selectedImage.filepath, img => ({ ...img, favorite }));
+    } catch (error) {
+      console.error('Failed to update favorite:', error);
+    }
+  };
+
+  const handleAddTags = async () => {
+    if (!selectedImage) return;
+    const input = window.prompt('Add tags, separated by commas');
+    const added = (input || '').split(',').map(normalizeTag).filter(Boolean);
+    if (added.length === 0) return;
+    try {
+      await TagScreenshots([selectedImage.filepath], added);
+      updateImage(selectedImage.filepath, img => ({
+        ...img,
+        tags: Array.from(new Set([...(img.tags || []), ...added])).sort(),
+      }));
+      loadTags();
+    } catch (error) {
+      console.error('Failed to tag screenshot:', error);
+      setStatus(`Tagging failed: ${error}`);
+    }
+  };
+
+  const handleRemoveTag = async (tag: string) => {
+    if (!selectedImage) return;
+    try {
+      await UntagScreenshots([selectedImage.filepath], [tag]);
+      updateImage(selectedImage.filepath, img => ({
+        ...img,
+        tags: (img.tags || []).filter(t => t !== tag),
+      }));
+      loadTags();
+    } catch (error) {
+      console.error('Failed to untag screenshot:', error);
+    }
+  };
+
+  // Uploads every image the filter shows, e.g. everything tagged
+  // "release-notes". The backend copies the links to the clipboard
+  const handleUploadShown = async () => {
+    if (images.length === 0) return;
+    setIsUploading(true);
+    setStatus('');
+    try {
+      const results = await UploadLibraryImages(images.map(img => img.filepath), '');
+      const uploaded = results.filter(r => r.url).length;
+      setStatus(uploaded === results.length
+        ? `Uploaded ${uploaded}, links copied`
+        : `Uploaded ${uploaded} of ${results.length}, links copied`);
+    } catch (error) {
+      console.error('Failed to upload screenshots:', error);
+      setStatus(`Upload failed: ${error}`);
+    }
+    setIsUploading(false);
+  };
+
   const handleSelect = useCallback((index: number) => {
     setSelectedIndex(index);
   }, []);
@@ -197,6 +308,37 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
           </button>
         </div>
 
+        {/* Filter */}
+        {(tags.length > 0 || isFiltered || images.some(img => img.favorite)) && (
+          <div className="px-5 py-3 border-b border-white/10 flex items-center gap-2 flex-wrap">
+            <button
+              onClick={() => setFavoritesOnly(prev => !prev)}
+              className={`px-2.5 py-1 rounded-lg text-xs flex items-center gap-1 border transition-all duration-200 ${
+                favoritesOnly
+                  ? 'bg-amber-500/20 border-amber-500/40 text-amber-300'
+                  : 'bg-white/5 border-white/10 text-slate-400 hover:text-white'
+              }`}
+            >
+              <Star className={`w-3 h-3 ${favoritesOnly ? 'fill-amber-300' : ''}`} />
+              Favorites
+            </button>
+            {tags.map(tag => (
+              <button
+                key={tag}
+                onClick={() => toggleFilterTag(tag)}
+                className={`px-2.5 py-1 rounded-lg text-xs flex items-center gap-1 border transition-all duration-200 ${
+                  filterTags.includes(tag)
+                    ? 'bg-violet-500/20 border-violet-500/40 text-violet-300'
+                    : 'bg-white/5 border-white/10 text-slate-400 hover:text-white'
+                }`}
+              >
+                <Tag className="w-3 h-3" />
+                {tag}
+              </button>
+            ))}
+          </div>
+        )}
+
         {/* Grid */}
         <div className="flex-1 overflow-y-auto p-4">
           {isLoading ? (
@@ -210,6 +352,12 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
                 <span>Loading screenshots...</span>
               </div>
             </div>
+          ) : images.length === 0 && isFiltered ? (
+            <div className="flex flex-col items-center justify-center py-20 text-slate-400">
+              <Tag className="w-16 h-16 text-slate-600 mb-4" />
+              <p className="text-lg font-medium mb-2">No screenshots match</p>
+              <p className="text-sm text-slate-500">Select fewer tags or turn off Favorites</p>
+            </div>
           ) : images.length === 0 ? (
             <div className="flex flex-col items-center justify-center py-20 text-slate-400">
               <Image className="w-16 h-16 text-slate-600 mb-4" />
@@ -254,11 +402,32 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
                       <Calendar className="w-3 h-3" />
                       {formatDate(image.modifiedDate)}
                     </div>
+                    {image.tags && image.tags.length > 0 && (
+                      <div className="flex items-center gap-1 text-[10px] text-violet-300/80 mt-0.5 truncate">
+                        <Tag className="w-3 h-3 shrink-0" />
+                        {image.tags.join(', ')}
+                      </div>
+                    )}
+                    {image.expires && (
+                      <div className="flex items-center gap-1 text-[10px] text-amber-300/80 mt-0.5" title={image.sharedUrl}>
+                        <Link className="w-3 h-3" />
+                        {new Date(image.expires) > new Date()
+                          ? `Link expires ${formatDate(image.expires)}`
+                          : 'Link expired'}
+                      </div>
+                    )}
                   </div>
 
-                  {/* Dimensions badge */}
-                  <div className="absolute top-2 right-2 bg-black/60 text-[10px] text-slate-300 px-1.5 py-0.5 rounded">
-                    {image.width}x{image.height}
+                  {/* Favorite and dimensions badges */}
+                  <div className="absolute top-2 right-2 flex items-center gap-1">
+                    {image.favorite && (
+                      <div className="bg-black/60 px-1 py-0.5 rounded">
+                        <Star className="w-3 h-3 text-amber-300 fill-amber-300" />
+                      </div>
+                    )}
+                    <div className="bg-black/60 text-[10px] text-slate-300 px-1.5 py-0.5 rounded">
+                      {image.width}x{image.height}
+                    </div>
                   </div>
 
                   {/* Selection indicator */}
@@ -288,6 +457,37 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
               <RefreshCw className={`w-4 h-4 ${isLoading ? 'animate-spin' : ''}`} />
               Refresh
             </button>
+
+            {isFiltered && (
+              <button
+                onClick={handleUploadShown}
+                disabled={images.length === 0 || isUploading}
+                title="Upload every screenshot shown and copy the links"
+                className="px-3 py-2 text-slate-400 hover:text-violet-400 transition-all duration-200
+                           text-sm flex items-center gap-2 rounded-lg hover:bg-white/5 disabled:opacity-50"
+              >
+                <Upload className={`w-4 h-4 ${isUploading ? 'animate-pulse' : ''}`} />
+                Upload {images.length}
+              </button>
+            )}
+
+            {selectedImage && (selectedImage.tags || []).map(tag => (
+              <span
+                key={tag}
+                className="px-2 py-1 rounded-lg text-xs flex items-center gap-1 bg-violet-500/10 border border-violet-500/20 text-violet-300"
+              >
+                {tag}
+                <button
+                  onClick={() => handleRemoveTag(tag)}
+                  title={`Remove tag ${tag}`}
+                  className="text-violet-300/60 hover:text-white"
+                >
+                  <X className="w-3 h-3" />
+                </button>
+              </span>
+            ))}
+
+            {status && <span className="text-xs text-slate-400">{status}</span>}
           </div>
 
           <div className="flex items-center gap-2">
@@ -300,6 +500,26 @@ export function LibraryWindow({ isOpen, onClose, onCapture, onEdit }: LibraryWin
               Capture
             </button>
 
+            <button
+              onClick={handleToggleFavorite}
+              disabled={!selectedImage}
+              title={selectedImage?.favorite ? 'Remove from favorites' : 'Add to favorites'}
+              className="p-2 bg-white/5 hover:bg-white/10 rounded-xl transition-all duration-200
+                         border border-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
+            >
+              <Star className={`w-4 h-4 ${selectedImage?.favorite ? 'text-amber-300 fill-amber-300' : 'text-slate-300'}`} />
+            </button>
+
+            <button
+              onClick={handleAddTags}
+              disabled={!selectedImage}
+              title="Add tags"
+              className="p-2 bg-white/5 hover:bg-white/10 text-slate-300 rounded-xl transition-all duration-200
+                         border border-white/10 disabled:opacity-50 disabled:cursor-not-allowed"
+            >
+              <Tag className="w-4 h-4" />
+            </button>
+
             <button
               onClick={handleEdit}
               disabled={!selectedImage}
@@ -3,12 +3,18 @@ import { HotkeyInput } from './hotkey-input';
 import {
   GetConfig,
   SaveConfig,
+  IsSafeMode,
   SelectFolder,
   GetR2Config,
   SaveR2Config,
   SaveR2Credentials,
   IsR2Configured,
   TestR2Connection,
+  GetS3Config,
+  SaveS3Config,
+  SaveS3Credentials,
+  IsS3Configured,
+  TestS3Connection,
   GetGDriveConfig,
   SaveGDriveConfig,
   SaveGDriveCredentials,
@@ -16,6 +22,25 @@ import {
   GetGDriveStatus,
   StartGDriveAuth,
   DisconnectGDrive,
+  GetConfluenceConfig,
+  SaveConfluenceConfig,
+  SaveConfluenceCredentials,
+  IsConfluenceConfigured,
+  TestConfluenceConnection,
+  GetEphemeralConfig,
+  GetEncryptionConfig,
+  GetUploadReview,
+  SetUploadReview,
+  HasEncryptionPassphrase,
+  SaveEncryptionConfig,
+  SaveEncryptionPassphrase,
+  SaveEphemeralConfig,
+  TestEphemeralConnection,
+  GetNotesConfig,
+  SaveNotesConfig,
+  GetOneNoteStatus,
+  StartOneNoteAuth,
+  DisconnectOneNote,
 } from '../../wailsjs/go/main/App';
 import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime';
 import { config } from '../../wailsjs/go/models';
@@ -34,6 +59,9 @@ interface LocalConfig {
     fullscreen: string;
     region: string;
     window: string;
+    text: string;
+    color: string;
+    repeat: string;
   };
   startup: {
     launchOnStartup: boolean;
@@ -50,10 +78,23 @@ interface LocalConfig {
     jpegQuality: number;
     includeBackground: boolean;
     autoCopyToClipboard: boolean;
+    adjustments: {
+      brightness: number;
+      contrast: number;
+      gamma: number;
+      grayscale: boolean;
+    };
   };
   update: {
     checkOnStartup: boolean;
   };
+  capture: {
+    includeCursor: boolean;
+  };
+  history: {
+    disabled: boolean;
+    maxEntries: number;
+  };
 }
 
 // Cloud config local state
@@ -66,11 +107,48 @@ interface CloudLocalConfig {
     publicUrl: string;
     directory: string;
   };
+  s3: {
+    endpoint: string;
+    region: string;
+    accessKeyId: string;
+    secretAccessKey: string;
+    bucket: string;
+    publicUrl: string;
+    keyTemplate: string;
+    acl: string;
+    pathStyle: boolean;
+  };
   gdrive: {
     clientId: string;
     clientSecret: string;
     folderId: string;
   };
+  confluence: {
+    baseUrl: string;
+    pageId: string;
+    marker: string;
+    user: string;
+    token: string;
+  };
+  ephemeral: {
+    enabled: boolean;
+    url: string;
+    expiryHours: string; // Kept as typed, empty uses the host's retention
+    secret: boolean;
+  };
+  encrypt: {
+    enabled: boolean;
+    viewer: boolean;
+    usePassphrase: boolean;
+    passphrase: string; // Only sent when changed, the saved one stays in Credential Manager
+  };
+  notes: {
+    tags: string; // Comma separated
+    vault: string;
+    folder: string;
+    oneNoteClientId: string;
+    oneNoteSectionId: string;
+  };
 }
 
 const defaultCloudConfig: CloudLocalConfig = {
@@ -82,11 +160,48 @@ const defaultCloudConfig: CloudLocalConfig = {
     publicUrl: '',
     directory: '',
   },
+  s3: {
+    endpoint: '',
+    region: '',
+    accessKeyId: '',
+    secretAccessKey: '',
+    bucket: '',
+    publicUrl: '',
+    keyTemplate: '',
+    acl: '',
+    pathStyle: false,
+  },
   gdrive: {
     clientId: '',
     clientSecret: '',
     folderId: '',
   },
+  confluence: {
+    baseUrl: '',
+    pageId: '',
+    marker: '',
+    user: '',
+    token: '',
+  },
+  ephemeral: {
+    enabled: false,
+    url: '',
+    expiryHours: '',
+    secret: true,
+  },
+  encrypt: {
+    enabled: false,
+    viewer: true,
+    usePassphrase: false,
+    passphrase: '',
+  },
+  notes: {
+    tags: '',
+    vault: '',
+    folder: '',
+    oneNoteClientId: '',
+    oneNoteSectionId: '',
+  },
 };
 
 const defaultConfig: LocalConfig = {
@@ -94,6 +209,9 @@ const defaultConfig: LocalConfig = {
     fullscreen: 'PrintScreen',
     region: 'Ctrl+PrintScreen',
     window: 'Ctrl+Shift+PrintScreen',
+    text: '',
+    color: '',
+    repeat: '',
   },
   startup: {
     launchOnStartup: false,
@@ -110,12 +228,30 @@ const defaultConfig: LocalConfig = {
     jpegQuality: 95,
     includeBackground: true,
     autoCopyToClipboard: true,
+    adjustments: {
+      brightness: 0,
+      contrast: 0,
+      gamma: 1,
+      grayscale: false,
+    },
   },
   update: {
     checkOnStartup: true,
   },
+  capture: {
+    includeCursor: false,
+  },
+  history: {
+    disabled: false,
+    maxEntries: 0,
+  },
 };
 
+// Shown instead of saving while the app runs with --safe-mode
+const SAFE_MODE_MESSAGE =
+  'WinShot was started in safe mode, so it runs on default settings and changes can\'t be saved. ' +
+  'Your saved settings are untouched. Restart WinShot without --safe-mode to edit them.';
+
 export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
   const [activeTab, setActiveTab] = useState<SettingsTab>('hotkeys');
   const [localConfig, setLocalConfig] = useState<LocalConfig>(defaultConfig);
@@ -127,16 +263,30 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
   const [cloudConfig, setCloudConfig] = useState<CloudLocalConfig>(defaultCloudConfig);
   const [r2Status, setR2Status] = useState<'unconfigured' | 'testing' | 'connected' | 'error'>('unconfigured');
   const [r2Error, setR2Error] = useState<string | null>(null);
+  const [s3Status, setS3Status] = useState<'unconfigured' | 'testing' | 'connected' | 'error'>('unconfigured');
+  const [s3Error, setS3Error] = useState<string | null>(null);
   const [gdriveEmail, setGdriveEmail] = useState<string | null>(null);
   const [gdriveConnecting, setGdriveConnecting] = useState(false);
   const [showR2Instructions, setShowR2Instructions] = useState(false);
   const [showGDriveInstructions, setShowGDriveInstructions] = useState(false);
+  const [confluenceStatus, setConfluenceStatus] = useState<'unconfigured' | 'testing' | 'connected' | 'error'>('unconfigured');
+  const [confluenceError, setConfluenceError] = useState<string | null>(null);
+  const [ephemeralStatus, setEphemeralStatus] = useState<'idle' | 'testing' | 'connected' | 'error'>('idle');
+  const [ephemeralError, setEphemeralError] = useState<string | null>(null);
+  const [hasPassphrase, setHasPassphrase] = useState(false);
+  const [reviewProviders, setReviewProviders] = useState<string[]>([]);
+  const [encryptStatus, setEncryptStatus] = useState<'idle' | 'saved' | 'error'>('idle');
+  const [encryptError, setEncryptError] = useState<string | null>(null);
+  const [oneNoteConnected, setOneNoteConnected] = useState(false);
+  const [oneNoteConnecting, setOneNoteConnecting] = useState(false);
+  const [safeMode, setSafeMode] = useState(false);
 
   // Load config when modal opens
   useEffect(() => {
     if (isOpen) {
       loadConfig();
       loadCloudConfig();
+      IsSafeMode().then(setSafeMode).catch(() => setSafeMode(false));
     }
   }, [isOpen]);
 
@@ -156,9 +306,20 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
     EventsOn('gdrive:auth:success', successHandler);
     EventsOn('gdrive:auth:error', errorHandler);
 
+    EventsOn('onenote:auth:success', () => {
+      setOneNoteConnecting(false);
+      setOneNoteConnected(true);
+    });
+    EventsOn('onenote:auth:error', (err: string) => {
+      setOneNoteConnecting(false);
+      setError(`OneNote connection failed: ${err}`);
+    });
+
     return () => {
       EventsOff('gdrive:auth:success');
       EventsOff('gdrive:auth:error');
+      EventsOff('onenote:auth:success');
+      EventsOff('onenote:auth:error');
     };
   }, []);
 
@@ -170,6 +331,9 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
           fullscreen: cfg.hotkeys?.fullscreen || defaultConfig.hotkeys.fullscreen,
           region: cfg.hotkeys?.region || defaultConfig.hotkeys.region,
           window: cfg.hotkeys?.window || defaultConfig.hotkeys.window,
+          text: cfg.hotkeys?.text || defaultConfig.hotkeys.text,
+          color: cfg.hotkeys?.color || defaultConfig.hotkeys.color,
+          repeat: cfg.hotkeys?.repeat || defaultConfig.hotkeys.repeat,
         },
         startup: {
           launchOnStartup: cfg.startup?.launchOnStartup || false,
@@ -186,10 +350,23 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
           jpegQuality: cfg.export?.jpegQuality || 95,
           includeBackground: cfg.export?.includeBackground ?? true,
           autoCopyToClipboard: cfg.export?.autoCopyToClipboard ?? true,
+          adjustments: {
+            brightness: cfg.export?.adjustments?.brightness || 0,
+            contrast: cfg.export?.adjustments?.contrast || 0,
+            gamma: cfg.export?.adjustments?.gamma || 1,
+            grayscale: cfg.export?.adjustments?.grayscale || false,
+          },
         },
         update: {
           checkOnStartup: cfg.update?.checkOnStartup ?? true,
         },
+        capture: {
+          includeCursor: cfg.capture?.includeCursor || false,
+        },
+        history: {
+          disabled: cfg.history?.disabled || false,
+          maxEntries: cfg.history?.maxEntries || 0,
+        },
       };
       setLocalConfig(local);
       setOriginalConfig(local);
@@ -219,6 +396,26 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
       setR2Status(r2Configured ? 'connected' : 'unconfigured');
       setR2Error(null);
 
+      // S3
+      const s3Cfg = await GetS3Config();
+      setCloudConfig((prev) => ({
+        ...prev,
+        s3: {
+          ...prev.s3,
+          endpoint: s3Cfg.endpoint || '',
+          region: s3Cfg.region || '',
+          bucket: s3Cfg.bucket || '',
+          publicUrl: s3Cfg.publicUrl || '',
+          keyTemplate: s3Cfg.keyTemplate || '',
+          acl: s3Cfg.acl || '',
+          pathStyle: s3Cfg.pathStyle || false,
+        },
+      }));
+
+      const s3Configured = await IsS3Configured();
+      setS3Status(s3Configured ? 'connected' : 'unconfigured');
+      setS3Error(null);
+
       // GDrive
       const gdriveCfg = await GetGDriveConfig();
       setCloudConfig((prev) => ({
@@ -231,6 +428,67 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
 
       const status = await GetGDriveStatus();
       setGdriveEmail(status.connected ? status.email || null : null);
+
+      // Confluence
+      const confluenceCfg = await GetConfluenceConfig();
+      setCloudConfig((prev) => ({
+        ...prev,
+        confluence: {
+          ...prev.confluence,
+          baseUrl: confluenceCfg.baseUrl || '',
+          pageId: confluenceCfg.pageId || '',
+          marker: confluenceCfg.marker || '',
+        },
+      }));
+
+      const confluenceConfigured = await IsConfluenceConfigured();
+      setConfluenceStatus(confluenceConfigured ? 'connected' : 'unconfigured');
+      setConfluenceError(null);
+
+      // Ephemeral host
+      const ephemeralCfg = await GetEphemeralConfig();
+      setCloudConfig((prev) => ({
+        ...prev,
+        ephemeral: {
+          enabled: ephemeralCfg.enabled || false,
+          url: ephemeralCfg.url || '',
+          expiryHours: ephemeralCfg.expiryHours ? String(ephemeralCfg.expiryHours) : '',
+          secret: ephemeralCfg.secret || false,
+        },
+      }));
+      setEphemeralStatus('idle');
+      setEphemeralError(null);
+
+      // Encryption
+      const encryptCfg = await GetEncryptionConfig();
+      setCloudConfig((prev) => ({
+        ...prev,
+        encrypt: {
+          enabled: encryptCfg.enabled || false,
+          viewer: encryptCfg.viewer || false,
+          usePassphrase: encryptCfg.passphrase || false,
+          passphrase: '',
+        },
+      }));
+      setHasPassphrase(await HasEncryptionPassphrase());
+      setReviewProviders((await GetUploadReview()) || []);
+      setEncryptStatus('idle');
+      setEncryptError(null);
+
+      // Notes
+      const notesCfg = await GetNotesConfig();
+      setCloudConfig((prev) => ({
+        ...prev,
+        notes: {
+          ...prev.notes,
+          tags: (notesCfg.tags || []).join(', '),
+          vault: notesCfg.obsidian?.vault || '',
+          folder: notesCfg.obsidian?.folder || '',
+          oneNoteSectionId: notesCfg.onenote?.sectionId || '',
+        },
+      }));
+      const oneNote = await GetOneNoteStatus();
+      setOneNoteConnected(oneNote.connected);
     } catch (err) {
       console.error('Failed to load cloud config:', err);
     }
@@ -263,6 +521,133 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
     }
   };
 
+  // S3 handlers
+  const handleS3Test = async () => {
+    setS3Status('testing');
+    setS3Error(null);
+    try {
+      // Save config first
+      await SaveS3Config(
+        new config.S3Config({
+          endpoint: cloudConfig.s3.endpoint.trim(),
+          region: cloudConfig.s3.region.trim(),
+          bucket: cloudConfig.s3.bucket.trim(),
+          publicUrl: cloudConfig.s3.publicUrl.trim(),
+          keyTemplate: cloudConfig.s3.keyTemplate.trim(),
+          acl: cloudConfig.s3.acl,
+          pathStyle: cloudConfig.s3.pathStyle,
+        })
+      );
+      if (cloudConfig.s3.secretAccessKey) {
+        await SaveS3Credentials(
+          cloudConfig.s3.accessKeyId.trim(),
+          cloudConfig.s3.secretAccessKey
+        );
+      }
+
+      // Test connection
+      await TestS3Connection();
+      setS3Status('connected');
+    } catch (err) {
+      setS3Status('error');
+      setS3Error(err instanceof Error ? err.message : 'Connection failed');
+      console.error('S3 test failed:', err);
+    }
+  };
+
+  // Confluence handlers
+  const handleConfluenceTest = async () => {
+    setConfluenceStatus('testing');
+    setConfluenceError(null);
+    try {
+      // Save config first
+      await SaveConfluenceConfig(
+        new config.ConfluenceConfig({
+          baseUrl: cloudConfig.confluence.baseUrl.trim(),
+          pageId: cloudConfig.confluence.pageId.trim(),
+          marker: cloudConfig.confluence.marker,
+        })
+      );
+      if (cloudConfig.confluence.token) {
+        await SaveConfluenceCredentials(
+          cloudConfig.confluence.user.trim(),
+          cloudConfig.confluence.token
+        );
+      }
+
+      // Test connection
+      await TestConfluenceConnection();
+      setConfluenceStatus('connected');
+    } catch (err) {
+      setConfluenceStatus('error');
+      setConfluenceError(err instanceof Error ? err.message : 'Connection failed');
+      console.error('Confluence test failed:', err);
+    }
+  };
+
+  // Ephemeral host handlers
+  const saveEphemeralConfig = async () => {
+    const hours = parseInt(cloudConfig.ephemeral.expiryHours, 10);
+    await SaveEphemeralConfig(
+      new config.EphemeralConfig({
+        enabled: cloudConfig.ephemeral.enabled,
+        url: cloudConfig.ephemeral.url.trim(),
+        expiryHours: hours > 0 ? hours : 0,
+        secret: cloudConfig.ephemeral.secret,
+      })
+    );
+  };
+
+  const handleEphemeralTest = async () => {
+    setEphemeralStatus('testing');
+    setEphemeralError(null);
+    try {
+      await saveEphemeralConfig();
+      await TestEphemeralConnection();
+      setEphemeralStatus('connected');
+    } catch (err) {
+      setEphemeralStatus('error');
+      setEphemeralError(err instanceof Error ? err.message : String(err));
+      console.error('Ephemeral host test failed:', err);
+    }
+  };
+
+  // Encryption handlers
+  const handleEncryptSave = async () => {
+    setEncryptError(null);
+    try {
+      if (cloudConfig.encrypt.passphrase) {
+        await SaveEncryptionPassphrase(cloudConfig.encrypt.passphrase);
+        setHasPassphrase(true);
+        setCloudConfig((prev) => ({ ...prev, encrypt: { ...prev.encrypt, passphrase: '' } }));
+      }
+      await SaveEncryptionConfig(
+        new config.EncryptConfig({
+          enabled: cloudConfig.encrypt.enabled,
+          viewer: cloudConfig.encrypt.viewer,
+          passphrase: cloudConfig.encrypt.usePassphrase,
+        })
+      );
+      setEncryptStatus('saved');
+    } catch (err) {
+      setEncryptStatus('error');
+      setEncryptError(err instanceof Error ? err.message : String(err));
+    }
+  };
+
+  // Review handlers
+  const toggleReview = async (provider: string, enabled: boolean) => {
+    const next = enabled
+      ? [...reviewProviders.filter((p) => p !== provider), provider]
+      : reviewProviders.filter((p) => p !== provider);
+    setReviewProviders(next);
+    try {
+      await SetUploadReview(next);
+    } catch (err) {
+      console.error('Failed to save upload review:', err);
+    }
+  };
+
   // GDrive handlers
   const handleGDriveConnect = async () => {
     setGdriveConnecting(true);
@@ -294,6 +679,51 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
     }
   };
 
+  // Notes handlers
+  const saveNotesConfig = async (notes = cloudConfig.notes) => {
+    try {
+      await SaveNotesConfig(
+        new config.NotesConfig({
+          tags: notes.tags.split(',').map((t) => t.trim()).filter(Boolean),
+          obsidian: { vault: notes.vault, folder: notes.folder },
+          onenote: { sectionId: notes.oneNoteSectionId },
+        })
+      );
+    } catch (err) {
+      console.error('Failed to save notes config:', err);
+    }
+  };
+
+  const handleSelectVault = async () => {
+    const vault = await SelectFolder();
+    if (vault) {
+      const notes = { ...cloudConfig.notes, vault };
+      setCloudConfig((prev) => ({ ...prev, notes }));
+      saveNotesConfig(notes);
+    }
+  };
+
+  const handleOneNoteConnect = async () => {
+    setOneNoteConnecting(true);
+    try {
+      await saveNotesConfig();
+      // Opens the Microsoft sign-in page, completion handled by event listener
+      await StartOneNoteAuth(cloudConfig.notes.oneNoteClientId);
+    } catch (err) {
+      setOneNoteConnecting(false);
+      setError(`OneNote connection failed: ${err}`);
+    }
+  };
+
+  const handleOneNoteDisconnect = async () => {
+    try {
+      await DisconnectOneNote();
+      setOneNoteConnected(false);
+    } catch (err) {
+      console.error('OneNote disconnect failed:', err);
+    }
+  };
+
   const handleSave = async () => {
     setIsSaving(true);
     setError(null);
@@ -306,13 +736,15 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
         quickSave: new config.QuickSaveConfig(localConfig.quickSave),
         export: new config.ExportConfig(localConfig.export),
         update: new config.UpdateConfig(localConfig.update),
+        capture: new config.CaptureConfig(localConfig.capture),
+        history: new config.HistoryConfig(localConfig.history),
       });
       await SaveConfig(cfg);
       setOriginalConfig(localConfig);
       onClose();
     } catch (err) {
       console.error('Failed to save config:', err);
-      setError('Failed to save settings');
+      setError(safeMode ? SAFE_MODE_MESSAGE : 'Failed to save settings');
     }
 
     setIsSaving(false);
@@ -363,6 +795,12 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
           </button>
         </div>
 
+        {safeMode && (
+          <div className="mx-5 mt-4 p-3 rounded-xl bg-amber-500/10 border border-amber-500/30 text-amber-300 text-sm">
+            {SAFE_MODE_MESSAGE}
+          </div>
+        )}
+
         {/* Tabs */}
         <div className="flex border-b border-white/10">
           {tabs.map((tab) => (
@@ -420,6 +858,36 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                   }))
                 }
               />
+              <HotkeyInput
+                label="Copy Text"
+                value={localConfig.hotkeys.text}
+                onChange={(value) =>
+                  setLocalConfig((prev) => ({
+                    ...prev,
+                    hotkeys: { ...prev.hotkeys, text: value },
+                  }))
+                }
+              />
+              <HotkeyInput
+                label="Pick Color"
+                value={localConfig.hotkeys.color}
+                onChange={(value) =>
+                  setLocalConfig((prev) => ({
+                    ...prev,
+                    hotkeys: { ...prev.hotkeys, color: value },
+                  }))
+                }
+              />
+              <HotkeyInput
+                label="Repeat Last Region"
+                value={localConfig.hotkeys.repeat}
+                onChange={(value) =>
+                  setLocalConfig((prev) => ({
+                    ...prev,
+                    hotkeys: { ...prev.hotkeys, repeat: value },
+                  }))
+                }
+              />
             </div>
           )}
 
@@ -518,7 +986,7 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                       ...prev,
                       quickSave: {
                         ...prev.quickSave,
-                        pattern: e.target.value as 'timestamp' | 'date' | 'increment',
+                        pattern: e.target.value as 'timestamp' | 'date' | 'increment' | 'source',
                       },
                     }))
                   }
@@ -527,6 +995,7 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                   <option value="timestamp">winshot_2024-12-01_15-30-45</option>
                   <option value="date">winshot_2024-12-01</option>
                   <option value="increment">winshot_001, winshot_002...</option>
+                  <option value="source">winshot_example.com_2024-12-01_15-30-45</option>
                 </select>
               </div>
             </div>
@@ -599,6 +1068,60 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                 />
               </div>
 
+              <div className="p-3 rounded-lg bg-white/5 border border-white/5 space-y-3">
+                <div>
+                  <span className="text-sm text-slate-300 font-medium">Adjustments</span>
+                  <p className="text-xs text-slate-400 mt-0.5">Applied to hotkey and scheduled captures, and where the editor's adjustments start</p>
+                </div>
+                {([
+                  { key: 'brightness', label: 'Brightness', min: -100, max: 100, step: 1 },
+                  { key: 'contrast', label: 'Contrast', min: -100, max: 100, step: 1 },
+                  { key: 'gamma', label: 'Gamma', min: 0.1, max: 5, step: 0.1 },
+                ] as const).map(({ key, label, min, max, step }) => (
+                  <div key={key}>
+                    <div className="flex justify-between items-center mb-2">
+                      <label className="text-sm text-slate-300">{label}</label>
+                      <span className="text-xs text-violet-400 font-semibold bg-violet-500/10 px-2 py-0.5 rounded-full">
+                        {key === 'gamma' ? localConfig.export.adjustments.gamma.toFixed(1) : localConfig.export.adjustments[key]}
+                      </span>
+                    </div>
+                    <input
+                      type="range"
+                      min={min}
+                      max={max}
+                      step={step}
+                      value={localConfig.export.adjustments[key]}
+                      onChange={(e) =>
+                        setLocalConfig((prev) => ({
+                          ...prev,
+                          export: {
+                            ...prev.export,
+                            adjustments: { ...prev.export.adjustments, [key]: Number(e.target.value) },
+                          },
+                        }))
+                      }
+                      className="w-full"
+                    />
+                  </div>
+                ))}
+                <label className="flex items-center gap-3 cursor-pointer">
+                  <input
+                    type="checkbox"
+                    checked={localConfig.export.adjustments.grayscale}
+                    onChange={(e) =>
+                      setLocalConfig((prev) => ({
+                        ...prev,
+                        export: {
+                          ...prev.export,
+                          adjustments: { ...prev.export.adjustments, grayscale: e.target.checked },
+                        },
+                      }))
+                    }
+                  />
+                  <span className="text-slate-200">Grayscale</span>
+                </label>
+              </div>
+
               <label className="flex items-center gap-3 cursor-pointer p-3 rounded-lg bg-white/5 hover:bg-white/8 border border-white/5 transition-all duration-200">
                 <input
                   type="checkbox"
@@ -613,6 +1136,37 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                 <span className="text-slate-200">Include styled background</span>
               </label>
 
+              <label className="flex items-center gap-3 cursor-pointer p-3 rounded-lg bg-white/5 hover:bg-white/8 border border-white/5 transition-all duration-200">
+                <input
+                  type="checkbox"
+                  checked={localConfig.capture.includeCursor}
+                  onChange={(e) =>
+                    setLocalConfig((prev) => ({
+                      ...prev,
+                      capture: { ...prev.capture, includeCursor: e.target.checked },
+                    }))
+                  }
+                />
+                <span className="text-slate-200">Include mouse cursor in captures</span>
+              </label>
+
+              <label className="flex items-center gap-3 cursor-pointer p-3 rounded-lg bg-white/5 hover:bg-white/8 border border-white/5 transition-all duration-200">
+                <input
+                  type="checkbox"
+                  checked={!localConfig.history.disabled}
+                  onChange={(e) =>
+                    setLocalConfig((prev) => ({
+                      ...prev,
+                      history: { ...prev.history, disabled: !e.target.checked },
+                    }))
+                  }
+                />
+                <div>
+                  <span className="text-slate-200">Keep a history of captures</span>
+                  <p className="text-xs text-slate-400 mt-0.5">Includes captures that were only copied or uploaded, so they can be copied or uploaded again</p>
+                </div>
+              </label>
+
               <label className="flex items-center gap-3 cursor-pointer p-3 rounded-lg bg-white/5 hover:bg-white/8 border border-white/5 transition-all duration-200">
                 <input
                   type="checkbox"
@@ -790,76 +1344,224 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                 )}
               </div>
 
-              {/* Google Drive Section */}
+              {/* S3-compatible Section */}
               <div className="p-4 rounded-xl bg-white/5 border border-white/10">
                 <div className="flex items-center justify-between mb-4">
-                  <h3 className="text-sm font-semibold text-slate-200">Google Drive</h3>
-                  {gdriveEmail && (
-                    <span className="text-xs text-emerald-400 flex items-center gap-1">
-                      <span className="w-1.5 h-1.5 rounded-full bg-emerald-400"></span>
-                      {gdriveEmail}
-                    </span>
-                  )}
+                  <h3 className="text-sm font-semibold text-slate-200">S3 / MinIO / Backblaze B2</h3>
+                  <div className="flex items-center gap-2">
+                    {s3Status === 'connected' && (
+                      <span className="text-xs text-emerald-400 flex items-center gap-1">
+                        <span className="w-1.5 h-1.5 rounded-full bg-emerald-400"></span>
+                        Connected
+                      </span>
+                    )}
+                    {s3Status === 'error' && (
+                      <span className="text-xs text-rose-400">Connection failed</span>
+                    )}
+                  </div>
                 </div>
 
-                {!gdriveEmail ? (
-                  <>
-                    <div className="space-y-3 mb-3">
-                      <input
-                        type="text"
-                        placeholder="OAuth Client ID"
-                        value={cloudConfig.gdrive.clientId}
-                        onChange={(e) =>
-                          setCloudConfig((prev) => ({
-                            ...prev,
-                            gdrive: { ...prev.gdrive, clientId: e.target.value },
-                          }))
-                        }
-                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
-                      />
-                      <input
-                        type="password"
-                        placeholder="OAuth Client Secret"
-                        value={cloudConfig.gdrive.clientSecret}
-                        onChange={(e) =>
-                          setCloudConfig((prev) => ({
-                            ...prev,
-                            gdrive: { ...prev.gdrive, clientSecret: e.target.value },
-                          }))
-                        }
-                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
-                      />
-                      <input
-                        type="text"
-                        placeholder="Folder ID (optional)"
-                        value={cloudConfig.gdrive.folderId}
-                        onChange={(e) =>
-                          setCloudConfig((prev) => ({
-                            ...prev,
-                            gdrive: { ...prev.gdrive, folderId: e.target.value },
-                          }))
-                        }
-                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
-                      />
-                    </div>
-                    <button
-                      onClick={handleGDriveConnect}
-                      disabled={gdriveConnecting || !cloudConfig.gdrive.clientId || !cloudConfig.gdrive.clientSecret}
-                      className="px-4 py-2 text-sm rounded-lg bg-gradient-to-r from-violet-500 to-purple-600 text-white transition-all duration-200 disabled:opacity-50 disabled:cursor-not-allowed"
-                    >
-                      {gdriveConnecting ? 'Connecting...' : 'Connect Google Account'}
-                    </button>
-                  </>
-                ) : (
-                  <div className="space-y-3">
-                    <input
-                      type="text"
-                      placeholder="Folder ID (optional)"
-                      value={cloudConfig.gdrive.folderId}
-                      onChange={(e) =>
-                        setCloudConfig((prev) => ({
-                          ...prev,
-                          gdrive: { ...prev.gdrive, folderId: e.target.value },
+                <div className="space-y-3">
+                  <input
+                    type="text"
+                    placeholder="Endpoint (empty for AWS, e.g., https://s3.us-west-004.backblazeb2.com)"
+                    value={cloudConfig.s3.endpoint}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, endpoint: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Region (optional, defaults to us-east-1)"
+                    value={cloudConfig.s3.region}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, region: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="password"
+                    placeholder="Access Key ID"
+                    value={cloudConfig.s3.accessKeyId}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, accessKeyId: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="password"
+                    placeholder="Secret Access Key"
+                    value={cloudConfig.s3.secretAccessKey}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, secretAccessKey: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Bucket Name"
+                    value={cloudConfig.s3.bucket}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, bucket: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Public URL (optional, e.g., https://cdn.example.com)"
+                    value={cloudConfig.s3.publicUrl}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, publicUrl: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Key template (optional, e.g., shots/{{date}}/{{filename}})"
+                    value={cloudConfig.s3.keyTemplate}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, keyTemplate: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <select
+                    value={cloudConfig.s3.acl}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        s3: { ...prev.s3, acl: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  >
+                    <option value="">Bucket default ACL</option>
+                    <option value="public-read">public-read</option>
+                    <option value="private">private</option>
+                    <option value="authenticated-read">authenticated-read</option>
+                    <option value="bucket-owner-full-control">bucket-owner-full-control</option>
+                  </select>
+                  <label className="flex items-center gap-3 cursor-pointer">
+                    <input
+                      type="checkbox"
+                      checked={cloudConfig.s3.pathStyle}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          s3: { ...prev.s3, pathStyle: e.target.checked },
+                        }))
+                      }
+                    />
+                    <span className="text-sm text-slate-200">Path-style URLs (needed for MinIO)</span>
+                  </label>
+
+                  {s3Error && (
+                    <p className="text-xs text-rose-400">{s3Error}</p>
+                  )}
+
+                  <div className="flex gap-2">
+                    <button
+                      onClick={handleS3Test}
+                      disabled={s3Status === 'testing'}
+                      className="px-3 py-1.5 text-sm rounded-lg bg-white/5 hover:bg-white/10 border border-white/10 text-slate-300 transition-all duration-200 disabled:opacity-50"
+                    >
+                      {s3Status === 'testing' ? 'Testing...' : 'Test Connection'}
+                    </button>
+                  </div>
+                </div>
+              </div>
+
+              {/* Google Drive Section */}
+              <div className="p-4 rounded-xl bg-white/5 border border-white/10">
+                <div className="flex items-center justify-between mb-4">
+                  <h3 className="text-sm font-semibold text-slate-200">Google Drive</h3>
+                  {gdriveEmail && (
+                    <span className="text-xs text-emerald-400 flex items-center gap-1">
+                      <span className="w-1.5 h-1.5 rounded-full bg-emerald-400"></span>
+                      {gdriveEmail}
+                    </span>
+                  )}
+                </div>
+
+                {!gdriveEmail ? (
+                  <>
+                    <div className="space-y-3 mb-3">
+                      <input
+                        type="text"
+                        placeholder="OAuth Client ID"
+                        value={cloudConfig.gdrive.clientId}
+                        onChange={(e) =>
+                          setCloudConfig((prev) => ({
+                            ...prev,
+                            gdrive: { ...prev.gdrive, clientId: e.target.value },
+                          }))
+                        }
+                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                      />
+                      <input
+                        type="password"
+                        placeholder="OAuth Client Secret"
+                        value={cloudConfig.gdrive.clientSecret}
+                        onChange={(e) =>
+                          setCloudConfig((prev) => ({
+                            ...prev,
+                            gdrive: { ...prev.gdrive, clientSecret: e.target.value },
+                          }))
+                        }
+                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                      />
+                      <input
+                        type="text"
+                        placeholder="Folder ID (optional)"
+                        value={cloudConfig.gdrive.folderId}
+                        onChange={(e) =>
+                          setCloudConfig((prev) => ({
+                            ...prev,
+                            gdrive: { ...prev.gdrive, folderId: e.target.value },
+                          }))
+                        }
+                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                      />
+                    </div>
+                    <button
+                      onClick={handleGDriveConnect}
+                      disabled={gdriveConnecting || !cloudConfig.gdrive.clientId || !cloudConfig.gdrive.clientSecret}
+                      className="px-4 py-2 text-sm rounded-lg bg-gradient-to-r from-violet-500 to-purple-600 text-white transition-all duration-200 disabled:opacity-50 disabled:cursor-not-allowed"
+                    >
+                      {gdriveConnecting ? 'Connecting...' : 'Connect Google Account'}
+                    </button>
+                  </>
+                ) : (
+                  <div className="space-y-3">
+                    <input
+                      type="text"
+                      placeholder="Folder ID (optional)"
+                      value={cloudConfig.gdrive.folderId}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          gdrive: { ...prev.gdrive, folderId: e.target.value },
                         }))
                       }
                       onBlur={() => SaveGDriveConfig(cloudConfig.gdrive.folderId)}
@@ -897,6 +1599,394 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                   </div>
                 )}
               </div>
+
+              {/* Confluence Section */}
+              <div className="p-4 rounded-xl bg-white/5 border border-white/10">
+                <div className="flex items-center justify-between mb-4">
+                  <h3 className="text-sm font-semibold text-slate-200">Confluence</h3>
+                  <div className="flex items-center gap-2">
+                    {confluenceStatus === 'connected' && (
+                      <span className="text-xs text-emerald-400 flex items-center gap-1">
+                        <span className="w-1.5 h-1.5 rounded-full bg-emerald-400"></span>
+                        Connected
+                      </span>
+                    )}
+                    {confluenceStatus === 'error' && (
+                      <span className="text-xs text-rose-400">Connection failed</span>
+                    )}
+                  </div>
+                </div>
+
+                <div className="space-y-3">
+                  <input
+                    type="text"
+                    placeholder="Site URL (e.g., https://example.atlassian.net/wiki)"
+                    value={cloudConfig.confluence.baseUrl}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        confluence: { ...prev.confluence, baseUrl: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Page ID"
+                    value={cloudConfig.confluence.pageId}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        confluence: { ...prev.confluence, pageId: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Account email (leave empty for a Data Center token)"
+                    value={cloudConfig.confluence.user}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        confluence: { ...prev.confluence, user: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="password"
+                    placeholder="API token"
+                    value={cloudConfig.confluence.token}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        confluence: { ...prev.confluence, token: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="Insert images before this page text (optional, e.g., [[screenshots]])"
+                    value={cloudConfig.confluence.marker}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        confluence: { ...prev.confluence, marker: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+
+                  {confluenceError && (
+                    <p className="text-xs text-rose-400">{confluenceError}</p>
+                  )}
+
+                  <div className="flex gap-2">
+                    <button
+                      onClick={handleConfluenceTest}
+                      disabled={confluenceStatus === 'testing'}
+                      className="px-3 py-1.5 text-sm rounded-lg bg-white/5 hover:bg-white/10 border border-white/10 text-slate-300 transition-all duration-200 disabled:opacity-50"
+                    >
+                      {confluenceStatus === 'testing' ? 'Testing...' : 'Test Connection'}
+                    </button>
+                  </div>
+                </div>
+              </div>
+
+              {/* Ephemeral Host Section */}
+              <div className="p-4 rounded-xl bg-white/5 border border-white/10">
+                <div className="flex items-center justify-between mb-4">
+                  <h3 className="text-sm font-semibold text-slate-200">Temporary Links</h3>
+                  <div className="flex items-center gap-2">
+                    {ephemeralStatus === 'connected' && (
+                      <span className="text-xs text-emerald-400 flex items-center gap-1">
+                        <span className="w-1.5 h-1.5 rounded-full bg-emerald-400"></span>
+                        Reachable
+                      </span>
+                    )}
+                    {ephemeralStatus === 'error' && (
+                      <span className="text-xs text-rose-400">Connection failed</span>
+                    )}
+                  </div>
+                </div>
+
+                <div className="space-y-3">
+                  <p className="text-xs text-slate-400">
+                    Uploads anonymously to a 0x0-style host that deletes the file after a while. Anyone with the link can open it until then.
+                  </p>
+                  <label className="flex items-center gap-3 cursor-pointer">
+                    <input
+                      type="checkbox"
+                      checked={cloudConfig.ephemeral.enabled}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          ephemeral: { ...prev.ephemeral, enabled: e.target.checked },
+                        }))
+                      }
+                    />
+                    <span className="text-sm text-slate-200">Enable temporary links</span>
+                  </label>
+                  <input
+                    type="text"
+                    placeholder="Host (default https://0x0.st)"
+                    value={cloudConfig.ephemeral.url}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        ephemeral: { ...prev.ephemeral, url: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="number"
+                    min={0}
+                    placeholder="Delete after hours (empty uses the host's retention)"
+                    value={cloudConfig.ephemeral.expiryHours}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        ephemeral: { ...prev.ephemeral, expiryHours: e.target.value },
+                      }))
+                    }
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <label className="flex items-center gap-3 cursor-pointer">
+                    <input
+                      type="checkbox"
+                      checked={cloudConfig.ephemeral.secret}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          ephemeral: { ...prev.ephemeral, secret: e.target.checked },
+                        }))
+                      }
+                    />
+                    <span className="text-sm text-slate-200">Hard to guess links</span>
+                  </label>
+
+                  {ephemeralError && (
+                    <p className="text-xs text-rose-400">{ephemeralError}</p>
+                  )}
+
+                  <div className="flex gap-2">
+                    <button
+                      onClick={handleEphemeralTest}
+                      disabled={ephemeralStatus === 'testing'}
+                      className="px-3 py-1.5 text-sm rounded-lg bg-white/5 hover:bg-white/10 border border-white/10 text-slate-300 transition-all duration-200 disabled:opacity-50"
+                    >
+                      {ephemeralStatus === 'testing' ? 'Testing...' : 'Save & Test'}
+                    </button>
+                  </div>
+                </div>
+              </div>
+
+              {/* Encryption Section */}
+              <div className="p-4 rounded-xl bg-white/5 border border-white/10">
+                <div className="flex items-center justify-between mb-4">
+                  <h3 className="text-sm font-semibold text-slate-200">Encrypted Uploads</h3>
+                  {encryptStatus === 'saved' && (
+                    <span className="text-xs text-emerald-400">Saved</span>
+                  )}
+                </div>
+
+                <div className="space-y-3">
+                  <p className="text-xs text-slate-400">
+                    Encrypts captures before they are uploaded to R2, Google Drive or a temporary link host, so the provider only stores ciphertext. The key is added to the link after the #, which browsers never send to the server. Confluence attachments are not encrypted.
+                  </p>
+                  <label className="flex items-center gap-3 cursor-pointer">
+                    <input
+                      type="checkbox"
+                      checked={cloudConfig.encrypt.enabled}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          encrypt: { ...prev.encrypt, enabled: e.target.checked },
+                        }))
+                      }
+                    />
+                    <span className="text-sm text-slate-200">Encrypt uploads</span>
+                  </label>
+                  <label className="flex items-center gap-3 cursor-pointer">
+                    <input
+                      type="checkbox"
+                      checked={cloudConfig.encrypt.viewer}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          encrypt: { ...prev.encrypt, viewer: e.target.checked },
+                        }))
+                      }
+                    />
+                    <span className="text-sm text-slate-200">Upload a page that decrypts in the browser</span>
+                  </label>
+                  <label className="flex items-center gap-3 cursor-pointer">
+                    <input
+                      type="checkbox"
+                      checked={cloudConfig.encrypt.usePassphrase}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          encrypt: { ...prev.encrypt, usePassphrase: e.target.checked },
+                        }))
+                      }
+                    />
+                    <span className="text-sm text-slate-200">Lock with a passphrase instead of a key in the link</span>
+                  </label>
+                  {cloudConfig.encrypt.usePassphrase && (
+                    <input
+                      type="password"
+                      placeholder={hasPassphrase ? 'Passphrase saved (type to replace)' : 'Passphrase'}
+                      value={cloudConfig.encrypt.passphrase}
+                      onChange={(e) =>
+                        setCloudConfig((prev) => ({
+                          ...prev,
+                          encrypt: { ...prev.encrypt, passphrase: e.target.value },
+                        }))
+                      }
+                      className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                    />
+                  )}
+
+                  {encryptError && (
+                    <p className="text-xs text-rose-400">{encryptError}</p>
+                  )}
+
+                  <div className="flex gap-2">
+                    <button
+                      onClick={handleEncryptSave}
+                      className="px-3 py-1.5 text-sm rounded-lg bg-white/5 hover:bg-white/10 border border-white/10 text-slate-300 transition-all duration-200"
+                    >
+                      Save
+                    </button>
+                  </div>
+                </div>
+              </div>
+
+              {/* Review Section */}
+              <div className="p-4 rounded-xl bg-white/5 border border-white/10">
+                <h3 className="text-sm font-semibold text-slate-200 mb-4">Review Before Upload</h3>
+                <div className="space-y-3">
+                  <p className="text-xs text-slate-400">
+                    Hotkey and script uploads to these providers first show the capture in a small window to approve, redact or cancel, so nothing is shared by accident.
+                  </p>
+                  {[
+                    { id: 'r2', label: 'Cloudflare R2' },
+                    { id: 's3', label: 'S3' },
+                    { id: 'gdrive', label: 'Google Drive' },
+                    { id: 'confluence', label: 'Confluence' },
+                    { id: 'ephemeral', label: 'Temporary links' },
+                  ].map(({ id, label }) => (
+                    <label key={id} className="flex items-center gap-3 cursor-pointer">
+                      <input
+                        type="checkbox"
+                        checked={reviewProviders.includes(id) || reviewProviders.includes('*')}
+                        disabled={reviewProviders.includes('*')}
+                        onChange={(e) => toggleReview(id, e.target.checked)}
+                      />
+                      <span className="text-sm text-slate-200">{label}</span>
+                    </label>
+                  ))}
+                </div>
+              </div>
+
+              {/* Notes Section */}
+              <div className="p-4 rounded-xl bg-white/5 border border-white/10">
+                <h3 className="text-sm font-semibold text-slate-200 mb-4">Notes</h3>
+                <div className="space-y-3">
+                  <input
+                    type="text"
+                    placeholder="Tags for every note, comma separated"
+                    value={cloudConfig.notes.tags}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        notes: { ...prev.notes, tags: e.target.value },
+                      }))
+                    }
+                    onBlur={() => saveNotesConfig()}
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <div className="flex gap-2">
+                    <input
+                      type="text"
+                      placeholder="Obsidian vault folder"
+                      value={cloudConfig.notes.vault}
+                      readOnly
+                      className="flex-1 px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                    />
+                    <button
+                      onClick={handleSelectVault}
+                      className="px-4 py-2 text-sm rounded-lg bg-white/5 border border-white/10 text-slate-300 hover:bg-white/10 transition-all duration-200"
+                    >
+                      Browse
+                    </button>
+                  </div>
+                  <input
+                    type="text"
+                    placeholder="Folder in the vault (Screenshots)"
+                    value={cloudConfig.notes.folder}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        notes: { ...prev.notes, folder: e.target.value },
+                      }))
+                    }
+                    onBlur={() => saveNotesConfig()}
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  <input
+                    type="text"
+                    placeholder="OneNote section ID (optional)"
+                    value={cloudConfig.notes.oneNoteSectionId}
+                    onChange={(e) =>
+                      setCloudConfig((prev) => ({
+                        ...prev,
+                        notes: { ...prev.notes, oneNoteSectionId: e.target.value },
+                      }))
+                    }
+                    onBlur={() => saveNotesConfig()}
+                    className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                  />
+                  {!oneNoteConnected ? (
+                    <>
+                      <input
+                        type="text"
+                        placeholder="Microsoft app client ID"
+                        value={cloudConfig.notes.oneNoteClientId}
+                        onChange={(e) =>
+                          setCloudConfig((prev) => ({
+                            ...prev,
+                            notes: { ...prev.notes, oneNoteClientId: e.target.value },
+                          }))
+                        }
+                        className="w-full px-3 py-2 bg-white/5 border border-white/10 rounded-lg text-slate-200 text-sm placeholder:text-slate-500 focus:outline-none focus:border-violet-500/50"
+                      />
+                      <button
+                        onClick={handleOneNoteConnect}
+                        disabled={oneNoteConnecting}
+                        className="px-4 py-2 text-sm rounded-lg bg-gradient-to-r from-violet-500 to-purple-600 text-white transition-all duration-200 disabled:opacity-50 disabled:cursor-not-allowed"
+                      >
+                        {oneNoteConnecting ? 'Connecting...' : 'Connect OneNote'}
+                      </button>
+                    </>
+                  ) : (
+                    <button
+                      onClick={handleOneNoteDisconnect}
+                      className="px-4 py-2 text-sm rounded-lg bg-rose-500/20 text-rose-300 border border-rose-500/30 hover:bg-rose-500/30 transition-all duration-200"
+                    >
+                      Disconnect OneNote
+                    </button>
+                  )}
+                  <p className="text-xs text-slate-500">
+                    Send captures here with the obsidian or onenote hotkey destination. The OneNote app registration needs
+                    http://localhost:8090/callback as a mobile and desktop redirect URI.
+                  </p>
+                </div>
+              </div>
             </div>
           )}
 
@@ -919,7 +2009,8 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
           </button>
           <button
             onClick={handleSave}
-            disabled={isSaving}
+            disabled={isSaving || safeMode}
+            title={safeMode ? 'Settings can\'t be saved in safe mode' : undefined}
             className="px-5 py-2.5 rounded-xl font-medium transition-all duration-200
                        bg-gradient-to-r from-violet-500 to-purple-600 hover:from-violet-400 hover:to-purple-500
                        text-white shadow-lg shadow-violet-500/25 hover:shadow-violet-500/40
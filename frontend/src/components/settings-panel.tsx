@@ -1,7 +1,7 @@
 import { useRef, useState, useEffect } from 'react';
-import { OutputRatio, BorderType } from '../types';
+import { OutputRatio, BorderType, Adjustments } from '../types';
 import { GetBackgroundImages, SaveBackgroundImages } from '../../wailsjs/go/main/App';
-import { X, ImagePlus, Eye, EyeOff } from 'lucide-react';
+import { X, ImagePlus, Eye, EyeOff, RotateCcw } from 'lucide-react';
 
 const MAX_BACKGROUND_IMAGES = 8;
 const MAX_BG_IMAGE_SIZE = 2048;  // Max dimension
@@ -89,6 +89,12 @@ interface SettingsPanelProps {
   onBorderColorChange: (value: string) => void;
   onBorderOpacityChange: (value: number) => void;
   onBorderTypeChange: (value: BorderType) => void;
+  adjustments: Adjustments;
+  onAdjustmentsChange: (value: Adjustments) => void;
+  onAdjustmentsReset: () => void;
+  deviceFrame: string;
+  deviceFrames: string[];
+  onDeviceFrameChange: (value: string) => void;
 }
 
 const GRADIENT_PRESETS = [
@@ -156,6 +162,12 @@ export function SettingsPanel({
   onBorderColorChange,
   onBorderOpacityChange,
   onBorderTypeChange,
+  adjustments,
+  onAdjustmentsChange,
+  onAdjustmentsReset,
+  deviceFrame,
+  deviceFrames,
+  onDeviceFrameChange,
 }: SettingsPanelProps) {
   const fileInputRef = useRef<HTMLInputElement>(null);
   const [uploadedImages, setUploadedImages] = useState<string[]>([]);
@@ -421,6 +433,111 @@ export function SettingsPanel({
         />
       </div>
 
+      {/* Adjustments */}
+      <div className="mb-6">
+        <div className="flex items-center justify-between mb-3">
+          <label className="text-sm text-slate-300 font-medium">Adjustments</label>
+          <button
+            onClick={onAdjustmentsReset}
+            className="flex items-center gap-1.5 px-2.5 py-1 rounded-lg text-xs font-medium transition-all duration-200 bg-slate-500/20 text-slate-400 hover:bg-slate-500/30"
+            title="Reset to the export defaults in settings"
+          >
+            <RotateCcw className="w-3.5 h-3.5" />
+            <span>Reset</span>
+          </button>
+        </div>
+
+        <div className="space-y-4">
+          {/* Brightness */}
+          <div>
+            <div className="flex justify-between items-center mb-2">
+              <label className="text-sm text-slate-300 font-medium">Brightness</label>
+              <span className="text-xs text-amber-400 font-semibold bg-amber-500/10 px-2 py-0.5 rounded-full">{adjustments.brightness}</span>
+            </div>
+            <input
+              type="range"
+              min="-100"
+              max="100"
+              value={adjustments.brightness}
+              onChange={(e) => onAdjustmentsChange({ ...adjustments, brightness: Number(e.target.value) })}
+              className="w-full"
+            />
+          </div>
+
+          {/* Contrast */}
+          <div>
+            <div className="flex justify-between items-center mb-2">
+              <label className="text-sm text-slate-300 font-medium">Contrast</label>
+              <span className="text-xs text-cyan-400 font-semibold bg-cyan-500/10 px-2 py-0.5 rounded-full">{adjustments.contrast}</span>
+            </div>
+            <input
+              type="range"
+              min="-100"
+              max="100"
+              value={adjustments.contrast}
+              onChange={(e) => onAdjustmentsChange({ ...adjustments, contrast: Number(e.target.value) })}
+              className="w-full"
+            />
+          </div>
+
+          {/* Gamma */}
+          <div>
+            <div className="flex justify-between items-center mb-2">
+              <label className="text-sm text-slate-300 font-medium">Gamma</label>
+              <span className="text-xs text-emerald-400 font-semibold bg-emerald-500/10 px-2 py-0.5 rounded-full">{adjustments.gamma.toFixed(1)}</span>
+            </div>
+            <input
+              type="range"
+              min="0.1"
+              max="5"
+              step="0.1"
+              value={adjustments.gamma}
+              onChange={(e) => onAdjustmentsChange({ ...adjustments, gamma: Number(e.target.value) })}
+              className="w-full"
+            />
+          </div>
+
+          {/* Grayscale */}
+          <div className="flex items-center justify-between">
+            <label className="text-sm text-slate-300 font-medium">Grayscale</label>
+            <button
+              onClick={() => onAdjustmentsChange({ ...adjustments, grayscale: !adjustments.grayscale })}
+              className={`flex items-center gap-1.5 px-2.5 py-1 rounded-lg text-xs font-medium transition-all duration-200
+                ${adjustments.grayscale
+                  ? 'bg-violet-500/20 text-violet-300 hover:bg-violet-500/30'
+                  : 'bg-slate-500/20 text-slate-400 hover:bg-slate-500/30'
+                }`}
+            >
+              {adjustments.grayscale ? <Eye className="w-3.5 h-3.5" /> : <EyeOff className="w-3.5 h-3.5" />}
+              <span>{adjustments.grayscale ? 'Enabled' : 'Disabled'}</span>
+            </button>
+          </div>
+        </div>
+      </div>
+
+      {/* Device Frame */}
+      <div className="mb-6">
+        <label className="block text-sm text-slate-300 font-medium mb-3">
+          Device Frame
+        </label>
+        <div className="grid grid-cols-3 gap-1.5">
+          {['', ...deviceFrames].map((name) => (
+            <button
+              key={name || 'none'}
+              onClick={() => onDeviceFrameChange(name)}
+              className={`px-2 py-1.5 text-xs rounded-lg transition-all duration-200 font-medium capitalize truncate
+                ${deviceFrame === name
+                  ? 'bg-gradient-to-r from-violet-500 to-purple-600 text-white shadow-lg shadow-violet-500/30'
+                  : 'bg-white/5 text-slate-300 hover:bg-white/10 border border-white/5'
+                }`}
+              title={name || 'No frame'}
+            >
+              {name || 'None'}
+            </button>
+          ))}
+        </div>
+      </div>
+
       {/* Output Ratio */}
       <div className={`mb-6 transition-opacity duration-200 ${!showBackground ? 'opacity-50 pointer-events-none' : ''}`}>
         <label className="block text-sm text-slate-300 font-medium mb-3">
@@ -1,7 +1,14 @@
+export interface ClipboardMetadata {
+  sourceUrl?: string;
+  sourceApp?: string;
+  dpi?: number;
+}
+
 export interface CaptureResult {
   width: number;
   height: number;
   data: string;
+  metadata?: ClipboardMetadata;
 }
 
 export interface WindowInfo {
@@ -90,11 +97,21 @@ export type OutputRatio = 'auto' | '1:1' | '4:3' | '3:2' | '16:9' | '5:3' | '9:1
 // Border position type - determines where border stroke is rendered relative to edge
 export type BorderType = 'outside' | 'center' | 'inside';
 
+// Tonal adjustments applied to the screenshot in the editor
+export interface Adjustments {
+  brightness: number; // -100 to 100
+  contrast: number; // -100 to 100
+  gamma: number; // 0.1 to 5.0, 1.0 = unchanged
+  grayscale: boolean;
+}
+
 // App configuration types
 export interface HotkeyConfig {
   fullscreen: string;
   region: string;
   window: string;
+  text?: string;
+  color?: string;
 }
 
 export interface StartupConfig {
@@ -105,13 +122,14 @@ export interface StartupConfig {
 
 export interface QuickSaveConfig {
   folder: string;
-  pattern: 'timestamp' | 'date' | 'increment';
+  pattern: 'timestamp' | 'date' | 'increment' | 'source';
 }
 
 export interface ExportConfig {
   defaultFormat: 'png' | 'jpeg';
   jpegQuality: number;
   includeBackground: boolean;
+  adjustments?: Adjustments;
 }
 
 export interface AppConfig {
@@ -129,4 +147,8 @@ export interface LibraryImage {
   thumbnail: string; // Base64 PNG
   width: number;
   height: number;
+  tags?: string[];
+  favorite: boolean;
+  sharedUrl?: string; // Link from the last upload
+  expires?: string; // When that link expires, for ephemeral hosts
 }
@@ -1,12 +1,34 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {config} from '../models';
 import {screenshot} from '../models';
 import {updater} from '../models';
-import {config} from '../models';
 import {main} from '../models';
 import {library} from '../models';
 import {windows} from '../models';
+import {history} from '../models';
 import {upload} from '../models';
+import {project} from '../models';
+import {server} from '../models';
+import {provenance} from '../models';
+
+export function AdjustImage(arg1:string,arg2:config.AdjustmentConfig):Promise<screenshot.CaptureResult>;
+
+export function ApplyDeviceFrame(arg1:string,arg2:string):Promise<screenshot.CaptureResult>;
+
+export function CancelUpload(arg1:string):Promise<boolean>;
+
+export function CaptureAnchor(arg1:string):Promise<screenshot.CaptureResult>;
+
+export function CaptureBestFrame(arg1:number,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number):Promise<screenshot.CaptureResult>;
+
+export function CaptureBurst(arg1:number,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number):Promise<string>;
+
+export function CaptureBurstAPNG(arg1:number,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number,arg7:number):Promise<string>;
+
+export function CaptureComposite(arg1:number,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number,arg7:string):Promise<screenshot.CaptureResult>;
+
+export function CaptureCountdown(arg1:number):Promise<boolean>;
 
 export function CaptureDisplay(arg1:number):Promise<screenshot.CaptureResult>;
 
@@ -14,60 +36,150 @@ export function CaptureFullscreen():Promise<screenshot.CaptureResult>;
 
 export function CaptureRegion(arg1:number,arg2:number,arg3:number,arg4:number):Promise<screenshot.CaptureResult>;
 
+export function CaptureScrolling(arg1:number):Promise<screenshot.CaptureResult>;
+
+export function CaptureVideoFrames(arg1:number,arg2:number):Promise<string>;
+
 export function CaptureWindow(arg1:number):Promise<screenshot.CaptureResult>;
 
 export function CheckForUpdate(arg1:string):Promise<updater.UpdateInfo>;
 
+export function ClearConfluenceCredentials():Promise<void>;
+
 export function ClearGDriveCredentials():Promise<void>;
 
 export function ClearR2Credentials():Promise<void>;
 
+export function ClearS3Credentials():Promise<void>;
+
+export function ComposeTemplate(arg1:string,arg2:Record<string, string>):Promise<main.SaveImageResult>;
+
+export function CopyFileToClipboard(arg1:string):Promise<void>;
+
+export function CopyHistoryEntry(arg1:string):Promise<void>;
+
+export function CreateAnchor(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number):Promise<config.AnchorConfig>;
+
+export function CreateDiagnosticsBundle():Promise<main.SaveImageResult>;
+
+export function DeleteAnchor(arg1:string):Promise<void>;
+
+export function DeleteHistoryEntry(arg1:string):Promise<void>;
+
 export function DeleteScreenshot(arg1:string):Promise<void>;
 
 export function DisconnectGDrive():Promise<void>;
 
+export function DisconnectOneNote():Promise<void>;
+
+export function ExportLibraryImages(arg1:Array<string>,arg2:string,arg3:number,arg4:number,arg5:string):Promise<Array<library.ExportedFile>>;
+
+export function FilterLibraryImages(arg1:Array<string>,arg2:boolean):Promise<Array<library.LibraryImage>>;
+
 export function FinishRegionCapture():Promise<void>;
 
 export function GetActiveDisplayIndex():Promise<number>;
 
 export function GetBackgroundImages():Promise<Array<string>>;
 
+export function GetBrowserSettings():Promise<config.BrowserConfig>;
+
 export function GetClipboardImage():Promise<screenshot.CaptureResult>;
 
 export function GetConfig():Promise<config.Config>;
 
+export function GetConfluenceConfig():Promise<config.ConfluenceConfig>;
+
+export function GetCropGuide():Promise<config.GuideConfig>;
+
+export function GetDeviceFrames():Promise<Array<string>>;
+
 export function GetDisplayBounds(arg1:number):Promise<main.DisplayBounds>;
 
 export function GetDisplayCount():Promise<number>;
 
 export function GetEditorConfig():Promise<config.EditorConfig>;
 
+export function GetEncryptionConfig():Promise<config.EncryptConfig>;
+
+export function GetEphemeralConfig():Promise<config.EphemeralConfig>;
+
+export function GetExternalFilters():Promise<Array<config.ExternalFilterConfig>>;
+
 export function GetGDriveConfig():Promise<config.GDriveConfig>;
 
 export function GetGDriveStatus():Promise<main.GDriveStatus>;
 
+export function GetHistoryThumbnail(arg1:string):Promise<string>;
+
 export function GetHotkeyConfig():Promise<main.HotkeyConfig>;
 
 export function GetLibraryImages():Promise<Array<library.LibraryImage>>;
 
+export function GetLibraryTags():Promise<Array<string>>;
+
+export function GetNotesConfig():Promise<config.NotesConfig>;
+
+export function GetOneNoteStatus():Promise<main.OneNoteStatus>;
+
+export function GetPlugins():Promise<Array<config.PluginConfig>>;
+
+export function GetPowerSettings():Promise<config.PowerConfig>;
+
 export function GetR2Config():Promise<config.R2Config>;
 
+export function GetS3Config():Promise<config.S3Config>;
+
+export function GetSchedule():Promise<config.ScheduleConfig>;
+
+export function GetScripts():Promise<Array<config.ScriptConfig>>;
+
+export function GetShareSettings():Promise<config.ShareConfig>;
+
 export function GetSkippedVersion():Promise<string>;
 
+export function GetTriggers():Promise<config.TriggerConfig>;
+
+export function GetUploadLimit():Promise<config.UploadLimitConfig>;
+
+export function GetUploadReview():Promise<Array<string>>;
+
+export function GetVideoSettings():Promise<config.VideoConfig>;
+
 export function GetVirtualScreenBounds():Promise<main.VirtualScreenBounds>;
 
+export function GetWatchdog():Promise<config.WatchdogConfig>;
+
 export function GetWindowInfo(arg1:number):Promise<windows.WindowInfo>;
 
 export function GetWindowList():Promise<Array<windows.WindowInfo>>;
 
 export function GetWindowListWithThumbnails():Promise<Array<windows.WindowInfoWithThumbnail>>;
 
+export function HasEncryptionPassphrase():Promise<boolean>;
+
 export function HasGDriveCredentials():Promise<boolean>;
 
+export function IsConfluenceConfigured():Promise<boolean>;
+
+export function IsEphemeralConfigured():Promise<boolean>;
+
 export function IsGDriveConnected():Promise<boolean>;
 
 export function IsR2Configured():Promise<boolean>;
 
+export function IsS3Configured():Promise<boolean>;
+
+export function IsSafeMode():Promise<boolean>;
+
+export function IsSecureDesktopActive():Promise<boolean>;
+
+export function ListAnchors():Promise<Array<config.AnchorConfig>>;
+
+export function ListHistory(arg1:string):Promise<Array<history.Entry>>;
+
+export function ListUploads():Promise<Array<upload.Progress>>;
+
 export function MinimizeToTray():Promise<void>;
 
 export function OpenImage():Promise<screenshot.CaptureResult>;
@@ -78,36 +190,130 @@ export function OpenURL(arg1:string):Promise<void>;
 
 export function PrepareRegionCapture():Promise<main.RegionCaptureData>;
 
+export function QueueUpload(arg1:string,arg2:string,arg3:string):Promise<string>;
+
 export function QuickSave(arg1:string,arg2:string):Promise<main.SaveImageResult>;
 
+export function RenderProject(arg1:string,arg2:string):Promise<main.SaveImageResult>;
+
+export function RevokeLocalShare(arg1:string):Promise<boolean>;
+
+export function RunScript(arg1:string):Promise<void>;
+
+export function SaveAnchor(arg1:config.AnchorConfig):Promise<void>;
+
 export function SaveBackgroundImages(arg1:Array<string>):Promise<void>;
 
 export function SaveConfig(arg1:config.Config):Promise<void>;
 
+export function SaveConfluenceConfig(arg1:config.ConfluenceConfig):Promise<void>;
+
+export function SaveConfluenceCredentials(arg1:string,arg2:string):Promise<void>;
+
 export function SaveEditorConfig(arg1:config.EditorConfig):Promise<void>;
 
+export function SaveEncryptionConfig(arg1:config.EncryptConfig):Promise<void>;
+
+export function SaveEncryptionPassphrase(arg1:string):Promise<void>;
+
+export function SaveEphemeralConfig(arg1:config.EphemeralConfig):Promise<void>;
+
 export function SaveGDriveConfig(arg1:string):Promise<void>;
 
 export function SaveGDriveCredentials(arg1:string,arg2:string):Promise<void>;
 
 export function SaveImage(arg1:string,arg2:string):Promise<main.SaveImageResult>;
 
+export function SaveNotesConfig(arg1:config.NotesConfig):Promise<void>;
+
+export function SaveProject(arg1:string,arg2:Array<project.Annotation>):Promise<main.SaveImageResult>;
+
 export function SaveR2Config(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
 
 export function SaveR2Credentials(arg1:string,arg2:string):Promise<void>;
 
+export function SaveS3Config(arg1:config.S3Config):Promise<void>;
+
+export function SaveS3Credentials(arg1:string,arg2:string):Promise<void>;
+
+export function SearchLibrary(arg1:string):Promise<Array<library.LibraryImage>>;
+
+export function SelectCropGuide():Promise<void>;
+
 export function SelectFolder():Promise<string>;
 
+export function SetBrowserSettings(arg1:config.BrowserConfig):Promise<void>;
+
+export function SetCropGuide(arg1:number,arg2:number,arg3:number,arg4:number):Promise<void>;
+
+export function SetCropGuideStyle(arg1:string,arg2:number):Promise<void>;
+
+export function SetExternalFilters(arg1:Array<config.ExternalFilterConfig>):Promise<void>;
+
+export function SetPlugins(arg1:Array<config.PluginConfig>):Promise<void>;
+
+export function SetPowerSettings(arg1:config.PowerConfig):Promise<void>;
+
+export function SetSchedule(arg1:config.ScheduleConfig):Promise<void>;
+
+export function SetScreenshotsFavorite(arg1:Array<string>,arg2:boolean):Promise<void>;
+
+export function SetScripts(arg1:Array<config.ScriptConfig>):Promise<void>;
+
+export function SetShareSettings(arg1:config.ShareConfig):Promise<void>;
+
 export function SetSkippedVersion(arg1:string):Promise<void>;
 
+export function SetTriggers(arg1:config.TriggerConfig):Promise<void>;
+
+export function SetUploadLimit(arg1:config.UploadLimitConfig):Promise<void>;
+
+export function SetUploadReview(arg1:Array<string>):Promise<void>;
+
+export function SetVideoSettings(arg1:config.VideoConfig):Promise<void>;
+
+export function SetWatchdog(arg1:config.WatchdogConfig):Promise<void>;
+
+export function ShareImage(arg1:string,arg2:string):Promise<void>;
+
+export function ShareLocal(arg1:string,arg2:string,arg3:string):Promise<server.Share>;
+
+export function ShowCropGuide(arg1:boolean):Promise<void>;
+
 export function ShowWindow():Promise<void>;
 
 export function StartGDriveAuth():Promise<string>;
 
+export function StartOneNoteAuth(arg1:string):Promise<string>;
+
+export function TagScreenshots(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
+export function TestConfluenceConnection():Promise<void>;
+
+export function TestEphemeralConnection():Promise<void>;
+
 export function TestR2Connection():Promise<void>;
 
+export function TestS3Connection():Promise<void>;
+
+export function UntagScreenshots(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
 export function UpdateWindowSize(arg1:number,arg2:number):Promise<void>;
 
+export function UploadHistoryEntry(arg1:string,arg2:string):Promise<string>;
+
+export function UploadLibraryImages(arg1:Array<string>,arg2:string):Promise<Array<main.LibraryUploadResult>>;
+
+export function UploadToConfluence(arg1:string,arg2:string):Promise<upload.UploadResult>;
+
+export function UploadToEphemeral(arg1:string,arg2:string):Promise<upload.UploadResult>;
+
 export function UploadToGDrive(arg1:string,arg2:string):Promise<upload.UploadResult>;
 
 export function UploadToR2(arg1:string,arg2:string):Promise<upload.UploadResult>;
+
+export function UploadToS3(arg1:string,arg2:string):Promise<upload.UploadResult>;
+
+export function VacuumLibrary(arg1:boolean):Promise<library.VacuumReport>;
+
+export function VerifyScreenshot(arg1:string):Promise<provenance.VerifyResult>;
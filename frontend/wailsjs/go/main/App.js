@@ -2,6 +2,42 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AdjustImage(arg1, arg2) {
+  return window['go']['main']['App']['AdjustImage'](arg1, arg2);
+}
+
+export function ApplyDeviceFrame(arg1, arg2) {
+  return window['go']['main']['App']['ApplyDeviceFrame'](arg1, arg2);
+}
+
+export function CancelUpload(arg1) {
+  return window['go']['main']['App']['CancelUpload'](arg1);
+}
+
+export function CaptureAnchor(arg1) {
+  return window['go']['main']['App']['CaptureAnchor'](arg1);
+}
+
+export function CaptureBestFrame(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['CaptureBestFrame'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function CaptureBurst(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['CaptureBurst'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function CaptureBurstAPNG(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['main']['App']['CaptureBurstAPNG'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
+}
+
+export function CaptureComposite(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['main']['App']['CaptureComposite'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
+}
+
+export function CaptureCountdown(arg1) {
+  return window['go']['main']['App']['CaptureCountdown'](arg1);
+}
+
 export function CaptureDisplay(arg1) {
   return window['go']['main']['App']['CaptureDisplay'](arg1);
 }
@@ -14,6 +50,14 @@ export function CaptureRegion(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['CaptureRegion'](arg1, arg2, arg3, arg4);
 }
 
+export function CaptureScrolling(arg1) {
+  return window['go']['main']['App']['CaptureScrolling'](arg1);
+}
+
+export function CaptureVideoFrames(arg1, arg2) {
+  return window['go']['main']['App']['CaptureVideoFrames'](arg1, arg2);
+}
+
 export function CaptureWindow(arg1) {
   return window['go']['main']['App']['CaptureWindow'](arg1);
 }
@@ -22,6 +66,10 @@ export function CheckForUpdate(arg1) {
   return window['go']['main']['App']['CheckForUpdate'](arg1);
 }
 
+export function ClearConfluenceCredentials() {
+  return window['go']['main']['App']['ClearConfluenceCredentials']();
+}
+
 export function ClearGDriveCredentials() {
   return window['go']['main']['App']['ClearGDriveCredentials']();
 }
@@ -30,6 +78,38 @@ export function ClearR2Credentials() {
   return window['go']['main']['App']['ClearR2Credentials']();
 }
 
+export function ClearS3Credentials() {
+  return window['go']['main']['App']['ClearS3Credentials']();
+}
+
+export function ComposeTemplate(arg1, arg2) {
+  return window['go']['main']['App']['ComposeTemplate'](arg1, arg2);
+}
+
+export function CopyFileToClipboard(arg1) {
+  return window['go']['main']['App']['CopyFileToClipboard'](arg1);
+}
+
+export function CopyHistoryEntry(arg1) {
+  return window['go']['main']['App']['CopyHistoryEntry'](arg1);
+}
+
+export function CreateAnchor(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['CreateAnchor'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function CreateDiagnosticsBundle() {
+  return window['go']['main']['App']['CreateDiagnosticsBundle']();
+}
+
+export function DeleteAnchor(arg1) {
+  return window['go']['main']['App']['DeleteAnchor'](arg1);
+}
+
+export function DeleteHistoryEntry(arg1) {
+  return window['go']['main']['App']['DeleteHistoryEntry'](arg1);
+}
+
 export function DeleteScreenshot(arg1) {
   return window['go']['main']['App']['DeleteScreenshot'](arg1);
 }
@@ -38,6 +118,18 @@ export function DisconnectGDrive() {
   return window['go']['main']['App']['DisconnectGDrive']();
 }
 
+export function DisconnectOneNote() {
+  return window['go']['main']['App']['DisconnectOneNote']();
+}
+
+export function ExportLibraryImages(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['ExportLibraryImages'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function FilterLibraryImages(arg1, arg2) {
+  return window['go']['main']['App']['FilterLibraryImages'](arg1, arg2);
+}
+
 export function FinishRegionCapture() {
   return window['go']['main']['App']['FinishRegionCapture']();
 }
@@ -50,6 +142,10 @@ export function GetBackgroundImages() {
   return window['go']['main']['App']['GetBackgroundImages']();
 }
 
+export function GetBrowserSettings() {
+  return window['go']['main']['App']['GetBrowserSettings']();
+}
+
 export function GetClipboardImage() {
   return window['go']['main']['App']['GetClipboardImage']();
 }
@@ -58,6 +154,18 @@ export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
 
+export function GetConfluenceConfig() {
+  return window['go']['main']['App']['GetConfluenceConfig']();
+}
+
+export function GetCropGuide() {
+  return window['go']['main']['App']['GetCropGuide']();
+}
+
+export function GetDeviceFrames() {
+  return window['go']['main']['App']['GetDeviceFrames']();
+}
+
 export function GetDisplayBounds(arg1) {
   return window['go']['main']['App']['GetDisplayBounds'](arg1);
 }
@@ -70,6 +178,18 @@ export function GetEditorConfig() {
   return window['go']['main']['App']['GetEditorConfig']();
 }
 
+export function GetEncryptionConfig() {
+  return window['go']['main']['App']['GetEncryptionConfig']();
+}
+
+export function GetEphemeralConfig() {
+  return window['go']['main']['App']['GetEphemeralConfig']();
+}
+
+export function GetExternalFilters() {
+  return window['go']['main']['App']['GetExternalFilters']();
+}
+
 export function GetGDriveConfig() {
   return window['go']['main']['App']['GetGDriveConfig']();
 }
@@ -78,6 +198,10 @@ export function GetGDriveStatus() {
   return window['go']['main']['App']['GetGDriveStatus']();
 }
 
+export function GetHistoryThumbnail(arg1) {
+  return window['go']['main']['App']['GetHistoryThumbnail'](arg1);
+}
+
 export function GetHotkeyConfig() {
   return window['go']['main']['App']['GetHotkeyConfig']();
 }
@@ -86,18 +210,74 @@ export function GetLibraryImages() {
   return window['go']['main']['App']['GetLibraryImages']();
 }
 
+export function GetLibraryTags() {
+  return window['go']['main']['App']['GetLibraryTags']();
+}
+
+export function GetNotesConfig() {
+  return window['go']['main']['App']['GetNotesConfig']();
+}
+
+export function GetOneNoteStatus() {
+  return window['go']['main']['App']['GetOneNoteStatus']();
+}
+
+export function GetPlugins() {
+  return window['go']['main']['App']['GetPlugins']();
+}
+
+export function GetPowerSettings() {
+  return window['go']['main']['App']['GetPowerSettings']();
+}
+
 export function GetR2Config() {
   return window['go']['main']['App']['GetR2Config']();
 }
 
+export function GetS3Config() {
+  return window['go']['main']['App']['GetS3Config']();
+}
+
+export function GetSchedule() {
+  return window['go']['main']['App']['GetSchedule']();
+}
+
+export function GetScripts() {
+  return window['go']['main']['App']['GetScripts']();
+}
+
+export function GetShareSettings() {
+  return window['go']['main']['App']['GetShareSettings']();
+}
+
 export function GetSkippedVersion() {
   return window['go']['main']['App']['GetSkippedVersion']();
 }
 
+export function GetTriggers() {
+  return window['go']['main']['App']['GetTriggers']();
+}
+
+export function GetUploadLimit() {
+  return window['go']['main']['App']['GetUploadLimit']();
+}
+
+export function GetUploadReview() {
+  return window['go']['main']['App']['GetUploadReview']();
+}
+
+export function GetVideoSettings() {
+  return window['go']['main']['App']['GetVideoSettings']();
+}
+
 export function GetVirtualScreenBounds() {
   return window['go']['main']['App']['GetVirtualScreenBounds']();
 }
 
+export function GetWatchdog() {
+  return window['go']['main']['App']['GetWatchdog']();
+}
+
 export function GetWindowInfo(arg1) {
   return window['go']['main']['App']['GetWindowInfo'](arg1);
 }
@@ -110,10 +290,22 @@ export function GetWindowListWithThumbnails() {
   return window['go']['main']['App']['GetWindowListWithThumbnails']();
 }
 
+export function HasEncryptionPassphrase() {
+  return window['go']['main']['App']['HasEncryptionPassphrase']();
+}
+
 export function HasGDriveCredentials() {
   return window['go']['main']['App']['HasGDriveCredentials']();
 }
 
+export function IsConfluenceConfigured() {
+  return window['go']['main']['App']['IsConfluenceConfigured']();
+}
+
+export function IsEphemeralConfigured() {
+  return window['go']['main']['App']['IsEphemeralConfigured']();
+}
+
 export function IsGDriveConnected() {
   return window['go']['main']['App']['IsGDriveConnected']();
 }
@@ -122,6 +314,30 @@ export function IsR2Configured() {
   return window['go']['main']['App']['IsR2Configured']();
 }
 
+export function IsS3Configured() {
+  return window['go']['main']['App']['IsS3Configured']();
+}
+
+export function IsSafeMode() {
+  return window['go']['main']['App']['IsSafeMode']();
+}
+
+export function IsSecureDesktopActive() {
+  return window['go']['main']['App']['IsSecureDesktopActive']();
+}
+
+export function ListAnchors() {
+  return window['go']['main']['App']['ListAnchors']();
+}
+
+export function ListHistory(arg1) {
+  return window['go']['main']['App']['ListHistory'](arg1);
+}
+
+export function ListUploads() {
+  return window['go']['main']['App']['ListUploads']();
+}
+
 export function MinimizeToTray() {
   return window['go']['main']['App']['MinimizeToTray']();
 }
@@ -142,10 +358,30 @@ export function PrepareRegionCapture() {
   return window['go']['main']['App']['PrepareRegionCapture']();
 }
 
+export function QueueUpload(arg1, arg2, arg3) {
+  return window['go']['main']['App']['QueueUpload'](arg1, arg2, arg3);
+}
+
 export function QuickSave(arg1, arg2) {
   return window['go']['main']['App']['QuickSave'](arg1, arg2);
 }
 
+export function RenderProject(arg1, arg2) {
+  return window['go']['main']['App']['RenderProject'](arg1, arg2);
+}
+
+export function RevokeLocalShare(arg1) {
+  return window['go']['main']['App']['RevokeLocalShare'](arg1);
+}
+
+export function RunScript(arg1) {
+  return window['go']['main']['App']['RunScript'](arg1);
+}
+
+export function SaveAnchor(arg1) {
+  return window['go']['main']['App']['SaveAnchor'](arg1);
+}
+
 export function SaveBackgroundImages(arg1) {
   return window['go']['main']['App']['SaveBackgroundImages'](arg1);
 }
@@ -154,10 +390,30 @@ export function SaveConfig(arg1) {
   return window['go']['main']['App']['SaveConfig'](arg1);
 }
 
+export function SaveConfluenceConfig(arg1) {
+  return window['go']['main']['App']['SaveConfluenceConfig'](arg1);
+}
+
+export function SaveConfluenceCredentials(arg1, arg2) {
+  return window['go']['main']['App']['SaveConfluenceCredentials'](arg1, arg2);
+}
+
 export function SaveEditorConfig(arg1) {
   return window['go']['main']['App']['SaveEditorConfig'](arg1);
 }
 
+export function SaveEncryptionConfig(arg1) {
+  return window['go']['main']['App']['SaveEncryptionConfig'](arg1);
+}
+
+export function SaveEncryptionPassphrase(arg1) {
+  return window['go']['main']['App']['SaveEncryptionPassphrase'](arg1);
+}
+
+export function SaveEphemeralConfig(arg1) {
+  return window['go']['main']['App']['SaveEphemeralConfig'](arg1);
+}
+
 export function SaveGDriveConfig(arg1) {
   return window['go']['main']['App']['SaveGDriveConfig'](arg1);
 }
@@ -170,6 +426,14 @@ export function SaveImage(arg1, arg2) {
   return window['go']['main']['App']['SaveImage'](arg1, arg2);
 }
 
+export function SaveNotesConfig(arg1) {
+  return window['go']['main']['App']['SaveNotesConfig'](arg1);
+}
+
+export function SaveProject(arg1, arg2) {
+  return window['go']['main']['App']['SaveProject'](arg1, arg2);
+}
+
 export function SaveR2Config(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['SaveR2Config'](arg1, arg2, arg3, arg4);
 }
@@ -178,14 +442,102 @@ export function SaveR2Credentials(arg1, arg2) {
   return window['go']['main']['App']['SaveR2Credentials'](arg1, arg2);
 }
 
+export function SaveS3Config(arg1) {
+  return window['go']['main']['App']['SaveS3Config'](arg1);
+}
+
+export function SaveS3Credentials(arg1, arg2) {
+  return window['go']['main']['App']['SaveS3Credentials'](arg1, arg2);
+}
+
+export function SearchLibrary(arg1) {
+  return window['go']['main']['App']['SearchLibrary'](arg1);
+}
+
+export function SelectCropGuide() {
+  return window['go']['main']['App']['SelectCropGuide']();
+}
+
 export function SelectFolder() {
   return window['go']['main']['App']['SelectFolder']();
 }
 
+export function SetBrowserSettings(arg1) {
+  return window['go']['main']['App']['SetBrowserSettings'](arg1);
+}
+
+export function SetCropGuide(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetCropGuide'](arg1, arg2, arg3, arg4);
+}
+
+export function SetCropGuideStyle(arg1, arg2) {
+  return window['go']['main']['App']['SetCropGuideStyle'](arg1, arg2);
+}
+
+export function SetExternalFilters(arg1) {
+  return window['go']['main']['App']['SetExternalFilters'](arg1);
+}
+
+export function SetPlugins(arg1) {
+  return window['go']['main']['App']['SetPlugins'](arg1);
+}
+
+export function SetPowerSettings(arg1) {
+  return window['go']['main']['App']['SetPowerSettings'](arg1);
+}
+
+export function SetSchedule(arg1) {
+  return window['go']['main']['App']['SetSchedule'](arg1);
+}
+
+export function SetScreenshotsFavorite(arg1, arg2) {
+  return window['go']['main']['App']['SetScreenshotsFavorite'](arg1, arg2);
+}
+
+export function SetScripts(arg1) {
+  return window['go']['main']['App']['SetScripts'](arg1);
+}
+
+export function SetShareSettings(arg1) {
+  return window['go']['main']['App']['SetShareSettings'](arg1);
+}
+
 export function SetSkippedVersion(arg1) {
   return window['go']['main']['App']['SetSkippedVersion'](arg1);
 }
 
+export function SetTriggers(arg1) {
+  return window['go']['main']['App']['SetTriggers'](arg1);
+}
+
+export function SetUploadLimit(arg1) {
+  return window['go']['main']['App']['SetUploadLimit'](arg1);
+}
+
+export function SetUploadReview(arg1) {
+  return window['go']['main']['App']['SetUploadReview'](arg1);
+}
+
+export function SetVideoSettings(arg1) {
+  return window['go']['main']['App']['SetVideoSettings'](arg1);
+}
+
+export function SetWatchdog(arg1) {
+  return window['go']['main']['App']['SetWatchdog'](arg1);
+}
+
+export function ShareImage(arg1, arg2) {
+  return window['go']['main']['App']['ShareImage'](arg1, arg2);
+}
+
+export function ShareLocal(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ShareLocal'](arg1, arg2, arg3);
+}
+
+export function ShowCropGuide(arg1) {
+  return window['go']['main']['App']['ShowCropGuide'](arg1);
+}
+
 export function ShowWindow() {
   return window['go']['main']['App']['ShowWindow']();
 }
@@ -194,14 +546,54 @@ export function StartGDriveAuth() {
   return window['go']['main']['App']['StartGDriveAuth']();
 }
 
+export function StartOneNoteAuth(arg1) {
+  return window['go']['main']['App']['StartOneNoteAuth'](arg1);
+}
+
+export function TagScreenshots(arg1, arg2) {
+  return window['go']['main']['App']['TagScreenshots'](arg1, arg2);
+}
+
+export function TestConfluenceConnection() {
+  return window['go']['main']['App']['TestConfluenceConnection']();
+}
+
+export function TestEphemeralConnection() {
+  return window['go']['main']['App']['TestEphemeralConnection']();
+}
+
 export function TestR2Connection() {
   return window['go']['main']['App']['TestR2Connection']();
 }
 
+export function TestS3Connection() {
+  return window['go']['main']['App']['TestS3Connection']();
+}
+
+export function UntagScreenshots(arg1, arg2) {
+  return window['go']['main']['App']['UntagScreenshots'](arg1, arg2);
+}
+
 export function UpdateWindowSize(arg1, arg2) {
   return window['go']['main']['App']['UpdateWindowSize'](arg1, arg2);
 }
 
+export function UploadHistoryEntry(arg1, arg2) {
+  return window['go']['main']['App']['UploadHistoryEntry'](arg1, arg2);
+}
+
+export function UploadLibraryImages(arg1, arg2) {
+  return window['go']['main']['App']['UploadLibraryImages'](arg1, arg2);
+}
+
+export function UploadToConfluence(arg1, arg2) {
+  return window['go']['main']['App']['UploadToConfluence'](arg1, arg2);
+}
+
+export function UploadToEphemeral(arg1, arg2) {
+  return window['go']['main']['App']['UploadToEphemeral'](arg1, arg2);
+}
+
 export function UploadToGDrive(arg1, arg2) {
   return window['go']['main']['App']['UploadToGDrive'](arg1, arg2);
 }
@@ -209,3 +601,15 @@ export function UploadToGDrive(arg1, arg2) {
 export function UploadToR2(arg1, arg2) {
   return window['go']['main']['App']['UploadToR2'](arg1, arg2);
 }
+
+export function UploadToS3(arg1, arg2) {
+  return window['go']['main']['App']['UploadToS3'](arg1, arg2);
+}
+
+export function VacuumLibrary(arg1) {
+  return window['go']['main']['App']['VacuumLibrary'](arg1);
+}
+
+export function VerifyScreenshot(arg1) {
+  return window['go']['main']['App']['VerifyScreenshot'](arg1);
+}
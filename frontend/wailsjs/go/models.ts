@@ -1,5 +1,181 @@
 export namespace config {
 	
+	export class AdjustmentConfig {
+	    brightness: number;
+	    contrast: number;
+	    gamma: number;
+	    grayscale: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AdjustmentConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.brightness = source["brightness"];
+	        this.contrast = source["contrast"];
+	        this.gamma = source["gamma"];
+	        this.grayscale = source["grayscale"];
+	    }
+	}
+	export class AnchorConfig {
+	    name: string;
+	    app?: string;
+	    title?: string;
+	    corner?: string;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnchorConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.app = source["app"];
+	        this.title = source["title"];
+	        this.corner = source["corner"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class BrowserConfig {
+	    recordUrl: boolean;
+	    stampUrl: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new BrowserConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recordUrl = source["recordUrl"];
+	        this.stampUrl = source["stampUrl"];
+	    }
+	}
+	export class RegionConfig {
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RegionConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class CaptureConfig {
+	    includeCursor: boolean;
+	    lastRegions?: Record<number, RegionConfig>;
+	    lastRegionMonitor?: number;
+	    anchors?: AnchorConfig[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptureConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.includeCursor = source["includeCursor"];
+	        this.lastRegions = this.convertValues(source["lastRegions"], RegionConfig, true);
+	        this.lastRegionMonitor = source["lastRegionMonitor"];
+	        this.anchors = this.convertValues(source["anchors"], AnchorConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UploadLimitConfig {
+	    kbps?: number;
+	    schedule?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UploadLimitConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kbps = source["kbps"];
+	        this.schedule = source["schedule"];
+	    }
+	}
+	export class EncryptConfig {
+	    enabled?: boolean;
+	    viewer?: boolean;
+	    passphrase?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.viewer = source["viewer"];
+	        this.passphrase = source["passphrase"];
+	    }
+	}
+	export class EphemeralConfig {
+	    enabled?: boolean;
+	    url?: string;
+	    expiryHours?: number;
+	    secret?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EphemeralConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.url = source["url"];
+	        this.expiryHours = source["expiryHours"];
+	        this.secret = source["secret"];
+	    }
+	}
+	export class ConfluenceConfig {
+	    baseUrl?: string;
+	    pageId?: string;
+	    marker?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConfluenceConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.baseUrl = source["baseUrl"];
+	        this.pageId = source["pageId"];
+	        this.marker = source["marker"];
+	    }
+	}
 	export class GDriveConfig {
 	    folderId?: string;
 	
@@ -12,6 +188,30 @@ export namespace config {
 	        this.folderId = source["folderId"];
 	    }
 	}
+	export class S3Config {
+	    endpoint?: string;
+	    region?: string;
+	    bucket?: string;
+	    publicUrl?: string;
+	    keyTemplate?: string;
+	    acl?: string;
+	    pathStyle?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new S3Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.endpoint = source["endpoint"];
+	        this.region = source["region"];
+	        this.bucket = source["bucket"];
+	        this.publicUrl = source["publicUrl"];
+	        this.keyTemplate = source["keyTemplate"];
+	        this.acl = source["acl"];
+	        this.pathStyle = source["pathStyle"];
+	    }
+	}
 	export class R2Config {
 	    accountId?: string;
 	    bucket?: string;
@@ -32,7 +232,13 @@ export namespace config {
 	}
 	export class CloudConfig {
 	    r2?: R2Config;
+	    s3?: S3Config;
 	    gdrive?: GDriveConfig;
+	    confluence?: ConfluenceConfig;
+	    ephemeral?: EphemeralConfig;
+	    encrypt?: EncryptConfig;
+	    limit?: UploadLimitConfig;
+	    review?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new CloudConfig(source);
@@ -41,7 +247,219 @@ export namespace config {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.r2 = this.convertValues(source["r2"], R2Config);
+	        this.s3 = this.convertValues(source["s3"], S3Config);
 	        this.gdrive = this.convertValues(source["gdrive"], GDriveConfig);
+	        this.confluence = this.convertValues(source["confluence"], ConfluenceConfig);
+	        this.ephemeral = this.convertValues(source["ephemeral"], EphemeralConfig);
+	        this.encrypt = this.convertValues(source["encrypt"], EncryptConfig);
+	        this.limit = this.convertValues(source["limit"], UploadLimitConfig);
+	        this.review = source["review"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ColorConfig {
+	    embedProfile: boolean;
+	    profiles?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ColorConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.embedProfile = source["embedProfile"];
+	        this.profiles = source["profiles"];
+	    }
+	}
+	export class HistoryConfig {
+	    disabled?: boolean;
+	    maxEntries?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.disabled = source["disabled"];
+	        this.maxEntries = source["maxEntries"];
+	    }
+	}
+	export class PowerConfig {
+	    idleMinutes?: number;
+	    batterySaver?: boolean;
+	    batteryPercent?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PowerConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.idleMinutes = source["idleMinutes"];
+	        this.batterySaver = source["batterySaver"];
+	        this.batteryPercent = source["batteryPercent"];
+	    }
+	}
+	export class LinksConfig {
+	    consent?: string;
+	    approved?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LinksConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.consent = source["consent"];
+	        this.approved = source["approved"];
+	    }
+	}
+	export class VideoConfig {
+	    stepKey?: string;
+	    stepDelayMs?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VideoConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.stepKey = source["stepKey"];
+	        this.stepDelayMs = source["stepDelayMs"];
+	    }
+	}
+	export class OneNoteConfig {
+	    sectionId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OneNoteConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sectionId = source["sectionId"];
+	    }
+	}
+	export class ObsidianConfig {
+	    vault?: string;
+	    folder?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ObsidianConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.vault = source["vault"];
+	        this.folder = source["folder"];
+	    }
+	}
+	export class NotesConfig {
+	    tags?: string[];
+	    obsidian: ObsidianConfig;
+	    onenote: OneNoteConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new NotesConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tags = source["tags"];
+	        this.obsidian = this.convertValues(source["obsidian"], ObsidianConfig);
+	        this.onenote = this.convertValues(source["onenote"], OneNoteConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScheduledTask {
+	    name: string;
+	    enabled: boolean;
+	    cron: string;
+	    capture?: string;
+	    output: HotkeyActionConfig;
+	    script?: string;
+	    skipMissed?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScheduledTask(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.cron = source["cron"];
+	        this.capture = source["capture"];
+	        this.output = this.convertValues(source["output"], HotkeyActionConfig);
+	        this.script = source["script"];
+	        this.skipMissed = source["skipMissed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScheduleConfig {
+	    enabled: boolean;
+	    tasks?: ScheduledTask[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ScheduleConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.tasks = this.convertValues(source["tasks"], ScheduledTask);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -62,6 +480,230 @@ export namespace config {
 		    return a;
 		}
 	}
+	export class ScriptConfig {
+	    name: string;
+	    path: string;
+	    hotkey?: string;
+	    timeoutSeconds?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScriptConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.hotkey = source["hotkey"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	    }
+	}
+	export class PluginConfig {
+	    path: string;
+	    enabled: boolean;
+	    timeoutSeconds?: number;
+	    memoryMB?: number;
+	    uploadHosts?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PluginConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.enabled = source["enabled"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	        this.memoryMB = source["memoryMB"];
+	        this.uploadHosts = source["uploadHosts"];
+	    }
+	}
+	export class ExternalFilterConfig {
+	    name: string;
+	    enabled: boolean;
+	    command: string;
+	    args?: string[];
+	    timeoutSeconds?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExternalFilterConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.command = source["command"];
+	        this.args = source["args"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	    }
+	}
+	export class ShareConfig {
+	    port?: number;
+	    expiryMinutes?: number;
+	    reusable?: boolean;
+	    viewer?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShareConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.port = source["port"];
+	        this.expiryMinutes = source["expiryMinutes"];
+	        this.reusable = source["reusable"];
+	        this.viewer = source["viewer"];
+	    }
+	}
+	export class WatchdogConfig {
+	    enabled: boolean;
+	    process?: string;
+	    hangSeconds?: number;
+	    folder?: string;
+	    reportTemplate?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatchdogConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.process = source["process"];
+	        this.hangSeconds = source["hangSeconds"];
+	        this.folder = source["folder"];
+	        this.reportTemplate = source["reportTemplate"];
+	    }
+	}
+	export class TriggerRule {
+	    name: string;
+	    enabled: boolean;
+	    event: string;
+	    title?: string;
+	    class?: string;
+	    capture?: string;
+	    output: HotkeyActionConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new TriggerRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.event = source["event"];
+	        this.title = source["title"];
+	        this.class = source["class"];
+	        this.capture = source["capture"];
+	        this.output = this.convertValues(source["output"], HotkeyActionConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TriggerConfig {
+	    enabled: boolean;
+	    cooldown?: number;
+	    rules?: TriggerRule[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TriggerConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.cooldown = source["cooldown"];
+	        this.rules = this.convertValues(source["rules"], TriggerRule);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GuideConfig {
+	    enabled: boolean;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	    color?: string;
+	    thickness?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GuideConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.color = source["color"];
+	        this.thickness = source["thickness"];
+	    }
+	}
+	export class OverlayConfig {
+	    perMonitor: boolean;
+	    dimInactive: boolean;
+	    inactiveOpacity: number;
+	    noSuggestions: boolean;
+	    clientCoords: boolean;
+	    colorFormat?: string;
+	    loupe: boolean;
+	    adjust: boolean;
+	    snap: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OverlayConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.perMonitor = source["perMonitor"];
+	        this.dimInactive = source["dimInactive"];
+	        this.inactiveOpacity = source["inactiveOpacity"];
+	        this.noSuggestions = source["noSuggestions"];
+	        this.clientCoords = source["clientCoords"];
+	        this.colorFormat = source["colorFormat"];
+	        this.loupe = source["loupe"];
+	        this.adjust = source["adjust"];
+	        this.snap = source["snap"];
+	    }
+	}
 	export class UpdateConfig {
 	    checkOnStartup: boolean;
 	    skippedVersion?: string;
@@ -92,6 +734,7 @@ export namespace config {
 	    borderColor: string;
 	    borderOpacity: number;
 	    borderType: string;
+	    deviceFrame?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new EditorConfig(source);
@@ -114,6 +757,7 @@ export namespace config {
 	        this.borderColor = source["borderColor"];
 	        this.borderOpacity = source["borderOpacity"];
 	        this.borderType = source["borderType"];
+	        this.deviceFrame = source["deviceFrame"];
 	    }
 	}
 	export class WindowConfig {
@@ -135,6 +779,12 @@ export namespace config {
 	    jpegQuality: number;
 	    includeBackground: boolean;
 	    autoCopyToClipboard: boolean;
+	    adjustments: AdjustmentConfig;
+	    writeManifest: boolean;
+	    color: ColorConfig;
+	    pngCompression?: string;
+	    webpEncoder?: string;
+	    avifEncoder?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ExportConfig(source);
@@ -146,11 +796,37 @@ export namespace config {
 	        this.jpegQuality = source["jpegQuality"];
 	        this.includeBackground = source["includeBackground"];
 	        this.autoCopyToClipboard = source["autoCopyToClipboard"];
+	        this.adjustments = this.convertValues(source["adjustments"], AdjustmentConfig);
+	        this.writeManifest = source["writeManifest"];
+	        this.color = this.convertValues(source["color"], ColorConfig);
+	        this.pngCompression = source["pngCompression"];
+	        this.webpEncoder = source["webpEncoder"];
+	        this.avifEncoder = source["avifEncoder"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class QuickSaveConfig {
 	    folder: string;
 	    pattern: string;
+	    deduplicate?: boolean;
+	    noWindowHistory?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new QuickSaveConfig(source);
@@ -160,6 +836,8 @@ export namespace config {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.folder = source["folder"];
 	        this.pattern = source["pattern"];
+	        this.deduplicate = source["deduplicate"];
+	        this.noWindowHistory = source["noWindowHistory"];
 	    }
 	}
 	export class StartupConfig {
@@ -180,10 +858,36 @@ export namespace config {
 	        this.closeToTray = source["closeToTray"];
 	    }
 	}
+	export class HotkeyActionConfig {
+	    destination: string;
+	    format?: string;
+	    quality?: number;
+	    provider?: string;
+	    delay?: number;
+	    annotate?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HotkeyActionConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.destination = source["destination"];
+	        this.format = source["format"];
+	        this.quality = source["quality"];
+	        this.provider = source["provider"];
+	        this.delay = source["delay"];
+	        this.annotate = source["annotate"];
+	    }
+	}
 	export class HotkeyConfig {
 	    fullscreen: string;
 	    region: string;
 	    window: string;
+	    text?: string;
+	    color?: string;
+	    repeat?: string;
+	    actions?: Record<string, HotkeyActionConfig>;
 	
 	    static createFrom(source: any = {}) {
 	        return new HotkeyConfig(source);
@@ -194,7 +898,29 @@ export namespace config {
 	        this.fullscreen = source["fullscreen"];
 	        this.region = source["region"];
 	        this.window = source["window"];
+	        this.text = source["text"];
+	        this.color = source["color"];
+	        this.repeat = source["repeat"];
+	        this.actions = this.convertValues(source["actions"], HotkeyActionConfig, true);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Config {
 	    hotkeys: HotkeyConfig;
@@ -205,6 +931,22 @@ export namespace config {
 	    editor: EditorConfig;
 	    update: UpdateConfig;
 	    cloud?: CloudConfig;
+	    overlay: OverlayConfig;
+	    guide: GuideConfig;
+	    triggers: TriggerConfig;
+	    watchdog: WatchdogConfig;
+	    share: ShareConfig;
+	    filters?: ExternalFilterConfig[];
+	    plugins?: PluginConfig[];
+	    scripts?: ScriptConfig[];
+	    schedule: ScheduleConfig;
+	    browser: BrowserConfig;
+	    notes: NotesConfig;
+	    video: VideoConfig;
+	    links: LinksConfig;
+	    power: PowerConfig;
+	    capture: CaptureConfig;
+	    history: HistoryConfig;
 	    backgroundImages?: string[];
 	
 	    static createFrom(source: any = {}) {
@@ -221,6 +963,22 @@ export namespace config {
 	        this.editor = this.convertValues(source["editor"], EditorConfig);
 	        this.update = this.convertValues(source["update"], UpdateConfig);
 	        this.cloud = this.convertValues(source["cloud"], CloudConfig);
+	        this.overlay = this.convertValues(source["overlay"], OverlayConfig);
+	        this.guide = this.convertValues(source["guide"], GuideConfig);
+	        this.triggers = this.convertValues(source["triggers"], TriggerConfig);
+	        this.watchdog = this.convertValues(source["watchdog"], WatchdogConfig);
+	        this.share = this.convertValues(source["share"], ShareConfig);
+	        this.filters = this.convertValues(source["filters"], ExternalFilterConfig);
+	        this.plugins = this.convertValues(source["plugins"], PluginConfig);
+	        this.scripts = this.convertValues(source["scripts"], ScriptConfig);
+	        this.schedule = this.convertValues(source["schedule"], ScheduleConfig);
+	        this.browser = this.convertValues(source["browser"], BrowserConfig);
+	        this.notes = this.convertValues(source["notes"], NotesConfig);
+	        this.video = this.convertValues(source["video"], VideoConfig);
+	        this.links = this.convertValues(source["links"], LinksConfig);
+	        this.power = this.convertValues(source["power"], PowerConfig);
+	        this.capture = this.convertValues(source["capture"], CaptureConfig);
+	        this.history = this.convertValues(source["history"], HistoryConfig);
 	        this.backgroundImages = source["backgroundImages"];
 	    }
 	
@@ -242,19 +1000,112 @@ export namespace config {
 		    return a;
 		}
 	}
-	
-	
-	
-	
-	
-	
-	
-	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+	
+
+}
+
+export namespace history {
+	
+	export class Entry {
+	    id: string;
+	    // Go type: time
+	    time: any;
+	    path?: string;
+	    url?: string;
+	    source?: string;
+	    app?: string;
+	    title?: string;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.path = source["path"];
+	        this.url = source["url"];
+	        this.source = source["source"];
+	        this.app = source["app"];
+	        this.title = source["title"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
 export namespace library {
 	
+	export class ExportedFile {
+	    source: string;
+	    output?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportedFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.output = source["output"];
+	        this.error = source["error"];
+	    }
+	}
 	export class LibraryImage {
 	    filepath: string;
 	    filename: string;
@@ -262,6 +1113,10 @@ export namespace library {
 	    thumbnail: string;
 	    width: number;
 	    height: number;
+	    tags?: string[];
+	    favorite: boolean;
+	    sharedUrl?: string;
+	    expires?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new LibraryImage(source);
@@ -275,6 +1130,30 @@ export namespace library {
 	        this.thumbnail = source["thumbnail"];
 	        this.width = source["width"];
 	        this.height = source["height"];
+	        this.tags = source["tags"];
+	        this.favorite = source["favorite"];
+	        this.sharedUrl = source["sharedUrl"];
+	        this.expires = source["expires"];
+	    }
+	}
+	export class VacuumReport {
+	    forgotten: number;
+	    deduplicated: number;
+	    savedBytes: number;
+	    removed: number;
+	    freedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VacuumReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.forgotten = source["forgotten"];
+	        this.deduplicated = source["deduplicated"];
+	        this.savedBytes = source["savedBytes"];
+	        this.removed = source["removed"];
+	        this.freedBytes = source["freedBytes"];
 	    }
 	}
 
@@ -330,6 +1209,36 @@ export namespace main {
 	        this.window = source["window"];
 	    }
 	}
+	export class LibraryUploadResult {
+	    path: string;
+	    url?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LibraryUploadResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.url = source["url"];
+	        this.error = source["error"];
+	    }
+	}
+	export class OneNoteStatus {
+	    connected: boolean;
+	    hasClientId: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OneNoteStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.hasClientId = source["hasClientId"];
+	    }
+	}
 	export class RegionCaptureData {
 	    screenshot?: screenshot.CaptureResult;
 	    screenX: number;
@@ -413,12 +1322,242 @@ export namespace main {
 
 }
 
+export namespace project {
+	
+	export class Point {
+	    x: number;
+	    y: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Point(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	    }
+	}
+	export class Annotation {
+	    id?: string;
+	    type: string;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	    stroke?: string;
+	    strokeWidth: number;
+	    fill?: string;
+	    cornerRadius?: number;
+	    points?: number[];
+	    curved?: boolean;
+	    curveOffset?: Point;
+	    text?: string;
+	    fontSize?: number;
+	    fontFamily?: string;
+	    fontStyle?: string;
+	    textAlign?: string;
+	    dimOpacity?: number;
+	    number?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Annotation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.stroke = source["stroke"];
+	        this.strokeWidth = source["strokeWidth"];
+	        this.fill = source["fill"];
+	        this.cornerRadius = source["cornerRadius"];
+	        this.points = source["points"];
+	        this.curved = source["curved"];
+	        this.curveOffset = this.convertValues(source["curveOffset"], Point);
+	        this.text = source["text"];
+	        this.fontSize = source["fontSize"];
+	        this.fontFamily = source["fontFamily"];
+	        this.fontStyle = source["fontStyle"];
+	        this.textAlign = source["textAlign"];
+	        this.dimOpacity = source["dimOpacity"];
+	        this.number = source["number"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace provenance {
+	
+	export class FrameClock {
+	    qpc: number;
+	    qpcEnd: number;
+	    qpcFrequency: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FrameClock(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.qpc = source["qpc"];
+	        this.qpcEnd = source["qpcEnd"];
+	        this.qpcFrequency = source["qpcFrequency"];
+	    }
+	}
+	export class MonitorInfo {
+	    index: number;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MonitorInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class Manifest {
+	    schema: number;
+	    file: string;
+	    sha256: string;
+	    size: number;
+	    capturedAt?: string;
+	    savedAt: string;
+	    machineIdHash?: string;
+	    appVersion: string;
+	    monitor?: MonitorInfo;
+	    frame?: FrameClock;
+	
+	    static createFrom(source: any = {}) {
+	        return new Manifest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.schema = source["schema"];
+	        this.file = source["file"];
+	        this.sha256 = source["sha256"];
+	        this.size = source["size"];
+	        this.capturedAt = source["capturedAt"];
+	        this.savedAt = source["savedAt"];
+	        this.machineIdHash = source["machineIdHash"];
+	        this.appVersion = source["appVersion"];
+	        this.monitor = this.convertValues(source["monitor"], MonitorInfo);
+	        this.frame = this.convertValues(source["frame"], FrameClock);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class VerifyResult {
+	    valid: boolean;
+	    expectedSha256: string;
+	    actualSha256: string;
+	    manifest?: Manifest;
+	
+	    static createFrom(source: any = {}) {
+	        return new VerifyResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.valid = source["valid"];
+	        this.expectedSha256 = source["expectedSha256"];
+	        this.actualSha256 = source["actualSha256"];
+	        this.manifest = this.convertValues(source["manifest"], Manifest);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace screenshot {
 	
+	export class ClipboardMetadata {
+	    sourceUrl?: string;
+	    sourceApp?: string;
+	    dpi?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipboardMetadata(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sourceUrl = source["sourceUrl"];
+	        this.sourceApp = source["sourceApp"];
+	        this.dpi = source["dpi"];
+	    }
+	}
 	export class CaptureResult {
 	    width: number;
 	    height: number;
 	    data: string;
+	    metadata?: ClipboardMetadata;
 	
 	    static createFrom(source: any = {}) {
 	        return new CaptureResult(source);
@@ -429,7 +1568,66 @@ export namespace screenshot {
 	        this.width = source["width"];
 	        this.height = source["height"];
 	        this.data = source["data"];
+	        this.metadata = this.convertValues(source["metadata"], ClipboardMetadata);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace server {
+	
+	export class Share {
+	    token: string;
+	    url: string;
+	    // Go type: time
+	    expires: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Share(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.token = source["token"];
+	        this.url = source["url"];
+	        this.expires = this.convertValues(source["expires"], null);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
@@ -465,10 +1663,43 @@ export namespace updater {
 
 export namespace upload {
 	
+	export class Progress {
+	    id: string;
+	    provider: string;
+	    filename: string;
+	    state: string;
+	    attempt: number;
+	    sent: number;
+	    total: number;
+	    url?: string;
+	    expiresAt?: string;
+	    error?: string;
+	    retryAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Progress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.provider = source["provider"];
+	        this.filename = source["filename"];
+	        this.state = source["state"];
+	        this.attempt = source["attempt"];
+	        this.sent = source["sent"];
+	        this.total = source["total"];
+	        this.url = source["url"];
+	        this.expiresAt = source["expiresAt"];
+	        this.error = source["error"];
+	        this.retryAt = source["retryAt"];
+	    }
+	}
 	export class UploadResult {
 	    success: boolean;
 	    publicUrl: string;
 	    error?: string;
+	    expiresAt?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new UploadResult(source);
@@ -479,6 +1710,7 @@ export namespace upload {
 	        this.success = source["success"];
 	        this.publicUrl = source["publicUrl"];
 	        this.error = source["error"];
+	        this.expiresAt = source["expiresAt"];
 	    }
 	}
 
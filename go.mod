@@ -9,11 +9,14 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.95.0
 	github.com/danieljoos/wincred v1.2.3
 	github.com/kbinani/screenshot v0.0.0-20250624051815-089614a94018
+	github.com/tetratelabs/wazero v1.9.0
 	github.com/wailsapp/wails/v2 v2.11.0
+	github.com/yuin/gopher-lua v1.1.2
 	golang.org/x/image v0.33.0
 	golang.org/x/oauth2 v0.34.0
 	golang.org/x/sys v0.39.0
 	google.golang.org/api v0.259.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
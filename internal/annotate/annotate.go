@@ -0,0 +1,225 @@
+// Package annotate is the post-capture editor: arrows, rectangles, ellipses,
+// freehand pen and highlighter strokes, text labels and blurred or
+// pixelated redactions drawn on a capture before it is saved, copied or
+// uploaded. Annotations use the project format, so the editor renders them
+// exactly like .wshot projects, and every change can be undone and redone.
+// Redactions always apply to the capture beneath the other annotations.
+package annotate
+
+import (
+	"image"
+	"slices"
+
+	"winshot/internal/project"
+)
+
+// maxUndo bounds the undo history.
+const maxUndo = 100
+
+// minDrag is how far a drag must go before it draws a shape.
+const minDrag = 3
+
+// highlighterScale widens highlighter strokes relative to Style.Width.
+const highlighterScale = 4
+
+// Tool is a drawing tool of the editor.
+type Tool int
+
+// Editor tools.
+const (
+	ToolArrow Tool = iota
+	ToolRectangle
+	ToolEllipse
+	ToolPen
+	ToolHighlighter
+	ToolText
+	ToolBlur
+	ToolPixelate
+)
+
+// String returns the tool's name as shown in the editor.
+func (t Tool) String() string {
+	switch t {
+	case ToolArrow:
+		return "Arrow"
+	case ToolRectangle:
+		return "Rectangle"
+	case ToolEllipse:
+		return "Ellipse"
+	case ToolPen:
+		return "Pen"
+	case ToolHighlighter:
+		return "Highlighter"
+	case ToolText:
+		return "Text"
+	case ToolBlur:
+		return "Blur"
+	case ToolPixelate:
+		return "Pixelate"
+	}
+	return "Unknown"
+}
+
+// Style is the color and size new annotations are drawn with.
+type Style struct {
+	Color    string  // Hex color such as "#ff3b30"
+	Width    float64 // Stroke width in image pixels
+	FontSize float64 // Text height in image pixels
+}
+
+// DefaultStyle is the style the editor starts with.
+var DefaultStyle = Style{Color: "#ff3b30", Width: 4, FontSize: 32}
+
+// Editor holds a capture and the annotations drawn on it, with undo and
+// redo. It is not safe for concurrent use.
+type Editor struct {
+	base        *image.RGBA
+	annotations []project.Annotation
+	undo        [][]project.Annotation // Earlier states, newest last
+	redo        [][]project.Annotation // States undone, newest last
+}
+
+// NewEditor returns an editor for img without annotations.
+func NewEditor(img *image.RGBA) *Editor {
+	return &Editor{base: img}
+}
+
+// Image returns the capture being annotated.
+func (e *Editor) Image() *image.RGBA {
+	return e.base
+}
+
+// Annotations returns a copy of the current annotations in drawing order.
+func (e *Editor) Annotations() []project.Annotation {
+	return slices.Clone(e.annotations)
+}
+
+// Add draws an annotation on top of the others.
+func (e *Editor) Add(a project.Annotation) {
+	e.change(append(slices.Clone(e.annotations), a))
+}
+
+// Clear removes every annotation. It can be undone.
+func (e *Editor) Clear() {
+	if len(e.annotations) > 0 {
+		e.change(nil)
+	}
+}
+
+// change records the current state for Undo and replaces it.
+func (e *Editor) change(next []project.Annotation) {
+	e.undo = append(e.undo, e.annotations)
+	if len(e.undo) > maxUndo {
+		e.undo = e.undo[1:]
+	}
+	e.redo = nil
+	e.annotations = next
+}
+
+// CanUndo reports whether there is a change to undo.
+func (e *Editor) CanUndo() bool {
+	return len(e.undo) > 0
+}
+
+// CanRedo reports whether there is an undone change to redo.
+func (e *Editor) CanRedo() bool {
+	return len(e.redo) > 0
+}
+
+// Undo reverts the last change, reporting false if there was none.
+func (e *Editor) Undo() bool {
+	if len(e.undo) == 0 {
+		return false
+	}
+	e.redo = append(e.redo, e.annotations)
+	e.annotations = e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	return true
+}
+
+// Redo reapplies the last undone change, reporting false if there was none.
+func (e *Editor) Redo() bool {
+	if len(e.redo) == 0 {
+		return false
+	}
+	e.undo = append(e.undo, e.annotations)
+	e.annotations = e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	return true
+}
+
+// Render returns the capture with the redactions applied and the other
+// annotations drawn on it, followed by extra, such as a shape still being
+// dragged.
+func (e *Editor) Render(extra ...project.Annotation) (*image.RGBA, error) {
+	base, rest := redacted(e.base, append(e.Annotations(), extra...))
+	p := project.Project{Version: project.Version, Annotations: rest}
+	return p.RenderOn(base)
+}
+
+// Shape returns the annotation drawn by dragging tool along path, in image
+// coordinates. It reports false for text, which is made with Text, and for
+// drags too short to see.
+func Shape(tool Tool, path []image.Point, style Style) (project.Annotation, bool) {
+	if len(path) == 0 {
+		return project.Annotation{}, false
+	}
+	from, to := path[0], path[len(path)-1]
+	a := project.Annotation{
+		X:           float64(from.X),
+		Y:           float64(from.Y),
+		Stroke:      style.Color,
+		StrokeWidth: style.Width,
+	}
+
+	switch tool {
+	case ToolArrow:
+		d := to.Sub(from)
+		if d.X*d.X+d.Y*d.Y < minDrag*minDrag {
+			return project.Annotation{}, false
+		}
+		a.Type = "arrow"
+		a.Points = []float64{0, 0, float64(d.X), float64(d.Y)}
+	case ToolRectangle, ToolEllipse, ToolBlur, ToolPixelate:
+		r := image.Rectangle{Min: from, Max: to}.Canon()
+		if r.Dx() < minDrag || r.Dy() < minDrag {
+			return project.Annotation{}, false
+		}
+		switch tool {
+		case ToolRectangle:
+			a.Type = "rectangle"
+		case ToolEllipse:
+			a.Type = "ellipse"
+		case ToolBlur:
+			a.Type = typeBlur
+		case ToolPixelate:
+			a.Type = typePixelate
+		}
+		a.X, a.Y = float64(r.Min.X), float64(r.Min.Y)
+		a.Width, a.Height = float64(r.Dx()), float64(r.Dy())
+	case ToolPen, ToolHighlighter:
+		a.Type = "pen"
+		if tool == ToolHighlighter {
+			a.Type = "highlighter"
+			a.StrokeWidth *= highlighterScale
+		}
+		for _, p := range path {
+			a.Points = append(a.Points, float64(p.X-from.X), float64(p.Y-from.Y))
+		}
+	default:
+		return project.Annotation{}, false
+	}
+	return a, true
+}
+
+// Text returns a text label with its top-left corner at at.
+func Text(at image.Point, text string, style Style) project.Annotation {
+	return project.Annotation{
+		Type:     "text",
+		X:        float64(at.X),
+		Y:        float64(at.Y),
+		Stroke:   style.Color,
+		Text:     text,
+		FontSize: style.FontSize,
+	}
+}
@@ -0,0 +1,213 @@
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func whiteImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+func TestEditorUndoRedo(t *testing.T) {
+	e := NewEditor(whiteImage(10, 10))
+	if e.CanUndo() || e.Undo() {
+		t.Fatal("new editor has something to undo")
+	}
+
+	e.Add(Text(image.Pt(1, 1), "a", DefaultStyle))
+	e.Add(Text(image.Pt(2, 2), "b", DefaultStyle))
+	e.Clear()
+	if n := len(e.Annotations()); n != 0 {
+		t.Fatalf("after Clear, %d annotations, want 0", n)
+	}
+
+	if !e.Undo() || len(e.Annotations()) != 2 {
+		t.Fatalf("Undo of Clear left %d annotations, want 2", len(e.Annotations()))
+	}
+	e.Undo()
+	if got := e.Annotations(); len(got) != 1 || got[0].Text != "a" {
+		t.Fatalf("second Undo left %+v, want only a", got)
+	}
+	if !e.Redo() || len(e.Annotations()) != 2 {
+		t.Fatalf("Redo left %d annotations, want 2", len(e.Annotations()))
+	}
+
+	// A new change drops what was undone
+	e.Undo()
+	e.Add(Text(image.Pt(3, 3), "c", DefaultStyle))
+	if e.CanRedo() {
+		t.Error("CanRedo after a new change, want false")
+	}
+}
+
+func TestEditorUndoLimit(t *testing.T) {
+	e := NewEditor(whiteImage(10, 10))
+	for i := 0; i < maxUndo+10; i++ {
+		e.Add(Text(image.Pt(i, 0), "x", DefaultStyle))
+	}
+	n := 0
+	for e.Undo() {
+		n++
+	}
+	if n != maxUndo {
+		t.Errorf("undid %d changes, want %d", n, maxUndo)
+	}
+	if got := len(e.Annotations()); got != 10 {
+		t.Errorf("%d annotations left, want 10", got)
+	}
+}
+
+func TestShape(t *testing.T) {
+	style := Style{Color: "#000000", Width: 2}
+	tests := []struct {
+		name string
+		tool Tool
+		path []image.Point
+		want string // Annotation type, "" for none
+	}{
+		{"arrow", ToolArrow, []image.Point{{10, 10}, {40, 20}}, "arrow"},
+		{"short arrow", ToolArrow, []image.Point{{10, 10}, {11, 11}}, ""},
+		{"rectangle", ToolRectangle, []image.Point{{40, 30}, {10, 10}}, "rectangle"},
+		{"flat ellipse", ToolEllipse, []image.Point{{10, 10}, {40, 11}}, ""},
+		{"pen dot", ToolPen, []image.Point{{5, 5}}, "pen"},
+		{"highlighter", ToolHighlighter, []image.Point{{5, 5}, {20, 5}}, "highlighter"},
+		{"text", ToolText, []image.Point{{5, 5}, {20, 5}}, ""},
+		{"blur", ToolBlur, []image.Point{{5, 5}, {20, 20}}, "blur"},
+		{"pixelate", ToolPixelate, []image.Point{{5, 5}, {20, 20}}, "pixelate"},
+		{"short pixelate", ToolPixelate, []image.Point{{5, 5}, {6, 20}}, ""},
+	}
+	for _, tt := range tests {
+		a, ok := Shape(tt.tool, tt.path, style)
+		if ok != (tt.want != "") || ok && a.Type != tt.want {
+			t.Errorf("%s: Shape = %q, %v, want %q", tt.name, a.Type, ok, tt.want)
+		}
+	}
+
+	r, _ := Shape(ToolRectangle, []image.Point{{40, 30}, {10, 10}}, style)
+	if r.X != 10 || r.Y != 10 || r.Width != 30 || r.Height != 20 {
+		t.Errorf("rectangle dragged up-left = %v,%v %vx%v, want 10,10 30x20", r.X, r.Y, r.Width, r.Height)
+	}
+	h, _ := Shape(ToolHighlighter, []image.Point{{5, 5}, {20, 5}}, style)
+	if h.StrokeWidth != style.Width*highlighterScale {
+		t.Errorf("highlighter width = %v, want %v", h.StrokeWidth, style.Width*highlighterScale)
+	}
+}
+
+func TestEditorRender(t *testing.T) {
+	e := NewEditor(whiteImage(40, 40))
+	a, _ := Shape(ToolPen, []image.Point{{5, 20}, {35, 20}}, Style{Color: "#0000ff", Width: 4})
+	e.Add(a)
+
+	img, err := e.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.RGBAAt(20, 20); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("stroke pixel = %v, want blue", got)
+	}
+	if got := e.Image().RGBAAt(20, 20); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("Render changed the capture: pixel = %v", got)
+	}
+
+	// Extra annotations are drawn without being added
+	b, _ := Shape(ToolRectangle, []image.Point{{0, 0}, {10, 10}}, Style{Color: "#ff0000", Width: 2})
+	if _, err := e.Render(b); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.Annotations()); n != 1 {
+		t.Errorf("%d annotations after Render with extra, want 1", n)
+	}
+}
+
+// stripes returns black and white columns, a stand-in for text.
+func stripes(w, h int) *image.RGBA {
+	img := whiteImage(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x += 2 {
+			img.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+		}
+	}
+	return img
+}
+
+func TestRedact(t *testing.T) {
+	for _, mode := range []RedactMode{RedactBlur, RedactPixelate} {
+		img := stripes(40, 40)
+		Redact(img, []image.Rectangle{image.Rect(8, 8, 32, 32)}, mode)
+
+		a, b := img.RGBAAt(19, 20), img.RGBAAt(20, 20)
+		if diff := int(a.R) - int(b.R); diff > 8 || diff < -8 {
+			t.Errorf("%v: neighbouring redacted pixels %v and %v still differ", mode, a, b)
+		}
+		if got := img.RGBAAt(2, 2); got != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("%v: pixel outside the region = %v, want it untouched", mode, got)
+		}
+	}
+}
+
+func TestParseRedactMode(t *testing.T) {
+	for _, mode := range []RedactMode{RedactBlur, RedactPixelate} {
+		if got, err := ParseRedactMode(mode.String()); err != nil || got != mode {
+			t.Errorf("ParseRedactMode(%q) = %v, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseRedactMode("smudge"); err == nil {
+		t.Error("ParseRedactMode(smudge) succeeded")
+	}
+}
+
+func TestEditorRedaction(t *testing.T) {
+	e := NewEditor(stripes(40, 40))
+	pen, _ := Shape(ToolPen, []image.Point{{0, 20}, {39, 20}}, Style{Color: "#0000ff", Width: 2})
+	blur, _ := Shape(ToolBlur, []image.Point{{0, 0}, {40, 40}}, DefaultStyle)
+	e.Add(pen)
+	e.Add(blur)
+
+	img, err := e.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The blur hides the capture but not the stroke drawn before it
+	if got := img.RGBAAt(10, 20); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("stroke pixel = %v, want blue", got)
+	}
+	if a, b := img.RGBAAt(10, 5), img.RGBAAt(11, 5); a == (color.RGBA{0, 0, 0, 255}) && b == (color.RGBA{255, 255, 255, 255}) {
+		t.Error("capture under the blur is unchanged")
+	}
+	if got := e.Image().RGBAAt(10, 5); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("Render changed the capture: pixel = %v", got)
+	}
+}
+
+func TestReviewLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      image.Point
+		wantShown image.Point
+	}{
+		{"large capture is scaled to half the work area", image.Pt(3840, 1080), image.Pt(960, 270)},
+		{"small capture is not enlarged", image.Pt(400, 300), image.Pt(400, 300)},
+		{"narrow capture keeps room for the buttons", image.Pt(40, 30), image.Pt(40, 30)},
+	}
+	for _, tt := range tests {
+		client, preview, buttons := reviewLayout(tt.size, image.Pt(1920, 1040))
+		if preview.Size() != tt.wantShown {
+			t.Errorf("%s: preview = %v, want size %v", tt.name, preview, tt.wantShown)
+		}
+		area := image.Rectangle{Max: client}
+		if !preview.In(area) || preview.Min.X-area.Min.X != area.Max.X-preview.Max.X {
+			t.Errorf("%s: preview %v not centered in %v", tt.name, preview, area)
+		}
+		for i, b := range buttons {
+			if !b.In(area) || b.Overlaps(preview) || (i > 0 && b.Overlaps(buttons[i-1])) {
+				t.Errorf("%s: button %d at %v in %v overlaps or is outside", tt.name, i, b, area)
+			}
+		}
+	}
+}
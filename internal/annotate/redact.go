@@ -0,0 +1,98 @@
+package annotate
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+
+	"winshot/internal/imaging"
+	"winshot/internal/project"
+)
+
+// RedactMode is how Redact hides a region.
+type RedactMode int
+
+// Redaction modes.
+const (
+	RedactBlur     RedactMode = iota // Gaussian-like blur
+	RedactPixelate                   // Flat blocks of the average color
+)
+
+// String returns the mode's name, as accepted by ParseRedactMode.
+func (m RedactMode) String() string {
+	switch m {
+	case RedactBlur:
+		return "blur"
+	case RedactPixelate:
+		return "pixelate"
+	}
+	return "unknown"
+}
+
+// ParseRedactMode parses "blur" or "pixelate". The empty string is blur.
+func ParseRedactMode(s string) (RedactMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "blur":
+		return RedactBlur, nil
+	case "pixelate":
+		return RedactPixelate, nil
+	}
+	return 0, fmt.Errorf("unknown redaction mode %q, want blur or pixelate", s)
+}
+
+// Redact hides rects of img in place so their contents cannot be read.
+// Rectangles are in img's coordinates and clipped to its bounds. Each one is
+// redacted from its own pixels only, so nothing outside it bleeds in or out.
+func Redact(img *image.RGBA, rects []image.Rectangle, mode RedactMode) {
+	for _, r := range rects {
+		switch mode {
+		case RedactPixelate:
+			imaging.PixelateRect(img, r)
+		default:
+			imaging.BlurRect(img, r)
+		}
+	}
+}
+
+// Annotation types of redactions in the editor. They are not part of the
+// project format: the editor applies them to the capture itself.
+const (
+	typeBlur     = "blur"
+	typePixelate = "pixelate"
+)
+
+// redactMode returns the mode of a redaction annotation.
+func redactMode(a project.Annotation) (RedactMode, bool) {
+	switch a.Type {
+	case typeBlur:
+		return RedactBlur, true
+	case typePixelate:
+		return RedactPixelate, true
+	}
+	return 0, false
+}
+
+// redacted returns a copy of img with the redactions among annotations
+// applied, and the annotations left to draw on top.
+func redacted(img *image.RGBA, annotations []project.Annotation) (*image.RGBA, []project.Annotation) {
+	var rest []project.Annotation
+	var out *image.RGBA
+	for _, a := range annotations {
+		mode, ok := redactMode(a)
+		if !ok {
+			rest = append(rest, a)
+			continue
+		}
+		if out == nil {
+			out = image.NewRGBA(img.Bounds())
+			draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+		}
+		r := image.Rect(int(a.X), int(a.Y), int(a.X+a.Width), int(a.Y+a.Height)).Add(img.Bounds().Min)
+		Redact(out, []image.Rectangle{r}, mode)
+	}
+	if out == nil {
+		return img, rest
+	}
+	return out, rest
+}
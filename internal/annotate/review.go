@@ -0,0 +1,65 @@
+package annotate
+
+import "image"
+
+// Decision is the outcome of reviewing a capture before it is uploaded.
+type Decision int
+
+// Review decisions.
+const (
+	DecisionCancel  Decision = iota // Don't upload
+	DecisionApprove                 // Upload as shown
+	DecisionRedact                  // Open the editor to hide parts first
+)
+
+// String returns the decision's name.
+func (d Decision) String() string {
+	switch d {
+	case DecisionCancel:
+		return "cancel"
+	case DecisionApprove:
+		return "approve"
+	case DecisionRedact:
+		return "redact"
+	}
+	return "unknown"
+}
+
+// Review window layout, in screen pixels.
+const (
+	reviewMaxFill = 0.5 // Largest share of the work area the preview covers
+	reviewMargin  = 12
+	reviewButtonW = 96
+	reviewButtonH = 28
+	reviewGap     = 8
+)
+
+// reviewButtons is the number of buttons in the review window: Approve,
+// Redact and Cancel.
+const reviewButtons = 3
+
+// reviewLayout lays out the review window for a capture of the given size on
+// a work area: the preview, scaled to fit reviewMaxFill of the work area and
+// centered, and the buttons right-aligned below it. It returns the client
+// size, the preview and the buttons in Approve, Redact, Cancel order.
+func reviewLayout(size, work image.Point) (client image.Point, preview image.Rectangle, buttons [reviewButtons]image.Rectangle) {
+	scale := 1.0
+	if size.X > 0 && size.Y > 0 && work.X > 0 && work.Y > 0 {
+		scale = min(scale, float64(work.X)*reviewMaxFill/float64(size.X))
+		scale = min(scale, float64(work.Y)*reviewMaxFill/float64(size.Y))
+	}
+	shown := image.Pt(max(int(float64(size.X)*scale), 1), max(int(float64(size.Y)*scale), 1))
+
+	rowW := reviewButtons*reviewButtonW + (reviewButtons-1)*reviewGap
+	client.X = max(shown.X, rowW) + 2*reviewMargin
+	client.Y = shown.Y + reviewButtonH + 3*reviewMargin
+
+	preview = image.Rectangle{Max: shown}.Add(image.Pt((client.X-shown.X)/2, reviewMargin))
+	x := client.X - reviewMargin - rowW
+	y := preview.Max.Y + reviewMargin
+	for i := range buttons {
+		buttons[i] = image.Rect(x, y, x+reviewButtonW, y+reviewButtonH)
+		x += reviewButtonW + reviewGap
+	}
+	return client, preview, buttons
+}
@@ -0,0 +1,253 @@
+package annotate
+
+import (
+	"errors"
+	"image"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procSendMessageW   = user32.NewProc("SendMessageW")
+	procGetStockObject = gdi32.NewProc("GetStockObject")
+)
+
+const (
+	wmSetFont = 0x0030
+	wmCommand = 0x0111
+
+	wsChild         = 0x40000000
+	wsVisible       = 0x10000000
+	wsTabStop       = 0x00010000
+	wsExTopmost     = 0x00000008
+	bsPushButton    = 0x00000000
+	bsDefPushButton = 0x00000001
+	bnClicked       = 0
+	colorBtnFace    = 15
+	defaultGUIFont  = 17
+	idcArrow        = 32512
+)
+
+// Button IDs are the decisions they make, offset so none is zero.
+const reviewButtonID = 100
+
+// ErrReviewBusy is returned by Review while another review window is open.
+var ErrReviewBusy = errors.New("a review window is already open")
+
+// reviewWindow is the state of the open review window. It is only touched on
+// the thread running its message loop.
+type reviewWindow struct {
+	hwnd     uintptr
+	img      *image.RGBA
+	title    string
+	preview  image.Rectangle
+	decision Decision
+}
+
+var (
+	reviewProcCallback = syscall.NewCallback(reviewProc)
+	reviewing          *reviewWindow
+	reviewingMu        sync.Mutex
+)
+
+// Review shows img in a small window titled title, above other windows, and
+// blocks until one of its buttons is chosen. Enter approves, R redacts and
+// Esc or closing the window cancels.
+func Review(img *image.RGBA, title string) (Decision, error) {
+	reviewingMu.Lock()
+	if reviewing != nil {
+		reviewingMu.Unlock()
+		return DecisionCancel, ErrReviewBusy
+	}
+	w := &reviewWindow{img: img, title: title}
+	reviewing = w
+	reviewingMu.Unlock()
+	defer func() {
+		reviewingMu.Lock()
+		reviewing = nil
+		reviewingMu.Unlock()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		// Window messages are delivered to the creating thread
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		errCh <- w.run()
+	}()
+	if err := <-errCh; err != nil {
+		return DecisionCancel, err
+	}
+	return w.decision, nil
+}
+
+// run creates the window and its buttons and pumps messages until it is
+// destroyed.
+func (w *reviewWindow) run() error {
+	hInstance, _, _ := procGetModuleHandleW.Call(0)
+	className, _ := syscall.UTF16PtrFromString("WinShotReview")
+	cursor, _, _ := procLoadCursorW.Call(0, idcArrow)
+
+	wc := wndClassEx{
+		wndProc:    reviewProcCallback,
+		instance:   hInstance,
+		cursor:     cursor,
+		background: colorBtnFace + 1,
+		className:  className,
+	}
+	wc.size = uint32(unsafe.Sizeof(wc))
+	if ret, _, _ := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); ret == 0 {
+		return errors.New("failed to register review window class")
+	}
+	defer procUnregisterClassW.Call(uintptr(unsafe.Pointer(className)), hInstance)
+
+	var work rect
+	procSystemParametersInfo.Call(spiGetWorkArea, 0, uintptr(unsafe.Pointer(&work)), 0)
+	size, preview, buttons := reviewLayout(w.img.Bounds().Size(), image.Pt(int(work.right-work.left), int(work.bottom-work.top)))
+	w.preview = preview
+
+	client := rect{right: int32(size.X), bottom: int32(size.Y)}
+	procAdjustWindowRectEx.Call(uintptr(unsafe.Pointer(&client)), windowStyle, 0, wsExTopmost)
+
+	title, _ := syscall.UTF16PtrFromString(w.title)
+	hwnd, _, _ := procCreateWindowExW.Call(
+		wsExTopmost,
+		uintptr(unsafe.Pointer(className)),
+		uintptr(unsafe.Pointer(title)),
+		windowStyle,
+		cwUseDefault, cwUseDefault,
+		uintptr(client.right-client.left), uintptr(client.bottom-client.top),
+		0, 0, hInstance, 0,
+	)
+	if hwnd == 0 {
+		return errors.New("failed to create review window")
+	}
+	w.hwnd = hwnd
+
+	buttonClass, _ := syscall.UTF16PtrFromString("BUTTON")
+	font, _, _ := procGetStockObject.Call(defaultGUIFont)
+	labels := [reviewButtons]string{"&Approve", "&Redact", "Cancel"}
+	decisions := [reviewButtons]Decision{DecisionApprove, DecisionRedact, DecisionCancel}
+	for i, r := range buttons {
+		style := uintptr(wsChild | wsVisible | wsTabStop | bsPushButton)
+		if decisions[i] == DecisionApprove {
+			style |= bsDefPushButton
+		}
+		label, _ := syscall.UTF16PtrFromString(labels[i])
+		button, _, _ := procCreateWindowExW.Call(
+			0,
+			uintptr(unsafe.Pointer(buttonClass)),
+			uintptr(unsafe.Pointer(label)),
+			style,
+			uintptr(r.Min.X), uintptr(r.Min.Y), uintptr(r.Dx()), uintptr(r.Dy()),
+			hwnd, uintptr(reviewButtonID+decisions[i]), hInstance, 0,
+		)
+		procSendMessageW.Call(button, wmSetFont, font, 0)
+	}
+
+	procShowWindow.Call(hwnd, swShow)
+	procSetForegroundWindow.Call(hwnd)
+
+	var m msg
+	for {
+		ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			break
+		}
+		// The buttons take the keyboard focus, so keys are handled here
+		// before they reach them
+		if m.message == wmKeyDown && w.keyDown(m.wParam) {
+			continue
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+	return nil
+}
+
+// keyDown decides with Enter, R and Esc. It reports whether the key was
+// handled.
+func (w *reviewWindow) keyDown(key uintptr) bool {
+	switch key {
+	case vkReturn, 'A':
+		w.decide(DecisionApprove)
+	case 'R':
+		w.decide(DecisionRedact)
+	case vkEscape:
+		w.decide(DecisionCancel)
+	default:
+		return false
+	}
+	return true
+}
+
+// decide closes the window with d.
+func (w *reviewWindow) decide(d Decision) {
+	w.decision = d
+	procDestroyWindow.Call(w.hwnd)
+}
+
+func reviewProc(hwnd, message, wParam, lParam uintptr) uintptr {
+	w := reviewing
+	if w == nil || w.hwnd != hwnd {
+		ret, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+		return ret
+	}
+
+	switch message {
+	case wmPaint:
+		w.paint()
+		return 0
+
+	case wmCommand:
+		id, code := wParam&0xFFFF, (wParam>>16)&0xFFFF
+		if code == bnClicked && id >= reviewButtonID && id < reviewButtonID+reviewButtons {
+			w.decide(Decision(id - reviewButtonID))
+		}
+		return 0
+
+	case wmClose:
+		w.decide(DecisionCancel)
+		return 0
+
+	case wmDestroy:
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+
+	ret, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+	return ret
+}
+
+// paint draws the capture scaled into the preview area.
+func (w *reviewWindow) paint() {
+	var ps paintStruct
+	hdc, _, _ := procBeginPaint.Call(w.hwnd, uintptr(unsafe.Pointer(&ps)))
+	defer procEndPaint.Call(w.hwnd, uintptr(unsafe.Pointer(&ps)))
+
+	size := w.img.Bounds().Size()
+	if size.X == 0 || size.Y == 0 {
+		return
+	}
+	bgra := toBGRA(w.img)
+	bi := bitmapInfoHeader{
+		width:    int32(size.X),
+		height:   -int32(size.Y), // Top-down
+		planes:   1,
+		bitCount: 32,
+	}
+	bi.size = uint32(unsafe.Sizeof(bi))
+
+	procSetStretchBltMode.Call(hdc, halftone)
+	procStretchDIBits.Call(
+		hdc,
+		uintptr(w.preview.Min.X), uintptr(w.preview.Min.Y), uintptr(w.preview.Dx()), uintptr(w.preview.Dy()),
+		0, 0, uintptr(size.X), uintptr(size.Y),
+		uintptr(unsafe.Pointer(&bgra[0])),
+		uintptr(unsafe.Pointer(&bi)),
+		dibRGBColors,
+		srcCopy,
+	)
+}
@@ -0,0 +1,538 @@
+package annotate
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"winshot/internal/project"
+)
+
+var (
+	user32   = windows.NewLazySystemDLL("user32.dll")
+	gdi32    = windows.NewLazySystemDLL("gdi32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procRegisterClassExW     = user32.NewProc("RegisterClassExW")
+	procUnregisterClassW     = user32.NewProc("UnregisterClassW")
+	procCreateWindowExW      = user32.NewProc("CreateWindowExW")
+	procDestroyWindow        = user32.NewProc("DestroyWindow")
+	procDefWindowProcW       = user32.NewProc("DefWindowProcW")
+	procGetMessageW          = user32.NewProc("GetMessageW")
+	procTranslateMessage     = user32.NewProc("TranslateMessage")
+	procDispatchMessageW     = user32.NewProc("DispatchMessageW")
+	procPostQuitMessage      = user32.NewProc("PostQuitMessage")
+	procShowWindow           = user32.NewProc("ShowWindow")
+	procSetForegroundWindow  = user32.NewProc("SetForegroundWindow")
+	procInvalidateRect       = user32.NewProc("InvalidateRect")
+	procBeginPaint           = user32.NewProc("BeginPaint")
+	procEndPaint             = user32.NewProc("EndPaint")
+	procGetKeyState          = user32.NewProc("GetKeyState")
+	procSetCapture           = user32.NewProc("SetCapture")
+	procReleaseCapture       = user32.NewProc("ReleaseCapture")
+	procLoadCursorW          = user32.NewProc("LoadCursorW")
+	procAdjustWindowRectEx   = user32.NewProc("AdjustWindowRectEx")
+	procSystemParametersInfo = user32.NewProc("SystemParametersInfoW")
+	procSetWindowTextW       = user32.NewProc("SetWindowTextW")
+	procStretchDIBits        = gdi32.NewProc("StretchDIBits")
+	procSetStretchBltMode    = gdi32.NewProc("SetStretchBltMode")
+	procGetModuleHandleW     = kernel32.NewProc("GetModuleHandleW")
+)
+
+const (
+	wmDestroy     = 0x0002
+	wmPaint       = 0x000F
+	wmClose       = 0x0010
+	wmKeyDown     = 0x0100
+	wmChar        = 0x0102
+	wmMouseMove   = 0x0200
+	wmLButtonDown = 0x0201
+	wmLButtonUp   = 0x0202
+
+	wsOverlapped   = 0x00000000
+	wsCaption      = 0x00C00000
+	wsSysMenu      = 0x00080000
+	wsMinimizeBox  = 0x00020000
+	cwUseDefault   = 0x80000000
+	swShow         = 5
+	spiGetWorkArea = 0x0030
+	idcCross       = 32515
+	halftone       = 4
+	dibRGBColors   = 0
+	srcCopy        = 0x00CC0020
+
+	vkBack    = 0x08
+	vkReturn  = 0x0D
+	vkShift   = 0x10
+	vkControl = 0x11
+	vkEscape  = 0x1B
+)
+
+// windowStyle is a fixed-size window with a title bar and close button.
+const windowStyle = wsOverlapped | wsCaption | wsSysMenu | wsMinimizeBox
+
+// workAreaFill is how much of the work area a large capture may cover.
+const workAreaFill = 0.9
+
+// palette is picked with the number keys 1 to 6.
+var palette = []string{"#ff3b30", "#ff9500", "#ffcc00", "#34c759", "#007aff", "#000000"}
+
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   uintptr
+	icon       uintptr
+	cursor     uintptr
+	background uintptr
+	menuName   *uint16
+	className  *uint16
+	iconSm     uintptr
+}
+
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+type rect struct {
+	left, top, right, bottom int32
+}
+
+type paintStruct struct {
+	hdc       uintptr
+	erase     int32
+	paint     rect
+	restore   int32
+	incUpdate int32
+	reserved  [32]byte
+}
+
+type bitmapInfoHeader struct {
+	size          uint32
+	width         int32
+	height        int32
+	planes        uint16
+	bitCount      uint16
+	compression   uint32
+	sizeImage     uint32
+	xPelsPerMeter int32
+	yPelsPerMeter int32
+	clrUsed       uint32
+	clrImportant  uint32
+}
+
+// ErrBusy is returned by Edit while another editor window is open.
+var ErrBusy = errors.New("an annotation editor is already open")
+
+// window is the state of the open editor. It is only touched on the thread
+// running its message loop.
+type window struct {
+	hwnd     uintptr
+	editor   *Editor
+	tool     Tool
+	style    Style
+	scale    float64       // Screen pixels per image pixel
+	path     []image.Point // Drag in progress, image coordinates
+	dragging bool
+	textAt   image.Point
+	text     []rune // Label being typed, shown until committed
+	typing   bool
+	view     *image.RGBA // Rendered annotations, nil when stale
+	done     bool        // Closed with Enter rather than cancelled
+	err      error
+}
+
+var (
+	wndProcCallback = syscall.NewCallback(wndProc)
+	active          *window
+	activeMu        sync.Mutex
+)
+
+// Edit opens the editor window on img and blocks until it is closed. Enter
+// returns the capture with the annotations drawn on it, Esc or closing the
+// window returns nil. Keys pick the tool: A arrow, R rectangle, E ellipse,
+// P pen, H highlighter, T text, B blur and X pixelate; 1 to 6 pick the
+// color, Ctrl+Z undoes and Ctrl+Y redoes.
+func Edit(img *image.RGBA) (*image.RGBA, error) {
+	return EditWith(img, ToolArrow)
+}
+
+// EditWith is Edit starting with tool picked, such as ToolBlur to redact.
+func EditWith(img *image.RGBA, tool Tool) (*image.RGBA, error) {
+	activeMu.Lock()
+	if active != nil {
+		activeMu.Unlock()
+		return nil, ErrBusy
+	}
+	w := &window{editor: NewEditor(img), tool: tool, style: DefaultStyle}
+	active = w
+	activeMu.Unlock()
+	defer func() {
+		activeMu.Lock()
+		active = nil
+		activeMu.Unlock()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		// Window messages are delivered to the creating thread
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		errCh <- w.run()
+	}()
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	if !w.done {
+		return nil, nil
+	}
+	return w.editor.Render()
+}
+
+// run creates the window and pumps messages until it is destroyed.
+func (w *window) run() error {
+	hInstance, _, _ := procGetModuleHandleW.Call(0)
+	className, _ := syscall.UTF16PtrFromString("WinShotAnnotate")
+	cursor, _, _ := procLoadCursorW.Call(0, idcCross)
+
+	wc := wndClassEx{
+		wndProc:   wndProcCallback,
+		instance:  hInstance,
+		cursor:    cursor,
+		className: className,
+	}
+	wc.size = uint32(unsafe.Sizeof(wc))
+	if ret, _, _ := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); ret == 0 {
+		return errors.New("failed to register annotation window class")
+	}
+	defer procUnregisterClassW.Call(uintptr(unsafe.Pointer(className)), hInstance)
+
+	size := w.editor.Image().Bounds().Size()
+	var work rect
+	procSystemParametersInfo.Call(spiGetWorkArea, 0, uintptr(unsafe.Pointer(&work)), 0)
+	w.scale = fitScale(size, image.Pt(int(work.right-work.left), int(work.bottom-work.top)))
+
+	client := rect{right: int32(float64(size.X) * w.scale), bottom: int32(float64(size.Y) * w.scale)}
+	procAdjustWindowRectEx.Call(uintptr(unsafe.Pointer(&client)), windowStyle, 0, 0)
+
+	hwnd, _, _ := procCreateWindowExW.Call(
+		0,
+		uintptr(unsafe.Pointer(className)),
+		0,
+		windowStyle,
+		cwUseDefault, cwUseDefault,
+		uintptr(client.right-client.left), uintptr(client.bottom-client.top),
+		0, 0, hInstance, 0,
+	)
+	if hwnd == 0 {
+		return errors.New("failed to create annotation window")
+	}
+	w.hwnd = hwnd
+	w.updateTitle()
+	procShowWindow.Call(hwnd, swShow)
+	procSetForegroundWindow.Call(hwnd)
+
+	var m msg
+	for {
+		ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			break
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+	return w.err
+}
+
+// fitScale returns the scale that fits an image of the given size in
+// workAreaFill of the work area, never enlarging it.
+func fitScale(size, work image.Point) float64 {
+	scale := 1.0
+	if size.X <= 0 || size.Y <= 0 || work.X <= 0 || work.Y <= 0 {
+		return scale
+	}
+	scale = min(scale, float64(work.X)*workAreaFill/float64(size.X))
+	scale = min(scale, float64(work.Y)*workAreaFill/float64(size.Y))
+	return scale
+}
+
+func wndProc(hwnd, message, wParam, lParam uintptr) uintptr {
+	w := active
+	if w == nil || w.hwnd != hwnd {
+		ret, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+		return ret
+	}
+
+	switch message {
+	case wmPaint:
+		w.paint()
+		return 0
+
+	case wmLButtonDown:
+		p := w.imagePoint(lParam)
+		if w.tool == ToolText {
+			w.commitText()
+			w.textAt, w.text, w.typing = p, nil, true
+		} else {
+			w.path, w.dragging = []image.Point{p}, true
+			procSetCapture.Call(hwnd)
+		}
+		w.redraw(false)
+		return 0
+
+	case wmMouseMove:
+		if w.dragging {
+			p := w.imagePoint(lParam)
+			if w.tool == ToolPen || w.tool == ToolHighlighter {
+				w.path = append(w.path, p)
+			} else {
+				w.path = append(w.path[:1], p)
+			}
+			w.redraw(false)
+		}
+		return 0
+
+	case wmLButtonUp:
+		if w.dragging {
+			procReleaseCapture.Call()
+			w.dragging = false
+			if a, ok := Shape(w.tool, w.path, w.style); ok {
+				w.editor.Add(a)
+			}
+			w.path = nil
+			w.redraw(true)
+		}
+		return 0
+
+	case wmChar:
+		if w.typing {
+			switch r := rune(wParam); {
+			case r == vkBack:
+				if len(w.text) > 0 {
+					w.text = w.text[:len(w.text)-1]
+				}
+			case r >= ' ' && !utf16.IsSurrogate(r):
+				w.text = append(w.text, r)
+			}
+			w.redraw(false)
+		}
+		return 0
+
+	case wmKeyDown:
+		w.keyDown(wParam)
+		return 0
+
+	case wmClose:
+		procDestroyWindow.Call(hwnd)
+		return 0
+
+	case wmDestroy:
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+
+	ret, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+	return ret
+}
+
+// keyDown handles tool, color, undo and closing keys. Letters go to the
+// label instead while typing one.
+func (w *window) keyDown(key uintptr) {
+	ctrl := keyDown(vkControl)
+	switch {
+	case key == vkEscape:
+		if w.typing {
+			w.typing, w.text = false, nil
+			w.redraw(false)
+			return
+		}
+		procDestroyWindow.Call(w.hwnd)
+	case key == vkReturn:
+		if w.typing && keyDown(vkShift) {
+			w.text = append(w.text, '\n')
+			w.redraw(false)
+			return
+		}
+		if w.typing {
+			w.commitText()
+			w.redraw(true)
+			return
+		}
+		w.done = true
+		procDestroyWindow.Call(w.hwnd)
+	case ctrl && key == 'Z':
+		w.commitText()
+		if w.editor.Undo() {
+			w.redraw(true)
+		}
+	case ctrl && key == 'Y':
+		if w.editor.Redo() {
+			w.redraw(true)
+		}
+	case w.typing:
+		// Letters and digits are typed into the label
+	case key >= '1' && int(key-'1') < len(palette):
+		w.style.Color = palette[key-'1']
+	default:
+		if tool, ok := toolKeys[rune(key)]; ok {
+			w.tool = tool
+		}
+	}
+	w.updateTitle()
+}
+
+// toolKeys picks tools by virtual-key code, which is the uppercase letter.
+var toolKeys = map[rune]Tool{
+	'A': ToolArrow,
+	'R': ToolRectangle,
+	'E': ToolEllipse,
+	'P': ToolPen,
+	'H': ToolHighlighter,
+	'T': ToolText,
+	'B': ToolBlur,
+	'X': ToolPixelate,
+}
+
+// commitText adds the label being typed, if it has any text.
+func (w *window) commitText() {
+	if w.typing && len(w.text) > 0 {
+		w.editor.Add(Text(w.textAt, string(w.text), w.style))
+	}
+	w.typing, w.text = false, nil
+}
+
+// pending returns the shape being dragged or the label being typed.
+func (w *window) pending() []project.Annotation {
+	switch {
+	case w.dragging:
+		if a, ok := Shape(w.tool, w.path, w.style); ok {
+			return []project.Annotation{a}
+		}
+	case w.typing:
+		text := string(w.text) + "|" // Caret
+		return []project.Annotation{Text(w.textAt, text, w.style)}
+	}
+	return nil
+}
+
+// redraw repaints the window. stale drops the cached rendering after the
+// annotations changed.
+func (w *window) redraw(stale bool) {
+	if stale {
+		w.view = nil
+	}
+	w.updateTitle()
+	procInvalidateRect.Call(w.hwnd, 0, 0)
+}
+
+// paint draws the capture with its annotations, scaled to the window.
+func (w *window) paint() {
+	var ps paintStruct
+	hdc, _, _ := procBeginPaint.Call(w.hwnd, uintptr(unsafe.Pointer(&ps)))
+	defer procEndPaint.Call(w.hwnd, uintptr(unsafe.Pointer(&ps)))
+
+	if w.view == nil {
+		view, err := w.editor.Render()
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		w.view = view
+	}
+	img := w.view
+	if extra := w.pending(); len(extra) > 0 {
+		var out *image.RGBA
+		var err error
+		if w.tool == ToolBlur || w.tool == ToolPixelate {
+			// Redactions go beneath the other annotations, so redraw them all
+			out, err = w.editor.Render(extra...)
+		} else {
+			p := project.Project{Version: project.Version, Annotations: extra}
+			out, err = p.RenderOn(w.view)
+		}
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		img = out
+	}
+
+	size := img.Bounds().Size()
+	if size.X == 0 || size.Y == 0 {
+		return
+	}
+	bgra := toBGRA(img)
+	bi := bitmapInfoHeader{
+		width:    int32(size.X),
+		height:   -int32(size.Y), // Top-down
+		planes:   1,
+		bitCount: 32,
+	}
+	bi.size = uint32(unsafe.Sizeof(bi))
+
+	procSetStretchBltMode.Call(hdc, halftone)
+	procStretchDIBits.Call(
+		hdc,
+		0, 0, uintptr(float64(size.X)*w.scale), uintptr(float64(size.Y)*w.scale),
+		0, 0, uintptr(size.X), uintptr(size.Y),
+		uintptr(unsafe.Pointer(&bgra[0])),
+		uintptr(unsafe.Pointer(&bi)),
+		dibRGBColors,
+		srcCopy,
+	)
+}
+
+// fail closes the editor with err.
+func (w *window) fail(err error) {
+	w.err = fmt.Errorf("annotation editor: %w", err)
+	procDestroyWindow.Call(w.hwnd)
+}
+
+// imagePoint converts the client coordinates in lParam to image pixels.
+func (w *window) imagePoint(lParam uintptr) image.Point {
+	x := int(int16(lParam & 0xFFFF))
+	y := int(int16((lParam >> 16) & 0xFFFF))
+	return image.Pt(int(float64(x)/w.scale), int(float64(y)/w.scale))
+}
+
+// updateTitle shows the tool and the keys in the title bar.
+func (w *window) updateTitle() {
+	title := fmt.Sprintf("WinShot Annotate - %s  (A R E P H T B X tools, 1-6 colors, Ctrl+Z undo, Ctrl+Y redo, Enter done, Esc cancel)", w.tool)
+	if w.typing {
+		title = "WinShot Annotate - Text  (Enter add, Shift+Enter new line, Esc discard)"
+	}
+	ptr, _ := syscall.UTF16PtrFromString(title)
+	procSetWindowTextW.Call(w.hwnd, uintptr(unsafe.Pointer(ptr)))
+}
+
+func keyDown(vk uintptr) bool {
+	state, _, _ := procGetKeyState.Call(vk)
+	return state&0x8000 != 0
+}
+
+// toBGRA converts img to the BGRA byte order of 32-bit DIBs.
+func toBGRA(img *image.RGBA) []byte {
+	size := img.Bounds().Size()
+	out := make([]byte, size.X*size.Y*4)
+	for y := 0; y < size.Y; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+size.X*4]
+		dst := out[y*size.X*4:]
+		for i := 0; i < len(row); i += 4 {
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = row[i+2], row[i+1], row[i], 255
+		}
+	}
+	return out
+}
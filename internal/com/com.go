@@ -0,0 +1,135 @@
+// Package com calls COM and Windows Runtime interfaces through their
+// vtables, for the few system APIs that have no flat C export.
+package com
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	combase = windows.NewLazySystemDLL("combase.dll")
+
+	procRoGetActivationFactory    = combase.NewProc("RoGetActivationFactory")
+	procWindowsCreateString       = combase.NewProc("WindowsCreateString")
+	procWindowsDeleteString       = combase.NewProc("WindowsDeleteString")
+	procWindowsGetStringRawBuffer = combase.NewProc("WindowsGetStringRawBuffer")
+)
+
+var iidIAsyncInfo = windows.GUID{Data1: 0x00000036, Data2: 0x0000, Data3: 0x0000, Data4: [8]byte{0xc0, 0, 0, 0, 0, 0, 0, 0x46}}
+
+const (
+	// AsyncStatus values; Canceled and Error follow
+	asyncStarted   = 0
+	asyncCompleted = 1
+)
+
+// COM vtable slots of the interfaces used here. WinRT interfaces start after
+// the 6 IInspectable methods
+const (
+	vtblQueryInterface = 0
+	vtblRelease        = 2
+
+	vtblAsyncInfoStatus    = 7 // IAsyncInfo.get_Status
+	vtblAsyncInfoErrorCode = 8 // IAsyncInfo.get_ErrorCode
+)
+
+// Failed reports whether an HRESULT is an error
+func Failed(hr uintptr) bool {
+	return int32(hr) < 0
+}
+
+// Call calls method slot of a COM interface with the given arguments and
+// returns its HRESULT
+func Call(obj uintptr, slot int, args ...uintptr) uintptr {
+	vtbl := *(*uintptr)(unsafe.Pointer(obj))
+	method := *(*uintptr)(unsafe.Pointer(vtbl + uintptr(slot)*unsafe.Sizeof(uintptr(0))))
+	hr, _, _ := syscall.SyscallN(method, append([]uintptr{obj}, args...)...)
+	return hr
+}
+
+// Release releases a COM interface
+func Release(obj uintptr) {
+	Call(obj, vtblRelease)
+}
+
+// NewHString creates a WinRT string, released with DeleteHString
+func NewHString(s string) (uintptr, error) {
+	u, err := windows.UTF16FromString(s)
+	if err != nil {
+		return 0, err
+	}
+	var h uintptr
+	if hr, _, _ := procWindowsCreateString.Call(uintptr(unsafe.Pointer(&u[0])), uintptr(len(u)-1), uintptr(unsafe.Pointer(&h))); Failed(hr) {
+		return 0, fmt.Errorf("failed to create string: 0x%08x", uint32(hr))
+	}
+	return h, nil
+}
+
+// DeleteHString releases a WinRT string
+func DeleteHString(h uintptr) {
+	procWindowsDeleteString.Call(h)
+}
+
+// HStringText copies a WinRT string. A null HSTRING is the empty string
+func HStringText(h uintptr) string {
+	if h == 0 {
+		return ""
+	}
+	var n uint32
+	p, _, _ := procWindowsGetStringRawBuffer.Call(h, uintptr(unsafe.Pointer(&n)))
+	if p == 0 || n == 0 {
+		return ""
+	}
+	return windows.UTF16ToString(unsafe.Slice((*uint16)(unsafe.Pointer(p)), n))
+}
+
+// ActivationFactory returns the iid interface of a WinRT class factory
+func ActivationFactory(class string, iid *windows.GUID) (uintptr, error) {
+	name, err := NewHString(class)
+	if err != nil {
+		return 0, err
+	}
+	defer DeleteHString(name)
+
+	var factory uintptr
+	if hr, _, _ := procRoGetActivationFactory.Call(name, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&factory))); Failed(hr) {
+		return 0, fmt.Errorf("%s is not available: 0x%08x", class, uint32(hr))
+	}
+	return factory, nil
+}
+
+// AwaitAsync polls a WinRT async operation until it finishes. Polling avoids
+// implementing a completion handler for each result type
+func AwaitAsync(op uintptr, timeout time.Duration) error {
+	var info uintptr
+	if hr := Call(op, vtblQueryInterface, uintptr(unsafe.Pointer(&iidIAsyncInfo)), uintptr(unsafe.Pointer(&info))); Failed(hr) {
+		return fmt.Errorf("not an async operation: 0x%08x", uint32(hr))
+	}
+	defer Release(info)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var status int32
+		if hr := Call(info, vtblAsyncInfoStatus, uintptr(unsafe.Pointer(&status))); Failed(hr) {
+			return fmt.Errorf("async status: 0x%08x", uint32(hr))
+		}
+		switch status {
+		case asyncCompleted:
+			return nil
+		case asyncStarted:
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out after %v", timeout)
+			}
+			time.Sleep(10 * time.Millisecond)
+		default: // Canceled or Error
+			var code int32
+			Call(info, vtblAsyncInfoErrorCode, uintptr(unsafe.Pointer(&code)))
+			return fmt.Errorf("0x%08x", uint32(code))
+		}
+	}
+}
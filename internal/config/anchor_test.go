@@ -0,0 +1,90 @@
+package config
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAnchorConfig_Rect(t *testing.T) {
+	client := image.Rect(100, 200, 900, 800) // 800x600
+
+	tests := []struct {
+		name   string
+		anchor AnchorConfig
+		dpi    int
+		want   image.Rectangle
+	}{
+		{"top-left", AnchorConfig{X: 10, Y: 20, Width: 100, Height: 50}, 96, image.Rect(110, 220, 210, 270)},
+		{"scaled", AnchorConfig{X: 10, Y: 20, Width: 100, Height: 50}, 144, image.Rect(115, 230, 265, 305)},
+		{"top-right", AnchorConfig{Corner: CornerTopRight, X: 10, Y: 20, Width: 100, Height: 50}, 96, image.Rect(790, 220, 890, 270)},
+		{"bottom-right", AnchorConfig{Corner: CornerBottomRight, X: 0, Y: 0, Width: 100, Height: 50}, 96, image.Rect(800, 750, 900, 800)},
+		{"clipped", AnchorConfig{X: 750, Y: 0, Width: 100, Height: 50}, 96, image.Rect(850, 200, 900, 250)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.anchor.Rect(client, tt.dpi)
+			if err != nil || got != tt.want {
+				t.Errorf("Rect() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := (AnchorConfig{X: 900, Width: 10, Height: 10}).Rect(client, 96); err == nil {
+		t.Error("Rect() of an anchor outside the window succeeded")
+	}
+}
+
+func TestNewAnchor(t *testing.T) {
+	client := image.Rect(100, 200, 900, 800)
+
+	// Near the bottom right corner at 150%: offsets from that corner, at 96 DPI
+	r := image.Rect(600, 650, 870, 770)
+	a := NewAnchor("panel", r, client, 144)
+	want := AnchorConfig{Name: "panel", Corner: CornerBottomRight, X: 20, Y: 20, Width: 180, Height: 80}
+	if a != want {
+		t.Fatalf("NewAnchor() = %+v, want %+v", a, want)
+	}
+	if got, err := a.Rect(client, 144); err != nil || got != r {
+		t.Errorf("Rect() of the new anchor = %v, %v, want %v", got, err, r)
+	}
+
+	// Moving the window moves the region with it
+	moved := client.Add(image.Pt(-50, 30))
+	if got, _ := a.Rect(moved, 144); got != r.Add(image.Pt(-50, 30)) {
+		t.Errorf("Rect() after moving = %v, want %v", got, r.Add(image.Pt(-50, 30)))
+	}
+}
+
+func TestCaptureConfig_Anchors(t *testing.T) {
+	var c CaptureConfig
+	c.SetAnchor(AnchorConfig{Name: "Settings", App: "app.exe", Width: 10, Height: 10})
+	c.SetAnchor(AnchorConfig{Name: "settings", App: "other.exe", Width: 10, Height: 10})
+	if len(c.Anchors) != 1 {
+		t.Fatalf("anchors = %d, want 1 after replacing by name", len(c.Anchors))
+	}
+	if a, ok := c.Anchor("SETTINGS"); !ok || a.App != "other.exe" {
+		t.Errorf("Anchor() = %+v, %v, want the replaced anchor", a, ok)
+	}
+	if !c.DeleteAnchor("settings") || c.DeleteAnchor("settings") {
+		t.Error("DeleteAnchor() should remove the anchor once")
+	}
+}
+
+func TestAnchorConfig_Validate(t *testing.T) {
+	valid := AnchorConfig{Name: "panel", App: "app.exe", Width: 10, Height: 10}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	invalid := []AnchorConfig{
+		{App: "app.exe", Width: 10, Height: 10},
+		{Name: "panel", Width: 10, Height: 10},
+		{Name: "panel", App: "app.exe"},
+		{Name: "panel", App: "app.exe", X: -1, Width: 10, Height: 10},
+		{Name: "panel", App: "app.exe", Corner: "middle", Width: 10, Height: 10},
+	}
+	for _, a := range invalid {
+		if err := a.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", a)
+		}
+	}
+}
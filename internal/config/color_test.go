@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestColorConfig_ProfileFor(t *testing.T) {
+	const system = `C:\Windows\System32\spool\drivers\color\wide.icm`
+	profiles := map[string]string{
+		`\\.\DISPLAY2`: `D:\calibrated.icc`,
+		`\\.\DISPLAY3`: ProfileNone,
+	}
+	tests := []struct {
+		name   string
+		color  ColorConfig
+		device string
+		want   string
+	}{
+		{"disabled", ColorConfig{Profiles: profiles}, `\\.\DISPLAY2`, ""},
+		{"system profile", ColorConfig{EmbedProfile: true, Profiles: profiles}, `\\.\DISPLAY1`, system},
+		{"override", ColorConfig{EmbedProfile: true, Profiles: profiles}, `\\.\DISPLAY2`, `D:\calibrated.icc`},
+		{"turned off for display", ColorConfig{EmbedProfile: true, Profiles: profiles}, `\\.\DISPLAY3`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.color.ProfileFor(tt.device, system); got != tt.want {
+			t.Errorf("%s: ProfileFor() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,56 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // HotkeyConfig holds hotkey settings
 type HotkeyConfig struct {
-	Fullscreen string `json:"fullscreen"`
-	Region     string `json:"region"`
-	Window     string `json:"window"`
+	Fullscreen string                        `json:"fullscreen"`
+	Region     string                        `json:"region"`
+	Window     string                        `json:"window"`
+	Text       string                        `json:"text,omitempty"`    // Select a region and copy the text in it
+	Color      string                        `json:"color,omitempty"`   // Pick a color on screen and copy it
+	Repeat     string                        `json:"repeat,omitempty"`  // Capture the last selected region again without the overlay
+	Actions    map[string]HotkeyActionConfig `json:"actions,omitempty"` // Keyed by "fullscreen", "region", "window", "repeat"
+}
+
+// HotkeyActionConfig holds the output pipeline for a single hotkey action
+type HotkeyActionConfig struct {
+	Destination string `json:"destination"`        // "editor" (default), "clipboard", "file", "upload", "obsidian", "onenote"
+	Format      string `json:"format,omitempty"`   // "png", "jpeg", "webp" or "avif", empty uses export default
+	Quality     int    `json:"quality,omitempty"`  // JPEG, WebP and AVIF quality 1-100, 0 uses export default
+	Provider    string `json:"provider,omitempty"` // Upload provider: "r2", "s3", "gdrive" or "confluence"
+	Delay       int    `json:"delay,omitempty"`    // Seconds to count down before capturing
+	Annotate    bool   `json:"annotate,omitempty"` // Open the annotation window before delivering
+}
+
+// Hotkey action destinations
+const (
+	DestinationEditor    = "editor"
+	DestinationClipboard = "clipboard"
+	DestinationFile      = "file"
+	DestinationUpload    = "upload"
+	DestinationObsidian  = "obsidian"
+	DestinationOneNote   = "onenote"
+)
+
+// Action returns the pipeline for a hotkey action, defaulting to opening the editor
+func (h HotkeyConfig) Action(name string) HotkeyActionConfig {
+	action, ok := h.Actions[name]
+	if !ok || action.Destination == "" {
+		action.Destination = DestinationEditor
+	}
+	return action
 }
 
 // StartupConfig holds startup-related settings
@@ -30,15 +71,75 @@ type UpdateConfig struct {
 // QuickSaveConfig holds quick save settings
 type QuickSaveConfig struct {
 	Folder  string `json:"folder"`
-	Pattern string `json:"pattern"` // "timestamp", "date", "increment"
+	Pattern string `json:"pattern"` // "timestamp", "date", "increment", "source"
+	// Store identical captures once and hard link them into the folder
+	Deduplicate bool `json:"deduplicate,omitempty"`
+	// Don't record the windows open at capture time in the history index
+	NoWindowHistory bool `json:"noWindowHistory,omitempty"`
+}
+
+// AdjustmentConfig holds tonal adjustments applied at export time
+type AdjustmentConfig struct {
+	Brightness int     `json:"brightness"` // -100 to 100
+	Contrast   int     `json:"contrast"`   // -100 to 100
+	Gamma      float64 `json:"gamma"`      // 0.1 to 5.0, 1.0 = unchanged
+	Grayscale  bool    `json:"grayscale"`
 }
 
 // ExportConfig holds export default settings
 type ExportConfig struct {
-	DefaultFormat      string `json:"defaultFormat"`      // "png" or "jpeg"
-	JpegQuality        int    `json:"jpegQuality"`        // 0-100
-	IncludeBackground  bool   `json:"includeBackground"`
-	AutoCopyToClipboard bool  `json:"autoCopyToClipboard"`
+	DefaultFormat       string           `json:"defaultFormat"` // "png", "jpeg", "webp" or "avif"
+	JpegQuality         int              `json:"jpegQuality"`   // 0-100, also used for WebP and AVIF
+	IncludeBackground   bool             `json:"includeBackground"`
+	AutoCopyToClipboard bool             `json:"autoCopyToClipboard"`
+	Adjustments         AdjustmentConfig `json:"adjustments"`
+	WriteManifest       bool             `json:"writeManifest"` // Write SHA-256 provenance sidecar next to saved files
+	Color               ColorConfig      `json:"color"`
+	PngCompression      string           `json:"pngCompression,omitempty"` // "fast", "best" or "none", empty uses the default
+	WebPEncoder         string           `json:"webpEncoder,omitempty"`    // cwebp path, empty looks it up on PATH
+	AVIFEncoder         string           `json:"avifEncoder,omitempty"`    // avifenc path, empty looks it up on PATH
+}
+
+// CompressionLevel returns the PNG compression level for PngCompression.
+// Unknown values use the default
+func (e ExportConfig) CompressionLevel() png.CompressionLevel {
+	switch e.PngCompression {
+	case "fast":
+		return png.BestSpeed
+	case "best":
+		return png.BestCompression
+	case "none":
+		return png.NoCompression
+	}
+	return png.DefaultCompression
+}
+
+// ProfileNone turns off the color profile for one display in
+// ColorConfig.Profiles
+const ProfileNone = "none"
+
+// ColorConfig controls the color profile attached to saved PNG and JPEG
+// files. Wide-gamut displays render colors outside sRGB, which look
+// oversaturated elsewhere unless the display's profile travels along
+type ColorConfig struct {
+	EmbedProfile bool              `json:"embedProfile"`       // Attach the captured display's ICC profile
+	Profiles     map[string]string `json:"profiles,omitempty"` // ICC file per display device such as \\.\DISPLAY2, overriding Windows' choice, or ProfileNone
+}
+
+// ProfileFor returns the ICC file to embed for captures of a display, given
+// the profile Windows assigns to it, or "" for none
+func (c ColorConfig) ProfileFor(device, system string) string {
+	if !c.EmbedProfile {
+		return ""
+	}
+	profile, ok := c.Profiles[device]
+	if !ok {
+		return system
+	}
+	if profile == ProfileNone {
+		return ""
+	}
+	return profile
 }
 
 // WindowConfig holds window size and position settings
@@ -64,6 +165,7 @@ type EditorConfig struct {
 	BorderColor          string `json:"borderColor"`                    // Border color hex code
 	BorderOpacity        int    `json:"borderOpacity"`                  // Border opacity percentage (0-100)
 	BorderType           string `json:"borderType"`                     // Border position: outside, center, inside
+	DeviceFrame          string `json:"deviceFrame,omitempty"`          // Device mockup: "browser", "phone" or custom template name
 }
 
 // R2Config holds Cloudflare R2 settings (secrets stored in Credential Manager)
@@ -74,28 +176,500 @@ type R2Config struct {
 	Directory string `json:"directory,omitempty"` // Optional path prefix for uploads
 }
 
+// S3Config holds settings for AWS S3 or an S3-compatible service such as
+// MinIO or Backblaze B2 (keys stored in Credential Manager)
+type S3Config struct {
+	Endpoint    string `json:"endpoint,omitempty"` // Service URL, empty for AWS
+	Region      string `json:"region,omitempty"`   // us-east-1 if empty
+	Bucket      string `json:"bucket,omitempty"`
+	PublicURL   string `json:"publicUrl,omitempty"`   // Base of shared links, the bucket's own URL if empty
+	KeyTemplate string `json:"keyTemplate,omitempty"` // e.g. "shots/{{date}}/{{filename}}", the file name if empty
+	ACL         string `json:"acl,omitempty"`         // Canned ACL such as "public-read", none if empty
+	PathStyle   bool   `json:"pathStyle,omitempty"`   // Bucket in the URL path instead of the host name
+}
+
 // GDriveConfig holds Google Drive settings (OAuth tokens in Credential Manager)
 type GDriveConfig struct {
 	FolderID string `json:"folderId,omitempty"` // Optional upload folder ID
 }
 
+// ConfluenceConfig holds Confluence settings (API token in Credential Manager)
+type ConfluenceConfig struct {
+	BaseURL string `json:"baseUrl,omitempty"` // Site including the context path, e.g. https://example.atlassian.net/wiki
+	PageID  string `json:"pageId,omitempty"`  // Page captures are attached to
+	Marker  string `json:"marker,omitempty"`  // Page text image macros are inserted before, empty only attaches
+}
+
+// EphemeralConfig holds settings for a 0x0-style host that deletes uploads
+// after a while
+type EphemeralConfig struct {
+	Enabled     bool   `json:"enabled,omitempty"`
+	URL         string `json:"url,omitempty"`         // Instance to upload to, empty uses https://0x0.st
+	ExpiryHours int    `json:"expiryHours,omitempty"` // Hours until the host deletes an upload, 0 keeps its own retention
+	Secret      bool   `json:"secret,omitempty"`      // Ask for hard to guess links
+}
+
+// EncryptConfig holds client-side encryption settings for uploads (the
+// passphrase is stored in Credential Manager)
+type EncryptConfig struct {
+	Enabled    bool `json:"enabled,omitempty"`
+	Viewer     bool `json:"viewer,omitempty"`     // Upload a page that decrypts in the browser instead of the bare file
+	Passphrase bool `json:"passphrase,omitempty"` // Lock uploads with the saved passphrase instead of a key in the link
+}
+
+// UploadLimitConfig caps the upload rate of all providers
+type UploadLimitConfig struct {
+	KBps     int    `json:"kbps,omitempty"`     // 0 is unlimited
+	Schedule string `json:"schedule,omitempty"` // When the cap applies, e.g. "Mon-Fri 09:00-17:00", always when empty
+}
+
 // CloudConfig holds cloud upload provider settings
 type CloudConfig struct {
-	R2     R2Config     `json:"r2,omitempty"`
-	GDrive GDriveConfig `json:"gdrive,omitempty"`
+	R2         R2Config          `json:"r2,omitempty"`
+	S3         S3Config          `json:"s3,omitempty"`
+	GDrive     GDriveConfig      `json:"gdrive,omitempty"`
+	Confluence ConfluenceConfig  `json:"confluence,omitempty"`
+	Ephemeral  EphemeralConfig   `json:"ephemeral,omitempty"`
+	Encrypt    EncryptConfig     `json:"encrypt,omitempty"`
+	Limit      UploadLimitConfig `json:"limit,omitempty"`
+	Review     []string          `json:"review,omitempty"` // Providers whose automatic uploads need approval first, "*" for all
+}
+
+// ReviewAll in CloudConfig.Review asks before automatic uploads to any provider
+const ReviewAll = "*"
+
+// NeedsReview reports whether automatic uploads to provider are shown for
+// approval first. An empty provider is R2, the default
+func (c CloudConfig) NeedsReview(provider string) bool {
+	if provider == "" {
+		provider = "r2"
+	}
+	return slices.Contains(c.Review, ReviewAll) || slices.Contains(c.Review, provider)
+}
+
+// CaptureConfig holds settings shared by every kind of capture
+type CaptureConfig struct {
+	IncludeCursor bool `json:"includeCursor"` // Draw the mouse cursor into captures
+
+	// Last confirmed region selection on each monitor, keyed by display
+	// index, and the monitor it was last made on
+	LastRegions       map[int]RegionConfig `json:"lastRegions,omitempty"`
+	LastRegionMonitor int                  `json:"lastRegionMonitor,omitempty"`
+
+	Anchors []AnchorConfig `json:"anchors,omitempty"` // Named regions of app windows, captured with --anchor
+}
+
+// HistoryConfig holds capture history settings
+type HistoryConfig struct {
+	Disabled   bool `json:"disabled,omitempty"`   // Don't record captures
+	MaxEntries int  `json:"maxEntries,omitempty"` // Oldest entries are dropped past this, 0 keeps 500
+}
+
+// RegionConfig is a rectangle in virtual screen coordinates
+type RegionConfig struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// LastRegion returns the region last selected on monitor, or the most recent
+// selection on any monitor if none was made there. ok is false before the
+// first selection
+func (c CaptureConfig) LastRegion(monitor int) (region RegionConfig, ok bool) {
+	if r, ok := c.LastRegions[monitor]; ok {
+		return r, true
+	}
+	r, ok := c.LastRegions[c.LastRegionMonitor]
+	return r, ok
+}
+
+// SetLastRegion remembers a confirmed region selection on monitor
+func (c *CaptureConfig) SetLastRegion(monitor int, r RegionConfig) {
+	if c.LastRegions == nil {
+		c.LastRegions = make(map[int]RegionConfig)
+	}
+	c.LastRegions[monitor] = r
+	c.LastRegionMonitor = monitor
+}
+
+// Anchor corners, which client area corner an anchor's offsets are from
+const (
+	CornerTopLeft     = "top-left"
+	CornerTopRight    = "top-right"
+	CornerBottomLeft  = "bottom-left"
+	CornerBottomRight = "bottom-right"
+)
+
+// AnchorConfig is a named region inside the client area of an app's window,
+// such as the settings panel of an app, so it can be captured identically
+// wherever the window is moved. Offsets and size are at 96 DPI and scaled to
+// the window's DPI, so the same anchor works on displays with different
+// scaling
+type AnchorConfig struct {
+	Name   string `json:"name"`
+	App    string `json:"app,omitempty"`    // Executable of the window, such as "code.exe"
+	Title  string `json:"title,omitempty"`  // Text the window title contains, ignoring case
+	Corner string `json:"corner,omitempty"` // Client area corner X and Y are measured from, top-left if empty
+	X      int    `json:"x"`                // Distance from the corner to the nearest region edge
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Validate checks that an anchor can be resolved
+func (a AnchorConfig) Validate() error {
+	switch {
+	case strings.TrimSpace(a.Name) == "":
+		return errors.New("anchor has no name")
+	case a.App == "" && a.Title == "":
+		return fmt.Errorf("anchor %q needs an app or a window title", a.Name)
+	case a.Width <= 0 || a.Height <= 0:
+		return fmt.Errorf("anchor %q has no size", a.Name)
+	case a.X < 0 || a.Y < 0:
+		return fmt.Errorf("anchor %q has a negative offset", a.Name)
+	}
+	switch a.Corner {
+	case "", CornerTopLeft, CornerTopRight, CornerBottomLeft, CornerBottomRight:
+		return nil
+	}
+	return fmt.Errorf("anchor %q has an invalid corner %q", a.Name, a.Corner)
+}
+
+// Rect returns where the anchored region is on screen for a window whose
+// client area is client, drawn at dpi. The region is clipped to the client
+// area; an error is returned when nothing of it is left, such as when the
+// window was made too small
+func (a AnchorConfig) Rect(client image.Rectangle, dpi int) (image.Rectangle, error) {
+	if dpi <= 0 {
+		dpi = 96
+	}
+	scale := func(v int) int { return (v*dpi + 48) / 96 }
+	x, y, w, h := scale(a.X), scale(a.Y), scale(a.Width), scale(a.Height)
+
+	r := image.Rect(client.Min.X+x, client.Min.Y+y, client.Min.X+x+w, client.Min.Y+y+h)
+	if a.Corner == CornerTopRight || a.Corner == CornerBottomRight {
+		r.Min.X, r.Max.X = client.Max.X-x-w, client.Max.X-x
+	}
+	if a.Corner == CornerBottomLeft || a.Corner == CornerBottomRight {
+		r.Min.Y, r.Max.Y = client.Max.Y-y-h, client.Max.Y-y
+	}
+	r = r.Intersect(client)
+	if r.Empty() {
+		return image.Rectangle{}, fmt.Errorf("anchor %q is outside the window", a.Name)
+	}
+	return r, nil
+}
+
+// NewAnchor returns an anchor for the screen region r inside a window whose
+// client area is client, drawn at dpi, with offsets from the nearest corner
+// so the region follows that corner when the window is resized
+func NewAnchor(name string, r, client image.Rectangle, dpi int) AnchorConfig {
+	if dpi <= 0 {
+		dpi = 96
+	}
+	r = r.Intersect(client)
+	unscale := func(v int) int { return (v*96 + dpi/2) / dpi }
+	a := AnchorConfig{Name: name, Width: unscale(r.Dx()), Height: unscale(r.Dy())}
+
+	left, right := r.Min.X-client.Min.X, client.Max.X-r.Max.X
+	top, bottom := r.Min.Y-client.Min.Y, client.Max.Y-r.Max.Y
+	a.X, a.Y = unscale(left), unscale(top)
+	vertical, horizontal := "top", "left"
+	if right < left {
+		a.X, horizontal = unscale(right), "right"
+	}
+	if bottom < top {
+		a.Y, vertical = unscale(bottom), "bottom"
+	}
+	if corner := vertical + "-" + horizontal; corner != CornerTopLeft {
+		a.Corner = corner
+	}
+	return a
+}
+
+// Anchor returns the anchor with the given name, ignoring case
+func (c CaptureConfig) Anchor(name string) (AnchorConfig, bool) {
+	for _, a := range c.Anchors {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return AnchorConfig{}, false
+}
+
+// SetAnchor adds an anchor, replacing one with the same name
+func (c *CaptureConfig) SetAnchor(a AnchorConfig) {
+	for i := range c.Anchors {
+		if strings.EqualFold(c.Anchors[i].Name, a.Name) {
+			c.Anchors[i] = a
+			return
+		}
+	}
+	c.Anchors = append(c.Anchors, a)
+}
+
+// DeleteAnchor removes the anchor with the given name. It returns false if
+// there is none
+func (c *CaptureConfig) DeleteAnchor(name string) bool {
+	n := len(c.Anchors)
+	c.Anchors = slices.DeleteFunc(c.Anchors, func(a AnchorConfig) bool { return strings.EqualFold(a.Name, name) })
+	return len(c.Anchors) < n
+}
+
+// OverlayConfig holds region selection overlay settings
+type OverlayConfig struct {
+	PerMonitor      bool   `json:"perMonitor"`            // One overlay window per monitor instead of one spanning window
+	DimInactive     bool   `json:"dimInactive"`           // Dim monitors other than the one being captured
+	InactiveOpacity int    `json:"inactiveOpacity"`       // Dim strength over inactive monitors (1-100, 100 is black)
+	NoSuggestions   bool   `json:"noSuggestions"`         // Don't outline detected windows and panels for one click selection
+	ClientCoords    bool   `json:"clientCoords"`          // Show the selection position in client coordinates of the window under it
+	ColorFormat     string `json:"colorFormat,omitempty"` // "hex" (default), "rgb" or "hsl" copied by the color picker
+	Loupe           bool   `json:"loupe"`                 // Magnify the pixels around the cursor and show its coordinates while dragging
+	Adjust          bool   `json:"adjust"`                // Keep the selection adjustable after the drag until Enter captures it
+	Snap            bool   `json:"snap"`                  // Snap selections to window edges and the controls of the foreground window
+}
+
+// DefaultInactiveOpacity is used when InactiveOpacity is unset
+const DefaultInactiveOpacity = 85
+
+// DimAlpha returns the inactive monitor dim as an overlay alpha (0-255)
+func (o OverlayConfig) DimAlpha() uint8 {
+	opacity := o.InactiveOpacity
+	if opacity <= 0 {
+		opacity = DefaultInactiveOpacity
+	}
+	if opacity > 100 {
+		opacity = 100
+	}
+	return uint8(opacity * 255 / 100)
+}
+
+// GuideConfig holds the on-screen crop guide settings
+type GuideConfig struct {
+	Enabled   bool   `json:"enabled"`
+	X         int    `json:"x"` // Region in screen coordinates
+	Y         int    `json:"y"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Color     string `json:"color,omitempty"`     // Hex border color, red when empty
+	Thickness int    `json:"thickness,omitempty"` // Border width in pixels, 2 when zero
+}
+
+// TriggerConfig holds rules that capture automatically on window events
+type TriggerConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Cooldown int           `json:"cooldown,omitempty"` // Seconds before a rule fires again, 0 uses the default
+	Rules    []TriggerRule `json:"rules,omitempty"`
+}
+
+// TriggerRule captures when a window matching its patterns opens, closes or
+// comes to the foreground
+type TriggerRule struct {
+	Name    string             `json:"name"`
+	Enabled bool               `json:"enabled"`
+	Event   string             `json:"event"`             // "open", "close" or "focus"
+	Title   string             `json:"title,omitempty"`   // Regular expression matched against the window title
+	Class   string             `json:"class,omitempty"`   // Regular expression matched against the window class
+	Capture string             `json:"capture,omitempty"` // "window" (default) or "fullscreen"
+	Output  HotkeyActionConfig `json:"output"`            // Where the capture goes, delay is ignored
+}
+
+// Trigger capture targets
+const (
+	TriggerCaptureWindow     = "window"
+	TriggerCaptureFullscreen = "fullscreen"
+)
+
+// Pipeline returns the rule's output, saving to a file when no destination is
+// set since opening the editor would interrupt whatever fired the rule
+func (r TriggerRule) Pipeline() HotkeyActionConfig {
+	out := r.Output
+	if out.Destination == "" {
+		out.Destination = DestinationFile
+	}
+	out.Delay = 0
+	return out
+}
+
+// WatchdogConfig holds crash-scene capture settings for a watched process
+type WatchdogConfig struct {
+	Enabled        bool   `json:"enabled"`
+	Process        string `json:"process,omitempty"`        // Executable name, e.g. "app.exe"
+	HangSeconds    int    `json:"hangSeconds,omitempty"`    // Extra seconds a window must stay hung before capturing
+	Folder         string `json:"folder,omitempty"`         // Where incidents are saved, quick save folder when empty
+	ReportTemplate string `json:"reportTemplate,omitempty"` // text/template for the report, built-in when empty
+}
+
+// ShareConfig holds settings for sharing captures by local link
+type ShareConfig struct {
+	Port          int  `json:"port,omitempty"`          // 0 picks a free port each run
+	ExpiryMinutes int  `json:"expiryMinutes,omitempty"` // 0 uses the default of 15
+	Reusable      bool `json:"reusable,omitempty"`      // Links keep working after the first view
+	Viewer        bool `json:"viewer,omitempty"`        // Links open a viewer page with the note
+}
+
+// ExternalFilterConfig declares a program every capture is piped through
+// before it is encoded: PNG on stdin, transformed PNG on stdout
+type ExternalFilterConfig struct {
+	Name           string   `json:"name"`
+	Enabled        bool     `json:"enabled"`
+	Command        string   `json:"command"`
+	Args           []string `json:"args,omitempty"`
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"` // 0 uses the default of 10
+}
+
+// PluginConfig declares a sandboxed WebAssembly module. A filter module is
+// run on every capture after the external filters; an uploader module is the
+// upload provider "plugin:<name>"
+type PluginConfig struct {
+	Path           string   `json:"path"`
+	Enabled        bool     `json:"enabled"`
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"` // 0 uses the default of 5, for filters only
+	MemoryMB       int      `json:"memoryMB,omitempty"`       // 0 uses the default of 256
+	UploadHosts    []string `json:"uploadHosts,omitempty"`    // Hosts an uploader may send HTTPS requests to
+}
+
+// ScriptConfig binds a Lua workflow script to a hotkey
+type ScriptConfig struct {
+	Name           string `json:"name"`
+	Path           string `json:"path"`
+	Hotkey         string `json:"hotkey,omitempty"`         // Empty runs the script only on demand
+	TimeoutSeconds int    `json:"timeoutSeconds,omitempty"` // 0 uses the default of 120
+}
+
+// ScheduleConfig holds capture tasks run on cron-like schedules
+type ScheduleConfig struct {
+	Enabled bool            `json:"enabled"`
+	Tasks   []ScheduledTask `json:"tasks,omitempty"`
+}
+
+// ScheduledTask captures and delivers on a schedule, or runs a script
+type ScheduledTask struct {
+	Name       string             `json:"name"`
+	Enabled    bool               `json:"enabled"`
+	Cron       string             `json:"cron"`                 // "minute hour day month weekday", e.g. "0 9 * * Mon-Fri"
+	Capture    string             `json:"capture,omitempty"`    // "fullscreen" (default), "window" or "display:N"
+	Output     HotkeyActionConfig `json:"output"`               // Where the capture goes, delay is ignored
+	Script     string             `json:"script,omitempty"`     // Name of a script to run instead of capturing
+	SkipMissed bool               `json:"skipMissed,omitempty"` // Drop runs slept through instead of catching up once
+}
+
+// Pipeline returns the task's output with file as the default destination
+func (t ScheduledTask) Pipeline() HotkeyActionConfig {
+	out := t.Output
+	if out.Destination == "" {
+		out.Destination = DestinationFile
+	}
+	out.Delay = 0
+	return out
+}
+
+// BrowserConfig controls reading the address of the browser tab captured
+type BrowserConfig struct {
+	RecordURL bool `json:"recordUrl"` // Store the foreground tab's URL in the history index
+	StampURL  bool `json:"stampUrl"`  // Also draw the URL onto the capture
+}
+
+// NotesConfig holds the note-taking app destinations
+type NotesConfig struct {
+	Tags     []string       `json:"tags,omitempty"` // Tags added to every note
+	Obsidian ObsidianConfig `json:"obsidian"`
+	OneNote  OneNoteConfig  `json:"onenote"`
+}
+
+// ObsidianConfig holds where captures go in an Obsidian vault
+type ObsidianConfig struct {
+	Vault  string `json:"vault,omitempty"`  // Vault folder
+	Folder string `json:"folder,omitempty"` // Folder in the vault for notes and images, "Screenshots" if empty
+}
+
+// OneNoteConfig holds OneNote settings (client ID and token in Credential Manager)
+type OneNoteConfig struct {
+	SectionID string `json:"sectionId,omitempty"` // Section for new pages, the default section if empty
+}
+
+// VideoConfig holds settings for stepping through paused video frame by frame
+type VideoConfig struct {
+	StepKey     string `json:"stepKey,omitempty"`     // Key that advances the player one frame, "." if empty
+	StepDelayMs int    `json:"stepDelayMs,omitempty"` // Wait after stepping before capturing, 0 uses 250
+}
+
+// Consent modes for captures started by winshot:// links
+const (
+	LinkConsentAsk      = "ask"      // Confirm every link capture
+	LinkConsentRemember = "remember" // Confirm each distinct link once
+	LinkConsentAllow    = "allow"    // Capture without asking
+)
+
+// LinksConfig controls captures started by winshot:// links instead of the
+// user's hotkeys. Any web page or program can open a link
+type LinksConfig struct {
+	Consent  string   `json:"consent,omitempty"`  // One of the LinkConsent modes, LinkConsentAsk if empty
+	Approved []string `json:"approved,omitempty"` // Capture commands confirmed in LinkConsentRemember mode
+}
+
+// NeedsConsent reports whether the user must confirm a link capture. command
+// is the capture command the link runs
+func (l LinksConfig) NeedsConsent(command string) bool {
+	switch l.Consent {
+	case LinkConsentAllow:
+		return false
+	case LinkConsentRemember:
+		return !slices.Contains(l.Approved, command)
+	}
+	return true
+}
+
+// PowerConfig holds background work back on laptops while the user is away
+// or power is short. Background work is scheduled tasks and pipeline uploads
+type PowerConfig struct {
+	IdleMinutes    int  `json:"idleMinutes,omitempty"`    // Pause after this long without input, 0 never
+	BatterySaver   bool `json:"batterySaver,omitempty"`   // Pause while Windows battery saver is on
+	BatteryPercent int  `json:"batteryPercent,omitempty"` // Pause on battery at or below this charge, 0 never
+}
+
+// PauseReason returns why background work should wait, or "" if it can run.
+// batteryPercent is -1 when unknown
+func (p PowerConfig) PauseReason(idle time.Duration, onBattery, batterySaver bool, batteryPercent int) string {
+	switch {
+	case p.BatterySaver && batterySaver:
+		return "battery saver is on"
+	case p.BatteryPercent > 0 && onBattery && batteryPercent >= 0 && batteryPercent <= p.BatteryPercent:
+		return "battery is low"
+	case p.IdleMinutes > 0 && idle >= time.Duration(p.IdleMinutes)*time.Minute:
+		return "user is idle"
+	}
+	return ""
 }
 
 // Config holds all application settings
 type Config struct {
-	Hotkeys          HotkeyConfig    `json:"hotkeys"`
-	Startup          StartupConfig   `json:"startup"`
-	QuickSave        QuickSaveConfig `json:"quickSave"`
-	Export           ExportConfig    `json:"export"`
-	Window           WindowConfig    `json:"window"`
-	Editor           EditorConfig    `json:"editor"`
-	Update           UpdateConfig    `json:"update"`
-	Cloud            CloudConfig     `json:"cloud,omitempty"`
-	BackgroundImages []string        `json:"backgroundImages,omitempty"`
+	Hotkeys          HotkeyConfig           `json:"hotkeys"`
+	Startup          StartupConfig          `json:"startup"`
+	QuickSave        QuickSaveConfig        `json:"quickSave"`
+	Export           ExportConfig           `json:"export"`
+	Window           WindowConfig           `json:"window"`
+	Editor           EditorConfig           `json:"editor"`
+	Update           UpdateConfig           `json:"update"`
+	Cloud            CloudConfig            `json:"cloud,omitempty"`
+	Overlay          OverlayConfig          `json:"overlay"`
+	Guide            GuideConfig            `json:"guide"`
+	Triggers         TriggerConfig          `json:"triggers"`
+	Watchdog         WatchdogConfig         `json:"watchdog"`
+	Share            ShareConfig            `json:"share"`
+	Filters          []ExternalFilterConfig `json:"filters,omitempty"`
+	Plugins          []PluginConfig         `json:"plugins,omitempty"`
+	Scripts          []ScriptConfig         `json:"scripts,omitempty"`
+	Schedule         ScheduleConfig         `json:"schedule"`
+	Browser          BrowserConfig          `json:"browser"`
+	Notes            NotesConfig            `json:"notes"`
+	Video            VideoConfig            `json:"video"`
+	Links            LinksConfig            `json:"links"`
+	Power            PowerConfig            `json:"power"`
+	Capture          CaptureConfig          `json:"capture"`
+	History          HistoryConfig          `json:"history"`
+	BackgroundImages []string               `json:"backgroundImages,omitempty"`
 }
 
 // Default returns default configuration
@@ -124,6 +698,9 @@ func Default() *Config {
 			JpegQuality:         95,
 			IncludeBackground:   true,
 			AutoCopyToClipboard: true,
+			Adjustments: AdjustmentConfig{
+				Gamma: 1.0,
+			},
 		},
 		Window: WindowConfig{
 			Width:  1200,
@@ -153,16 +730,19 @@ func Default() *Config {
 			R2:     R2Config{},
 			GDrive: GDriveConfig{},
 		},
+		Overlay: OverlayConfig{
+			InactiveOpacity: DefaultInactiveOpacity,
+		},
 	}
 }
 
 // GetConfigPath returns the path to the config file
 func GetConfigPath() (string, error) {
-	configDir, err := os.UserConfigDir()
+	dir, err := DataDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(configDir, "WinShot", "config.json"), nil
+	return filepath.Join(dir, "config.json"), nil
 }
 
 // Load reads config from disk, returns default if not found
@@ -193,8 +773,22 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// ErrReadOnly is returned by Save while settings are read-only
+var ErrReadOnly = errors.New("settings can't be changed in safe mode")
+
+var readOnly atomic.Bool
+
+// SetReadOnly makes Save fail with ErrReadOnly, so safe mode can't write its
+// defaults over the user's config
+func SetReadOnly(ro bool) {
+	readOnly.Store(ro)
+}
+
 // Save writes config to disk
 func (c *Config) Save() error {
+	if readOnly.Load() {
+		return ErrReadOnly
+	}
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return err
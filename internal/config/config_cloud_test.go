@@ -137,3 +137,23 @@ func TestDefault_IncludesCloudConfig(t *testing.T) {
 		t.Error("Default GDrive FolderID should be empty")
 	}
 }
+
+func TestCloudConfig_NeedsReview(t *testing.T) {
+	tests := []struct {
+		name     string
+		review   []string
+		provider string
+		want     bool
+	}{
+		{"off by default", nil, "r2", false},
+		{"listed provider", []string{"ephemeral"}, "ephemeral", true},
+		{"other provider", []string{"ephemeral"}, "gdrive", false},
+		{"empty provider is r2", []string{"r2"}, "", true},
+		{"all providers", []string{ReviewAll}, "confluence", true},
+	}
+	for _, tt := range tests {
+		if got := (CloudConfig{Review: tt.review}).NeedsReview(tt.provider); got != tt.want {
+			t.Errorf("%s: NeedsReview(%q) = %v, want %v", tt.name, tt.provider, got, tt.want)
+		}
+	}
+}
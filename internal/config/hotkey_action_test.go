@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHotkeyConfig_Action(t *testing.T) {
+	h := HotkeyConfig{
+		Actions: map[string]HotkeyActionConfig{
+			"region": {Destination: DestinationFile, Format: "jpeg", Quality: 80},
+			"window": {Format: "png"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"region", DestinationFile},
+		{"window", DestinationEditor},     // Empty destination defaults to editor
+		{"fullscreen", DestinationEditor}, // Missing action defaults to editor
+	}
+
+	for _, tt := range tests {
+		if got := h.Action(tt.name).Destination; got != tt.want {
+			t.Errorf("Action(%q).Destination = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := h.Action("region").Quality; got != 80 {
+		t.Errorf("Action(region).Quality = %d, want 80", got)
+	}
+}
+
+func TestHotkeyConfig_ActionsOmittedWhenEmpty(t *testing.T) {
+	data, err := json.Marshal(Default().Hotkeys)
+	if err != nil {
+		t.Fatalf("Failed to marshal hotkeys: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Failed to unmarshal hotkeys: %v", err)
+	}
+	if _, ok := raw["actions"]; ok {
+		t.Error("actions should be omitted when no pipelines are configured")
+	}
+}
+
+func TestTriggerRule_Pipeline(t *testing.T) {
+	r := TriggerRule{Output: HotkeyActionConfig{Format: "jpeg", Delay: 5}}
+	got := r.Pipeline()
+	if got.Destination != DestinationFile {
+		t.Errorf("Pipeline().Destination = %q, want %q", got.Destination, DestinationFile)
+	}
+	if got.Delay != 0 {
+		t.Errorf("Pipeline().Delay = %d, want 0", got.Delay)
+	}
+	if got.Format != "jpeg" {
+		t.Errorf("Pipeline().Format = %q, want jpeg", got.Format)
+	}
+
+	r.Output.Destination = DestinationClipboard
+	if got := r.Pipeline().Destination; got != DestinationClipboard {
+		t.Errorf("Pipeline().Destination = %q, want %q", got, DestinationClipboard)
+	}
+}
+
+func TestCaptureConfig_LastRegion(t *testing.T) {
+	var c CaptureConfig
+	if _, ok := c.LastRegion(0); ok {
+		t.Fatal("LastRegion() ok before any selection")
+	}
+
+	c.SetLastRegion(0, RegionConfig{X: 10, Y: 20, Width: 300, Height: 200})
+	c.SetLastRegion(1, RegionConfig{X: 2000, Y: 40, Width: 50, Height: 60})
+
+	if r, _ := c.LastRegion(0); r.X != 10 {
+		t.Errorf("LastRegion(0).X = %d, want 10", r.X)
+	}
+	// A monitor without a selection falls back to the most recent one
+	if r, ok := c.LastRegion(2); !ok || r.X != 2000 {
+		t.Errorf("LastRegion(2) = %+v, %v, want the monitor 1 region", r, ok)
+	}
+}
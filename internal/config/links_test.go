@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestLinksConfig_NeedsConsent(t *testing.T) {
+	const command = "winshot capture --window=Notes --clipboard"
+	tests := []struct {
+		name  string
+		links LinksConfig
+		want  bool
+	}{
+		{"default asks", LinksConfig{}, true},
+		{"ask", LinksConfig{Consent: LinkConsentAsk, Approved: []string{command}}, true},
+		{"unknown mode asks", LinksConfig{Consent: "sometimes"}, true},
+		{"remember new link", LinksConfig{Consent: LinkConsentRemember, Approved: []string{"winshot capture"}}, true},
+		{"remember approved link", LinksConfig{Consent: LinkConsentRemember, Approved: []string{command}}, false},
+		{"allow", LinksConfig{Consent: LinkConsentAllow}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.links.NeedsConsent(command); got != tt.want {
+			t.Errorf("%s: NeedsConsent() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var migrateOnce sync.Once
+
+// DataDir returns the per-user folder for config and other app state,
+// %LOCALAPPDATA%\WinShot. Local app data never roams, so terminal server and
+// roaming profile users don't share hotkeys or window positions between
+// machines. Settings from the old %APPDATA% location are moved on first use
+func DataDir() (string, error) {
+	base := os.Getenv("LOCALAPPDATA")
+	if base == "" {
+		var err error
+		if base, err = os.UserConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	dir := filepath.Join(base, appName)
+
+	migrateOnce.Do(func() {
+		if roaming, err := os.UserConfigDir(); err == nil {
+			_ = migrateLegacyDir(filepath.Join(roaming, appName), dir)
+		}
+	})
+	return dir, nil
+}
+
+// migrateLegacyDir moves the old data folder to dir unless dir already
+// exists. The folders can be on different volumes when app data is
+// redirected, so it falls back to copying
+func migrateLegacyDir(legacy, dir string) error {
+	if filepath.Clean(legacy) == filepath.Clean(dir) {
+		return nil
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(legacy, dir); err == nil {
+		return nil
+	}
+	return copyDir(legacy, dir)
+}
+
+// copyDir copies a folder tree, leaving the source in place
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
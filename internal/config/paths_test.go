@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLegacyDir(t *testing.T) {
+	root := t.TempDir()
+	legacy := filepath.Join(root, "Roaming", "WinShot")
+	dir := filepath.Join(root, "Local", "WinShot")
+	if err := os.MkdirAll(filepath.Join(legacy, "frames"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(legacy, "config.json"), []byte(`{"theme":"dark"}`), 0644)
+	os.WriteFile(filepath.Join(legacy, "frames", "phone.json"), []byte(`{}`), 0644)
+
+	if err := migrateLegacyDir(legacy, dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil || string(data) != `{"theme":"dark"}` {
+		t.Errorf("migrated config = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "frames", "phone.json")); err != nil {
+		t.Errorf("device frames were not migrated: %v", err)
+	}
+
+	// An existing folder is never overwritten by a later migration
+	os.MkdirAll(legacy, 0755)
+	os.WriteFile(filepath.Join(legacy, "config.json"), []byte(`{"theme":"light"}`), 0644)
+	if err := migrateLegacyDir(legacy, dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "config.json")); string(data) != `{"theme":"dark"}` {
+		t.Errorf("config after second migration = %q, want it untouched", data)
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "copy")
+	os.MkdirAll(filepath.Join(src, "a", "b"), 0755)
+	os.WriteFile(filepath.Join(src, "a", "b", "file.txt"), []byte("hello"), 0644)
+
+	if err := copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "a", "b", "file.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("copied file = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "a", "b", "file.txt")); err != nil {
+		t.Error("copyDir removed the source")
+	}
+}
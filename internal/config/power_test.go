@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPowerConfig_PauseReason(t *testing.T) {
+	all := PowerConfig{IdleMinutes: 10, BatterySaver: true, BatteryPercent: 20}
+	tests := []struct {
+		name         string
+		power        PowerConfig
+		idle         time.Duration
+		onBattery    bool
+		batterySaver bool
+		percent      int
+		want         string
+	}{
+		{"disabled", PowerConfig{}, time.Hour, true, true, 5, ""},
+		{"active on AC", all, time.Minute, false, false, -1, ""},
+		{"idle", all, 10 * time.Minute, false, false, -1, "user is idle"},
+		{"battery saver", all, 0, true, true, 80, "battery saver is on"},
+		{"battery saver ignored", PowerConfig{IdleMinutes: 10}, 0, true, true, 80, ""},
+		{"low battery", all, 0, true, false, 20, "battery is low"},
+		{"charged battery", all, 0, true, false, 21, ""},
+		{"low charge on AC", all, 0, false, false, 5, ""},
+		{"unknown charge", all, 0, true, false, -1, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.power.PauseReason(tt.idle, tt.onBattery, tt.batterySaver, tt.percent); got != tt.want {
+			t.Errorf("%s: PauseReason() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
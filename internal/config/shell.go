@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// winshot:// links, handled by the CLI
+	protocolKeyPath = `Software\Classes\winshot`
+	// Explorer context menu for annotation projects (project.Extension)
+	projectMenuKeyPath = `Software\Classes\SystemFileAssociations\.wshot\shell\WinShot.Render`
+
+	appModelErrorNoPackage = 15700
+)
+
+var procGetCurrentPackageFullName = syscall.NewLazyDLL("kernel32.dll").NewProc("GetCurrentPackageFullName")
+
+// IsPackaged reports whether WinShot runs from an MSIX package. Packaged
+// apps declare their protocol and context menus in the package manifest, and
+// Windows removes them with the package, so nothing is written to the registry
+func IsPackaged() bool {
+	if procGetCurrentPackageFullName.Find() != nil {
+		return false
+	}
+	var length uint32
+	ret, _, _ := procGetCurrentPackageFullName.Call(uintptr(unsafe.Pointer(&length)), 0)
+	return ret != appModelErrorNoPackage
+}
+
+// SyncShellIntegration registers the winshot:// protocol and the Explorer
+// context menu for the current executable. Like SyncStartupPath it is safe
+// to call on every launch, and it repoints the entries after an upgrade
+// installs to a new folder
+func SyncShellIntegration() error {
+	if IsPackaged() {
+		return nil
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	exePath, err = filepath.Abs(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	for _, entry := range shellEntries(exePath) {
+		if err := writeRegistryValues(entry.path, entry.values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type shellEntry struct {
+	path   string
+	values map[string]string // "" is the key's default value
+}
+
+func shellEntries(exePath string) []shellEntry {
+	quoted := fmt.Sprintf(`"%s"`, exePath)
+	return []shellEntry{
+		{protocolKeyPath, map[string]string{"": "URL:WinShot Protocol", "URL Protocol": ""}},
+		{protocolKeyPath + `\DefaultIcon`, map[string]string{"": quoted + ",0"}},
+		{protocolKeyPath + `\shell\open\command`, map[string]string{"": quoted + ` "%1"`}},
+		{projectMenuKeyPath, map[string]string{"MUIVerb": "Export to PNG with WinShot", "Icon": quoted}},
+		{projectMenuKeyPath + `\command`, map[string]string{"": quoted + ` render "%1"`}},
+	}
+}
+
+func writeRegistryValues(path string, values map[string]string) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, path, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to create registry key %s: %w", path, err)
+	}
+	defer key.Close()
+	for name, value := range values {
+		if err := key.SetStringValue(name, value); err != nil {
+			return fmt.Errorf("failed to set registry value: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveShellIntegration deletes the protocol and context menu entries
+func RemoveShellIntegration() error {
+	var errs []error
+	for _, path := range []string{protocolKeyPath, projectMenuKeyPath} {
+		if err := deleteKeyTree(registry.CURRENT_USER, path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete registry key %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UninstallCleanup removes every registry entry WinShot creates: the startup
+// entry, the protocol handler and the context menu. Installers run it before
+// deleting the program files. Settings and screenshots are left alone
+func UninstallCleanup() error {
+	return errors.Join(disableStartup(), RemoveShellIntegration())
+}
+
+// deleteKeyTree deletes a key and its subkeys. A missing key is not an error
+func deleteKeyTree(root registry.Key, path string) error {
+	key, err := registry.OpenKey(root, path, registry.ENUMERATE_SUB_KEYS)
+	if err == registry.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	subkeys, err := key.ReadSubKeyNames(-1)
+	key.Close()
+	if err != nil {
+		return err
+	}
+	for _, name := range subkeys {
+		if err := deleteKeyTree(root, path+`\`+name); err != nil {
+			return err
+		}
+	}
+	if err := registry.DeleteKey(root, path); err != nil && err != registry.ErrNotExist {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package diag
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Report describes the machine and process in a diagnostics bundle.
+type Report struct {
+	Generated    time.Time                 `json:"generated"`
+	Version      string                    `json:"version"`
+	Capabilities []Capability              `json:"capabilities"`
+	Displays     []Display                 `json:"displays"`
+	Resources    map[string]ResourceCounts `json:"resources"` // Keyed by process, such as "app (pid 1234)"
+}
+
+// Capability is one row of the capability matrix: a platform feature
+// capture depends on and whether this machine has it.
+type Capability struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// Display is one monitor of the display topology.
+type Display struct {
+	Index   int     `json:"index"`
+	X       int     `json:"x"`
+	Y       int     `json:"y"`
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	DPI     int     `json:"dpi,omitempty"` // 0 when the system can't report it
+	Scale   float64 `json:"scale,omitempty"`
+	Primary bool    `json:"primary,omitempty"`
+}
+
+// BundleFile is one file in a diagnostics bundle.
+type BundleFile struct {
+	Name string
+	Data []byte
+}
+
+// WriteBundle zips files into w, stamped with the given time.
+func WriteBundle(w io.Writer, files []BundleFile, modified time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// Redacted replaces secret values and other personal data in a bundle.
+const Redacted = "[redacted]"
+
+// secretKey matches config keys whose values must never leave the machine.
+var secretKey = regexp.MustCompile(`(?i)(secret|token|password|passwd|apikey|api_key|accesskey|credential|webhook|auth)`)
+
+// RedactConfig returns config JSON with secret values replaced, embedded
+// images summarized and the user's home folder shortened to %USERPROFILE%,
+// so the file can be attached to a public bug report.
+func RedactConfig(data []byte, home string) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	v = redact(v, "", home)
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func redact(v any, key, home string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			t[k] = redact(child, k, home)
+		}
+		return t
+	case []any:
+		if key == "backgroundImages" {
+			return fmt.Sprintf("[%d images]", len(t))
+		}
+		for i, child := range t {
+			t[i] = redact(child, key, home)
+		}
+		return t
+	case string:
+		if t != "" && secretKey.MatchString(key) {
+			return Redacted
+		}
+		return RedactPaths(t, home)
+	}
+	return v
+}
+
+// RedactPaths replaces the user's home folder in s with %USERPROFILE%,
+// ignoring case as Windows paths do.
+func RedactPaths(s, home string) string {
+	if home == "" {
+		return s
+	}
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(strings.TrimRight(home, `\/`)))
+	return re.ReplaceAllLiteralString(s, "%USERPROFILE%")
+}
@@ -0,0 +1,91 @@
+package diag
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactConfig(t *testing.T) {
+	config := `{
+		"quickSave": {"folder": "C:\\Users\\Ada\\Pictures\\WinShot"},
+		"cloud": {"r2": {"accountId": "abc", "bucket": "shots"}},
+		"triggers": {"webhookUrl": "https://hooks.example.com/T0/B1"},
+		"scripts": [{"name": "upload", "apiKey": "sk-123", "path": "c:\\users\\ada\\s.lua"}],
+		"backgroundImages": ["data:image/png;base64,AAAA", "data:image/png;base64,BBBB"]
+	}`
+	out, err := RedactConfig([]byte(config), `C:\Users\Ada`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+
+	for _, secret := range []string{"sk-123", "hooks.example.com", "Ada", "AAAA"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("redacted config still contains %q:\n%s", secret, got)
+		}
+	}
+	for _, kept := range []string{`"bucket": "shots"`, `%USERPROFILE%\\Pictures\\WinShot`, `"[2 images]"`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("redacted config is missing %s:\n%s", kept, got)
+		}
+	}
+
+	if _, err := RedactConfig([]byte("{"), ""); err == nil {
+		t.Error("RedactConfig() of invalid JSON succeeded")
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	var buf bytes.Buffer
+	files := []BundleFile{{"report.json", []byte(`{}`)}, {"logs/safe-mode.log", []byte("started")}}
+	if err := WriteBundle(&buf, files, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[1].Name != "logs/safe-mode.log" {
+		t.Fatalf("bundle files = %v", zr.File)
+	}
+	rc, _ := zr.File[1].Open()
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "started" {
+		t.Errorf("log contents = %q", data)
+	}
+}
+
+func TestErrorLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	if _, err := ReadErrors(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadErrors() of a missing log = %v, want ErrNotExist", err)
+	}
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxErrors+5; i++ {
+		entry := ErrorEntry{Time: start.Add(time.Duration(i) * time.Second), Source: "pipeline", Message: "capture failed"}
+		if err := AppendError(path, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := ReadErrors(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != MaxErrors {
+		t.Fatalf("kept %d entries, want %d", len(entries), MaxErrors)
+	}
+	if want := start.Add(5 * time.Second); !entries[0].Time.Equal(want) {
+		t.Errorf("oldest entry = %v, want %v", entries[0].Time, want)
+	}
+}
@@ -0,0 +1,72 @@
+package diag
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MaxErrors is how many recent errors an error log keeps.
+const MaxErrors = 200
+
+// ErrorEntry is one recorded error.
+type ErrorEntry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"` // What failed, such as "pipeline" or "startup"
+	Message string    `json:"message"`
+}
+
+var errorLogMu sync.Mutex
+
+// AppendError records an error in the JSON lines file at path, dropping the
+// oldest entries beyond MaxErrors.
+func AppendError(path string, entry ErrorEntry) error {
+	errorLogMu.Lock()
+	defer errorLogMu.Unlock()
+
+	entries, err := ReadErrors(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		entries = nil // Start over rather than keep a corrupt log
+	}
+	entries = append(entries, entry)
+	if len(entries) > MaxErrors {
+		entries = entries[len(entries)-MaxErrors:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ReadErrors returns the recorded errors, oldest first.
+func ReadErrors(path string) ([]ErrorEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ErrorEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e ErrorEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,141 @@
+// Package diag provides self-diagnostics for long-running tray usage, such
+// as soak tests that detect slow GDI and handle leaks, and the diagnostics
+// bundles attached to bug reports.
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResourceCounts is a snapshot of process resource usage.
+type ResourceCounts struct {
+	GDIObjects  int `json:"gdiObjects"`
+	UserObjects int `json:"userObjects"`
+	Handles     int `json:"handles"`
+}
+
+// Sub returns the per-field difference r - other.
+func (r ResourceCounts) Sub(other ResourceCounts) ResourceCounts {
+	return ResourceCounts{
+		GDIObjects:  r.GDIObjects - other.GDIObjects,
+		UserObjects: r.UserObjects - other.UserObjects,
+		Handles:     r.Handles - other.Handles,
+	}
+}
+
+// max returns the per-field maximum of r and other.
+func (r ResourceCounts) max(other ResourceCounts) ResourceCounts {
+	return ResourceCounts{
+		GDIObjects:  max(r.GDIObjects, other.GDIObjects),
+		UserObjects: max(r.UserObjects, other.UserObjects),
+		Handles:     max(r.Handles, other.Handles),
+	}
+}
+
+// SoakOptions configures a soak run.
+type SoakOptions struct {
+	Iterations int                   // Measured iterations (default 200)
+	Warmup     int                   // Unmeasured iterations that let caches settle (default 10, negative for none)
+	Tolerance  ResourceCounts        // Allowed growth per counter over the whole run
+	Step       func(i int) error     // One open/capture/close cycle
+	Sample     func() ResourceCounts // Reads current resource counts
+	Progress   func(i int, counts ResourceCounts)
+}
+
+// DefaultTolerance allows a little noise from lazily created system objects.
+var DefaultTolerance = ResourceCounts{GDIObjects: 4, UserObjects: 4, Handles: 16}
+
+// SoakReport summarizes a soak run.
+type SoakReport struct {
+	Iterations int            `json:"iterations"`
+	Baseline   ResourceCounts `json:"baseline"`
+	Final      ResourceCounts `json:"final"`
+	Peak       ResourceCounts `json:"peak"`
+	Growth     ResourceCounts `json:"growth"`
+	Leaks      []string       `json:"leaks,omitempty"` // Counters whose growth exceeded tolerance
+}
+
+// Leaked reports whether any counter grew beyond tolerance.
+func (r *SoakReport) Leaked() bool {
+	return len(r.Leaks) > 0
+}
+
+// String formats the report for console output.
+func (r *SoakReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "iterations: %d\n", r.Iterations)
+	fmt.Fprintf(&b, "%-12s %10s %10s %10s %10s\n", "counter", "baseline", "final", "peak", "growth")
+	rows := []struct {
+		name                string
+		base, fin, pk, grow int
+	}{
+		{"gdi", r.Baseline.GDIObjects, r.Final.GDIObjects, r.Peak.GDIObjects, r.Growth.GDIObjects},
+		{"user", r.Baseline.UserObjects, r.Final.UserObjects, r.Peak.UserObjects, r.Growth.UserObjects},
+		{"handles", r.Baseline.Handles, r.Final.Handles, r.Peak.Handles, r.Growth.Handles},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%-12s %10d %10d %10d %+10d\n", row.name, row.base, row.fin, row.pk, row.grow)
+	}
+	if r.Leaked() {
+		fmt.Fprintf(&b, "LEAK: %s\n", strings.Join(r.Leaks, ", "))
+	} else {
+		b.WriteString("OK: no resource growth beyond tolerance\n")
+	}
+	return b.String()
+}
+
+// Soak runs Step repeatedly and compares resource counts before and after.
+// A step error aborts the run and is returned together with the partial report.
+func Soak(opts SoakOptions) (*SoakReport, error) {
+	if opts.Step == nil || opts.Sample == nil {
+		return nil, errors.New("soak requires Step and Sample")
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = 200
+	}
+	if opts.Warmup < 0 {
+		opts.Warmup = 0
+	} else if opts.Warmup == 0 {
+		opts.Warmup = 10
+	}
+
+	for i := 0; i < opts.Warmup; i++ {
+		if err := opts.Step(i); err != nil {
+			return nil, fmt.Errorf("warmup iteration %d: %w", i, err)
+		}
+	}
+
+	report := &SoakReport{Baseline: opts.Sample()}
+	report.Peak = report.Baseline
+
+	var stepErr error
+	for i := 0; i < opts.Iterations; i++ {
+		if err := opts.Step(opts.Warmup + i); err != nil {
+			stepErr = fmt.Errorf("iteration %d: %w", i, err)
+			break
+		}
+		counts := opts.Sample()
+		report.Peak = report.Peak.max(counts)
+		report.Iterations++
+		if opts.Progress != nil {
+			opts.Progress(i, counts)
+		}
+	}
+
+	report.Final = opts.Sample()
+	report.Growth = report.Final.Sub(report.Baseline)
+
+	if report.Growth.GDIObjects > opts.Tolerance.GDIObjects {
+		report.Leaks = append(report.Leaks, fmt.Sprintf("gdi +%d", report.Growth.GDIObjects))
+	}
+	if report.Growth.UserObjects > opts.Tolerance.UserObjects {
+		report.Leaks = append(report.Leaks, fmt.Sprintf("user +%d", report.Growth.UserObjects))
+	}
+	if report.Growth.Handles > opts.Tolerance.Handles {
+		report.Leaks = append(report.Leaks, fmt.Sprintf("handles +%d", report.Growth.Handles))
+	}
+
+	return report, stepErr
+}
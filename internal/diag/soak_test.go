@@ -0,0 +1,86 @@
+package diag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSoak_NoLeak(t *testing.T) {
+	current := ResourceCounts{GDIObjects: 50, UserObjects: 20, Handles: 300}
+
+	report, err := Soak(SoakOptions{
+		Iterations: 100,
+		Tolerance:  DefaultTolerance,
+		Step: func(i int) error {
+			// Resources rise during a step and are released again
+			current.GDIObjects += 3
+			current.GDIObjects -= 3
+			return nil
+		},
+		Sample: func() ResourceCounts { return current },
+	})
+	if err != nil {
+		t.Fatalf("Soak() error = %v", err)
+	}
+	if report.Leaked() {
+		t.Errorf("Soak() reported leak for stable counts: %v", report.Leaks)
+	}
+	if report.Iterations != 100 {
+		t.Errorf("Iterations = %d, want 100", report.Iterations)
+	}
+}
+
+func TestSoak_DetectsGDILeak(t *testing.T) {
+	current := ResourceCounts{GDIObjects: 50, Handles: 300}
+
+	report, err := Soak(SoakOptions{
+		Iterations: 20,
+		Warmup:     -1,
+		Tolerance:  DefaultTolerance,
+		Step: func(i int) error {
+			current.GDIObjects++ // One leaked bitmap per cycle
+			return nil
+		},
+		Sample: func() ResourceCounts { return current },
+	})
+	if err != nil {
+		t.Fatalf("Soak() error = %v", err)
+	}
+	if !report.Leaked() {
+		t.Fatal("Soak() did not report a leak")
+	}
+	if report.Growth.GDIObjects != 20 {
+		t.Errorf("Growth.GDIObjects = %d, want 20", report.Growth.GDIObjects)
+	}
+	if report.Peak.GDIObjects != 70 {
+		t.Errorf("Peak.GDIObjects = %d, want 70", report.Peak.GDIObjects)
+	}
+}
+
+func TestSoak_StepError(t *testing.T) {
+	stepErr := errors.New("overlay failed")
+
+	report, err := Soak(SoakOptions{
+		Iterations: 10,
+		Warmup:     -1,
+		Step: func(i int) error {
+			if i == 3 {
+				return stepErr
+			}
+			return nil
+		},
+		Sample: func() ResourceCounts { return ResourceCounts{} },
+	})
+	if !errors.Is(err, stepErr) {
+		t.Errorf("Soak() error = %v, want %v", err, stepErr)
+	}
+	if report == nil || report.Iterations != 3 {
+		t.Errorf("partial report should cover 3 iterations, got %+v", report)
+	}
+}
+
+func TestSoak_RequiresCallbacks(t *testing.T) {
+	if _, err := Soak(SoakOptions{}); err == nil {
+		t.Error("Soak() with no Step/Sample should fail")
+	}
+}
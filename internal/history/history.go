@@ -0,0 +1,315 @@
+// Package history keeps a log of every capture WinShot delivers, whether it
+// was saved, copied or uploaded, so it can be found, copied or uploaded again
+// later. The library only knows the files in the screenshot folder; captures
+// that went straight to the clipboard or a cloud host are only here.
+//
+// Entries are JSON lines in history.jsonl, appended as captures happen, with a
+// JPEG thumbnail per entry. Captures that were never saved keep a PNG copy in
+// the store so they can be copied again. The oldest entries are dropped with
+// their files once the store holds more than its limit.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Decode saved JPEG captures
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "golang.org/x/image/webp" // Decode saved WebP captures
+	"winshot/internal/imaging"
+)
+
+// DefaultMaxEntries is how many entries a store keeps when no limit is set
+const DefaultMaxEntries = 500
+
+// Thumbnail settings
+const (
+	ThumbnailSize    = 256 // Longer side in pixels
+	thumbnailQuality = 80
+)
+
+// Store layout inside its folder
+const (
+	logName  = "history.jsonl"
+	thumbDir = "thumbs"
+	imageDir = "images"
+)
+
+// ErrNotFound is returned for an entry ID that is not in the store
+var ErrNotFound = errors.New("history entry not found")
+
+// Entry is one capture in the history
+type Entry struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	Path   string    `json:"path,omitempty"`   // Saved file, empty when the capture was only copied or uploaded
+	URL    string    `json:"url,omitempty"`    // Latest upload link
+	Source string    `json:"source,omitempty"` // How it was captured, such as "region", "window" or "editor"
+	App    string    `json:"app,omitempty"`    // Executable of the foreground window at capture
+	Title  string    `json:"title,omitempty"`  // Title of the foreground window at capture
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+}
+
+// Store is a capture history kept in a folder. It is safe for concurrent use.
+type Store struct {
+	dir        string
+	maxEntries int
+	entries    []Entry // Oldest first
+	mu         sync.Mutex
+}
+
+// Open loads the history kept in dir, creating the folder if needed. A
+// maxEntries of 0 keeps DefaultMaxEntries. Lines that can't be parsed, such
+// as one cut short by a crash, are skipped
+func Open(dir string, maxEntries int) (*Store, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if err := os.MkdirAll(filepath.Join(dir, thumbDir), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, imageDir), 0755); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, maxEntries: maxEntries}
+
+	f, err := os.Open(filepath.Join(dir, logName))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil && e.ID != "" {
+			s.entries = append(s.entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return s, nil
+}
+
+// Add records a capture of img. ID, Time, Width and Height are filled in
+// when unset. A PNG copy of img is kept when e has no Path
+func (s *Store) Add(img image.Image, e Entry) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.ID == "" {
+		e.ID = s.newID(e.Time)
+	}
+	if e.Width == 0 && e.Height == 0 {
+		e.Width, e.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	thumb, err := imaging.PreviewJPEG(img, ThumbnailSize, thumbnailQuality)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to make thumbnail: %w", err)
+	}
+	if err := os.WriteFile(s.thumbPath(e.ID), thumb, 0644); err != nil {
+		return Entry{}, err
+	}
+	if e.Path == "" {
+		var buf bytes.Buffer
+		enc := png.Encoder{CompressionLevel: png.BestSpeed}
+		if err := enc.Encode(&buf, img); err != nil {
+			return Entry{}, err
+		}
+		if err := os.WriteFile(s.imagePath(e.ID), buf.Bytes(), 0644); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, logName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return Entry{}, err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to write history: %w", err)
+	}
+	s.entries = append(s.entries, e)
+
+	if len(s.entries) > s.maxEntries {
+		for _, old := range s.entries[:len(s.entries)-s.maxEntries] {
+			s.removeFiles(old.ID)
+		}
+		s.entries = slices.Clone(s.entries[len(s.entries)-s.maxEntries:])
+		if err := s.rewrite(); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// List returns all entries, newest first
+func (s *Store) List() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := slices.Clone(s.entries)
+	slices.Reverse(list)
+	return list
+}
+
+// Search returns the entries, newest first, whose file name, URL, source,
+// app or window title contain every word of query, ignoring case. An empty
+// query matches everything
+func (s *Store) Search(query string) []Entry {
+	words := strings.Fields(strings.ToLower(query))
+	var found []Entry
+	for _, e := range s.List() {
+		text := strings.ToLower(strings.Join([]string{filepath.Base(e.Path), e.URL, e.Source, e.App, e.Title}, "\n"))
+		if !slices.ContainsFunc(words, func(w string) bool { return !strings.Contains(text, w) }) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Get returns an entry by ID
+func (s *Store) Get(id string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.find(id); i >= 0 {
+		return s.entries[i], nil
+	}
+	return Entry{}, ErrNotFound
+}
+
+// SetURL records a new upload link for an entry
+func (s *Store) SetURL(id, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.find(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.entries[i].URL = url
+	return s.rewrite()
+}
+
+// Delete removes an entry with its thumbnail and stored copy. The saved
+// capture file, if any, is left alone
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.find(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.removeFiles(id)
+	s.entries = slices.Delete(s.entries, i, i+1)
+	return s.rewrite()
+}
+
+// Thumbnail returns the JPEG thumbnail of an entry
+func (s *Store) Thumbnail(id string) ([]byte, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(s.thumbPath(id))
+}
+
+// Image decodes the full capture of an entry, from its saved file or from
+// the stored copy when it was never saved
+func (s *Store) Image(id string) (image.Image, error) {
+	e, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	path := e.Path
+	if path == "" {
+		path = s.imagePath(id)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("capture is no longer available: %w", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// newID returns a unique, time ordered ID. Callers hold s.mu
+func (s *Store) newID(t time.Time) string {
+	n := t.UnixNano()
+	for {
+		id := strconv.FormatInt(n, 36)
+		if s.find(id) < 0 {
+			return id
+		}
+		n++
+	}
+}
+
+// find returns the index of an entry, or -1. Callers hold s.mu
+func (s *Store) find(id string) int {
+	return slices.IndexFunc(s.entries, func(e Entry) bool { return e.ID == id })
+}
+
+// rewrite replaces the log with the current entries atomically. Callers hold
+// s.mu
+func (s *Store) rewrite() error {
+	var buf bytes.Buffer
+	for _, e := range s.entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := filepath.Join(s.dir, logName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// removeFiles deletes the thumbnail and stored copy of an entry
+func (s *Store) removeFiles(id string) {
+	os.Remove(s.thumbPath(id))
+	os.Remove(s.imagePath(id))
+}
+
+func (s *Store) thumbPath(id string) string {
+	return filepath.Join(s.dir, thumbDir, id+".jpg")
+}
+
+func (s *Store) imagePath(id string) string {
+	return filepath.Join(s.dir, imageDir, id+".png")
+}
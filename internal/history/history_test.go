@@ -0,0 +1,145 @@
+package history
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestStore_AddListReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := filepath.Join(dir, "shot.png")
+	f, _ := os.Create(saved)
+	png.Encode(f, testImage(color.RGBA{255, 0, 0, 255}))
+	f.Close()
+
+	first, err := s.Add(testImage(color.RGBA{255, 0, 0, 255}), Entry{Path: saved, Source: "region", App: "notepad.exe"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	second, err := s.Add(testImage(color.RGBA{0, 0, 255, 255}), Entry{Source: "fullscreen", Title: "Build failed - Jenkins"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if first.Width != 40 || first.Height != 20 {
+		t.Errorf("size = %dx%d, want 40x20", first.Width, first.Height)
+	}
+	if first.ID == "" || first.ID == second.ID {
+		t.Errorf("IDs %q and %q are not unique", first.ID, second.ID)
+	}
+
+	s, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := s.List()
+	if len(list) != 2 || list[0].ID != second.ID {
+		t.Fatalf("List() = %+v, want the two entries newest first", list)
+	}
+	if thumb, err := s.Thumbnail(first.ID); err != nil || len(thumb) == 0 {
+		t.Errorf("Thumbnail() = %d bytes, %v", len(thumb), err)
+	}
+
+	// A capture that was never saved is decoded from the stored copy
+	img, err := s.Image(second.ID)
+	if err != nil {
+		t.Fatalf("Image() error = %v", err)
+	}
+	if _, _, b, _ := img.At(0, 0).RGBA(); b != 0xffff {
+		t.Error("stored copy is not the captured image")
+	}
+	if _, err := s.Image(first.ID); err != nil {
+		t.Errorf("Image() of a saved capture error = %v", err)
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	s, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Add(testImage(color.RGBA{A: 255}), Entry{Source: "region", App: "chrome.exe", Title: "Build failed - Jenkins"})
+	s.Add(testImage(color.RGBA{A: 255}), Entry{Source: "window", App: "notepad.exe", URL: "https://example.com/a.png"})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 2},
+		{"jenkins", 1},
+		{"BUILD chrome", 1},
+		{"build notepad", 0},
+		{"example.com", 1},
+	}
+	for _, tt := range tests {
+		if got := len(s.Search(tt.query)); got != tt.want {
+			t.Errorf("Search(%q) = %d entries, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestStore_DeleteAndSetURL(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := s.Add(testImage(color.RGBA{A: 255}), Entry{Source: "region"})
+
+	if err := s.SetURL(e.ID, "https://example.com/x"); err != nil {
+		t.Fatalf("SetURL() error = %v", err)
+	}
+	s, _ = Open(dir, 0)
+	if got, _ := s.Get(e.ID); got.URL != "https://example.com/x" {
+		t.Errorf("URL after reopen = %q", got.URL)
+	}
+
+	if err := s.Delete(e.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get(e.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete error = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, imageDir, e.ID+".png")); !os.IsNotExist(err) {
+		t.Error("stored copy was not deleted")
+	}
+	if err := s.Delete(e.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldest, _ := s.Add(testImage(color.RGBA{A: 255}), Entry{Source: "a"})
+	s.Add(testImage(color.RGBA{A: 255}), Entry{Source: "b"})
+	s.Add(testImage(color.RGBA{A: 255}), Entry{Source: "c"})
+
+	list := s.List()
+	if len(list) != 2 || list[1].Source != "b" {
+		t.Fatalf("List() = %+v, want b and c", list)
+	}
+	if _, err := os.Stat(filepath.Join(dir, thumbDir, oldest.ID+".jpg")); !os.IsNotExist(err) {
+		t.Error("pruned thumbnail was not deleted")
+	}
+}
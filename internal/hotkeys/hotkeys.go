@@ -1,6 +1,7 @@
 package hotkeys
 
 import (
+	"fmt"
 	"runtime"
 	"strings"
 	"sync"
@@ -17,6 +18,11 @@ var (
 	procPeekMessageW    = user32.NewProc("PeekMessageW")
 )
 
+// RegisterHotKey fails with this when the combination is taken. Hotkeys are
+// scoped to the session's desktop, so the holder is another program or
+// another account running WinShot in the same session
+const errHotkeyAlreadyRegistered syscall.Errno = 1409
+
 // Key modifier constants
 const (
 	ModAlt   uint = 0x0001
@@ -53,6 +59,10 @@ const (
 	HotkeyFullscreen = 1
 	HotkeyRegion     = 2
 	HotkeyWindow     = 3
+	HotkeyText       = 4   // Region capture copied as recognized text
+	HotkeyColor      = 5   // Screen color picker
+	HotkeyRepeat     = 6   // Capture the last selected region again
+	HotkeyScriptBase = 100 // Scripts register HotkeyScriptBase + their index
 )
 
 // MSG structure for Windows messages
@@ -154,6 +164,9 @@ func (m *HotkeyManager) registerOnThread(id int, modifiers, keyCode uint) error
 	)
 
 	if ret == 0 {
+		if err == errHotkeyAlreadyRegistered {
+			return fmt.Errorf("%s is already in use by another program in this session", FormatHotkey(modifiers, keyCode))
+		}
 		return err
 	}
 
@@ -438,6 +451,14 @@ var keyNameToCode = map[string]uint{
 	"DOWN":      0x28,
 	"LEFT":      0x25,
 	"RIGHT":     0x27,
+	// Punctuation and media keys, used to step video players
+	"PERIOD":         0xBE,
+	".":              0xBE,
+	"COMMA":          0xBC,
+	",":              0xBC,
+	"MEDIAPLAYPAUSE": 0xB3,
+	"MEDIANEXT":      0xB0,
+	"MEDIAPREV":      0xB1,
 }
 
 // ParseHotkeyString parses a hotkey string like "Ctrl+Shift+PrintScreen" into modifiers and key code
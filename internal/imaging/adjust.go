@@ -0,0 +1,123 @@
+// Package imaging provides pixel transforms applied to screenshots before export
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Adjustments holds tonal adjustment settings
+type Adjustments struct {
+	Brightness int     `json:"brightness"` // -100..100, 0 = unchanged
+	Contrast   int     `json:"contrast"`   // -100..100, 0 = unchanged
+	Gamma      float64 `json:"gamma"`      // 0.1..5.0, 1.0 = unchanged (0 treated as 1.0)
+	Grayscale  bool    `json:"grayscale"`
+}
+
+// IsIdentity reports whether the adjustments leave the image unchanged
+func (a Adjustments) IsIdentity() bool {
+	return a.Brightness == 0 && a.Contrast == 0 && (a.Gamma == 0 || a.Gamma == 1) && !a.Grayscale
+}
+
+// Adjust applies tonal adjustments and returns a new RGBA image.
+// Alpha is preserved; color channels are mapped through a lookup table.
+func Adjust(img image.Image, adj Adjustments) *image.RGBA {
+	src := ToRGBA(img)
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+
+	lut := buildLUT(adj)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		srcRow := src.Pix[(y-bounds.Min.Y)*src.Stride:]
+		dstRow := dst.Pix[(y-bounds.Min.Y)*dst.Stride:]
+		for x := 0; x < bounds.Dx(); x++ {
+			i := x * 4
+			r, g, b, a := srcRow[i], srcRow[i+1], srcRow[i+2], srcRow[i+3]
+
+			if adj.Grayscale {
+				// Rec. 601 luma, computed on premultiplied values so alpha stays consistent
+				luma := uint8((299*uint32(r) + 587*uint32(g) + 114*uint32(b) + 500) / 1000)
+				r, g, b = luma, luma, luma
+			}
+
+			if a == 255 {
+				r, g, b = lut[r], lut[g], lut[b]
+			} else if a > 0 {
+				// Adjust straight (un-premultiplied) color, then premultiply again
+				r = premul(lut[unpremul(r, a)], a)
+				g = premul(lut[unpremul(g, a)], a)
+				b = premul(lut[unpremul(b, a)], a)
+			}
+
+			dstRow[i], dstRow[i+1], dstRow[i+2], dstRow[i+3] = r, g, b, a
+		}
+	}
+
+	return dst
+}
+
+// buildLUT precomputes the brightness/contrast/gamma curve for all 256 levels
+func buildLUT(adj Adjustments) [256]uint8 {
+	brightness := float64(clamp(adj.Brightness, -100, 100)) / 100.0
+	contrast := float64(clamp(adj.Contrast, -100, 100)) / 100.0
+
+	gamma := adj.Gamma
+	if gamma <= 0 {
+		gamma = 1
+	}
+	gamma = math.Max(0.1, math.Min(5.0, gamma))
+
+	// Contrast factor: -100 flattens to mid-grey, +100 quadruples the slope
+	contrastFactor := 1 + contrast
+	if contrast > 0 {
+		contrastFactor = 1 / (1 - 0.75*contrast)
+	}
+
+	var lut [256]uint8
+	for i := 0; i < 256; i++ {
+		v := float64(i) / 255.0
+
+		// Gamma first so brightness/contrast operate on the perceptual curve
+		v = math.Pow(v, 1/gamma)
+		v = (v-0.5)*contrastFactor + 0.5
+		v += brightness
+
+		lut[i] = uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return lut
+}
+
+// ToRGBA converts an image.Image to *image.RGBA, returning the input if it already is one
+func ToRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	return rgba
+}
+
+func premul(c, a uint8) uint8 {
+	return uint8((uint32(c)*uint32(a) + 127) / 255)
+}
+
+func unpremul(c, a uint8) uint8 {
+	v := (uint32(c)*255 + uint32(a)/2) / uint32(a)
+	if v > 255 {
+		v = 255
+	}
+	return uint8(v)
+}
+
+func clamp(val, min, max int) int {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
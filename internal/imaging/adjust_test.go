@@ -0,0 +1,110 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestAdjustments_IsIdentity(t *testing.T) {
+	tests := []struct {
+		name string
+		adj  Adjustments
+		want bool
+	}{
+		{"zero value", Adjustments{}, true},
+		{"gamma one", Adjustments{Gamma: 1}, true},
+		{"brightness", Adjustments{Brightness: 10}, false},
+		{"contrast", Adjustments{Contrast: -5}, false},
+		{"gamma", Adjustments{Gamma: 2.2}, false},
+		{"grayscale", Adjustments{Grayscale: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.adj.IsIdentity(); got != tt.want {
+				t.Errorf("IsIdentity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjust_Identity(t *testing.T) {
+	src := solidImage(color.RGBA{R: 10, G: 128, B: 250, A: 255})
+	got := Adjust(src, Adjustments{})
+
+	if got.RGBAAt(1, 1) != src.RGBAAt(1, 1) {
+		t.Errorf("identity adjust changed pixel: got %v, want %v", got.RGBAAt(1, 1), src.RGBAAt(1, 1))
+	}
+	if got == src {
+		t.Error("Adjust should return a new image")
+	}
+}
+
+func TestAdjust_Brightness(t *testing.T) {
+	src := solidImage(color.RGBA{R: 40, G: 40, B: 40, A: 255})
+
+	brighter := Adjust(src, Adjustments{Brightness: 50}).RGBAAt(0, 0)
+	if brighter.R <= 40 {
+		t.Errorf("Brightness +50: R = %d, want > 40", brighter.R)
+	}
+
+	darker := Adjust(src, Adjustments{Brightness: -100}).RGBAAt(0, 0)
+	if darker.R != 0 {
+		t.Errorf("Brightness -100: R = %d, want 0", darker.R)
+	}
+}
+
+func TestAdjust_ContrastFlattensToGrey(t *testing.T) {
+	src := solidImage(color.RGBA{R: 0, G: 255, B: 30, A: 255})
+	got := Adjust(src, Adjustments{Contrast: -100}).RGBAAt(0, 0)
+
+	for _, c := range []uint8{got.R, got.G, got.B} {
+		if c < 127 || c > 128 {
+			t.Errorf("Contrast -100: channel = %d, want mid-grey", c)
+		}
+	}
+}
+
+func TestAdjust_GammaLightensShadows(t *testing.T) {
+	src := solidImage(color.RGBA{R: 32, G: 32, B: 32, A: 255})
+	got := Adjust(src, Adjustments{Gamma: 2.2}).RGBAAt(0, 0)
+
+	if got.R <= 32 {
+		t.Errorf("Gamma 2.2: R = %d, want > 32", got.R)
+	}
+}
+
+func TestAdjust_Grayscale(t *testing.T) {
+	src := solidImage(color.RGBA{R: 255, G: 0, B: 0, A: 255})
+	got := Adjust(src, Adjustments{Grayscale: true}).RGBAAt(0, 0)
+
+	if got.R != got.G || got.G != got.B {
+		t.Errorf("Grayscale produced non-grey pixel %v", got)
+	}
+	if got.R != 76 {
+		t.Errorf("Grayscale luma of pure red = %d, want 76", got.R)
+	}
+}
+
+func TestAdjust_PreservesAlpha(t *testing.T) {
+	src := solidImage(color.RGBA{R: 50, G: 50, B: 50, A: 100})
+	got := Adjust(src, Adjustments{Brightness: 30}).RGBAAt(0, 0)
+
+	if got.A != 100 {
+		t.Errorf("alpha = %d, want 100", got.A)
+	}
+	if got.R > got.A {
+		t.Errorf("premultiplied R = %d exceeds alpha %d", got.R, got.A)
+	}
+}
@@ -0,0 +1,162 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Annotation defaults matching the editor's palette
+var (
+	DefaultArrowColor     = color.RGBA{255, 59, 48, 255}
+	DefaultHighlightColor = color.RGBA{255, 235, 59, 102}
+)
+
+// DrawArrow draws a straight arrow from one point to another with its head at
+// to. The head scales with the line width.
+func DrawArrow(img *image.RGBA, from, to image.Point, width int, c color.Color) {
+	if width < 1 {
+		width = 1
+	}
+	dx, dy := float64(to.X-from.X), float64(to.Y-from.Y)
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	ux, uy := dx/length, dy/length // Along the arrow
+	nx, ny := -uy, ux              // Across it
+
+	headLen := math.Min(float64(width)*4+6, length)
+	headHalf := headLen * 0.6
+	shaftEnd := length - headLen*0.8 // Overlap the head so no gap shows
+
+	at := func(along, across float64) image.Point {
+		return image.Pt(
+			int(math.Round(float64(from.X)+ux*along+nx*across)),
+			int(math.Round(float64(from.Y)+uy*along+ny*across)),
+		)
+	}
+	half := float64(width) / 2
+	fillPolygon(img, []image.Point{at(0, -half), at(shaftEnd, -half), at(shaftEnd, half), at(0, half)}, c)
+	fillPolygon(img, []image.Point{to, at(length-headLen, -headHalf), at(length-headLen, headHalf)}, c)
+}
+
+// Highlight tints r with a translucent color, like a highlighter pen
+func Highlight(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r.Intersect(img.Bounds()), image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// BlurRect blurs r beyond recognition. The radius scales with the region so
+// text of any size becomes unreadable. Three box blur passes approximate a
+// gaussian.
+func BlurRect(img *image.RGBA, r image.Rectangle) {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return
+	}
+	radius := max(4, min(r.Dx(), r.Dy())/4)
+	region := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(region, region.Bounds(), img, r.Min, draw.Src)
+	for i := 0; i < 3; i++ {
+		boxBlur(region, radius, true)
+		boxBlur(region, radius, false)
+	}
+	draw.Draw(img, r, region, image.Point{}, draw.Src)
+}
+
+// PixelateRect replaces r with large flat blocks of its average colors. The
+// blocks scale with the region so small text cannot be read back.
+func PixelateRect(img *image.RGBA, r image.Rectangle) {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return
+	}
+	block := max(8, min(r.Dx(), r.Dy())/8)
+	for y := r.Min.Y; y < r.Max.Y; y += block {
+		for x := r.Min.X; x < r.Max.X; x += block {
+			b := image.Rect(x, y, x+block, y+block).Intersect(r)
+			var sum [4]int
+			for by := b.Min.Y; by < b.Max.Y; by++ {
+				row := img.Pix[img.PixOffset(b.Min.X, by):img.PixOffset(b.Max.X, by)]
+				for i := 0; i < len(row); i += 4 {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[i+c])
+					}
+				}
+			}
+			n := b.Dx() * b.Dy()
+			avg := color.RGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), uint8(sum[3] / n)}
+			draw.Draw(img, b, image.NewUniform(avg), image.Point{}, draw.Src)
+		}
+	}
+}
+
+// boxBlur averages each pixel with its neighbors within radius along rows, or
+// along columns when horizontal is false. Edges repeat the border pixel.
+func boxBlur(img *image.RGBA, radius int, horizontal bool) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	lines, length, step := h, w, 4
+	if !horizontal {
+		lines, length, step = w, h, img.Stride
+	}
+	line := make([]uint8, length*4)
+	window := 2*radius + 1
+
+	for l := 0; l < lines; l++ {
+		start := l * img.Stride
+		if !horizontal {
+			start = l * 4
+		}
+		for i := 0; i < length; i++ {
+			copy(line[i*4:i*4+4], img.Pix[start+i*step:start+i*step+4])
+		}
+
+		var sum [4]int
+		for i := -radius; i <= radius; i++ {
+			p := clamp(i, 0, length-1) * 4
+			for c := 0; c < 4; c++ {
+				sum[c] += int(line[p+c])
+			}
+		}
+		for i := 0; i < length; i++ {
+			o := start + i*step
+			for c := 0; c < 4; c++ {
+				img.Pix[o+c] = uint8(sum[c] / window)
+			}
+			out := clamp(i-radius, 0, length-1) * 4
+			in := clamp(i+radius+1, 0, length-1) * 4
+			for c := 0; c < 4; c++ {
+				sum[c] += int(line[in+c]) - int(line[out+c])
+			}
+		}
+	}
+}
+
+// AddCaption returns img with a strip below it holding text, so the caption
+// never covers the capture
+func AddCaption(img image.Image, text string, fg, bg color.Color) *image.RGBA {
+	b := img.Bounds()
+	strip := LabelSize(text).Y
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()+strip))
+	draw.Draw(out, out.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(0, 0, b.Dx(), b.Dy()), img, b.Min, draw.Src)
+	DrawLabel(out, text, image.Pt(0, b.Dy()), fg, bg)
+	return out
+}
+
+// fillPolygon fills the closed polygon pts with c
+func fillPolygon(img *image.RGBA, pts []image.Point, c color.Color) {
+	src := image.NewUniform(c)
+	bounds := img.Bounds()
+	minY, maxY := pts[0].Y, pts[0].Y
+	for _, p := range pts {
+		minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+	}
+	for y := max(minY, bounds.Min.Y); y < min(maxY+1, bounds.Max.Y); y++ {
+		for _, span := range PolygonSpans(pts, y) {
+			r := image.Rect(span[0], y, span[1], y+1).Intersect(bounds)
+			draw.Draw(img, r, src, image.Point{}, draw.Over)
+		}
+	}
+}
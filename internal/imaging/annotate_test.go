@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDrawArrow(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 60, 20))
+	red := color.RGBA{255, 0, 0, 255}
+	DrawArrow(img, image.Pt(5, 10), image.Pt(55, 10), 2, red)
+
+	tests := []struct {
+		pt   image.Point
+		want color.RGBA
+	}{
+		{image.Pt(6, 10), red},           // Shaft start
+		{image.Pt(30, 10), red},          // Middle of the shaft
+		{image.Pt(50, 10), red},          // Head
+		{image.Pt(44, 6), red},           // Head is wider than the shaft
+		{image.Pt(20, 6), color.RGBA{}},  // Beside the shaft
+		{image.Pt(58, 10), color.RGBA{}}, // Past the tip
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.pt.X, tt.pt.Y); got != tt.want {
+			t.Errorf("pixel %v = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+
+	// A zero-length arrow draws nothing
+	DrawArrow(img, image.Pt(2, 2), image.Pt(2, 2), 2, red)
+	if got := img.RGBAAt(2, 2); got != (color.RGBA{}) {
+		t.Errorf("zero-length arrow drew %v", got)
+	}
+}
+
+func TestBlurRect(t *testing.T) {
+	// Black and white stripes, like text, blur to a uniform grey
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			if x%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	BlurRect(img, image.Rect(10, 10, 30, 30))
+
+	a, b := img.RGBAAt(19, 20), img.RGBAAt(20, 20)
+	if diff := int(a.R) - int(b.R); diff > 8 || diff < -8 {
+		t.Errorf("neighbouring blurred pixels %v and %v still differ", a, b)
+	}
+	if got := img.RGBAAt(5, 5); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("pixel outside the region = %v, want it untouched", got)
+	}
+	if got := img.RGBAAt(20, 20); got.A != 255 {
+		t.Errorf("blurred pixel alpha = %d, want 255", got.A)
+	}
+
+	// Regions past the edge are clipped
+	BlurRect(img, image.Rect(35, 35, 80, 80))
+}
+
+func TestPixelateRect(t *testing.T) {
+	// A gradient becomes flat blocks of at least 8 pixels
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 6), uint8(y * 6), 0, 255})
+		}
+	}
+	PixelateRect(img, image.Rect(8, 8, 32, 32))
+
+	if a, b := img.RGBAAt(8, 8), img.RGBAAt(15, 15); a != b {
+		t.Errorf("pixels in one block = %v and %v, want the same", a, b)
+	}
+	if a, b := img.RGBAAt(15, 15), img.RGBAAt(16, 16); a == b {
+		t.Errorf("pixels in neighbouring blocks are both %v, want them to differ", a)
+	}
+	if got, want := img.RGBAAt(4, 4), (color.RGBA{24, 24, 0, 255}); got != want {
+		t.Errorf("pixel outside the region = %v, want it untouched", got)
+	}
+
+	// Regions past the edge are clipped
+	PixelateRect(img, image.Rect(35, 35, 80, 80))
+}
+
+func TestAddCaption(t *testing.T) {
+	src := solidImage(color.RGBA{0, 0, 255, 255})
+	src = src.SubImage(image.Rect(1, 1, 4, 4)).(*image.RGBA)
+	bg := color.RGBA{20, 20, 20, 255}
+
+	out := AddCaption(src, "Build #123", color.RGBA{255, 255, 255, 255}, bg)
+	if got, want := out.Bounds().Size(), image.Pt(3, 3+LabelSize("Build #123").Y); got != want {
+		t.Errorf("size = %v, want %v", got, want)
+	}
+	if got := out.RGBAAt(0, 0); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("capture pixel = %v, want it kept", got)
+	}
+	if got := out.RGBAAt(0, 3); got != bg {
+		t.Errorf("caption strip = %v, want the background", got)
+	}
+}
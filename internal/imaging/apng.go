@@ -0,0 +1,305 @@
+package imaging
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"time"
+)
+
+// DefaultFrameDelay is how long each APNG frame shows when no delay is set
+const DefaultFrameDelay = 100 * time.Millisecond
+
+// maxFrameDelay is the longest delay an fcTL chunk holds in milliseconds
+const maxFrameDelay = 65535 * time.Millisecond
+
+// APNGOptions controls EncodeAPNG
+type APNGOptions struct {
+	Delay  time.Duration   // How long each frame shows, DefaultFrameDelay if zero
+	Delays []time.Duration // Per-frame delays, overriding Delay where set and non-zero
+	Loops  int             // Times to play the animation, 0 loops forever
+}
+
+// delay returns how long frame i shows, clamped to what APNG can store
+func (o APNGOptions) delay(i int) time.Duration {
+	d := o.Delay
+	if i < len(o.Delays) && o.Delays[i] > 0 {
+		d = o.Delays[i]
+	}
+	if d <= 0 {
+		d = DefaultFrameDelay
+	}
+	return min(d, maxFrameDelay)
+}
+
+// apngFrame is one frame as stored: the changed area of the canvas and how
+// long it shows
+type apngFrame struct {
+	img   *image.RGBA
+	rect  image.Rectangle // Within the canvas
+	delay time.Duration
+}
+
+// EncodeAPNG writes frames as an animated PNG, which keeps full 8-bit RGBA
+// color where GIF is limited to 256 colors, and plays in all modern
+// browsers. Viewers without APNG support show the first frame. Frames must
+// all be the size of the first. After the first frame, only the area that
+// changed is stored, and frames identical to the previous one extend its
+// delay instead, so bursts of mostly still UI stay small
+func EncodeAPNG(w io.Writer, frames []image.Image, opts APNGOptions) error {
+	stored, err := apngFrames(frames, opts)
+	if err != nil {
+		return err
+	}
+	size := stored[0].rect.Size()
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(size.X))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(size.Y))
+	ihdr[8] = 8 // Bit depth
+	ihdr[9] = 6 // Truecolor with alpha
+	writeChunk(&buf, "IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(stored)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(max(opts.Loops, 0)))
+	writeChunk(&buf, "acTL", actl)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	// Sequence numbers run across fcTL and fdAT chunks
+	var seq uint32
+	for i, f := range stored {
+		buf.Reset()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(f.rect.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(f.rect.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], uint32(f.rect.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:], uint32(f.rect.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(f.delay.Milliseconds()))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		fctl[24] = 0 // APNG_DISPOSE_OP_NONE, the next frame draws over this one
+		fctl[25] = 0 // APNG_BLEND_OP_SOURCE, changed pixels replace the canvas
+		writeChunk(&buf, "fcTL", fctl)
+		seq++
+
+		data, err := compressRGBA(f.img)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			writeChunk(&buf, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			writeChunk(&buf, "fdAT", append(fdat, data...))
+			seq++
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	buf.Reset()
+	writeChunk(&buf, "IEND", nil)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// EstimateAPNGSize predicts the size in bytes of EncodeAPNG's output from
+// the first frame and up to samples evenly spaced later frames, which is
+// much faster than encoding a long burst
+func EstimateAPNGSize(frames []image.Image, opts APNGOptions, samples int) (int64, error) {
+	stored, err := apngFrames(frames, opts)
+	if err != nil {
+		return 0, err
+	}
+	first, err := compressRGBA(stored[0].img)
+	if err != nil {
+		return 0, err
+	}
+	// Signature, IHDR, acTL, IEND and the first frame's fcTL and IDAT
+	total := int64(8+25+20+12+38+12) + int64(len(first))
+	rest := stored[1:]
+	if len(rest) == 0 {
+		return total, nil
+	}
+
+	samples = min(max(samples, 1), len(rest))
+	var sampled int64
+	for i := 0; i < samples; i++ {
+		f := rest[i*len(rest)/samples]
+		data, err := compressRGBA(f.img)
+		if err != nil {
+			return 0, err
+		}
+		sampled += int64(len(data))
+	}
+	// Each later frame adds an fcTL and an fdAT chunk
+	return total + (sampled/int64(samples)+38+16)*int64(len(rest)), nil
+}
+
+// apngFrames works out what EncodeAPNG stores for each frame
+func apngFrames(frames []image.Image, opts APNGOptions) ([]apngFrame, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("no frames to encode")
+	}
+	size := frames[0].Bounds().Size()
+	if size.X == 0 || size.Y == 0 {
+		return nil, errors.New("frames are empty")
+	}
+
+	var stored []apngFrame
+	var prev *image.RGBA
+	for i, f := range frames {
+		if f.Bounds().Size() != size {
+			return nil, fmt.Errorf("frame %d is %v, want %v like the first", i+1, f.Bounds().Size(), size)
+		}
+		img := ToRGBA(f)
+		delay := opts.delay(i)
+		if prev == nil {
+			stored = append(stored, apngFrame{img: img, rect: image.Rect(0, 0, size.X, size.Y), delay: delay})
+			prev = img
+			continue
+		}
+
+		changed := changedRect(prev, img)
+		if changed.Empty() {
+			last := &stored[len(stored)-1]
+			last.delay = min(last.delay+delay, maxFrameDelay)
+			continue
+		}
+		sub := img.SubImage(changed.Add(img.Rect.Min)).(*image.RGBA)
+		stored = append(stored, apngFrame{img: sub, rect: changed, delay: delay})
+		prev = img
+	}
+	return stored, nil
+}
+
+// changedRect returns the bounding box of the pixels that differ between two
+// images of the same size, relative to their top-left corners
+func changedRect(a, b *image.RGBA) image.Rectangle {
+	w, h := a.Rect.Dx(), a.Rect.Dy()
+	changed := image.Rectangle{}
+	for y := 0; y < h; y++ {
+		rowA := a.Pix[a.PixOffset(a.Rect.Min.X, a.Rect.Min.Y+y):][:w*4]
+		rowB := b.Pix[b.PixOffset(b.Rect.Min.X, b.Rect.Min.Y+y):][:w*4]
+		if bytes.Equal(rowA, rowB) {
+			continue
+		}
+		x0 := 0
+		for x0 < w && bytes.Equal(rowA[x0*4:x0*4+4], rowB[x0*4:x0*4+4]) {
+			x0++
+		}
+		x1 := w
+		for x1 > x0 && bytes.Equal(rowA[x1*4-4:x1*4], rowB[x1*4-4:x1*4]) {
+			x1--
+		}
+		changed = changed.Union(image.Rect(x0, y, x1, y+1))
+	}
+	return changed
+}
+
+// compressRGBA returns the zlib-compressed, filtered scanlines of img as 8-bit
+// RGBA, the payload of an IDAT or fdAT chunk. Each row uses the filter with
+// the smallest sum of absolute values, like image/png
+func compressRGBA(img *image.RGBA) ([]byte, error) {
+	r := img.Rect
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	n := r.Dx() * 4
+	prior := make([]byte, n) // Row above, zero for the first row
+	filtered := make([][]byte, 5)
+	for i := range filtered {
+		filtered[i] = make([]byte, n+1)
+		filtered[i][0] = byte(i)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):][:n]
+		best, bestSum := 0, -1
+		for ft := range filtered {
+			out := filtered[ft][1:]
+			sum := 0
+			for i := 0; i < n; i++ {
+				var left, upLeft byte
+				if i >= 4 {
+					left, upLeft = row[i-4], prior[i-4]
+				}
+				up := prior[i]
+				var v byte
+				switch ft {
+				case 0:
+					v = row[i]
+				case 1:
+					v = row[i] - left
+				case 2:
+					v = row[i] - up
+				case 3:
+					v = row[i] - byte((int(left)+int(up))/2)
+				case 4:
+					v = row[i] - paeth(left, up, upLeft)
+				}
+				out[i] = v
+				sum += abs(int(int8(v)))
+			}
+			if bestSum < 0 || sum < bestSum {
+				best, bestSum = ft, sum
+			}
+		}
+		if _, err := zw.Write(filtered[best]); err != nil {
+			return nil, err
+		}
+		prior = row
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// paeth is the PNG Paeth predictor
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// writeChunk appends a PNG chunk with its length and CRC
+func writeChunk(buf *bytes.Buffer, name string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	buf.Write(n[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(name))
+	crc.Write(data)
+	buf.WriteString(name)
+	buf.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	buf.Write(n[:])
+}
@@ -0,0 +1,128 @@
+package imaging
+
+import (
+	"image"
+	"math"
+)
+
+// maxScorePixels bounds the pixels read per frame when scoring, so large
+// regions are sampled on a coarser grid
+const maxScorePixels = 1 << 20
+
+// FrameScore rates one frame of a burst for BestFrame
+type FrameScore struct {
+	Sharpness float64 `json:"sharpness"` // Variance of the luma Laplacian, higher is crisper
+	Motion    float64 `json:"motion"`    // Mean luma change to the closer neighbor frame, 0-1
+	Score     float64 `json:"score"`     // 0-1 combining both, higher is better
+}
+
+// ScoreFrames rates frames of the same region captured in sequence. A frame
+// scores well when it is sharp and settled: a frame caught mid-animation,
+// such as a fading tooltip or a half-drawn menu, differs from both its
+// neighbors, while a settled one matches at least one of them. Sharpness
+// penalizes motion blur and frames caught during a smooth scroll or zoom
+func ScoreFrames(frames []image.Image) []FrameScore {
+	scores := make([]FrameScore, len(frames))
+	if len(frames) == 0 {
+		return scores
+	}
+
+	b := frames[0].Bounds()
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > maxScorePixels {
+		step++
+	}
+	lumas := make([][]float64, len(frames))
+	for i, f := range frames {
+		lumas[i] = sampleLuma(ToRGBA(f), step)
+	}
+	w, h := b.Dx()/step, b.Dy()/step
+
+	var maxSharp, maxMotion float64
+	for i := range frames {
+		scores[i].Sharpness = laplacianVariance(lumas[i], w, h)
+		scores[i].Motion = math.Inf(1)
+		if i > 0 {
+			scores[i].Motion = lumaDiff(lumas[i], lumas[i-1])
+		}
+		if i < len(frames)-1 {
+			scores[i].Motion = min(scores[i].Motion, lumaDiff(lumas[i], lumas[i+1]))
+		}
+		if math.IsInf(scores[i].Motion, 1) {
+			scores[i].Motion = 0 // A single frame has nothing to compare with
+		}
+		maxSharp = max(maxSharp, scores[i].Sharpness)
+		maxMotion = max(maxMotion, scores[i].Motion)
+	}
+
+	for i := range scores {
+		sharp, settled := 1.0, 1.0
+		if maxSharp > 0 {
+			sharp = scores[i].Sharpness / maxSharp
+		}
+		if maxMotion > 0 {
+			settled = 1 - scores[i].Motion/maxMotion
+		}
+		scores[i].Score = sharp * settled
+	}
+	return scores
+}
+
+// BestFrame returns the index of the best scored frame, or -1 if there are
+// none. Ties go to the later frame, since animations end settled
+func BestFrame(scores []FrameScore) int {
+	best := -1
+	for i, s := range scores {
+		if best < 0 || s.Score >= scores[best].Score {
+			best = i
+		}
+	}
+	return best
+}
+
+// sampleLuma reads the luma of every step-th pixel of img in both directions
+func sampleLuma(img *image.RGBA, step int) []float64 {
+	b := img.Bounds()
+	w, h := b.Dx()/step, b.Dy()/step
+	luma := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y*step):]
+		for x := 0; x < w; x++ {
+			p := row[x*step*4:]
+			luma[y*w+x] = 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+		}
+	}
+	return luma
+}
+
+// laplacianVariance is the variance of the 4-neighbor Laplacian, a standard
+// focus measure: sharp edges give large responses, blur flattens them
+func laplacianVariance(luma []float64, w, h int) float64 {
+	if w < 3 || h < 3 {
+		return 0
+	}
+	var sum, sumSq float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			v := luma[i-w] + luma[i+w] + luma[i-1] + luma[i+1] - 4*luma[i]
+			sum += v
+			sumSq += v * v
+		}
+	}
+	n := float64((w - 2) * (h - 2))
+	mean := sum / n
+	return sumSq/n - mean*mean
+}
+
+// lumaDiff is the mean absolute luma difference of two samples, 0-1
+func lumaDiff(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += math.Abs(a[i] - b[i])
+	}
+	return sum / float64(len(a)) / 255
+}
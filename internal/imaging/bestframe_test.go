@@ -0,0 +1,59 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// checkerboard returns a sharp black and white pattern of 4px squares
+func checkerboard() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+func TestScoreFrames_PrefersSharpSettledFrame(t *testing.T) {
+	blurred := checkerboard()
+	BlurRect(blurred, blurred.Bounds())
+
+	// Half drawn, as if caught while a panel was still appearing
+	half := checkerboard()
+	for y := 32; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			half.Set(x, y, color.Gray{128})
+		}
+	}
+
+	frames := []image.Image{blurred, half, checkerboard(), checkerboard()}
+	scores := ScoreFrames(frames)
+	if got := BestFrame(scores); got != 3 {
+		t.Errorf("BestFrame() = %d, want 3 (scores %+v)", got, scores)
+	}
+	if scores[0].Sharpness >= scores[2].Sharpness {
+		t.Errorf("blurred sharpness %v >= sharp %v", scores[0].Sharpness, scores[2].Sharpness)
+	}
+	if scores[1].Motion == 0 {
+		t.Error("half drawn frame has no motion")
+	}
+	if scores[3].Motion != 0 {
+		t.Errorf("settled frame motion = %v, want 0", scores[3].Motion)
+	}
+}
+
+func TestBestFrame_Empty(t *testing.T) {
+	if got := BestFrame(ScoreFrames(nil)); got != -1 {
+		t.Errorf("BestFrame(nil) = %d, want -1", got)
+	}
+	if got := BestFrame(ScoreFrames([]image.Image{checkerboard()})); got != 0 {
+		t.Errorf("BestFrame(one frame) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,47 @@
+package imaging
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"time"
+)
+
+// BurstIndexName is the file ZipFrames writes the index to
+const BurstIndexName = "frames.json"
+
+// BurstFrameName is the name of frame i, counting from 0, in a ZipFrames
+// archive
+func BurstFrameName(i int) string {
+	return fmt.Sprintf("frame_%04d.png", i+1)
+}
+
+// ZipFrames writes frames as numbered PNGs into a zip archive, followed by
+// index as frames.json when it is not nil. PNG data is already compressed,
+// so frames are stored rather than deflated, and encoded for speed since a
+// burst can hold hundreds of frames
+func ZipFrames(w io.Writer, frames []image.Image, index []byte, modified time.Time) error {
+	zw := zip.NewWriter(w)
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	for i, img := range frames {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: BurstFrameName(i), Method: zip.Store, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(fw, img); err != nil {
+			return fmt.Errorf("%s: %w", BurstFrameName(i), err)
+		}
+	}
+	if index != nil {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: BurstIndexName, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(index); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,48 @@
+package imaging
+
+import "image"
+
+// Difference describes how an image differs from a baseline
+type Difference struct {
+	Pixels  int             // Pixels that differ by more than the tolerance
+	Total   int             // Pixels compared
+	Bounds  image.Rectangle // Box around the differing pixels, relative to the top-left corner
+	Resized bool            // The sizes differ, so every pixel counts as changed
+}
+
+// Percent returns the share of pixels that changed, 0-100
+func (d Difference) Percent() float64 {
+	if d.Resized {
+		return 100
+	}
+	if d.Total == 0 {
+		return 0
+	}
+	return float64(d.Pixels) * 100 / float64(d.Total)
+}
+
+// Compare returns how img differs from baseline. A pixel counts as changed
+// when any channel moves by more than tolerance (0-255), so font smoothing
+// and dithering that vary between captures of the same screen don't count
+func Compare(baseline, img image.Image, tolerance int) Difference {
+	a, b := ToRGBA(baseline), ToRGBA(img)
+	w, h := a.Rect.Dx(), a.Rect.Dy()
+	if w != b.Rect.Dx() || h != b.Rect.Dy() {
+		return Difference{Resized: true, Total: b.Rect.Dx() * b.Rect.Dy(), Bounds: image.Rect(0, 0, b.Rect.Dx(), b.Rect.Dy())}
+	}
+	d := Difference{Total: w * h}
+	for y := 0; y < h; y++ {
+		rowA := a.Pix[a.PixOffset(a.Rect.Min.X, a.Rect.Min.Y+y):][:w*4]
+		rowB := b.Pix[b.PixOffset(b.Rect.Min.X, b.Rect.Min.Y+y):][:w*4]
+		for x := 0; x < w; x++ {
+			for c := x * 4; c < x*4+4; c++ {
+				if abs(int(rowA[c])-int(rowB[c])) > tolerance {
+					d.Pixels++
+					d.Bounds = d.Bounds.Union(image.Rect(x, y, x+1, y+1))
+					break
+				}
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,39 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	baseline := solidImage(color.RGBA{200, 200, 200, 255})
+
+	same := solidImage(color.RGBA{204, 198, 200, 255})
+	if d := Compare(baseline, same, 8); d.Pixels != 0 || d.Percent() != 0 {
+		t.Errorf("Compare() within tolerance = %+v, want no change", d)
+	}
+
+	changed := solidImage(color.RGBA{200, 200, 200, 255})
+	changed.SetRGBA(1, 2, color.RGBA{0, 0, 0, 255})
+	changed.SetRGBA(3, 3, color.RGBA{200, 200, 255, 255})
+	d := Compare(baseline, changed, 8)
+	if d.Pixels != 2 || d.Total != 16 || d.Bounds != image.Rect(1, 2, 4, 4) {
+		t.Errorf("Compare() = %+v, want 2 of 16 pixels in (1,2)-(4,4)", d)
+	}
+	if d.Percent() != 12.5 {
+		t.Errorf("Percent() = %v, want 12.5", d.Percent())
+	}
+
+	// Offset bounds compare from the top-left corner
+	moved := solidImage(color.RGBA{200, 200, 200, 255})
+	moved.Rect = moved.Rect.Add(image.Pt(10, 10))
+	if d := Compare(baseline, moved, 0); d.Pixels != 0 {
+		t.Errorf("Compare() of offset images = %+v, want no change", d)
+	}
+
+	resized := image.NewRGBA(image.Rect(0, 0, 4, 5))
+	if d := Compare(baseline, resized, 255); !d.Resized || d.Percent() != 100 {
+		t.Errorf("Compare() of resized image = %+v, want resized", d)
+	}
+}
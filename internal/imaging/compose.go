@@ -0,0 +1,165 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Stitch places images side by side, left to right or top to bottom when
+// vertical, separated by gap pixels. Images narrower than the row (or
+// column) are aligned to its start and the leftover area is filled with bg.
+func Stitch(imgs []image.Image, vertical bool, gap int, bg color.Color) *image.RGBA {
+	if gap < 0 {
+		gap = 0
+	}
+	var w, h int
+	for i, img := range imgs {
+		size := img.Bounds().Size()
+		if i > 0 {
+			if vertical {
+				h += gap
+			} else {
+				w += gap
+			}
+		}
+		if vertical {
+			h += size.Y
+			w = max(w, size.X)
+		} else {
+			w += size.X
+			h = max(h, size.Y)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	var offset image.Point
+	for _, img := range imgs {
+		b := img.Bounds()
+		draw.Draw(dst, b.Sub(b.Min).Add(offset), img, b.Min, draw.Over)
+		if vertical {
+			offset.Y += b.Dy() + gap
+		} else {
+			offset.X += b.Dx() + gap
+		}
+	}
+	return dst
+}
+
+// Blend modes for BlendFrames
+const (
+	BlendAverage = "average" // Mean of each pixel, a long exposure that removes noise and flicker
+	BlendMax     = "max"     // Brightest of each channel, leaving motion trails of bright objects
+)
+
+// BlendFrames composites frames of the same size pixel by pixel with mode.
+// Averaging a burst of noisy video gives a clean still; max blending an
+// animation shows the path of everything that moved. Frames are aligned at
+// their top-left corners and cropped to the smallest of them
+func BlendFrames(frames []image.Image, mode string) (*image.RGBA, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to blend")
+	}
+	if mode != BlendAverage && mode != BlendMax {
+		return nil, fmt.Errorf("unknown blend mode %q, want %s or %s", mode, BlendAverage, BlendMax)
+	}
+
+	size := frames[0].Bounds().Size()
+	rgbas := make([]*image.RGBA, len(frames))
+	for i, f := range frames {
+		rgbas[i] = ToRGBA(f)
+		size.X = min(size.X, f.Bounds().Dx())
+		size.Y = min(size.Y, f.Bounds().Dy())
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	sums := make([]uint32, size.X*4)
+	for y := 0; y < size.Y; y++ {
+		clear(sums)
+		for _, src := range rgbas {
+			row := src.Pix[src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+y):][:size.X*4]
+			for i, v := range row {
+				if mode == BlendMax {
+					sums[i] = max(sums[i], uint32(v))
+				} else {
+					sums[i] += uint32(v)
+				}
+			}
+		}
+		out := dst.Pix[y*dst.Stride:][:size.X*4]
+		for i, v := range sums {
+			if mode == BlendAverage {
+				// Round to nearest so long bursts don't darken
+				v = (v + uint32(len(rgbas))/2) / uint32(len(rgbas))
+			}
+			out[i] = uint8(v)
+		}
+	}
+	return dst, nil
+}
+
+// labelPadding is the space between a label's text and its background edge
+const labelPadding = 4
+
+// DrawLabel draws text on a filled background with its top-left corner at
+// pt, clipped to the image. Uses the built-in 7x13 bitmap font so labels
+// look the same on every machine.
+func DrawLabel(img *image.RGBA, text string, pt image.Point, fg, bg color.Color) {
+	face := basicfont.Face7x13
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(fg), Face: face}
+
+	box := image.Rectangle{Min: pt, Max: pt.Add(LabelSize(text))}
+	draw.Draw(img, box.Intersect(img.Bounds()), image.NewUniform(bg), image.Point{}, draw.Over)
+
+	d.Dot = fixed.P(pt.X+labelPadding, pt.Y+labelPadding+face.Metrics().Ascent.Ceil())
+	d.DrawString(text)
+}
+
+// LabelSize returns the size DrawLabel covers for text, background included
+func LabelSize(text string) image.Point {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	height := face.Metrics().Height.Ceil()
+	return image.Pt(width+2*labelPadding, height+2*labelPadding)
+}
+
+// StrokeRect draws the outline of r inward with the given thickness
+func StrokeRect(img *image.RGBA, r image.Rectangle, thickness int, c color.Color) {
+	if thickness < 1 {
+		thickness = 1
+	}
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+thickness), // Top
+		image.Rect(r.Min.X, r.Max.Y-thickness, r.Max.X, r.Max.Y), // Bottom
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+thickness, r.Max.Y), // Left
+		image.Rect(r.Max.X-thickness, r.Min.Y, r.Max.X, r.Max.Y), // Right
+	}
+	for _, e := range edges {
+		draw.Draw(img, e.Intersect(r).Intersect(img.Bounds()), src, image.Point{}, draw.Over)
+	}
+}
+
+// ParseColor parses "#RRGGBB" or "#RRGGBBAA" hex colors
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	if len(hex) == 6 {
+		v = v<<8 | 0xFF
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
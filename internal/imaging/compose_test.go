@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestStitch(t *testing.T) {
+	red := solidImage(color.RGBA{255, 0, 0, 255})     // 4x4
+	blue := image.NewRGBA(image.Rect(10, 10, 12, 16)) // 2x6 with a non-zero origin
+	for y := 10; y < 16; y++ {
+		for x := 10; x < 12; x++ {
+			blue.SetRGBA(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+	white := color.RGBA{255, 255, 255, 255}
+
+	tests := []struct {
+		name     string
+		vertical bool
+		wantSize image.Point
+		probes   map[image.Point]color.RGBA
+	}{
+		{"horizontal", false, image.Pt(4+2+2, 6), map[image.Point]color.RGBA{
+			{0, 0}: {255, 0, 0, 255},
+			{4, 0}: white, // Gap
+			{6, 5}: {0, 0, 255, 255},
+			{0, 5}: white, // Below the shorter image
+		}},
+		{"vertical", true, image.Pt(4, 4+2+6), map[image.Point]color.RGBA{
+			{3, 3}: {255, 0, 0, 255},
+			{0, 4}: white,
+			{1, 6}: {0, 0, 255, 255},
+			{3, 6}: white, // Beside the narrower image
+		}},
+	}
+	for _, tt := range tests {
+		out := Stitch([]image.Image{red, blue}, tt.vertical, 2, white)
+		if got := out.Bounds().Size(); got != tt.wantSize {
+			t.Errorf("%s: size = %v, want %v", tt.name, got, tt.wantSize)
+			continue
+		}
+		for pt, want := range tt.probes {
+			if got := out.RGBAAt(pt.X, pt.Y); got != want {
+				t.Errorf("%s: pixel %v = %v, want %v", tt.name, pt, got, want)
+			}
+		}
+	}
+}
+
+func TestDrawLabel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 30))
+	black := color.RGBA{0, 0, 0, 255}
+	yellow := color.RGBA{255, 255, 0, 255}
+	DrawLabel(img, "12:00", image.Pt(2, 2), black, yellow)
+
+	if got := img.RGBAAt(2, 2); got != yellow {
+		t.Errorf("label corner = %v, want the background color", got)
+	}
+	if got := img.RGBAAt(99, 29); got != (color.RGBA{}) {
+		t.Errorf("pixel outside the label = %v, want it untouched", got)
+	}
+	ink := 0
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 100; x++ {
+			if img.RGBAAt(x, y) == black {
+				ink++
+			}
+		}
+	}
+	if ink == 0 {
+		t.Error("DrawLabel() drew no text")
+	}
+
+	// Labels running past the edge are clipped rather than panicking
+	DrawLabel(img, "overflowing label text", image.Pt(90, 25), black, yellow)
+}
+
+func TestStrokeRect(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	green := color.RGBA{0, 255, 0, 255}
+	StrokeRect(img, image.Rect(2, 2, 8, 8), 2, green)
+
+	tests := []struct {
+		pt   image.Point
+		want color.RGBA
+	}{
+		{image.Pt(2, 2), green},
+		{image.Pt(7, 5), green},
+		{image.Pt(5, 3), green},
+		{image.Pt(5, 5), color.RGBA{}}, // Inside stays clear
+		{image.Pt(1, 1), color.RGBA{}}, // Outside stays clear
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.pt.X, tt.pt.Y); got != tt.want {
+			t.Errorf("pixel %v = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestBlendFrames(t *testing.T) {
+	dark := solidImage(color.RGBA{10, 100, 0, 255})
+	light := solidImage(color.RGBA{30, 0, 201, 255})
+	frames := []image.Image{dark, light}
+
+	avg, err := BlendFrames(frames, BlendAverage)
+	if err != nil {
+		t.Fatalf("BlendFrames(average) error = %v", err)
+	}
+	if got, want := avg.RGBAAt(1, 1), (color.RGBA{20, 50, 101, 255}); got != want {
+		t.Errorf("average pixel = %v, want %v", got, want)
+	}
+
+	bright, err := BlendFrames(frames, BlendMax)
+	if err != nil {
+		t.Fatalf("BlendFrames(max) error = %v", err)
+	}
+	if got, want := bright.RGBAAt(1, 1), (color.RGBA{30, 100, 201, 255}); got != want {
+		t.Errorf("max pixel = %v, want %v", got, want)
+	}
+
+	if _, err := BlendFrames(frames, "median"); err == nil {
+		t.Error("BlendFrames() accepted an unknown mode")
+	}
+	if _, err := BlendFrames(nil, BlendAverage); err == nil {
+		t.Error("BlendFrames() accepted no frames")
+	}
+}
@@ -0,0 +1,177 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultQuality is used for lossy formats when EncodeOptions.Quality is unset
+const DefaultQuality = 95
+
+// Image formats accepted by Encode
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+// encodeTimeout bounds an external WebP or AVIF encoder
+const encodeTimeout = time.Minute
+
+// Encoder settings taken from the config. WebPEncoder and AVIFEncoder write
+// the formats the Go libraries can only read, and are looked up on PATH unless
+// set to a full path
+var (
+	WebPEncoder        = "cwebp"
+	AVIFEncoder        = "avifenc"
+	DefaultCompression = png.DefaultCompression // Used when EncodeOptions.Compression is zero
+)
+
+// EncodeOptions controls how Encode writes an image
+type EncodeOptions struct {
+	Format      string               // FormatPNG (default), FormatJPEG, FormatWebP or FormatAVIF; "jpg" is accepted
+	Quality     int                  // 1-100 for JPEG, WebP and AVIF, 0 uses DefaultQuality
+	Compression png.CompressionLevel // PNG compression, 0 uses DefaultCompression; BestSpeed and BestCompression also set the WebP and AVIF effort
+}
+
+// NormalizeFormat returns the Encode format name for format, or "" if it is
+// not one Encode writes. The empty string is PNG
+func NormalizeFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "", FormatPNG:
+		return FormatPNG
+	case FormatJPEG, "jpg":
+		return FormatJPEG
+	case FormatWebP:
+		return FormatWebP
+	case FormatAVIF:
+		return FormatAVIF
+	}
+	return ""
+}
+
+// Encode writes img in the requested format. WebP and AVIF run WebPEncoder or
+// AVIFEncoder on a temporary PNG
+func Encode(img image.Image, opts EncodeOptions) ([]byte, error) {
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = DefaultQuality
+	}
+	level := opts.Compression
+	if level == png.DefaultCompression {
+		level = DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	switch NormalizeFormat(opts.Format) {
+	case FormatPNG:
+		enc := png.Encoder{CompressionLevel: level}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+	case FormatWebP:
+		return encodeExternal(img, ".webp", func(in, out string) (string, []string) {
+			return WebPEncoder, webpArgs(in, out, quality, level)
+		})
+	case FormatAVIF:
+		return encodeExternal(img, ".avif", func(in, out string) (string, []string) {
+			return AVIFEncoder, avifArgs(in, out, quality, level)
+		})
+	default:
+		return nil, fmt.Errorf("unsupported image format: %q", opts.Format)
+	}
+	return buf.Bytes(), nil
+}
+
+// webpArgs builds the cwebp command line. Method 0-6 trades speed for size
+func webpArgs(in, out string, quality int, level png.CompressionLevel) []string {
+	method := 4
+	switch level {
+	case png.BestSpeed:
+		method = 0
+	case png.BestCompression:
+		method = 6
+	}
+	return []string{"-quiet", "-q", strconv.Itoa(quality), "-m", strconv.Itoa(method), in, "-o", out}
+}
+
+// avifArgs builds the avifenc command line. Speed 0-10 trades size for speed
+func avifArgs(in, out string, quality int, level png.CompressionLevel) []string {
+	speed := 6
+	switch level {
+	case png.BestSpeed:
+		speed = 10
+	case png.BestCompression:
+		speed = 2
+	}
+	return []string{"-q", strconv.Itoa(quality), "-s", strconv.Itoa(speed), in, out}
+}
+
+// encodeExternal writes img to a temporary PNG, runs the command returned by
+// command on it and reads back the output file
+func encodeExternal(img image.Image, ext string, command func(in, out string) (string, []string)) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "winshot-encode-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.png")
+	out := filepath.Join(dir, "out"+ext)
+	var src bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(&src, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(in, src.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), encodeTimeout)
+	defer cancel()
+
+	path, args := command(in, out)
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, path, args...)
+	c.Stderr = &stderr
+	c.WaitDelay = time.Second
+	if hideConsole != nil {
+		hideConsole(c)
+	}
+	if err := c.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("%s encoder %s not found; install it or set its path in the export settings", strings.TrimPrefix(ext, "."), path)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %v", path, encodeTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > maxStderr {
+				msg = msg[:maxStderr] + "..."
+			}
+			return nil, fmt.Errorf("%s failed: %w: %s", path, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", path, err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no output: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,191 @@
+package imaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	src := solidImage(color.RGBA{10, 20, 30, 255})
+
+	data, err := Encode(src, EncodeOptions{})
+	if err != nil {
+		t.Fatalf("png error = %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("default format is not PNG: %v", err)
+	}
+
+	data, err = Encode(src, EncodeOptions{Format: "JPG", Quality: 50})
+	if err != nil {
+		t.Fatalf("jpeg error = %v", err)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("jpg format is not JPEG: %v", err)
+	}
+
+	if _, err := Encode(src, EncodeOptions{Format: "tiff"}); err == nil {
+		t.Error("tiff encoded, want an unsupported format error")
+	}
+}
+
+func TestEncodeMissingEncoder(t *testing.T) {
+	orig := WebPEncoder
+	t.Cleanup(func() { WebPEncoder = orig })
+	WebPEncoder = "winshot-no-such-encoder"
+
+	_, err := Encode(image.NewRGBA(image.Rect(0, 0, 2, 2)), EncodeOptions{Format: FormatWebP})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("error = %v, want it to mention the encoder was not found", err)
+	}
+}
+
+func TestEncoderArgs(t *testing.T) {
+	if got, want := webpArgs("a.png", "b.webp", 80, png.BestCompression), []string{"-quiet", "-q", "80", "-m", "6", "a.png", "-o", "b.webp"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("webpArgs = %q, want %q", got, want)
+	}
+	if got, want := avifArgs("a.png", "b.avif", 60, png.DefaultCompression), []string{"-q", "60", "-s", "6", "a.png", "b.avif"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("avifArgs = %q, want %q", got, want)
+	}
+}
+
+func TestPreviewJPEG(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3840, 2160))
+
+	data, err := PreviewJPEG(src, 1280, 70)
+	if err != nil {
+		t.Fatalf("PreviewJPEG() error = %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("preview is not a JPEG: %v", err)
+	}
+	if cfg.Width != 1280 || cfg.Height != 720 {
+		t.Errorf("preview size = %dx%d, want 1280x720", cfg.Width, cfg.Height)
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	data, err = PreviewJPEG(small, 1280, 70)
+	if err != nil {
+		t.Fatalf("PreviewJPEG() error = %v", err)
+	}
+	if cfg, _ := jpeg.DecodeConfig(bytes.NewReader(data)); cfg.Width != 200 || cfg.Height != 100 {
+		t.Errorf("small preview size = %dx%d, want 200x100 unscaled", cfg.Width, cfg.Height)
+	}
+}
+
+func TestZipFrames(t *testing.T) {
+	frames := []image.Image{
+		solidImage(color.RGBA{255, 0, 0, 255}),
+		solidImage(color.RGBA{0, 0, 255, 255}),
+	}
+	var buf bytes.Buffer
+	if err := ZipFrames(&buf, frames, []byte(`{"frames":2}`), time.Now()); err != nil {
+		t.Fatalf("ZipFrames() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"frame_0001.png", "frame_0002.png", BurstIndexName}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+
+	rc, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	img, err := png.Decode(rc)
+	if err != nil {
+		t.Fatalf("frame is not a PNG: %v", err)
+	}
+	if r, _, b, _ := img.At(0, 0).RGBA(); r != 0 || b != 0xffff {
+		t.Errorf("second frame is not blue")
+	}
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	red := solidImage(color.RGBA{255, 0, 0, 255})
+	dot := solidImage(color.RGBA{255, 0, 0, 255})
+	dot.SetRGBA(2, 1, color.RGBA{0, 0, 255, 255})
+	frames := []image.Image{red, red, dot}
+
+	var buf bytes.Buffer
+	if err := EncodeAPNG(&buf, frames, APNGOptions{Delay: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("EncodeAPNG() error = %v", err)
+	}
+
+	// Viewers without APNG support decode the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("not a PNG: %v", err)
+	}
+	if r, _, b, _ := img.At(2, 1).RGBA(); r != 0xffff || b != 0 {
+		t.Errorf("first frame is not red")
+	}
+
+	chunks := apngChunks(t, buf.Bytes())
+	if n := binary.BigEndian.Uint32(chunks["acTL"][0]); n != 2 {
+		t.Errorf("acTL frames = %d, want 2 with the repeated frame merged", n)
+	}
+	fctl := chunks["fcTL"]
+	if len(fctl) != 2 || len(chunks["fdAT"]) != 1 {
+		t.Fatalf("got %d fcTL and %d fdAT chunks, want 2 and 1", len(fctl), len(chunks["fdAT"]))
+	}
+	if d := binary.BigEndian.Uint16(fctl[0][20:]); d != 100 {
+		t.Errorf("first frame delay = %dms, want 100ms", d)
+	}
+	// The second frame only stores the changed pixel
+	w, h := binary.BigEndian.Uint32(fctl[1][4:]), binary.BigEndian.Uint32(fctl[1][8:])
+	x, y := binary.BigEndian.Uint32(fctl[1][12:]), binary.BigEndian.Uint32(fctl[1][16:])
+	if w != 1 || h != 1 || x != 2 || y != 1 {
+		t.Errorf("second frame = %dx%d at %d,%d, want 1x1 at 2,1", w, h, x, y)
+	}
+
+	size, err := EstimateAPNGSize(frames, APNGOptions{}, 4)
+	if err != nil {
+		t.Fatalf("EstimateAPNGSize() error = %v", err)
+	}
+	if size != int64(buf.Len()) {
+		t.Errorf("EstimateAPNGSize() = %d, want %d when every frame is sampled", size, buf.Len())
+	}
+}
+
+func TestEncodeAPNG_SizeMismatch(t *testing.T) {
+	frames := []image.Image{solidImage(color.RGBA{}), image.NewRGBA(image.Rect(0, 0, 2, 2))}
+	if err := EncodeAPNG(&bytes.Buffer{}, frames, APNGOptions{}); err == nil {
+		t.Error("EncodeAPNG() accepted frames of different sizes")
+	}
+	if err := EncodeAPNG(&bytes.Buffer{}, nil, APNGOptions{}); err == nil {
+		t.Error("EncodeAPNG() accepted no frames")
+	}
+}
+
+// apngChunks returns the data of every chunk in a PNG file by type
+func apngChunks(t *testing.T, data []byte) map[string][][]byte {
+	t.Helper()
+	chunks := map[string][][]byte{}
+	for p := len(pngSignature); p+12 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[p:]))
+		name := string(data[p+4 : p+8])
+		chunks[name] = append(chunks[name], data[p+8:p+8+n])
+		p += 12 + n
+	}
+	return chunks
+}
@@ -0,0 +1,78 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultExternalTimeout bounds an external filter when no timeout is given
+const DefaultExternalTimeout = 10 * time.Second
+
+// maxStderr limits how much of a failing filter's output ends up in errors
+const maxStderr = 512
+
+// ExternalCommand is a program that transforms captures: it reads a PNG on
+// stdin and writes the transformed PNG to stdout
+type ExternalCommand struct {
+	Path    string
+	Args    []string
+	Timeout time.Duration // DefaultExternalTimeout when zero
+}
+
+// hideConsole keeps console programs from flashing a window, set on Windows
+var hideConsole func(*exec.Cmd)
+
+// ExternalFilter returns a Filter that pipes captures through cmd. The
+// program is killed if it runs past its timeout, and a non-zero exit or an
+// undecodable output fails the filter.
+func ExternalFilter(cmd ExternalCommand) Filter {
+	return func(img *image.RGBA) (*image.RGBA, error) {
+		timeout := cmd.Timeout
+		if timeout <= 0 {
+			timeout = DefaultExternalTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var in bytes.Buffer
+		if err := png.Encode(&in, img); err != nil {
+			return nil, err
+		}
+
+		var out, stderr bytes.Buffer
+		c := exec.CommandContext(ctx, cmd.Path, cmd.Args...)
+		c.Stdin = &in
+		c.Stdout = &out
+		c.Stderr = &stderr
+		c.WaitDelay = time.Second // Don't wait on pipes held open by grandchildren
+		if hideConsole != nil {
+			hideConsole(c)
+		}
+
+		if err := c.Run(); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s timed out after %v", cmd.Path, timeout)
+			}
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				if len(msg) > maxStderr {
+					msg = msg[:maxStderr] + "..."
+				}
+				return nil, fmt.Errorf("%s failed: %w: %s", cmd.Path, err, msg)
+			}
+			return nil, fmt.Errorf("%s failed: %w", cmd.Path, err)
+		}
+
+		result, err := png.Decode(&out)
+		if err != nil {
+			return nil, fmt.Errorf("%s wrote no valid PNG: %w", cmd.Path, err)
+		}
+		return ToRGBA(result), nil
+	}
+}
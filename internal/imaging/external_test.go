@@ -0,0 +1,80 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperFilter is not a real test: ExternalFilter tests run the test
+// binary itself as the external program, selected by WINSHOT_HELPER_FILTER
+func TestHelperFilter(t *testing.T) {
+	mode := os.Getenv("WINSHOT_HELPER_FILTER")
+	if mode == "" {
+		return
+	}
+	switch mode {
+	case "invert":
+		img, err := png.Decode(os.Stdin)
+		if err != nil {
+			os.Exit(3)
+		}
+		rgba := ToRGBA(img)
+		for i := 0; i < len(rgba.Pix); i += 4 {
+			rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2] = 255-rgba.Pix[i], 255-rgba.Pix[i+1], 255-rgba.Pix[i+2]
+		}
+		png.Encode(os.Stdout, rgba)
+	case "fail":
+		io.Copy(io.Discard, os.Stdin)
+		os.Stderr.WriteString("license check failed")
+		os.Exit(1)
+	case "garbage":
+		io.Copy(io.Discard, os.Stdin)
+		os.Stdout.WriteString("not a png")
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+func helperCommand(t *testing.T, mode string, timeout time.Duration) ExternalCommand {
+	t.Setenv("WINSHOT_HELPER_FILTER", mode)
+	return ExternalCommand{
+		Path:    os.Args[0],
+		Args:    []string{"-test.run=^TestHelperFilter$"},
+		Timeout: timeout,
+	}
+}
+
+func TestExternalFilter(t *testing.T) {
+	src := solidImage(color.RGBA{10, 20, 30, 255})
+
+	out, err := ExternalFilter(helperCommand(t, "invert", 0))(src)
+	if err != nil {
+		t.Fatalf("invert filter error = %v", err)
+	}
+	if got := out.RGBAAt(1, 1); got != (color.RGBA{245, 235, 225, 255}) {
+		t.Errorf("inverted pixel = %v, want {245 235 225 255}", got)
+	}
+
+	tests := []struct {
+		mode    string
+		timeout time.Duration
+		wantErr string
+	}{
+		{"fail", 0, "license check failed"},
+		{"garbage", 0, "no valid PNG"},
+		{"hang", 200 * time.Millisecond, "timed out"},
+	}
+	for _, tt := range tests {
+		_, err := ExternalFilter(helperCommand(t, tt.mode, tt.timeout))(image.NewRGBA(image.Rect(0, 0, 2, 2)))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s filter error = %v, want it to mention %q", tt.mode, err, tt.wantErr)
+		}
+	}
+}
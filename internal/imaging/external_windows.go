@@ -0,0 +1,19 @@
+package imaging
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// createNoWindow is CREATE_NO_WINDOW, which runs console programs without
+// opening a console
+const createNoWindow = 0x08000000
+
+func init() {
+	hideConsole = func(c *exec.Cmd) {
+		c.SysProcAttr = &syscall.SysProcAttr{
+			HideWindow:    true,
+			CreationFlags: createNoWindow,
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"sync"
+)
+
+// Filter transforms a capture. It may modify img in place and return it, or
+// return a new image
+type Filter func(img *image.RGBA) (*image.RGBA, error)
+
+// FilterChain runs registered filters in registration order. The zero value
+// is an empty chain, and it is safe for concurrent use.
+type FilterChain struct {
+	mu    sync.RWMutex
+	steps []namedFilter
+}
+
+type namedFilter struct {
+	name   string
+	filter Filter
+}
+
+// Register appends a filter to the chain. Registering a name again replaces
+// that filter in place, keeping its position.
+func (c *FilterChain) Register(name string, f Filter) error {
+	if name == "" {
+		return fmt.Errorf("filter name is empty")
+	}
+	if f == nil {
+		return fmt.Errorf("filter %q is nil", name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.steps {
+		if c.steps[i].name == name {
+			c.steps[i].filter = f
+			return nil
+		}
+	}
+	c.steps = append(c.steps, namedFilter{name, f})
+	return nil
+}
+
+// Unregister removes a filter and reports whether it was registered
+func (c *FilterChain) Unregister(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.steps {
+		if c.steps[i].name == name {
+			c.steps = append(c.steps[:i:i], c.steps[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the registered filter names in the order they run
+func (c *FilterChain) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, len(c.steps))
+	for i, s := range c.steps {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Apply runs every filter on img. An empty chain returns img itself when it
+// is already RGBA. The first failing filter stops the chain, so a transform
+// such as redaction never lets an unprocessed image through.
+func (c *FilterChain) Apply(img image.Image) (*image.RGBA, error) {
+	c.mu.RLock()
+	steps := append([]namedFilter(nil), c.steps...)
+	c.mu.RUnlock()
+
+	rgba := ToRGBA(img)
+	for _, s := range steps {
+		out, err := s.filter(rgba)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", s.name, err)
+		}
+		if out == nil {
+			return nil, fmt.Errorf("filter %q returned no image", s.name)
+		}
+		rgba = out
+	}
+	return rgba, nil
+}
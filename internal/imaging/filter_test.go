@@ -0,0 +1,84 @@
+package imaging
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestFilterChain_Apply(t *testing.T) {
+	var chain FilterChain
+	src := solidImage(color.RGBA{10, 20, 30, 255})
+
+	// An empty chain passes the image through untouched
+	out, err := chain.Apply(src)
+	if err != nil || out != src {
+		t.Fatalf("empty chain Apply() = %p, %v, want the input image", out, err)
+	}
+
+	var order []string
+	tint := func(name string, c color.RGBA) Filter {
+		return func(img *image.RGBA) (*image.RGBA, error) {
+			order = append(order, name)
+			img.SetRGBA(0, 0, c)
+			return img, nil
+		}
+	}
+	chain.Register("red", tint("red", color.RGBA{255, 0, 0, 255}))
+	chain.Register("crop", func(img *image.RGBA) (*image.RGBA, error) {
+		order = append(order, "crop")
+		return img.SubImage(image.Rect(0, 0, 2, 2)).(*image.RGBA), nil
+	})
+	chain.Register("red", tint("blue", color.RGBA{0, 0, 255, 255})) // Replaces, keeping its place
+
+	out, err = chain.Apply(src)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"blue", "crop"}) {
+		t.Errorf("filters ran as %v, want [blue crop]", order)
+	}
+	if got := out.Bounds().Size(); got != image.Pt(2, 2) {
+		t.Errorf("output size = %v, want 2x2", got)
+	}
+	if got := out.RGBAAt(0, 0); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("pixel = %v, want blue", got)
+	}
+
+	if !chain.Unregister("crop") || chain.Unregister("crop") {
+		t.Error("Unregister() should report the filter only once")
+	}
+	if got := chain.Names(); !reflect.DeepEqual(got, []string{"red"}) {
+		t.Errorf("Names() = %v, want [red]", got)
+	}
+}
+
+func TestFilterChain_Errors(t *testing.T) {
+	var chain FilterChain
+	if err := chain.Register("", func(img *image.RGBA) (*image.RGBA, error) { return img, nil }); err == nil {
+		t.Error("Register() with an empty name should fail")
+	}
+	if err := chain.Register("nil", nil); err == nil {
+		t.Error("Register() with a nil filter should fail")
+	}
+
+	errRedact := errors.New("redaction service unavailable")
+	ran := false
+	chain.Register("redact", func(img *image.RGBA) (*image.RGBA, error) { return nil, errRedact })
+	chain.Register("after", func(img *image.RGBA) (*image.RGBA, error) { ran = true; return img, nil })
+
+	if _, err := chain.Apply(solidImage(color.RGBA{})); !errors.Is(err, errRedact) {
+		t.Errorf("Apply() error = %v, want the filter's error", err)
+	}
+	if ran {
+		t.Error("filters after a failing one should not run")
+	}
+
+	chain.Unregister("redact")
+	chain.Register("empty", func(img *image.RGBA) (*image.RGBA, error) { return nil, nil })
+	if _, err := chain.Apply(solidImage(color.RGBA{})); err == nil {
+		t.Error("Apply() should fail when a filter returns no image")
+	}
+}
@@ -0,0 +1,48 @@
+package imaging
+
+import (
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+var (
+	fontsOnce sync.Once
+	fonts     map[[2]bool]*opentype.Font
+	fontsErr  error
+)
+
+// FontFace returns a face of the bundled Go fonts at a size in pixels. The
+// fonts ship with the binary, so text renders the same on every machine.
+// Close the face when done.
+func FontFace(bold, italic bool, size float64) (font.Face, error) {
+	fontsOnce.Do(func() {
+		fonts = make(map[[2]bool]*opentype.Font)
+		for style, data := range map[[2]bool][]byte{
+			{false, false}: goregular.TTF,
+			{true, false}:  gobold.TTF,
+			{false, true}:  goitalic.TTF,
+			{true, true}:   gobolditalic.TTF,
+		} {
+			f, err := opentype.Parse(data)
+			if err != nil {
+				fontsErr = err
+				return
+			}
+			fonts[style] = f
+		}
+	})
+	if fontsErr != nil {
+		return nil, fontsErr
+	}
+	return opentype.NewFace(fonts[[2]bool{bold, italic}], &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72, // One point per pixel
+		Hinting: font.HintingNone,
+	})
+}
@@ -0,0 +1,256 @@
+package imaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Built-in device frame names
+const (
+	FrameBrowser = "browser"
+	FramePhone   = "phone"
+)
+
+// Insets describes the width of each edge in pixels
+type Insets struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+}
+
+// DeviceFrame is a 9-slice template wrapped around a capture.
+// Corners are copied as-is, edges are stretched along one axis and the
+// capture is placed in the center cell. Pinned, if set, is a region of the
+// top edge copied unscaled at the same place, for controls that must keep
+// their shape however wide the capture is.
+type DeviceFrame struct {
+	Name   string
+	Image  *image.RGBA
+	Slice  Insets
+	Pinned image.Rectangle
+}
+
+// ApplyDeviceFrame wraps img in the device frame and returns the composed image
+func ApplyDeviceFrame(img image.Image, frame *DeviceFrame) (*image.RGBA, error) {
+	if frame == nil || frame.Image == nil {
+		return nil, fmt.Errorf("device frame is empty")
+	}
+
+	tb := frame.Image.Bounds()
+	s := frame.Slice
+	if s.Left < 0 || s.Top < 0 || s.Right < 0 || s.Bottom < 0 ||
+		s.Left+s.Right >= tb.Dx() || s.Top+s.Bottom >= tb.Dy() {
+		return nil, fmt.Errorf("invalid slice insets for frame %q", frame.Name)
+	}
+	pinned := frame.Pinned
+	if !pinned.Empty() && (!pinned.In(tb) || pinned.Max.Y > tb.Min.Y+s.Top) {
+		return nil, fmt.Errorf("pinned region of frame %q is outside the top edge", frame.Name)
+	}
+
+	cb := img.Bounds()
+	outW := cb.Dx() + s.Left + s.Right
+	outH := cb.Dy() + s.Top + s.Bottom
+	out := image.NewRGBA(image.Rect(0, 0, outW, outH))
+
+	// Column and row boundaries in template and output space
+	srcX := [4]int{tb.Min.X, tb.Min.X + s.Left, tb.Max.X - s.Right, tb.Max.X}
+	srcY := [4]int{tb.Min.Y, tb.Min.Y + s.Top, tb.Max.Y - s.Bottom, tb.Max.Y}
+	dstX := [4]int{0, s.Left, outW - s.Right, outW}
+	dstY := [4]int{0, s.Top, outH - s.Bottom, outH}
+
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			src := image.Rect(srcX[col], srcY[row], srcX[col+1], srcY[row+1])
+			dst := image.Rect(dstX[col], dstY[row], dstX[col+1], dstY[row+1])
+			if src.Empty() || dst.Empty() {
+				continue
+			}
+			if src.Dx() == dst.Dx() && src.Dy() == dst.Dy() {
+				draw.Draw(out, dst, frame.Image, src.Min, draw.Src)
+			} else {
+				draw.ApproxBiLinear.Scale(out, dst, frame.Image, src, draw.Src, nil)
+			}
+		}
+	}
+
+	// Pinned controls go back over the stretched top edge, short of the
+	// top-right corner when the capture is narrower than the template
+	if !pinned.Empty() {
+		dst := pinned.Sub(tb.Min).Intersect(image.Rect(0, 0, outW-s.Right, s.Top))
+		draw.Draw(out, dst, frame.Image, pinned.Min, draw.Src)
+	}
+
+	// Capture goes over the center cell
+	content := image.Rect(s.Left, s.Top, s.Left+cb.Dx(), s.Top+cb.Dy())
+	draw.Draw(out, content, img, cb.Min, draw.Over)
+
+	return out, nil
+}
+
+// BuiltinFrame returns a procedurally drawn frame by name, or nil if unknown
+func BuiltinFrame(name string) *DeviceFrame {
+	switch name {
+	case FrameBrowser:
+		return browserFrame()
+	case FramePhone:
+		return phoneFrame()
+	}
+	return nil
+}
+
+// LoadDeviceFrame loads a custom frame from "<dir>/<name>.png" with slice insets
+// read from the "<dir>/<name>.json" sidecar
+func LoadDeviceFrame(dir, name string) (*DeviceFrame, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name != filepath.Base(name) {
+		return nil, fmt.Errorf("invalid frame name: %q", name)
+	}
+
+	f, err := os.Open(filepath.Join(dir, name+".png"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open frame template: %w", err)
+	}
+	defer f.Close()
+
+	tmpl, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode frame template: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frame insets: %w", err)
+	}
+	var slice Insets
+	if err := json.Unmarshal(data, &slice); err != nil {
+		return nil, fmt.Errorf("invalid frame insets: %w", err)
+	}
+
+	return &DeviceFrame{Name: name, Image: ToRGBA(tmpl), Slice: slice}, nil
+}
+
+// ListDeviceFrames returns built-in frame names followed by custom frames found in dir
+func ListDeviceFrames(dir string) []string {
+	names := []string{FrameBrowser, FramePhone}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return names
+	}
+
+	var custom []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(filepath.Ext(entry.Name())) != ".png" {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, err := os.Stat(filepath.Join(dir, name+".json")); err != nil {
+			continue // Skip templates without insets sidecar
+		}
+		if name == FrameBrowser || name == FramePhone {
+			continue
+		}
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+
+	return append(names, custom...)
+}
+
+// browserFrame draws a light browser-window chrome with traffic-light buttons
+func browserFrame() *DeviceFrame {
+	const w, h = 240, 120
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	chrome := color.RGBA{R: 0xE8, G: 0xE8, B: 0xEC, A: 0xFF}
+	fillRoundedRect(img, image.Rect(0, 0, w, h), 10, chrome)
+
+	// Traffic lights sit in the pinned top-left region of the title bar
+	dots := []color.RGBA{
+		{R: 0xFF, G: 0x5F, B: 0x57, A: 0xFF},
+		{R: 0xFE, G: 0xBC, B: 0x2E, A: 0xFF},
+		{R: 0x28, G: 0xC8, B: 0x40, A: 0xFF},
+	}
+	for i, c := range dots {
+		fillCircle(img, 18+i*20, 20, 6, c)
+	}
+
+	// Address bar lives in the top edge cell and stretches with the capture width
+	fillRoundedRect(img, image.Rect(80, 10, w-20, 30), 10, color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF})
+
+	return &DeviceFrame{
+		Name:   FrameBrowser,
+		Image:  img,
+		Slice:  Insets{Left: 12, Top: 40, Right: 12, Bottom: 12},
+		Pinned: image.Rect(0, 0, 72, 40),
+	}
+}
+
+// phoneFrame draws a dark phone bezel with rounded corners and a camera dot
+func phoneFrame() *DeviceFrame {
+	const w, h = 160, 240
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	bezel := color.RGBA{R: 0x1C, G: 0x1C, B: 0x1E, A: 0xFF}
+	fillRoundedRect(img, image.Rect(0, 0, w, h), 44, bezel)
+	fillCircle(img, 40, 28, 5, color.RGBA{R: 0x3A, G: 0x3A, B: 0x3C, A: 0xFF})
+
+	return &DeviceFrame{
+		Name:  FramePhone,
+		Image: img,
+		Slice: Insets{Left: 56, Top: 56, Right: 56, Bottom: 56},
+	}
+}
+
+// fillRoundedRect fills r with an anti-aliased rounded rectangle
+func fillRoundedRect(img *image.RGBA, r image.Rectangle, radius int, c color.RGBA) {
+	rad := float64(radius)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			// Distance from the nearest corner center, only inside corner squares
+			cx := math.Max(float64(r.Min.X)+rad, math.Min(float64(x)+0.5, float64(r.Max.X)-rad))
+			cy := math.Max(float64(r.Min.Y)+rad, math.Min(float64(y)+0.5, float64(r.Max.Y)-rad))
+			d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			blendPixel(img, x, y, c, coverage(rad-d))
+		}
+	}
+}
+
+// fillCircle fills an anti-aliased circle centered at (cx, cy)
+func fillCircle(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	rad := float64(radius)
+	for y := cy - radius - 1; y <= cy+radius+1; y++ {
+		for x := cx - radius - 1; x <= cx+radius+1; x++ {
+			d := math.Hypot(float64(x)+0.5-float64(cx), float64(y)+0.5-float64(cy))
+			blendPixel(img, x, y, c, coverage(rad-d))
+		}
+	}
+}
+
+// coverage maps a signed distance to the edge into 0..1 pixel coverage
+func coverage(dist float64) float64 {
+	return math.Max(0, math.Min(1, dist+0.5))
+}
+
+// blendPixel composites c over the pixel at (x, y) with the given coverage
+func blendPixel(img *image.RGBA, x, y int, c color.RGBA, cov float64) {
+	if cov <= 0 || !(image.Point{X: x, Y: y}.In(img.Bounds())) {
+		return
+	}
+	a := float64(c.A) / 255 * cov
+	i := img.PixOffset(x, y)
+	img.Pix[i+0] = uint8(float64(c.R)*a + float64(img.Pix[i+0])*(1-a))
+	img.Pix[i+1] = uint8(float64(c.G)*a + float64(img.Pix[i+1])*(1-a))
+	img.Pix[i+2] = uint8(float64(c.B)*a + float64(img.Pix[i+2])*(1-a))
+	img.Pix[i+3] = uint8(255*a + float64(img.Pix[i+3])*(1-a))
+}
@@ -0,0 +1,115 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyDeviceFrame_OutputSize(t *testing.T) {
+	capture := solidImage(color.RGBA{R: 200, A: 255})
+
+	for _, name := range []string{FrameBrowser, FramePhone} {
+		t.Run(name, func(t *testing.T) {
+			frame := BuiltinFrame(name)
+			if frame == nil {
+				t.Fatalf("BuiltinFrame(%q) returned nil", name)
+			}
+
+			out, err := ApplyDeviceFrame(capture, frame)
+			if err != nil {
+				t.Fatalf("ApplyDeviceFrame() error = %v", err)
+			}
+
+			wantW := 4 + frame.Slice.Left + frame.Slice.Right
+			wantH := 4 + frame.Slice.Top + frame.Slice.Bottom
+			if out.Bounds().Dx() != wantW || out.Bounds().Dy() != wantH {
+				t.Errorf("size = %dx%d, want %dx%d", out.Bounds().Dx(), out.Bounds().Dy(), wantW, wantH)
+			}
+
+			// Capture pixels must appear unchanged in the content cell
+			got := out.RGBAAt(frame.Slice.Left+1, frame.Slice.Top+1)
+			if got != (color.RGBA{R: 200, A: 255}) {
+				t.Errorf("content pixel = %v, want capture color", got)
+			}
+		})
+	}
+}
+
+func TestApplyDeviceFrame_BrowserControls(t *testing.T) {
+	frame := BuiltinFrame(FrameBrowser)
+	if frame.Slice.Left != frame.Slice.Right {
+		t.Errorf("side bezels = %d and %d, want them equal", frame.Slice.Left, frame.Slice.Right)
+	}
+
+	// The close button keeps its place and color however wide the capture is
+	want := frame.Image.RGBAAt(18, 20)
+	for _, width := range []int{60, 1000} {
+		capture := image.NewRGBA(image.Rect(0, 0, width, 10))
+		out, err := ApplyDeviceFrame(capture, frame)
+		if err != nil {
+			t.Fatalf("ApplyDeviceFrame() error = %v", err)
+		}
+		if got := out.RGBAAt(18, 20); got != want {
+			t.Errorf("width %d: close button pixel = %v, want %v", width, got, want)
+		}
+	}
+}
+
+func TestApplyDeviceFrame_InvalidInsets(t *testing.T) {
+	frame := &DeviceFrame{
+		Name:  "bad",
+		Image: image.NewRGBA(image.Rect(0, 0, 10, 10)),
+		Slice: Insets{Left: 6, Right: 6},
+	}
+	if _, err := ApplyDeviceFrame(solidImage(color.RGBA{A: 255}), frame); err == nil {
+		t.Error("expected error for insets wider than template")
+	}
+
+	frame = BuiltinFrame(FrameBrowser)
+	frame.Pinned = image.Rect(0, 0, 72, 60)
+	if _, err := ApplyDeviceFrame(solidImage(color.RGBA{A: 255}), frame); err == nil {
+		t.Error("expected error for a pinned region below the top edge")
+	}
+
+	if _, err := ApplyDeviceFrame(solidImage(color.RGBA{A: 255}), nil); err == nil {
+		t.Error("expected error for nil frame")
+	}
+}
+
+func TestLoadDeviceFrame(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(dir, "tablet.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 30, 30))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, "tablet.json"), []byte(`{"left":5,"top":6,"right":7,"bottom":8}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := LoadDeviceFrame(dir, "tablet")
+	if err != nil {
+		t.Fatalf("LoadDeviceFrame() error = %v", err)
+	}
+	if frame.Slice != (Insets{Left: 5, Top: 6, Right: 7, Bottom: 8}) {
+		t.Errorf("Slice = %+v", frame.Slice)
+	}
+
+	if _, err := LoadDeviceFrame(dir, "../tablet"); err == nil {
+		t.Error("expected error for path traversal in frame name")
+	}
+
+	names := ListDeviceFrames(dir)
+	if len(names) != 3 || names[2] != "tablet" {
+		t.Errorf("ListDeviceFrames() = %v, want built-ins plus tablet", names)
+	}
+}
@@ -0,0 +1,81 @@
+package imaging
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// iccChunkMax is the most profile data one JPEG APP2 segment holds: the
+// segment length field covers itself and the ICC_PROFILE header too
+const iccChunkMax = 65535 - 2 - 14
+
+// EmbedICCProfile attaches an ICC color profile to encoded PNG or JPEG data,
+// as an iCCP chunk after the PNG header or as APP2 segments after the JPEG
+// start marker. Viewers then show the colors as the display rendered them
+// instead of assuming sRGB
+func EmbedICCProfile(data []byte, format string, profile []byte) ([]byte, error) {
+	if len(profile) == 0 {
+		return data, nil
+	}
+	switch strings.ToLower(format) {
+	case "jpeg", "jpg":
+		return embedJPEGProfile(data, profile)
+	default:
+		return embedPNGProfile(data, profile)
+	}
+}
+
+func embedPNGProfile(data, profile []byte) ([]byte, error) {
+	// The signature is followed by IHDR: length, type, 13 bytes of data, CRC
+	headerEnd := len(pngSignature) + 4 + 4 + 13 + 4
+	if len(data) < headerEnd || !bytes.HasPrefix(data, pngSignature) || string(data[12:16]) != "IHDR" {
+		return nil, errors.New("not a PNG image")
+	}
+
+	var chunk bytes.Buffer
+	chunk.WriteString("ICC Profile\x00")
+	chunk.WriteByte(0) // Compression method: zlib
+	zw := zlib.NewWriter(&chunk)
+	if _, err := zw.Write(profile); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data)+chunk.Len()+12)
+	out = append(out, data[:headerEnd]...)
+	out = binary.BigEndian.AppendUint32(out, uint32(chunk.Len()))
+	typed := append([]byte("iCCP"), chunk.Bytes()...)
+	out = append(out, typed...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(typed))
+	return append(out, data[headerEnd:]...), nil
+}
+
+func embedJPEGProfile(data, profile []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errors.New("not a JPEG image")
+	}
+	count := (len(profile) + iccChunkMax - 1) / iccChunkMax
+	if count > 255 {
+		return nil, errors.New("ICC profile too large for JPEG")
+	}
+
+	out := make([]byte, 0, len(data)+len(profile)+count*18)
+	out = append(out, data[:2]...)
+	for i := 0; i < count; i++ {
+		part := profile[i*iccChunkMax : min((i+1)*iccChunkMax, len(profile))]
+		out = append(out, 0xFF, 0xE2)
+		out = binary.BigEndian.AppendUint16(out, uint16(2+14+len(part)))
+		out = append(out, "ICC_PROFILE\x00"...)
+		out = append(out, byte(i+1), byte(count))
+		out = append(out, part...)
+	}
+	return append(out, data[2:]...), nil
+}
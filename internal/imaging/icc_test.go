@@ -0,0 +1,82 @@
+package imaging
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func TestEmbedICCProfile_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	profile := bytes.Repeat([]byte("icc"), 100)
+
+	out, err := EmbedICCProfile(buf.Bytes(), "png", profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("decode with iCCP: %v", err)
+	}
+
+	// The first chunk after IHDR is iCCP with the compressed profile
+	if string(out[37:41]) != "iCCP" {
+		t.Fatalf("chunk after IHDR = %q, want iCCP", out[37:41])
+	}
+	size := binary.BigEndian.Uint32(out[33:37])
+	body := out[41 : 41+size]
+	_, compressed, _ := bytes.Cut(body, []byte{0})
+	zr, err := zlib.NewReader(bytes.NewReader(compressed[1:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, profile) {
+		t.Errorf("embedded profile = %d bytes, want %d", len(got), len(profile))
+	}
+}
+
+func TestEmbedICCProfile_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil)
+	profile := bytes.Repeat([]byte{7}, iccChunkMax+10) // Needs two segments
+
+	out, err := EmbedICCProfile(buf.Bytes(), "jpg", profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("decode with APP2: %v", err)
+	}
+
+	var got []byte
+	pos := 2
+	for seq := 1; seq <= 2; seq++ {
+		if out[pos] != 0xFF || out[pos+1] != 0xE2 {
+			t.Fatalf("segment %d marker = %x, want FFE2", seq, out[pos:pos+2])
+		}
+		length := int(binary.BigEndian.Uint16(out[pos+2:]))
+		header := out[pos+4 : pos+18]
+		if string(header[:12]) != "ICC_PROFILE\x00" || header[12] != byte(seq) || header[13] != 2 {
+			t.Fatalf("segment %d header = %q", seq, header)
+		}
+		got = append(got, out[pos+18:pos+2+length]...)
+		pos += 2 + length
+	}
+	if !bytes.Equal(got, profile) {
+		t.Errorf("embedded profile = %d bytes, want %d", len(got), len(profile))
+	}
+}
+
+func TestEmbedICCProfile_RejectsOtherData(t *testing.T) {
+	if _, err := EmbedICCProfile([]byte("GIF89a"), "png", []byte("icc")); err == nil {
+		t.Error("EmbedICCProfile(GIF as png) error = nil")
+	}
+	if _, err := EmbedICCProfile([]byte("GIF89a"), "jpeg", []byte("icc")); err == nil {
+		t.Error("EmbedICCProfile(GIF as jpeg) error = nil")
+	}
+}
@@ -0,0 +1,31 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+// PreviewJPEG scales img down to fit within maxSide pixels on its longer side
+// and encodes it as a JPEG, a quick stand-in to show while the full image is
+// still being encoded. Images that already fit are encoded at full size
+func PreviewJPEG(img image.Image, maxSide, quality int) ([]byte, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if longest := max(w, h); longest > maxSide && maxSide > 0 {
+		w = max(w*maxSide/longest, 1)
+		h = max(h*maxSide/longest, 1)
+		scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+		// Bilinear is fast enough for 4K frames and smooth enough for a preview
+		draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, b, draw.Src, nil)
+		img = scaled
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// EllipseSpan returns the pixels [x0, x1) of row y whose centers lie inside the
+// ellipse inscribed in r. ok is false when the row misses the ellipse.
+func EllipseSpan(r image.Rectangle, y int) (x0, x1 int, ok bool) {
+	if r.Empty() || y < r.Min.Y || y >= r.Max.Y {
+		return 0, 0, false
+	}
+	rx := float64(r.Dx()) / 2
+	ry := float64(r.Dy()) / 2
+	cx := float64(r.Min.X) + rx
+	cy := float64(r.Min.Y) + ry
+
+	dy := (float64(y) + 0.5 - cy) / ry
+	if dy*dy >= 1 {
+		return 0, 0, false
+	}
+	half := rx * math.Sqrt(1-dy*dy)
+	x0 = int(math.Ceil(cx - half - 0.5))
+	x1 = int(math.Floor(cx+half-0.5)) + 1
+	if x0 >= x1 {
+		return 0, 0, false
+	}
+	return x0, x1, true
+}
+
+// PolygonSpans returns the pixel spans of row y inside the closed polygon pts
+// using the even-odd rule, sampled at pixel centers. Each span is [x0, x1).
+func PolygonSpans(pts []image.Point, y int) [][2]int {
+	if len(pts) < 3 {
+		return nil
+	}
+	sy := float64(y) + 0.5
+
+	var xs []float64
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		ay, by := float64(a.Y), float64(b.Y)
+		if (ay <= sy) == (by <= sy) {
+			continue // Edge doesn't cross this row
+		}
+		t := (sy - ay) / (by - ay)
+		xs = append(xs, float64(a.X)+t*float64(b.X-a.X))
+	}
+	sort.Float64s(xs)
+
+	var spans [][2]int
+	for i := 0; i+1 < len(xs); i += 2 {
+		x0 := int(math.Ceil(xs[i] - 0.5))
+		x1 := int(math.Floor(xs[i+1]-0.5)) + 1
+		if x0 < x1 {
+			spans = append(spans, [2]int{x0, x1})
+		}
+	}
+	return spans
+}
+
+// MaskEllipse returns a copy of img with pixels outside its inscribed ellipse
+// made transparent
+func MaskEllipse(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		if x0, x1, ok := EllipseSpan(b, y); ok {
+			draw.Draw(out, image.Rect(x0, y, x1, y+1), img, image.Pt(x0, y), draw.Src)
+		}
+	}
+	return out
+}
+
+// ApplyMask returns a copy of img keeping each pixel's color where mask is
+// opaque and fading it out where mask is transparent. The mask's origin is
+// aligned with the top left of img
+func ApplyMask(img image.Image, mask *image.Alpha) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.DrawMask(out, b, img, b.Min, mask, mask.Bounds().Min, draw.Src)
+	return out
+}
+
+// MaskPolygon returns a copy of img with pixels outside the closed polygon pts
+// (in img coordinates) made transparent
+func MaskPolygon(img image.Image, pts []image.Point) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for _, span := range PolygonSpans(pts, y) {
+			r := image.Rect(span[0], y, span[1], y+1).Intersect(b)
+			if !r.Empty() {
+				draw.Draw(out, r, img, r.Min, draw.Src)
+			}
+		}
+	}
+	return out
+}
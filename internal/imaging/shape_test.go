@@ -0,0 +1,100 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEllipseSpan(t *testing.T) {
+	r := image.Rect(0, 0, 10, 10)
+
+	tests := []struct {
+		y              int
+		wantX0, wantX1 int
+		wantOK         bool
+	}{
+		{-1, 0, 0, false},
+		{5, 0, 10, true}, // Widest row covers the full width
+		{0, 3, 7, true},
+		{10, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		x0, x1, ok := EllipseSpan(r, tt.y)
+		if ok != tt.wantOK || (ok && (x0 != tt.wantX0 || x1 != tt.wantX1)) {
+			t.Errorf("EllipseSpan(y=%d) = %d, %d, %v, want %d, %d, %v", tt.y, x0, x1, ok, tt.wantX0, tt.wantX1, tt.wantOK)
+		}
+	}
+}
+
+func TestPolygonSpans(t *testing.T) {
+	// Square with a notch cut into the top edge
+	pts := []image.Point{{0, 0}, {4, 0}, {5, 5}, {6, 0}, {10, 0}, {10, 10}, {0, 10}}
+
+	top := PolygonSpans(pts, 1)
+	if len(top) != 2 {
+		t.Fatalf("PolygonSpans(y=1) = %v, want two spans around the notch", top)
+	}
+	bottom := PolygonSpans(pts, 8)
+	if len(bottom) != 1 || bottom[0] != [2]int{0, 10} {
+		t.Errorf("PolygonSpans(y=8) = %v, want [[0 10]]", bottom)
+	}
+	if spans := PolygonSpans(pts[:2], 1); spans != nil {
+		t.Errorf("PolygonSpans() with two points = %v, want nil", spans)
+	}
+}
+
+func TestMaskEllipse(t *testing.T) {
+	big := image.NewRGBA(image.Rect(10, 10, 30, 30))
+	for i := range big.Pix {
+		big.Pix[i] = 255
+	}
+
+	out := MaskEllipse(big)
+	if out.Bounds() != big.Bounds() {
+		t.Fatalf("bounds = %v, want %v", out.Bounds(), big.Bounds())
+	}
+	if a := out.RGBAAt(10, 10).A; a != 0 {
+		t.Errorf("corner alpha = %d, want 0", a)
+	}
+	if a := out.RGBAAt(20, 20).A; a != 255 {
+		t.Errorf("center alpha = %d, want 255", a)
+	}
+}
+
+func TestMaskPolygon(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	// Lower-left triangle
+	out := MaskPolygon(img, []image.Point{{0, 0}, {10, 10}, {0, 10}})
+	if a := out.RGBAAt(1, 8).A; a != 255 {
+		t.Errorf("inside alpha = %d, want 255", a)
+	}
+	if a := out.RGBAAt(8, 1).A; a != 0 {
+		t.Errorf("outside alpha = %d, want 0", a)
+	}
+}
+
+func TestApplyMask(t *testing.T) {
+	img := image.NewRGBA(image.Rect(5, 5, 15, 15))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, 10, 10))
+	mask.SetAlpha(2, 3, color.Alpha{255})
+
+	out := ApplyMask(img, mask)
+	if out.Bounds() != img.Bounds() {
+		t.Fatalf("bounds = %v, want %v", out.Bounds(), img.Bounds())
+	}
+	if a := out.RGBAAt(7, 8).A; a != 255 {
+		t.Errorf("masked in alpha = %d, want 255", a)
+	}
+	if a := out.RGBAAt(5, 5).A; a != 0 {
+		t.Errorf("masked out alpha = %d, want 0", a)
+	}
+}
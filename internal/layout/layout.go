@@ -0,0 +1,161 @@
+// Package layout fills composition templates: a canvas with slots for text,
+// captures and images, described in JSON or YAML and rendered into one
+// finished image. Templates automate recurring imagery such as weekly status
+// reports or release notes.
+package layout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxCanvas bounds the canvas so a typo can't allocate gigabytes.
+const maxCanvas = 16384
+
+// Template is a canvas and the slots drawn on it, in order.
+type Template struct {
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Background string `json:"background,omitempty"` // #RRGGBB, white if empty
+	Slots      []Slot `json:"slots"`
+
+	dir string // Folder the template was loaded from, for image paths
+}
+
+// Slot is one element of a template. Text, source and path may contain
+// {{variables}}.
+type Slot struct {
+	Type   string `json:"type"` // text, capture, image or box
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+
+	// Text slots wrap within the slot
+	Text     string  `json:"text,omitempty"`
+	FontSize float64 `json:"fontSize,omitempty"` // Pixels, 32 if zero
+	Bold     bool    `json:"bold,omitempty"`
+	Italic   bool    `json:"italic,omitempty"`
+	Color    string  `json:"color,omitempty"`  // Text color, black if empty
+	Align    string  `json:"align,omitempty"`  // left, center or right
+	VAlign   string  `json:"valign,omitempty"` // top, middle or bottom
+
+	// Capture and image slots
+	Source string `json:"source,omitempty"` // fullscreen, display:N, window:<title> or region:x,y,w,h
+	Path   string `json:"path,omitempty"`   // Image file, relative to the template
+	Fit    string `json:"fit,omitempty"`    // contain (default), cover or stretch
+
+	// Any slot
+	Fill        string `json:"fill,omitempty"`        // Background color
+	Border      int    `json:"border,omitempty"`      // Outline thickness drawn inward
+	BorderColor string `json:"borderColor,omitempty"` // Black if empty
+}
+
+var slotTypes = map[string]bool{"text": true, "capture": true, "image": true, "box": true}
+
+// Load reads a template from a .json, .yaml or .yml file.
+func Load(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t *Template
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		t, err = ParseYAML(data)
+	default:
+		t, err = Parse(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	t.dir = filepath.Dir(path)
+	return t, nil
+}
+
+// Parse decodes a JSON template. Relative image paths resolve against the
+// working directory.
+func Parse(data []byte) (*Template, error) {
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ParseYAML decodes a YAML template. It takes the same keys as JSON.
+func ParseYAML(data []byte) (*Template, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	// Going through JSON keeps the json tags the one definition of the format
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return Parse(data)
+}
+
+func (t *Template) validate() error {
+	if t.Width <= 0 || t.Height <= 0 || t.Width > maxCanvas || t.Height > maxCanvas {
+		return fmt.Errorf("canvas size %dx%d must be between 1 and %d", t.Width, t.Height, maxCanvas)
+	}
+	for i, s := range t.Slots {
+		switch {
+		case !slotTypes[s.Type]:
+			return fmt.Errorf("slot %d: unknown type %q", i+1, s.Type)
+		case s.Width <= 0 || s.Height <= 0:
+			return fmt.Errorf("slot %d: width and height must be positive", i+1)
+		case s.Type == "capture" && s.Source == "":
+			return fmt.Errorf("slot %d: capture needs a source", i+1)
+		case s.Type == "image" && s.Path == "":
+			return fmt.Errorf("slot %d: image needs a path", i+1)
+		}
+	}
+	return nil
+}
+
+var varPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// expand replaces {{name}} with its value. Besides the given vars, date,
+// time, year and week come from now. Unknown names are an error so typos
+// don't end up in the image.
+func expand(s string, vars map[string]string, now time.Time) (string, error) {
+	var missing []string
+	out := varPattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := varPattern.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		_, week := now.ISOWeek()
+		switch name {
+		case "date":
+			return now.Format("2006-01-02")
+		case "time":
+			return now.Format("15:04")
+		case "year":
+			return strconv.Itoa(now.Year())
+		case "week":
+			return strconv.Itoa(week)
+		}
+		missing = append(missing, name)
+		return m
+	})
+	if len(missing) > 0 {
+		return "", errors.New("undefined variable " + strings.Join(missing, ", "))
+	}
+	return out, nil
+}
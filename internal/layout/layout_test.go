@@ -0,0 +1,192 @@
+package layout
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"valid", `{"width":100,"height":50,"slots":[{"type":"text","width":10,"height":10,"text":"hi"}]}`, false},
+		{"no size", `{"slots":[]}`, true},
+		{"huge canvas", `{"width":100000,"height":50}`, true},
+		{"unknown slot", `{"width":10,"height":10,"slots":[{"type":"video","width":1,"height":1}]}`, true},
+		{"empty slot", `{"width":10,"height":10,"slots":[{"type":"box"}]}`, true},
+		{"capture without source", `{"width":10,"height":10,"slots":[{"type":"capture","width":1,"height":1}]}`, true},
+		{"image without path", `{"width":10,"height":10,"slots":[{"type":"image","width":1,"height":1}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	tpl, err := ParseYAML([]byte(`
+width: 800
+height: 400
+background: "#102030"
+slots:
+  - type: text
+    x: 20
+    y: 20
+    width: 760
+    height: 60
+    text: "Week {{week}}"
+    fontSize: 40
+    bold: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpl.Width != 800 || tpl.Background != "#102030" || len(tpl.Slots) != 1 {
+		t.Fatalf("template = %+v", tpl)
+	}
+	if s := tpl.Slots[0]; s.FontSize != 40 || !s.Bold || s.Text != "Week {{week}}" {
+		t.Errorf("slot = %+v", s)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	vars := map[string]string{"version": "2.1"}
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"Release {{version}}", "Release 2.1", false},
+		{"{{ date }} {{time}}", "2024-01-15 09:30", false},
+		{"Week {{week}}, {{year}}", "Week 3, 2024", false},
+		{"no variables", "no variables", false},
+		{"{{verison}}", "", true},
+	}
+	for _, tt := range tests {
+		got, err := expand(tt.in, vars, now)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("expand(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range logo.Pix {
+		logo.Pix[i] = 255
+	}
+	f, _ := os.Create(filepath.Join(dir, "logo.png"))
+	png.Encode(f, logo)
+	f.Close()
+
+	path := filepath.Join(dir, "status.json")
+	os.WriteFile(path, []byte(`{
+		"width": 200, "height": 100, "background": "#000000",
+		"slots": [
+			{"type": "capture", "source": "window:{{app}}", "x": 0, "y": 0, "width": 100, "height": 100, "fit": "stretch"},
+			{"type": "image", "path": "logo.png", "x": 150, "y": 0, "width": 50, "height": 20},
+			{"type": "box", "x": 100, "y": 80, "width": 100, "height": 20, "fill": "#00ff00", "border": 2, "borderColor": "#0000ff"}
+		]
+	}`), 0644)
+	tpl, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var source string
+	red := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < len(red.Pix); i += 4 {
+		red.Pix[i], red.Pix[i+3] = 255, 255
+	}
+	img, err := tpl.Render(Options{
+		Vars: map[string]string{"app": "Notes"},
+		Capture: func(s string) (image.Image, error) {
+			source = s
+			return red, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != "window:Notes" {
+		t.Errorf("capture source = %q, want window:Notes", source)
+	}
+
+	tests := []struct {
+		name string
+		pt   image.Point
+		want color.RGBA
+	}{
+		{"capture", image.Pt(50, 50), color.RGBA{255, 0, 0, 255}},
+		{"contained logo", image.Pt(175, 10), color.RGBA{255, 255, 255, 255}},
+		{"beside the logo", image.Pt(155, 10), color.RGBA{0, 0, 0, 255}},
+		{"box fill", image.Pt(150, 90), color.RGBA{0, 255, 0, 255}},
+		{"box border", image.Pt(150, 80), color.RGBA{0, 0, 255, 255}},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.pt.X, tt.pt.Y); got != tt.want {
+			t.Errorf("%s pixel %v = %v, want %v", tt.name, tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	capture := `{"width":10,"height":10,"slots":[{"type":"capture","source":"display:0","width":5,"height":5}]}`
+	tpl, err := Parse([]byte(capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpl.Render(Options{}); err == nil {
+		t.Error("Render() without a capture function succeeded")
+	}
+	failing := func(string) (image.Image, error) { return nil, errors.New("no such display") }
+	if _, err := tpl.Render(Options{Capture: failing}); err == nil {
+		t.Error("Render() with a failing capture succeeded")
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	tpl, err := Parse([]byte(`{"width":200,"height":100,"slots":[
+		{"type":"text","text":"a long line of words that wraps","x":0,"y":0,"width":120,"height":100,"fontSize":20}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := tpl.Render(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wrapped text reaches the second line and never leaves its slot
+	if !hasDark(img, image.Rect(0, 25, 120, 50)) {
+		t.Error("no text on the second line")
+	}
+	if hasDark(img, image.Rect(120, 0, 200, 100)) {
+		t.Error("text drawn outside its slot")
+	}
+}
+
+func hasDark(img *image.RGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.RGBAAt(x, y).R < 128 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"winshot/internal/imaging"
+)
+
+const defaultFontSize = 32
+
+// CaptureFunc captures the screen for a capture slot source, such as
+// "window:Release Notes" or "display:1".
+type CaptureFunc func(source string) (image.Image, error)
+
+// Options supply what a template needs at run time.
+type Options struct {
+	Vars    map[string]string // Values for {{name}} in text, sources and paths
+	Now     time.Time         // For {{date}} and friends, time.Now() if zero
+	Capture CaptureFunc       // Required when the template has capture slots
+}
+
+// Render fills the template's slots and draws them in order.
+func (t *Template) Render(opts Options) (*image.RGBA, error) {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	bg := color.RGBA{255, 255, 255, 255}
+	if t.Background != "" {
+		c, err := imaging.ParseColor(t.Background)
+		if err != nil {
+			return nil, fmt.Errorf("background: %w", err)
+		}
+		bg = c
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, t.Width, t.Height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	for i, s := range t.Slots {
+		if err := t.drawSlot(canvas, s, opts); err != nil {
+			return nil, fmt.Errorf("slot %d (%s): %w", i+1, s.Type, err)
+		}
+	}
+	return canvas, nil
+}
+
+func (t *Template) drawSlot(canvas *image.RGBA, s Slot, opts Options) error {
+	r := image.Rect(s.X, s.Y, s.X+s.Width, s.Y+s.Height)
+	if s.Fill != "" {
+		c, err := imaging.ParseColor(s.Fill)
+		if err != nil {
+			return err
+		}
+		draw.Draw(canvas, r.Intersect(canvas.Bounds()), image.NewUniform(c), image.Point{}, draw.Over)
+	}
+
+	switch s.Type {
+	case "text":
+		text, err := expand(s.Text, opts.Vars, opts.Now)
+		if err != nil {
+			return err
+		}
+		if err := drawText(canvas, r, text, s); err != nil {
+			return err
+		}
+	case "capture":
+		source, err := expand(s.Source, opts.Vars, opts.Now)
+		if err != nil {
+			return err
+		}
+		if opts.Capture == nil {
+			return fmt.Errorf("no capture available for %q", source)
+		}
+		img, err := opts.Capture(source)
+		if err != nil {
+			return err
+		}
+		drawFitted(canvas, r, img, s.Fit)
+	case "image":
+		path, err := expand(s.Path, opts.Vars, opts.Now)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(t.dir, path)
+		}
+		img, err := loadImage(path)
+		if err != nil {
+			return err
+		}
+		drawFitted(canvas, r, img, s.Fit)
+	}
+
+	if s.Border > 0 {
+		c := color.RGBA{0, 0, 0, 255}
+		if s.BorderColor != "" {
+			var err error
+			if c, err = imaging.ParseColor(s.BorderColor); err != nil {
+				return err
+			}
+		}
+		imaging.StrokeRect(canvas, r, s.Border, c)
+	}
+	return nil
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+	}
+	return img, nil
+}
+
+// drawFitted scales img into r. "contain" fits it whole and centers it,
+// "cover" fills r and crops the overflow evenly, "stretch" ignores the
+// aspect ratio.
+func drawFitted(canvas *image.RGBA, r image.Rectangle, img image.Image, fit string) {
+	b := img.Bounds()
+	if b.Empty() {
+		return
+	}
+	dst, src := r, b
+	sx, sy := float64(r.Dx())/float64(b.Dx()), float64(r.Dy())/float64(b.Dy())
+	switch fit {
+	case "stretch":
+	case "cover":
+		scale := math.Max(sx, sy)
+		w, h := int(math.Round(float64(r.Dx())/scale)), int(math.Round(float64(r.Dy())/scale))
+		src = image.Rect(0, 0, w, h).Add(b.Min).Add(image.Pt((b.Dx()-w)/2, (b.Dy()-h)/2))
+	default:
+		scale := math.Min(sx, sy)
+		w, h := int(math.Round(float64(b.Dx())*scale)), int(math.Round(float64(b.Dy())*scale))
+		dst = image.Rect(0, 0, w, h).Add(r.Min).Add(image.Pt((r.Dx()-w)/2, (r.Dy()-h)/2))
+	}
+	draw.CatmullRom.Scale(canvas, dst, img, src, draw.Over, nil)
+}
+
+// drawText wraps text to the slot width and draws it clipped to the slot.
+// Explicit newlines start new lines.
+func drawText(canvas *image.RGBA, r image.Rectangle, text string, s Slot) error {
+	size := s.FontSize
+	if size <= 0 {
+		size = defaultFontSize
+	}
+	fg := color.RGBA{0, 0, 0, 255}
+	if s.Color != "" {
+		var err error
+		if fg, err = imaging.ParseColor(s.Color); err != nil {
+			return err
+		}
+	}
+	face, err := imaging.FontFace(s.Bold, s.Italic, size)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	lines := wrap(face, text, r.Dx())
+	m := face.Metrics()
+	lineHeight := m.Height.Ceil()
+	blockHeight := lineHeight * len(lines)
+	top := r.Min.Y
+	switch s.VAlign {
+	case "middle":
+		top += (r.Dy() - blockHeight) / 2
+	case "bottom":
+		top += r.Dy() - blockHeight
+	}
+
+	clip, ok := canvas.SubImage(r).(*image.RGBA)
+	if !ok {
+		return nil
+	}
+	d := &font.Drawer{Dst: clip, Src: image.NewUniform(fg), Face: face}
+	for i, line := range lines {
+		x := r.Min.X
+		switch w := font.MeasureString(face, line).Ceil(); s.Align {
+		case "center":
+			x += (r.Dx() - w) / 2
+		case "right":
+			x += r.Dx() - w
+		}
+		d.Dot = fixed.P(x, top+i*lineHeight+m.Ascent.Ceil())
+		d.DrawString(line)
+	}
+	return nil
+}
+
+// wrap breaks text into lines no wider than width, at spaces where possible.
+// A word wider than the line is left to be clipped.
+func wrap(face font.Face, text string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, w := range words[1:] {
+			if font.MeasureString(face, line+" "+w).Ceil() > width {
+				lines = append(lines, line)
+				line = w
+			} else {
+				line += " " + w
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,199 @@
+package library
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// ExportOptions configures a batch export of library images
+type ExportOptions struct {
+	Format   string // "png" or "jpeg", empty keeps each image's format
+	Quality  int    // JPEG quality 1-100, 0 uses the default of 90
+	MaxWidth int    // Downscale wider images to this width, 0 keeps the size
+	OutDir   string // Created if it doesn't exist
+}
+
+// ExportedFile pairs a library image with the file written for it
+type ExportedFile struct {
+	Source string `json:"source"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ListSince returns the library images in folderPath modified at or after
+// since, oldest first so exports read in capture order. A zero since lists
+// every image.
+func ListSince(folderPath string, since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(folderPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	index, err := OpenIndex(folderPath)
+	if err != nil {
+		index = &Index{entries: map[string]EntryMeta{}}
+	}
+
+	type dated struct {
+		path    string
+		modTime time.Time
+	}
+	var files []dated
+	for _, entry := range entries {
+		if entry.IsDir() || !supportedExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		modTime := index.capturedAt(entry.Name(), info)
+		if modTime.Before(since) {
+			continue
+		}
+		files = append(files, dated{filepath.Join(folderPath, entry.Name()), modTime})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// Export re-encodes each image into opts.OutDir. A file that fails is
+// reported in its ExportedFile and doesn't stop the rest; the returned error
+// is only for problems with the output folder or options.
+func Export(paths []string, opts ExportOptions) ([]ExportedFile, error) {
+	format := strings.ToLower(opts.Format)
+	switch format {
+	case "", "png", "jpeg", "jpg":
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", opts.Format)
+	}
+	if opts.MaxWidth < 0 {
+		return nil, fmt.Errorf("invalid max width: %d", opts.MaxWidth)
+	}
+	if opts.OutDir == "" {
+		return nil, fmt.Errorf("output folder is empty")
+	}
+	if err := os.MkdirAll(opts.OutDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	results := make([]ExportedFile, 0, len(paths))
+	for _, src := range paths {
+		result := ExportedFile{Source: src}
+		out, err := exportFile(src, format, opts)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Output = out
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// exportFile re-encodes a single image and returns the path written
+func exportFile(src, format string, opts ExportOptions) (string, error) {
+	img, err := decodeImageFile(src)
+	if err != nil {
+		return "", err
+	}
+	if opts.MaxWidth > 0 && img.Bounds().Dx() > opts.MaxWidth {
+		img = scaleToWidth(img, opts.MaxWidth)
+	}
+
+	ext := strings.ToLower(filepath.Ext(src))
+	switch format {
+	case "png":
+		ext = ".png"
+	case "jpeg", "jpg":
+		ext = ".jpg"
+	}
+
+	var buf bytes.Buffer
+	if ext == ".png" {
+		err = png.Encode(&buf, img)
+	} else {
+		quality := opts.Quality
+		if quality <= 0 || quality > 100 {
+			quality = 90
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	out := uniquePath(filepath.Join(opts.OutDir, base+ext))
+	if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// scaleToWidth downscales img to width, keeping the aspect ratio
+func scaleToWidth(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	height := max(1, b.Dy()*width/b.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// uniquePath appends _1, _2, ... to path until it names no existing file
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := base + "_" + strconv.Itoa(i) + ext
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// ParseSince parses a relative age such as "30m", "12h", "7d" or "2w", or a
+// date such as "2024-01-15", into the time it refers to before now
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+
+	units := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	unit, ok := units[s[len(s)-1]]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if !ok || err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q, use e.g. 7d, 12h or 2024-01-15", s)
+	}
+	return now.Add(-time.Duration(n) * unit), nil
+}
@@ -0,0 +1,121 @@
+package library
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestPNG(t *testing.T, path string, w, h int, modTime time.Time) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"7d", now.Add(-7 * 24 * time.Hour), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"2w", now.Add(-14 * 24 * time.Hour), false},
+		{"2024-01-10", time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), false},
+		{"7", time.Time{}, true},
+		{"xd", time.Time{}, true},
+		{"3y", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestListSince_OldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeTestPNG(t, filepath.Join(dir, "new.png"), 4, 4, now.Add(-time.Hour))
+	writeTestPNG(t, filepath.Join(dir, "old.png"), 4, 4, now.Add(-48*time.Hour))
+	writeTestPNG(t, filepath.Join(dir, "ancient.png"), 4, 4, now.Add(-30*24*time.Hour))
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+
+	paths, err := ListSince(dir, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("ListSince() error = %v", err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "old.png" || filepath.Base(paths[1]) != "new.png" {
+		t.Errorf("ListSince() = %v, want [old.png new.png]", paths)
+	}
+}
+
+func TestExport_ReencodesAndResizes(t *testing.T) {
+	src := t.TempDir()
+	out := filepath.Join(t.TempDir(), "report")
+	wide := filepath.Join(src, "wide.png")
+	small := filepath.Join(src, "small.png")
+	writeTestPNG(t, wide, 400, 200, time.Now())
+	writeTestPNG(t, small, 50, 50, time.Now())
+
+	results, err := Export([]string{wide, small, filepath.Join(src, "missing.png")}, ExportOptions{
+		Format:   "jpg",
+		MaxWidth: 100,
+		OutDir:   out,
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Export() returned %d results, want 3", len(results))
+	}
+	if results[2].Error == "" {
+		t.Error("missing source should report an error")
+	}
+
+	wantSizes := []image.Point{{100, 50}, {50, 50}}
+	for i, want := range wantSizes {
+		if filepath.Ext(results[i].Output) != ".jpg" {
+			t.Errorf("output %q, want .jpg", results[i].Output)
+			continue
+		}
+		img, err := decodeImageFile(results[i].Output)
+		if err != nil {
+			t.Fatalf("decode %s: %v", results[i].Output, err)
+		}
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("%s size = %v, want %v", filepath.Base(results[i].Output), got, want)
+		}
+	}
+
+	// A second export doesn't overwrite the first
+	again, _ := Export([]string{small}, ExportOptions{Format: "jpg", OutDir: out})
+	if again[0].Output == results[1].Output {
+		t.Errorf("second export overwrote %s", again[0].Output)
+	}
+
+	if _, err := Export(nil, ExportOptions{Format: "gif", OutDir: out}); err == nil {
+		t.Error("Export() with an unsupported format should fail")
+	}
+}
@@ -0,0 +1,330 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexFileName is the history index kept alongside the screenshots, so tags
+// follow the folder when it is moved or synced
+const IndexFileName = ".winshot-history.json"
+
+// EntryMeta is the metadata stored for a screenshot: what the user added and
+// what was known about the capture
+type EntryMeta struct {
+	Tags     []string `json:"tags,omitempty"`
+	Favorite bool     `json:"favorite,omitempty"`
+	App      string   `json:"app,omitempty"`   // Executable of the captured window
+	Title    string   `json:"title,omitempty"` // Title of the captured window
+	URL      string   `json:"url,omitempty"`   // Address of the captured browser tab
+	Text     string   `json:"text,omitempty"`  // Text recognized in the image
+
+	// Link from the last upload, and when the host deletes it for ephemeral hosts
+	SharedURL string    `json:"sharedUrl,omitempty"`
+	Expires   time.Time `json:"expires,omitzero"`
+
+	// Top-level windows on screen at capture time, topmost first
+	Windows []WindowRecord `json:"windows,omitempty"`
+
+	// Set for files written into the deduplicated store, see WriteDeduplicated
+	Hash     string    `json:"hash,omitempty"`    // SHA-256 of the stored object
+	Captured time.Time `json:"captured,omitzero"` // Hard links share one modified time, so it is kept here
+}
+
+// WindowRecord is a top-level window that was open when a screenshot was
+// taken, so a capture can later be traced back to the app, channel or tab
+// that was showing
+type WindowRecord struct {
+	Title      string `json:"title"`
+	App        string `json:"app,omitempty"` // Executable name
+	Class      string `json:"class,omitempty"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Foreground bool   `json:"foreground,omitempty"`
+	Minimized  bool   `json:"minimized,omitempty"`
+}
+
+// Filter selects library entries by their metadata
+type Filter struct {
+	Tags          []string // Entries must carry every tag
+	FavoritesOnly bool
+}
+
+// Index holds tags and favorites for the screenshots in a folder, keyed by
+// file name. It is safe for concurrent use.
+type Index struct {
+	path    string
+	entries map[string]EntryMeta
+	mu      sync.Mutex
+}
+
+// OpenIndex loads the history index of folderPath. A missing index is empty.
+func OpenIndex(folderPath string) (*Index, error) {
+	ix := &Index{
+		path:    filepath.Join(folderPath, IndexFileName),
+		entries: make(map[string]EntryMeta),
+	}
+
+	data, err := os.ReadFile(ix.path)
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history index: %w", err)
+	}
+
+	var stored struct {
+		Entries map[string]EntryMeta `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse history index: %w", err)
+	}
+	if stored.Entries != nil {
+		ix.entries = stored.Entries
+	}
+	return ix, nil
+}
+
+// Save writes the index, replacing the previous file atomically
+func (ix *Index) Save() error {
+	ix.mu.Lock()
+	data, err := json.MarshalIndent(struct {
+		Entries map[string]EntryMeta `json:"entries"`
+	}{ix.entries}, "", "  ")
+	ix.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write history index: %w", err)
+	}
+	return nil
+}
+
+// Get returns the metadata of a screenshot by file name
+func (ix *Index) Get(name string) EntryMeta {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.entries[name]
+}
+
+// AddTags adds tags to each named screenshot
+func (ix *Index) AddTags(names []string, tags []string) error {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	ix.update(names, func(m *EntryMeta) {
+		for _, tag := range normalized {
+			if !containsTag(m.Tags, tag) {
+				m.Tags = append(m.Tags, tag)
+			}
+		}
+		sort.Strings(m.Tags)
+	})
+	return nil
+}
+
+// RemoveTags removes tags from each named screenshot
+func (ix *Index) RemoveTags(names []string, tags []string) error {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	ix.update(names, func(m *EntryMeta) {
+		var kept []string
+		for _, tag := range m.Tags {
+			if !containsTag(normalized, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		m.Tags = kept
+	})
+	return nil
+}
+
+// SetFavorite marks or unmarks each named screenshot as a favorite
+func (ix *Index) SetFavorite(names []string, favorite bool) {
+	ix.update(names, func(m *EntryMeta) {
+		m.Favorite = favorite
+	})
+}
+
+// SetSource records the window a screenshot was captured from
+func (ix *Index) SetSource(name, app, title string) {
+	ix.update([]string{name}, func(m *EntryMeta) {
+		m.App = app
+		m.Title = title
+	})
+}
+
+// SetURL records the browser address a screenshot was captured from
+func (ix *Index) SetURL(name, url string) {
+	ix.update([]string{name}, func(m *EntryMeta) {
+		m.URL = url
+	})
+}
+
+// SetWindows records the windows that were open when a screenshot was taken
+func (ix *Index) SetWindows(name string, windows []WindowRecord) {
+	ix.update([]string{name}, func(m *EntryMeta) {
+		m.Windows = windows
+	})
+}
+
+// SetText records the text recognized in a screenshot, for text: searches
+func (ix *Index) SetText(name, text string) {
+	ix.update([]string{name}, func(m *EntryMeta) {
+		m.Text = text
+	})
+}
+
+// RecordText stores the text recognized in the screenshot at path in the
+// history index of its folder, so text: searches and bare words find it
+func RecordText(path, text string) error {
+	index, err := OpenIndex(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	index.SetText(filepath.Base(path), strings.TrimSpace(text))
+	return index.Save()
+}
+
+// SetShared records the link a screenshot was last uploaded to and when
+// that link expires, zero if it doesn't
+func (ix *Index) SetShared(name, url string, expires time.Time) {
+	ix.update([]string{name}, func(m *EntryMeta) {
+		m.SharedURL = url
+		m.Expires = expires
+	})
+}
+
+// SetObject records the store object a screenshot is a link to
+func (ix *Index) SetObject(name, hash string, captured time.Time) {
+	ix.update([]string{name}, func(m *EntryMeta) {
+		m.Hash = hash
+		m.Captured = captured
+	})
+}
+
+// Remove forgets a screenshot, e.g. after it was deleted
+func (ix *Index) Remove(name string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	delete(ix.entries, name)
+}
+
+// snapshot returns a copy of every entry
+func (ix *Index) snapshot() map[string]EntryMeta {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	entries := make(map[string]EntryMeta, len(ix.entries))
+	for name, m := range ix.entries {
+		entries[name] = m
+	}
+	return entries
+}
+
+// Tags returns every tag in use, sorted
+func (ix *Index) Tags() []string {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	var tags []string
+	for _, m := range ix.entries {
+		for _, tag := range m.Tags {
+			if !containsTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Matches reports whether a screenshot's metadata satisfies the filter
+func (f Filter) Matches(m EntryMeta) bool {
+	if f.FavoritesOnly && !m.Favorite {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !containsTag(m.Tags, NormalizeTag(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// update applies fn to each named entry, dropping entries left empty
+func (ix *Index) update(names []string, fn func(*EntryMeta)) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, name := range names {
+		m := ix.entries[name]
+		// Copy so slices handed out by Get never change underneath callers
+		m.Tags = append([]string(nil), m.Tags...)
+		fn(&m)
+		if m.empty() {
+			delete(ix.entries, name)
+		} else {
+			ix.entries[name] = m
+		}
+	}
+}
+
+// empty reports whether m holds nothing worth storing
+func (m EntryMeta) empty() bool {
+	return len(m.Tags) == 0 && !m.Favorite && m.App == "" && m.Title == "" && m.Text == "" &&
+		m.SharedURL == "" && m.Hash == "" && m.Captured.IsZero()
+}
+
+// capturedAt returns when a screenshot was taken: the recorded capture time
+// for store links, the file's modified time otherwise
+func (ix *Index) capturedAt(name string, info os.FileInfo) time.Time {
+	if m := ix.Get(name); !m.Captured.IsZero() {
+		return m.Captured
+	}
+	return info.ModTime()
+}
+
+// NormalizeTag lowercases a tag and joins words with dashes, so "Release
+// Notes" and "release-notes" are the same tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		n := NormalizeTag(tag)
+		if n == "" {
+			return nil, fmt.Errorf("tag is empty")
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
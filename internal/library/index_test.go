@@ -0,0 +1,102 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIndex_TagsAndFavoritesPersist(t *testing.T) {
+	dir := t.TempDir()
+
+	ix, err := OpenIndex(dir)
+	if err != nil {
+		t.Fatalf("OpenIndex() on empty folder error = %v", err)
+	}
+	if err := ix.AddTags([]string{"a.png", "b.png"}, []string{"Release Notes", "bug"}); err != nil {
+		t.Fatalf("AddTags() error = %v", err)
+	}
+	ix.SetFavorite([]string{"b.png"}, true)
+	if err := ix.RemoveTags([]string{"b.png"}, []string{"bug"}); err != nil {
+		t.Fatalf("RemoveTags() error = %v", err)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reopened, err := OpenIndex(dir)
+	if err != nil {
+		t.Fatalf("OpenIndex() error = %v", err)
+	}
+	if got := reopened.Get("a.png"); !reflect.DeepEqual(got.Tags, []string{"bug", "release-notes"}) || got.Favorite {
+		t.Errorf("a.png = %+v, want tags [bug release-notes], not favorite", got)
+	}
+	if got := reopened.Get("b.png"); !reflect.DeepEqual(got.Tags, []string{"release-notes"}) || !got.Favorite {
+		t.Errorf("b.png = %+v, want tags [release-notes], favorite", got)
+	}
+	if got := reopened.Tags(); !reflect.DeepEqual(got, []string{"bug", "release-notes"}) {
+		t.Errorf("Tags() = %v, want [bug release-notes]", got)
+	}
+
+	// Entries with nothing left are dropped
+	reopened.RemoveTags([]string{"a.png"}, []string{"bug", "release-notes"})
+	reopened.Remove("b.png")
+	if got := reopened.Tags(); len(got) != 0 {
+		t.Errorf("Tags() after clearing = %v, want none", got)
+	}
+
+	if err := ix.AddTags([]string{"a.png"}, []string{"  "}); err == nil {
+		t.Error("AddTags() with a blank tag should fail")
+	}
+}
+
+func TestFilter_Matches(t *testing.T) {
+	meta := EntryMeta{Tags: []string{"bug", "release-notes"}, Favorite: true}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		meta   EntryMeta
+		want   bool
+	}{
+		{"empty filter", Filter{}, EntryMeta{}, true},
+		{"tag normalized", Filter{Tags: []string{"Release Notes"}}, meta, true},
+		{"all tags required", Filter{Tags: []string{"bug", "ui"}}, meta, false},
+		{"favorites only", Filter{FavoritesOnly: true}, EntryMeta{Tags: []string{"bug"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Matches(tt.meta); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScanFolder_IncludesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "shot.png"), 8, 8, time.Now())
+
+	ix, _ := OpenIndex(dir)
+	ix.AddTags([]string{"shot.png"}, []string{"demo"})
+	ix.SetFavorite([]string{"shot.png"}, true)
+	ix.SetShared("shot.png", "https://0x0.example/abc.png", time.Date(2024, 1, 15, 14, 30, 45, 0, time.UTC))
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, IndexFileName)); err != nil {
+		t.Fatalf("index file not written: %v", err)
+	}
+
+	images, err := ScanFolder(dir, DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("ScanFolder() error = %v", err)
+	}
+	if len(images) != 1 || !images[0].Favorite || !reflect.DeepEqual(images[0].Tags, []string{"demo"}) {
+		t.Errorf("ScanFolder() = %+v, want one favorite tagged demo", images)
+	}
+	if len(images) == 1 && (images[0].SharedURL != "https://0x0.example/abc.png" || images[0].Expires != "2024-01-15T14:30:45Z") {
+		t.Errorf("ScanFolder() shared link = %q expiring %q", images[0].SharedURL, images[0].Expires)
+	}
+}
@@ -12,12 +12,16 @@ import (
 
 // LibraryImage represents a screenshot in the library
 type LibraryImage struct {
-	Filepath     string `json:"filepath"`
-	Filename     string `json:"filename"`
-	ModifiedDate string `json:"modifiedDate"`
-	Thumbnail    string `json:"thumbnail"` // Base64 encoded PNG
-	Width        int    `json:"width"`
-	Height       int    `json:"height"`
+	Filepath     string   `json:"filepath"`
+	Filename     string   `json:"filename"`
+	ModifiedDate string   `json:"modifiedDate"`
+	Thumbnail    string   `json:"thumbnail"` // Base64 encoded PNG
+	Width        int      `json:"width"`
+	Height       int      `json:"height"`
+	Tags         []string `json:"tags,omitempty"`
+	Favorite     bool     `json:"favorite"`
+	SharedURL    string   `json:"sharedUrl,omitempty"` // Link from the last upload
+	Expires      string   `json:"expires,omitempty"`   // RFC 3339 time the shared link expires, empty if it doesn't
 }
 
 // ScanOptions configures the folder scan behavior
@@ -71,6 +75,12 @@ func ScanFolder(folderPath string, opts ScanOptions) ([]LibraryImage, error) {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
 
+	// Tags and favorites are optional, so an unreadable index only loses them
+	index, err := OpenIndex(folderPath)
+	if err != nil {
+		index = &Index{entries: map[string]EntryMeta{}}
+	}
+
 	var images []LibraryImage
 
 	for _, entry := range entries {
@@ -99,13 +109,22 @@ func ScanFolder(folderPath string, opts ScanOptions) ([]LibraryImage, error) {
 			continue // Skip files we can't read/decode
 		}
 
+		meta := index.Get(entry.Name())
+		var expires string
+		if !meta.Expires.IsZero() {
+			expires = meta.Expires.UTC().Format(time.RFC3339)
+		}
 		images = append(images, LibraryImage{
 			Filepath:     fullPath,
 			Filename:     entry.Name(),
-			ModifiedDate: fileInfo.ModTime().Format(time.RFC3339),
+			ModifiedDate: index.capturedAt(entry.Name(), fileInfo).Format(time.RFC3339),
 			Thumbnail:    thumb,
 			Width:        width,
 			Height:       height,
+			Tags:         meta.Tags,
+			Favorite:     meta.Favorite,
+			SharedURL:    meta.SharedURL,
+			Expires:      expires,
 		})
 
 		// Respect MaxFiles limit
@@ -0,0 +1,217 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed history search such as
+//
+//	app:chrome before:2024-06-01 tag:bug text:"timeout error"
+//
+// Every term must match. Values are case-insensitive substrings, except tag:
+// which matches a whole tag. window: matches the title or executable of any
+// window that was open at capture time. Words without a field match the file name,
+// window title or recognized text. A leading "-" negates a term.
+type Query struct {
+	terms []queryTerm
+}
+
+type queryTerm struct {
+	field  string
+	value  string
+	date   time.Time
+	negate bool
+}
+
+// queryFields are the fields a term may name
+var queryFields = map[string]bool{
+	"app":    true,
+	"title":  true,
+	"url":    true,
+	"tag":    true,
+	"text":   true,
+	"window": true,
+	"name":   true,
+	"before": true,
+	"after":  true,
+	"is":     true,
+}
+
+// Document is a screenshot as seen by a query
+type Document struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+	EntryMeta
+}
+
+// ParseQuery parses a search query. Dates for before: and after: use
+// ParseSince, so "before:2024-06-01" and "after:7d" both work; before: is
+// exclusive and after: inclusive. An empty query matches everything.
+func ParseQuery(s string, now time.Time) (Query, error) {
+	words, err := splitQuery(s)
+	if err != nil {
+		return Query{}, err
+	}
+
+	var q Query
+	for _, word := range words {
+		var term queryTerm
+		if strings.HasPrefix(word, "-") && len(word) > 1 {
+			term.negate = true
+			word = word[1:]
+		}
+
+		if i := strings.IndexByte(word, ':'); i > 0 && queryFields[strings.ToLower(word[:i])] {
+			term.field = strings.ToLower(word[:i])
+			term.value = word[i+1:]
+		} else {
+			term.value = word
+		}
+		if term.value == "" {
+			return Query{}, fmt.Errorf("empty value for %s:", term.field)
+		}
+
+		switch term.field {
+		case "before", "after":
+			term.date, err = ParseSince(term.value, now)
+			if err != nil {
+				return Query{}, fmt.Errorf("invalid %s date %q, use e.g. 2024-06-01 or 7d", term.field, term.value)
+			}
+		case "is":
+			if v := strings.ToLower(term.value); v != "favorite" && v != "fav" {
+				return Query{}, fmt.Errorf("unknown is:%s, only is:favorite is supported", term.value)
+			}
+		case "tag":
+			term.value = NormalizeTag(term.value)
+		default:
+			term.value = strings.ToLower(term.value)
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+// Matches reports whether a screenshot satisfies every term of the query
+func (q Query) Matches(d Document) bool {
+	for _, term := range q.terms {
+		if term.matches(d) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t queryTerm) matches(d Document) bool {
+	switch t.field {
+	case "app":
+		return containsFold(d.App, t.value)
+	case "title":
+		return containsFold(d.Title, t.value)
+	case "url":
+		return containsFold(d.URL, t.value)
+	case "text":
+		return containsFold(d.Text, t.value)
+	case "window":
+		for _, w := range d.Windows {
+			if containsFold(w.Title, t.value) || containsFold(w.App, t.value) {
+				return true
+			}
+		}
+		return false
+	case "name":
+		return containsFold(d.Name, t.value)
+	case "tag":
+		return containsTag(d.Tags, t.value)
+	case "before":
+		return d.Modified.Before(t.date)
+	case "after":
+		return !d.Modified.Before(t.date)
+	case "is":
+		return d.Favorite
+	default:
+		return containsFold(d.Name, t.value) || containsFold(d.Title, t.value) || containsFold(d.Text, t.value)
+	}
+}
+
+// containsFold reports whether s contains the lowercase substr, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), substr)
+}
+
+// splitQuery splits a query on whitespace, keeping double-quoted runs such as
+// text:"connection timeout" together and dropping the quotes
+func splitQuery(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inQuote, quoted := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			quoted = true
+		case unicode.IsSpace(r) && !inQuote:
+			if word.Len() > 0 || quoted {
+				words = append(words, word.String())
+			}
+			word.Reset()
+			quoted = false
+		default:
+			word.WriteRune(r)
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in query")
+	}
+	if word.Len() > 0 || quoted {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Search returns the screenshots in folderPath matching q, newest first,
+// without decoding any images
+func Search(folderPath string, q Query) ([]Document, error) {
+	entries, err := os.ReadDir(folderPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	// Metadata is optional, so an unreadable index only limits what matches
+	index, err := OpenIndex(folderPath)
+	if err != nil {
+		index = &Index{entries: map[string]EntryMeta{}}
+	}
+
+	matched := []Document{}
+	for _, entry := range entries {
+		if entry.IsDir() || !supportedExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		d := Document{
+			Name:      entry.Name(),
+			Path:      filepath.Join(folderPath, entry.Name()),
+			Modified:  index.capturedAt(entry.Name(), info),
+			EntryMeta: index.Get(entry.Name()),
+		}
+		if q.Matches(d) {
+			matched = append(matched, d)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Modified.After(matched[j].Modified)
+	})
+	return matched, nil
+}
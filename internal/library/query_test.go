@@ -0,0 +1,145 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseQuery_Matches(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	doc := Document{
+		Name:     "screenshot_2024-05-20.png",
+		Modified: time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC),
+		EntryMeta: EntryMeta{
+			Tags:     []string{"bug", "release-notes"},
+			Favorite: true,
+			App:      "chrome.exe",
+			Title:    "Dashboard - Google Chrome",
+			URL:      "https://grafana.example.com/d/api-latency",
+			Text:     "Request failed: connection timeout",
+			Windows: []WindowRecord{
+				{Title: "Dashboard - Google Chrome", App: "chrome.exe", Foreground: true},
+				{Title: "#incidents - Acme - Slack", App: "slack.exe"},
+			},
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"app:chrome", true},
+		{"APP:Chrome", true},
+		{"app:firefox", false},
+		{"tag:bug", true},
+		{"tag:bu", false},
+		{`tag:"Release Notes"`, true},
+		{`text:"connection timeout"`, true},
+		{`text:"timeout connection"`, false},
+		{"before:2024-06-01", true},
+		{"before:2024-05-20", false},
+		{"after:2024-05-20", true},
+		{"after:7d", false},
+		{"is:favorite", true},
+		{"name:05-20", true},
+		{"dashboard", true},
+		{"timeout", true},
+		{"-tag:bug", false},
+		{"-app:firefox", true},
+		{"url:grafana.example.com", true},
+		{"url:kibana", false},
+		{"window:#incidents", true},
+		{"window:slack.exe", true},
+		{"window:outlook", false},
+		{"incidents", false}, // Bare words don't search background windows
+		{`app:chrome before:2024-06-01 tag:bug text:"timeout"`, true},
+		{`app:chrome tag:feature`, false},
+		{"note:x", false}, // Not a field, so a bare word
+	}
+
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query, now)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) error = %v", tt.query, err)
+			continue
+		}
+		if got := q.Matches(doc); got != tt.want {
+			t.Errorf("ParseQuery(%q).Matches() = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, query := range []string{
+		`text:"unterminated`,
+		"before:soon",
+		"is:pinned",
+		"tag:",
+	} {
+		if _, err := ParseQuery(query, time.Now()); err == nil {
+			t.Errorf("ParseQuery(%q) should fail", query)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeTestPNG(t, filepath.Join(dir, "a.png"), 4, 4, now.Add(-2*time.Hour))
+	writeTestPNG(t, filepath.Join(dir, "b.png"), 4, 4, now.Add(-time.Hour))
+	writeTestPNG(t, filepath.Join(dir, "c.png"), 4, 4, now)
+
+	index, _ := OpenIndex(dir)
+	index.AddTags([]string{"a.png", "b.png"}, []string{"bug"})
+	index.SetSource("b.png", "code.exe", "main.go - Visual Studio Code")
+	index.SetWindows("a.png", []WindowRecord{{Title: "#incidents - Acme - Slack", App: "slack.exe", Width: 800, Height: 600}})
+	if err := index.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, _ := ParseQuery("tag:bug", now)
+	docs, err := Search(dir, q)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(docs) != 2 || docs[0].Name != "b.png" || docs[1].Name != "a.png" {
+		t.Errorf("Search(tag:bug) = %v, want [b.png a.png]", docs)
+	}
+
+	q, _ = ParseQuery("app:code", now)
+	docs, _ = Search(dir, q)
+	if len(docs) != 1 || docs[0].Title != "main.go - Visual Studio Code" {
+		t.Errorf("Search(app:code) = %v, want b.png with its title", docs)
+	}
+
+	// Window snapshots survive the index round trip
+	q, _ = ParseQuery("window:#incidents", now)
+	docs, _ = Search(dir, q)
+	if len(docs) != 1 || docs[0].Name != "a.png" || docs[0].Windows[0].Width != 800 {
+		t.Errorf("Search(window:#incidents) = %v, want a.png with its windows", docs)
+	}
+}
+
+func TestRecordText(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	path := filepath.Join(dir, "error.png")
+	writeTestPNG(t, path, 4, 4, now)
+	writeTestPNG(t, filepath.Join(dir, "other.png"), 4, 4, now)
+
+	if err := RecordText(path, "Request failed: connection refused\n"); err != nil {
+		t.Fatalf("RecordText() error = %v", err)
+	}
+	for _, query := range []string{`text:"connection refused"`, "refused"} {
+		q, _ := ParseQuery(query, now)
+		docs, err := Search(dir, q)
+		if err != nil {
+			t.Fatalf("Search(%s) error = %v", query, err)
+		}
+		if len(docs) != 1 || docs[0].Name != "error.png" || docs[0].Text != "Request failed: connection refused" {
+			t.Errorf("Search(%s) = %v, want error.png with its text", query, docs)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StoreDirName is the folder inside the history folder holding deduplicated
+// originals, each named by the SHA-256 of its content
+const StoreDirName = ".winshot-store"
+
+// VacuumReport summarizes what Vacuum cleaned up
+type VacuumReport struct {
+	Forgotten    int   `json:"forgotten"`    // Index entries of files that no longer exist
+	Deduplicated int   `json:"deduplicated"` // Files replaced by a link to an identical object
+	SavedBytes   int64 `json:"savedBytes"`   // Disk space freed by deduplicating
+	Removed      int   `json:"removed"`      // Store objects no screenshot referred to
+	FreedBytes   int64 `json:"freedBytes"`   // Disk space freed by removing objects
+}
+
+// WriteDeduplicated writes data to path like os.WriteFile, but stores the
+// content once under StoreDirName and makes path a hard link to it, so
+// identical captures don't take extra space. The index records the object as
+// the file's reference, which is what Vacuum counts.
+//
+// Links share their content, so a file edited in place changes every
+// identical capture. WinShot never edits saved files, but other tools might.
+// Where hard links aren't supported, such as on FAT32 or network drives, a
+// plain copy is written instead.
+func WriteDeduplicated(path string, data []byte, captured time.Time) error {
+	folder, name := filepath.Split(path)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	obj := objectPath(folder, hash, filepath.Ext(name))
+
+	if _, err := os.Stat(obj); os.IsNotExist(err) {
+		if err := writeObject(obj, data); err != nil {
+			return writeCopy(folder, name, data)
+		}
+	}
+	// Link under a temporary name and rename it over path: a capture saved
+	// under the name of an earlier one must replace that link, not write
+	// through it into an object other captures share
+	tmp := path + ".link"
+	os.Remove(tmp)
+	if err := os.Link(obj, tmp); err != nil {
+		return writeCopy(folder, name, data)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	index, err := OpenIndex(folder)
+	if err != nil {
+		return err
+	}
+	index.SetObject(name, hash, captured)
+	return index.Save()
+}
+
+// Vacuum tidies the history folder: it forgets index entries for deleted
+// files and removes store objects no file refers to any more. With dedupe it
+// first moves plain files into the store, replacing duplicates with links.
+func Vacuum(folderPath string, dedupe bool) (VacuumReport, error) {
+	var report VacuumReport
+	index, err := OpenIndex(folderPath)
+	if err != nil {
+		return report, err
+	}
+
+	for name := range index.snapshot() {
+		if _, err := os.Lstat(filepath.Join(folderPath, name)); os.IsNotExist(err) {
+			index.Remove(name)
+			report.Forgotten++
+		}
+	}
+
+	if dedupe {
+		entries, err := os.ReadDir(folderPath)
+		if err != nil {
+			return report, fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !supportedExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+				continue
+			}
+			if index.Get(entry.Name()).Hash != "" {
+				continue
+			}
+			saved, err := adoptFile(folderPath, entry, index)
+			if err != nil {
+				continue // Left as a plain file, tried again next time
+			}
+			if saved > 0 {
+				report.Deduplicated++
+				report.SavedBytes += saved
+			}
+		}
+	}
+
+	refs := make(map[string]int)
+	for _, m := range index.snapshot() {
+		if m.Hash != "" {
+			refs[m.Hash]++
+		}
+	}
+	storeDir := filepath.Join(folderPath, StoreDirName)
+	err = filepath.WalkDir(storeDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		hash := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		if refs[hash] > 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err == nil && os.Remove(p) == nil {
+			report.Removed++
+			report.FreedBytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return report, index.Save()
+}
+
+// adoptFile moves a plain history file into the store. If an identical object
+// already exists the file is replaced by a link to it and the bytes saved are
+// returned; otherwise the file becomes the object.
+func adoptFile(folderPath string, entry fs.DirEntry, index *Index) (int64, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return 0, err
+	}
+	path := filepath.Join(folderPath, entry.Name())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	obj := objectPath(folderPath, hash, filepath.Ext(entry.Name()))
+
+	var saved int64
+	if _, err := os.Stat(obj); err == nil {
+		// Link under a temporary name first so the file is never missing
+		tmp := path + ".link"
+		if err := os.Link(obj, tmp); err != nil {
+			return 0, err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return 0, err
+		}
+		saved = info.Size()
+	} else {
+		if err := os.MkdirAll(filepath.Dir(obj), 0755); err != nil {
+			return 0, err
+		}
+		if err := os.Link(path, obj); err != nil {
+			return 0, err
+		}
+	}
+
+	index.SetObject(entry.Name(), hash, info.ModTime())
+	return saved, nil
+}
+
+// objectPath returns where content with the given hash is stored, fanned out
+// by the first two hex digits to keep folders small
+func objectPath(folderPath, hash, ext string) string {
+	return filepath.Join(folderPath, StoreDirName, hash[:2], hash+strings.ToLower(ext))
+}
+
+// writeObject writes a store object atomically, so a partial write is never
+// mistaken for the real content
+// writeCopy writes data to a plain file in folder, replacing whatever link
+// was there, and forgets the object the name referred to
+func writeCopy(folder, name string, data []byte) error {
+	path := filepath.Join(folder, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	index, err := OpenIndex(folder)
+	if err != nil {
+		return err
+	}
+	if index.Get(name).Hash == "" {
+		return nil
+	}
+	index.SetObject(name, "", time.Time{})
+	return index.Save()
+}
+
+func writeObject(obj string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(obj), 0755); err != nil {
+		return err
+	}
+	tmp := obj + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, obj); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package library
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// storeObjects counts the objects in a history folder's store
+func storeObjects(t *testing.T, dir string) int {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, StoreDirName, "*", "*"))
+	return len(matches)
+}
+
+func TestWriteDeduplicated(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("identical capture")
+	first := time.Date(2024, 1, 15, 10, 0, 0, 0, time.Local)
+	second := first.Add(time.Minute)
+
+	if err := WriteDeduplicated(filepath.Join(dir, "a.png"), data, first); err != nil {
+		t.Fatalf("WriteDeduplicated() error = %v", err)
+	}
+	if err := WriteDeduplicated(filepath.Join(dir, "b.png"), data, second); err != nil {
+		t.Fatalf("WriteDeduplicated() error = %v", err)
+	}
+	if err := WriteDeduplicated(filepath.Join(dir, "c.png"), []byte("other"), second); err != nil {
+		t.Fatalf("WriteDeduplicated() error = %v", err)
+	}
+
+	if got := storeObjects(t, dir); got != 2 {
+		t.Errorf("store holds %d objects, want 2", got)
+	}
+	for _, name := range []string{"a.png", "b.png"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, data)
+		}
+	}
+
+	// Links share a modified time, so listings use the recorded capture time
+	paths, _ := ListSince(dir, second)
+	if len(paths) != 2 || filepath.Base(paths[0]) == "a.png" || filepath.Base(paths[1]) == "a.png" {
+		t.Errorf("ListSince() = %v, want b.png and c.png", paths)
+	}
+}
+
+func TestWriteDeduplicated_ReplacesLink(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	data := []byte("identical capture")
+	path := filepath.Join(dir, "capture.png")
+
+	// Two captures in the same second get the same name from the timestamp
+	// pattern, while an identical capture elsewhere shares the first object
+	if err := WriteDeduplicated(filepath.Join(dir, "other.png"), data, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDeduplicated(path, data, now); err != nil {
+		t.Fatal(err)
+	}
+	newer := []byte("newer capture")
+	if err := WriteDeduplicated(path, newer, now); err != nil {
+		t.Fatalf("WriteDeduplicated() over a link error = %v", err)
+	}
+
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, newer) {
+		t.Errorf("capture.png = %q, want %q", got, newer)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "other.png")); !bytes.Equal(got, data) {
+		t.Errorf("other.png = %q, want it untouched as %q", got, data)
+	}
+	index, _ := OpenIndex(dir)
+	sum := sha256.Sum256(newer)
+	if got := index.Get("capture.png").Hash; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("index hash = %s, want the newer capture's", got)
+	}
+	if _, err := os.Stat(path + ".link"); !os.IsNotExist(err) {
+		t.Errorf("temporary link left behind: %v", err)
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	data := []byte("same pixels")
+
+	WriteDeduplicated(filepath.Join(dir, "linked.png"), data, now)
+	WriteDeduplicated(filepath.Join(dir, "gone.png"), []byte("deleted later"), now)
+	os.Remove(filepath.Join(dir, "gone.png"))
+	os.WriteFile(filepath.Join(dir, "copy.png"), data, 0644)
+	os.WriteFile(filepath.Join(dir, "unique.png"), []byte("only one"), 0644)
+
+	report, err := Vacuum(dir, false)
+	if err != nil {
+		t.Fatalf("Vacuum() error = %v", err)
+	}
+	if report.Forgotten != 1 || report.Removed != 1 || report.Deduplicated != 0 {
+		t.Errorf("Vacuum(dedupe=false) = %+v, want 1 forgotten, 1 removed", report)
+	}
+
+	report, err = Vacuum(dir, true)
+	if err != nil {
+		t.Fatalf("Vacuum() error = %v", err)
+	}
+	if report.Deduplicated != 1 || report.SavedBytes != int64(len(data)) || report.Removed != 0 {
+		t.Errorf("Vacuum(dedupe=true) = %+v, want copy.png deduplicated", report)
+	}
+	if got := storeObjects(t, dir); got != 2 {
+		t.Errorf("store holds %d objects, want 2", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "copy.png")); !bytes.Equal(got, data) {
+		t.Errorf("copy.png = %q after dedupe, want %q", got, data)
+	}
+
+	index, _ := OpenIndex(dir)
+	if index.Get("copy.png").Hash != index.Get("linked.png").Hash {
+		t.Error("copy.png and linked.png should refer to the same object")
+	}
+}
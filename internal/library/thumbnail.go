@@ -16,26 +16,7 @@ import (
 // GenerateThumbnail creates a base64 PNG thumbnail from an image file
 // Returns: thumbnail base64 string, original width, original height, error
 func GenerateThumbnail(imagePath string, maxWidth, maxHeight int) (string, int, int, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return "", 0, 0, err
-	}
-	defer file.Close()
-
-	// Decode based on extension
-	var img image.Image
-	ext := strings.ToLower(filepath.Ext(imagePath))
-
-	switch ext {
-	case ".png":
-		img, err = png.Decode(file)
-	case ".jpg", ".jpeg":
-		img, err = jpeg.Decode(file)
-	default:
-		// Try generic decode for other formats
-		img, _, err = image.Decode(file)
-	}
-
+	img, err := decodeImageFile(imagePath)
 	if err != nil {
 		return "", 0, 0, err
 	}
@@ -90,3 +71,24 @@ func calculateThumbnailSize(origW, origH, maxW, maxH int) (int, int) {
 
 	return newW, newH
 }
+
+// decodeImageFile decodes an image file based on its extension
+func decodeImageFile(imagePath string) (image.Image, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var img image.Image
+	switch strings.ToLower(filepath.Ext(imagePath)) {
+	case ".png":
+		img, err = png.Decode(file)
+	case ".jpg", ".jpeg":
+		img, err = jpeg.Decode(file)
+	default:
+		// Try generic decode for other formats
+		img, _, err = image.Decode(file)
+	}
+	return img, err
+}
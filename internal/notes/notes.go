@@ -0,0 +1,43 @@
+// Package notes files captures into note-taking apps: a Markdown note in an
+// Obsidian vault, or a page in a OneNote section through Microsoft Graph.
+package notes
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Note is a capture on its way into a note-taking app.
+type Note struct {
+	Title    string    // Heading and page title
+	Tags     []string  // Without the leading '#'
+	Source   string    // Page the capture was taken from, if known
+	Time     time.Time // When the capture was taken
+	Image    []byte    // Encoded image
+	Filename string    // Image file name, e.g. "winshot_2024-01-15_14-30-45.png"
+}
+
+// cleanTags drops the leading '#' that people type out of habit and
+// replaces characters neither app allows in a tag with '-'. Empty, repeated
+// and purely numeric tags, which Obsidian does not treat as tags, are
+// dropped.
+func cleanTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '/' {
+				return r
+			}
+			return '-'
+		}, strings.TrimLeft(strings.TrimSpace(tag), "#"))
+		tag = strings.Trim(tag, "-/")
+		if strings.TrimFunc(tag, unicode.IsDigit) == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		out = append(out, tag)
+	}
+	return out
+}
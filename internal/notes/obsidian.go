@@ -0,0 +1,118 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultObsidianFolder is the vault folder notes go to when none is set.
+const DefaultObsidianFolder = "Screenshots"
+
+// maxNameTries bounds the numbered names tried when a file name is taken.
+const maxNameTries = 1000
+
+// SaveToVault writes the image and a Markdown note embedding it into folder
+// inside an Obsidian vault, creating the folder if needed, and returns the
+// note's path. folder is relative to the vault. Existing files are never
+// overwritten; a taken name gets a number instead.
+func SaveToVault(vault, folder string, n Note) (string, error) {
+	if vault == "" {
+		return "", errors.New("no Obsidian vault is set")
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("Obsidian vault %s not found", vault)
+	}
+	if folder == "" {
+		folder = DefaultObsidianFolder
+	}
+	folder = filepath.FromSlash(folder)
+	if !filepath.IsLocal(folder) {
+		return "", fmt.Errorf("folder %q is not inside the vault", folder)
+	}
+	name := filepath.Base(n.Filename)
+	if name == "." || name == string(filepath.Separator) {
+		return "", errors.New("note image has no file name")
+	}
+
+	dir := filepath.Join(vault, folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	imagePath, err := createUnique(dir, name, n.Image)
+	if err != nil {
+		return "", err
+	}
+	image := filepath.Base(imagePath)
+	note := []byte(obsidianMarkdown(n, image))
+	notePath, err := createUnique(dir, strings.TrimSuffix(image, filepath.Ext(image))+".md", note)
+	if err != nil {
+		os.Remove(imagePath)
+		return "", err
+	}
+	return notePath, nil
+}
+
+// obsidianMarkdown returns the note for a capture: properties with the
+// time, tags and source, the title as a heading and an embed of the image.
+// Values are quoted so tags such as "yes" stay strings.
+func obsidianMarkdown(n Note, image string) string {
+	var props strings.Builder
+	if !n.Time.IsZero() {
+		fmt.Fprintf(&props, "created: %s\n", n.Time.Format(time.RFC3339))
+	}
+	if tags := cleanTags(n.Tags); len(tags) > 0 {
+		props.WriteString("tags:\n")
+		for _, tag := range tags {
+			fmt.Fprintf(&props, "  - %s\n", strconv.Quote(tag))
+		}
+	}
+	if n.Source != "" {
+		fmt.Fprintf(&props, "source: %s\n", strconv.Quote(n.Source))
+	}
+
+	var b strings.Builder
+	if props.Len() > 0 {
+		b.WriteString("---\n" + props.String() + "---\n\n")
+	}
+	if title := strings.Join(strings.Fields(n.Title), " "); title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", title)
+	}
+	fmt.Fprintf(&b, "![[%s]]\n", image)
+	return b.String()
+}
+
+// createUnique writes data to name in dir, or to "name-2", "name-3" and so
+// on before the extension if it is taken, and returns the path written.
+func createUnique(dir, name string, data []byte) (string, error) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; i <= maxNameTries; i++ {
+		path := filepath.Join(dir, name)
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, i, ext))
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("no free file name for %s in %s", name, dir)
+}
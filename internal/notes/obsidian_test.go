@@ -0,0 +1,105 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testNote() Note {
+	return Note{
+		Title:    "Build failed\non main",
+		Tags:     []string{"#work", "ci runs", "Work", "2024", "yes"},
+		Source:   "https://ci.example.com/runs/42",
+		Time:     time.Date(2024, 1, 15, 14, 30, 45, 0, time.UTC),
+		Image:    []byte("png data"),
+		Filename: "winshot_2024-01-15_14-30-45.png",
+	}
+}
+
+func TestCleanTags(t *testing.T) {
+	got := cleanTags([]string{" #todo ", "two words", "Todo", "2024", "", "#", "a/b", "q1-2024", "-x-"})
+	want := []string{"todo", "two-words", "a/b", "q1-2024", "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cleanTags = %q, want %q", got, want)
+	}
+}
+
+func TestSaveToVault(t *testing.T) {
+	vault := t.TempDir()
+	path, err := SaveToVault(vault, "Inbox/Shots", testNote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(vault, "Inbox", "Shots", "winshot_2024-01-15_14-30-45.md"); path != want {
+		t.Errorf("note path = %s, want %s", path, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(vault, "Inbox", "Shots", "winshot_2024-01-15_14-30-45.png")); err != nil || string(data) != "png data" {
+		t.Errorf("image = %q, %v", data, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `---
+created: 2024-01-15T14:30:45Z
+tags:
+  - "work"
+  - "ci-runs"
+  - "yes"
+source: "https://ci.example.com/runs/42"
+---
+
+# Build failed on main
+
+![[winshot_2024-01-15_14-30-45.png]]
+`
+	if string(data) != want {
+		t.Errorf("note =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestSaveToVault_KeepsExistingFiles(t *testing.T) {
+	vault := t.TempDir()
+	first, err := SaveToVault(vault, "", testNote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := SaveToVault(vault, "", testNote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(first) != filepath.Join(vault, DefaultObsidianFolder) {
+		t.Errorf("note saved to %s, want the default folder", first)
+	}
+	if !strings.HasSuffix(second, "winshot_2024-01-15_14-30-45-2.md") {
+		t.Errorf("second note = %s, want a numbered name", second)
+	}
+	data, _ := os.ReadFile(second)
+	if !strings.Contains(string(data), "![[winshot_2024-01-15_14-30-45-2.png]]") {
+		t.Errorf("second note does not embed its own image:\n%s", data)
+	}
+}
+
+func TestSaveToVault_Errors(t *testing.T) {
+	vault := t.TempDir()
+	tests := []struct {
+		name   string
+		vault  string
+		folder string
+	}{
+		{"no vault", "", ""},
+		{"missing vault", filepath.Join(vault, "missing"), ""},
+		{"folder outside the vault", vault, "../elsewhere"},
+		{"absolute folder", vault, filepath.Join(vault, "abs")},
+	}
+	for _, tt := range tests {
+		if path, err := SaveToVault(tt.vault, tt.folder, testNote()); err == nil {
+			t.Errorf("%s: saved %s, want an error", tt.name, path)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/microsoft"
+
+	"winshot/internal/upload"
+)
+
+const (
+	graphURL            = "https://graph.microsoft.com/v1.0"
+	oneNoteCallbackPort = 8090
+	oneNoteAuthTimeout  = 5 * time.Minute
+	oneNotePageTimeout  = 60 * time.Second
+	oneNoteMaxImageSize = 25 * 1024 * 1024 // Graph rejects larger parts
+
+	// oneNoteImagePart names the image part the page HTML refers to
+	oneNoteImagePart = "capture"
+)
+
+// ErrAuth is returned when OneNote is not connected or refuses the stored
+// sign-in. Reconnecting in Settings fixes it.
+var ErrAuth = errors.New("OneNote authorization failed")
+
+// oneNoteScopes lets WinShot create pages, and keep doing so without
+// signing in again.
+var oneNoteScopes = []string{"Notes.Create", "offline_access"}
+
+// OneNote appends captures as pages to a OneNote section through Microsoft
+// Graph. It signs in with a Microsoft app registration the user provides,
+// as a public client with PKCE, so only the client ID is needed.
+type OneNote struct {
+	creds     *upload.CredentialManager
+	sectionID string // Empty uses the default section of the default notebook
+	baseURL   string // graphURL, tests use a local server
+	transport http.RoundTripper
+	verifier  string
+	authState string
+	authDone  chan string
+	authErr   chan error
+	server    *http.Server
+	mu        sync.Mutex
+}
+
+// NewOneNote creates a OneNote client that adds pages to sectionID, or to
+// the default section if it is empty.
+func NewOneNote(creds *upload.CredentialManager, sectionID string) *OneNote {
+	return &OneNote{creds: creds, sectionID: sectionID, baseURL: graphURL}
+}
+
+// oauthConfig returns the OAuth2 configuration for the stored client ID.
+func (o *OneNote) oauthConfig() (*oauth2.Config, error) {
+	clientID, err := o.creds.Get(upload.CredOneNoteClientID)
+	if err != nil || clientID == "" {
+		return nil, fmt.Errorf("%w: Microsoft app client ID not configured. Please set it in Settings", ErrAuth)
+	}
+	return &oauth2.Config{
+		ClientID:    clientID,
+		RedirectURL: fmt.Sprintf("http://localhost:%d/callback", oneNoteCallbackPort),
+		Scopes:      oneNoteScopes,
+		Endpoint:    microsoft.AzureADEndpoint("common"),
+	}, nil
+}
+
+// IsConnected returns true if a Microsoft account has been signed in.
+func (o *OneNote) IsConnected() bool {
+	return o.creds.Exists(upload.CredOneNoteToken)
+}
+
+// StartAuth begins the OAuth2 flow and returns the sign-in URL.
+func (o *OneNote) StartAuth() (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cfg, err := o.oauthConfig()
+	if err != nil {
+		return "", err
+	}
+
+	// State guards against forged callbacks, the verifier against
+	// intercepted codes
+	o.authState = fmt.Sprintf("%d", time.Now().UnixNano())
+	o.verifier = oauth2.GenerateVerifier()
+	o.authDone = make(chan string, 1)
+	o.authErr = make(chan error, 1)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", oneNoteCallbackPort))
+	if err != nil {
+		return "", fmt.Errorf("callback port %d is in use: %w", oneNoteCallbackPort, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", o.handleCallback)
+	o.server = &http.Server{Handler: mux}
+	go func() {
+		if err := o.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			o.authErr <- err
+		}
+	}()
+
+	return cfg.AuthCodeURL(o.authState, oauth2.S256ChallengeOption(o.verifier)), nil
+}
+
+// handleCallback processes the OAuth callback.
+func (o *OneNote) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("state") != o.authState {
+		http.Error(w, "Invalid state", http.StatusBadRequest)
+		o.authErr <- errors.New("invalid state parameter")
+		return
+	}
+	if errParam := query.Get("error"); errParam != "" {
+		http.Error(w, "Authorization denied", http.StatusUnauthorized)
+		o.authErr <- fmt.Errorf("authorization denied: %s", errParam)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		http.Error(w, "No code provided", http.StatusBadRequest)
+		o.authErr <- errors.New("no authorization code received")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, `<!DOCTYPE html><html><head><title>WinShot - Authorization</title>
+<style>body{font-family:system-ui;text-align:center;padding-top:50px;background:#f5f5f5}
+.container{background:white;border-radius:8px;padding:40px;max-width:400px;margin:0 auto;box-shadow:0 2px 10px rgba(0,0,0,0.1)}
+h1{color:#22c55e;margin-bottom:10px}p{color:#666}</style></head>
+<body><div class="container"><h1>OneNote Connected!</h1>
+<p>You can close this window and return to WinShot.</p></div>
+<script>setTimeout(function(){window.close()},2000);</script></body></html>`)
+
+	o.authDone <- code
+}
+
+// WaitForAuth waits for the OAuth callback and stores the token.
+func (o *OneNote) WaitForAuth() error {
+	defer o.stopServer()
+	select {
+	case code := <-o.authDone:
+		return o.completeAuth(code)
+	case err := <-o.authErr:
+		return err
+	case <-time.After(oneNoteAuthTimeout):
+		return errors.New("authorization timeout - please try again")
+	}
+}
+
+// completeAuth exchanges the auth code for tokens.
+func (o *OneNote) completeAuth(code string) error {
+	cfg, err := o.oauthConfig()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	token, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(o.verifier))
+	if err != nil {
+		return fmt.Errorf("failed to exchange code for token: %w", err)
+	}
+	return o.saveToken(token)
+}
+
+func (o *OneNote) saveToken(token *oauth2.Token) error {
+	tokenJSON, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to serialize token: %w", err)
+	}
+	if err := o.creds.Set(upload.CredOneNoteToken, string(tokenJSON)); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	return nil
+}
+
+// stopServer gracefully stops the callback server.
+func (o *OneNote) stopServer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		o.server.Shutdown(ctx)
+		o.server = nil
+	}
+}
+
+// Disconnect removes the stored token.
+func (o *OneNote) Disconnect() error {
+	o.creds.Delete(upload.CredOneNoteToken)
+	return nil
+}
+
+// client returns an HTTP client that signs requests with the stored token,
+// refreshing and saving it when it has expired.
+func (o *OneNote) client(ctx context.Context) (*http.Client, error) {
+	tokenJSON, err := o.creds.Get(upload.CredOneNoteToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not signed in - please connect OneNote in Settings", ErrAuth)
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal([]byte(tokenJSON), &token); err != nil {
+		return nil, fmt.Errorf("%w: invalid stored token - please reconnect OneNote", ErrAuth)
+	}
+	cfg, err := o.oauthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.transport != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: o.transport})
+	}
+	source := cfg.TokenSource(ctx, &token)
+	fresh, err := source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: token refresh failed - please reconnect OneNote: %v", ErrAuth, err)
+	}
+	if fresh.AccessToken != token.AccessToken {
+		o.saveToken(fresh)
+	}
+	return oauth2.NewClient(ctx, source), nil
+}
+
+// oneNotePage is the part of a created page WinShot reads.
+type oneNotePage struct {
+	Links struct {
+		OneNoteWebURL struct {
+			Href string `json:"href"`
+		} `json:"oneNoteWebUrl"`
+	} `json:"links"`
+}
+
+// AppendPage adds the note as a new page and returns the page's web link.
+func (o *OneNote) AppendPage(ctx context.Context, n Note) (string, error) {
+	if len(n.Image) == 0 {
+		return "", errors.New("empty image data")
+	}
+	if len(n.Image) > oneNoteMaxImageSize {
+		return "", fmt.Errorf("image size %d exceeds the OneNote maximum of %d bytes", len(n.Image), oneNoteMaxImageSize)
+	}
+	client, err := o.client(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	body, contentType, err := oneNotePageBody(n)
+	if err != nil {
+		return "", err
+	}
+	endpoint := o.baseURL + "/me/onenote/pages"
+	if o.sectionID != "" {
+		endpoint = o.baseURL + "/me/onenote/sections/" + url.PathEscape(o.sectionID) + "/pages"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, oneNotePageTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create OneNote page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("%w: %s - please reconnect OneNote", ErrAuth, resp.Status)
+	case resp.StatusCode != http.StatusCreated:
+		return "", fmt.Errorf("failed to create OneNote page: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var page oneNotePage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return "", fmt.Errorf("unexpected OneNote response: %w", err)
+	}
+	return page.Links.OneNoteWebURL.Href, nil
+}
+
+// oneNotePageBody builds the multipart request that creates a page: the
+// page HTML, which refers to the image by part name, and the image.
+func oneNotePageBody(n Note) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	presentation := make(textproto.MIMEHeader)
+	presentation.Set("Content-Disposition", `form-data; name="Presentation"`)
+	presentation.Set("Content-Type", "text/html")
+	part, err := mw.CreatePart(presentation)
+	if err != nil {
+		return nil, "", err
+	}
+	io.WriteString(part, oneNoteHTML(n))
+
+	imageType := mime.TypeByExtension(strings.ToLower(filepath.Ext(n.Filename)))
+	if imageType == "" {
+		imageType = "image/png"
+	}
+	imageHeader := make(textproto.MIMEHeader)
+	imageHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, oneNoteImagePart))
+	imageHeader.Set("Content-Type", imageType)
+	if part, err = mw.CreatePart(imageHeader); err != nil {
+		return nil, "", err
+	}
+	part.Write(n.Image)
+
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
+
+// oneNoteHTML returns the page for a capture. OneNote has no free-form tags,
+// so they are written as hashtags OneNote search finds.
+func oneNoteHTML(n Note) string {
+	title := strings.Join(strings.Fields(n.Title), " ")
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	if !n.Time.IsZero() {
+		fmt.Fprintf(&b, "<meta name=\"created\" content=\"%s\" />\n", n.Time.Format(time.RFC3339))
+	}
+	b.WriteString("</head>\n<body>\n")
+	if tags := cleanTags(n.Tags); len(tags) > 0 {
+		fmt.Fprintf(&b, "<p>#%s</p>\n", html.EscapeString(strings.Join(tags, " #")))
+	}
+	if n.Source != "" {
+		source := html.EscapeString(n.Source)
+		if u, err := url.Parse(n.Source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			fmt.Fprintf(&b, "<p>Source: <a href=\"%s\">%s</a></p>\n", source, source)
+		} else {
+			fmt.Fprintf(&b, "<p>Source: %s</p>\n", source)
+		}
+	}
+	fmt.Fprintf(&b, "<img src=\"name:%s\" alt=\"%s\" />\n", oneNoteImagePart, html.EscapeString(title))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"winshot/internal/upload"
+)
+
+// connectedOneNote returns a client signed in with a token that does not
+// need refreshing, sending Graph requests to server
+func connectedOneNote(t *testing.T, server *httptest.Server, sectionID string) *OneNote {
+	t.Helper()
+	token, _ := json.Marshal(&oauth2.Token{AccessToken: "access", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)})
+	creds := upload.NewMemoryCredentialManager(map[string]string{
+		upload.CredOneNoteClientID: "client",
+		upload.CredOneNoteToken:    string(token),
+	})
+	o := NewOneNote(creds, sectionID)
+	o.baseURL = server.URL
+	return o
+}
+
+func TestOneNoteAppendPage(t *testing.T) {
+	var gotPath, gotAuth, gotHTML, gotImageType string
+	var gotImage []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Errorf("content type: %v", err)
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			switch part.FormName() {
+			case "Presentation":
+				gotHTML = string(data)
+			case oneNoteImagePart:
+				gotImage, gotImageType = data, part.Header.Get("Content-Type")
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"links":{"oneNoteWebUrl":{"href":"https://onenote.example/page"}}}`)
+	}))
+	defer server.Close()
+
+	link, err := connectedOneNote(t, server, "1-abc!42").AppendPage(context.Background(), testNote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://onenote.example/page" {
+		t.Errorf("link = %q", link)
+	}
+	if gotPath != "/me/onenote/sections/1-abc!42/pages" {
+		t.Errorf("path = %s, want the section's pages", gotPath)
+	}
+	if gotAuth != "Bearer access" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if string(gotImage) != "png data" || gotImageType != "image/png" {
+		t.Errorf("image part = %q (%s)", gotImage, gotImageType)
+	}
+	for _, want := range []string{
+		"<title>Build failed on main</title>",
+		`<meta name="created" content="2024-01-15T14:30:45Z" />`,
+		"<p>#work #ci-runs #yes</p>",
+		`<a href="https://ci.example.com/runs/42">`,
+		`<img src="name:capture"`,
+	} {
+		if !strings.Contains(gotHTML, want) {
+			t.Errorf("page HTML lacks %q:\n%s", want, gotHTML)
+		}
+	}
+}
+
+func TestOneNoteAppendPage_DefaultSectionAndErrors(t *testing.T) {
+	status := http.StatusUnauthorized
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	o := connectedOneNote(t, server, "")
+
+	_, err := o.AppendPage(context.Background(), testNote())
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("401 error = %v, want ErrAuth", err)
+	}
+	if gotPath != "/me/onenote/pages" {
+		t.Errorf("path = %s, want the default section", gotPath)
+	}
+
+	status = http.StatusInternalServerError
+	if _, err := o.AppendPage(context.Background(), testNote()); err == nil || errors.Is(err, ErrAuth) {
+		t.Errorf("500 error = %v, want a plain error", err)
+	}
+
+	o.Disconnect()
+	if _, err := o.AppendPage(context.Background(), testNote()); !errors.Is(err, ErrAuth) {
+		t.Errorf("disconnected error = %v, want ErrAuth", err)
+	}
+}
+
+func TestOneNoteHTML_EscapesAndSkipsUnsafeLinks(t *testing.T) {
+	n := Note{Title: `<script>x</script>`, Source: "javascript:alert(1)"}
+	page := oneNoteHTML(n)
+	if strings.Contains(page, "<script>") || strings.Contains(page, `href="javascript`) {
+		t.Errorf("page HTML is not escaped:\n%s", page)
+	}
+}
@@ -0,0 +1,62 @@
+// Package ocr recognizes text in captures with the OCR engine built into
+// Windows (Windows.Media.Ocr). The engine reads the languages of the user's
+// profile, so no models need to be bundled.
+package ocr
+
+import (
+	"errors"
+	"image"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrUnavailable is returned when Windows has no OCR engine for any of the
+// user's languages.
+var ErrUnavailable = errors.New("no OCR language is installed; add one under Settings > Time & language > Language")
+
+// upscaleLimit is the size below which captures are doubled before
+// recognition. Screen text at 100% scaling is smaller than the engine
+// reads reliably.
+const upscaleLimit = 1200
+
+// scaleFactor returns the factor to resize an image of the given size by
+// before recognition: 2 for small captures, less than 1 for captures larger
+// than the engine's maxDim, and 1 otherwise.
+func scaleFactor(size image.Point, maxDim int) float64 {
+	longest := max(size.X, size.Y)
+	switch {
+	case longest <= 0:
+		return 1
+	case maxDim > 0 && longest > maxDim:
+		return float64(maxDim) / float64(longest)
+	case longest <= upscaleLimit && (maxDim <= 0 || 2*longest <= maxDim):
+		return 2
+	}
+	return 1
+}
+
+// prepare returns an opaque copy of img scaled for recognition. Transparent
+// pixels become white, since the engine ignores alpha and reads them as
+// black.
+func prepare(img image.Image, maxDim int) *image.RGBA {
+	b := img.Bounds()
+	f := scaleFactor(b.Size(), maxDim)
+	w := max(1, int(float64(b.Dx())*f))
+	h := max(1, int(float64(b.Dy())*f))
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(out, out.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(out, out.Bounds(), img, b, draw.Over, nil)
+	return out
+}
+
+// joinLines joins recognized lines, dropping blank ones.
+func joinLines(lines []string) string {
+	kept := lines[:0:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\r\n")
+}
@@ -0,0 +1,55 @@
+package ocr
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestScaleFactor(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   image.Point
+		maxDim int
+		want   float64
+	}{
+		{"small capture", image.Pt(400, 120), 10000, 2},
+		{"small without a limit", image.Pt(400, 120), 0, 2},
+		{"doubling would pass the limit", image.Pt(1000, 200), 1500, 1},
+		{"large capture", image.Pt(2560, 1440), 10000, 1},
+		{"larger than the engine reads", image.Pt(8000, 2000), 4000, 0.5},
+		{"empty", image.Pt(0, 0), 4000, 1},
+	}
+	for _, tt := range tests {
+		if got := scaleFactor(tt.size, tt.maxDim); got != tt.want {
+			t.Errorf("%s: scaleFactor(%v, %d) = %v, want %v", tt.name, tt.size, tt.maxDim, got, tt.want)
+		}
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	img := image.NewRGBA(image.Rect(10, 10, 30, 20))
+	img.SetRGBA(10, 10, color.RGBA{0, 0, 0, 255})
+
+	out := prepare(img, 10000)
+	if got := out.Bounds(); got != image.Rect(0, 0, 40, 20) {
+		t.Fatalf("bounds = %v, want a doubled image at the origin", got)
+	}
+	// Transparent pixels read as white rather than black
+	if got := out.RGBAAt(39, 19); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("transparent pixel = %v, want white", got)
+	}
+	if got := out.RGBAAt(0, 0); got.R > 64 || got.A != 255 {
+		t.Errorf("black pixel = %v, want dark and opaque", got)
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	got := joinLines([]string{" Invoice 1042 ", "", "  ", "Total: $12.50"})
+	if want := "Invoice 1042\r\nTotal: $12.50"; got != want {
+		t.Errorf("joinLines = %q, want %q", got, want)
+	}
+	if got := joinLines(nil); got != "" {
+		t.Errorf("joinLines(nil) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,231 @@
+package ocr
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"winshot/internal/com"
+)
+
+var (
+	iidIOcrEngineStatics       = windows.GUID{Data1: 0x5bffa85a, Data2: 0x3384, Data3: 0x3540, Data4: [8]byte{0x99, 0x40, 0x69, 0x91, 0x20, 0xd4, 0x28, 0xa8}}
+	iidISoftwareBitmapFactory  = windows.GUID{Data1: 0xc99feb69, Data2: 0x2d62, Data3: 0x4d47, Data4: [8]byte{0xa6, 0xb3, 0x4f, 0xdb, 0x6a, 0x07, 0xfd, 0xf8}}
+	iidIMemoryBuffer           = windows.GUID{Data1: 0xfbc4dd2a, Data2: 0x245b, Data3: 0x11e4, Data4: [8]byte{0xaf, 0x98, 0x68, 0x94, 0x23, 0x26, 0x0c, 0xf8}}
+	iidIMemoryBufferByteAccess = windows.GUID{Data1: 0x5b0d3235, Data2: 0x4dba, Data3: 0x4d44, Data4: [8]byte{0x86, 0x5e, 0x8f, 0x1d, 0x0e, 0x4f, 0xd0, 0x4d}}
+)
+
+// WinRT classes activated for recognition
+const (
+	classOcrEngine      = "Windows.Media.Ocr.OcrEngine"
+	classSoftwareBitmap = "Windows.Graphics.Imaging.SoftwareBitmap"
+)
+
+const (
+	rpcEChangedMode = 0x80010106
+
+	pixelFormatBgra8 = 87 // BitmapPixelFormat.Bgra8
+	bufferWrite      = 2  // BitmapBufferAccessMode.Write
+)
+
+// COM vtable slots of the WinRT interfaces used for recognition. WinRT
+// interfaces start after the 6 IInspectable methods
+const (
+	vtblQueryInterface = 0
+
+	vtblAsyncGetResults = 8 // IAsyncOperation<T>.GetResults
+
+	vtblMaxImageDimension            = 6  // IOcrEngineStatics
+	vtblTryCreateFromUserProfileLang = 10 // IOcrEngineStatics
+	vtblRecognizeAsync               = 6  // IOcrEngine
+
+	vtblCreateBitmap        = 6  // ISoftwareBitmapFactory.Create
+	vtblLockBuffer          = 15 // ISoftwareBitmap
+	vtblGetPlaneDescription = 7  // IBitmapBuffer
+	vtblCreateReference     = 6  // IMemoryBuffer
+	vtblGetBuffer           = 3  // IMemoryBufferByteAccess
+
+	vtblGetLines    = 6 // IOcrResult
+	vtblVectorGetAt = 6 // IVectorView<OcrLine>
+	vtblVectorSize  = 7
+	vtblLineGetText = 7 // IOcrLine
+)
+
+// recognizeTimeout bounds a single recognition, which takes well under a
+// second for a screen of text
+const recognizeTimeout = 30 * time.Second
+
+// bitmapPlane is BitmapPlaneDescription
+type bitmapPlane struct {
+	StartIndex, Width, Height, Stride int32
+}
+
+// Recognize returns the text in img, one line per recognized line of text
+// joined with CRLF, or "" if it holds none. It uses the first of the user's
+// profile languages that has an OCR engine installed
+func Recognize(img image.Image) (string, error) {
+	// COM state belongs to the thread, so keep the goroutine on it
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	switch err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED); {
+	case err == nil, err == syscall.Errno(1): // S_FALSE: already initialized
+		defer windows.CoUninitialize()
+	case err == syscall.Errno(rpcEChangedMode):
+		// Already initialized for another apartment, which works as well
+	default:
+		return "", fmt.Errorf("failed to initialize COM: %w", err)
+	}
+
+	statics, err := com.ActivationFactory(classOcrEngine, &iidIOcrEngineStatics)
+	if err != nil {
+		return "", err
+	}
+	defer com.Release(statics)
+
+	var engine uintptr
+	if hr := com.Call(statics, vtblTryCreateFromUserProfileLang, uintptr(unsafe.Pointer(&engine))); com.Failed(hr) {
+		return "", fmt.Errorf("failed to create the OCR engine: 0x%08x", uint32(hr))
+	}
+	if engine == 0 {
+		return "", ErrUnavailable
+	}
+	defer com.Release(engine)
+
+	var maxDim uint32
+	com.Call(statics, vtblMaxImageDimension, uintptr(unsafe.Pointer(&maxDim)))
+
+	bitmap, err := softwareBitmap(prepare(img, int(maxDim)))
+	if err != nil {
+		return "", err
+	}
+	defer com.Release(bitmap)
+
+	var op uintptr
+	if hr := com.Call(engine, vtblRecognizeAsync, bitmap, uintptr(unsafe.Pointer(&op))); com.Failed(hr) {
+		return "", fmt.Errorf("failed to start text recognition: 0x%08x", uint32(hr))
+	}
+	defer com.Release(op)
+	if err := com.AwaitAsync(op, recognizeTimeout); err != nil {
+		return "", fmt.Errorf("text recognition failed: %w", err)
+	}
+	var result uintptr
+	if hr := com.Call(op, vtblAsyncGetResults, uintptr(unsafe.Pointer(&result))); com.Failed(hr) || result == 0 {
+		return "", fmt.Errorf("text recognition failed: 0x%08x", uint32(hr))
+	}
+	defer com.Release(result)
+
+	lines, err := resultLines(result)
+	if err != nil {
+		return "", err
+	}
+	return joinLines(lines), nil
+}
+
+// softwareBitmap copies an opaque image into a new BGRA SoftwareBitmap
+func softwareBitmap(img *image.RGBA) (uintptr, error) {
+	factory, err := com.ActivationFactory(classSoftwareBitmap, &iidISoftwareBitmapFactory)
+	if err != nil {
+		return 0, err
+	}
+	defer com.Release(factory)
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	var bitmap uintptr
+	if hr := com.Call(factory, vtblCreateBitmap, pixelFormatBgra8, uintptr(w), uintptr(h), uintptr(unsafe.Pointer(&bitmap))); com.Failed(hr) {
+		return 0, fmt.Errorf("failed to create a %dx%d bitmap: 0x%08x", w, h, uint32(hr))
+	}
+	if err := writePixels(bitmap, img); err != nil {
+		com.Release(bitmap)
+		return 0, err
+	}
+	return bitmap, nil
+}
+
+// writePixels locks the bitmap's buffer and fills it from img. The lock is
+// released with the last reference to the buffer, before the bitmap is used
+func writePixels(bitmap uintptr, img *image.RGBA) error {
+	var buffer uintptr
+	if hr := com.Call(bitmap, vtblLockBuffer, bufferWrite, uintptr(unsafe.Pointer(&buffer))); com.Failed(hr) {
+		return fmt.Errorf("failed to lock the bitmap: 0x%08x", uint32(hr))
+	}
+	defer com.Release(buffer)
+
+	var plane bitmapPlane
+	if hr := com.Call(buffer, vtblGetPlaneDescription, 0, uintptr(unsafe.Pointer(&plane))); com.Failed(hr) {
+		return fmt.Errorf("failed to read the bitmap layout: 0x%08x", uint32(hr))
+	}
+
+	var memory, ref, access uintptr
+	if hr := com.Call(buffer, vtblQueryInterface, uintptr(unsafe.Pointer(&iidIMemoryBuffer)), uintptr(unsafe.Pointer(&memory))); com.Failed(hr) {
+		return fmt.Errorf("bitmap buffer is not accessible: 0x%08x", uint32(hr))
+	}
+	defer com.Release(memory)
+	if hr := com.Call(memory, vtblCreateReference, uintptr(unsafe.Pointer(&ref))); com.Failed(hr) {
+		return fmt.Errorf("bitmap buffer is not accessible: 0x%08x", uint32(hr))
+	}
+	defer com.Release(ref)
+	if hr := com.Call(ref, vtblQueryInterface, uintptr(unsafe.Pointer(&iidIMemoryBufferByteAccess)), uintptr(unsafe.Pointer(&access))); com.Failed(hr) {
+		return fmt.Errorf("bitmap buffer is not accessible: 0x%08x", uint32(hr))
+	}
+	defer com.Release(access)
+
+	var data *byte
+	var capacity uint32
+	if hr := com.Call(access, vtblGetBuffer, uintptr(unsafe.Pointer(&data)), uintptr(unsafe.Pointer(&capacity))); com.Failed(hr) {
+		return fmt.Errorf("bitmap buffer is not accessible: 0x%08x", uint32(hr))
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	start, stride := int(plane.StartIndex), int(plane.Stride)
+	if stride < w*4 || start+(h-1)*stride+w*4 > int(capacity) {
+		return fmt.Errorf("unexpected bitmap layout: stride %d, %d bytes", stride, capacity)
+	}
+	dst := unsafe.Slice(data, capacity)
+	for y := 0; y < h; y++ {
+		src := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		row := dst[start+y*stride : start+y*stride+w*4]
+		for x := 0; x < w*4; x += 4 {
+			row[x+0] = src[x+2]
+			row[x+1] = src[x+1]
+			row[x+2] = src[x+0]
+			row[x+3] = 255
+		}
+	}
+	return nil
+}
+
+// resultLines returns the text of each line in an OcrResult, top to bottom
+func resultLines(result uintptr) ([]string, error) {
+	var vector uintptr
+	if hr := com.Call(result, vtblGetLines, uintptr(unsafe.Pointer(&vector))); com.Failed(hr) {
+		return nil, fmt.Errorf("failed to read recognized lines: 0x%08x", uint32(hr))
+	}
+	defer com.Release(vector)
+
+	var n uint32
+	if hr := com.Call(vector, vtblVectorSize, uintptr(unsafe.Pointer(&n))); com.Failed(hr) {
+		return nil, fmt.Errorf("failed to read recognized lines: 0x%08x", uint32(hr))
+	}
+	lines := make([]string, 0, n)
+	for i := uint32(0); i < n; i++ {
+		var line uintptr
+		if hr := com.Call(vector, vtblVectorGetAt, uintptr(i), uintptr(unsafe.Pointer(&line))); com.Failed(hr) {
+			return nil, fmt.Errorf("failed to read recognized line %d: 0x%08x", i, uint32(hr))
+		}
+		var text uintptr
+		hr := com.Call(line, vtblLineGetText, uintptr(unsafe.Pointer(&text)))
+		com.Release(line)
+		if com.Failed(hr) {
+			return nil, fmt.Errorf("failed to read recognized line %d: 0x%08x", i, uint32(hr))
+		}
+		lines = append(lines, com.HStringText(text))
+		com.DeleteHString(text)
+	}
+	return lines, nil
+}
@@ -0,0 +1,198 @@
+package overlay
+
+import (
+	"image"
+	"unsafe"
+)
+
+// grip is the part of an adjustable selection under the pointer: a set of
+// edges to resize, or the inside to move it
+type grip int
+
+const gripNone grip = 0
+
+const (
+	gripLeft grip = 1 << iota
+	gripTop
+	gripRight
+	gripBottom
+	gripMove
+)
+
+// gripTolerance is how far from an edge, in screen pixels, it can be grabbed
+const gripTolerance = 6
+
+// hitGrip returns the grip of r at p, grabbing edges within tol pixels on
+// either side. Corners grab both of their edges
+func hitGrip(r image.Rectangle, p image.Point, tol int) grip {
+	if r.Empty() || !p.In(r.Inset(-tol)) {
+		return gripNone
+	}
+	g := gripNone
+	if absInt(p.X-r.Min.X) <= tol {
+		g |= gripLeft
+	} else if absInt(p.X-r.Max.X) <= tol {
+		g |= gripRight
+	}
+	if absInt(p.Y-r.Min.Y) <= tol {
+		g |= gripTop
+	} else if absInt(p.Y-r.Max.Y) <= tol {
+		g |= gripBottom
+	}
+	if g == gripNone {
+		return gripMove
+	}
+	return g
+}
+
+// adjustRect returns r after dragging grip g by d, kept within area. Moving
+// stops at the edges of area; an edge dragged past the opposite one flips
+// the selection
+func adjustRect(r image.Rectangle, g grip, d image.Point, area image.Rectangle) image.Rectangle {
+	if g == gripMove {
+		d.X = clampInt(d.X, area.Min.X-r.Min.X, area.Max.X-r.Max.X)
+		d.Y = clampInt(d.Y, area.Min.Y-r.Min.Y, area.Max.Y-r.Max.Y)
+		return r.Add(d)
+	}
+	if g&gripLeft != 0 {
+		r.Min.X = clampInt(r.Min.X+d.X, area.Min.X, area.Max.X)
+	}
+	if g&gripRight != 0 {
+		r.Max.X = clampInt(r.Max.X+d.X, area.Min.X, area.Max.X)
+	}
+	if g&gripTop != 0 {
+		r.Min.Y = clampInt(r.Min.Y+d.Y, area.Min.Y, area.Max.Y)
+	}
+	if g&gripBottom != 0 {
+		r.Max.Y = clampInt(r.Max.Y+d.Y, area.Min.Y, area.Max.Y)
+	}
+	return r.Canon()
+}
+
+// cursor returns the system cursor shown over the grip
+func (g grip) cursor() uintptr {
+	switch g {
+	case gripMove:
+		return IDC_SIZEALL
+	case gripLeft | gripTop, gripRight | gripBottom:
+		return IDC_SIZENWSE
+	case gripRight | gripTop, gripLeft | gripBottom:
+		return IDC_SIZENESW
+	case gripLeft, gripRight:
+		return IDC_SIZEWE
+	case gripTop, gripBottom:
+		return IDC_SIZENS
+	}
+	return IDC_CROSS
+}
+
+// beginAdjust keeps rect (overlay coordinates) on screen as an adjustable
+// selection after the first drag, instead of capturing it right away
+func (m *Manager) beginAdjust(rect image.Rectangle) {
+	m.mu.Lock()
+	sel := &m.selection
+	sel.StartX, sel.StartY = rect.Min.X, rect.Min.Y
+	sel.EndX, sel.EndY = rect.Max.X, rect.Max.Y
+	sel.Inflate = 0
+	sel.Adjusting = true
+	m.mu.Unlock()
+	m.redraw()
+}
+
+// gripTol returns gripTolerance in overlay pixels at the current zoom.
+// Callers hold m.mu
+func (m *Manager) gripTol() int {
+	return maxInt(gripTolerance/maxInt(m.zoom, 1), 1)
+}
+
+// grabSelection starts resizing or moving an adjustable selection when p
+// (overlay coordinates) is on it. It reports false when p is elsewhere, so a
+// new selection starts there
+func (m *Manager) grabSelection(p image.Point) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sel := &m.selection
+	if !sel.Adjusting {
+		return false
+	}
+	r := sel.Rect()
+	g := hitGrip(r, p, m.gripTol())
+	if g == gripNone {
+		sel.Adjusting = false
+		return false
+	}
+	// Fold any wheel inflation into the corners so the grip moves what is drawn
+	sel.StartX, sel.StartY = r.Min.X, r.Min.Y
+	sel.EndX, sel.EndY = r.Max.X, r.Max.Y
+	sel.Inflate = 0
+	sel.CursorX, sel.CursorY = p.X, p.Y
+	m.grip, m.gripStart, m.gripRect = g, p, r
+	return true
+}
+
+// dragGrip follows the pointer at x, y (overlay coordinates) while a selection
+// is adjustable, reshaping it when a grip is held. It reports false when no
+// selection is being adjusted
+func (m *Manager) dragGrip(x, y int) bool {
+	area := m.selectableRect()
+	m.mu.Lock()
+	sel := &m.selection
+	if !sel.Adjusting {
+		m.mu.Unlock()
+		return false
+	}
+	sel.CursorX, sel.CursorY = x, y
+	if m.grip != gripNone {
+		r := adjustRect(m.gripRect, m.grip, image.Pt(x, y).Sub(m.gripStart), area)
+		sel.StartX, sel.StartY = r.Min.X, r.Min.Y
+		sel.EndX, sel.EndY = r.Max.X, r.Max.Y
+	}
+	m.mu.Unlock()
+	m.redraw()
+	return true
+}
+
+// releaseGrip ends a resize or move. It reports false when no grip was held
+func (m *Manager) releaseGrip() bool {
+	m.mu.Lock()
+	held := m.grip != gripNone
+	m.grip = gripNone
+	m.mu.Unlock()
+	if held {
+		m.redraw()
+	}
+	return held
+}
+
+// hoverGrip returns the grip under the pointer, or the one held, while a
+// selection is adjustable
+func (m *Manager) hoverGrip() grip {
+	var pt POINT
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.selection.Adjusting {
+		return gripNone
+	}
+	if m.grip != gripNone {
+		return m.grip
+	}
+	p := unzoomPoint(image.Pt(int(pt.X), int(pt.Y)).Sub(m.bounds.Min), m.zoom, m.zoomAnchor)
+	return hitGrip(m.selection.Rect(), p, m.gripTol())
+}
+
+// confirmAdjust captures the adjustable selection. It reports false when
+// there is none
+func (m *Manager) confirmAdjust() bool {
+	area := m.selectableRect()
+	m.mu.Lock()
+	adjusting := m.selection.Adjusting
+	rect := m.selection.Rect().Intersect(area)
+	m.mu.Unlock()
+	if !adjusting || rect.Empty() {
+		return false
+	}
+	m.finish(rect, nil)
+	return true
+}
@@ -0,0 +1,206 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"unsafe"
+)
+
+// Color formats accepted by PickedColor.Format
+const (
+	ColorHex = "hex"
+	ColorRGB = "rgb"
+	ColorHSL = "hsl"
+)
+
+// Loupe layout. An odd number of cells puts one under the cursor
+const (
+	loupeCells  = 15
+	loupeCell   = 8  // Screen pixels per magnified pixel, including the grid line
+	loupeLabelH = 20 // Height of the color label under the grid
+)
+
+// PickedColor is the pixel chosen with RunColorPicker
+type PickedColor struct {
+	Color     color.RGBA
+	Point     image.Point // Screen coordinates of the pixel
+	Cancelled bool
+}
+
+// Hex formats the color as "#RRGGBB"
+func (c PickedColor) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.Color.R, c.Color.G, c.Color.B)
+}
+
+// RGB formats the color as CSS "rgb(r, g, b)"
+func (c PickedColor) RGB() string {
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.Color.R, c.Color.G, c.Color.B)
+}
+
+// HSL formats the color as CSS "hsl(h, s%, l%)" rounded to whole numbers
+func (c PickedColor) HSL() string {
+	h, s, l := rgbToHSL(c.Color)
+	return fmt.Sprintf("hsl(%d, %d%%, %d%%)", int(math.Round(h))%360, int(math.Round(s*100)), int(math.Round(l*100)))
+}
+
+// Format returns the color as ColorHex, ColorRGB or ColorHSL. Anything else
+// is formatted as hex
+func (c PickedColor) Format(format string) string {
+	switch format {
+	case ColorRGB:
+		return c.RGB()
+	case ColorHSL:
+		return c.HSL()
+	default:
+		return c.Hex()
+	}
+}
+
+// rgbToHSL converts c to hue in degrees [0, 360) and saturation and
+// lightness in [0, 1]
+func rgbToHSL(c color.RGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	d := hi - lo
+	if d == 0 {
+		return 0, 0, l // Gray
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// RunColorPicker shows the overlay in color mode and blocks until a pixel is
+// clicked. The screen is shown undimmed, and a loupe next to the cursor
+// magnifies the pixels around it with the one under the cursor outlined and
+// its value written below. Enter picks the pixel under the cursor; Esc and
+// right-click cancel
+func (m *Manager) RunColorPicker(screenshot *image.RGBA, bounds image.Rectangle, monitors []image.Rectangle, scaleRatio float64) PickedColor {
+	res := <-m.show(overlayCmd{
+		Type:       cmdShow,
+		Screenshot: screenshot,
+		Bounds:     bounds,
+		Monitors:   monitors,
+		ScaleRatio: scaleRatio,
+		PickColor:  true,
+	})
+	if res.Cancelled || res.Mode != ModeColor {
+		return PickedColor{Cancelled: true}
+	}
+	return PickedColor{Color: res.Color, Point: image.Pt(res.X, res.Y).Add(bounds.Min)}
+}
+
+// colorMode reports whether the overlay is picking a color
+func (m *Manager) colorMode() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.selection.Mode == ModeColor
+}
+
+// pickColor resolves the pending Show with the screenshot pixel at p
+// (overlay coordinates) and hides
+func (m *Manager) pickColor(p image.Point) {
+	area := m.selectableRect()
+	p.X = clampInt(p.X, area.Min.X, area.Max.X-1)
+	p.Y = clampInt(p.Y, area.Min.Y, area.Max.Y-1)
+
+	m.mu.Lock()
+	c := pixelAt(m.screenshot, p)
+	res := Result{X: p.X, Y: p.Y, Width: 1, Height: 1, Mode: ModeColor, Color: c}
+	resultCh := m.resultCh
+	m.mu.Unlock()
+
+	if resultCh != nil {
+		select {
+		case resultCh <- res:
+		default:
+		}
+	}
+	m.handleHide()
+}
+
+// pixelAt returns the opaque color of the screenshot pixel at p, or black if
+// p is outside the screenshot
+func pixelAt(screenshot *image.RGBA, p image.Point) color.RGBA {
+	if screenshot == nil || !p.In(screenshot.Bounds()) {
+		return color.RGBA{A: 255}
+	}
+	i := screenshot.PixOffset(p.X, p.Y)
+	return color.RGBA{R: screenshot.Pix[i], G: screenshot.Pix[i+1], B: screenshot.Pix[i+2], A: 255}
+}
+
+// loupeRect places the loupe, grid and label, next to the cursor within a
+// width x height context. If it would cover avoid, such as the live preview,
+// it moves to the other side of the cursor, or failing that next to avoid
+func loupeRect(cursor image.Point, width, height int, avoid image.Rectangle) image.Rectangle {
+	size := loupeCells * loupeCell
+	r := previewRect(cursor, size, size+loupeLabelH, width, height)
+	if !r.Overlaps(avoid) {
+		return r
+	}
+	mirrorX := image.Pt(2*cursor.X-r.Max.X-r.Min.X, 0)
+	mirrorY := image.Pt(0, 2*cursor.Y-r.Max.Y-r.Min.Y)
+	candidates := []image.Rectangle{
+		r.Add(mirrorX),
+		r.Add(mirrorY),
+		r.Add(mirrorX).Add(mirrorY),
+		r.Add(image.Pt(0, avoid.Max.Y-r.Min.Y)), // Below avoid
+		r.Add(image.Pt(0, avoid.Min.Y-r.Max.Y)), // Above avoid
+	}
+	ctx := image.Rect(0, 0, width, height)
+	for _, c := range candidates {
+		if c.In(ctx) && !c.Overlaps(avoid) && !cursor.In(c) {
+			return c
+		}
+	}
+	return r
+}
+
+// drawLoupe draws the magnified pixels around center (overlay coordinates)
+// next to cursor (local view coordinates), away from avoid, with label
+// written below
+func (dc *DrawContext) drawLoupe(cursor, center image.Point, label string, screenshot *image.RGBA, avoid image.Rectangle) {
+	r := loupeRect(cursor, dc.width, dc.height, avoid)
+	half := loupeCells / 2
+
+	// Cells one pixel smaller than their pitch leave dark grid lines
+	dc.fillRect(r, color.RGBA{R: 32, G: 32, B: 32, A: 255})
+	for cy := 0; cy < loupeCells; cy++ {
+		for cx := 0; cx < loupeCells; cx++ {
+			c := pixelAt(screenshot, center.Add(image.Pt(cx-half, cy-half)))
+			at := r.Min.Add(image.Pt(cx*loupeCell, cy*loupeCell))
+			dc.fillRect(image.Rectangle{Min: at, Max: at.Add(image.Pt(loupeCell-1, loupeCell-1))}, c)
+		}
+	}
+
+	// Outline the picked pixel in white inside black so it shows on any color
+	cell := image.Rect(0, 0, loupeCell-1, loupeCell-1).Add(r.Min.Add(image.Pt(half*loupeCell, half*loupeCell)))
+	dc.strokeRect(cell.Inset(-1), color.RGBA{A: 255})
+	dc.strokeRect(cell.Inset(-2), color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	dc.strokeRect(cell.Inset(-3), color.RGBA{A: 255})
+
+	pill := image.Rect(r.Min.X, r.Max.Y-loupeLabelH, r.Max.X, r.Max.Y)
+	dc.fillRect(pill, color.RGBA{R: 0x00, G: 0x78, B: 0xD7, A: 255})
+	pixels := unsafe.Slice((*uint32)(dc.pixels), dc.width*dc.height)
+	dc.drawInstructionText(pill.Min.X+6, pill.Min.Y+6, label, pixels)
+
+	dc.drawSelectionBorder(r.Min.X-2, r.Min.Y-2, r.Dx()+4, r.Dy()+4)
+}
+
+// strokeRect draws a one pixel outline just inside r
+func (dc *DrawContext) strokeRect(r image.Rectangle, c color.RGBA) {
+	dc.fillRect(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), c)
+	dc.fillRect(image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), c)
+	dc.fillRect(image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), c)
+	dc.fillRect(image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), c)
+}
@@ -6,6 +6,8 @@ import (
 	"image"
 	"syscall"
 	"unsafe"
+
+	"winshot/internal/imaging"
 )
 
 var (
@@ -30,6 +32,17 @@ type DrawContext struct {
 	pixels     unsafe.Pointer
 	width      int
 	height     int
+	originX    int // Top-left of this context in overlay coordinates
+	originY    int
+	inactive   []image.Rectangle // Overlay coordinates of monitors to dim harder
+	dimAlpha   uint8
+	zoom       int               // Magnification, 1 when not zoomed
+	zoomAnchor image.Point       // Fixed point of the magnification in overlay coordinates
+	zoomBuf    []uint32          // Scratch copy of the frame for resampling
+	dim        uint8             // Dim layer alpha over the screenshot
+	preview    bool              // Draw the live preview next to the cursor
+	loupe      bool              // Draw the loupe next to the cursor while dragging
+	ghosts     []image.Rectangle // Recent or suggested regions outlined, overlay coordinates
 }
 
 // NewDrawContext creates a 32-bit DIB for overlay drawing
@@ -65,6 +78,11 @@ func NewDrawContext(screenDC uintptr, width, height int) (*DrawContext, error) {
 	}
 
 	hOldBitmap, _, _ := procSelectObject.Call(hMemDC, hBitmap)
+	if hOldBitmap == 0 {
+		procDeleteObject.Call(hBitmap)
+		procDeleteDC.Call(hMemDC)
+		return nil, errors.New("failed to select DIB section")
+	}
 
 	return &DrawContext{
 		HMemDC:     hMemDC,
@@ -76,39 +94,257 @@ func NewDrawContext(screenDC uintptr, width, height int) (*DrawContext, error) {
 	}, nil
 }
 
-// DrawOverlay renders the selection overlay
-func (dc *DrawContext) DrawOverlay(screenshot *image.RGBA, sel *Selection, scaleRatio float64) {
+// SetOrigin sets where this context sits within the overlay, for per-monitor
+// windows that each draw a slice of the shared screenshot and selection
+func (dc *DrawContext) SetOrigin(p image.Point) {
+	dc.originX, dc.originY = p.X, p.Y
+}
+
+// SetInactive sets monitor areas (overlay coordinates) drawn with a stronger dim
+func (dc *DrawContext) SetInactive(rects []image.Rectangle, alpha uint8) {
+	dc.inactive = rects
+	dc.dimAlpha = alpha
+}
+
+// SetDim sets the dim layer alpha drawn over the screenshot
+func (dc *DrawContext) SetDim(alpha uint8) {
+	dc.dim = alpha
+}
+
+// SetPreview turns the live selection preview on or off
+func (dc *DrawContext) SetPreview(show bool) {
+	dc.preview = show
+}
+
+// SetLoupe turns the loupe shown while dragging on or off. Color mode always
+// shows it
+func (dc *DrawContext) SetLoupe(show bool) {
+	dc.loupe = show
+}
+
+// SetGhosts sets the recent or suggested regions (overlay coordinates) drawn
+// as dashed outlines behind the selection
+func (dc *DrawContext) SetGhosts(rects []image.Rectangle) {
+	dc.ghosts = rects
+}
+
+// SetZoom magnifies the frame around anchor (overlay coordinates)
+func (dc *DrawContext) SetZoom(zoom int, anchor image.Point) {
+	dc.zoom = zoom
+	dc.zoomAnchor = anchor
+}
+
+// toView maps a local point to where it appears in the zoomed frame
+func (dc *DrawContext) toView(x, y int) (int, int) {
+	if dc.zoom <= 1 {
+		return x, y
+	}
+	ax, ay := dc.zoomAnchor.X-dc.originX, dc.zoomAnchor.Y-dc.originY
+	return ax + (x-ax)*dc.zoom, ay + (y-ay)*dc.zoom
+}
+
+// DrawOverlay renders the selection overlay. scale converts overlay units to
+// physical pixels for the size label, per monitor in per-monitor mode
+func (dc *DrawContext) DrawOverlay(screenshot *image.RGBA, sel *Selection, scale float64) {
 	// 1. Draw screenshot as background
 	dc.drawScreenshot(screenshot)
 
+	// Color mode leaves the screen undimmed so colors can be judged
+	if sel.Mode == ModeColor {
+		dc.applyZoom()
+		cursorX, cursorY := dc.toView(sel.CursorX-dc.originX, sel.CursorY-dc.originY)
+		if cursorX >= 0 && cursorX < dc.width && cursorY >= 0 && cursorY < dc.height {
+			c := pixelAt(screenshot, image.Pt(sel.CursorX, sel.CursorY))
+			label := fmt.Sprintf("%s %d,%d,%d", PickedColor{Color: c}.Hex(), c.R, c.G, c.B)
+			dc.drawLoupe(image.Pt(cursorX, cursorY), image.Pt(sel.CursorX, sel.CursorY), label, screenshot, image.Rectangle{})
+		}
+		dc.drawInstructions(sel)
+		return
+	}
+
 	// 2. Draw semi-transparent dark overlay
-	dc.fillOverlay(128) // 50% opacity
+	dc.fillOverlay(dc.dim)
+	for _, r := range dc.inactive {
+		dc.dimRegion(r.Sub(image.Pt(dc.originX, dc.originY)), dc.dimAlpha, screenshot)
+	}
+	for _, r := range dc.ghosts {
+		dc.drawGhost(r.Sub(image.Pt(dc.originX, dc.originY)))
+	}
 
-	if sel.IsDragging {
+	if sel.IsDragging || sel.Adjusting {
 		// 3. Calculate normalized selection bounds
-		x1, y1 := minInt(sel.StartX, sel.EndX), minInt(sel.StartY, sel.EndY)
-		x2, y2 := maxInt(sel.StartX, sel.EndX), maxInt(sel.StartY, sel.EndY)
+		// translated into this context's local coordinates
+		r := sel.Rect().Sub(image.Pt(dc.originX, dc.originY))
+		x1, y1, x2, y2 := r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
 		w, h := x2-x1, y2-y1
 
-		// 4. Clear the selection area (show screenshot)
-		dc.clearRegion(x1, y1, w, h, screenshot)
-
-		// 5. Draw blue border (2px)
-		dc.drawSelectionBorder(x1, y1, w, h)
+		// 4-6. Clear the selection area (show screenshot) and outline it
+		switch sel.Mode {
+		case ModeEllipse:
+			dc.clearEllipse(r, screenshot)
+			dc.drawEllipseBorder(r)
+			if sel.Adjusting {
+				dc.drawCornerHandles(x1, y1, w, h)
+			}
+		case ModeFreehand:
+			path := make([]image.Point, len(sel.Path))
+			for i, p := range sel.Path {
+				path[i] = p.Sub(image.Pt(dc.originX, dc.originY))
+			}
+			dc.clearPolygon(path, screenshot)
+			dc.drawPath(path)
+		default:
+			dc.clearRegion(x1, y1, w, h, screenshot)
+			dc.drawSelectionBorder(x1, y1, w, h)
+			dc.drawCornerHandles(x1, y1, w, h)
+		}
 
-		// 6. Draw corner handles
-		dc.drawCornerHandles(x1, y1, w, h)
+		// Magnify the frame before drawing labels so they stay readable
+		dc.applyZoom()
+		labelX, labelY := dc.toView(x1, y2)
+
+		// 7. Draw size indicator, only on the context holding its anchor so
+		// per-monitor windows don't each clamp a copy onto their own edge
+		if labelX >= 0 && labelX <= dc.width && labelY >= 0 && labelY <= dc.height {
+			scaledW := int(float64(w) * scale)
+			scaledH := int(float64(h) * scale)
+			label := fmt.Sprintf("%d x %d", scaledW, scaledH)
+			if sel.HasClientPos {
+				label = fmt.Sprintf("%d,%d  %s", sel.ClientPos.X, sel.ClientPos.Y, label)
+			}
+			dc.drawSizeIndicator(labelX, labelY+8, label)
+		}
 
-		// 7. Draw size indicator
-		scaledW := int(float64(w) * scaleRatio)
-		scaledH := int(float64(h) * scaleRatio)
-		dc.drawSizeIndicator(x1, y2+8, scaledW, scaledH)
+		// 8. Draw live preview and the loupe next to the cursor
+		cursorX, cursorY := dc.toView(sel.CursorX-dc.originX, sel.CursorY-dc.originY)
+		if cursorX >= 0 && cursorX <= dc.width && cursorY >= 0 && cursorY <= dc.height {
+			var preview image.Rectangle
+			if dc.preview {
+				preview = dc.drawPreview(image.Rect(x1, y1, x2, y2), image.Pt(cursorX, cursorY), screenshot)
+			}
+			if dc.loupe {
+				label := fmt.Sprintf("%d,%d", sel.ScreenCursor.X, sel.ScreenCursor.Y)
+				dc.drawLoupe(image.Pt(cursorX, cursorY), image.Pt(sel.CursorX, sel.CursorY), label, screenshot, preview)
+			}
+		}
+	} else {
+		dc.applyZoom()
 	}
 
-	// 8. Draw instructions
+	// 9. Draw instructions
 	dc.drawInstructions(sel)
 }
 
+// applyZoom resamples the frame drawn so far around the zoom anchor with
+// nearest-neighbor scaling, adding a pixel grid at high magnification
+func (dc *DrawContext) applyZoom() {
+	if dc.zoom <= 1 {
+		return
+	}
+
+	pixelCount := dc.width * dc.height
+	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
+	if len(dc.zoomBuf) != pixelCount {
+		dc.zoomBuf = make([]uint32, pixelCount)
+	}
+	copy(dc.zoomBuf, pixels)
+
+	ax, ay := dc.zoomAnchor.X-dc.originX, dc.zoomAnchor.Y-dc.originY
+	grid := dc.zoom >= 8
+
+	for y := 0; y < dc.height; y++ {
+		sy := ay + floorDiv(y-ay, dc.zoom)
+		rowEdge := grid && (y-ay-floorDiv(y-ay, dc.zoom)*dc.zoom) == 0
+		for x := 0; x < dc.width; x++ {
+			sx := ax + floorDiv(x-ax, dc.zoom)
+			color := uint32(255 << 24) // Outside this context's frame
+			if sx >= 0 && sx < dc.width && sy >= 0 && sy < dc.height {
+				color = dc.zoomBuf[sy*dc.width+sx]
+			}
+			if rowEdge || (grid && (x-ax-floorDiv(x-ax, dc.zoom)*dc.zoom) == 0) {
+				// Darken pixel boundaries by a quarter
+				color = (255 << 24) | ((color >> 2) & 0x3F3F3F) * 3
+			}
+			pixels[y*dc.width+x] = color
+		}
+	}
+}
+
+// Live preview limits
+const (
+	previewMaxWidth  = 200
+	previewMaxHeight = 150
+	previewMaxZoom   = 4 // Small selections are magnified to show pixel detail
+	previewOffset    = 24
+)
+
+// previewSize returns the thumbnail size for a w x h selection, preserving aspect ratio
+func previewSize(w, h int) (int, int, float64) {
+	if w <= 0 || h <= 0 {
+		return 0, 0, 0
+	}
+	scale := minFloat(float64(previewMaxWidth)/float64(w), float64(previewMaxHeight)/float64(h))
+	scale = minFloat(scale, previewMaxZoom)
+	return maxInt(int(float64(w)*scale), 1), maxInt(int(float64(h)*scale), 1), scale
+}
+
+// previewRect places a pw x ph thumbnail below-right of the cursor, flipping to
+// the other side when it would leave a width x height context
+func previewRect(cursor image.Point, pw, ph, width, height int) image.Rectangle {
+	x := cursor.X + previewOffset
+	if x+pw > width {
+		x = cursor.X - previewOffset - pw
+	}
+	y := cursor.Y + previewOffset
+	if y+ph > height {
+		y = cursor.Y - previewOffset - ph
+	}
+	return image.Rect(x, y, x+pw, y+ph)
+}
+
+// drawPreview draws a scaled copy of the selection (local coordinates) near
+// the cursor and returns where, including its frame
+func (dc *DrawContext) drawPreview(sel image.Rectangle, cursor image.Point, screenshot *image.RGBA) image.Rectangle {
+	if screenshot == nil {
+		return image.Rectangle{}
+	}
+	pw, ph, scale := previewSize(sel.Dx(), sel.Dy())
+	if pw == 0 {
+		return image.Rectangle{}
+	}
+
+	pixelCount := dc.width * dc.height
+	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
+	bounds := screenshot.Bounds()
+	dst := previewRect(cursor, pw, ph, dc.width, dc.height)
+
+	// Nearest-neighbor sampling keeps individual pixels crisp when magnified
+	for dy := 0; dy < ph; dy++ {
+		py := dst.Min.Y + dy
+		if py < 0 || py >= dc.height {
+			continue
+		}
+		sy := clampInt(sel.Min.Y+dc.originY+int(float64(dy)/scale), 0, bounds.Dy()-1)
+		for dx := 0; dx < pw; dx++ {
+			px := dst.Min.X + dx
+			if px < 0 || px >= dc.width {
+				continue
+			}
+			sx := clampInt(sel.Min.X+dc.originX+int(float64(dx)/scale), 0, bounds.Dx()-1)
+			srcIdx := sy*screenshot.Stride + sx*4
+
+			r := screenshot.Pix[srcIdx]
+			g := screenshot.Pix[srcIdx+1]
+			b := screenshot.Pix[srcIdx+2]
+			pixels[py*dc.width+px] = (255 << 24) | (uint32(r) << 16) | (uint32(g) << 8) | uint32(b)
+		}
+	}
+
+	// Frame the thumbnail with the selection border style
+	dc.drawSelectionBorder(dst.Min.X-2, dst.Min.Y-2, pw+4, ph+4)
+	return dst.Inset(-2)
+}
+
 // drawScreenshot copies screenshot to pixel buffer
 func (dc *DrawContext) drawScreenshot(screenshot *image.RGBA) {
 	if screenshot == nil {
@@ -124,9 +360,9 @@ func (dc *DrawContext) drawScreenshot(screenshot *image.RGBA) {
 	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
 
 	// Copy pixels (convert RGBA to BGRA with premultiplied alpha)
-	for y := 0; y < minInt(srcHeight, dc.height); y++ {
-		for x := 0; x < minInt(srcWidth, dc.width); x++ {
-			srcIdx := y*screenshot.Stride + x*4
+	for y := 0; y < minInt(srcHeight-dc.originY, dc.height); y++ {
+		for x := 0; x < minInt(srcWidth-dc.originX, dc.width); x++ {
+			srcIdx := (y+dc.originY)*screenshot.Stride + (x+dc.originX)*4
 			dstIdx := y*dc.width + x
 
 			r := screenshot.Pix[srcIdx]
@@ -162,6 +398,33 @@ func (dc *DrawContext) fillOverlay(alpha uint8) {
 	}
 }
 
+// dimRegion redraws a local rectangle of the screenshot darkened to the given alpha
+func (dc *DrawContext) dimRegion(r image.Rectangle, alpha uint8, screenshot *image.RGBA) {
+	if screenshot == nil {
+		return
+	}
+
+	bounds := screenshot.Bounds()
+	r = r.Intersect(image.Rect(0, 0, dc.width, dc.height))
+	r = r.Intersect(image.Rect(-dc.originX, -dc.originY, bounds.Dx()-dc.originX, bounds.Dy()-dc.originY))
+
+	pixelCount := dc.width * dc.height
+	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
+	factor := float64(255-alpha) / 255.0
+
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			srcIdx := (py+dc.originY)*screenshot.Stride + (px+dc.originX)*4
+
+			red := uint32(float64(screenshot.Pix[srcIdx]) * factor)
+			g := uint32(float64(screenshot.Pix[srcIdx+1]) * factor)
+			b := uint32(float64(screenshot.Pix[srcIdx+2]) * factor)
+
+			pixels[py*dc.width+px] = (255 << 24) | (red << 16) | (g << 8) | b
+		}
+	}
+}
+
 // clearRegion restores original screenshot in the selection area
 func (dc *DrawContext) clearRegion(x, y, w, h int, screenshot *image.RGBA) {
 	if screenshot == nil {
@@ -177,16 +440,16 @@ func (dc *DrawContext) clearRegion(x, y, w, h int, screenshot *image.RGBA) {
 
 	for dy := 0; dy < h; dy++ {
 		py := y + dy
-		if py < 0 || py >= dc.height || py >= srcHeight {
+		if py < 0 || py >= dc.height || py+dc.originY >= srcHeight {
 			continue
 		}
 		for dx := 0; dx < w; dx++ {
 			px := x + dx
-			if px < 0 || px >= dc.width || px >= srcWidth {
+			if px < 0 || px >= dc.width || px+dc.originX >= srcWidth {
 				continue
 			}
 
-			srcIdx := py*screenshot.Stride + px*4
+			srcIdx := (py+dc.originY)*screenshot.Stride + (px+dc.originX)*4
 			dstIdx := py*dc.width + px
 
 			r := screenshot.Pix[srcIdx]
@@ -198,6 +461,89 @@ func (dc *DrawContext) clearRegion(x, y, w, h int, screenshot *image.RGBA) {
 	}
 }
 
+// clearEllipse restores the screenshot inside the ellipse inscribed in r (local coordinates)
+func (dc *DrawContext) clearEllipse(r image.Rectangle, screenshot *image.RGBA) {
+	for y := maxInt(r.Min.Y, 0); y < minInt(r.Max.Y, dc.height); y++ {
+		if x0, x1, ok := imaging.EllipseSpan(r, y); ok {
+			dc.clearRegion(x0, y, x1-x0, 1, screenshot)
+		}
+	}
+}
+
+// clearPolygon restores the screenshot inside the closed polygon path (local coordinates)
+func (dc *DrawContext) clearPolygon(path []image.Point, screenshot *image.RGBA) {
+	bounds := pathBounds(path)
+	for y := maxInt(bounds.Min.Y, 0); y < minInt(bounds.Max.Y, dc.height); y++ {
+		for _, span := range imaging.PolygonSpans(path, y) {
+			dc.clearRegion(span[0], y, span[1]-span[0], 1, screenshot)
+		}
+	}
+}
+
+// drawEllipseBorder draws a 2px blue outline of the ellipse inscribed in r
+func (dc *DrawContext) drawEllipseBorder(r image.Rectangle) {
+	pixelCount := dc.width * dc.height
+	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
+	blue := uint32((255 << 24) | (0x00 << 16) | (0x78 << 8) | 0xD7)
+	inner := r.Inset(2)
+
+	for y := maxInt(r.Min.Y, 0); y < minInt(r.Max.Y, dc.height); y++ {
+		x0, x1, ok := imaging.EllipseSpan(r, y)
+		if !ok {
+			continue
+		}
+		ix0, ix1, innerOK := imaging.EllipseSpan(inner, y)
+		for x := maxInt(x0, 0); x < minInt(x1, dc.width); x++ {
+			if innerOK && x >= ix0 && x < ix1 {
+				continue
+			}
+			pixels[y*dc.width+x] = blue
+		}
+	}
+}
+
+// drawPath draws a 2px blue closed outline through path (local coordinates)
+func (dc *DrawContext) drawPath(path []image.Point) {
+	pixelCount := dc.width * dc.height
+	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
+	blue := uint32((255 << 24) | (0x00 << 16) | (0x78 << 8) | 0xD7)
+
+	plot := func(x, y int) {
+		for dy := 0; dy < 2; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				px, py := x+dx, y+dy
+				if px >= 0 && px < dc.width && py >= 0 && py < dc.height {
+					pixels[py*dc.width+px] = blue
+				}
+			}
+		}
+	}
+
+	for i := range path {
+		a, b := path[i], path[(i+1)%len(path)]
+		// Bresenham line from a to b
+		dx, dy := absInt(b.X-a.X), -absInt(b.Y-a.Y)
+		sx, sy := signInt(b.X-a.X), signInt(b.Y-a.Y)
+		err := dx + dy
+		x, y := a.X, a.Y
+		for {
+			plot(x, y)
+			if x == b.X && y == b.Y {
+				break
+			}
+			e2 := 2 * err
+			if e2 >= dy {
+				err += dy
+				x += sx
+			}
+			if e2 <= dx {
+				err += dx
+				y += sy
+			}
+		}
+	}
+}
+
 // drawSelectionBorder draws a 2px blue border
 func (dc *DrawContext) drawSelectionBorder(x, y, w, h int) {
 	pixelCount := dc.width * dc.height
@@ -239,6 +585,27 @@ func (dc *DrawContext) drawSelectionBorder(x, y, w, h int) {
 	}
 }
 
+// drawGhost draws a 1px dashed white outline for a recent or suggested region
+func (dc *DrawContext) drawGhost(r image.Rectangle) {
+	pixelCount := dc.width * dc.height
+	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
+
+	white := uint32((255 << 24) | (255 << 16) | (255 << 8) | 255)
+	plot := func(px, py, along int) {
+		if (along/4)%2 == 0 && px >= 0 && px < dc.width && py >= 0 && py < dc.height {
+			pixels[py*dc.width+px] = white
+		}
+	}
+	for px := r.Min.X; px < r.Max.X; px++ {
+		plot(px, r.Min.Y, px)
+		plot(px, r.Max.Y-1, px)
+	}
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		plot(r.Min.X, py, py)
+		plot(r.Max.X-1, py, py)
+	}
+}
+
 // drawCornerHandles draws 6x6 blue squares at corners
 func (dc *DrawContext) drawCornerHandles(x, y, w, h int) {
 	pixelCount := dc.width * dc.height
@@ -267,13 +634,13 @@ func (dc *DrawContext) drawCornerHandles(x, y, w, h int) {
 	}
 }
 
-// drawSizeIndicator draws the "WxH" size text
-func (dc *DrawContext) drawSizeIndicator(x, y, w, h int) {
+// drawSizeIndicator draws the "W x H" size label, optionally preceded by the
+// client position
+func (dc *DrawContext) drawSizeIndicator(x, y int, text string) {
 	pixelCount := dc.width * dc.height
 	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
 
 	// Draw a blue background pill
-	text := fmt.Sprintf("%d x %d", w, h)
 	textWidth := len(text) * 7 // Approximate character width
 	pillWidth := textWidth + 16
 	pillHeight := 20
@@ -312,7 +679,7 @@ func (dc *DrawContext) drawSizeIndicator(x, y, w, h int) {
 func (dc *DrawContext) drawText(x, y int, text string, pixels []uint32) {
 	white := uint32((255 << 24) | (255 << 16) | (255 << 8) | 255)
 
-	// Simple 5x7 bitmap font for digits and 'x'
+	// Simple 5x7 bitmap font for numbers and 'x'
 	font := map[rune][]uint8{
 		'0': {0x3E, 0x45, 0x49, 0x51, 0x3E},
 		'1': {0x00, 0x21, 0x7F, 0x01, 0x00},
@@ -325,6 +692,8 @@ func (dc *DrawContext) drawText(x, y int, text string, pixels []uint32) {
 		'8': {0x36, 0x49, 0x49, 0x49, 0x36},
 		'9': {0x32, 0x49, 0x49, 0x49, 0x3E},
 		'x': {0x00, 0x14, 0x08, 0x14, 0x00},
+		',': {0x00, 0x01, 0x06, 0x00, 0x00},
+		'-': {0x08, 0x08, 0x08, 0x08, 0x08},
 		' ': {0x00, 0x00, 0x00, 0x00, 0x00},
 	}
 
@@ -357,9 +726,24 @@ func (dc *DrawContext) drawInstructions(sel *Selection) {
 	pixels := unsafe.Slice((*uint32)(dc.pixels), pixelCount)
 
 	var text string
-	if sel.IsDragging && sel.SpaceHeld {
+	switch {
+	case sel.Recent > 0:
+		text = fmt.Sprintf("Recent %d/%d: Tab/Shift+Tab cycle. Enter capture. C copy. ESC cancel", sel.Recent, sel.RecentCount)
+	case sel.IsDragging && sel.SpaceHeld:
 		text = "Hold Space + Drag to reposition"
-	} else {
+	case sel.Adjusting:
+		text = "Drag edges to resize or inside to move. Enter capture. ESC cancel"
+	case sel.Mode == ModeColor:
+		text = "Click a pixel to pick its color. ESC cancel"
+	case sel.Mode == ModeWindow:
+		text = "Click a window to select. Hold Ctrl for controls. C copy bounds. ESC cancel"
+	case sel.Suggested:
+		text = "Click to select the outlined area. Drag to select. ESC cancel"
+	case sel.Snap:
+		text = "Drag to select, snapping to edges. S no snap. Space to move. ESC cancel"
+	case sel.RecentCount > 0:
+		text = "Drag to select. Space to move. Tab recent. ESC cancel"
+	default:
 		text = "Drag to select. Space to move. ESC cancel"
 	}
 
@@ -391,52 +775,9 @@ func (dc *DrawContext) drawInstructions(sel *Selection) {
 func (dc *DrawContext) drawInstructionText(x, y int, text string, pixels []uint32) {
 	white := uint32((255 << 24) | (255 << 16) | (255 << 8) | 255)
 
-	// Extended 5x7 bitmap font
-	font := map[rune][]uint8{
-		'0': {0x3E, 0x45, 0x49, 0x51, 0x3E},
-		'1': {0x00, 0x21, 0x7F, 0x01, 0x00},
-		'2': {0x27, 0x49, 0x49, 0x49, 0x31},
-		'3': {0x22, 0x49, 0x49, 0x49, 0x36},
-		'4': {0x0C, 0x14, 0x24, 0x7F, 0x04},
-		'5': {0x72, 0x51, 0x51, 0x51, 0x4E},
-		'6': {0x3E, 0x49, 0x49, 0x49, 0x26},
-		'7': {0x40, 0x40, 0x47, 0x48, 0x70},
-		'8': {0x36, 0x49, 0x49, 0x49, 0x36},
-		'9': {0x32, 0x49, 0x49, 0x49, 0x3E},
-		'A': {0x3F, 0x48, 0x48, 0x48, 0x3F},
-		'B': {0x7F, 0x49, 0x49, 0x49, 0x36},
-		'C': {0x3E, 0x41, 0x41, 0x41, 0x22},
-		'D': {0x7F, 0x41, 0x41, 0x41, 0x3E},
-		'E': {0x7F, 0x49, 0x49, 0x49, 0x41},
-		'S': {0x32, 0x49, 0x49, 0x49, 0x26},
-		'a': {0x02, 0x15, 0x15, 0x15, 0x0F},
-		'c': {0x0E, 0x11, 0x11, 0x11, 0x0A},
-		'd': {0x0E, 0x11, 0x11, 0x11, 0x7F},
-		'e': {0x0E, 0x15, 0x15, 0x15, 0x0C},
-		'g': {0x08, 0x15, 0x15, 0x15, 0x1E},
-		'h': {0x7F, 0x08, 0x08, 0x08, 0x07},
-		'i': {0x00, 0x00, 0x2F, 0x00, 0x00},
-		'l': {0x00, 0x00, 0x7F, 0x00, 0x00},
-		'm': {0x1F, 0x10, 0x0E, 0x10, 0x0F},
-		'n': {0x1F, 0x08, 0x10, 0x10, 0x0F},
-		'o': {0x0E, 0x11, 0x11, 0x11, 0x0E},
-		'p': {0x1F, 0x14, 0x14, 0x14, 0x08},
-		'r': {0x1F, 0x08, 0x10, 0x10, 0x08},
-		's': {0x09, 0x15, 0x15, 0x15, 0x12},
-		't': {0x10, 0x7E, 0x11, 0x01, 0x02},
-		'u': {0x1E, 0x01, 0x01, 0x01, 0x1E},
-		'v': {0x18, 0x06, 0x01, 0x06, 0x18},
-		'x': {0x11, 0x0A, 0x04, 0x0A, 0x11},
-		' ': {0x00, 0x00, 0x00, 0x00, 0x00},
-		'.': {0x00, 0x01, 0x00, 0x00, 0x00},
-		'+': {0x04, 0x04, 0x1F, 0x04, 0x04},
-		'H': {0x7F, 0x08, 0x08, 0x08, 0x7F},
-		'P': {0x7F, 0x48, 0x48, 0x48, 0x30},
-	}
-
 	curX := x
 	for _, ch := range text {
-		glyph, ok := font[ch]
+		glyph, ok := instructionFont[ch]
 		if !ok {
 			curX += 6
 			continue
@@ -457,17 +798,89 @@ func (dc *DrawContext) drawInstructionText(x, y int, text string, pixels []uint3
 	}
 }
 
-// Cleanup releases GDI resources
+// instructionFont is the extended 5x7 bitmap font used for hints and the
+// status widget. Each column is one byte with the top row at bit 6.
+var instructionFont = map[rune][]uint8{
+	'0': {0x3E, 0x45, 0x49, 0x51, 0x3E},
+	'1': {0x00, 0x21, 0x7F, 0x01, 0x00},
+	'2': {0x27, 0x49, 0x49, 0x49, 0x31},
+	'3': {0x22, 0x49, 0x49, 0x49, 0x36},
+	'4': {0x0C, 0x14, 0x24, 0x7F, 0x04},
+	'5': {0x72, 0x51, 0x51, 0x51, 0x4E},
+	'6': {0x3E, 0x49, 0x49, 0x49, 0x26},
+	'7': {0x40, 0x40, 0x47, 0x48, 0x70},
+	'8': {0x36, 0x49, 0x49, 0x49, 0x36},
+	'9': {0x32, 0x49, 0x49, 0x49, 0x3E},
+	'A': {0x3F, 0x48, 0x48, 0x48, 0x3F},
+	'B': {0x7F, 0x49, 0x49, 0x49, 0x36},
+	'C': {0x3E, 0x41, 0x41, 0x41, 0x22},
+	'D': {0x7F, 0x41, 0x41, 0x41, 0x3E},
+	'E': {0x7F, 0x49, 0x49, 0x49, 0x41},
+	'S': {0x32, 0x49, 0x49, 0x49, 0x26},
+	'a': {0x02, 0x15, 0x15, 0x15, 0x0F},
+	'c': {0x0E, 0x11, 0x11, 0x11, 0x0A},
+	'd': {0x0E, 0x11, 0x11, 0x11, 0x7F},
+	'e': {0x0E, 0x15, 0x15, 0x15, 0x0C},
+	'g': {0x08, 0x15, 0x15, 0x15, 0x1E},
+	'h': {0x7F, 0x08, 0x08, 0x08, 0x07},
+	'i': {0x00, 0x00, 0x2F, 0x00, 0x00},
+	'l': {0x00, 0x00, 0x7F, 0x00, 0x00},
+	'm': {0x1F, 0x10, 0x0E, 0x10, 0x0F},
+	'n': {0x1F, 0x08, 0x10, 0x10, 0x0F},
+	'o': {0x0E, 0x11, 0x11, 0x11, 0x0E},
+	'p': {0x1F, 0x14, 0x14, 0x14, 0x08},
+	'r': {0x1F, 0x08, 0x10, 0x10, 0x08},
+	's': {0x09, 0x15, 0x15, 0x15, 0x12},
+	't': {0x10, 0x7E, 0x11, 0x01, 0x02},
+	'u': {0x1E, 0x01, 0x01, 0x01, 0x1E},
+	'v': {0x18, 0x06, 0x01, 0x06, 0x18},
+	'x': {0x11, 0x0A, 0x04, 0x0A, 0x11},
+	' ': {0x00, 0x00, 0x00, 0x00, 0x00},
+	'.': {0x00, 0x01, 0x00, 0x00, 0x00},
+	'+': {0x04, 0x04, 0x1F, 0x04, 0x04},
+	'H': {0x7F, 0x08, 0x08, 0x08, 0x7F},
+	'P': {0x7F, 0x48, 0x48, 0x48, 0x30},
+	'F': {0x7F, 0x48, 0x48, 0x48, 0x40},
+	'G': {0x3E, 0x41, 0x49, 0x49, 0x2F},
+	'I': {0x00, 0x41, 0x7F, 0x41, 0x00},
+	'K': {0x7F, 0x08, 0x14, 0x22, 0x41},
+	'L': {0x7F, 0x01, 0x01, 0x01, 0x01},
+	'M': {0x7F, 0x20, 0x18, 0x20, 0x7F},
+	'N': {0x7F, 0x10, 0x08, 0x04, 0x7F},
+	'O': {0x3E, 0x41, 0x41, 0x41, 0x3E},
+	'R': {0x7F, 0x48, 0x4C, 0x4A, 0x31},
+	'T': {0x40, 0x40, 0x7F, 0x40, 0x40},
+	'U': {0x7E, 0x01, 0x01, 0x01, 0x7E},
+	'W': {0x7E, 0x01, 0x0E, 0x01, 0x7E},
+	'b': {0x7F, 0x09, 0x11, 0x11, 0x0E},
+	'f': {0x08, 0x3F, 0x48, 0x40, 0x20},
+	'k': {0x7F, 0x04, 0x0A, 0x11, 0x00},
+	'w': {0x1E, 0x01, 0x06, 0x01, 0x1E},
+	'y': {0x18, 0x05, 0x05, 0x05, 0x1E},
+	':': {0x00, 0x36, 0x36, 0x00, 0x00},
+	'-': {0x08, 0x08, 0x08, 0x08, 0x08},
+	'/': {0x02, 0x04, 0x08, 0x10, 0x20},
+	',': {0x00, 0x01, 0x06, 0x00, 0x00},
+	'#': {0x14, 0x7F, 0x14, 0x7F, 0x14},
+}
+
+// Cleanup releases GDI resources. The original bitmap is restored before the
+// DIB is deleted, since a bitmap selected into a DC cannot be freed.
+// Calling Cleanup more than once is safe.
 func (dc *DrawContext) Cleanup() {
 	if dc.hOldBitmap != 0 {
 		procSelectObject.Call(dc.HMemDC, dc.hOldBitmap)
+		dc.hOldBitmap = 0
 	}
 	if dc.hBitmap != 0 {
 		procDeleteObject.Call(dc.hBitmap)
+		dc.hBitmap = 0
 	}
 	if dc.HMemDC != 0 {
 		procDeleteDC.Call(dc.HMemDC)
+		dc.HMemDC = 0
 	}
+	dc.pixels = nil
 }
 
 // Helper functions
@@ -478,6 +891,13 @@ func minInt(a, b int) int {
 	return b
 }
 
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 func maxInt(a, b int) int {
 	if a > b {
 		return a
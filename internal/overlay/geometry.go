@@ -0,0 +1,128 @@
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+)
+
+// selectionGeometry is the JSON copied with C. In window mode the bounds
+// are the visible DWM frame, and WindowRect and Delta relate them to the
+// GetWindowRect bounds Win32 APIs use
+type selectionGeometry struct {
+	X          int         `json:"x"`
+	Y          int         `json:"y"`
+	W          int         `json:"w"`
+	H          int         `json:"h"`
+	Monitor    int         `json:"monitor"`
+	WindowRect *rectJSON   `json:"windowRect,omitempty"`
+	Delta      *FrameDelta `json:"delta,omitempty"`
+}
+
+// rectJSON is a rectangle as position and size
+type rectJSON struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// geometryText formats r (screen coordinates) on monitor as JSON, or as a
+// --region argument for the capture command when region is set
+func geometryText(r image.Rectangle, monitor int, region bool) string {
+	if region {
+		return fmt.Sprintf("--region %d,%d,%d,%d", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+	data, _ := json.Marshal(selectionGeometry{
+		X:       r.Min.X,
+		Y:       r.Min.Y,
+		W:       r.Dx(),
+		H:       r.Dy(),
+		Monitor: monitor,
+	})
+	return string(data)
+}
+
+// windowGeometryText formats a window's visible bounds as JSON along with
+// its GetWindowRect bounds api and the difference between them
+func windowGeometryText(visual, api image.Rectangle, monitor int) string {
+	delta := frameDelta(visual, api)
+	data, _ := json.Marshal(selectionGeometry{
+		X:          visual.Min.X,
+		Y:          visual.Min.Y,
+		W:          visual.Dx(),
+		H:          visual.Dy(),
+		Monitor:    monitor,
+		WindowRect: &rectJSON{X: api.Min.X, Y: api.Min.Y, W: api.Dx(), H: api.Dy()},
+		Delta:      &delta,
+	})
+	return string(data)
+}
+
+// monitorIndex returns the index of the monitor containing the center of r,
+// or -1 when it is off every monitor
+func monitorIndex(monitors []image.Rectangle, r image.Rectangle) int {
+	center := image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+	for i, mon := range monitors {
+		if center.In(mon) {
+			return i
+		}
+	}
+	return -1
+}
+
+// currentRect returns the selection being dragged, the highlighted recent
+// region or the hovered window, in overlay coordinates
+func (m *Manager) currentRect() (image.Rectangle, bool) {
+	rects := m.recentRects()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.selection.Mode == ModeWindow {
+		return m.hover, !m.hover.Empty()
+	}
+	if m.selection.IsDragging || m.selection.Adjusting {
+		r := m.selection.Rect()
+		return r, !r.Empty()
+	}
+	if m.recent >= 0 && m.recent < len(rects) {
+		return rects[m.recent], true
+	}
+	return image.Rectangle{}, false
+}
+
+// copyGeometry resolves the pending Show with the selection geometry as text
+// for the clipboard, without capturing. Nothing happens with no selection
+func (m *Manager) copyGeometry(region bool) {
+	r, ok := m.currentRect()
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	monitor := monitorIndex(m.monitors, r)
+	if len(m.monitors) == 0 {
+		monitor = 0
+	}
+	hwnd := m.hoverWindow
+	if m.selection.Mode != ModeWindow {
+		hwnd = 0
+	}
+	resultCh := m.resultCh
+	m.mu.Unlock()
+
+	screen := image.Rectangle{Min: m.screenPoint(r.Min), Max: m.screenPoint(r.Max)}
+	text := geometryText(screen, monitor, region)
+	if hwnd != 0 && !region {
+		// Unclipped, so windows partly off screen keep their real geometry
+		visual, api := windowBounds(hwnd), windowRect(hwnd)
+		if !visual.Empty() && !api.Empty() {
+			text = windowGeometryText(visual, api, monitor)
+		}
+	}
+	if resultCh != nil {
+		select {
+		case resultCh <- Result{Cancelled: true, Copy: text}:
+		default:
+		}
+	}
+	m.handleHide()
+}
@@ -0,0 +1,272 @@
+package overlay
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"winshot/internal/imaging"
+)
+
+var procSetWindowDisplayAffinity = user32.NewProc("SetWindowDisplayAffinity")
+
+// Guide window constants
+const (
+	WS_EX_TRANSPARENT      = 0x00000020 // Click-through
+	SW_SHOWNOACTIVATE      = 4
+	WDA_EXCLUDEFROMCAPTURE = 0x00000011 // Windows 10 2004+
+)
+
+// Guide defaults
+const (
+	DefaultGuideThickness = 2
+	maxGuideThickness     = 20
+)
+
+// DefaultGuideColor is used when no guide color is configured
+var DefaultGuideColor = color.RGBA{R: 0xFF, G: 0x30, B: 0x30, A: 0xFF}
+
+type guideCmd struct {
+	stop      bool
+	show      bool
+	region    image.Rectangle
+	color     color.RGBA
+	thickness int
+}
+
+// Guide keeps a click-through outline around a screen region on top of other
+// windows until hidden, so streamers can see what a capture covers. The border
+// is drawn outside the region so captures of the region itself stay clean.
+type Guide struct {
+	cmdCh   chan guideCmd
+	doneCh  chan struct{}
+	running bool
+	visible bool
+	region  image.Rectangle
+	mu      sync.Mutex
+}
+
+// NewGuide creates a hidden crop guide
+func NewGuide() *Guide {
+	return &Guide{
+		cmdCh: make(chan guideCmd, 10),
+	}
+}
+
+// Show outlines region (screen coordinates) with a border of the given color
+// and thickness, replacing any region shown before
+func (g *Guide) Show(region image.Rectangle, c color.RGBA, thickness int) error {
+	if region.Empty() {
+		return errors.New("crop guide region is empty")
+	}
+	if thickness <= 0 {
+		thickness = DefaultGuideThickness
+	}
+	thickness = minInt(thickness, maxGuideThickness)
+
+	if err := g.start(); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.visible = true
+	g.region = region
+	g.mu.Unlock()
+
+	g.cmdCh <- guideCmd{show: true, region: region, color: c, thickness: thickness}
+	return nil
+}
+
+// Hide removes the guide from the screen
+func (g *Guide) Hide() {
+	g.mu.Lock()
+	running := g.running
+	g.visible = false
+	g.mu.Unlock()
+
+	if running {
+		g.cmdCh <- guideCmd{}
+	}
+}
+
+// Visible returns the outlined region and whether the guide is showing
+func (g *Guide) Visible() (image.Rectangle, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.region, g.visible
+}
+
+// Close destroys the guide window
+func (g *Guide) Close() {
+	g.mu.Lock()
+	if !g.running {
+		g.mu.Unlock()
+		return
+	}
+	g.running = false
+	g.visible = false
+	doneCh := g.doneCh
+	g.mu.Unlock()
+	g.cmdCh <- guideCmd{stop: true}
+
+	select {
+	case <-doneCh:
+	case <-time.After(2 * time.Second):
+	}
+}
+
+// start creates the guide window on its own thread if not already running
+func (g *Guide) start() error {
+	g.mu.Lock()
+	if g.running {
+		g.mu.Unlock()
+		return nil
+	}
+	g.running = true
+	g.doneCh = make(chan struct{})
+	g.mu.Unlock()
+
+	readyCh := make(chan error, 1)
+	go g.messageLoop(readyCh)
+
+	if err := <-readyCh; err != nil {
+		g.mu.Lock()
+		g.running = false
+		g.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (g *Guide) messageLoop(readyCh chan<- error) {
+	// Window messages are delivered to the creating thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(g.doneCh)
+
+	hInstance, _, _ := procGetModuleHandleW.Call(0)
+	className, _ := syscall.UTF16PtrFromString("WinShotGuide")
+
+	// The guide never handles input, so the default procedure is enough
+	wc := WNDCLASSEXW{
+		CbSize:        uint32(unsafe.Sizeof(WNDCLASSEXW{})),
+		LpfnWndProc:   procDefWindowProcW.Addr(),
+		HInstance:     hInstance,
+		LpszClassName: className,
+	}
+	if registerClassEx(&wc) == 0 {
+		readyCh <- errors.New("failed to register crop guide window class")
+		return
+	}
+	defer unregisterClass(className, hInstance)
+
+	hwnd, _, _ := procCreateWindowExW.Call(
+		WS_EX_LAYERED|WS_EX_TRANSPARENT|WS_EX_TOPMOST|WS_EX_TOOLWINDOW|WS_EX_NOACTIVATE,
+		uintptr(unsafe.Pointer(className)),
+		0,
+		WS_POPUP,
+		0, 0, 1, 1,
+		0, 0, hInstance, 0,
+	)
+	if hwnd == 0 {
+		readyCh <- errors.New("failed to create crop guide window")
+		return
+	}
+	defer procDestroyWindow.Call(hwnd)
+
+	// Keep the guide out of screenshots and stream captures; it is only a
+	// hint for the person at the screen. Older systems simply show it.
+	procSetWindowDisplayAffinity.Call(hwnd, WDA_EXCLUDEFROMCAPTURE)
+	readyCh <- nil
+
+	var msg MSG
+	for {
+		select {
+		case cmd := <-g.cmdCh:
+			switch {
+			case cmd.stop:
+				return
+			case cmd.show:
+				if err := renderGuide(hwnd, cmd); err != nil {
+					println("Warning: failed to draw crop guide:", err.Error())
+					continue
+				}
+				procShowWindow.Call(hwnd, SW_SHOWNOACTIVATE)
+			default:
+				procShowWindow.Call(hwnd, SW_HIDE)
+			}
+		default:
+			ret, _, _ := procPeekMessageW.Call(
+				uintptr(unsafe.Pointer(&msg)),
+				0, 0, 0, PM_REMOVE,
+			)
+			if ret != 0 {
+				procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+				procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+// renderGuide draws the border into a layered window positioned around the region
+func renderGuide(hwnd uintptr, cmd guideCmd) error {
+	outer := cmd.region.Inset(-cmd.thickness)
+
+	hScreenDC, _, _ := procGetDC.Call(0)
+	defer procReleaseDC.Call(0, hScreenDC)
+
+	dc, err := newDrawContext(hScreenDC, outer.Dx(), outer.Dy())
+	if err != nil {
+		return err
+	}
+	defer dc.Cleanup()
+	dc.drawGuideBorder(cmd.thickness, cmd.color)
+
+	ptSrc := POINT{0, 0}
+	ptDst := POINT{int32(outer.Min.X), int32(outer.Min.Y)}
+	size := SIZE{int32(outer.Dx()), int32(outer.Dy())}
+	blend := BLENDFUNCTION{AC_SRC_OVER, 0, 255, AC_SRC_ALPHA}
+
+	ret, _, _ := procUpdateLayeredWindow.Call(
+		hwnd,
+		0,
+		uintptr(unsafe.Pointer(&ptDst)),
+		uintptr(unsafe.Pointer(&size)),
+		dc.HMemDC,
+		uintptr(unsafe.Pointer(&ptSrc)),
+		0,
+		uintptr(unsafe.Pointer(&blend)),
+		ULW_ALPHA,
+	)
+	if ret == 0 {
+		return errors.New("UpdateLayeredWindow failed")
+	}
+	return nil
+}
+
+// drawGuideBorder fills a border of the given thickness around the edge of the
+// context, leaving the interior fully transparent
+func (dc *DrawContext) drawGuideBorder(thickness int, c color.RGBA) {
+	pixels := unsafe.Slice((*uint32)(dc.pixels), dc.width*dc.height)
+
+	px := premultiplied(c)
+
+	for y := 0; y < dc.height; y++ {
+		for x := 0; x < dc.width; x++ {
+			if x < thickness || y < thickness || x >= dc.width-thickness || y >= dc.height-thickness {
+				pixels[y*dc.width+x] = px
+			}
+		}
+	}
+}
+
+// ParseColor parses "#RRGGBB" or "#RRGGBBAA" hex colors
+func ParseColor(s string) (color.RGBA, error) {
+	return imaging.ParseColor(s)
+}
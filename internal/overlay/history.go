@@ -0,0 +1,91 @@
+package overlay
+
+import "image"
+
+// maxHistory is how many recently used regions Tab cycles through
+const maxHistory = 8
+
+// minRecentSize drops remembered regions that have become slivers after
+// clipping to the selectable area, matching the smallest drag accepted
+const minRecentSize = 10
+
+// pushHistory puts r (screen coordinates) at the front of history, dropping
+// an older copy of it and anything beyond maxHistory
+func pushHistory(history []image.Rectangle, r image.Rectangle) []image.Rectangle {
+	out := []image.Rectangle{r}
+	for _, h := range history {
+		if h != r && len(out) < maxHistory {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// visibleHistory returns the remembered regions in overlay coordinates,
+// clipped to the selectable area, newest first
+func visibleHistory(history []image.Rectangle, origin image.Point, area image.Rectangle) []image.Rectangle {
+	var rects []image.Rectangle
+	for _, h := range history {
+		r := h.Sub(origin).Intersect(area)
+		if r.Dx() > minRecentSize && r.Dy() > minRecentSize {
+			rects = append(rects, r)
+		}
+	}
+	return rects
+}
+
+// cycleIndex steps through n entries from i, wrapping at either end. Tab
+// from no selection (-1) starts at the newest, Shift+Tab at the oldest
+func cycleIndex(i, n, step int) int {
+	if n == 0 {
+		return -1
+	}
+	if i < 0 {
+		if step > 0 {
+			return 0
+		}
+		return n - 1
+	}
+	return ((i+step)%n + n) % n
+}
+
+// recentRects returns the regions Tab cycles through on this show
+func (m *Manager) recentRects() []image.Rectangle {
+	m.mu.Lock()
+	history := m.history
+	m.mu.Unlock()
+	return visibleHistory(history, m.bounds.Min, m.selectableRect())
+}
+
+// cycleRecent highlights the next (step 1) or previous (step -1) recently
+// used region, unless a drag is in progress
+func (m *Manager) cycleRecent(step int) {
+	rects := m.recentRects()
+	m.mu.Lock()
+	if m.selection.IsDragging || m.selection.Adjusting {
+		m.mu.Unlock()
+		return
+	}
+	m.recent = cycleIndex(m.recent, len(rects), step)
+	m.mu.Unlock()
+	m.redraw()
+}
+
+// useRecent selects the highlighted recent region. It reports false when
+// none is highlighted
+func (m *Manager) useRecent() bool {
+	rects := m.recentRects()
+	m.mu.Lock()
+	i := m.recent
+	if i < 0 || i >= len(rects) {
+		m.mu.Unlock()
+		return false
+	}
+	// A remembered region has no outline to reuse
+	if m.selection.Mode == ModeFreehand {
+		m.selection.Mode = ModeRectangle
+	}
+	m.mu.Unlock()
+	m.finish(rects[i], nil)
+	return true
+}
@@ -0,0 +1,354 @@
+//go:build windows && integration
+
+// Integration tests drive the real overlay window with synthesized input, so
+// they need an interactive desktop session that nothing else is using:
+//
+//	go test -tags integration -run Integration ./internal/overlay
+//
+// They show the overlay over a synthetic screenshot, move the mouse and press
+// keys with SendInput, and check both the Result and what was on screen.
+
+package overlay
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+
+	"winshot/internal/screenshot"
+)
+
+var (
+	procSendInput                     = user32.NewProc("SendInput")
+	procSetProcessDpiAwarenessContext = user32.NewProc("SetProcessDpiAwarenessContext")
+)
+
+const (
+	inputMouse    = 0
+	inputKeyboard = 1
+
+	mouseeventfMove       = 0x0001
+	mouseeventfLeftDown   = 0x0002
+	mouseeventfLeftUp     = 0x0004
+	mouseeventfVirtualDsk = 0x4000
+	mouseeventfAbsolute   = 0x8000
+	keyeventfKeyUp        = 0x0002
+
+	// DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2, so overlay, input and
+	// capture coordinates are all physical pixels
+	dpiPerMonitorAwareV2 = ^uintptr(3)
+
+	inputSettle   = 30 * time.Millisecond  // Lets the message loop handle each event
+	showSettle    = 400 * time.Millisecond // Lets the overlay appear and take focus
+	resultTimeout = 3 * time.Second
+)
+
+// mouseInputEvent and keyboardInputEvent mirror INPUT with MOUSEINPUT and
+// KEYBDINPUT, padded to the size of the union
+type mouseInputEvent struct {
+	typ       uint32
+	_         uint32
+	dx, dy    int32
+	mouseData uint32
+	flags     uint32
+	time      uint32
+	extraInfo uintptr
+}
+
+type keyboardInputEvent struct {
+	typ       uint32
+	_         uint32
+	vk, scan  uint16
+	flags     uint32
+	time      uint32
+	extraInfo uintptr
+	_         [8]byte
+}
+
+// patternColor fills the synthetic screenshot, bright enough that dimming
+// is obvious
+var patternColor = color.RGBA{R: 220, G: 180, B: 60, A: 255}
+
+func TestMain(m *testing.M) {
+	if procSetProcessDpiAwarenessContext.Find() == nil {
+		procSetProcessDpiAwarenessContext.Call(dpiPerMonitorAwareV2)
+	}
+	os.Exit(m.Run())
+}
+
+// harness is a running overlay manager covering the primary screen
+type harness struct {
+	t       *testing.T
+	m       *Manager
+	virtual image.Rectangle // Virtual screen, for absolute mouse coordinates
+	bounds  image.Rectangle // Area the overlay covers
+}
+
+func newHarness(t *testing.T, opts Options) *harness {
+	t.Helper()
+	if desktop, err := screenshot.InputDesktop(); err != nil || desktop != "Default" {
+		t.Skipf("no interactive desktop (input desktop %q, %v)", desktop, err)
+	}
+
+	m := NewManager()
+	m.SetOptions(opts)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(m.Stop)
+	return &harness{t: t, m: m, virtual: virtualScreen(), bounds: primaryScreen()}
+}
+
+// show opens the overlay over a synthetic screenshot and waits for it to
+// appear
+func (h *harness) show() <-chan Result {
+	ch := h.m.Show(h.pattern(), h.bounds, 1.0)
+	time.Sleep(showSettle)
+	return ch
+}
+
+// pattern returns a synthetic screenshot of the overlay area filled with
+// patternColor
+func (h *harness) pattern() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, h.bounds.Dx(), h.bounds.Dy()))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = patternColor.R, patternColor.G, patternColor.B, patternColor.A
+	}
+	return img
+}
+
+// move moves the pointer to p, in overlay coordinates
+func (h *harness) move(p image.Point) {
+	h.mouse(p, mouseeventfMove)
+}
+
+func (h *harness) down(p image.Point) { h.mouse(p, mouseeventfMove|mouseeventfLeftDown) }
+func (h *harness) up(p image.Point)   { h.mouse(p, mouseeventfMove|mouseeventfLeftUp) }
+
+// drag presses at from, moves to to in steps and releases there
+func (h *harness) drag(from, to image.Point) {
+	h.down(from)
+	h.dragTo(from, to)
+	h.up(to)
+}
+
+// dragTo moves with the button held from from to to in a few steps, as a
+// real drag would
+func (h *harness) dragTo(from, to image.Point) {
+	const steps = 8
+	d := to.Sub(from)
+	for i := 1; i <= steps; i++ {
+		h.move(from.Add(d.Mul(i).Div(steps)))
+	}
+}
+
+func (h *harness) mouse(p image.Point, flags uint32) {
+	h.t.Helper()
+	screen := p.Add(h.bounds.Min).Sub(h.virtual.Min)
+	in := mouseInputEvent{
+		typ:   inputMouse,
+		dx:    int32(screen.X * 65535 / max(h.virtual.Dx()-1, 1)),
+		dy:    int32(screen.Y * 65535 / max(h.virtual.Dy()-1, 1)),
+		flags: flags | mouseeventfAbsolute | mouseeventfVirtualDsk,
+	}
+	h.send(unsafe.Pointer(&in), unsafe.Sizeof(in))
+}
+
+// key presses and releases vk, holding the modifiers meanwhile
+func (h *harness) key(vk uint16, modifiers ...uint16) {
+	for _, m := range modifiers {
+		h.keyEvent(m, 0)
+	}
+	h.keyEvent(vk, 0)
+	h.keyEvent(vk, keyeventfKeyUp)
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		h.keyEvent(modifiers[i], keyeventfKeyUp)
+	}
+}
+
+func (h *harness) keyEvent(vk uint16, flags uint32) {
+	in := keyboardInputEvent{typ: inputKeyboard, vk: vk, flags: flags}
+	h.send(unsafe.Pointer(&in), unsafe.Sizeof(in))
+}
+
+func (h *harness) send(in unsafe.Pointer, size uintptr) {
+	h.t.Helper()
+	if n, _, err := procSendInput.Call(1, uintptr(in), size); n != 1 {
+		h.t.Fatalf("SendInput: %v", err)
+	}
+	time.Sleep(inputSettle)
+}
+
+// result waits for the overlay to resolve
+func (h *harness) result(ch <-chan Result) Result {
+	h.t.Helper()
+	select {
+	case res := <-ch:
+		return res
+	case <-time.After(resultTimeout):
+		h.t.Fatal("overlay did not return a result")
+		return Result{}
+	}
+}
+
+// frame captures what is on screen over the overlay area with Desktop
+// Duplication, which sees layered windows. It skips the test where that is
+// unavailable, such as in some remote sessions
+func (h *harness) frame() *image.RGBA {
+	h.t.Helper()
+	img, err := screenshot.CaptureRectRaw(h.bounds, screenshot.CaptureOptions{Backend: screenshot.BackendDXGI})
+	if errors.Is(err, screenshot.ErrCaptureBackendUnavailable) {
+		h.t.Skipf("cannot capture the screen: %v", err)
+	}
+	if err != nil {
+		h.t.Fatalf("capture: %v", err)
+	}
+	return img
+}
+
+// closeTo reports whether every channel of a and b is within tolerance
+func closeTo(a, b color.RGBA, tolerance int) bool {
+	d := func(x, y uint8) bool { return int(x)-int(y) <= tolerance && int(y)-int(x) <= tolerance }
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B)
+}
+
+// darker reports whether a is clearly darker than b on every channel
+func darker(a, b color.RGBA) bool {
+	return int(a.R) < int(b.R)-30 && int(a.G) < int(b.G)-30 && int(a.B) < int(b.B)-15
+}
+
+func TestIntegrationDragSelection(t *testing.T) {
+	h := newHarness(t, Options{})
+	ch := h.show()
+
+	from, to := image.Pt(200, 180), image.Pt(600, 480)
+	h.down(from)
+	h.dragTo(from, to)
+
+	// Mid-drag the selection shows the screenshot as is and the rest is dimmed
+	frame := h.frame()
+	inside := frame.RGBAAt(400, 330)
+	outside := frame.RGBAAt(h.bounds.Dx()-100, h.bounds.Dy()-100)
+	if !closeTo(inside, patternColor, 8) {
+		t.Errorf("pixel inside the selection = %v, want the screenshot %v", inside, patternColor)
+	}
+	if !darker(outside, patternColor) {
+		t.Errorf("pixel outside the selection = %v, want it dimmed from %v", outside, patternColor)
+	}
+
+	h.up(to)
+	res := h.result(ch)
+	want := image.Rect(from.X, from.Y, to.X, to.Y)
+	if res.Cancelled || image.Rect(res.X, res.Y, res.X+res.Width, res.Y+res.Height) != want {
+		t.Errorf("result = %+v, want selection %v", res, want)
+	}
+}
+
+func TestIntegrationReverseDrag(t *testing.T) {
+	h := newHarness(t, Options{})
+	ch := h.show()
+
+	h.drag(image.Pt(500, 400), image.Pt(300, 250))
+	res := h.result(ch)
+	if got := image.Rect(res.X, res.Y, res.X+res.Width, res.Y+res.Height); got != image.Rect(300, 250, 500, 400) {
+		t.Errorf("selection dragged up-left = %v, want (300,250)-(500,400)", got)
+	}
+}
+
+func TestIntegrationSquareWithShift(t *testing.T) {
+	h := newHarness(t, Options{})
+	ch := h.show()
+
+	h.keyEvent(VK_SHIFT, 0)
+	h.drag(image.Pt(100, 100), image.Pt(400, 250))
+	h.keyEvent(VK_SHIFT, keyeventfKeyUp)
+
+	res := h.result(ch)
+	if res.Cancelled || res.Width != res.Height {
+		t.Errorf("Shift drag result = %dx%d (cancelled %v), want a square", res.Width, res.Height, res.Cancelled)
+	}
+}
+
+func TestIntegrationEscapeCancels(t *testing.T) {
+	h := newHarness(t, Options{})
+	ch := h.show()
+
+	h.move(image.Pt(300, 300))
+	h.key(VK_ESCAPE)
+	if res := h.result(ch); !res.Cancelled {
+		t.Errorf("result after Esc = %+v, want cancelled", res)
+	}
+}
+
+func TestIntegrationCopyGeometry(t *testing.T) {
+	h := newHarness(t, Options{})
+	ch := h.show()
+
+	from, to := image.Pt(150, 120), image.Pt(450, 320)
+	h.down(from)
+	h.dragTo(from, to)
+	h.key(VK_C)
+	h.up(to)
+
+	res := h.result(ch)
+	if !res.Cancelled || res.Copy == "" {
+		t.Fatalf("result after C = %+v, want cancelled with geometry to copy", res)
+	}
+	var g selectionGeometry
+	if err := json.Unmarshal([]byte(res.Copy), &g); err != nil {
+		t.Fatalf("copied geometry %q: %v", res.Copy, err)
+	}
+	if g.W != to.X-from.X || g.H != to.Y-from.Y {
+		t.Errorf("copied size = %dx%d, want %dx%d", g.W, g.H, to.X-from.X, to.Y-from.Y)
+	}
+}
+
+func TestIntegrationColorPicker(t *testing.T) {
+	h := newHarness(t, Options{})
+	img := h.pattern()
+	want := color.RGBA{R: 12, G: 200, B: 99, A: 255}
+	p := image.Pt(321, 123)
+	img.SetRGBA(p.X, p.Y, want)
+
+	picked := make(chan PickedColor, 1)
+	go func() { picked <- h.m.RunColorPicker(img, h.bounds, nil, 1.0) }()
+	time.Sleep(showSettle)
+	h.down(p)
+	h.up(p)
+
+	select {
+	case c := <-picked:
+		if c.Cancelled || c.Color != want || c.Point != p.Add(h.bounds.Min) {
+			t.Errorf("picked %+v, want %v at %v", c, want, p.Add(h.bounds.Min))
+		}
+	case <-time.After(resultTimeout):
+		t.Fatal("color picker did not return")
+	}
+}
+
+func TestIntegrationAdjustSelection(t *testing.T) {
+	h := newHarness(t, Options{Adjust: true})
+	ch := h.show()
+
+	h.drag(image.Pt(200, 200), image.Pt(400, 300))
+	select {
+	case res := <-ch:
+		t.Fatalf("releasing the drag returned %+v, want the selection kept for adjusting", res)
+	case <-time.After(showSettle):
+	}
+
+	h.drag(image.Pt(400, 300), image.Pt(500, 350)) // Bottom-right corner
+	h.drag(image.Pt(300, 250), image.Pt(320, 240)) // Inside
+	h.key(VK_RETURN)
+
+	res := h.result(ch)
+	want := image.Rect(220, 190, 520, 340)
+	if got := image.Rect(res.X, res.Y, res.X+res.Width, res.Y+res.Height); res.Cancelled || got != want {
+		t.Errorf("adjusted selection = %v (cancelled %v), want %v", got, res.Cancelled, want)
+	}
+}
@@ -0,0 +1,98 @@
+package overlay
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procCreatePopupMenu = user32.NewProc("CreatePopupMenu")
+	procAppendMenuW     = user32.NewProc("AppendMenuW")
+	procTrackPopupMenu  = user32.NewProc("TrackPopupMenu")
+	procDestroyMenu     = user32.NewProc("DestroyMenu")
+)
+
+// Menu constants
+const (
+	MF_STRING       = 0x00000000
+	MF_CHECKED      = 0x00000008
+	MF_SEPARATOR    = 0x00000800
+	TPM_RIGHTBUTTON = 0x0002
+	TPM_RETURNCMD   = 0x0100
+)
+
+// Context menu item IDs
+const (
+	menuRectangle = iota + 1
+	menuEllipse
+	menuFreehand
+	menuWindow
+	menuCursor
+	menuMagnifier
+	menuCancel
+)
+
+// showContextMenu shows the right-click menu at the cursor and applies the choice
+func (m *Manager) showContextMenu(hwnd uintptr) {
+	hMenu, _, _ := procCreatePopupMenu.Call()
+	if hMenu == 0 {
+		return
+	}
+	defer procDestroyMenu.Call(hMenu)
+
+	m.mu.Lock()
+	mode, includeCursor, preview := m.mode, m.includeCursor, m.preview
+	m.mu.Unlock()
+
+	appendMenu(hMenu, checkedFlag(mode == ModeRectangle), menuRectangle, "Rectangle")
+	appendMenu(hMenu, checkedFlag(mode == ModeEllipse), menuEllipse, "Ellipse")
+	appendMenu(hMenu, checkedFlag(mode == ModeFreehand), menuFreehand, "Freehand")
+	appendMenu(hMenu, checkedFlag(mode == ModeWindow), menuWindow, "Window")
+	appendMenu(hMenu, MF_SEPARATOR, 0, "")
+	appendMenu(hMenu, checkedFlag(includeCursor), menuCursor, "Capture cursor")
+	appendMenu(hMenu, checkedFlag(preview), menuMagnifier, "Magnifier")
+	appendMenu(hMenu, MF_SEPARATOR, 0, "")
+	appendMenu(hMenu, MF_STRING, menuCancel, "Cancel\tEsc")
+
+	var pt POINT
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+
+	cmd, _, _ := procTrackPopupMenu.Call(
+		hMenu,
+		TPM_RETURNCMD|TPM_RIGHTBUTTON,
+		uintptr(pt.X),
+		uintptr(pt.Y),
+		0,
+		hwnd,
+		0,
+	)
+
+	switch int(cmd) {
+	case menuRectangle, menuEllipse, menuFreehand, menuWindow:
+		m.setMode(Mode(int(cmd) - menuRectangle))
+	case menuCursor:
+		m.mu.Lock()
+		m.includeCursor = !m.includeCursor
+		m.mu.Unlock()
+	case menuMagnifier:
+		m.mu.Lock()
+		m.preview = !m.preview
+		m.mu.Unlock()
+	case menuCancel:
+		m.cancel()
+		return
+	}
+	m.redraw()
+}
+
+func checkedFlag(checked bool) int {
+	if checked {
+		return MF_STRING | MF_CHECKED
+	}
+	return MF_STRING
+}
+
+func appendMenu(hMenu uintptr, flags, id int, text string) {
+	textPtr := syscall.StringToUTF16Ptr(text)
+	procAppendMenuW.Call(hMenu, uintptr(flags), uintptr(id), uintptr(unsafe.Pointer(textPtr)))
+}
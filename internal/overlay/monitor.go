@@ -0,0 +1,90 @@
+package overlay
+
+import (
+	"image"
+	"unsafe"
+)
+
+var (
+	procMonitorFromRect      = user32.NewProc("MonitorFromRect")
+	procGetMonitorInfoW      = user32.NewProc("GetMonitorInfoW")
+	procEnumDisplaySettingsW = user32.NewProc("EnumDisplaySettingsW")
+)
+
+const (
+	monitorDefaultToNearest = 2
+	enumCurrentSettings     = 0xFFFFFFFF
+)
+
+// monitorInfoEx mirrors MONITORINFOEXW
+type monitorInfoEx struct {
+	size    uint32
+	monitor RECT
+	work    RECT
+	flags   uint32
+	device  [32]uint16
+}
+
+// devMode mirrors DEVMODEW up to the display fields this package reads
+type devMode struct {
+	deviceName    [32]uint16
+	specVersion   uint16
+	driverVersion uint16
+	size          uint16
+	driverExtra   uint16
+	fields        uint32
+	position      [16]byte // Printer or display union
+	color         int16
+	duplex        int16
+	yResolution   int16
+	ttOption      int16
+	collate       int16
+	formName      [32]uint16
+	logPixels     uint16
+	bitsPerPel    uint32
+	pelsWidth     uint32
+	pelsHeight    uint32
+	displayFlags  uint32
+	frequency     uint32
+	icmMethod     uint32
+	icmIntent     uint32
+	mediaType     uint32
+	ditherType    uint32
+	reserved1     uint32
+	reserved2     uint32
+	panningWidth  uint32
+	panningHeight uint32
+}
+
+// monitorScale returns how many physical pixels one overlay unit covers on
+// the monitor showing most of r, or fallback when Windows can't tell. It is
+// 1 in a per-monitor DPI aware process and the monitor's own scaling where
+// Windows virtualizes coordinates, so monitors with mixed DPI each get their
+// own
+func monitorScale(r image.Rectangle, fallback float64) float64 {
+	rect := RECT{Left: int32(r.Min.X), Top: int32(r.Min.Y), Right: int32(r.Max.X), Bottom: int32(r.Max.Y)}
+	monitor, _, _ := procMonitorFromRect.Call(uintptr(unsafe.Pointer(&rect)), monitorDefaultToNearest)
+	if monitor == 0 {
+		return fallback
+	}
+	info := monitorInfoEx{}
+	info.size = uint32(unsafe.Sizeof(info))
+	if ret, _, _ := procGetMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return fallback
+	}
+	mode := devMode{}
+	mode.size = uint16(unsafe.Sizeof(mode))
+	if ret, _, _ := procEnumDisplaySettingsW.Call(uintptr(unsafe.Pointer(&info.device[0])), enumCurrentSettings, uintptr(unsafe.Pointer(&mode))); ret == 0 {
+		return fallback
+	}
+	return pixelScale(int(mode.pelsWidth), int(info.monitor.Right-info.monitor.Left), fallback)
+}
+
+// pixelScale returns physical over logical, or fallback when either is
+// unknown
+func pixelScale(physical, logical int, fallback float64) float64 {
+	if physical <= 0 || logical <= 0 {
+		return fallback
+	}
+	return float64(physical) / float64(logical)
+}
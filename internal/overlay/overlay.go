@@ -35,6 +35,7 @@ var (
 	procReleaseCapture       = user32.NewProc("ReleaseCapture")
 	procSetForegroundWindow  = user32.NewProc("SetForegroundWindow")
 	procSetFocus             = user32.NewProc("SetFocus")
+	procGetCursorPos         = user32.NewProc("GetCursorPos")
 )
 
 // Command types for channel communication
@@ -50,22 +51,100 @@ type overlayCmd struct {
 	Type       cmdType
 	Screenshot *image.RGBA
 	Bounds     image.Rectangle
+	Monitors   []image.Rectangle // Display layout in screen coordinates
 	ScaleRatio float64
 	ResultCh   chan Result
+	PickWindow bool // Start in window mode without changing the remembered mode
+	PickColor  bool // Pick a pixel's color instead of a region
 }
 
+// overlayWindow is one layered window covering part of the overlay
+type overlayWindow struct {
+	hwnd    uintptr
+	drawCtx *DrawContext
+	rect    image.Rectangle // Screen coordinates
+	scale   float64         // Physical pixels per overlay unit on its monitor
+	owned   bool            // Created for this show and destroyed on hide
+}
+
+// Win32 setup calls, replaceable in tests to inject failures
+var (
+	registerClassEx = func(wc *WNDCLASSEXW) uintptr {
+		ret, _, _ := procRegisterClassExW.Call(uintptr(unsafe.Pointer(wc)))
+		return ret
+	}
+	createOverlayWindow = func(className *uint16, hInstance uintptr) uintptr {
+		hwnd, _, _ := procCreateWindowExW.Call(
+			WS_EX_LAYERED|WS_EX_TOPMOST|WS_EX_TOOLWINDOW,
+			uintptr(unsafe.Pointer(className)),
+			0, // No title
+			WS_POPUP,
+			0, 0, 1, 1, // Will be sized when shown
+			0, 0, hInstance, 0,
+		)
+		return hwnd
+	}
+	unregisterClass = func(className *uint16, hInstance uintptr) {
+		procUnregisterClassW.Call(uintptr(unsafe.Pointer(className)), hInstance)
+	}
+	newDrawContext = NewDrawContext
+)
+
+// Options configures overlay behavior, applied on the next Show
+type Options struct {
+	PerMonitor  bool  // One window per monitor instead of one spanning window
+	DimInactive bool  // Dim monitors other than the active one; click one to switch
+	InactiveDim uint8 // Overlay alpha over inactive monitors, 255 is fully black
+	Dim         uint8 // Overlay alpha over the screenshot, 0 uses defaultDim
+	Suggest     bool  // Outline detected windows and panels under the cursor for one click selection
+	ClientPos   bool  // Show the selection position relative to the client area of the window under it
+	Loupe       bool  // Magnify the pixels around the cursor and show its coordinates while dragging
+	Adjust      bool  // Keep rectangle and ellipse selections adjustable after the drag until Enter
+	Snap        bool  // Snap selections to window and UI element edges; S toggles it
+	Cursor      bool  // Start with the capture cursor menu item checked
+}
+
+// Dim layer defaults and Shift+wheel adjustment limits
+const (
+	defaultDim = 128
+	dimStep    = 16
+	maxDim     = 240 // Keep the screenshot visible underneath
+)
+
 // Manager manages the native overlay window
 type Manager struct {
 	hwnd       uintptr
 	className  *uint16
 	hInstance  uintptr
-	drawCtx    *DrawContext
+	windows    []*overlayWindow // Visible windows; windows[0] is always hwnd
 	screenshot *image.RGBA
 	scaleRatio float64
 	selection  Selection
 	bounds     image.Rectangle
+	monitors   []image.Rectangle // Overlay coordinates
+	active     int               // Index into monitors while dimming, -1 otherwise
+	zoom       int               // Magnification, 1 when not zoomed
+	zoomAnchor image.Point       // Fixed point of the magnification in overlay coordinates
+	dim        uint8             // Current dim layer alpha
+	mode          Mode              // Selection shape, kept between shows
+	includeCursor bool              // Capture cursor toggle, kept between shows
+	preview       bool              // Magnifier toggle, kept between shows
+	windowList    []Window          // Window mode candidates, topmost first
+	hover         image.Rectangle   // Window under the cursor in window mode
+	hoverWindow   uintptr           // Handle of the hovered window or control, 0 if unknown
+	history       []image.Rectangle // Recently used regions in screen coordinates, newest first, kept between shows
+	recent        int               // Index into recentRects() while cycling with Tab, -1 otherwise
+	suggestions   []image.Rectangle // Likely regions in overlay coordinates, computed on show
+	suggested     image.Rectangle   // Suggestion under the cursor, selected by a click
+	grip          grip              // Part of the adjustable selection being dragged
+	gripStart     image.Point       // Pointer where the grip was grabbed
+	gripRect      image.Rectangle   // Selection when the grip was grabbed
+	windowFrames  []image.Rectangle // Visible frames of windowList in overlay coordinates, computed on show
+	elements      []image.Rectangle // UI elements selections snap to, screen coordinates
+	opts       Options
 	resultCh   chan Result
 	cmdCh      chan overlayCmd
+	doneCh     chan struct{} // Closed when the message loop has torn down
 	running    bool
 	isShowing  bool
 	mu         sync.Mutex
@@ -78,7 +157,8 @@ var managerInstance *Manager
 // NewManager creates a new overlay manager
 func NewManager() *Manager {
 	return &Manager{
-		cmdCh: make(chan overlayCmd, 10),
+		cmdCh:   make(chan overlayCmd, 10),
+		preview: true,
 	}
 }
 
@@ -90,6 +170,7 @@ func (m *Manager) Start() error {
 		return nil
 	}
 	m.running = true
+	m.doneCh = make(chan struct{})
 	m.mu.Unlock()
 
 	managerInstance = m
@@ -97,11 +178,61 @@ func (m *Manager) Start() error {
 
 	go m.messageLoop(readyCh)
 
-	return <-readyCh
+	if err := <-readyCh; err != nil {
+		// Setup failed and was torn down, allow a later retry
+		m.mu.Lock()
+		m.running = false
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// SetOptions changes overlay behavior for subsequent Show calls
+func (m *Manager) SetOptions(opts Options) {
+	m.mu.Lock()
+	// The menu toggle is kept between shows until the setting itself changes
+	if opts.Cursor != m.opts.Cursor {
+		m.includeCursor = opts.Cursor
+	}
+	m.opts = opts
+	m.mu.Unlock()
 }
 
-// Show displays the overlay with screenshot
+// SetWindowRects sets the window bounds (screen coordinates, topmost first)
+// offered in window mode for the next Show. Prefer SetWindows, which also
+// reports the picked window
+func (m *Manager) SetWindowRects(rects []image.Rectangle) {
+	windows := make([]Window, len(rects))
+	for i, r := range rects {
+		windows[i] = Window{Bounds: r}
+	}
+	m.SetWindows(windows)
+}
+
+// Show displays the overlay with screenshot in a single window spanning bounds
 func (m *Manager) Show(screenshot *image.RGBA, bounds image.Rectangle, scaleRatio float64) <-chan Result {
+	return m.ShowOnMonitors(screenshot, bounds, nil, scaleRatio)
+}
+
+// ShowOnMonitors displays the overlay for a monitor layout (screen coordinates
+// within bounds). With Options.PerMonitor it uses one window per monitor sharing a
+// single selection, which avoids one huge DIB on wide multi-monitor setups and
+// behaves better with mixed DPI. With Options.DimInactive the monitor under the
+// cursor is active and selections stay on it. With fewer than two monitors it is
+// the same as Show.
+func (m *Manager) ShowOnMonitors(screenshot *image.RGBA, bounds image.Rectangle, monitors []image.Rectangle, scaleRatio float64) <-chan Result {
+	return m.show(overlayCmd{
+		Type:       cmdShow,
+		Screenshot: screenshot,
+		Bounds:     bounds,
+		Monitors:   monitors,
+		ScaleRatio: scaleRatio,
+	})
+}
+
+// show sends a show command and returns the channel its result arrives on
+func (m *Manager) show(cmd overlayCmd) <-chan Result {
 	m.mu.Lock()
 	if m.isShowing {
 		m.mu.Unlock()
@@ -114,13 +245,8 @@ func (m *Manager) Show(screenshot *image.RGBA, bounds image.Rectangle, scaleRati
 	m.mu.Unlock()
 
 	resultCh := make(chan Result, 1)
-	m.cmdCh <- overlayCmd{
-		Type:       cmdShow,
-		Screenshot: screenshot,
-		Bounds:     bounds,
-		ScaleRatio: scaleRatio,
-		ResultCh:   resultCh,
-	}
+	cmd.ResultCh = resultCh
+	m.cmdCh <- cmd
 	return resultCh
 }
 
@@ -129,6 +255,13 @@ func (m *Manager) Hide() {
 	m.cmdCh <- overlayCmd{Type: cmdHide}
 }
 
+// IsShowing reports whether the overlay is currently visible or about to be shown
+func (m *Manager) IsShowing() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.isShowing
+}
+
 // Stop stops the overlay manager
 func (m *Manager) Stop() {
 	m.mu.Lock()
@@ -137,14 +270,22 @@ func (m *Manager) Stop() {
 		return
 	}
 	m.running = false
+	doneCh := m.doneCh
 	m.mu.Unlock()
 	m.cmdCh <- overlayCmd{Type: cmdStop}
+
+	// Wait for teardown so a following Start can re-register the window class
+	select {
+	case <-doneCh:
+	case <-time.After(2 * time.Second):
+	}
 }
 
 func (m *Manager) messageLoop(readyCh chan<- error) {
 	// CRITICAL: Lock this goroutine to the current OS thread
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
+	defer close(m.doneCh)
 
 	// Get module handle
 	m.hInstance, _, _ = procGetModuleHandleW.Call(0)
@@ -162,23 +303,17 @@ func (m *Manager) messageLoop(readyCh chan<- error) {
 		LpszClassName: className,
 	}
 
-	ret, _, _ := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
-	if ret == 0 {
+	if registerClassEx(&wc) == 0 {
+		m.className = nil // Not registered, nothing to unregister
+		m.cleanup()
 		readyCh <- errors.New("failed to register window class")
 		return
 	}
 
 	// Create the overlay window (hidden initially)
-	hwnd, _, _ := procCreateWindowExW.Call(
-		WS_EX_LAYERED|WS_EX_TOPMOST|WS_EX_TOOLWINDOW,
-		uintptr(unsafe.Pointer(className)),
-		0, // No title
-		WS_POPUP,
-		0, 0, 1, 1, // Will be sized when shown
-		0, 0, m.hInstance, 0,
-	)
-
+	hwnd := createOverlayWindow(className, m.hInstance)
 	if hwnd == 0 {
+		m.cleanup()
 		readyCh <- errors.New("failed to create overlay window")
 		return
 	}
@@ -216,12 +351,28 @@ func (m *Manager) messageLoop(readyCh chan<- error) {
 }
 
 func (m *Manager) handleShow(cmd overlayCmd) {
-	// Hide window first to avoid flash of old content
-	procShowWindow.Call(m.hwnd, SW_HIDE)
+	// Hide and release any previous windows first to avoid flash of old content
+	m.releaseWindows()
 
 	// Reset selection state
 	m.mu.Lock()
-	m.selection = Selection{}
+	m.selection = Selection{Mode: m.mode, Snap: m.opts.Snap}
+	if cmd.PickWindow {
+		m.selection.Mode = ModeWindow
+	}
+	if cmd.PickColor {
+		m.selection.Mode = ModeColor
+	}
+	m.hover, m.hoverWindow = image.Rectangle{}, 0
+	m.recent = -1
+	m.suggested = image.Rectangle{}
+	m.grip = gripNone
+	m.zoom = 1
+	m.dim = m.opts.Dim
+	if m.dim == 0 {
+		m.dim = defaultDim
+	}
+	opts := m.opts
 	m.mu.Unlock()
 
 	m.screenshot = cmd.Screenshot
@@ -229,106 +380,310 @@ func (m *Manager) handleShow(cmd overlayCmd) {
 	m.scaleRatio = cmd.ScaleRatio
 	m.resultCh = cmd.ResultCh
 
-	// Get screen DC for creating compatible DC
-	hScreenDC, _, _ := procGetDC.Call(0)
-	defer procReleaseDC.Call(0, hScreenDC)
+	m.monitors = nil
+	for _, mon := range windowRects(cmd.Bounds, cmd.Monitors) {
+		m.monitors = append(m.monitors, mon.Sub(cmd.Bounds.Min))
+	}
+	m.mu.Lock()
+	windows := m.windowList
+	m.mu.Unlock()
+	frames := m.visibleFrames(windows)
+	m.mu.Lock()
+	m.windowFrames = frames
+	m.mu.Unlock()
 
-	// Create draw context
-	if m.drawCtx != nil {
-		m.drawCtx.Cleanup()
+	m.suggestions = nil
+	if opts.Suggest && !cmd.PickColor {
+		m.suggestions = m.detectSuggestions()
 	}
-	var err error
-	m.drawCtx, err = NewDrawContext(hScreenDC, m.bounds.Dx(), m.bounds.Dy())
-	if err != nil {
-		m.resultCh <- Result{Cancelled: true}
+
+	// The loupe starts under the cursor rather than waiting for it to move
+	if cmd.PickColor {
+		var pt POINT
+		procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
 		m.mu.Lock()
-		m.isShowing = false
+		m.selection.CursorX, m.selection.CursorY = int(pt.X)-cmd.Bounds.Min.X, int(pt.Y)-cmd.Bounds.Min.Y
 		m.mu.Unlock()
-		return
 	}
 
-	// Position and size window (without showing yet)
-	procSetWindowPos.Call(
-		m.hwnd,
-		HWND_TOPMOST,
-		uintptr(m.bounds.Min.X),
-		uintptr(m.bounds.Min.Y),
-		uintptr(m.bounds.Dx()),
-		uintptr(m.bounds.Dy()),
-		SWP_NOSIZE|SWP_NOMOVE, // Just set topmost, will reposition below
-	)
-	procSetWindowPos.Call(
-		m.hwnd,
-		0,
-		uintptr(m.bounds.Min.X),
-		uintptr(m.bounds.Min.Y),
-		uintptr(m.bounds.Dx()),
-		uintptr(m.bounds.Dy()),
-		0, // No flags - just position and size
-	)
-
-	// Draw fresh content BEFORE showing window
+	m.active = -1
+	if opts.DimInactive && len(m.monitors) > 1 {
+		var pt POINT
+		procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+		cursor := image.Pt(int(pt.X), int(pt.Y)).Sub(cmd.Bounds.Min)
+		m.active = maxInt(monitorAt(m.monitors, cursor), 0)
+	}
+
+	var layout []image.Rectangle
+	if opts.PerMonitor {
+		layout = cmd.Monitors
+	}
+
+	// Get screen DC for creating compatible DC
+	hScreenDC, _, _ := procGetDC.Call(0)
+	defer procReleaseDC.Call(0, hScreenDC)
+
+	for i, rect := range windowRects(cmd.Bounds, layout) {
+		win := &overlayWindow{hwnd: m.hwnd, rect: rect, scale: m.scaleRatio}
+		if len(layout) > 0 {
+			win.scale = monitorScale(rect, m.scaleRatio)
+		}
+		if i > 0 {
+			win.hwnd = createOverlayWindow(m.className, m.hInstance)
+			win.owned = true
+			if win.hwnd == 0 {
+				m.handleHide()
+				return
+			}
+		}
+		m.windows = append(m.windows, win)
+
+		var err error
+		win.drawCtx, err = newDrawContext(hScreenDC, rect.Dx(), rect.Dy())
+		if err != nil {
+			m.handleHide()
+			return
+		}
+		win.drawCtx.SetOrigin(rect.Min.Sub(cmd.Bounds.Min))
+
+		// Position and size window (without showing yet)
+		procSetWindowPos.Call(
+			win.hwnd,
+			HWND_TOPMOST,
+			uintptr(rect.Min.X),
+			uintptr(rect.Min.Y),
+			uintptr(rect.Dx()),
+			uintptr(rect.Dy()),
+			SWP_NOSIZE|SWP_NOMOVE, // Just set topmost, will reposition below
+		)
+		procSetWindowPos.Call(
+			win.hwnd,
+			0,
+			uintptr(rect.Min.X),
+			uintptr(rect.Min.Y),
+			uintptr(rect.Dx()),
+			uintptr(rect.Dy()),
+			0, // No flags - just position and size
+		)
+	}
+
+	// Draw fresh content BEFORE showing windows
 	m.redraw()
 
-	// NOW show window with fresh content
-	procShowWindow.Call(m.hwnd, SW_SHOW)
+	// NOW show windows with fresh content
+	for _, win := range m.windows {
+		procShowWindow.Call(win.hwnd, SW_SHOW)
+	}
 
 	// Set focus to receive keyboard input (Esc key)
 	procSetForegroundWindow.Call(m.hwnd)
 	procSetFocus.Call(m.hwnd)
 }
 
+// windowRects returns the screen rectangles to cover with overlay windows:
+// bounds itself, or each monitor clipped to bounds in per-monitor mode
+func windowRects(bounds image.Rectangle, monitors []image.Rectangle) []image.Rectangle {
+	if len(monitors) < 2 {
+		return []image.Rectangle{bounds}
+	}
+	var rects []image.Rectangle
+	for _, mon := range monitors {
+		if r := mon.Intersect(bounds); !r.Empty() {
+			rects = append(rects, r)
+		}
+	}
+	if len(rects) == 0 {
+		return []image.Rectangle{bounds}
+	}
+	return rects
+}
+
+// monitorAt returns the index of the monitor containing p, or -1
+func monitorAt(monitors []image.Rectangle, p image.Point) int {
+	for i, mon := range monitors {
+		if p.In(mon) {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectableRect returns the area selections are confined to in overlay
+// coordinates: the active monitor while dimming, otherwise the whole overlay
+func (m *Manager) selectableRect() image.Rectangle {
+	if m.active >= 0 && m.active < len(m.monitors) {
+		return m.monitors[m.active]
+	}
+	return image.Rect(0, 0, m.bounds.Dx(), m.bounds.Dy())
+}
+
+// inactiveRects returns the monitors to dim in overlay coordinates
+func (m *Manager) inactiveRects() []image.Rectangle {
+	if m.active < 0 {
+		return nil
+	}
+	var rects []image.Rectangle
+	for i, mon := range m.monitors {
+		if i != m.active {
+			rects = append(rects, mon)
+		}
+	}
+	return rects
+}
+
+// releaseWindows hides all overlay windows, frees their draw contexts and
+// destroys windows created for per-monitor mode
+func (m *Manager) releaseWindows() {
+	for _, win := range m.windows {
+		if win.hwnd != 0 {
+			procShowWindow.Call(win.hwnd, SW_HIDE)
+		}
+		if win.drawCtx != nil {
+			win.drawCtx.Cleanup()
+		}
+		if win.owned && win.hwnd != 0 {
+			procDestroyWindow.Call(win.hwnd)
+		}
+	}
+	m.windows = nil
+}
+
+// windowOrigin returns the offset of a window's client area in overlay coordinates
+func (m *Manager) windowOrigin(hwnd uintptr) image.Point {
+	for _, win := range m.windows {
+		if win.hwnd == hwnd {
+			return win.rect.Min.Sub(m.bounds.Min)
+		}
+	}
+	return image.Point{}
+}
+
 func (m *Manager) handleHide() {
-	procShowWindow.Call(m.hwnd, SW_HIDE)
-	if m.drawCtx != nil {
-		m.drawCtx.Cleanup()
-		m.drawCtx = nil
+	if m.hwnd != 0 {
+		procShowWindow.Call(m.hwnd, SW_HIDE)
 	}
+	m.releaseWindows()
+	m.screenshot = nil
+
 	m.mu.Lock()
+	resultCh := m.resultCh
+	m.resultCh = nil
 	m.isShowing = false
 	m.mu.Unlock()
+
+	// Resolve a pending Show so callers never wait forever. Selection and Esc
+	// have already filled the buffered channel, so this send is dropped for them.
+	if resultCh != nil {
+		select {
+		case resultCh <- Result{Cancelled: true}:
+		default:
+		}
+	}
 }
 
 func (m *Manager) redraw() {
-	if m.drawCtx == nil || m.screenshot == nil {
+	if len(m.windows) == 0 || m.screenshot == nil {
 		return
 	}
 
 	m.mu.Lock()
 	sel := m.selection
-	scaleRatio := m.scaleRatio
+	inactiveDim := m.opts.InactiveDim
+	zoom, zoomAnchor := m.zoom, m.zoomAnchor
+	dim := m.dim
+	preview := m.preview
+	recent := m.recent
+	suggested := m.suggested
+	clientPos := m.opts.ClientPos
+	loupe := m.opts.Loupe
 	m.mu.Unlock()
 
-	m.drawCtx.DrawOverlay(m.screenshot, &sel, scaleRatio)
-
-	// Update layered window
-	ptSrc := POINT{0, 0}
-	ptDst := POINT{int32(m.bounds.Min.X), int32(m.bounds.Min.Y)}
-	size := SIZE{int32(m.bounds.Dx()), int32(m.bounds.Dy())}
-	blend := BLENDFUNCTION{AC_SRC_OVER, 0, 255, AC_SRC_ALPHA}
+	// While cycling with Tab the highlighted region is the selection and the
+	// other remembered regions are drawn as ghost outlines
+	var ghosts []image.Rectangle
+	rects := m.recentRects()
+	sel.RecentCount = len(rects)
+	if recent >= 0 && recent < len(rects) {
+		r := rects[recent]
+		sel.StartX, sel.StartY = r.Min.X, r.Min.Y
+		sel.EndX, sel.EndY = r.Max.X, r.Max.Y
+		sel.Inflate = 0
+		sel.IsDragging = true
+		if sel.Mode == ModeFreehand {
+			sel.Mode = ModeRectangle
+		}
+		ghosts = append(append(ghosts, rects[:recent]...), rects[recent+1:]...)
+		sel.Recent = recent + 1
+	} else if sel.Mode == ModeWindow && !m.hover.Empty() {
+		// Show the highlighted window as the selection
+		sel.StartX, sel.StartY = m.hover.Min.X, m.hover.Min.Y
+		sel.EndX, sel.EndY = m.hover.Max.X, m.hover.Max.Y
+		sel.IsDragging = true
+	} else if !sel.IsDragging && !suggested.Empty() {
+		ghosts = append(ghosts, suggested)
+		sel.Suggested = true
+	}
+	if clientPos && (sel.IsDragging || sel.Adjusting) {
+		// Measure from the window the drag started on, or the one under the
+		// middle of a region picked without dragging
+		anchor := image.Pt(sel.AnchorX, sel.AnchorY)
+		if recent >= 0 || sel.Mode == ModeWindow {
+			r := sel.Rect()
+			anchor = r.Min.Add(r.Max).Div(2)
+		}
+		sel.ClientPos, sel.HasClientPos = m.clientPosition(sel.Rect().Min, anchor)
+	}
+	sel.ScreenCursor = m.screenPoint(image.Pt(sel.CursorX, sel.CursorY))
+	inactive := m.inactiveRects()
 
-	procUpdateLayeredWindow.Call(
-		m.hwnd,
-		0,
-		uintptr(unsafe.Pointer(&ptDst)),
-		uintptr(unsafe.Pointer(&size)),
-		m.drawCtx.HMemDC,
-		uintptr(unsafe.Pointer(&ptSrc)),
-		0,
-		uintptr(unsafe.Pointer(&blend)),
-		ULW_ALPHA,
-	)
+	for _, win := range m.windows {
+		if win.drawCtx == nil {
+			continue
+		}
+		win.drawCtx.SetInactive(inactive, inactiveDim)
+		win.drawCtx.SetZoom(zoom, zoomAnchor)
+		win.drawCtx.SetDim(dim)
+		win.drawCtx.SetPreview(preview)
+		win.drawCtx.SetLoupe(loupe)
+		win.drawCtx.SetGhosts(ghosts)
+		win.drawCtx.DrawOverlay(m.screenshot, &sel, win.scale)
+
+		// Update layered window
+		ptSrc := POINT{0, 0}
+		ptDst := POINT{int32(win.rect.Min.X), int32(win.rect.Min.Y)}
+		size := SIZE{int32(win.rect.Dx()), int32(win.rect.Dy())}
+		blend := BLENDFUNCTION{AC_SRC_OVER, 0, 255, AC_SRC_ALPHA}
+
+		procUpdateLayeredWindow.Call(
+			win.hwnd,
+			0,
+			uintptr(unsafe.Pointer(&ptDst)),
+			uintptr(unsafe.Pointer(&size)),
+			win.drawCtx.HMemDC,
+			uintptr(unsafe.Pointer(&ptSrc)),
+			0,
+			uintptr(unsafe.Pointer(&blend)),
+			ULW_ALPHA,
+		)
+	}
 }
 
+// cleanup tears down everything messageLoop created. Safe to call after a
+// partial setup failure and more than once.
 func (m *Manager) cleanup() {
+	// Releases the draw context and resolves any pending Show
+	m.handleHide()
+
 	if m.hwnd != 0 {
 		procDestroyWindow.Call(m.hwnd)
+		m.hwnd = 0
 	}
 	if m.className != nil {
-		procUnregisterClassW.Call(uintptr(unsafe.Pointer(m.className)), m.hInstance)
+		unregisterClass(m.className, m.hInstance)
+		m.className = nil
 	}
-	if m.drawCtx != nil {
-		m.drawCtx.Cleanup()
+	if managerInstance == m {
+		managerInstance = nil
 	}
 }
 
@@ -351,113 +706,257 @@ func wndProc(hwnd, msg, wParam, lParam uintptr) uintptr {
 
 		if spaceHeld {
 			procSetCursor.Call(loadCursor(IDC_SIZEALL))
+		} else if g := m.hoverGrip(); g != gripNone {
+			procSetCursor.Call(loadCursor(g.cursor()))
 		} else {
 			procSetCursor.Call(loadCursor(IDC_CROSS))
 		}
 		return 1
 
+	case WM_RBUTTONUP:
+		if m.colorMode() {
+			m.cancel()
+			return 0
+		}
+		m.mu.Lock()
+		isDragging := m.selection.IsDragging
+		m.mu.Unlock()
+		if !isDragging {
+			m.showContextMenu(hwnd)
+		}
+		return 0
+
 	case WM_LBUTTONDOWN:
-		x := int(int16(lParam & 0xFFFF))
-		y := int(int16((lParam >> 16) & 0xFFFF))
+		x, y := m.overlayPoint(hwnd, lParam)
+		m.mu.Lock()
+		m.recent = -1
+		m.mu.Unlock()
+
+		// Clicking a dimmed monitor makes it active instead of starting a drag
+		area := m.selectableRect()
+		if m.active >= 0 && !image.Pt(x, y).In(area) {
+			if idx := monitorAt(m.monitors, image.Pt(x, y)); idx >= 0 {
+				m.active = idx
+				m.mu.Lock()
+				m.selection.Adjusting = false
+				m.mu.Unlock()
+				m.redraw()
+			}
+			return 0
+		}
+
+		if m.selection.Mode == ModeColor {
+			m.pickColor(image.Pt(x, y))
+			return 0
+		}
+
+		// Window mode selects the highlighted window with a single click
+		if m.selection.Mode == ModeWindow {
+			if !m.hover.Empty() {
+				m.finish(m.hover, nil)
+			}
+			return 0
+		}
 
-		// Clamp to bounds (use Dx() not Dx()-1 to allow edge pixels)
-		x = clampInt(x, 0, m.bounds.Dx())
-		y = clampInt(y, 0, m.bounds.Dy())
+		// Grabbing an adjustable selection reshapes it instead of starting over
+		if m.grabSelection(image.Pt(x, y)) {
+			procSetCapture.Call(hwnd)
+			m.redraw()
+			return 0
+		}
 
+		// Snap to a nearby edge, then clamp to bounds (use Max not Max-1 to allow edge pixels)
 		m.mu.Lock()
+		start := m.snap(image.Pt(x, y))
+		x = clampInt(start.X, area.Min.X, area.Max.X)
+		y = clampInt(start.Y, area.Min.Y, area.Max.Y)
+
+		m.selection.Path = []image.Point{{X: x, Y: y}}
 		m.selection.StartX = x
 		m.selection.StartY = y
 		m.selection.EndX = x
 		m.selection.EndY = y
+		m.selection.AnchorX = x
+		m.selection.AnchorY = y
+		m.selection.CursorX = x
+		m.selection.CursorY = y
+		m.selection.Inflate = 0
 		m.selection.IsDragging = true
 		m.mu.Unlock()
 
-		// Capture mouse
-		procSetCapture.Call(m.hwnd)
+		// Capture mouse so drags continue across monitor windows
+		procSetCapture.Call(hwnd)
 
 		m.redraw()
 
 	case WM_MOUSEMOVE:
+		if m.dragGrip(m.overlayPoint(hwnd, lParam)) {
+			break
+		}
+
 		m.mu.Lock()
 		isDragging := m.selection.IsDragging
+		mode := m.selection.Mode
 		m.mu.Unlock()
 
+		if !isDragging && mode == ModeWindow {
+			x, y := m.overlayPoint(hwnd, lParam)
+			m.mu.Lock()
+			m.selection.CursorX, m.selection.CursorY = x, y
+			m.mu.Unlock()
+			m.hover, m.hoverWindow = m.windowAt(image.Pt(x, y))
+			m.redraw()
+		} else if mode == ModeColor {
+			x, y := m.overlayPoint(hwnd, lParam)
+			m.mu.Lock()
+			m.selection.CursorX, m.selection.CursorY = x, y
+			m.mu.Unlock()
+			m.redraw()
+		} else if !isDragging && len(m.suggestions) > 0 {
+			x, y := m.overlayPoint(hwnd, lParam)
+			if m.updateSuggested(image.Pt(x, y)) {
+				m.redraw()
+			}
+		}
+
 		if isDragging {
-			x := int(int16(lParam & 0xFFFF))
-			y := int(int16((lParam >> 16) & 0xFFFF))
+			x, y := m.overlayPoint(hwnd, lParam)
 
-			// Clamp to bounds (use Dx() not Dx()-1 to allow edge pixels)
-			x = clampInt(x, 0, m.bounds.Dx())
-			y = clampInt(y, 0, m.bounds.Dy())
+			// Clamp to bounds (use Max not Max-1 to allow edge pixels)
+			area := m.selectableRect()
+			x = clampInt(x, area.Min.X, area.Max.X)
+			y = clampInt(y, area.Min.Y, area.Max.Y)
 
-			// Check if Space is held for repositioning
-			spaceState, _, _ := procGetAsyncKeyState.Call(VK_SPACE)
-			spaceHeld := spaceState&0x8000 != 0
+			// Check if Space is held for repositioning, Alt for center-out and Shift for square
+			spaceHeld := isKeyDown(VK_SPACE)
+			fromCenter := isKeyDown(VK_MENU)
+			square := isKeyDown(VK_SHIFT)
 
 			m.mu.Lock()
-			if spaceHeld {
-				if !m.selection.SpaceHeld {
-					m.selection.SpaceHeld = true
+			sel := &m.selection
+			if sel.Mode == ModeFreehand {
+				if last := sel.Path[len(sel.Path)-1]; last != image.Pt(x, y) {
+					sel.Path = append(sel.Path, image.Pt(x, y))
 				}
-				dx := x - m.selection.EndX
-				dy := y - m.selection.EndY
-				m.selection.StartX += dx
-				m.selection.StartY += dy
-			} else if m.selection.SpaceHeld {
-				m.selection.SpaceHeld = false
+			} else if spaceHeld {
+				if !sel.SpaceHeld {
+					sel.SpaceHeld = true
+				}
+				dx := x - sel.CursorX
+				dy := y - sel.CursorY
+				sel.StartX += dx
+				sel.StartY += dy
+				sel.EndX += dx
+				sel.EndY += dy
+				sel.AnchorX += dx
+				sel.AnchorY += dy
+			} else {
+				sel.SpaceHeld = false
+				cursor := image.Pt(x, y)
+				if !fromCenter && !square {
+					cursor = m.snap(cursor)
+				}
+				start, end := shapeSelection(image.Pt(sel.AnchorX, sel.AnchorY), cursor, fromCenter, square)
+				sel.StartX = clampInt(start.X, area.Min.X, area.Max.X)
+				sel.StartY = clampInt(start.Y, area.Min.Y, area.Max.Y)
+				sel.EndX = clampInt(end.X, area.Min.X, area.Max.X)
+				sel.EndY = clampInt(end.Y, area.Min.Y, area.Max.Y)
 			}
-
-			m.selection.EndX = x
-			m.selection.EndY = y
+			sel.CursorX = x
+			sel.CursorY = y
 			m.mu.Unlock()
 			m.redraw()
 		}
 
+	case WM_MOUSEWHEEL:
+		// Ctrl+wheel zooms, Shift+wheel changes the dim layer and the plain
+		// wheel grows or shrinks the selection by 1px on every side
+		delta := int(int16((wParam >> 16) & 0xFFFF))
+		notches := delta / WHEEL_DELTA
+		if notches == 0 {
+			notches = signInt(delta)
+		}
+
+		m.mu.Lock()
+		switch {
+		case wParam&MK_CONTROL != 0:
+			// Wheel coordinates are in screen space, not client space
+			raw := image.Pt(int(int16(lParam&0xFFFF)), int(int16((lParam>>16)&0xFFFF))).Sub(m.bounds.Min)
+			m.zoom, m.zoomAnchor = zoomStep(m.zoom, m.zoomAnchor, raw, delta)
+		case wParam&MK_SHIFT != 0:
+			m.dim = uint8(clampInt(int(m.dim)+notches*dimStep, 0, maxDim))
+		case (m.selection.IsDragging || m.selection.Adjusting) && m.selection.Mode != ModeFreehand:
+			m.selection.Inflate = inflateStep(m.selection, notches)
+		}
+		m.mu.Unlock()
+		m.redraw()
+		return 0
+
 	case WM_LBUTTONUP:
 		// Release mouse capture
 		procReleaseCapture.Call()
+		if m.releaseGrip() {
+			break
+		}
 
 		m.mu.Lock()
 		wasDragging := m.selection.IsDragging
 		if wasDragging {
 			m.selection.IsDragging = false
 			m.selection.SpaceHeld = false
+			m.zoom = 1 // Back to 1:1 to confirm the final selection
 		}
-		startX, startY := m.selection.StartX, m.selection.StartY
-		endX, endY := m.selection.EndX, m.selection.EndY
-		resultCh := m.resultCh
+		rect := m.selection.Rect().Intersect(m.selectableRect())
+		path := m.selection.Path
+		freehand := m.selection.Mode == ModeFreehand
+		anchor := image.Pt(m.selection.AnchorX, m.selection.AnchorY)
+		adjust := m.opts.Adjust && !freehand
 		m.mu.Unlock()
 
 		if wasDragging {
 			// Calculate final selection
-			x1 := minInt(startX, endX)
-			y1 := minInt(startY, endY)
-			x2 := maxInt(startX, endX)
-			y2 := maxInt(startY, endY)
-
-			w, h := x2-x1, y2-y1
-			if w > 10 && h > 10 && resultCh != nil {
-				// Non-blocking send to avoid UI freeze
-				select {
-				case resultCh <- Result{X: x1, Y: y1, Width: w, Height: h}:
-				default:
+			if rect.Dx() > 10 && rect.Dy() > 10 && (!freehand || len(path) >= 3) {
+				if !freehand {
+					path = nil
 				}
-				m.handleHide()
+				if adjust {
+					m.beginAdjust(rect)
+				} else {
+					m.finish(rect, path)
+				}
+			} else {
+				// A click without a drag takes the suggestion under it
+				m.useSuggestion(anchor)
 			}
 		}
 
 	case WM_KEYDOWN:
 		if wParam == VK_ESCAPE {
-			m.mu.Lock()
-			resultCh := m.resultCh
-			m.mu.Unlock()
-			if resultCh != nil {
-				select {
-				case resultCh <- Result{Cancelled: true}:
-				default:
-				}
+			m.cancel()
+		} else if m.colorMode() {
+			// Enter picks the pixel under the cursor; region keys don't apply
+			if wParam == VK_RETURN {
+				m.mu.Lock()
+				cursor := image.Pt(m.selection.CursorX, m.selection.CursorY)
+				m.mu.Unlock()
+				m.pickColor(cursor)
 			}
-			m.handleHide()
+		} else if wParam == VK_TAB {
+			if isKeyDown(VK_SHIFT) {
+				m.cycleRecent(-1)
+			} else {
+				m.cycleRecent(1)
+			}
+		} else if wParam == VK_RETURN {
+			if !m.confirmAdjust() {
+				m.useRecent()
+			}
+		} else if wParam == VK_CONTROL {
+			m.refreshHover()
+		} else if wParam == VK_C {
+			m.copyGeometry(isKeyDown(VK_SHIFT))
+		} else if wParam == VK_S {
+			m.toggleSnap()
 		} else if wParam == VK_SPACE {
 			m.mu.Lock()
 			m.selection.SpaceHeld = true
@@ -466,20 +965,203 @@ func wndProc(hwnd, msg, wParam, lParam uintptr) uintptr {
 		}
 
 	case WM_KEYUP:
-		if wParam == VK_SPACE {
+		if wParam == VK_CONTROL {
+			m.refreshHover()
+		} else if wParam == VK_SPACE {
 			m.mu.Lock()
 			m.selection.SpaceHeld = false
 			m.mu.Unlock()
 			procSetCursor.Call(loadCursor(IDC_CROSS))
 		}
 
+	case WM_SYSKEYDOWN, WM_SYSKEYUP:
+		// Swallow Alt so it modifies drags instead of entering menu mode
+		if wParam == VK_MENU {
+			return 0
+		}
+
 	case WM_DESTROY:
-		procPostQuitMessage.Call(0)
+		// Per-monitor windows are destroyed on every hide; only the primary ends the loop
+		if hwnd == m.hwnd {
+			procPostQuitMessage.Call(0)
+		}
 	}
 
 	return defWindowProc(hwnd, msg, wParam, lParam)
 }
 
+// overlayPoint converts mouse lParam client coordinates of hwnd to overlay
+// coordinates, undoing any zoom so selections land on exact screenshot pixels
+func (m *Manager) overlayPoint(hwnd, lParam uintptr) (int, int) {
+	origin := m.windowOrigin(hwnd)
+	x := int(int16(lParam&0xFFFF)) + origin.X
+	y := int(int16((lParam>>16)&0xFFFF)) + origin.Y
+
+	m.mu.Lock()
+	zoom, anchor := m.zoom, m.zoomAnchor
+	m.mu.Unlock()
+	p := unzoomPoint(image.Pt(x, y), zoom, anchor)
+	return p.X, p.Y
+}
+
+// finish resolves the pending Show with rect (overlay coordinates) and hides.
+// path is the freehand outline in overlay coordinates, if any.
+func (m *Manager) finish(rect image.Rectangle, path []image.Point) {
+	m.mu.Lock()
+	res := Result{
+		X:             rect.Min.X,
+		Y:             rect.Min.Y,
+		Width:         rect.Dx(),
+		Height:        rect.Dy(),
+		Mode:          m.selection.Mode,
+		IncludeCursor: m.includeCursor,
+	}
+	if res.Mode == ModeWindow {
+		res.Window = m.hoverWindow
+	}
+	for _, p := range path {
+		res.Path = append(res.Path, p.Sub(rect.Min))
+	}
+	m.history = pushHistory(m.history, rect.Add(m.bounds.Min))
+	resultCh := m.resultCh
+	m.mu.Unlock()
+
+	if resultCh != nil {
+		// Non-blocking send to avoid UI freeze
+		select {
+		case resultCh <- res:
+		default:
+		}
+	}
+	m.handleHide()
+}
+
+// cancel resolves the pending Show as cancelled and hides
+func (m *Manager) cancel() {
+	m.mu.Lock()
+	resultCh := m.resultCh
+	m.mu.Unlock()
+	if resultCh != nil {
+		select {
+		case resultCh <- Result{Cancelled: true}:
+		default:
+		}
+	}
+	m.handleHide()
+}
+
+// setMode switches the selection shape, discarding any selection in progress
+func (m *Manager) setMode(mode Mode) {
+	m.mu.Lock()
+	m.mode = mode
+	m.selection = Selection{Mode: mode, Snap: m.selection.Snap}
+	m.suggested = image.Rectangle{}
+	m.mu.Unlock()
+	m.hover, m.hoverWindow = image.Rectangle{}, 0
+}
+
+// refreshHover picks the hovered window again after Ctrl switches between
+// windows and controls
+func (m *Manager) refreshHover() {
+	m.mu.Lock()
+	sel := m.selection
+	m.mu.Unlock()
+	if sel.Mode != ModeWindow || sel.IsDragging {
+		return
+	}
+	m.hover, m.hoverWindow = m.windowAt(image.Pt(sel.CursorX, sel.CursorY))
+	m.redraw()
+}
+
+// isKeyDown reports whether a virtual key is currently held
+func isKeyDown(vk uintptr) bool {
+	state, _, _ := procGetAsyncKeyState.Call(vk)
+	return state&0x8000 != 0
+}
+
+// shapeSelection returns the selection corners for a drag from anchor to cursor.
+// fromCenter grows the selection symmetrically around anchor and square makes
+// both sides as long as the larger one.
+func shapeSelection(anchor, cursor image.Point, fromCenter, square bool) (image.Point, image.Point) {
+	d := cursor.Sub(anchor)
+	if square {
+		side := maxInt(absInt(d.X), absInt(d.Y))
+		d = image.Pt(signInt(d.X)*side, signInt(d.Y)*side)
+	}
+	if fromCenter {
+		return anchor.Sub(d), anchor.Add(d)
+	}
+	return anchor, anchor.Add(d)
+}
+
+// inflateStep returns the selection inflation after wheel notches, never
+// shrinking the selection below a single pixel
+func inflateStep(sel Selection, notches int) int {
+	inflate := sel.Inflate + notches
+	base := image.Rect(sel.StartX, sel.StartY, sel.EndX, sel.EndY)
+	if minSide := minInt(base.Dx(), base.Dy()); inflate < -(minSide-1)/2 {
+		inflate = -(minSide - 1) / 2
+	}
+	return inflate
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// signInt returns -1 for negative values and 1 otherwise
+func signInt(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// Zoom limits for Ctrl+wheel magnification
+const maxZoom = 16
+
+// zoomStep doubles or halves the zoom for a wheel delta, keeping the screenshot
+// pixel under the cursor (raw view coordinates) fixed
+func zoomStep(zoom int, anchor, raw image.Point, delta int) (int, image.Point) {
+	if zoom < 1 {
+		zoom = 1
+	}
+	p := unzoomPoint(raw, zoom, anchor)
+
+	next := zoom
+	if delta > 0 && zoom < maxZoom {
+		next = zoom * 2
+	} else if delta < 0 && zoom > 1 {
+		next = zoom / 2
+	}
+	if next == 1 {
+		return 1, image.Point{}
+	}
+
+	// Solve p = anchor + (raw-anchor)/next for the new anchor
+	return next, image.Pt((p.X*next-raw.X)/(next-1), (p.Y*next-raw.Y)/(next-1))
+}
+
+// unzoomPoint maps a view point to overlay coordinates under magnification
+func unzoomPoint(p image.Point, zoom int, anchor image.Point) image.Point {
+	if zoom <= 1 {
+		return p
+	}
+	return image.Pt(anchor.X+floorDiv(p.X-anchor.X, zoom), anchor.Y+floorDiv(p.Y-anchor.Y, zoom))
+}
+
+// floorDiv divides rounding toward negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
 func defWindowProc(hwnd, msg, wParam, lParam uintptr) uintptr {
 	ret, _, _ := procDefWindowProcW.Call(hwnd, msg, wParam, lParam)
 	return ret
@@ -0,0 +1,925 @@
+package overlay
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// stubSetup replaces Win32 setup calls for the duration of a test
+func stubSetup(t *testing.T, register func(*WNDCLASSEXW) uintptr, create func(*uint16, uintptr) uintptr) *int {
+	t.Helper()
+	origRegister, origCreate, origUnregister := registerClassEx, createOverlayWindow, unregisterClass
+	t.Cleanup(func() {
+		registerClassEx, createOverlayWindow, unregisterClass = origRegister, origCreate, origUnregister
+	})
+
+	unregistered := 0
+	registerClassEx = register
+	createOverlayWindow = create
+	unregisterClass = func(*uint16, uintptr) { unregistered++ }
+	return &unregistered
+}
+
+func TestStart_CreateWindowFailureUnregistersClass(t *testing.T) {
+	unregistered := stubSetup(t,
+		func(*WNDCLASSEXW) uintptr { return 1 },
+		func(*uint16, uintptr) uintptr { return 0 },
+	)
+
+	m := NewManager()
+	if err := m.Start(); err == nil {
+		t.Fatal("Start() should fail when window creation fails")
+	}
+	if *unregistered != 1 {
+		t.Errorf("window class unregistered %d times, want 1", *unregistered)
+	}
+	if m.running {
+		t.Error("manager should not be running after failed Start")
+	}
+	if managerInstance == m {
+		t.Error("failed manager should not stay registered as the window proc target")
+	}
+}
+
+func TestStart_RegisterFailureSkipsUnregister(t *testing.T) {
+	unregistered := stubSetup(t,
+		func(*WNDCLASSEXW) uintptr { return 0 },
+		func(*uint16, uintptr) uintptr {
+			t.Error("window must not be created when class registration fails")
+			return 0
+		},
+	)
+
+	m := NewManager()
+	if err := m.Start(); err == nil {
+		t.Fatal("Start() should fail when class registration fails")
+	}
+	if *unregistered != 0 {
+		t.Errorf("unregistered a class that was never registered (%d calls)", *unregistered)
+	}
+}
+
+func TestStart_RetryAfterFailure(t *testing.T) {
+	attempts := 0
+	stubSetup(t,
+		func(*WNDCLASSEXW) uintptr {
+			attempts++
+			if attempts == 1 {
+				return 0
+			}
+			return 1
+		},
+		func(*uint16, uintptr) uintptr { return 0 },
+	)
+
+	m := NewManager()
+	m.Start()
+	m.Start()
+	if attempts != 2 {
+		t.Errorf("Start() after a failure should retry setup, got %d attempts", attempts)
+	}
+}
+
+func TestHandleShow_DrawContextFailureCancels(t *testing.T) {
+	orig := newDrawContext
+	t.Cleanup(func() { newDrawContext = orig })
+	newDrawContext = func(uintptr, int, int) (*DrawContext, error) {
+		return nil, errors.New("injected DIB failure")
+	}
+
+	m := NewManager()
+	m.isShowing = true
+	resultCh := make(chan Result, 1)
+	m.handleShow(overlayCmd{
+		Type:       cmdShow,
+		Screenshot: image.NewRGBA(image.Rect(0, 0, 4, 4)),
+		Bounds:     image.Rect(0, 0, 4, 4),
+		ScaleRatio: 1,
+		ResultCh:   resultCh,
+	})
+
+	select {
+	case res := <-resultCh:
+		if !res.Cancelled {
+			t.Errorf("result = %+v, want cancelled", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending Show was never resolved")
+	}
+	if m.IsShowing() {
+		t.Error("overlay should not be showing after draw context failure")
+	}
+}
+
+func TestHandleHide_ResolvesPendingShow(t *testing.T) {
+	m := NewManager()
+	resultCh := make(chan Result, 1)
+	m.resultCh = resultCh
+	m.isShowing = true
+
+	m.handleHide()
+	m.handleHide() // Repeated hide must be harmless
+
+	if res := <-resultCh; !res.Cancelled {
+		t.Errorf("result = %+v, want cancelled", res)
+	}
+	if m.resultCh != nil {
+		t.Error("result channel should be released after hide")
+	}
+}
+
+func TestDrawContextCleanup_Idempotent(t *testing.T) {
+	dc := &DrawContext{}
+	dc.Cleanup()
+	dc.Cleanup()
+	if dc.HMemDC != 0 || dc.hBitmap != 0 || dc.hOldBitmap != 0 {
+		t.Error("Cleanup should zero released handles")
+	}
+}
+
+func TestWindowRects(t *testing.T) {
+	bounds := image.Rect(-1920, 0, 2560, 1440)
+	left := image.Rect(-1920, 0, 0, 1080)
+	right := image.Rect(0, 0, 2560, 1440)
+
+	tests := []struct {
+		name     string
+		monitors []image.Rectangle
+		want     []image.Rectangle
+	}{
+		{"single window", nil, []image.Rectangle{bounds}},
+		{"one monitor", []image.Rectangle{right}, []image.Rectangle{bounds}},
+		{"per monitor", []image.Rectangle{left, right}, []image.Rectangle{left, right}},
+		{"clipped to bounds", []image.Rectangle{left, image.Rect(0, -100, 2560, 1440)}, []image.Rectangle{left, right}},
+		{"outside bounds", []image.Rectangle{image.Rect(5000, 0, 6000, 100), image.Rect(7000, 0, 8000, 100)}, []image.Rectangle{bounds}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := windowRects(bounds, tt.monitors)
+			if len(got) != len(tt.want) {
+				t.Fatalf("windowRects() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("windowRects()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorAt(t *testing.T) {
+	monitors := []image.Rectangle{image.Rect(0, 0, 1920, 1080), image.Rect(1920, 0, 4480, 1440)}
+
+	tests := []struct {
+		p    image.Point
+		want int
+	}{
+		{image.Pt(0, 0), 0},
+		{image.Pt(1919, 1079), 0},
+		{image.Pt(1920, 0), 1},
+		{image.Pt(100, 1200), -1}, // Below the shorter monitor
+	}
+
+	for _, tt := range tests {
+		if got := monitorAt(monitors, tt.p); got != tt.want {
+			t.Errorf("monitorAt(%v) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPixelScale(t *testing.T) {
+	tests := []struct {
+		physical, logical int
+		want              float64
+	}{
+		{3840, 3840, 1},   // Per-monitor aware, coordinates are pixels
+		{3840, 2560, 1.5}, // Virtualized 150% monitor
+		{0, 1920, 1.25},   // Unknown mode, fallback
+		{1920, 0, 1.25},
+	}
+	for _, tt := range tests {
+		if got := pixelScale(tt.physical, tt.logical, 1.25); got != tt.want {
+			t.Errorf("pixelScale(%d, %d) = %v, want %v", tt.physical, tt.logical, got, tt.want)
+		}
+	}
+}
+
+func TestSelectableRect(t *testing.T) {
+	m := NewManager()
+	m.bounds = image.Rect(-1920, 0, 2560, 1440)
+	m.monitors = []image.Rectangle{image.Rect(0, 0, 1920, 1080), image.Rect(1920, 0, 4480, 1440)}
+
+	m.active = -1
+	if got, want := m.selectableRect(), image.Rect(0, 0, 4480, 1440); got != want {
+		t.Errorf("selectableRect() without dimming = %v, want %v", got, want)
+	}
+	if len(m.inactiveRects()) != 0 {
+		t.Error("no monitors should be dimmed without an active monitor")
+	}
+
+	m.active = 1
+	if got := m.selectableRect(); got != m.monitors[1] {
+		t.Errorf("selectableRect() = %v, want %v", got, m.monitors[1])
+	}
+	if got := m.inactiveRects(); len(got) != 1 || got[0] != m.monitors[0] {
+		t.Errorf("inactiveRects() = %v, want [%v]", got, m.monitors[0])
+	}
+}
+
+func TestPreviewSize(t *testing.T) {
+	tests := []struct {
+		w, h         int
+		wantW, wantH int
+	}{
+		{0, 10, 0, 0},
+		{20, 10, 80, 40},    // Magnified up to previewMaxZoom
+		{400, 100, 200, 50}, // Limited by width
+		{100, 300, 50, 150}, // Limited by height
+	}
+
+	for _, tt := range tests {
+		gotW, gotH, _ := previewSize(tt.w, tt.h)
+		if gotW != tt.wantW || gotH != tt.wantH {
+			t.Errorf("previewSize(%d, %d) = %dx%d, want %dx%d", tt.w, tt.h, gotW, gotH, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestPreviewRect_FlipsAtEdges(t *testing.T) {
+	if got, want := previewRect(image.Pt(100, 100), 50, 40, 1000, 800), image.Rect(124, 124, 174, 164); got != want {
+		t.Errorf("previewRect() = %v, want %v", got, want)
+	}
+	if got, want := previewRect(image.Pt(990, 790), 50, 40, 1000, 800), image.Rect(916, 726, 966, 766); got != want {
+		t.Errorf("previewRect() at corner = %v, want %v", got, want)
+	}
+}
+
+func TestZoomStep_KeepsPixelUnderCursor(t *testing.T) {
+	raw := image.Pt(503, 301)
+	zoom, anchor := 1, image.Point{}
+
+	for _, delta := range []int{WHEEL_DELTA, WHEEL_DELTA, WHEEL_DELTA, -WHEEL_DELTA} {
+		before := unzoomPoint(raw, zoom, anchor)
+		zoom, anchor = zoomStep(zoom, anchor, raw, delta)
+		if got := unzoomPoint(raw, zoom, anchor); got != before {
+			t.Errorf("zoom %d: pixel under cursor = %v, want %v", zoom, got, before)
+		}
+	}
+	if zoom != 4 {
+		t.Errorf("zoom = %d, want 4", zoom)
+	}
+
+	for i := 0; i < 10; i++ {
+		zoom, anchor = zoomStep(zoom, anchor, raw, -WHEEL_DELTA)
+	}
+	if zoom != 1 || anchor != (image.Point{}) {
+		t.Errorf("zoom out = %d at %v, want 1:1", zoom, anchor)
+	}
+
+	for i := 0; i < 10; i++ {
+		zoom, anchor = zoomStep(zoom, anchor, raw, WHEEL_DELTA)
+	}
+	if zoom != maxZoom {
+		t.Errorf("zoom in = %d, want capped at %d", zoom, maxZoom)
+	}
+}
+
+func TestUnzoomPoint(t *testing.T) {
+	anchor := image.Pt(100, 100)
+	tests := []struct {
+		p    image.Point
+		zoom int
+		want image.Point
+	}{
+		{image.Pt(150, 90), 1, image.Pt(150, 90)},
+		{image.Pt(107, 100), 8, image.Pt(100, 100)},
+		{image.Pt(108, 116), 8, image.Pt(101, 102)},
+		{image.Pt(99, 93), 8, image.Pt(99, 99)}, // Rounds toward negative infinity
+	}
+
+	for _, tt := range tests {
+		if got := unzoomPoint(tt.p, tt.zoom, anchor); got != tt.want {
+			t.Errorf("unzoomPoint(%v, %d) = %v, want %v", tt.p, tt.zoom, got, tt.want)
+		}
+	}
+}
+
+func TestShapeSelection(t *testing.T) {
+	anchor := image.Pt(100, 100)
+	tests := []struct {
+		name               string
+		cursor             image.Point
+		fromCenter, square bool
+		wantStart, wantEnd image.Point
+	}{
+		{"corner", image.Pt(130, 120), false, false, image.Pt(100, 100), image.Pt(130, 120)},
+		{"center", image.Pt(130, 120), true, false, image.Pt(70, 80), image.Pt(130, 120)},
+		{"square", image.Pt(130, 120), false, true, image.Pt(100, 100), image.Pt(130, 130)},
+		{"square up-left", image.Pt(90, 60), false, true, image.Pt(100, 100), image.Pt(60, 60)},
+		{"centered square", image.Pt(110, 125), true, true, image.Pt(75, 75), image.Pt(125, 125)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := shapeSelection(anchor, tt.cursor, tt.fromCenter, tt.square)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("shapeSelection() = %v-%v, want %v-%v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestSelectionRect_Inflate(t *testing.T) {
+	sel := Selection{StartX: 50, StartY: 40, EndX: 10, EndY: 20, Inflate: 2}
+	if got, want := sel.Rect(), image.Rect(8, 18, 52, 42); got != want {
+		t.Errorf("Rect() = %v, want %v", got, want)
+	}
+}
+
+func TestInflateStep(t *testing.T) {
+	sel := Selection{StartX: 0, StartY: 0, EndX: 5, EndY: 20}
+	tests := []struct {
+		inflate, notches, want int
+	}{
+		{0, 1, 1},
+		{3, -1, 2},
+		{0, -1, -1},
+		{-2, -1, -2}, // 5px wide selection stays at least 1px
+	}
+
+	for _, tt := range tests {
+		sel.Inflate = tt.inflate
+		if got := inflateStep(sel, tt.notches); got != tt.want {
+			t.Errorf("inflateStep(inflate=%d, notches=%d) = %d, want %d", tt.inflate, tt.notches, got, tt.want)
+		}
+	}
+}
+
+func TestSelectionRect_Freehand(t *testing.T) {
+	sel := Selection{
+		Mode:    ModeFreehand,
+		Path:    []image.Point{{30, 40}, {10, 60}, {50, 20}},
+		Inflate: 5, // Ignored for freehand outlines
+	}
+	if got, want := sel.Rect(), image.Rect(10, 20, 50, 60); got != want {
+		t.Errorf("Rect() = %v, want %v", got, want)
+	}
+}
+
+func TestSetOptions_CursorDefault(t *testing.T) {
+	m := NewManager()
+	m.SetOptions(Options{Cursor: true})
+	if !m.includeCursor {
+		t.Fatal("Cursor option should check the capture cursor toggle")
+	}
+
+	// A toggle from the menu lasts until the setting changes
+	m.includeCursor = false
+	m.SetOptions(Options{Cursor: true, Loupe: true})
+	if m.includeCursor {
+		t.Error("unchanged Cursor option should keep the toggled state")
+	}
+	m.SetOptions(Options{})
+	m.SetOptions(Options{Cursor: true})
+	if !m.includeCursor {
+		t.Error("turning the Cursor option on again should check the toggle")
+	}
+}
+
+func TestFinish_ReportsModeAndRelativePath(t *testing.T) {
+	m := NewManager()
+	resultCh := make(chan Result, 1)
+	m.resultCh = resultCh
+	m.isShowing = true
+	m.includeCursor = true
+	m.selection.Mode = ModeFreehand
+
+	path := []image.Point{{10, 20}, {40, 20}, {25, 50}}
+	m.finish(image.Rect(10, 20, 40, 50), path)
+
+	res := <-resultCh
+	if res.Mode != ModeFreehand || !res.IncludeCursor {
+		t.Errorf("result = %+v, want freehand with cursor", res)
+	}
+	if res.X != 10 || res.Y != 20 || res.Width != 30 || res.Height != 30 {
+		t.Errorf("result rect = %d,%d %dx%d, want 10,20 30x30", res.X, res.Y, res.Width, res.Height)
+	}
+	if len(res.Path) != 3 || res.Path[0] != (image.Point{}) || res.Path[2] != image.Pt(15, 30) {
+		t.Errorf("result path = %v, want relative to the selection", res.Path)
+	}
+	if m.IsShowing() {
+		t.Error("overlay should hide after finishing")
+	}
+}
+
+func TestResultMask(t *testing.T) {
+	if mask := (Result{Mode: ModeRectangle, Width: 10, Height: 10}).Mask(1); mask != nil {
+		t.Errorf("rectangle mask = %v, want nil", mask.Bounds())
+	}
+
+	// Lower-left triangle at 2x scale
+	res := Result{Mode: ModeFreehand, Width: 10, Height: 10, Path: []image.Point{{0, 0}, {10, 10}, {0, 10}}}
+	mask := res.Mask(2)
+	if mask.Bounds() != image.Rect(0, 0, 20, 20) {
+		t.Fatalf("bounds = %v, want 20x20", mask.Bounds())
+	}
+	if a := mask.AlphaAt(2, 17).A; a != 255 {
+		t.Errorf("inside alpha = %d, want 255", a)
+	}
+	if a := mask.AlphaAt(17, 2).A; a != 0 {
+		t.Errorf("outside alpha = %d, want 0", a)
+	}
+
+	ellipse := Result{Mode: ModeEllipse, Width: 20, Height: 20}.Mask(1)
+	if ellipse.AlphaAt(0, 0).A != 0 || ellipse.AlphaAt(10, 10).A != 255 {
+		t.Errorf("ellipse corner/center alpha = %d/%d, want 0/255", ellipse.AlphaAt(0, 0).A, ellipse.AlphaAt(10, 10).A)
+	}
+}
+
+func TestPushHistory(t *testing.T) {
+	a, b := image.Rect(0, 0, 100, 100), image.Rect(50, 50, 200, 200)
+
+	h := pushHistory(nil, a)
+	h = pushHistory(h, b)
+	h = pushHistory(h, a)
+	if len(h) != 2 || h[0] != a || h[1] != b {
+		t.Errorf("history = %v, want reused region first without duplicates", h)
+	}
+
+	for i := 0; i < maxHistory+3; i++ {
+		h = pushHistory(h, image.Rect(i, i, i+50, i+50))
+	}
+	if len(h) != maxHistory {
+		t.Errorf("history length = %d, want %d", len(h), maxHistory)
+	}
+}
+
+func TestVisibleHistory(t *testing.T) {
+	history := []image.Rectangle{
+		image.Rect(-1900, 100, -1500, 400), // Another monitor
+		image.Rect(100, 100, 300, 300),
+		image.Rect(1915, 0, 2100, 50), // Only a sliver left after clipping
+	}
+	got := visibleHistory(history, image.Pt(0, 0), image.Rect(0, 0, 1920, 1080))
+	if len(got) != 1 || got[0] != image.Rect(100, 100, 300, 300) {
+		t.Errorf("visibleHistory() = %v, want only the region on this monitor", got)
+	}
+
+	// Overlay coordinates are relative to the overlay origin
+	got = visibleHistory(history, image.Pt(-1920, 0), image.Rect(0, 0, 3840, 1080))
+	if len(got) < 1 || got[0] != image.Rect(20, 100, 420, 400) {
+		t.Errorf("visibleHistory() with offset origin = %v", got)
+	}
+}
+
+func TestCycleIndex(t *testing.T) {
+	tests := []struct {
+		i, n, step, want int
+	}{
+		{-1, 3, 1, 0},
+		{-1, 3, -1, 2},
+		{0, 3, 1, 1},
+		{2, 3, 1, 0},
+		{0, 3, -1, 2},
+		{-1, 0, 1, -1},
+	}
+	for _, tt := range tests {
+		if got := cycleIndex(tt.i, tt.n, tt.step); got != tt.want {
+			t.Errorf("cycleIndex(%d, %d, %d) = %d, want %d", tt.i, tt.n, tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestUseRecent_SelectsHighlightedRegion(t *testing.T) {
+	m := NewManager()
+	resultCh := make(chan Result, 1)
+	m.resultCh = resultCh
+	m.isShowing = true
+	m.active = -1
+	m.bounds = image.Rect(-100, 0, 1820, 1080)
+	m.selection.Mode = ModeFreehand
+	m.history = []image.Rectangle{image.Rect(0, 0, 200, 100), image.Rect(400, 300, 600, 500)}
+
+	m.recent = -1
+	if m.useRecent() {
+		t.Fatal("useRecent() without a highlighted region should do nothing")
+	}
+
+	m.recent = 1
+	if !m.useRecent() {
+		t.Fatal("useRecent() = false, want the second region selected")
+	}
+	res := <-resultCh
+	if res.X != 500 || res.Y != 300 || res.Width != 200 || res.Height != 200 || res.Mode != ModeRectangle {
+		t.Errorf("result = %+v, want the region in overlay coordinates as a rectangle", res)
+	}
+	if m.history[0] != image.Rect(400, 300, 600, 500) {
+		t.Errorf("history = %v, want the reused region moved to the front", m.history)
+	}
+}
+
+func TestDetectPanels(t *testing.T) {
+	// A busy background with a flat sidebar and a flat dialog on it
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 800; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 7), uint8(y * 13), uint8(x ^ y), 255})
+		}
+	}
+	fill := func(r image.Rectangle, c color.RGBA) {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	sidebar := image.Rect(0, 0, 203, 600)
+	dialog := image.Rect(301, 157, 659, 411)
+	fill(sidebar, color.RGBA{40, 40, 48, 255})
+	fill(dialog, color.RGBA{240, 240, 240, 255})
+	fill(image.Rect(350, 200, 420, 220), color.RGBA{0, 0, 0, 255}) // Text on the dialog
+
+	got := detectPanels(img, img.Bounds())
+	for _, want := range []image.Rectangle{sidebar, dialog} {
+		found := false
+		for _, r := range got {
+			if r == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("detectPanels() = %v, want %v among them", got, want)
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("detectPanels() found %d panels, want 2", len(got))
+	}
+
+	// A flat screen is the desktop, not a panel
+	fill(img.Bounds(), color.RGBA{0, 120, 215, 255})
+	if got := detectPanels(img, img.Bounds()); len(got) != 0 {
+		t.Errorf("detectPanels() on a flat screen = %v, want none", got)
+	}
+}
+
+func TestSuggestRegions(t *testing.T) {
+	area := image.Rect(0, 0, 1920, 1080)
+	windows := []image.Rectangle{
+		image.Rect(100, 100, 900, 700),
+		image.Rect(200, 200, 400, 400),    // Behind the first window
+		image.Rect(1800, 900, 2400, 1400), // Partly off screen
+		image.Rect(1000, 100, 1020, 500),  // Too narrow
+	}
+	panels := []image.Rectangle{
+		image.Rect(102, 98, 898, 701), // Same as the first window
+		image.Rect(100, 100, 300, 700),
+	}
+	got := suggestRegions(windows, panels, area)
+	want := []image.Rectangle{
+		image.Rect(100, 100, 900, 700),
+		image.Rect(1800, 900, 1920, 1080),
+		image.Rect(100, 100, 300, 700),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("suggestRegions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("suggestRegions()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// The innermost suggestion under the cursor wins
+	if r := suggestionAt(got, image.Pt(150, 300), area); r != image.Rect(100, 100, 300, 700) {
+		t.Errorf("suggestionAt() = %v, want the sidebar", r)
+	}
+	if r := suggestionAt(got, image.Pt(1500, 300), area); !r.Empty() {
+		t.Errorf("suggestionAt() away from suggestions = %v, want empty", r)
+	}
+	// Suggestions are clipped to the active monitor
+	if r := suggestionAt(got, image.Pt(500, 300), image.Rect(0, 0, 600, 1080)); r != image.Rect(100, 100, 600, 700) {
+		t.Errorf("suggestionAt() on the active monitor = %v", r)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{"#FF3030", color.RGBA{R: 0xFF, G: 0x30, B: 0x30, A: 0xFF}, false},
+		{"00ff0080", color.RGBA{G: 0xFF, A: 0x80}, false},
+		{"#fff", color.RGBA{}, true},
+		{"#GGHHII", color.RGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseColor(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGuideShow_RejectsEmptyRegion(t *testing.T) {
+	g := NewGuide()
+	if err := g.Show(image.Rectangle{}, DefaultGuideColor, 2); err == nil {
+		t.Error("Show() with an empty region should fail")
+	}
+	if _, visible := g.Visible(); visible {
+		t.Error("guide should not be visible after a failed Show")
+	}
+}
+
+func TestStatusLayout_ButtonHitTest(t *testing.T) {
+	width, buttons := statusLayout("REC  0:05", false)
+	if len(buttons) != 2 {
+		t.Fatalf("statusLayout() returned %d buttons, want 2", len(buttons))
+	}
+	for _, b := range buttons {
+		if b.rect.Max.X > width {
+			t.Errorf("button %q ends at %d, beyond width %d", b.label, b.rect.Max.X, width)
+		}
+		if got := statusButtonAt(buttons, b.rect.Min); got != b.action {
+			t.Errorf("statusButtonAt(%v) = %v, want %v", b.rect.Min, got, b.action)
+		}
+	}
+	if got := statusButtonAt(buttons, image.Pt(1, 1)); got != 0 {
+		t.Errorf("statusButtonAt() over the text = %v, want 0", got)
+	}
+
+	if _, paused := statusLayout("REC", true); paused[0].label != "Resume" {
+		t.Errorf("paused pause button label = %q, want Resume", paused[0].label)
+	}
+}
+
+func TestStatusPosition(t *testing.T) {
+	screen := image.Rect(0, 0, 1920, 1080)
+	size := image.Pt(200, statusHeight)
+
+	tests := []struct {
+		name   string
+		region image.Rectangle
+		want   image.Point
+	}{
+		{"no region", image.Rectangle{}, image.Pt(860, statusMargin)},
+		{"above", image.Rect(100, 500, 500, 800), image.Pt(200, 500-statusBorder-statusMargin-statusHeight)},
+		{"below", image.Rect(100, 10, 500, 800), image.Pt(200, 800+statusBorder+statusMargin)},
+		{"inside", image.Rect(0, 0, 1920, 1080), image.Pt(860, statusMargin)},
+		{"clamped", image.Rect(1800, 500, 1900, 600), image.Pt(1720, 500-statusBorder-statusMargin-statusHeight)},
+	}
+
+	for _, tt := range tests {
+		if got := statusPosition(tt.region, size, screen); got != tt.want {
+			t.Errorf("%s: statusPosition() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatusText(t *testing.T) {
+	tests := []struct {
+		info StatusInfo
+		want string
+	}{
+		{StatusInfo{Label: "Capture in 3"}, "Capture in 3"},
+		{StatusInfo{Label: "REC", Elapsed: 65 * time.Second, Bytes: 3 << 20}, "REC  1:05  3.0 MB"},
+		{StatusInfo{Label: "REC", Elapsed: time.Hour + 2*time.Second, Bytes: 512}, "REC  1:00:02  512 B"},
+		{StatusInfo{Label: "REC", Bytes: 5 << 30}, "REC  5.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := statusText(tt.info); got != tt.want {
+			t.Errorf("statusText(%+v) = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestInnermostWindow(t *testing.T) {
+	windows := []Window{
+		{Handle: 1, Bounds: image.Rect(0, 0, 400, 300)},
+		{Handle: 2, Bounds: image.Rect(10, 10, 200, 100)},
+		{Handle: 3, Bounds: image.Rect(20, 20, 60, 40)},
+	}
+
+	tests := []struct {
+		p    image.Point
+		want uintptr
+	}{
+		{image.Pt(30, 30), 3},
+		{image.Pt(150, 50), 2},
+		{image.Pt(300, 200), 1},
+		{image.Pt(500, 500), 0},
+	}
+
+	for _, tt := range tests {
+		if got := innermostWindow(windows, tt.p); got.Handle != tt.want {
+			t.Errorf("innermostWindow(%v) = %d, want %d", tt.p, got.Handle, tt.want)
+		}
+	}
+}
+
+func TestWindowUnder(t *testing.T) {
+	windows := []Window{
+		{Handle: 1, Bounds: image.Rect(100, 100, 300, 300)},
+		{Handle: 2, Bounds: image.Rect(0, 0, 400, 400)},
+	}
+
+	if w, ok := windowUnder(windows, image.Pt(150, 150)); !ok || w.Handle != 1 {
+		t.Errorf("windowUnder(150,150) = %d, %v, want topmost 1", w.Handle, ok)
+	}
+	if w, ok := windowUnder(windows, image.Pt(350, 50)); !ok || w.Handle != 2 {
+		t.Errorf("windowUnder(350,50) = %d, %v, want 2", w.Handle, ok)
+	}
+	if _, ok := windowUnder(windows, image.Pt(500, 500)); ok {
+		t.Error("windowUnder(500,500) found a window, want none")
+	}
+}
+
+func TestGeometryText(t *testing.T) {
+	r := image.Rect(-1920, 40, -1120, 640)
+	if got, want := geometryText(r, 1, false), `{"x":-1920,"y":40,"w":800,"h":600,"monitor":1}`; got != want {
+		t.Errorf("json = %s, want %s", got, want)
+	}
+	if got, want := geometryText(r, 1, true), "--region -1920,40,800,600"; got != want {
+		t.Errorf("region = %q, want %q", got, want)
+	}
+}
+
+func TestMonitorIndex(t *testing.T) {
+	monitors := []image.Rectangle{image.Rect(0, 0, 1920, 1080), image.Rect(1920, 0, 3840, 1080)}
+	tests := []struct {
+		r    image.Rectangle
+		want int
+	}{
+		{image.Rect(10, 10, 100, 100), 0},
+		{image.Rect(1800, 10, 2200, 100), 1}, // Center decides
+		{image.Rect(4000, 0, 4100, 100), -1},
+	}
+	for _, tt := range tests {
+		if got := monitorIndex(monitors, tt.r); got != tt.want {
+			t.Errorf("monitorIndex(%v) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestWindowGeometryText(t *testing.T) {
+	visual := image.Rect(100, 50, 900, 650)
+	api := image.Rect(93, 50, 907, 657) // Invisible resize borders on three sides
+	want := `{"x":100,"y":50,"w":800,"h":600,"monitor":0,"windowRect":{"x":93,"y":50,"w":814,"h":607},"delta":{"left":7,"top":0,"right":7,"bottom":7}}`
+	if got := windowGeometryText(visual, api, 0); got != want {
+		t.Errorf("windowGeometryText = %s, want %s", got, want)
+	}
+}
+
+func TestPickedColorFormat(t *testing.T) {
+	tests := []struct {
+		c             color.RGBA
+		hex, rgb, hsl string
+	}{
+		{color.RGBA{A: 255}, "#000000", "rgb(0, 0, 0)", "hsl(0, 0%, 0%)"},
+		{color.RGBA{R: 255, G: 255, B: 255, A: 255}, "#FFFFFF", "rgb(255, 255, 255)", "hsl(0, 0%, 100%)"},
+		{color.RGBA{R: 255, A: 255}, "#FF0000", "rgb(255, 0, 0)", "hsl(0, 100%, 50%)"},
+		{color.RGBA{R: 0, G: 120, B: 215, A: 255}, "#0078D7", "rgb(0, 120, 215)", "hsl(207, 100%, 42%)"},
+		{color.RGBA{R: 255, G: 0, B: 1, A: 255}, "#FF0001", "rgb(255, 0, 1)", "hsl(0, 100%, 50%)"}, // Hue 359.8 wraps
+		{color.RGBA{R: 128, G: 64, B: 192, A: 255}, "#8040C0", "rgb(128, 64, 192)", "hsl(270, 50%, 50%)"},
+	}
+	for _, tt := range tests {
+		c := PickedColor{Color: tt.c}
+		if got := c.Format(ColorHex); got != tt.hex {
+			t.Errorf("hex of %v = %s, want %s", tt.c, got, tt.hex)
+		}
+		if got := c.Format(ColorRGB); got != tt.rgb {
+			t.Errorf("rgb of %v = %s, want %s", tt.c, got, tt.rgb)
+		}
+		if got := c.Format(ColorHSL); got != tt.hsl {
+			t.Errorf("hsl of %v = %s, want %s", tt.c, got, tt.hsl)
+		}
+		if got := c.Format(""); got != tt.hex {
+			t.Errorf("default format of %v = %s, want hex", tt.c, got)
+		}
+	}
+}
+
+func TestPickColor_ReportsPixel(t *testing.T) {
+	m := NewManager()
+	resultCh := make(chan Result, 1)
+	m.resultCh = resultCh
+	m.isShowing = true
+	m.active = -1
+	m.bounds = image.Rect(100, 100, 110, 110)
+	m.screenshot = image.NewRGBA(image.Rect(0, 0, 10, 10))
+	m.screenshot.SetRGBA(9, 3, color.RGBA{R: 1, G: 2, B: 3, A: 128})
+	m.selection.Mode = ModeColor
+
+	m.pickColor(image.Pt(10, 3)) // On the right edge, clamped to the last column
+
+	res := <-resultCh
+	if res.Mode != ModeColor || res.X != 9 || res.Y != 3 {
+		t.Errorf("result = %+v, want color mode at 9,3", res)
+	}
+	if want := (color.RGBA{R: 1, G: 2, B: 3, A: 255}); res.Color != want {
+		t.Errorf("color = %v, want %v opaque", res.Color, want)
+	}
+	if m.IsShowing() {
+		t.Error("overlay should hide after picking")
+	}
+}
+
+func TestLoupeRect_StaysInContext(t *testing.T) {
+	for _, cursor := range []image.Point{{0, 0}, {1919, 0}, {0, 1079}, {1919, 1079}, {960, 540}} {
+		r := loupeRect(cursor, 1920, 1080, image.Rectangle{})
+		if !r.In(image.Rect(0, 0, 1920, 1080)) || cursor.In(r) {
+			t.Errorf("loupeRect(%v) = %v, want inside the context and off the cursor", cursor, r)
+		}
+	}
+}
+
+func TestHitGrip(t *testing.T) {
+	r := image.Rect(100, 100, 300, 200)
+	tests := []struct {
+		p    image.Point
+		want grip
+	}{
+		{image.Pt(98, 103), gripLeft | gripTop},
+		{image.Pt(305, 204), gripRight | gripBottom},
+		{image.Pt(300, 100), gripRight | gripTop},
+		{image.Pt(200, 196), gripBottom},
+		{image.Pt(96, 150), gripLeft},
+		{image.Pt(200, 150), gripMove},
+		{image.Pt(200, 210), gripNone},
+		{image.Pt(50, 50), gripNone},
+	}
+	for _, tt := range tests {
+		if got := hitGrip(r, tt.p, 6); got != tt.want {
+			t.Errorf("hitGrip(%v) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestAdjustRect(t *testing.T) {
+	area := image.Rect(0, 0, 1920, 1080)
+	r := image.Rect(100, 100, 300, 200)
+	tests := []struct {
+		name string
+		g    grip
+		d    image.Point
+		want image.Rectangle
+	}{
+		{"corner", gripRight | gripBottom, image.Pt(50, 30), image.Rect(100, 100, 350, 230)},
+		{"edge ignores the other axis", gripLeft, image.Pt(-40, 90), image.Rect(60, 100, 300, 200)},
+		{"past the opposite edge flips", gripTop, image.Pt(0, 150), image.Rect(100, 200, 300, 250)},
+		{"resize stops at the area", gripLeft | gripTop, image.Pt(-500, -500), image.Rect(0, 0, 300, 200)},
+		{"move", gripMove, image.Pt(20, -10), image.Rect(120, 90, 320, 190)},
+		{"move keeps the size at the area edge", gripMove, image.Pt(5000, 5000), image.Rect(1720, 980, 1920, 1080)},
+	}
+	for _, tt := range tests {
+		if got := adjustRect(r, tt.g, tt.d, area); got != tt.want {
+			t.Errorf("%s: adjustRect = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoupeRect_AvoidsPreview(t *testing.T) {
+	ctx := image.Rect(0, 0, 1920, 1080)
+	for _, cursor := range []image.Point{{960, 540}, {10, 540}, {1910, 1070}, {10, 10}} {
+		preview := previewRect(cursor, 200, 150, 1920, 1080).Inset(-2)
+		r := loupeRect(cursor, 1920, 1080, preview)
+		if !r.In(ctx) || cursor.In(r) || r.Overlaps(preview) {
+			t.Errorf("loupeRect(%v) = %v, want inside the context, off the cursor and clear of %v", cursor, r, preview)
+		}
+	}
+}
+
+func TestSnapPoint(t *testing.T) {
+	rects := []image.Rectangle{
+		image.Rect(100, 100, 500, 400), // Window
+		image.Rect(120, 130, 220, 160), // Button inside it
+	}
+	tests := []struct {
+		name string
+		p    image.Point
+		want image.Point
+	}{
+		{"far from edges", image.Pt(300, 250), image.Pt(300, 250)},
+		{"near a window edge", image.Pt(104, 250), image.Pt(100, 250)},
+		{"near a corner snaps both axes", image.Pt(495, 397), image.Pt(500, 400)},
+		{"nearest edge wins", image.Pt(123, 158), image.Pt(120, 160)},
+		{"edge line beyond its length", image.Pt(700, 103), image.Pt(700, 103)},
+		{"outside within reach", image.Pt(96, 96), image.Pt(100, 100)},
+	}
+	for _, tt := range tests {
+		if got := snapPoint(tt.p, rects, snapDistance); got != tt.want {
+			t.Errorf("%s: snapPoint(%v) = %v, want %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package overlay
+
+import (
+	"image"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	dwmapi                    = syscall.NewLazyDLL("dwmapi.dll")
+	procEnumChildWindows      = user32.NewProc("EnumChildWindows")
+	procGetWindowRect         = user32.NewProc("GetWindowRect")
+	procIsWindowVisible       = user32.NewProc("IsWindowVisible")
+	procDwmGetWindowAttribute = dwmapi.NewProc("DwmGetWindowAttribute")
+	procClientToScreen        = user32.NewProc("ClientToScreen")
+)
+
+const dwmwaExtendedFrameBounds = 9
+
+// Window is a window offered in window mode
+type Window struct {
+	Handle uintptr         // 0 when only the bounds are known
+	Bounds image.Rectangle // Screen coordinates
+}
+
+// PickedWindow is the window chosen with RunWindowPicker
+type PickedWindow struct {
+	Handle     uintptr
+	Bounds     image.Rectangle // DWM frame bounds in screen coordinates, without the invisible resize borders
+	WindowRect image.Rectangle // GetWindowRect bounds, which Win32 APIs such as SetWindowPos use
+	Delta      FrameDelta      // How far WindowRect extends past Bounds
+	Cancelled  bool
+}
+
+// FrameDelta is how far the GetWindowRect bounds of a window extend past its
+// visible DWM frame on each side. Windows 10 and 11 windows have invisible
+// resize borders, usually 7 pixels at 100% scaling, on the left, right and
+// bottom
+type FrameDelta struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+}
+
+// frameDelta returns how far api extends past visual on each side
+func frameDelta(visual, api image.Rectangle) FrameDelta {
+	return FrameDelta{
+		Left:   visual.Min.X - api.Min.X,
+		Top:    visual.Min.Y - api.Min.Y,
+		Right:  api.Max.X - visual.Max.X,
+		Bottom: api.Max.Y - visual.Max.Y,
+	}
+}
+
+// SetWindows sets the top-level windows (topmost first) offered in window
+// mode for the next Show. Holding Ctrl picks their child controls
+func (m *Manager) SetWindows(windows []Window) {
+	m.mu.Lock()
+	m.windowList = windows
+	m.mu.Unlock()
+}
+
+// RunWindowPicker shows the overlay in window mode and blocks until a window
+// is clicked, like Snipping Tool's window snip. Hovering highlights the
+// window under the cursor, or its innermost child control while Ctrl is
+// held. The windows come from SetWindows
+func (m *Manager) RunWindowPicker(screenshot *image.RGBA, bounds image.Rectangle, monitors []image.Rectangle, scaleRatio float64) PickedWindow {
+	res := <-m.show(overlayCmd{
+		Type:       cmdShow,
+		Screenshot: screenshot,
+		Bounds:     bounds,
+		Monitors:   monitors,
+		ScaleRatio: scaleRatio,
+		PickWindow: true,
+	})
+	// Switching shape from the context menu ends the pick without a window
+	if res.Cancelled || res.Window == 0 {
+		return PickedWindow{Cancelled: true}
+	}
+	frame := windowBounds(res.Window)
+	if frame.Empty() {
+		frame = image.Rect(res.X, res.Y, res.X+res.Width, res.Y+res.Height).Add(bounds.Min)
+	}
+	rect := windowRect(res.Window)
+	if rect.Empty() {
+		rect = frame
+	}
+	return PickedWindow{Handle: res.Window, Bounds: frame, WindowRect: rect, Delta: frameDelta(frame, rect)}
+}
+
+// windowAt returns the topmost window containing p (overlay coordinates)
+// clipped to the selectable area, or an empty rectangle. With Ctrl held it
+// returns the innermost visible child control instead
+func (m *Manager) windowAt(p image.Point) (image.Rectangle, uintptr) {
+	m.mu.Lock()
+	windows := m.windowList
+	m.mu.Unlock()
+
+	area := m.selectableRect()
+	for _, w := range windows {
+		r := w.Bounds.Sub(m.bounds.Min).Intersect(area)
+		if !p.In(r) {
+			continue
+		}
+		if w.Handle == 0 {
+			return r, 0
+		}
+		if isKeyDown(VK_CONTROL) {
+			if child := innermostWindow(childWindows(w.Handle), p.Add(m.bounds.Min)); child.Handle != 0 {
+				return child.Bounds.Sub(m.bounds.Min).Intersect(area), child.Handle
+			}
+		}
+		if frame := windowBounds(w.Handle); !frame.Empty() {
+			r = frame.Sub(m.bounds.Min).Intersect(area)
+		}
+		return r, w.Handle
+	}
+	return image.Rectangle{}, 0
+}
+
+// innermostWindow returns the smallest window containing p
+func innermostWindow(windows []Window, p image.Point) Window {
+	var best Window
+	for _, w := range windows {
+		if !p.In(w.Bounds) {
+			continue
+		}
+		if best.Handle == 0 || w.Bounds.Dx()*w.Bounds.Dy() < best.Bounds.Dx()*best.Bounds.Dy() {
+			best = w
+		}
+	}
+	return best
+}
+
+// Callbacks can't be freed, so EnumChildWindows reuses one. It only runs on
+// the message loop thread
+var (
+	enumChildCallback = syscall.NewCallback(enumChild)
+	enumChildResult   []Window
+)
+
+func enumChild(hwnd, _ uintptr) uintptr {
+	if visible, _, _ := procIsWindowVisible.Call(hwnd); visible != 0 {
+		enumChildResult = append(enumChildResult, Window{Handle: hwnd, Bounds: windowRect(hwnd)})
+	}
+	return 1
+}
+
+// childWindows returns every visible descendant of a window
+func childWindows(parent uintptr) []Window {
+	enumChildResult = nil
+	procEnumChildWindows.Call(parent, enumChildCallback, 0)
+	children := enumChildResult
+	enumChildResult = nil
+	return children
+}
+
+// windowBounds returns the DWM frame bounds of a top-level window, which
+// leave out the invisible resize borders, or the window rect of a control
+func windowBounds(hwnd uintptr) image.Rectangle {
+	if procDwmGetWindowAttribute.Find() == nil {
+		var rect RECT
+		hr, _, _ := procDwmGetWindowAttribute.Call(hwnd, dwmwaExtendedFrameBounds, uintptr(unsafe.Pointer(&rect)), unsafe.Sizeof(rect))
+		if hr == 0 {
+			return image.Rect(int(rect.Left), int(rect.Top), int(rect.Right), int(rect.Bottom))
+		}
+	}
+	return windowRect(hwnd)
+}
+
+func windowRect(hwnd uintptr) image.Rectangle {
+	var rect RECT
+	if ret, _, _ := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); ret == 0 {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(rect.Left), int(rect.Top), int(rect.Right), int(rect.Bottom))
+}
+
+// windowUnder returns the topmost window containing p (screen coordinates)
+func windowUnder(windows []Window, p image.Point) (Window, bool) {
+	for _, w := range windows {
+		if p.In(w.Bounds) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// screenPoint converts overlay coordinates to physical screen coordinates
+func (m *Manager) screenPoint(p image.Point) image.Point {
+	return m.bounds.Min.Add(image.Pt(int(float64(p.X)*m.scaleRatio), int(float64(p.Y)*m.scaleRatio)))
+}
+
+// clientPosition returns p (overlay coordinates) relative to the client area
+// of the window under anchor, as UI automation tools address clicks
+func (m *Manager) clientPosition(p, anchor image.Point) (image.Point, bool) {
+	m.mu.Lock()
+	windows := m.windowList
+	m.mu.Unlock()
+
+	w, ok := windowUnder(windows, m.screenPoint(anchor))
+	if !ok || w.Handle == 0 {
+		return image.Point{}, false
+	}
+	var origin POINT
+	if ret, _, _ := procClientToScreen.Call(w.Handle, uintptr(unsafe.Pointer(&origin))); ret == 0 {
+		return image.Point{}, false
+	}
+	return m.screenPoint(p).Sub(image.Pt(int(origin.X), int(origin.Y))), true
+}
@@ -0,0 +1,80 @@
+package overlay
+
+import "image"
+
+// snapDistance is how close, in screen pixels, the cursor must come to an
+// edge for the selection to snap to it
+const snapDistance = 8
+
+// snapPoint moves p onto the nearest vertical and nearest horizontal edge of
+// rects within dist of it, each axis on its own. An edge only attracts p
+// alongside it, not along the line it lies on
+func snapPoint(p image.Point, rects []image.Rectangle, dist int) image.Point {
+	bestX, bestY := dist+1, dist+1
+	snapped := p
+	for _, r := range rects {
+		if p.Y >= r.Min.Y-dist && p.Y <= r.Max.Y+dist {
+			for _, x := range [2]int{r.Min.X, r.Max.X} {
+				if d := absInt(p.X - x); d < bestX {
+					bestX, snapped.X = d, x
+				}
+			}
+		}
+		if p.X >= r.Min.X-dist && p.X <= r.Max.X+dist {
+			for _, y := range [2]int{r.Min.Y, r.Max.Y} {
+				if d := absInt(p.Y - y); d < bestY {
+					bestY, snapped.Y = d, y
+				}
+			}
+		}
+	}
+	return snapped
+}
+
+// SetElements sets the bounds of UI elements (screen coordinates), such as
+// the controls of the window under the cursor, that selections snap to
+// alongside the windows from SetWindows. It can be called while the overlay
+// is showing, as elements are usually found after it appears
+func (m *Manager) SetElements(rects []image.Rectangle) {
+	m.mu.Lock()
+	m.elements = rects
+	m.mu.Unlock()
+}
+
+// snap returns p (overlay coordinates) snapped to the nearest window or
+// element edge while snapping is on. Callers hold m.mu
+func (m *Manager) snap(p image.Point) image.Point {
+	if !m.selection.Snap || m.selection.Mode == ModeFreehand {
+		return p
+	}
+	rects := make([]image.Rectangle, 0, len(m.windowFrames)+len(m.elements))
+	rects = append(rects, m.windowFrames...)
+	for _, r := range m.elements {
+		rects = append(rects, r.Sub(m.bounds.Min))
+	}
+	return snapPoint(p, rects, maxInt(snapDistance/maxInt(m.zoom, 1), 1))
+}
+
+// visibleFrames returns the visible frames of windows in overlay coordinates,
+// without the invisible resize borders selections would otherwise snap to
+func (m *Manager) visibleFrames(windows []Window) []image.Rectangle {
+	frames := make([]image.Rectangle, 0, len(windows))
+	for _, w := range windows {
+		r := w.Bounds
+		if w.Handle != 0 {
+			if frame := windowBounds(w.Handle); !frame.Empty() {
+				r = frame
+			}
+		}
+		frames = append(frames, r.Sub(m.bounds.Min))
+	}
+	return frames
+}
+
+// toggleSnap turns snapping to window and element edges on or off
+func (m *Manager) toggleSnap() {
+	m.mu.Lock()
+	m.selection.Snap = !m.selection.Snap
+	m.mu.Unlock()
+	m.redraw()
+}
@@ -0,0 +1,489 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+var procGetSystemMetrics = user32.NewProc("GetSystemMetrics")
+
+// Status window constants
+const (
+	WM_MOUSEACTIVATE   = 0x0021
+	MA_NOACTIVATE      = 3
+	IDC_ARROW          = 32512
+	SM_CXSCREEN        = 0
+	SM_CYSCREEN        = 1
+	SM_XVIRTUALSCREEN  = 76
+	SM_YVIRTUALSCREEN  = 77
+	SM_CXVIRTUALSCREEN = 78
+	SM_CYVIRTUALSCREEN = 79
+)
+
+// Status widget layout
+const (
+	statusHeight    = 24
+	statusPadding   = 8
+	statusGap       = 4
+	statusMargin    = 12 // Distance from the region or the screen edge
+	statusDotSize   = 8
+	statusBorder    = 3 // Thickness of the red outline around the region
+	glyphAdvance    = 6
+	glyphHeight     = 7
+	statusButtonPad = 3
+)
+
+// Status widget colors
+var (
+	statusBackground  = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xE6}
+	statusButtonColor = color.RGBA{R: 0x48, G: 0x48, B: 0x48, A: 0xFF}
+	statusActiveDot   = color.RGBA{R: 0xFF, G: 0x30, B: 0x30, A: 0xFF}
+	statusPausedDot   = color.RGBA{R: 0xFF, G: 0xB0, B: 0x20, A: 0xFF}
+	statusRegionColor = color.RGBA{R: 0xFF, G: 0x20, B: 0x20, A: 0xFF}
+)
+
+// StatusAction is a button pressed on the status widget
+type StatusAction int
+
+const (
+	StatusPause StatusAction = iota + 1 // Pause or resume
+	StatusStop
+)
+
+// StatusInfo describes what the status widget shows
+type StatusInfo struct {
+	Label   string          // Leading text such as "REC" or "Capture in 3"
+	Elapsed time.Duration   // Shown as m:ss when non-zero
+	Bytes   int64           // Estimated output size, shown when non-zero
+	Paused  bool            // Swaps the pause button for resume
+	Region  image.Rectangle // Screen region outlined in red, may be empty
+}
+
+type statusButton struct {
+	rect   image.Rectangle // Widget coordinates
+	action StatusAction
+	label  string
+}
+
+type statusCmd struct {
+	stop bool
+	show bool
+	info StatusInfo
+}
+
+// Status is a small always-on-top widget for countdowns and recordings. It
+// shows a label, elapsed time and size estimate with Pause and Stop buttons,
+// and outlines the captured region in red. It never takes focus and is kept
+// out of captures where the system supports it. Only one Status may be shown
+// at a time.
+type Status struct {
+	onAction func(StatusAction)
+	border   *Guide
+	buttons  []statusButton // Only touched on the message loop thread
+	cmdCh    chan statusCmd
+	doneCh   chan struct{}
+	running  bool
+	visible  bool
+	mu       sync.Mutex
+}
+
+var statusWndProc = syscall.NewCallback(statusProc)
+var statusInstance *Status
+
+// NewStatus creates a hidden status widget. onAction is called on its own
+// goroutine when a button is clicked.
+func NewStatus(onAction func(StatusAction)) *Status {
+	return &Status{
+		onAction: onAction,
+		border:   NewGuide(),
+		cmdCh:    make(chan statusCmd, 10),
+	}
+}
+
+// Show displays the widget or updates it in place
+func (s *Status) Show(info StatusInfo) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.visible = true
+	s.mu.Unlock()
+
+	s.cmdCh <- statusCmd{show: true, info: info}
+
+	if info.Region.Empty() {
+		s.border.Hide()
+		return nil
+	}
+	return s.border.Show(info.Region, statusRegionColor, statusBorder)
+}
+
+// Hide removes the widget and region outline from the screen
+func (s *Status) Hide() {
+	s.mu.Lock()
+	running := s.running
+	s.visible = false
+	s.mu.Unlock()
+
+	if running {
+		s.cmdCh <- statusCmd{}
+	}
+	s.border.Hide()
+}
+
+// Visible reports whether the widget is showing
+func (s *Status) Visible() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.visible
+}
+
+// Close destroys the widget and region outline windows
+func (s *Status) Close() {
+	s.border.Close()
+
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.visible = false
+	doneCh := s.doneCh
+	s.mu.Unlock()
+	s.cmdCh <- statusCmd{stop: true}
+
+	select {
+	case <-doneCh:
+	case <-time.After(2 * time.Second):
+	}
+}
+
+// start creates the widget window on its own thread if not already running
+func (s *Status) start() error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = true
+	s.doneCh = make(chan struct{})
+	s.mu.Unlock()
+
+	readyCh := make(chan error, 1)
+	go s.messageLoop(readyCh)
+
+	if err := <-readyCh; err != nil {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *Status) messageLoop(readyCh chan<- error) {
+	// Window messages are delivered to the creating thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(s.doneCh)
+
+	hInstance, _, _ := procGetModuleHandleW.Call(0)
+	className, _ := syscall.UTF16PtrFromString("WinShotStatus")
+
+	wc := WNDCLASSEXW{
+		CbSize:        uint32(unsafe.Sizeof(WNDCLASSEXW{})),
+		LpfnWndProc:   statusWndProc,
+		HInstance:     hInstance,
+		LpszClassName: className,
+	}
+	if registerClassEx(&wc) == 0 {
+		readyCh <- errors.New("failed to register status window class")
+		return
+	}
+	defer unregisterClass(className, hInstance)
+
+	// Clickable, unlike the guide, but never activated so the window being
+	// captured or recorded keeps focus
+	hwnd, _, _ := procCreateWindowExW.Call(
+		WS_EX_LAYERED|WS_EX_TOPMOST|WS_EX_TOOLWINDOW|WS_EX_NOACTIVATE,
+		uintptr(unsafe.Pointer(className)),
+		0,
+		WS_POPUP,
+		0, 0, 1, 1,
+		0, 0, hInstance, 0,
+	)
+	if hwnd == 0 {
+		readyCh <- errors.New("failed to create status window")
+		return
+	}
+	defer procDestroyWindow.Call(hwnd)
+
+	statusInstance = s
+	defer func() {
+		if statusInstance == s {
+			statusInstance = nil
+		}
+	}()
+
+	procSetWindowDisplayAffinity.Call(hwnd, WDA_EXCLUDEFROMCAPTURE)
+	readyCh <- nil
+
+	var msg MSG
+	for {
+		select {
+		case cmd := <-s.cmdCh:
+			switch {
+			case cmd.stop:
+				return
+			case cmd.show:
+				if err := s.render(hwnd, cmd.info); err != nil {
+					println("Warning: failed to draw status widget:", err.Error())
+					continue
+				}
+				procShowWindow.Call(hwnd, SW_SHOWNOACTIVATE)
+			default:
+				procShowWindow.Call(hwnd, SW_HIDE)
+			}
+		default:
+			ret, _, _ := procPeekMessageW.Call(
+				uintptr(unsafe.Pointer(&msg)),
+				0, 0, 0, PM_REMOVE,
+			)
+			if ret != 0 {
+				procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+				procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+// statusProc handles clicks on the widget buttons
+func statusProc(hwnd, msg, wParam, lParam uintptr) uintptr {
+	s := statusInstance
+	if s == nil {
+		return defWindowProc(hwnd, msg, wParam, lParam)
+	}
+
+	switch msg {
+	case WM_NCHITTEST:
+		return HTCLIENT
+
+	case WM_MOUSEACTIVATE:
+		return MA_NOACTIVATE
+
+	case WM_SETCURSOR:
+		procSetCursor.Call(loadCursor(IDC_ARROW))
+		return 1
+
+	case WM_LBUTTONUP:
+		pt := image.Pt(int(int16(lParam&0xFFFF)), int(int16((lParam>>16)&0xFFFF)))
+		if action := statusButtonAt(s.buttons, pt); action != 0 && s.onAction != nil {
+			go s.onAction(action)
+		}
+		return 0
+	}
+
+	return defWindowProc(hwnd, msg, wParam, lParam)
+}
+
+// render draws the widget and moves it next to the region
+func (s *Status) render(hwnd uintptr, info StatusInfo) error {
+	text := statusText(info)
+	width, buttons := statusLayout(text, info.Paused)
+	s.buttons = buttons
+
+	screen := virtualScreen()
+	if info.Region.Empty() {
+		screen = primaryScreen()
+	}
+	pos := statusPosition(info.Region, image.Pt(width, statusHeight), screen)
+
+	hScreenDC, _, _ := procGetDC.Call(0)
+	defer procReleaseDC.Call(0, hScreenDC)
+
+	dc, err := newDrawContext(hScreenDC, width, statusHeight)
+	if err != nil {
+		return err
+	}
+	defer dc.Cleanup()
+
+	pixels := unsafe.Slice((*uint32)(dc.pixels), width*statusHeight)
+	dc.fillRect(image.Rect(0, 0, width, statusHeight), statusBackground)
+
+	dot := statusActiveDot
+	if info.Paused {
+		dot = statusPausedDot
+	}
+	dotY := (statusHeight - statusDotSize) / 2
+	dc.fillRect(image.Rect(statusPadding, dotY, statusPadding+statusDotSize, dotY+statusDotSize), dot)
+
+	textY := (statusHeight - glyphHeight) / 2
+	dc.drawInstructionText(statusPadding+statusDotSize+statusGap*2, textY, text, pixels)
+
+	for _, b := range buttons {
+		dc.fillRect(b.rect, statusButtonColor)
+		dc.drawInstructionText(b.rect.Min.X+statusPadding, textY, b.label, pixels)
+	}
+
+	ptSrc := POINT{0, 0}
+	ptDst := POINT{int32(pos.X), int32(pos.Y)}
+	size := SIZE{int32(width), statusHeight}
+	blend := BLENDFUNCTION{AC_SRC_OVER, 0, 255, AC_SRC_ALPHA}
+
+	ret, _, _ := procUpdateLayeredWindow.Call(
+		hwnd,
+		0,
+		uintptr(unsafe.Pointer(&ptDst)),
+		uintptr(unsafe.Pointer(&size)),
+		dc.HMemDC,
+		uintptr(unsafe.Pointer(&ptSrc)),
+		0,
+		uintptr(unsafe.Pointer(&blend)),
+		ULW_ALPHA,
+	)
+	if ret == 0 {
+		return errors.New("UpdateLayeredWindow failed")
+	}
+	return nil
+}
+
+// fillRect fills r with a solid color, clipped to the context
+func (dc *DrawContext) fillRect(r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(image.Rect(0, 0, dc.width, dc.height))
+	pixels := unsafe.Slice((*uint32)(dc.pixels), dc.width*dc.height)
+	px := premultiplied(c)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			pixels[y*dc.width+x] = px
+		}
+	}
+}
+
+// statusText joins the label with the elapsed time and size estimate
+func statusText(info StatusInfo) string {
+	parts := []string{info.Label}
+	if info.Elapsed > 0 {
+		parts = append(parts, formatElapsed(info.Elapsed))
+	}
+	if info.Bytes > 0 {
+		parts = append(parts, formatSize(info.Bytes))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// statusLayout returns the widget width and the Pause and Stop buttons placed
+// after the text
+func statusLayout(text string, paused bool) (int, []statusButton) {
+	pause := "Pause"
+	if paused {
+		pause = "Resume"
+	}
+
+	x := statusPadding + statusDotSize + statusGap*2 + textWidth(text) + statusPadding
+	var buttons []statusButton
+	for _, b := range []statusButton{{action: StatusPause, label: pause}, {action: StatusStop, label: "Stop"}} {
+		w := textWidth(b.label) + statusPadding*2
+		b.rect = image.Rect(x, statusButtonPad, x+w, statusHeight-statusButtonPad)
+		buttons = append(buttons, b)
+		x += w + statusGap
+	}
+	return x - statusGap + statusButtonPad, buttons
+}
+
+// statusButtonAt returns the action of the button under pt, or 0 for none
+func statusButtonAt(buttons []statusButton, pt image.Point) StatusAction {
+	for _, b := range buttons {
+		if pt.In(b.rect) {
+			return b.action
+		}
+	}
+	return 0
+}
+
+// statusPosition places a widget of the given size centered above region,
+// below it when there is no room above, or inside its top edge when neither
+// fits. Without a region the widget sits at the top center of screen.
+func statusPosition(region image.Rectangle, size image.Point, screen image.Rectangle) image.Point {
+	if region.Empty() {
+		return image.Pt(screen.Min.X+(screen.Dx()-size.X)/2, screen.Min.Y+statusMargin)
+	}
+
+	x := region.Min.X + (region.Dx()-size.X)/2
+	x = clampInt(x, screen.Min.X, maxInt(screen.Min.X, screen.Max.X-size.X))
+
+	outside := statusBorder + statusMargin
+	y := region.Min.Y - outside - size.Y
+	if y < screen.Min.Y {
+		y = region.Max.Y + outside
+		if y+size.Y > screen.Max.Y {
+			y = region.Min.Y + statusMargin
+		}
+	}
+	return image.Pt(x, y)
+}
+
+// textWidth returns the width in pixels of text in the bitmap font
+func textWidth(text string) int {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return 0
+	}
+	return n*glyphAdvance - 1
+}
+
+// formatElapsed formats d as m:ss, or h:mm:ss from one hour
+func formatElapsed(d time.Duration) string {
+	total := int(d / time.Second)
+	h, m, sec := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
+
+// formatSize formats a byte count with binary units
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit && exp < 2; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMG"[exp])
+}
+
+// premultiplied converts c to the premultiplied BGRA layered windows expect
+func premultiplied(c color.RGBA) uint32 {
+	a := uint32(c.A)
+	return a<<24 | (uint32(c.R)*a/255)<<16 | (uint32(c.G)*a/255)<<8 | uint32(c.B)*a/255
+}
+
+func primaryScreen() image.Rectangle {
+	w, _, _ := procGetSystemMetrics.Call(SM_CXSCREEN)
+	h, _, _ := procGetSystemMetrics.Call(SM_CYSCREEN)
+	return image.Rect(0, 0, int(w), int(h))
+}
+
+func virtualScreen() image.Rectangle {
+	x, _, _ := procGetSystemMetrics.Call(SM_XVIRTUALSCREEN)
+	y, _, _ := procGetSystemMetrics.Call(SM_YVIRTUALSCREEN)
+	w, _, _ := procGetSystemMetrics.Call(SM_CXVIRTUALSCREEN)
+	h, _, _ := procGetSystemMetrics.Call(SM_CYVIRTUALSCREEN)
+	left, top := int(int32(x)), int(int32(y))
+	return image.Rect(left, top, left+int(int32(w)), top+int(int32(h)))
+}
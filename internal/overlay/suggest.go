@@ -0,0 +1,232 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+)
+
+// Panel detection works on a grid of panelCell pixel cells. A cell is uniform
+// when its sampled pixels differ by at most panelTolerance per channel, and
+// neighbouring uniform cells of a similar color form one panel
+const (
+	panelCell      = 16
+	panelSample    = 4 // Pixel step when sampling a cell
+	panelTolerance = 6
+	minPanelCells  = 6   // Smallest panel side in cells
+	minPanelFill   = 0.7 // Share of a panel's bounds its cells must cover
+	maxPanelCover  = 0.9 // Larger panels are the desktop or a maximized background
+	minSuggestion  = 48  // Smallest suggested region side in pixels
+	edgeSlack      = 4   // Suggestions closer than this on every edge are duplicates
+	edgeMatch      = 0.5 // Share of an edge line that must match the panel color to extend it
+)
+
+// detectPanels finds large areas of near uniform color in img within area,
+// such as sidebars, toolbars and dialog backgrounds. Edges are refined from
+// the cell grid to the pixel
+func detectPanels(img *image.RGBA, area image.Rectangle) []image.Rectangle {
+	area = area.Intersect(img.Bounds())
+	cols, rows := area.Dx()/panelCell, area.Dy()/panelCell
+	if cols < minPanelCells || rows < minPanelCells {
+		return nil
+	}
+
+	uniform := make([]bool, cols*rows)
+	colors := make([]color.RGBA, cols*rows)
+	for cy := 0; cy < rows; cy++ {
+		for cx := 0; cx < cols; cx++ {
+			cell := image.Rect(cx*panelCell, cy*panelCell, (cx+1)*panelCell, (cy+1)*panelCell).Add(area.Min)
+			colors[cy*cols+cx], uniform[cy*cols+cx] = cellColor(img, cell)
+		}
+	}
+
+	var panels []image.Rectangle
+	seen := make([]bool, cols*rows)
+	for start := range uniform {
+		if !uniform[start] || seen[start] {
+			continue
+		}
+		c := colors[start]
+		bounds := image.Rect(start%cols, start/cols, start%cols+1, start/cols+1)
+		count := 0
+		queue := []int{start}
+		seen[start] = true
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			count++
+			x, y := i%cols, i/cols
+			bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			for _, n := range [4]image.Point{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n.X < 0 || n.Y < 0 || n.X >= cols || n.Y >= rows {
+					continue
+				}
+				j := n.Y*cols + n.X
+				if uniform[j] && !seen[j] && similar(colors[j], c) {
+					seen[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+
+		if bounds.Dx() < minPanelCells || bounds.Dy() < minPanelCells {
+			continue
+		}
+		if float64(count) < minPanelFill*float64(bounds.Dx()*bounds.Dy()) {
+			continue
+		}
+		r := image.Rect(bounds.Min.X*panelCell, bounds.Min.Y*panelCell, bounds.Max.X*panelCell, bounds.Max.Y*panelCell).Add(area.Min)
+		r = refinePanel(img, r, c, area)
+		if float64(r.Dx()*r.Dy()) >= maxPanelCover*float64(area.Dx()*area.Dy()) {
+			continue
+		}
+		panels = append(panels, r)
+	}
+	return panels
+}
+
+// cellColor returns the color of a cell and whether it is uniform
+func cellColor(img *image.RGBA, cell image.Rectangle) (color.RGBA, bool) {
+	first := img.RGBAAt(cell.Min.X, cell.Min.Y)
+	for y := cell.Min.Y; y < cell.Max.Y; y += panelSample {
+		for x := cell.Min.X; x < cell.Max.X; x += panelSample {
+			if !similar(img.RGBAAt(x, y), first) {
+				return first, false
+			}
+		}
+	}
+	return first, true
+}
+
+func similar(a, b color.RGBA) bool {
+	return absInt(int(a.R)-int(b.R)) <= panelTolerance &&
+		absInt(int(a.G)-int(b.G)) <= panelTolerance &&
+		absInt(int(a.B)-int(b.B)) <= panelTolerance
+}
+
+// refinePanel moves each edge of a grid aligned panel outward while the line
+// beyond it is still mostly the panel color, up to one cell
+func refinePanel(img *image.RGBA, r image.Rectangle, c color.RGBA, area image.Rectangle) image.Rectangle {
+	matches := func(x0, y0, x1, y1 int) bool {
+		total, hits := 0, 0
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				total++
+				if similar(img.RGBAAt(x, y), c) {
+					hits++
+				}
+			}
+		}
+		return total > 0 && float64(hits) >= edgeMatch*float64(total)
+	}
+	for i := 0; i < panelCell && r.Min.X > area.Min.X && matches(r.Min.X-1, r.Min.Y, r.Min.X, r.Max.Y); i++ {
+		r.Min.X--
+	}
+	for i := 0; i < panelCell && r.Max.X < area.Max.X && matches(r.Max.X, r.Min.Y, r.Max.X+1, r.Max.Y); i++ {
+		r.Max.X++
+	}
+	for i := 0; i < panelCell && r.Min.Y > area.Min.Y && matches(r.Min.X, r.Min.Y-1, r.Max.X, r.Min.Y); i++ {
+		r.Min.Y--
+	}
+	for i := 0; i < panelCell && r.Max.Y < area.Max.Y && matches(r.Min.X, r.Max.Y, r.Max.X, r.Max.Y+1); i++ {
+		r.Max.Y++
+	}
+	return r
+}
+
+// suggestRegions merges window bounds (topmost first) and detected panels into
+// the regions offered for one click selection, all in overlay coordinates.
+// Windows hidden behind a higher one, slivers and duplicates are dropped
+func suggestRegions(windows, panels []image.Rectangle, area image.Rectangle) []image.Rectangle {
+	var out []image.Rectangle
+	var above []image.Rectangle
+	for _, w := range windows {
+		r := w.Intersect(area)
+		hidden := false
+		for _, a := range above {
+			if r.In(a) {
+				hidden = true
+				break
+			}
+		}
+		above = append(above, r)
+		if !hidden {
+			out = appendSuggestion(out, r)
+		}
+	}
+	for _, p := range panels {
+		out = appendSuggestion(out, p.Intersect(area))
+	}
+	return out
+}
+
+func appendSuggestion(rects []image.Rectangle, r image.Rectangle) []image.Rectangle {
+	if r.Dx() < minSuggestion || r.Dy() < minSuggestion {
+		return rects
+	}
+	for _, s := range rects {
+		if absInt(s.Min.X-r.Min.X) <= edgeSlack && absInt(s.Min.Y-r.Min.Y) <= edgeSlack &&
+			absInt(s.Max.X-r.Max.X) <= edgeSlack && absInt(s.Max.Y-r.Max.Y) <= edgeSlack {
+			return rects
+		}
+	}
+	return append(rects, r)
+}
+
+// suggestionAt returns the smallest suggested region containing p, clipped to
+// area, or an empty rectangle
+func suggestionAt(rects []image.Rectangle, p image.Point, area image.Rectangle) image.Rectangle {
+	var best image.Rectangle
+	for _, r := range rects {
+		r = r.Intersect(area)
+		if !p.In(r) || r.Dx() < minSuggestion || r.Dy() < minSuggestion {
+			continue
+		}
+		if best.Empty() || r.Dx()*r.Dy() < best.Dx()*best.Dy() {
+			best = r
+		}
+	}
+	return best
+}
+
+// detectSuggestions computes the suggestions for the screenshot being shown
+func (m *Manager) detectSuggestions() []image.Rectangle {
+	m.mu.Lock()
+	windowList := m.windowList
+	m.mu.Unlock()
+
+	area := image.Rect(0, 0, m.bounds.Dx(), m.bounds.Dy())
+	var windows []image.Rectangle
+	for _, w := range windowList {
+		windows = append(windows, w.Bounds.Sub(m.bounds.Min))
+	}
+	return suggestRegions(windows, detectPanels(m.screenshot, area), area)
+}
+
+// updateSuggested highlights the suggestion under the cursor and reports
+// whether it changed
+func (m *Manager) updateSuggested(p image.Point) bool {
+	r := suggestionAt(m.suggestions, p, m.selectableRect())
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r == m.suggested {
+		return false
+	}
+	m.suggested = r
+	return true
+}
+
+// useSuggestion selects the suggested region under p, as a click without a
+// drag does. It reports false when there is none
+func (m *Manager) useSuggestion(p image.Point) bool {
+	r := suggestionAt(m.suggestions, p, m.selectableRect())
+	if r.Empty() {
+		return false
+	}
+	m.mu.Lock()
+	if m.selection.Mode == ModeFreehand {
+		m.selection.Mode = ModeRectangle
+	}
+	m.mu.Unlock()
+	m.finish(r, nil)
+	return true
+}
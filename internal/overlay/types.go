@@ -1,5 +1,12 @@
 package overlay
 
+import (
+	"image"
+	"image/color"
+
+	"winshot/internal/imaging"
+)
+
 // Window style constants
 const (
 	WS_POPUP         = 0x80000000
@@ -17,13 +24,27 @@ const (
 	WM_PAINT       = 0x000F
 	WM_KEYDOWN     = 0x0100
 	WM_KEYUP       = 0x0101
+	WM_SYSKEYDOWN  = 0x0104
+	WM_SYSKEYUP    = 0x0105
 	WM_LBUTTONDOWN = 0x0201
 	WM_LBUTTONUP   = 0x0202
+	WM_RBUTTONUP   = 0x0205
 	WM_MOUSEMOVE   = 0x0200
 	WM_NCHITTEST   = 0x0084
 	WM_SETCURSOR   = 0x0020
+	WM_MOUSEWHEEL  = 0x020A
+	MK_SHIFT       = 0x0004
+	MK_CONTROL     = 0x0008
+	WHEEL_DELTA    = 120
 	VK_ESCAPE      = 0x1B
+	VK_TAB         = 0x09
+	VK_RETURN      = 0x0D
 	VK_SPACE       = 0x20
+	VK_SHIFT       = 0x10
+	VK_MENU        = 0x12 // Alt
+	VK_CONTROL     = 0x11
+	VK_C           = 0x43 // Copy the selection geometry, Shift+C as --region
+	VK_S           = 0x53 // Toggle snapping to window and element edges
 	HTCLIENT       = 1
 	PM_REMOVE      = 0x0001
 )
@@ -77,12 +98,97 @@ type BITMAPINFO struct {
 	BmiColors [1]uint32
 }
 
+// Mode is the shape of a region selection
+type Mode int
+
+const (
+	ModeRectangle Mode = iota
+	ModeEllipse
+	ModeFreehand
+	ModeWindow // Click a window to select its bounds
+	ModeColor  // Click a pixel to pick its color
+)
+
 // Selection represents the user's region selection
 type Selection struct {
+	Mode           Mode
+	Path           []image.Point // Freehand outline in overlay coordinates
 	StartX, StartY int
 	EndX, EndY     int
+	AnchorX        int // Initial click point, the center when Alt is held
+	AnchorY        int
+	CursorX        int // Last pointer position
+	CursorY        int
+	Inflate        int // Pixels added on every side with the mouse wheel
 	IsDragging     bool
-	SpaceHeld      bool // For repositioning selection
+	Adjusting      bool        // Released and waiting for Enter: edges resize and the inside moves
+	SpaceHeld      bool        // For repositioning selection
+	Recent         int         // 1-based position of a recent region shown with Tab, 0 otherwise
+	RecentCount    int         // Recent regions available to cycle through
+	Suggested      bool        // A suggested region is outlined under the cursor
+	ClientPos      image.Point // Selection corner relative to the client area of the window it started on
+	HasClientPos   bool        // ClientPos is known and shown
+	ScreenCursor   image.Point // Cursor in screen coordinates, labelled on the loupe
+	Snap           bool        // Corners snap to window and UI element edges
+}
+
+// Rect returns the normalized selection grown by Inflate on all sides, or the
+// bounds of the outline for freehand selections
+func (s Selection) Rect() image.Rectangle {
+	if s.Mode == ModeFreehand {
+		return pathBounds(s.Path)
+	}
+	return image.Rect(s.StartX, s.StartY, s.EndX, s.EndY).Inset(-s.Inflate)
+}
+
+// pathBounds returns the smallest rectangle containing every point of path
+func pathBounds(path []image.Point) image.Rectangle {
+	if len(path) == 0 {
+		return image.Rectangle{}
+	}
+	r := image.Rectangle{Min: path[0], Max: path[0]}
+	for _, p := range path[1:] {
+		r.Min.X = minInt(r.Min.X, p.X)
+		r.Min.Y = minInt(r.Min.Y, p.Y)
+		r.Max.X = maxInt(r.Max.X, p.X)
+		r.Max.Y = maxInt(r.Max.Y, p.Y)
+	}
+	return r
+}
+
+// Mask returns the selection shape as an alpha mask the size of the
+// selection multiplied by scale, opaque inside the shape and transparent
+// around it. Rectangle and window selections have no mask and return nil
+func (r Result) Mask(scale float64) *image.Alpha {
+	b := image.Rect(0, 0, int(float64(r.Width)*scale), int(float64(r.Height)*scale))
+	var spans func(y int) [][2]int
+	switch r.Mode {
+	case ModeEllipse:
+		spans = func(y int) [][2]int {
+			if x0, x1, ok := imaging.EllipseSpan(b, y); ok {
+				return [][2]int{{x0, x1}}
+			}
+			return nil
+		}
+	case ModeFreehand:
+		path := make([]image.Point, len(r.Path))
+		for i, p := range r.Path {
+			path[i] = image.Pt(int(float64(p.X)*scale), int(float64(p.Y)*scale))
+		}
+		spans = func(y int) [][2]int { return imaging.PolygonSpans(path, y) }
+	default:
+		return nil
+	}
+
+	mask := image.NewAlpha(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for _, span := range spans(y) {
+			for x := max(span[0], b.Min.X); x < min(span[1], b.Max.X); x++ {
+				mask.Pix[mask.PixOffset(x, y)] = 0xff
+			}
+		}
+	}
+	return mask
 }
 
 // Result represents the final selection result
@@ -90,6 +196,12 @@ type Result struct {
 	X, Y          int
 	Width, Height int
 	Cancelled     bool
+	Mode          Mode
+	Path          []image.Point // Freehand outline relative to X, Y
+	IncludeCursor bool          // Draw the mouse cursor into the capture
+	Window        uintptr       // Window or control clicked in window mode, 0 if unknown
+	Color         color.RGBA    // Pixel clicked in color mode, at X, Y
+	Copy          string        // Selection geometry to put on the clipboard instead of capturing, with Cancelled set
 }
 
 // WNDCLASSEXW for RegisterClassExW
@@ -140,6 +252,10 @@ const (
 	HWND_TOPMOST  = ^uintptr(0) // -1
 	IDC_CROSS     = 32515
 	IDC_SIZEALL   = 32646
+	IDC_SIZENWSE  = 32642
+	IDC_SIZENESW  = 32643
+	IDC_SIZEWE    = 32644
+	IDC_SIZENS    = 32645
 	SWP_NOSIZE    = 0x0001
 	SWP_NOMOVE    = 0x0002
 	SWP_SHOWWINDOW = 0x0040
@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// HostModule is the import module name of the functions the host offers
+// uploader modules.
+const HostModule = "winshot"
+
+// maxResponseSize bounds the response body kept for a module to read.
+const maxResponseSize = 1 << 20
+
+// uploadCall is the state of one Upload call, reached by the host functions
+// through the call's context.
+type uploadCall struct {
+	plugin   *Plugin
+	response []byte
+	url      string
+	err      error // Why the last request was refused or failed
+}
+
+type uploadCallKey struct{}
+
+// instantiateHost adds the host functions to the runtime.
+func instantiateHost(ctx context.Context, r wazero.Runtime) error {
+	_, err := r.NewHostModuleBuilder(HostModule).
+		NewFunctionBuilder().WithFunc(hostHTTPRequest).Export("http_request").
+		NewFunctionBuilder().WithFunc(hostHTTPResponse).Export("http_response").
+		NewFunctionBuilder().WithFunc(hostSetURL).Export("set_url").
+		Instantiate(ctx)
+	return err
+}
+
+// hostHTTPRequest sends the request whose head is at headPtr, with the body
+// at bodyPtr, and returns the response status code, or -1 when the request
+// was refused or failed.
+func hostHTTPRequest(ctx context.Context, m api.Module, headPtr, headLen, bodyPtr, bodyLen uint32) int32 {
+	call, _ := ctx.Value(uploadCallKey{}).(*uploadCall)
+	if call == nil {
+		return -1 // Filters have no network
+	}
+	call.response = nil
+	head, ok := m.Memory().Read(headPtr, headLen)
+	if !ok {
+		call.err = fmt.Errorf("request head out of bounds")
+		return -1
+	}
+	body, ok := m.Memory().Read(bodyPtr, bodyLen)
+	if !ok {
+		call.err = fmt.Errorf("request body out of bounds")
+		return -1
+	}
+	req, err := call.plugin.newRequest(ctx, string(head), bytes.Clone(body))
+	if err != nil {
+		call.err = err
+		return -1
+	}
+	resp, err := call.plugin.client.Do(req)
+	if err != nil {
+		call.err = err
+		return -1
+	}
+	defer resp.Body.Close()
+	call.response, err = io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		call.err = err
+		return -1
+	}
+	call.err = nil
+	return int32(resp.StatusCode)
+}
+
+// hostHTTPResponse copies up to size bytes of the last response body to ptr
+// and returns how many were copied.
+func hostHTTPResponse(ctx context.Context, m api.Module, ptr, size uint32) int32 {
+	call, _ := ctx.Value(uploadCallKey{}).(*uploadCall)
+	if call == nil {
+		return 0
+	}
+	n := min(int(size), len(call.response))
+	if !m.Memory().Write(ptr, call.response[:n]) {
+		return 0
+	}
+	return int32(n)
+}
+
+// hostSetURL reports the link to the uploaded file.
+func hostSetURL(ctx context.Context, m api.Module, ptr, size uint32) {
+	call, _ := ctx.Value(uploadCallKey{}).(*uploadCall)
+	if call == nil {
+		return
+	}
+	if b, ok := m.Memory().Read(ptr, size); ok {
+		call.url = strings.TrimSpace(string(b))
+	}
+}
+
+// newRequest parses a request head: "METHOD URL" on the first line and a
+// "Name: value" header on each following one. Only HTTPS requests to the
+// plugin's upload hosts are allowed.
+func (p *Plugin) newRequest(ctx context.Context, head string, body []byte) (*http.Request, error) {
+	lines := bufio.NewScanner(strings.NewReader(head))
+	if !lines.Scan() {
+		return nil, fmt.Errorf("empty request")
+	}
+	method, rawURL, ok := strings.Cut(strings.TrimSpace(lines.Text()), " ")
+	if !ok {
+		return nil, fmt.Errorf("request line %q is not METHOD URL", lines.Text())
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	if !p.allowed(u) {
+		return nil, fmt.Errorf("request to %s refused: not an upload host of the plugin", u.Redacted())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("header line %q has no colon", line)
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return req, nil
+}
+
+// allowed reports whether u is an HTTPS URL on one of the upload hosts.
+func (p *Plugin) allowed(u *url.URL) bool {
+	if u.Scheme != "https" {
+		return false
+	}
+	for _, host := range p.hosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,382 @@
+// Package plugin runs third-party capture filters and uploaders as
+// WebAssembly modules. Modules run in a sandbox with no filesystem, network,
+// clock or environment access, under a memory limit and a per-call timeout,
+// so a shared plugin can transform captures but cannot touch anything else
+// on the machine.
+//
+// A module implements ABI version 1 by exporting:
+//
+//	memory                                   the linear memory
+//	winshot_alloc(size i32) -> ptr i32       reserve size bytes, 0 on failure
+//	winshot_filter(ptr, width, height i32) -> status i32
+//	winshot_upload(ptr, size, name_ptr, name_len i32) -> status i32
+//	winshot_abi_version() -> i32             optional, must return 1
+//
+// and at least one of winshot_filter and winshot_upload.
+//
+// For a filter the host allocates width*height*4 bytes, copies the capture
+// in as RGBA rows (stride width*4), and calls winshot_filter, which
+// transforms the pixels in place and returns 0 on success. Any other status
+// fails the filter.
+//
+// For an upload the host allocates one buffer holding the encoded file
+// followed by its name and calls winshot_upload, which returns 0 once it has
+// reported the link. An uploader reaches the network only through these
+// imports from the "winshot" module, and only over HTTPS to the hosts the
+// user listed for it:
+//
+//	http_request(head_ptr, head_len, body_ptr, body_len i32) -> status i32
+//	http_response(ptr, size i32) -> length i32
+//	set_url(ptr, len i32)
+//
+// The request head is "METHOD URL" followed by one "Name: value" header per
+// line. http_request returns the response status code, or -1 when the
+// request was refused or failed; http_response copies the start of the
+// response body. Modules built for WASI may import wasi_snapshot_preview1;
+// its calls see an empty environment.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"winshot/internal/imaging"
+)
+
+// ABIVersion is the module ABI this host implements.
+const ABIVersion = 1
+
+// ErrClosed is returned by calls made after Close.
+var ErrClosed = errors.New("plugin is closed")
+
+const (
+	// DefaultTimeout bounds one filter call when Options.Timeout is zero.
+	DefaultTimeout = 5 * time.Second
+	// DefaultUploadTimeout bounds one upload call when Options.UploadTimeout
+	// is zero, on top of the time its data takes at minUploadRate.
+	DefaultUploadTimeout = time.Minute
+	// DefaultMemoryMB caps a module's memory when Options.MemoryMB is zero.
+	DefaultMemoryMB = 256
+
+	wasmPageSize  = 64 * 1024
+	minUploadRate = 256 * 1024 // Bytes per second a slow uplink still manages
+)
+
+// Options limits what a plugin may use.
+type Options struct {
+	Timeout       time.Duration // DefaultTimeout when zero
+	UploadTimeout time.Duration // DefaultUploadTimeout when zero
+	MemoryMB      int           // DefaultMemoryMB when zero
+	UploadHosts   []string      // Hosts an uploader may send HTTPS requests to, none when empty
+}
+
+// Plugin is a compiled module. Every call runs in a fresh instance, so no
+// state carries over from one capture to the next.
+type Plugin struct {
+	name          string
+	timeout       time.Duration
+	uploadTimeout time.Duration
+	hosts         []string
+	client        *http.Client
+	runtime       wazero.Runtime
+	compiled      wazero.CompiledModule
+
+	mu     sync.RWMutex // Held for reading by calls, so Close waits for them
+	closed bool
+}
+
+// Load reads and compiles the module at path.
+func Load(path string, opts Options) (*Plugin, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return Compile(name, wasm, opts)
+}
+
+// Compile compiles a module and checks that it implements the filter ABI.
+func Compile(name string, wasm []byte, opts Options) (*Plugin, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.UploadTimeout <= 0 {
+		opts.UploadTimeout = DefaultUploadTimeout
+	}
+	if opts.MemoryMB <= 0 {
+		opts.MemoryMB = DefaultMemoryMB
+	}
+
+	ctx := context.Background()
+	cfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(opts.MemoryMB * 1024 * 1024 / wasmPageSize)).
+		WithCloseOnContextDone(true)
+	r := wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	compiled, err := r.CompileModule(ctx, wasm)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	if err := checkABI(compiled); err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	imports := make(map[string]bool)
+	for _, imp := range compiled.ImportedFunctions() {
+		mod, _, _ := imp.Import()
+		imports[mod] = true
+	}
+	if imports[wasi_snapshot_preview1.ModuleName] {
+		wasi_snapshot_preview1.MustInstantiate(ctx, r)
+	}
+	if imports[HostModule] {
+		if err := instantiateHost(ctx, r); err != nil {
+			r.Close(ctx)
+			return nil, fmt.Errorf("plugin %s: %w", name, err)
+		}
+	}
+
+	p := &Plugin{name: name, timeout: opts.Timeout, uploadTimeout: opts.UploadTimeout, hosts: opts.UploadHosts, runtime: r, compiled: compiled}
+	p.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !p.allowed(req.URL) {
+				return fmt.Errorf("redirect to %s refused: not an upload host of the plugin", req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+	return p, nil
+}
+
+// checkABI verifies the module exports what the host calls.
+func checkABI(m wazero.CompiledModule) error {
+	if _, ok := m.ExportedMemories()["memory"]; !ok {
+		return errors.New("module does not export its memory")
+	}
+	sigs := map[string][2]int{ // Parameter and result counts
+		"winshot_alloc":  {1, 1},
+		"winshot_filter": {3, 1},
+		"winshot_upload": {4, 1},
+	}
+	funcs := m.ExportedFunctions()
+	if _, ok := funcs["winshot_alloc"]; !ok {
+		return errors.New("module does not export winshot_alloc")
+	}
+	if funcs["winshot_filter"] == nil && funcs["winshot_upload"] == nil {
+		return errors.New("module exports neither winshot_filter nor winshot_upload")
+	}
+	for name, sig := range sigs {
+		fn, ok := funcs[name]
+		if !ok {
+			continue
+		}
+		if len(fn.ParamTypes()) != sig[0] || len(fn.ResultTypes()) != sig[1] {
+			return fmt.Errorf("%s has the wrong signature", name)
+		}
+	}
+	return nil
+}
+
+// Name returns the plugin's name, the module file name without extension.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// IsFilter reports whether the module exports winshot_filter.
+func (p *Plugin) IsFilter() bool {
+	_, ok := p.compiled.ExportedFunctions()["winshot_filter"]
+	return ok
+}
+
+// IsUploader reports whether the module exports winshot_upload.
+func (p *Plugin) IsUploader() bool {
+	_, ok := p.compiled.ExportedFunctions()["winshot_upload"]
+	return ok
+}
+
+// Filter returns the plugin as a capture filter.
+func (p *Plugin) Filter() imaging.Filter {
+	return p.Apply
+}
+
+// instantiate starts a fresh instance of the module and checks its ABI
+// version, within the call's timeout. The caller closes it.
+func (p *Plugin) instantiate(ctx context.Context, timeout time.Duration) (api.Module, error) {
+	mod, err := p.runtime.InstantiateModule(ctx, p.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, p.callError(ctx, err, timeout)
+	}
+
+	if fn := mod.ExportedFunction("winshot_abi_version"); fn != nil {
+		res, err := fn.Call(ctx)
+		if err != nil {
+			mod.Close(context.Background())
+			return nil, p.callError(ctx, err, timeout)
+		}
+		if v := api.DecodeI32(res[0]); v != ABIVersion {
+			mod.Close(context.Background())
+			return nil, fmt.Errorf("plugin %s implements ABI version %d, want %d", p.name, v, ABIVersion)
+		}
+	}
+	return mod, nil
+}
+
+// Apply runs the module on img and returns the transformed copy.
+func (p *Plugin) Apply(img *image.RGBA) (*image.RGBA, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, fmt.Errorf("plugin %s: %w", p.name, ErrClosed)
+	}
+	if !p.IsFilter() {
+		return nil, fmt.Errorf("plugin %s is not a filter", p.name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	mod, err := p.instantiate(ctx, p.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer mod.Close(context.Background())
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	size := w * h * 4
+	pixels := packPixels(img)
+
+	res, err := mod.ExportedFunction("winshot_alloc").Call(ctx, api.EncodeI32(int32(size)))
+	if err != nil {
+		return nil, p.callError(ctx, err, p.timeout)
+	}
+	ptr := api.DecodeU32(res[0])
+	if ptr == 0 || !mod.Memory().Write(ptr, pixels) {
+		return nil, fmt.Errorf("plugin %s could not allocate %d bytes", p.name, size)
+	}
+
+	res, err = mod.ExportedFunction("winshot_filter").Call(ctx, uint64(ptr), uint64(w), uint64(h))
+	if err != nil {
+		return nil, p.callError(ctx, err, p.timeout)
+	}
+	if status := api.DecodeI32(res[0]); status != 0 {
+		return nil, fmt.Errorf("plugin %s failed with status %d", p.name, status)
+	}
+
+	out, ok := mod.Memory().Read(ptr, uint32(size))
+	if !ok {
+		return nil, fmt.Errorf("plugin %s freed its output", p.name)
+	}
+	result := image.NewRGBA(image.Rect(0, 0, w, h))
+	copy(result.Pix, out)
+	return result, nil
+}
+
+// Upload runs the module's uploader on data and returns the link it
+// reported.
+func (p *Plugin) Upload(ctx context.Context, data []byte, filename string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return "", fmt.Errorf("plugin %s: %w", p.name, ErrClosed)
+	}
+	if !p.IsUploader() {
+		return "", fmt.Errorf("plugin %s is not an uploader", p.name)
+	}
+
+	timeout := p.uploadDeadline(len(data))
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	call := &uploadCall{plugin: p}
+	ctx = context.WithValue(ctx, uploadCallKey{}, call)
+
+	mod, err := p.instantiate(ctx, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer mod.Close(context.Background())
+
+	size := len(data) + len(filename)
+	res, err := mod.ExportedFunction("winshot_alloc").Call(ctx, api.EncodeI32(int32(size)))
+	if err != nil {
+		return "", p.callError(ctx, err, timeout)
+	}
+	ptr := api.DecodeU32(res[0])
+	if ptr == 0 || !mod.Memory().Write(ptr, data) || !mod.Memory().WriteString(ptr+uint32(len(data)), filename) {
+		return "", fmt.Errorf("plugin %s could not allocate %d bytes", p.name, size)
+	}
+
+	res, err = mod.ExportedFunction("winshot_upload").Call(ctx,
+		uint64(ptr), uint64(len(data)), uint64(ptr+uint32(len(data))), uint64(len(filename)))
+	if err != nil {
+		return "", p.callError(ctx, err, timeout)
+	}
+	if status := api.DecodeI32(res[0]); status != 0 {
+		if call.err != nil {
+			return "", fmt.Errorf("plugin %s failed with status %d: %w", p.name, status, call.err)
+		}
+		return "", fmt.Errorf("plugin %s failed with status %d", p.name, status)
+	}
+	if call.url == "" {
+		return "", fmt.Errorf("plugin %s reported no link", p.name)
+	}
+	return call.url, nil
+}
+
+// uploadDeadline returns how long an upload of n bytes may take: the upload
+// timeout plus the time the data takes to send over a slow uplink.
+func (p *Plugin) uploadDeadline(n int) time.Duration {
+	return p.uploadTimeout + time.Duration(n)*time.Second/minUploadRate
+}
+
+// callError reports a module trap, or a timeout when the call's deadline cut
+// it short.
+func (p *Plugin) callError(ctx context.Context, err error, timeout time.Duration) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("plugin %s timed out after %v", p.name, timeout)
+	}
+	return fmt.Errorf("plugin %s: %w", p.name, err)
+}
+
+// packPixels copies img into tightly packed rows, dropping any stride
+// padding a sub-image carries.
+func packPixels(img *image.RGBA) []byte {
+	b := img.Bounds()
+	row := b.Dx() * 4
+	if img.Stride == row && b.Min == (image.Point{}) {
+		return img.Pix[:row*b.Dy()]
+	}
+	pixels := make([]byte, 0, row*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		off := img.PixOffset(b.Min.X, y)
+		pixels = append(pixels, img.Pix[off:off+row]...)
+	}
+	return pixels
+}
+
+// Close waits for calls in flight to finish and releases the compiled
+// module. Later calls fail with ErrClosed.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	return p.runtime.Close(context.Background())
+}
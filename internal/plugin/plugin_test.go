@@ -0,0 +1,311 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Filter function bodies, hand-assembled. Each takes (ptr, width, height)
+// and returns a status.
+var (
+	// Inverts RGB and keeps alpha: xors every pixel word with 0x00FFFFFF
+	invertBody = []byte{
+		0x01, 0x01, 0x7f, // One i32 local: the end pointer
+		0x20, 0x00, 0x20, 0x01, 0x20, 0x02, 0x6c, 0x41, 0x04, 0x6c, 0x6a, 0x21, 0x03, // end = ptr + w*h*4
+		0x02, 0x40, 0x03, 0x40, // block, loop
+		0x20, 0x00, 0x20, 0x03, 0x4f, 0x0d, 0x01, // br_if ptr >= end
+		0x20, 0x00, 0x20, 0x00, 0x28, 0x02, 0x00, 0x41, 0xff, 0xff, 0xff, 0x07, 0x73, 0x36, 0x02, 0x00, // *ptr ^= 0xFFFFFF
+		0x20, 0x00, 0x41, 0x04, 0x6a, 0x21, 0x00, // ptr += 4
+		0x0c, 0x00, 0x0b, 0x0b, // br loop, end, end
+		0x41, 0x00, 0x0b, // return 0
+	}
+	failBody = []byte{0x00, 0x41, 0x07, 0x0b}                               // return 7
+	hangBody = []byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b} // loop forever
+)
+
+// filterModule assembles a module exporting memory, an allocator that
+// always returns offset 1024, and a filter with the given body.
+func filterModule(filterBody []byte) []byte {
+	section := func(id byte, content ...byte) []byte {
+		return append([]byte{id, byte(len(content))}, content...)
+	}
+	name := func(s string) []byte { return append([]byte{byte(len(s))}, s...) }
+	body := func(code []byte) []byte { return append([]byte{byte(len(code))}, code...) }
+
+	var exports []byte
+	exports = append(exports, 0x03)
+	exports = append(append(exports, name("memory")...), 0x02, 0x00)
+	exports = append(append(exports, name("winshot_alloc")...), 0x00, 0x00)
+	exports = append(append(exports, name("winshot_filter")...), 0x00, 0x01)
+
+	code := []byte{0x02}
+	code = append(code, body([]byte{0x00, 0x41, 0x80, 0x08, 0x0b})...) // return 1024
+	code = append(code, body(filterBody)...)
+
+	m := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	m = append(m, section(0x01, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x03, 0x7f, 0x7f, 0x7f, 0x01, 0x7f)...)
+	m = append(m, section(0x03, 0x02, 0x00, 0x01)...)
+	m = append(m, section(0x05, 0x01, 0x00, 0x01)...) // One page
+	m = append(m, section(0x07, exports...)...)
+	m = append(m, section(0x0a, code...)...)
+	return m
+}
+
+func TestPlugin_Apply(t *testing.T) {
+	p, err := Compile("invert", filterModule(invertBody), Options{})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	defer p.Close()
+
+	src := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	for i := range src.Pix {
+		src.Pix[i] = 200
+	}
+	// A sub-image exercises repacking rows with a wider stride
+	sub := src.SubImage(image.Rect(1, 1, 4, 3)).(*image.RGBA)
+
+	for _, img := range []*image.RGBA{src, sub} {
+		out, err := p.Filter()(img)
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		if got, want := out.Bounds().Size(), img.Bounds().Size(); got != want {
+			t.Errorf("output size = %v, want %v", got, want)
+		}
+		if got := out.RGBAAt(0, 0); got != (color.RGBA{55, 55, 55, 200}) {
+			t.Errorf("pixel = %v, want {55 55 55 200}", got)
+		}
+	}
+	if src.Pix[0] != 200 {
+		t.Error("Apply() should not modify its input")
+	}
+}
+
+func TestPlugin_Errors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+
+	tests := []struct {
+		name    string
+		body    []byte
+		opts    Options
+		wantErr string
+	}{
+		{"fail", failBody, Options{}, "status 7"},
+		{"hang", hangBody, Options{Timeout: 100 * time.Millisecond}, "timed out"},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.name, filterModule(tt.body), tt.opts)
+		if err != nil {
+			t.Fatalf("Compile(%s) error = %v", tt.name, err)
+		}
+		_, err = p.Apply(img)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s Apply() error = %v, want it to mention %q", tt.name, err, tt.wantErr)
+		}
+		p.Close()
+	}
+
+	// 200x200 pixels do not fit the module's single page of memory
+	p, _ := Compile("invert", filterModule(invertBody), Options{})
+	defer p.Close()
+	if _, err := p.Apply(image.NewRGBA(image.Rect(0, 0, 200, 200))); err == nil {
+		t.Error("Apply() should fail when the module cannot hold the image")
+	}
+}
+
+func TestCompile_ABI(t *testing.T) {
+	if _, err := Compile("junk", []byte("not wasm"), Options{}); err == nil {
+		t.Error("Compile() should reject bytes that are not a module")
+	}
+
+	// A valid module that exports nothing
+	empty := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	if _, err := Compile("empty", empty, Options{}); err == nil || !strings.Contains(err.Error(), "memory") {
+		t.Errorf("Compile() error = %v, want a missing export", err)
+	}
+}
+
+// leb encodes n as a signed LEB128 number, as i32.const takes it.
+func leb(n int) []byte {
+	var b []byte
+	for {
+		c := byte(n & 0x7f)
+		n >>= 7
+		if (n == 0 && c&0x40 == 0) || (n == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// uploaderModule assembles a module whose winshot_upload sends head with the
+// file as body, and on a 200 response reports the start of the response
+// body as the link. Memory holds the file at 1024, head at 2048 and the
+// response at 3072.
+func uploaderModule(head string) []byte {
+	vec := func(items ...[]byte) []byte {
+		out := []byte{byte(len(items))}
+		for _, it := range items {
+			out = append(out, it...)
+		}
+		return out
+	}
+	section := func(id byte, content []byte) []byte {
+		return append(append([]byte{id}, leb(len(content))...), content...)
+	}
+	name := func(s string) []byte { return append([]byte{byte(len(s))}, s...) }
+	body := func(code ...[]byte) []byte {
+		var b []byte
+		for _, c := range code {
+			b = append(b, c...)
+		}
+		return append(leb(len(b)), b...)
+	}
+	i32 := func(n int) []byte { return append([]byte{0x41}, leb(n)...) }
+
+	types := vec(
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},                   // 0: alloc
+		[]byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f}, // 1: http_request, winshot_upload
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f},             // 2: http_response
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},                   // 3: set_url
+	)
+	imports := vec(
+		append(append(name(HostModule), name("http_request")...), 0x00, 0x01),
+		append(append(name(HostModule), name("http_response")...), 0x00, 0x02),
+		append(append(name(HostModule), name("set_url")...), 0x00, 0x03),
+	)
+	exports := vec(
+		append(name("memory"), 0x02, 0x00),
+		append(name("winshot_alloc"), 0x00, 0x03),
+		append(name("winshot_upload"), 0x00, 0x04),
+	)
+	code := vec(
+		body([]byte{0x00}, i32(1024), []byte{0x0b}),
+		body([]byte{0x00},
+			i32(2048), i32(len(head)), []byte{0x20, 0x00, 0x20, 0x01, 0x10, 0x00}, // http_request(head, file)
+			i32(200), []byte{0x47, 0x04, 0x40}, i32(1), []byte{0x0f, 0x0b}, // if status != 200 return 1
+			i32(3072), i32(3072), i32(256), []byte{0x10, 0x01, 0x10, 0x02}, // set_url(3072, http_response(3072, 256))
+			i32(0), []byte{0x0b}),
+	)
+	data := vec(append(append(append([]byte{0x00}, i32(2048)...), 0x0b), name(head)...))
+
+	m := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	m = append(m, section(0x01, types)...)
+	m = append(m, section(0x02, imports)...)
+	m = append(m, section(0x03, vec([]byte{0x00}, []byte{0x01}))...)
+	m = append(m, section(0x05, []byte{0x01, 0x00, 0x01})...) // One page
+	m = append(m, section(0x07, exports)...)
+	m = append(m, section(0x0a, code)...)
+	m = append(m, section(0x0b, data)...)
+	return m
+}
+
+func TestPlugin_Upload(t *testing.T) {
+	var got []byte
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "https://img.example/abc\n")
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	tests := []struct {
+		name    string
+		head    string
+		hosts   []string
+		wantErr string
+	}{
+		{"ok", "PUT " + srv.URL + "/up\nAuthorization: Bearer k\n", []string{host}, ""},
+		{"rejected", "PUT " + srv.URL + "/up\n", []string{host}, "status 1"},
+		{"not listed", "PUT " + srv.URL + "/up\n", nil, "refused"},
+		{"plain http", "PUT http://" + host + "/up\n", []string{host}, "refused"},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.name, uploaderModule(tt.head), Options{UploadHosts: tt.hosts})
+		if err != nil {
+			t.Fatalf("Compile(%s) error = %v", tt.name, err)
+		}
+		if p.IsFilter() || !p.IsUploader() {
+			t.Errorf("%s: IsFilter = %v, IsUploader = %v", tt.name, p.IsFilter(), p.IsUploader())
+		}
+		p.client.Transport = srv.Client().Transport
+		got = nil
+
+		url, err := p.Upload(context.Background(), []byte("PNGDATA"), "shot.png")
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s Upload() error = %v", tt.name, err)
+		case tt.wantErr == "" && url != "https://img.example/abc":
+			t.Errorf("%s Upload() = %q, want the link from the response", tt.name, url)
+		case tt.wantErr == "" && string(got) != "PNGDATA":
+			t.Errorf("%s request body = %q, want the file", tt.name, got)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s Upload() error = %v, want it to mention %q", tt.name, err, tt.wantErr)
+		}
+		p.Close()
+	}
+}
+
+func TestPlugin_UploadTimeout(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "https://img.example/abc")
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	// Uploads don't run under the filter timeout, which is far too short
+	// for a network transfer
+	p, err := Compile("slow", uploaderModule("PUT "+srv.URL+"/up\n"), Options{Timeout: time.Nanosecond, UploadHosts: []string{host}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.client.Transport = srv.Client().Transport
+	if _, err := p.Upload(context.Background(), []byte("PNGDATA"), "shot.png"); err != nil {
+		t.Errorf("Upload() error = %v", err)
+	}
+
+	if got := p.uploadDeadline(0); got != DefaultUploadTimeout {
+		t.Errorf("uploadDeadline(0) = %v, want %v", got, DefaultUploadTimeout)
+	}
+	if got, want := p.uploadDeadline(10<<20), DefaultUploadTimeout+40*time.Second; got != want {
+		t.Errorf("uploadDeadline(10MB) = %v, want %v", got, want)
+	}
+}
+
+func TestPlugin_CloseWaitsForCalls(t *testing.T) {
+	p, err := Compile("hang", filterModule(hangBody), Options{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	begin := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Apply(image.NewRGBA(image.Rect(0, 0, 2, 2)))
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	p.Close()
+	if elapsed := time.Since(begin); elapsed < 150*time.Millisecond {
+		t.Errorf("Close() returned after %v, before the call in flight timed out", elapsed)
+	}
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Apply() error = %v, want the call to run to its timeout", err)
+	}
+	if _, err := p.Apply(image.NewRGBA(image.Rect(0, 0, 2, 2))); !errors.Is(err, ErrClosed) {
+		t.Errorf("Apply() after Close error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,181 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Export writes the rendered project as "png", "svg" or "pdf".
+func (p *Project) Export(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "png":
+		img, err := p.Render()
+		if err != nil {
+			return err
+		}
+		return png.Encode(w, img)
+	case "svg":
+		return p.RenderSVG(w)
+	case "pdf":
+		return p.RenderPDF(w)
+	default:
+		return fmt.Errorf("unsupported format %q, want png, svg or pdf", format)
+	}
+}
+
+// RenderSVG writes the project as SVG: the capture embedded as PNG with the
+// annotations on top as paths and text, so they stay sharp at any zoom and
+// text stays selectable.
+func (p *Project) RenderSVG(w io.Writer) error {
+	src, err := p.LoadImage()
+	if err != nil {
+		return err
+	}
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, src); err != nil {
+		return err
+	}
+	size := src.Bounds().Size()
+	crop := p.cropRect(size)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d">`+"\n",
+		crop.Dx(), crop.Dy(), crop.Min.X, crop.Min.Y, crop.Dx(), crop.Dy())
+	fmt.Fprintf(bw, `<image width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+		size.X, size.Y, base64.StdEncoding.EncodeToString(pngData.Bytes()))
+
+	shapes, labels := p.layout(float64(size.X), float64(size.Y))
+	for _, s := range shapes {
+		var d strings.Builder
+		for _, c := range s.contours {
+			for i, pt := range c {
+				if i == 0 {
+					d.WriteString("M")
+				} else {
+					d.WriteString(" L")
+				}
+				d.WriteString(num(pt.X) + " " + num(pt.Y))
+			}
+			if len(c) > 0 {
+				d.WriteString(" Z ")
+			}
+		}
+		fmt.Fprintf(bw, `<path d="%s" fill-rule="evenodd" %s/>`+"\n", strings.TrimSpace(d.String()), svgFill(s.color))
+	}
+
+	for _, l := range labels {
+		box := l.width
+		for _, line := range l.lines {
+			box = math.Max(box, textWidth(l, line))
+		}
+		x, anchor := l.x, "start"
+		switch l.align {
+		case "center":
+			x, anchor = l.x+box/2, "middle"
+		case "right":
+			x, anchor = l.x+box, "end"
+		}
+		family := l.family
+		if family == "" {
+			family = "Arial" // The editor's default
+		}
+		weight, style := "normal", "normal"
+		if l.bold {
+			weight = "bold"
+		}
+		if l.italic {
+			style = "italic"
+		}
+		for i, line := range l.lines {
+			fmt.Fprintf(bw, `<text x="%s" y="%s" font-family="%s, sans-serif" font-size="%s" font-weight="%s" font-style="%s" text-anchor="%s" dominant-baseline="middle" xml:space="preserve" %s>`,
+				num(x), num(l.top+l.size*(float64(i)+0.5)), escapeAttr(family), num(l.size), weight, style, anchor, svgFill(l.color))
+			xml.EscapeText(bw, []byte(line))
+			bw.WriteString("</text>\n")
+		}
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// svgFill returns fill attributes for c.
+func svgFill(c color.NRGBA) string {
+	attr := fmt.Sprintf(`fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 255 {
+		attr += ` fill-opacity="` + num(float64(c.A)/255) + `"`
+	}
+	return attr
+}
+
+// num formats a coordinate with at most two decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// RenderPDF writes the rendered project as a single-page PDF, one point per
+// pixel. The page holds the rendered image, so annotations are not vector
+// in PDF output. Transparent areas are flattened onto white.
+func (p *Project) RenderPDF(w io.Writer) error {
+	img, err := p.Render()
+	if err != nil {
+		return err
+	}
+	b := img.Bounds()
+
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			white := 255 - c.A // Premultiplied, so adding white flattens
+			row = append(row, c.R+white, c.G+white, c.B+white)
+		}
+		zw.Write(row)
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", b.Dx(), b.Dy())
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>", b.Dx(), b.Dy()),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			b.Dx(), b.Dy(), pixels.Len(), pixels.Bytes()),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err = w.Write(out.Bytes())
+	return err
+}
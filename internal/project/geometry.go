@@ -0,0 +1,363 @@
+package project
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"winshot/internal/imaging"
+)
+
+// Arrow proportions, matching the editor's tapered arrows.
+const (
+	arrowTailFactor = 0.5 // Tail half-width = strokeWidth * 0.5
+	arrowBodyFactor = 2   // Half-width where the body meets the head
+	arrowHeadLength = 6   // Head length = strokeWidth * 6
+	arrowHeadWidth  = 4   // Head half-width = strokeWidth * 4
+)
+
+const (
+	defaultFontSize   = 48
+	defaultDimOpacity = 0.7
+	numberBaseRadius  = 18
+	curveSegments     = 30  // Samples along curved arrows
+	circleSegments    = 64  // Samples around ellipses and rounded corners
+	highlighterAlpha  = 102 // Highlighter strokes are at most 40% opaque
+	miterLimit        = 2   // Longest join offset, in half stroke widths
+)
+
+// shape is a filled outline made of one or more contours. Contours wound
+// against the first one cut holes, which is how strokes and the spotlight
+// dimming are drawn.
+type shape struct {
+	contours [][]Point
+	color    color.NRGBA
+}
+
+// label is text drawn on top of the shapes. Each line is centered vertically
+// on a band of size pixels starting at top, as the editor does.
+type label struct {
+	lines  []string
+	x, top float64
+	width  float64 // Box to align within, 0 for the width of the widest line
+	size   float64
+	bold   bool
+	italic bool
+	align  string
+	family string // Font for SVG output, which leaves rendering to the viewer
+	color  color.NRGBA
+}
+
+// layout turns the annotations into shapes and labels in drawing order.
+// Spotlights dim everything outside their areas and are drawn last, over
+// the other annotations, like in the editor.
+func (p *Project) layout(width, height float64) ([]shape, []label) {
+	var shapes []shape
+	var labels []label
+	var spots []Annotation
+
+	for _, a := range p.Annotations {
+		stroke, hasStroke := parseColor(a.Stroke)
+		switch a.Type {
+		case "rectangle", "ellipse":
+			outline := func(grow float64) []Point {
+				if a.Type == "ellipse" {
+					return ellipse(a.X+a.Width/2, a.Y+a.Height/2, a.Width/2+grow, a.Height/2+grow)
+				}
+				radius := a.CornerRadius
+				if radius > 0 {
+					radius += grow // Keep the stroke evenly thick around the corner
+				}
+				return roundedRect(a.X-grow, a.Y-grow, a.Width+2*grow, a.Height+2*grow, radius)
+			}
+			if fill, ok := parseColor(a.Fill); ok {
+				shapes = append(shapes, shape{[][]Point{outline(0)}, fill})
+			}
+			if hasStroke && a.StrokeWidth > 0 {
+				half := a.StrokeWidth / 2
+				shapes = append(shapes, shape{[][]Point{outline(half), reversed(outline(-half))}, stroke})
+			}
+		case "arrow":
+			if pts := a.arrowOutline(); hasStroke && len(pts) > 2 {
+				shapes = append(shapes, shape{[][]Point{pts}, stroke})
+			}
+		case "line":
+			x1, y1, x2, y2 := a.endpoints()
+			if pts := thickLine(a.X+x1, a.Y+y1, a.X+x2, a.Y+y2, a.StrokeWidth); hasStroke && pts != nil {
+				shapes = append(shapes, shape{[][]Point{pts}, stroke})
+			}
+		case "pen", "highlighter":
+			if !hasStroke {
+				continue
+			}
+			if a.Type == "highlighter" && stroke.A > highlighterAlpha {
+				stroke.A = highlighterAlpha
+			}
+			if pts := a.strokeOutline(); len(pts) > 2 {
+				shapes = append(shapes, shape{[][]Point{pts}, stroke})
+			}
+		case "number":
+			n := a.Number
+			if n == 0 {
+				n = 1
+			}
+			text := strconv.Itoa(n)
+			radius := float64(numberBaseRadius + (len(text)-1)*6)
+			size := radius * 1.2
+			if hasStroke {
+				shapes = append(shapes, shape{[][]Point{ellipse(a.X, a.Y, radius, radius)}, stroke})
+			}
+			labels = append(labels, label{
+				lines: []string{text}, x: a.X - radius, top: a.Y - size/2, width: 2 * radius,
+				size: size, bold: true, align: "center", color: color.NRGBA{255, 255, 255, 255},
+			})
+		case "text":
+			if !hasStroke {
+				continue
+			}
+			text := a.Text
+			if text == "" {
+				text = "Text"
+			}
+			size := a.FontSize
+			if size <= 0 {
+				size = defaultFontSize
+			}
+			labels = append(labels, label{
+				lines: strings.Split(text, "\n"), x: a.X, top: a.Y, size: size,
+				bold: strings.Contains(a.FontStyle, "bold"), italic: strings.Contains(a.FontStyle, "italic"),
+				align: a.TextAlign, family: a.FontFamily, color: stroke,
+			})
+		case "spotlight":
+			spots = append(spots, a)
+		}
+	}
+
+	if len(spots) > 0 {
+		// The editor uses the first spotlight's opacity for all of them
+		opacity := defaultDimOpacity
+		if spots[0].DimOpacity != nil {
+			opacity = *spots[0].DimOpacity
+		}
+		contours := [][]Point{{{0, 0}, {width, 0}, {width, height}, {0, height}}}
+		for _, s := range spots {
+			contours = append(contours, []Point{{s.X, s.Y}, {s.X, s.Y + s.Height}, {s.X + s.Width, s.Y + s.Height}, {s.X + s.Width, s.Y}})
+		}
+		shapes = append(shapes, shape{contours, color.NRGBA{A: uint8(math.Round(opacity * 255))}})
+	}
+	return shapes, labels
+}
+
+// endpoints returns the start and end of an arrow or line relative to its
+// position.
+func (a Annotation) endpoints() (x1, y1, x2, y2 float64) {
+	if len(a.Points) >= 4 {
+		return a.Points[0], a.Points[1], a.Points[2], a.Points[3]
+	}
+	return 0, 0, a.Width, a.Height
+}
+
+// arrowOutline returns the tapered arrow polygon in image coordinates.
+func (a Annotation) arrowOutline() []Point {
+	x1, y1, x2, y2 := a.endpoints()
+	x1, y1, x2, y2 = x1+a.X, y1+a.Y, x2+a.X, y2+a.Y
+	length := math.Hypot(x2-x1, y2-y1)
+	if length < 1 {
+		return nil
+	}
+	sw := a.StrokeWidth
+	tail, body := sw*arrowTailFactor, sw*arrowBodyFactor
+	headLen := math.Min(sw*arrowHeadLength, length*0.6)
+	headWidth := sw * arrowHeadWidth
+
+	if !a.Curved {
+		ux, uy := (x2-x1)/length, (y2-y1)/length
+		px, py := -uy, ux
+		jx, jy := x2-ux*headLen, y2-uy*headLen
+		return []Point{
+			{x1 + px*tail, y1 + py*tail},
+			{jx + px*body, jy + py*body},
+			{jx + px*headWidth, jy + py*headWidth},
+			{x2, y2},
+			{jx - px*headWidth, jy - py*headWidth},
+			{jx - px*body, jy - py*body},
+			{x1 - px*tail, y1 - py*tail},
+		}
+	}
+
+	// The control point defaults to 20% of the length off the midpoint
+	cx, cy := (x1+x2)/2, (y1+y2)/2
+	if a.CurveOffset != nil {
+		cx, cy = cx+a.CurveOffset.X, cy+a.CurveOffset.Y
+	} else {
+		cx, cy = cx-(y2-y1)*0.2, cy+(x2-x1)*0.2
+	}
+	point := func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*x1 + 2*mt*t*cx + t*t*x2, mt*mt*y1 + 2*mt*t*cy + t*t*y2
+	}
+	normal := func(t float64) (float64, float64) {
+		mt := 1 - t
+		dx, dy := 2*mt*(cx-x1)+2*t*(x2-cx), 2*mt*(cy-y1)+2*t*(y2-cy)
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			l = 1
+		}
+		return -dy / l, dx / l
+	}
+
+	tHead := math.Max(0.5, 1-headLen/length)
+	var left, right []Point
+	for i := 0; i <= curveSegments; i++ {
+		t := tHead * float64(i) / curveSegments
+		x, y := point(t)
+		nx, ny := normal(t)
+		w := tail + (body-tail)*(t/tHead)
+		left = append(left, Point{x + nx*w, y + ny*w})
+		right = append(right, Point{x - nx*w, y - ny*w})
+	}
+	jx, jy := point(tHead)
+	nx, ny := normal(1)
+	pts := append(left, Point{jx + nx*headWidth, jy + ny*headWidth}, Point{x2, y2}, Point{jx - nx*headWidth, jy - ny*headWidth})
+	return append(pts, reversed(right)...)
+}
+
+// strokeOutline returns the outline of a freehand stroke in image
+// coordinates. Points holds x,y pairs relative to the annotation's position.
+func (a Annotation) strokeOutline() []Point {
+	var pts []Point
+	for i := 0; i+1 < len(a.Points); i += 2 {
+		pt := Point{a.X + a.Points[i], a.Y + a.Points[i+1]}
+		if len(pts) == 0 || pt != pts[len(pts)-1] {
+			pts = append(pts, pt)
+		}
+	}
+	if len(pts) == 1 {
+		// A click without a drag leaves a dot
+		h := a.StrokeWidth / 2
+		return ellipse(pts[0].X, pts[0].Y, h, h)
+	}
+	return polyline(pts, a.StrokeWidth)
+}
+
+// polyline returns the outline of a line through pts with the given width
+// and mitered joins. The outline can cross itself at sharp turns, which
+// fills solid since shapes use the nonzero rule.
+func polyline(pts []Point, width float64) []Point {
+	if len(pts) < 2 || width <= 0 {
+		return nil
+	}
+	h := width / 2
+	normal := func(p, q Point) Point {
+		l := math.Hypot(q.X-p.X, q.Y-p.Y)
+		return Point{-(q.Y - p.Y) / l, (q.X - p.X) / l}
+	}
+	left := make([]Point, len(pts))
+	right := make([]Point, len(pts))
+	for i, p := range pts {
+		var n Point
+		switch i {
+		case 0:
+			n = normal(p, pts[1])
+		case len(pts) - 1:
+			n = normal(pts[i-1], p)
+		default:
+			n1, n2 := normal(pts[i-1], p), normal(p, pts[i+1])
+			n = Point{n1.X + n2.X, n1.Y + n2.Y}
+			l := math.Hypot(n.X, n.Y)
+			if l < 1e-9 {
+				n = n1 // The stroke doubles back on itself
+				break
+			}
+			// Scale so the offset edges stay h away from both segments
+			scale := math.Min(2/l, miterLimit)
+			n = Point{n.X / l * scale, n.Y / l * scale}
+		}
+		left[i] = Point{p.X + n.X*h, p.Y + n.Y*h}
+		right[i] = Point{p.X - n.X*h, p.Y - n.Y*h}
+	}
+	return append(left, reversed(right)...)
+}
+
+// thickLine returns a line of the given width with square caps, which reach
+// half the width past each end.
+func thickLine(x1, y1, x2, y2, width float64) []Point {
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 || width <= 0 {
+		return nil
+	}
+	h := width / 2
+	ux, uy := (x2-x1)/length*h, (y2-y1)/length*h
+	return []Point{
+		{x1 - ux - uy, y1 - uy + ux},
+		{x2 + ux - uy, y2 + uy + ux},
+		{x2 + ux + uy, y2 + uy - ux},
+		{x1 - ux + uy, y1 - uy - ux},
+	}
+}
+
+// ellipse returns an ellipse outline, clockwise on screen.
+func ellipse(cx, cy, rx, ry float64) []Point {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	pts := make([]Point, circleSegments)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / circleSegments
+		pts[i] = Point{cx + rx*math.Cos(a), cy + ry*math.Sin(a)}
+	}
+	return pts
+}
+
+// roundedRect returns a rectangle outline, clockwise on screen, with corners
+// rounded by r.
+func roundedRect(x, y, w, h, r float64) []Point {
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	r = math.Max(0, math.Min(r, math.Min(w, h)/2))
+	if r == 0 {
+		return []Point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+	}
+	centers := []Point{{x + w - r, y + r}, {x + w - r, y + h - r}, {x + r, y + h - r}, {x + r, y + r}}
+	var pts []Point
+	steps := circleSegments / 4
+	for c, center := range centers {
+		start := -math.Pi/2 + float64(c)*math.Pi/2
+		for i := 0; i <= steps; i++ {
+			a := start + math.Pi/2*float64(i)/float64(steps)
+			pts = append(pts, Point{center.X + r*math.Cos(a), center.Y + r*math.Sin(a)})
+		}
+	}
+	return pts
+}
+
+func reversed(pts []Point) []Point {
+	out := make([]Point, len(pts))
+	for i, p := range pts {
+		out[len(pts)-1-i] = p
+	}
+	return out
+}
+
+// parseColor parses the editor's colors, reporting false for missing or
+// transparent ones.
+func parseColor(s string) (color.NRGBA, bool) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "", "transparent", "none":
+		return color.NRGBA{}, false
+	case "white":
+		return color.NRGBA{255, 255, 255, 255}, true
+	case "black":
+		return color.NRGBA{0, 0, 0, 255}, true
+	}
+	if len(s) == 4 && s[0] == '#' {
+		s = string([]byte{'#', s[1], s[1], s[2], s[2], s[3], s[3]}) // #rgb
+	}
+	c, err := imaging.ParseColor(s)
+	if err != nil || c.A == 0 {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA(c), true // ParseColor leaves the alpha unapplied
+}
@@ -0,0 +1,169 @@
+// Package project reads and writes .wshot annotation projects: a capture
+// plus the editor's annotations, kept apart so the annotations stay editable.
+// Projects render to PNG, SVG or PDF without the editor, which lets
+// documentation builds regenerate annotated screenshots from source.
+package project
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+)
+
+// Extension is the file extension of annotation projects.
+const Extension = ".wshot"
+
+// Version is the project format version written by Save.
+const Version = 1
+
+// Project is a capture and the annotations drawn on it. The capture is either
+// embedded as base64 or referenced by a path relative to the project file, so
+// a documentation repo can keep screenshots and projects side by side.
+type Project struct {
+	Version     int          `json:"version"`
+	Image       string       `json:"image,omitempty"`     // Base64 PNG or JPEG
+	ImagePath   string       `json:"imagePath,omitempty"` // Used when Image is empty
+	Crop        *Rect        `json:"crop,omitempty"`      // Applied after the annotations are drawn
+	Annotations []Annotation `json:"annotations"`
+
+	dir string // Folder the project was loaded from, for ImagePath
+}
+
+// Rect is an area in image pixels.
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Point is a position or offset in image pixels.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Annotation mirrors the editor's annotation type, with coordinates relative
+// to the top-left corner of the capture.
+type Annotation struct {
+	ID           string    `json:"id,omitempty"`
+	Type         string    `json:"type"` // rectangle, ellipse, arrow, line, pen, highlighter, text, spotlight or number
+	X            float64   `json:"x"`
+	Y            float64   `json:"y"`
+	Width        float64   `json:"width"`
+	Height       float64   `json:"height"`
+	Stroke       string    `json:"stroke,omitempty"`
+	StrokeWidth  float64   `json:"strokeWidth"`
+	Fill         string    `json:"fill,omitempty"`
+	CornerRadius float64   `json:"cornerRadius,omitempty"`
+	Points       []float64 `json:"points,omitempty"`      // Arrows and lines: x1,y1,x2,y2 relative to X,Y. Pen and highlighter: x,y pairs
+	Curved       bool      `json:"curved,omitempty"`      // Arrows only
+	CurveOffset  *Point    `json:"curveOffset,omitempty"` // Control point offset from the midpoint
+	Text         string    `json:"text,omitempty"`
+	FontSize     float64   `json:"fontSize,omitempty"`
+	FontFamily   string    `json:"fontFamily,omitempty"`
+	FontStyle    string    `json:"fontStyle,omitempty"` // normal, bold, italic or "bold italic"
+	TextAlign    string    `json:"textAlign,omitempty"` // left, center or right
+	DimOpacity   *float64  `json:"dimOpacity,omitempty"`
+	Number       int       `json:"number,omitempty"`
+}
+
+// Load reads a project file.
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	p.dir = filepath.Dir(path)
+	return p, nil
+}
+
+// Parse decodes a project. Relative image paths resolve against the working
+// directory.
+func Parse(data []byte) (*Project, error) {
+	var p Project
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid project: %w", err)
+	}
+	if p.Version < 1 || p.Version > Version {
+		return nil, fmt.Errorf("unsupported project version %d", p.Version)
+	}
+	if p.Image == "" && p.ImagePath == "" {
+		return nil, errors.New("project has no image")
+	}
+	for i, a := range p.Annotations {
+		if !knownTypes[a.Type] {
+			return nil, fmt.Errorf("annotation %d: unknown type %q", i+1, a.Type)
+		}
+	}
+	return &p, nil
+}
+
+var knownTypes = map[string]bool{
+	"rectangle": true, "ellipse": true, "arrow": true, "line": true,
+	"pen": true, "highlighter": true, "text": true, "spotlight": true,
+	"number": true,
+}
+
+// Save writes the project as indented JSON, which diffs well under version
+// control.
+func (p *Project) Save(path string) error {
+	if p.Version == 0 {
+		p.Version = Version
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadImage decodes the capture the annotations are drawn on.
+func (p *Project) LoadImage() (image.Image, error) {
+	var data []byte
+	var err error
+	if p.Image != "" {
+		data, err = base64.StdEncoding.DecodeString(p.Image)
+		if err != nil {
+			return nil, fmt.Errorf("invalid embedded image: %w", err)
+		}
+	} else {
+		path := p.ImagePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(p.dir, path)
+		}
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// cropRect returns the output area within an image of the given size.
+func (p *Project) cropRect(size image.Point) image.Rectangle {
+	bounds := image.Rectangle{Max: size}
+	if p.Crop == nil {
+		return bounds
+	}
+	c := p.Crop
+	r := image.Rect(int(c.X), int(c.Y), int(c.X+c.Width), int(c.Y+c.Height)).Intersect(bounds)
+	if r.Empty() {
+		return bounds
+	}
+	return r
+}
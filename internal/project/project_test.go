@@ -0,0 +1,240 @@
+package project
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// whitePNG returns a base64 PNG of a white image.
+func whitePNG(t *testing.T, w, h int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"valid", `{"version":1,"image":"x","annotations":[{"type":"arrow"}]}`, false},
+		{"image path", `{"version":1,"imagePath":"shot.png","annotations":[]}`, false},
+		{"pen strokes", `{"version":1,"image":"x","annotations":[{"type":"pen"},{"type":"highlighter"}]}`, false},
+		{"not json", `{`, true},
+		{"missing version", `{"image":"x"}`, true},
+		{"future version", `{"version":2,"image":"x"}`, true},
+		{"no image", `{"version":1,"annotations":[]}`, true},
+		{"unknown type", `{"version":1,"image":"x","annotations":[{"type":"star"}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveLoadImagePath(t *testing.T) {
+	dir := t.TempDir()
+	data, _ := base64.StdEncoding.DecodeString(whitePNG(t, 4, 3))
+	if err := os.WriteFile(filepath.Join(dir, "shot.png"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Project{ImagePath: "shot.png", Annotations: []Annotation{{Type: "rectangle", Width: 2, Height: 2}}}
+	path := filepath.Join(dir, "doc"+Extension)
+	if err := p.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The image path resolves against the project, not the working directory
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := loaded.LoadImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(4, 3) {
+		t.Errorf("image size = %v, want 4x3", got)
+	}
+	if len(loaded.Annotations) != 1 || loaded.Version != Version {
+		t.Errorf("loaded = %+v, want the saved project", loaded)
+	}
+}
+
+func TestRender(t *testing.T) {
+	dim := 0.5
+	p := &Project{
+		Version: 1,
+		Image:   whitePNG(t, 100, 60),
+		Annotations: []Annotation{
+			{Type: "rectangle", X: 10, Y: 10, Width: 20, Height: 20, Stroke: "#ff0000", StrokeWidth: 4, Fill: "#0000ff"},
+			{Type: "arrow", X: 40, Y: 30, Points: []float64{0, 0, 50, 0}, Stroke: "#00ff00", StrokeWidth: 3},
+			{Type: "spotlight", X: 0, Y: 0, Width: 60, Height: 60, DimOpacity: &dim},
+		},
+	}
+	img, err := p.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pt   image.Point
+		want color.RGBA
+	}{
+		{"fill", image.Pt(20, 20), color.RGBA{0, 0, 255, 255}},
+		{"stroke", image.Pt(10, 20), color.RGBA{255, 0, 0, 255}},
+		{"arrow body", image.Pt(50, 30), color.RGBA{0, 255, 0, 255}},
+		{"untouched", image.Pt(50, 50), color.RGBA{255, 255, 255, 255}},
+		{"dimmed", image.Pt(80, 50), color.RGBA{128, 128, 128, 255}},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.pt.X, tt.pt.Y); !near(got, tt.want) {
+			t.Errorf("%s pixel %v = %v, want %v", tt.name, tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestRenderStrokes(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 100, 60))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+	p := &Project{Annotations: []Annotation{
+		{Type: "pen", X: 10, Y: 10, Points: []float64{0, 0, 40, 0, 40, 30}, Stroke: "#000000", StrokeWidth: 6},
+		// Crosses itself at 75,30 without getting darker there
+		{Type: "highlighter", X: 60, Y: 20, Points: []float64{0, 0, 30, 20, 30, 0, 0, 20}, Stroke: "#ffff00", StrokeWidth: 8},
+	}}
+	img, err := p.RenderOn(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pt   image.Point
+		want color.RGBA
+	}{
+		{"pen", image.Pt(30, 10), color.RGBA{0, 0, 0, 255}},
+		{"pen corner", image.Pt(50, 10), color.RGBA{0, 0, 0, 255}},
+		{"pen end", image.Pt(50, 35), color.RGBA{0, 0, 0, 255}},
+		{"highlighter", image.Pt(90, 30), color.RGBA{255, 255, 153, 255}},
+		{"highlighter crossing", image.Pt(75, 30), color.RGBA{255, 255, 153, 255}},
+		{"untouched", image.Pt(30, 40), color.RGBA{255, 255, 255, 255}},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.pt.X, tt.pt.Y); !near(got, tt.want) {
+			t.Errorf("%s pixel %v = %v, want %v", tt.name, tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestRenderCrop(t *testing.T) {
+	p := &Project{Version: 1, Image: whitePNG(t, 100, 60), Crop: &Rect{X: 10, Y: 5, Width: 30, Height: 20}}
+	img, err := p.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(30, 20) {
+		t.Errorf("size = %v, want 30x20", got)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	p := &Project{
+		Version: 1,
+		Image:   whitePNG(t, 200, 80),
+		Annotations: []Annotation{
+			{Type: "text", X: 10, Y: 10, Text: "Build", FontSize: 40, Stroke: "#000000"},
+			{Type: "number", X: 170, Y: 40, Number: 3, Stroke: "#ff0000"},
+		},
+	}
+	img, err := p.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dark := countDark(img, image.Rect(10, 10, 120, 50)); dark == 0 {
+		t.Error("text drew no pixels")
+	}
+	if got := img.RGBAAt(170, 25); !near(got, color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("number badge pixel = %v, want red", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	p := &Project{
+		Version: 1,
+		Image:   whitePNG(t, 40, 30),
+		Annotations: []Annotation{
+			{Type: "ellipse", X: 5, Y: 5, Width: 20, Height: 10, Stroke: "#ff000080", StrokeWidth: 2},
+			{Type: "text", X: 2, Y: 2, Text: "a < b", Stroke: "#000", TextAlign: "center"},
+		},
+	}
+
+	tests := []struct {
+		format string
+		prefix string
+		want   []string
+	}{
+		{"png", "\x89PNG", nil},
+		{"svg", "<svg", []string{`fill="#ff0000" fill-opacity="0.5"`, "a &lt; b", `text-anchor="middle"`, "data:image/png;base64,"}},
+		{"pdf", "%PDF-1.4", []string{"/Width 40 /Height 30", "%%EOF"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := p.Export(&buf, tt.format); err != nil {
+			t.Errorf("Export(%s) error = %v", tt.format, err)
+			continue
+		}
+		out := buf.String()
+		if !strings.HasPrefix(out, tt.prefix) {
+			t.Errorf("Export(%s) starts with %q, want %q", tt.format, out[:min(len(out), 10)], tt.prefix)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(out, w) {
+				t.Errorf("Export(%s) is missing %q", tt.format, w)
+			}
+		}
+	}
+
+	if err := p.Export(&bytes.Buffer{}, "gif"); err == nil {
+		t.Error("Export(gif) error = nil, want unsupported format")
+	}
+}
+
+func near(a, b color.RGBA) bool {
+	d := func(x, y uint8) bool { return int(x)-int(y) <= 2 && int(y)-int(x) <= 2 }
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+}
+
+func countDark(img *image.RGBA, r image.Rectangle) int {
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.RGBAAt(x, y).R < 128 {
+				n++
+			}
+		}
+	}
+	return n
+}
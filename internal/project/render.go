@@ -0,0 +1,125 @@
+package project
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+	"winshot/internal/imaging"
+)
+
+// Render draws the annotations onto the capture and applies the crop.
+// Text uses the Go fonts, which are bundled, so output is the same on every
+// machine but differs slightly from the editor's system fonts.
+func (p *Project) Render() (*image.RGBA, error) {
+	src, err := p.LoadImage()
+	if err != nil {
+		return nil, err
+	}
+	return p.RenderOn(src)
+}
+
+// RenderOn is Render with the capture already decoded, ignoring the
+// project's own image.
+func (p *Project) RenderOn(src image.Image) (*image.RGBA, error) {
+	b := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+
+	shapes, labels := p.layout(float64(b.Dx()), float64(b.Dy()))
+	for _, s := range shapes {
+		fillShape(img, s)
+	}
+	for _, l := range labels {
+		if err := drawText(img, l); err != nil {
+			return nil, err
+		}
+	}
+
+	crop := p.cropRect(b.Size())
+	if crop == img.Bounds() {
+		return img, nil
+	}
+	out := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(out, out.Bounds(), img, crop.Min, draw.Src)
+	return out, nil
+}
+
+// fillShape fills all contours of s at once with anti-aliasing.
+func fillShape(img *image.RGBA, s shape) {
+	size := img.Bounds().Size()
+	z := vector.NewRasterizer(size.X, size.Y)
+	for _, c := range s.contours {
+		if len(c) < 3 {
+			continue
+		}
+		z.MoveTo(float32(c[0].X), float32(c[0].Y))
+		for _, pt := range c[1:] {
+			z.LineTo(float32(pt.X), float32(pt.Y))
+		}
+		z.ClosePath()
+	}
+	z.DrawOp = draw.Over
+	z.Draw(img, img.Bounds(), image.NewUniform(s.color), image.Point{})
+}
+
+// drawText draws the lines of l. Each line's em box is centered on its band,
+// which matches the middle baseline the editor lays text out with.
+func drawText(img *image.RGBA, l label) error {
+	face, err := imaging.FontFace(l.bold, l.italic, l.size)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	widths := make([]float64, len(l.lines))
+	box := l.width
+	for i, line := range l.lines {
+		widths[i] = fixedToFloat(font.MeasureString(face, line))
+		box = math.Max(box, widths[i])
+	}
+
+	m := face.Metrics()
+	ascent, descent := fixedToFloat(m.Ascent), fixedToFloat(m.Descent)
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(l.color), Face: face}
+	for i, line := range l.lines {
+		x := l.x + alignOffset(l.align, box, widths[i])
+		middle := l.top + l.size*(float64(i)+0.5)
+		d.Dot = fixed.Point26_6{
+			X: fixed.Int26_6(math.Round(x * 64)),
+			Y: fixed.Int26_6(math.Round((middle + (ascent-descent)/2) * 64)),
+		}
+		d.DrawString(line)
+	}
+	return nil
+}
+
+// alignOffset returns how far a line of the given width moves right to take
+// its place in a box.
+func alignOffset(align string, box, width float64) float64 {
+	switch align {
+	case "center":
+		return (box - width) / 2
+	case "right":
+		return box - width
+	}
+	return 0
+}
+
+func fixedToFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
+
+// textWidth measures a line as Render draws it. SVG output uses it to align
+// text, since the viewer's font is unknown.
+func textWidth(l label, line string) float64 {
+	face, err := imaging.FontFace(l.bold, l.italic, l.size)
+	if err != nil {
+		return 0
+	}
+	defer face.Close()
+	return fixedToFloat(font.MeasureString(face, line))
+}
@@ -0,0 +1,87 @@
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"time"
+)
+
+// CaptureSchemaVersion is the capture record format version.
+const CaptureSchemaVersion = 1
+
+// WindowInfo describes the window a capture was taken of.
+type WindowInfo struct {
+	Title  string `json:"title"`
+	App    string `json:"app,omitempty"` // Executable name
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Capture is a machine-readable record of one capture, printed by
+// "winshot capture --json" and sent with pipeline events, so build pipelines
+// and bots can use results without parsing log lines.
+type Capture struct {
+	Schema     int          `json:"schema"`
+	Path       string       `json:"path,omitempty"`
+	URL        string       `json:"url,omitempty"`
+	Format     string       `json:"format,omitempty"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	Size       int64        `json:"size,omitempty"`   // Encoded bytes
+	SHA256     string       `json:"sha256,omitempty"` // Of the encoded bytes
+	Monitor    *MonitorInfo `json:"monitor,omitempty"`
+	Window     *WindowInfo  `json:"window,omitempty"`
+	Frame      *FrameClock  `json:"frame,omitempty"`
+	CapturedAt string       `json:"capturedAt"` // RFC3339 with milliseconds
+	DurationMs int64        `json:"durationMs"` // From capture start to delivery
+}
+
+// CaptureOptions carries what is known about a capture besides its data.
+type CaptureOptions struct {
+	Path    string
+	URL     string
+	Format  string
+	Size    image.Point // Pixel dimensions
+	Monitor *MonitorInfo
+	Window  *WindowInfo
+	Frame   *FrameClock
+	Started time.Time // When the capture began
+	Done    time.Time // When it was delivered, now if zero
+}
+
+// NewCapture builds a capture record for encoded image data, which may be
+// nil when the capture was not encoded, such as when it went to the
+// clipboard.
+func NewCapture(data []byte, opts CaptureOptions) *Capture {
+	done := opts.Done
+	if done.IsZero() {
+		done = time.Now()
+	}
+	started := opts.Started
+	if started.IsZero() {
+		started = done
+	}
+
+	c := &Capture{
+		Schema:     CaptureSchemaVersion,
+		Path:       opts.Path,
+		URL:        opts.URL,
+		Format:     opts.Format,
+		Width:      opts.Size.X,
+		Height:     opts.Size.Y,
+		Monitor:    opts.Monitor,
+		Window:     opts.Window,
+		Frame:      opts.Frame,
+		CapturedAt: started.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		DurationMs: done.Sub(started).Milliseconds(),
+	}
+	if data != nil {
+		sum := sha256.Sum256(data)
+		c.Size = int64(len(data))
+		c.SHA256 = hex.EncodeToString(sum[:])
+	}
+	return c
+}
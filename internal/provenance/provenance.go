@@ -0,0 +1,165 @@
+// Package provenance writes and verifies sidecar manifests for saved screenshots,
+// so a capture used as evidence can be checked for modification after the fact.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// ManifestSuffix is appended to the image path to form the sidecar path.
+	ManifestSuffix = ".winshot.json"
+	// SchemaVersion is the manifest format version.
+	SchemaVersion = 1
+
+	// machineIDSalt keeps the raw machine GUID out of shared manifests.
+	machineIDSalt = "winshot-provenance:"
+)
+
+// ErrNoManifest is returned when an image has no sidecar manifest.
+var ErrNoManifest = errors.New("no provenance manifest found")
+
+// MonitorInfo describes the display a capture was taken from.
+type MonitorInfo struct {
+	Index  int `json:"index"`
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// FrameClock places a capture on the QueryPerformanceCounter clock, which
+// Windows media APIs also stamp frames with, so captures can be lined up with
+// recordings and other capture sequences far more precisely than wall time
+// allows.
+type FrameClock struct {
+	QPC          int64 `json:"qpc"`          // Counter when the screen read began
+	QPCEnd       int64 `json:"qpcEnd"`       // Counter when it finished
+	QPCFrequency int64 `json:"qpcFrequency"` // Counter ticks per second
+}
+
+// Manifest is the sidecar record written next to a saved screenshot.
+type Manifest struct {
+	Schema        int          `json:"schema"`
+	File          string       `json:"file"`
+	SHA256        string       `json:"sha256"`
+	Size          int64        `json:"size"`
+	CapturedAt    string       `json:"capturedAt,omitempty"` // RFC3339, empty if unknown
+	SavedAt       string       `json:"savedAt"`              // RFC3339
+	MachineIDHash string       `json:"machineIdHash,omitempty"`
+	AppVersion    string       `json:"appVersion"`
+	Monitor       *MonitorInfo `json:"monitor,omitempty"`
+	Frame         *FrameClock  `json:"frame,omitempty"`
+}
+
+// Options carries capture context recorded in the manifest.
+type Options struct {
+	CapturedAt time.Time
+	MachineID  string // Raw machine identifier; only its salted hash is stored
+	AppVersion string
+	Monitor    *MonitorInfo
+	Frame      *FrameClock
+}
+
+// VerifyResult reports whether an image still matches its manifest.
+type VerifyResult struct {
+	Valid          bool      `json:"valid"`
+	ExpectedSHA256 string    `json:"expectedSha256"`
+	ActualSHA256   string    `json:"actualSha256"`
+	Manifest       *Manifest `json:"manifest"`
+}
+
+// SidecarPath returns the manifest path for an image file.
+func SidecarPath(imagePath string) string {
+	return imagePath + ManifestSuffix
+}
+
+// HashMachineID returns a salted SHA-256 of a machine identifier.
+func HashMachineID(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(machineIDSalt + id))
+	return hex.EncodeToString(sum[:])
+}
+
+// New builds a manifest for image data saved at imagePath.
+func New(imagePath string, data []byte, opts Options) *Manifest {
+	sum := sha256.Sum256(data)
+
+	m := &Manifest{
+		Schema:        SchemaVersion,
+		File:          filepath.Base(imagePath),
+		SHA256:        hex.EncodeToString(sum[:]),
+		Size:          int64(len(data)),
+		SavedAt:       time.Now().UTC().Format(time.RFC3339),
+		MachineIDHash: HashMachineID(opts.MachineID),
+		AppVersion:    opts.AppVersion,
+		Monitor:       opts.Monitor,
+		Frame:         opts.Frame,
+	}
+	if !opts.CapturedAt.IsZero() {
+		m.CapturedAt = opts.CapturedAt.UTC().Format(time.RFC3339)
+	}
+	return m
+}
+
+// Write builds a manifest for data and writes it next to imagePath.
+func Write(imagePath string, data []byte, opts Options) (*Manifest, error) {
+	m := New(imagePath, data, opts)
+
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(SidecarPath(imagePath), out, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Read loads the sidecar manifest for imagePath.
+func Read(imagePath string) (*Manifest, error) {
+	data, err := os.ReadFile(SidecarPath(imagePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoManifest
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Verify hashes imagePath and compares it against its sidecar manifest.
+func Verify(imagePath string) (*VerifyResult, error) {
+	m, err := Read(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+
+	return &VerifyResult{
+		Valid:          actual == m.SHA256 && int64(len(data)) == m.Size,
+		ExpectedSHA256: m.SHA256,
+		ActualSHA256:   actual,
+		Manifest:       m,
+	}, nil
+}
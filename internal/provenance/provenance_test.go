@@ -0,0 +1,136 @@
+package provenance
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "shot.png")
+	data := []byte("fake png bytes")
+
+	if err := os.WriteFile(imagePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	captured := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m, err := Write(imagePath, data, Options{
+		CapturedAt: captured,
+		MachineID:  "machine-guid",
+		AppVersion: "1.2.3",
+		Monitor:    &MonitorInfo{Index: 1, Width: 1920, Height: 1080},
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if m.File != "shot.png" {
+		t.Errorf("File = %q, want %q", m.File, "shot.png")
+	}
+	if m.CapturedAt != "2024-06-01T12:00:00Z" {
+		t.Errorf("CapturedAt = %q", m.CapturedAt)
+	}
+	if m.MachineIDHash == "" || m.MachineIDHash == "machine-guid" {
+		t.Errorf("MachineIDHash should be a salted hash, got %q", m.MachineIDHash)
+	}
+
+	res, err := Verify(imagePath)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !res.Valid {
+		t.Error("Verify() reported unmodified file as invalid")
+	}
+	if res.Manifest.Monitor == nil || res.Manifest.Monitor.Index != 1 {
+		t.Errorf("Monitor not round-tripped: %+v", res.Manifest.Monitor)
+	}
+
+	// Tamper with the image
+	if err := os.WriteFile(imagePath, []byte("edited bytes!!"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = Verify(imagePath)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if res.Valid {
+		t.Error("Verify() reported modified file as valid")
+	}
+}
+
+func TestVerify_NoManifest(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "missing.png")
+	if _, err := Verify(imagePath); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("Verify() error = %v, want ErrNoManifest", err)
+	}
+}
+
+func TestHashMachineID(t *testing.T) {
+	if HashMachineID("") != "" {
+		t.Error("empty machine ID should hash to empty string")
+	}
+	if HashMachineID("a") == HashMachineID("b") {
+		t.Error("different IDs produced the same hash")
+	}
+	if HashMachineID("a") != HashMachineID("a") {
+		t.Error("hash is not deterministic")
+	}
+}
+
+func TestNew_UnknownCaptureTime(t *testing.T) {
+	m := New("x.png", []byte{1, 2, 3}, Options{})
+	if m.CapturedAt != "" {
+		t.Errorf("CapturedAt = %q, want empty for zero time", m.CapturedAt)
+	}
+	if m.Size != 3 {
+		t.Errorf("Size = %d, want 3", m.Size)
+	}
+}
+
+func TestNewCapture(t *testing.T) {
+	started := time.Date(2024, 6, 1, 12, 0, 0, 250e6, time.UTC)
+	c := NewCapture([]byte("abc"), CaptureOptions{
+		Path:    `C:\shots\a.png`,
+		Format:  "png",
+		Size:    image.Pt(640, 480),
+		Monitor: &MonitorInfo{Index: 0, Width: 1920, Height: 1080},
+		Window:  &WindowInfo{Title: "Build", App: "chrome.exe"},
+		Frame:   &FrameClock{QPC: 1000, QPCEnd: 1200, QPCFrequency: 10_000_000},
+		Started: started,
+		Done:    started.Add(1500 * time.Millisecond),
+	})
+
+	if c.Schema != CaptureSchemaVersion || c.Width != 640 || c.Height != 480 {
+		t.Errorf("got schema %d, size %dx%d", c.Schema, c.Width, c.Height)
+	}
+	if c.Size != 3 || c.SHA256 != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("Size = %d, SHA256 = %q", c.Size, c.SHA256)
+	}
+	if c.CapturedAt != "2024-06-01T12:00:00.250Z" {
+		t.Errorf("CapturedAt = %q", c.CapturedAt)
+	}
+	if c.DurationMs != 1500 {
+		t.Errorf("DurationMs = %d, want 1500", c.DurationMs)
+	}
+	if c.Window == nil || c.Window.App != "chrome.exe" {
+		t.Errorf("Window = %+v", c.Window)
+	}
+	if c.Frame == nil || c.Frame.QPC != 1000 || c.Frame.QPCFrequency != 10_000_000 {
+		t.Errorf("Frame = %+v", c.Frame)
+	}
+}
+
+func TestNewCapture_NotEncoded(t *testing.T) {
+	c := NewCapture(nil, CaptureOptions{Size: image.Pt(1, 1)})
+	if c.Size != 0 || c.SHA256 != "" {
+		t.Errorf("Size = %d, SHA256 = %q, want none without data", c.Size, c.SHA256)
+	}
+	if c.DurationMs != 0 {
+		t.Errorf("DurationMs = %d, want 0 without a start time", c.DurationMs)
+	}
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// MissedAfter is how late a run may start and still count as on time. Runs
+// later than this were slept through, and SkipMissed decides what happens.
+const MissedAfter = 2 * time.Minute
+
+// Task is a named schedule.
+type Task struct {
+	Name       string
+	Cron       Cron
+	SkipMissed bool // Drop runs slept through instead of catching up once
+}
+
+// Run is a task that is due.
+type Run struct {
+	Task   int       // Index into the scheduler's tasks
+	At     time.Time // When the run was scheduled
+	Missed bool      // Catching up on a run slept through
+}
+
+// Scheduler tracks which runs are due between checks. It does not start
+// anything itself: the caller polls Due, typically from a ticker, so after
+// sleep the next check sees every run that came due in between. The zero
+// value has no tasks.
+type Scheduler struct {
+	mu    sync.Mutex
+	tasks []Task
+	last  time.Time // Runs up to and including this time are handled
+}
+
+// New returns a scheduler whose first check covers runs after now.
+func New(tasks []Task, now time.Time) *Scheduler {
+	return &Scheduler{tasks: tasks, last: now}
+}
+
+// Due returns the runs scheduled after the previous check and no later than
+// now, at most one per task: however many runs a long sleep swallowed, a
+// task catches up once, at its latest scheduled time. Missed runs of tasks
+// with SkipMissed are dropped.
+func (s *Scheduler) Due(now time.Time) []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !now.After(s.last) {
+		return nil // Clock went backwards, wait for it to catch up
+	}
+
+	var runs []Run
+	for i, t := range s.tasks {
+		var at time.Time
+		for next := t.Cron.Next(s.last); !next.IsZero() && !next.After(now); next = t.Cron.Next(next) {
+			at = next
+		}
+		if at.IsZero() {
+			continue
+		}
+		missed := now.Sub(at) > MissedAfter
+		if missed && t.SkipMissed {
+			continue
+		}
+		runs = append(runs, Run{Task: i, At: at, Missed: missed})
+	}
+	s.last = now
+	return runs
+}
+
+// Upcoming returns when each task runs next after now, zero for tasks that
+// never run again.
+func (s *Scheduler) Upcoming(now time.Time) []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := make([]time.Time, len(s.tasks))
+	for i, t := range s.tasks {
+		next[i] = t.Cron.Next(now)
+	}
+	return next
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a local time in the week of Monday 2024-03-04
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, 3, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"0 9 * * Mon-Fri", false},
+		{"*/15 8-18 * * *", false},
+		{"0 0 1,15 Jan,Jul *", false},
+		{"30 12 * * 7", false},
+		{"@daily", false},
+		{"5/20 * * * *", false},
+		{"0 9 * *", true},
+		{"60 * * * *", true},
+		{"0 24 * * *", true},
+		{"0 0 0 * *", true},
+		{"0 0 * * Fri-Mon", true},
+		{"*/0 * * * *", true},
+		{"0 0 * Foo *", true},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCron(tt.expr); (err != nil) != tt.wantErr {
+			t.Errorf("ParseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 9 * * Mon-Fri", at(4, 8, 59), at(4, 9, 0)},
+		{"0 9 * * Mon-Fri", at(4, 9, 0), at(5, 9, 0)},   // Strictly after
+		{"0 9 * * Mon-Fri", at(8, 10, 0), at(11, 9, 0)}, // Friday to Monday
+		{"*/15 8-18 * * *", at(4, 18, 50), at(5, 8, 0)}, // Past the last slot
+		{"5/20 * * * *", at(4, 10, 6), at(4, 10, 25)},   // Step from an offset
+		{"30 12 * * 7", at(4, 0, 0), at(10, 12, 30)},    // 7 is Sunday
+		{"0 0 1 * *", at(4, 0, 0), time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)},
+		{"0 0 29 Feb *", at(4, 0, 0), time.Date(2028, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"0 0 13 * Fri", at(4, 0, 0), at(8, 0, 0)}, // Either day field matches
+		{"0 0 30 Feb *", at(4, 0, 0), time.Time{}}, // Never
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q Next(%v) = %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestScheduler_Due(t *testing.T) {
+	weekdays, _ := ParseCron("0 9 * * Mon-Fri")
+	hourly, _ := ParseCron("@hourly")
+	s := New([]Task{
+		{Name: "dashboard", Cron: weekdays},
+		{Name: "hourly", Cron: hourly, SkipMissed: true},
+	}, at(4, 8, 58))
+
+	// Nothing is due before 9:00
+	if runs := s.Due(at(4, 8, 59)); len(runs) != 0 {
+		t.Errorf("Due(8:59) = %v, want none", runs)
+	}
+
+	// Both run on time at 9:00, a poll interval late
+	runs := s.Due(at(4, 9, 0).Add(20 * time.Second))
+	if len(runs) != 2 || runs[0].Missed || runs[1].Missed {
+		t.Fatalf("Due(9:00) = %v, want both tasks on time", runs)
+	}
+
+	// Asleep from 9:00 Monday to 10:30 Wednesday: the dashboard catches up
+	// once for Wednesday 9:00, the hourly task drops its missed runs
+	runs = s.Due(at(6, 10, 30))
+	if len(runs) != 1 {
+		t.Fatalf("Due after sleep = %v, want one catch-up run", runs)
+	}
+	if r := runs[0]; r.Task != 0 || !r.Missed || !r.At.Equal(at(6, 9, 0)) {
+		t.Errorf("catch-up run = %+v, want the dashboard's Wednesday run", r)
+	}
+
+	// Already handled runs don't come back, and a clock going backwards is ignored
+	if runs := s.Due(at(6, 10, 30)); len(runs) != 0 {
+		t.Errorf("repeated Due() = %v, want none", runs)
+	}
+	if runs := s.Due(at(6, 8, 0)); len(runs) != 0 {
+		t.Errorf("Due() with an earlier time = %v, want none", runs)
+	}
+}
+
+func TestScheduler_Upcoming(t *testing.T) {
+	weekdays, _ := ParseCron("0 9 * * Mon-Fri")
+	never, _ := ParseCron("0 0 30 Feb *")
+	s := New([]Task{{Cron: weekdays}, {Cron: never}}, at(4, 0, 0))
+
+	next := s.Upcoming(at(8, 12, 0))
+	if !next[0].Equal(at(11, 9, 0)) || !next[1].IsZero() {
+		t.Errorf("Upcoming() = %v, want [Monday 9:00, zero]", next)
+	}
+}
@@ -0,0 +1,74 @@
+package screenshot
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/kbinani/screenshot"
+)
+
+// CaptureBackend reads pixels from the virtual screen
+type CaptureBackend interface {
+	// Name is the value that selects the backend in CaptureOptions
+	Name() Backend
+	// Available reports whether the backend can run on this machine
+	Available() bool
+	// Capture reads a rectangle of the virtual screen into an opaque image
+	// whose bounds start at the origin. Calls are serialized by captureMu
+	Capture(r image.Rectangle) (*image.RGBA, error)
+}
+
+// backends lists every capture backend, preferred first. BackendAuto tries
+// them in this order and falls back to the next one on failure
+var backends = []CaptureBackend{dxgiBackend{}, gdiBackend{}}
+
+// gdiBackend copies the desktop DC with BitBlt. It works everywhere but is
+// slow on large desktops and sees black in exclusive fullscreen games
+type gdiBackend struct{}
+
+func (gdiBackend) Name() Backend   { return BackendGDI }
+func (gdiBackend) Available() bool { return true }
+
+func (gdiBackend) Capture(r image.Rectangle) (*image.RGBA, error) {
+	return screenshot.CaptureRect(r)
+}
+
+// AvailableBackends returns the backends that can run on this machine,
+// preferred first
+func AvailableBackends() []Backend {
+	var names []Backend
+	for _, b := range backends {
+		if b.Available() {
+			names = append(names, b.Name())
+		}
+	}
+	return names
+}
+
+// backendsFor returns the backends to try in order for a requested backend
+func backendsFor(name Backend) ([]CaptureBackend, error) {
+	var out []CaptureBackend
+	for _, b := range backends {
+		if (name == BackendAuto || b.Name() == name) && b.Available() {
+			out = append(out, b)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrCaptureBackendUnavailable, name)
+	}
+	return out, nil
+}
+
+// captureWith reads r with the first backend that succeeds
+func captureWith(r image.Rectangle, candidates []CaptureBackend) (*image.RGBA, error) {
+	var errs []error
+	for _, b := range candidates {
+		img, err := b.Capture(r)
+		if err == nil {
+			return img, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package screenshot
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestBackendsFor(t *testing.T) {
+	auto, err := backendsFor(BackendAuto)
+	if err != nil {
+		t.Fatalf("backendsFor(auto) error = %v", err)
+	}
+	if last := auto[len(auto)-1]; last.Name() != BackendGDI {
+		t.Errorf("backendsFor(auto) ends with %q, want GDI as the last resort", last.Name())
+	}
+
+	gdi, err := backendsFor(BackendGDI)
+	if err != nil || len(gdi) != 1 || gdi[0].Name() != BackendGDI {
+		t.Errorf("backendsFor(gdi) = %v, %v, want only GDI", gdi, err)
+	}
+
+	if _, err := backendsFor("vulkan"); !errors.Is(err, ErrCaptureBackendUnavailable) {
+		t.Errorf("backendsFor(unknown) error = %v, want ErrCaptureBackendUnavailable", err)
+	}
+}
+
+type fakeBackend struct {
+	name Backend
+	err  error
+}
+
+func (f fakeBackend) Name() Backend   { return f.name }
+func (f fakeBackend) Available() bool { return true }
+
+func (f fakeBackend) Capture(r image.Rectangle) (*image.RGBA, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy())), nil
+}
+
+func TestCaptureWith_FallsBack(t *testing.T) {
+	failing := fakeBackend{name: BackendDXGI, err: errors.New("access lost")}
+	working := fakeBackend{name: BackendGDI}
+
+	img, err := captureWith(image.Rect(10, 10, 50, 30), []CaptureBackend{failing, working})
+	if err != nil {
+		t.Fatalf("captureWith() error = %v, want fallback to succeed", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 40, 20) {
+		t.Errorf("captureWith() bounds = %v", img.Bounds())
+	}
+
+	if _, err := captureWith(image.Rect(0, 0, 10, 10), []CaptureBackend{failing}); err == nil {
+		t.Error("captureWith() with only failing backends should return an error")
+	}
+}
+
+func TestCopyBGRA(t *testing.T) {
+	// A 4x2 BGRA surface with rows padded to 20 bytes
+	pitch := 20
+	data := make([]byte, pitch*2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			i := y*pitch + x*4
+			data[i], data[i+1], data[i+2] = byte(x), byte(y), 200 // B, G, R
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	copyBGRA(img, image.Pt(1, 1), data, pitch, image.Rect(2, 0, 4, 2))
+
+	if got := img.RGBAAt(1, 1); got.R != 200 || got.G != 0 || got.B != 2 || got.A != 255 {
+		t.Errorf("pixel (1,1) = %v, want source pixel (2,0) as opaque RGBA", got)
+	}
+	if got := img.RGBAAt(2, 2); got.B != 3 || got.G != 1 {
+		t.Errorf("pixel (2,2) = %v, want source pixel (3,1)", got)
+	}
+	if got := img.RGBAAt(0, 0); got.A != 0 {
+		t.Errorf("pixel (0,0) = %v, want untouched", got)
+	}
+}
@@ -0,0 +1,36 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+)
+
+// VirtualScreenRect returns the virtual desktop bounds as a rectangle
+func VirtualScreenRect() image.Rectangle {
+	x, y, w, h := GetVirtualScreenBounds()
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// ValidateRect checks that r has a positive size and lies entirely within screen
+func ValidateRect(r, screen image.Rectangle) error {
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return fmt.Errorf("%w: invalid size %dx%d", ErrRegionOutOfBounds, r.Dx(), r.Dy())
+	}
+	if !r.In(screen) {
+		return fmt.Errorf("%w: %v is outside %v", ErrRegionOutOfBounds, r, screen)
+	}
+	return nil
+}
+
+// ClampRect intersects r with screen, so a region that hangs off the edge of
+// the desktop captures only its visible part. Returns an error if nothing remains.
+func ClampRect(r, screen image.Rectangle) (image.Rectangle, error) {
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return image.Rectangle{}, fmt.Errorf("%w: invalid size %dx%d", ErrRegionOutOfBounds, r.Dx(), r.Dy())
+	}
+	clamped := r.Intersect(screen)
+	if clamped.Empty() {
+		return image.Rectangle{}, fmt.Errorf("%w: %v does not overlap %v", ErrRegionOutOfBounds, r, screen)
+	}
+	return clamped, nil
+}
@@ -0,0 +1,67 @@
+package screenshot
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestValidateRect(t *testing.T) {
+	// Secondary monitor to the left of the primary
+	screen := image.Rect(-1920, 0, 1920, 1080)
+
+	tests := []struct {
+		name    string
+		r       image.Rectangle
+		wantErr bool
+	}{
+		{"inside primary", image.Rect(100, 100, 500, 400), false},
+		{"inside secondary", image.Rect(-1800, 0, -100, 1080), false},
+		{"whole desktop", screen, false},
+		{"zero width", image.Rect(10, 10, 10, 50), true},
+		{"negative size", image.Rectangle{Min: image.Pt(50, 50), Max: image.Pt(10, 10)}, true},
+		{"off right edge", image.Rect(1800, 0, 2000, 100), true},
+		{"entirely outside", image.Rect(5000, 5000, 5100, 5100), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRect(tt.r, screen)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRect(%v) error = %v, wantErr %v", tt.r, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrRegionOutOfBounds) {
+				t.Errorf("error = %v, want ErrRegionOutOfBounds", err)
+			}
+		})
+	}
+}
+
+func TestClampRect(t *testing.T) {
+	screen := image.Rect(0, 0, 1920, 1080)
+
+	tests := []struct {
+		name    string
+		r       image.Rectangle
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{"inside", image.Rect(10, 10, 100, 100), image.Rect(10, 10, 100, 100), false},
+		{"off right edge", image.Rect(1800, 0, 2000, 100), image.Rect(1800, 0, 1920, 100), false},
+		{"off top-left", image.Rect(-8, -8, 200, 200), image.Rect(0, 0, 200, 200), false},
+		{"entirely outside", image.Rect(-500, -500, -100, -100), image.Rectangle{}, true},
+		{"zero height", image.Rect(10, 10, 100, 10), image.Rectangle{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClampRect(tt.r, screen)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClampRect(%v) error = %v, wantErr %v", tt.r, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ClampRect(%v) = %v, want %v", tt.r, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"time"
+)
+
+// Burst limits. Frames are kept in memory as RGBA until the burst ends, so
+// the total size is capped as well as the count
+const (
+	MaxBurstFrames = 500
+	maxBurstBytes  = 1 << 30
+)
+
+// BurstFrame is one frame of a burst capture
+type BurstFrame struct {
+	Image *image.RGBA
+	Time  FrameTime // When the frame was read from the screen
+}
+
+// CaptureBurst captures n frames of a rectangle of the virtual screen, back
+// to back as fast as the backend allows when interval is zero, or one every
+// interval otherwise. A single shot easily misses a flickering or briefly
+// misrendered frame that a burst catches.
+// Frames skip PostCapture filters, and Format and Quality in opts are
+// ignored. Cancelling opts.Context stops the burst early; on any error the
+// frames captured so far are returned along with it
+func CaptureBurst(r image.Rectangle, n int, interval time.Duration, opts CaptureOptions) ([]BurstFrame, error) {
+	if n < 1 || n > MaxBurstFrames {
+		return nil, fmt.Errorf("frame count must be between 1 and %d", MaxBurstFrames)
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("invalid frame interval %v", interval)
+	}
+	if err := contextErr(opts.Context); err != nil {
+		return nil, err
+	}
+
+	candidates, err := backendsFor(opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Clamp {
+		r, err = ClampRect(r, VirtualScreenRect())
+	} else {
+		err = ValidateRect(r, VirtualScreenRect())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size := int64(r.Dx()) * int64(r.Dy()) * 4 * int64(n); size > maxBurstBytes {
+		return nil, fmt.Errorf("burst of %d frames of %dx%d needs %d MB, over the %d MB limit",
+			n, r.Dx(), r.Dy(), size>>20, maxBurstBytes>>20)
+	}
+
+	// A nil channel never fires, so bursts without a context just wait
+	var done <-chan struct{}
+	if opts.Context != nil {
+		done = opts.Context.Done()
+	}
+	cursor := opts.IncludeCursor || includeCursor.Load()
+	frames := make([]BurstFrame, 0, n)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if i > 0 && interval > 0 {
+			// Frames are scheduled from the start so a slow read doesn't
+			// push every later frame back
+			select {
+			case <-done:
+				return frames, opts.Context.Err()
+			case <-time.After(time.Until(start.Add(time.Duration(i) * interval))):
+			}
+		}
+		if err := contextErr(opts.Context); err != nil {
+			return frames, err
+		}
+
+		img, t, err := grabRectTimed(r, candidates)
+		if err != nil {
+			return frames, fmt.Errorf("frame %d: %w", i+1, err)
+		}
+		if cursor {
+			drawCursor(img, r.Min)
+		}
+		frames = append(frames, BurstFrame{Image: img, Time: t})
+	}
+	return frames, nil
+}
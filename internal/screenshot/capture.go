@@ -1,38 +1,167 @@
+// Package screenshot captures the screen, windows and clipboard images.
+//
+// Screen pixels are read by a CaptureBackend. DXGI Desktop Duplication is
+// preferred, and captures fall back to GDI BitBlt when it is unavailable or
+// fails, such as on rotated displays or in remote sessions.
+//
+// All exported functions are safe for concurrent use. Screen reads are
+// serialized internally because each GDI capture holds the desktop DC and a
+// DIB section for its duration, and interleaved BitBlt calls from several
+// goroutines can corrupt each other's output. DXGI allows one duplication
+// of an output per process, which the same lock guarantees. Clipboard
+// access is serialized the same way since only one caller can hold the
+// clipboard open at a time. Encoding happens outside the lock, so
+// concurrent callers only wait for the pixel copy itself.
 package screenshot
 
 import (
-	"bytes"
+	"context"
 	"encoding/base64"
+	"fmt"
 	"image"
-	"image/png"
 	"math"
+	"sync"
 
 	"github.com/kbinani/screenshot"
+	"winshot/internal/imaging"
 )
 
 // CaptureResult holds the screenshot data
 type CaptureResult struct {
 	Width  int    `json:"width"`
 	Height int    `json:"height"`
-	Data   string `json:"data"` // Base64 encoded PNG
+	Data   string `json:"data"` // Base64 encoded PNG, or the format requested via CaptureOptions
+
+	Metadata *ClipboardMetadata `json:"metadata,omitempty"` // Set for images pasted from the clipboard
+}
+
+// Backend selects the screen capture implementation
+type Backend string
+
+// Capture backends
+const (
+	BackendAuto Backend = ""     // Pick the best available backend, falling back on failure
+	BackendGDI  Backend = "gdi"  // GDI BitBlt from the desktop DC
+	BackendDXGI Backend = "dxgi" // DXGI Desktop Duplication
+)
+
+// CaptureOptions controls how CaptureRect captures and encodes an image
+type CaptureOptions struct {
+	IncludeCursor bool            // Composite the mouse cursor into the capture
+	Backend       Backend         // Capture backend, BackendAuto if empty
+	Format        string          // "png" (default), "jpeg", "webp" or "avif"
+	Quality       int             // JPEG, WebP and AVIF quality 1-100, 0 uses the default
+	Context       context.Context // Optional, cancels the capture before it starts or is encoded
+	Clamp         bool            // Clip the region to the virtual screen instead of rejecting it
+}
+
+// CaptureRect captures a rectangle of the virtual screen using the given options
+func CaptureRect(r image.Rectangle, opts CaptureOptions) (*CaptureResult, error) {
+	img, err := CaptureRectRaw(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := contextErr(opts.Context); err != nil {
+		return nil, err
+	}
+	return encodeImageAs(img, opts.Format, opts.Quality)
+}
+
+// CaptureRectRaw captures a rectangle of the virtual screen and returns the raw RGBA image
+// without running PostCapture filters. Format and Quality in opts are ignored. The region must lie within the virtual
+// screen unless opts.Clamp is set.
+func CaptureRectRaw(r image.Rectangle, opts CaptureOptions) (*image.RGBA, error) {
+	if err := contextErr(opts.Context); err != nil {
+		return nil, err
+	}
+
+	candidates, err := backendsFor(opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Clamp {
+		r, err = ClampRect(r, VirtualScreenRect())
+	} else {
+		err = ValidateRect(r, VirtualScreenRect())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := grabRectWith(r, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if opts.IncludeCursor || includeCursor.Load() {
+		drawCursor(img, r.Min)
+	}
+	return img, nil
+}
+
+// captureMu serializes screen reads across goroutines
+var captureMu sync.Mutex
+
+// grabRect reads r with the best available backend
+func grabRect(r image.Rectangle) (*image.RGBA, error) {
+	candidates, err := backendsFor(BackendAuto)
+	if err != nil {
+		return nil, err
+	}
+	return grabRectWith(r, candidates)
+}
+
+// grabRectWith is the single point where screen pixels are read
+func grabRectWith(r image.Rectangle, candidates []CaptureBackend) (*image.RGBA, error) {
+	img, _, err := grabRectTimed(r, candidates)
+	return img, err
+}
+
+// grabRectTimed is grabRectWith that also returns when the read happened
+func grabRectTimed(r image.Rectangle, candidates []CaptureBackend) (*image.RGBA, FrameTime, error) {
+	// Reads succeed on a secure desktop but return black, so refuse up front
+	if err := checkInputDesktop(); err != nil {
+		return nil, FrameTime{}, err
+	}
+
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	start := qpcNow()
+	img, err := captureWith(r, candidates)
+	if err != nil {
+		return nil, FrameTime{}, err
+	}
+	lastFrame = FrameTime{Start: start, End: qpcNow(), Frequency: qpcFrequency()}
+	return img, lastFrame, nil
+}
+
+// contextErr returns the context error, treating a nil context as never cancelled
+func contextErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Err()
 }
 
 // CaptureFullscreen captures the display where the cursor is currently located
 func CaptureFullscreen() (*CaptureResult, error) {
-	displayIndex := GetMonitorAtCursor()
-	bounds := screenshot.GetDisplayBounds(displayIndex)
-	img, err := screenshot.CaptureRect(bounds)
+	img, err := CaptureFullscreenRaw()
 	if err != nil {
 		return nil, err
 	}
 	return encodeImage(img)
 }
 
+// CaptureFullscreenRaw is CaptureFullscreen without PostCapture filters and
+// encoding
+func CaptureFullscreenRaw() (*image.RGBA, error) {
+	img, _, err := CaptureActiveDisplayRaw()
+	return img, err
+}
+
 // CaptureActiveDisplay captures the display where the cursor is located and returns display info
 func CaptureActiveDisplay() (*CaptureResult, int, error) {
-	displayIndex := GetMonitorAtCursor()
-	bounds := screenshot.GetDisplayBounds(displayIndex)
-	img, err := screenshot.CaptureRect(bounds)
+	img, displayIndex, err := CaptureActiveDisplayRaw()
 	if err != nil {
 		return nil, displayIndex, err
 	}
@@ -40,25 +169,53 @@ func CaptureActiveDisplay() (*CaptureResult, int, error) {
 	return result, displayIndex, err
 }
 
+// CaptureActiveDisplayRaw is CaptureActiveDisplay without PostCapture filters
+// and encoding
+func CaptureActiveDisplayRaw() (*image.RGBA, int, error) {
+	displayIndex := GetMonitorAtCursor()
+	img, err := grabDisplay(displayIndex)
+	return img, displayIndex, err
+}
+
 // CaptureRegion captures a specific region of the screen
+//
+// Deprecated: Use CaptureRect, which takes an image.Rectangle and CaptureOptions.
 func CaptureRegion(x, y, width, height int) (*CaptureResult, error) {
-	img, err := screenshot.Capture(x, y, width, height)
-	if err != nil {
-		return nil, err
-	}
-	return encodeImage(img)
+	return CaptureRect(image.Rect(x, y, x+width, y+height), CaptureOptions{Clamp: true})
+}
+
+// CaptureRegionRaw is CaptureRegion without PostCapture filters and encoding
+func CaptureRegionRaw(x, y, width, height int) (*image.RGBA, error) {
+	return CaptureRectRaw(image.Rect(x, y, x+width, y+height), CaptureOptions{Clamp: true})
 }
 
 // CaptureDisplay captures a specific display by index
 func CaptureDisplay(displayIndex int) (*CaptureResult, error) {
-	bounds := screenshot.GetDisplayBounds(displayIndex)
-	img, err := screenshot.CaptureRect(bounds)
+	img, err := CaptureDisplayRaw(displayIndex)
 	if err != nil {
 		return nil, err
 	}
 	return encodeImage(img)
 }
 
+// CaptureDisplayRaw is CaptureDisplay without PostCapture filters and encoding
+func CaptureDisplayRaw(displayIndex int) (*image.RGBA, error) {
+	if displayIndex < 0 || displayIndex >= GetDisplayCount() {
+		return nil, fmt.Errorf("%w: index %d", ErrDisplayNotFound, displayIndex)
+	}
+	return grabDisplay(displayIndex)
+}
+
+// grabDisplay reads a display, with the cursor if SetIncludeCursor is on
+func grabDisplay(displayIndex int) (*image.RGBA, error) {
+	r := screenshot.GetDisplayBounds(displayIndex)
+	img, err := grabRect(r)
+	if err == nil && includeCursor.Load() {
+		drawCursor(img, r.Min)
+	}
+	return img, err
+}
+
 // GetDisplayCount returns the number of active displays
 func GetDisplayCount() int {
 	return screenshot.NumActiveDisplays()
@@ -102,26 +259,59 @@ func GetVirtualScreenBounds() (x, y, width, height int) {
 // CaptureVirtualScreen captures the entire virtual desktop (all monitors combined)
 func CaptureVirtualScreen() (*CaptureResult, error) {
 	x, y, w, h := GetVirtualScreenBounds()
-	return CaptureRegion(x, y, w, h)
+	return CaptureRect(image.Rect(x, y, x+w, y+h), CaptureOptions{})
 }
 
 // CaptureVirtualScreenRaw captures the entire virtual desktop and returns raw RGBA image
 // This is faster than CaptureVirtualScreen as it skips PNG encoding
 func CaptureVirtualScreenRaw() (*image.RGBA, error) {
-	x, y, w, h := GetVirtualScreenBounds()
-	return screenshot.Capture(x, y, w, h)
+	return grabRect(VirtualScreenRect())
+}
+
+// PostCapture holds the filters every capture passes through after the pixels
+// are read and before it is encoded. Register filters at startup to apply
+// custom transforms, such as stamping or redaction, to all captures. Raw
+// captures skip the chain; pass them to ApplyFilters once they are final.
+var PostCapture imaging.FilterChain
+
+// ApplyFilters runs the PostCapture filters on a finished capture
+func ApplyFilters(img image.Image) (*image.RGBA, error) {
+	return PostCapture.Apply(img)
+}
+
+// Encode runs the PostCapture filters on an image assembled outside this
+// package, such as a stitched scrolling capture, and encodes it as PNG
+func Encode(img *image.RGBA) (*CaptureResult, error) {
+	return encodeImage(img)
 }
 
 // encodeImage converts an image to base64 PNG
 func encodeImage(img *image.RGBA) (*CaptureResult, error) {
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, img); err != nil {
+	return encodeImageAs(img, "png", 0)
+}
+
+// BeforeEncode, if set, is called with each filtered capture just before it
+// is encoded, such as to show a quick preview while a large capture encodes
+var BeforeEncode func(img *image.RGBA)
+
+// encodeImageAs converts an image to base64 in any format imaging.Encode writes
+func encodeImageAs(img *image.RGBA, format string, quality int) (*CaptureResult, error) {
+	img, err := ApplyFilters(img)
+	if err != nil {
+		return nil, err
+	}
+	if BeforeEncode != nil {
+		BeforeEncode(img)
+	}
+
+	data, err := imaging.Encode(img, imaging.EncodeOptions{Format: format, Quality: quality})
+	if err != nil {
 		return nil, err
 	}
 
 	return &CaptureResult{
 		Width:  img.Bounds().Dx(),
 		Height: img.Bounds().Dy(),
-		Data:   base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Data:   base64.StdEncoding.EncodeToString(data),
 	}, nil
 }
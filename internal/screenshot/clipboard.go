@@ -3,6 +3,7 @@ package screenshot
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"image"
 	_ "image/gif"  // Register GIF decoder
@@ -12,6 +13,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -24,11 +26,16 @@ var (
 	procGetClipboardData           = user32Clip.NewProc("GetClipboardData")
 	procIsClipboardFormatAvailable = user32Clip.NewProc("IsClipboardFormatAvailable")
 	procRegisterClipboardFormat    = user32Clip.NewProc("RegisterClipboardFormatW")
+	procEmptyClipboard             = user32Clip.NewProc("EmptyClipboard")
+	procSetClipboardData           = user32Clip.NewProc("SetClipboardData")
 
 	kernel32Clip     = windows.NewLazySystemDLL("kernel32.dll")
 	procGlobalLock   = kernel32Clip.NewProc("GlobalLock")
 	procGlobalUnlock = kernel32Clip.NewProc("GlobalUnlock")
 	procGlobalSize   = kernel32Clip.NewProc("GlobalSize")
+	procGlobalAlloc  = kernel32Clip.NewProc("GlobalAlloc")
+	procGlobalFree   = kernel32Clip.NewProc("GlobalFree")
+	procMoveMemory   = kernel32Clip.NewProc("RtlMoveMemory")
 
 	shell32          = windows.NewLazySystemDLL("shell32.dll")
 	procDragQueryFile = shell32.NewProc("DragQueryFileW")
@@ -39,9 +46,14 @@ const (
 	CF_DIBV5         = 17
 	CF_DIB           = 8
 	CF_HDROP         = 15 // File list format (File Explorer copy)
+	CF_UNICODETEXT   = 13
+	GMEM_MOVEABLE    = 0x0002
 	maxClipboardSize = 100 * 1024 * 1024 // 100MB max to prevent DoS
 )
 
+// clipboardMu serializes clipboard access within the process
+var clipboardMu sync.Mutex
+
 // Supported image extensions for file drop
 var supportedImageExtensions = map[string]bool{
 	".png":  true,
@@ -150,32 +162,12 @@ type BITMAPINFOHEADER struct {
 	BiClrImportant  uint32
 }
 
-// ErrNoImageInClipboard is returned when clipboard has no image
-var ErrNoImageInClipboard = errors.New("no image in clipboard")
-
 // readPNGFromClipboard reads PNG data from clipboard handle and returns CaptureResult.
 // PNG format contains raw PNG file bytes, which we decode and re-encode to ensure valid output.
 func readPNGFromClipboard(hData uintptr) (*CaptureResult, error) {
-	// Lock global memory to get pointer to data
-	ptr, _, _ := procGlobalLock.Call(hData)
-	if ptr == 0 {
-		return nil, errors.New("failed to lock clipboard data")
-	}
-	defer procGlobalUnlock.Call(hData)
-
-	// Get size of clipboard data
-	size, _, _ := procGlobalSize.Call(hData)
-	if size == 0 {
-		return nil, errors.New("failed to get clipboard data size")
-	}
-	if size > maxClipboardSize {
-		return nil, errors.New("clipboard image too large")
-	}
-
-	// Copy PNG data from clipboard memory
-	pngData := make([]byte, size)
-	for i := uintptr(0); i < size; i++ {
-		pngData[i] = *(*byte)(unsafe.Pointer(ptr + i))
+	pngData, err := clipboardBytes(hData)
+	if err != nil {
+		return nil, err
 	}
 
 	// Decode PNG to get dimensions and validate data
@@ -201,6 +193,32 @@ func readPNGFromClipboard(hData uintptr) (*CaptureResult, error) {
 	}, nil
 }
 
+// clipboardBytes copies the contents of a clipboard global memory handle
+func clipboardBytes(hData uintptr) ([]byte, error) {
+	// Lock global memory to get pointer to data
+	ptr, _, _ := procGlobalLock.Call(hData)
+	if ptr == 0 {
+		return nil, errors.New("failed to lock clipboard data")
+	}
+	// IMPORTANT: Must unlock before clipboard closes to prevent clipboard corruption
+	defer procGlobalUnlock.Call(hData)
+
+	// Get size of clipboard data
+	size, _, _ := procGlobalSize.Call(hData)
+	if size == 0 {
+		return nil, errors.New("failed to get clipboard data size")
+	}
+
+	// Check size limit to prevent DoS
+	if size > maxClipboardSize {
+		return nil, errors.New("clipboard image too large")
+	}
+
+	data := make([]byte, size)
+	copy(data, unsafe.Slice((*byte)(unsafe.Pointer(ptr)), size))
+	return data, nil
+}
+
 // GetClipboardImage reads image from Windows clipboard
 func GetClipboardImage() (*CaptureResult, error) {
 	// CRITICAL: Lock OS thread because Windows clipboard API requires
@@ -209,6 +227,9 @@ func GetClipboardImage() (*CaptureResult, error) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
+	clipboardMu.Lock()
+	defer clipboardMu.Unlock()
+
 	// Get PNG clipboard format (registered format used by Chrome, Firefox, etc.)
 	// Must get this ONCE and reuse - each call returns the same ID
 	cfPNG := getPNGClipboardFormat()
@@ -255,130 +276,288 @@ func GetClipboardImage() (*CaptureResult, error) {
 
 	// Handle PNG format (raw PNG bytes from modern apps)
 	if selectedFormat == cfPNG {
-		return readPNGFromClipboard(hData)
+		result, err := readPNGFromClipboard(hData)
+		if err != nil {
+			return nil, err
+		}
+		result.Metadata = readClipboardMetadata(nil)
+		return result, nil
 	}
 
-	// Lock global memory to get pointer to data
-	ptr, _, _ := procGlobalLock.Call(hData)
-	if ptr == 0 {
-		return nil, errors.New("failed to lock clipboard data")
+	// Parse a copy so nothing reads past the block whatever its header says
+	data, err := clipboardBytes(hData)
+	if err != nil {
+		return nil, err
 	}
-	// IMPORTANT: Must unlock before clipboard closes to prevent clipboard corruption
-	defer procGlobalUnlock.Call(hData)
+	img, err := decodeDIB(data)
+	if err != nil {
+		return nil, err
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
 
-	// Get size of clipboard data
-	size, _, _ := procGlobalSize.Call(hData)
-	if size == 0 {
-		return nil, errors.New("failed to get clipboard data size")
+	// Encode to PNG and return as CaptureResult
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
 	}
 
-	// Check size limit to prevent DoS
-	if size > maxClipboardSize {
-		return nil, errors.New("clipboard image too large")
+	return &CaptureResult{
+		Width:    width,
+		Height:   height,
+		Data:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Metadata: readClipboardMetadata(data),
+	}, nil
+}
+
+// clipboardEntry is a single format written by setClipboard
+type clipboardEntry struct {
+	format uintptr
+	data   []byte
+}
+
+// setClipboard replaces the clipboard contents with the given formats
+func setClipboard(entries ...clipboardEntry) error {
+	// Clipboard must be opened and closed on the same OS thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	clipboardMu.Lock()
+	defer clipboardMu.Unlock()
+
+	ret, _, _ := procOpenClipboard.Call(0)
+	if ret == 0 {
+		return errors.New("failed to open clipboard")
 	}
+	defer procCloseClipboard.Call()
 
-	// Parse BITMAPINFOHEADER
-	header := (*BITMAPINFOHEADER)(unsafe.Pointer(ptr))
+	procEmptyClipboard.Call()
 
-	width := int(header.BiWidth)
-	height := int(header.BiHeight)
-	bitCount := int(header.BiBitCount)
+	for _, entry := range entries {
+		hMem, err := globalAllocBytes(entry.data)
+		if err != nil {
+			return err
+		}
+		// On success the clipboard owns the memory, so only free it on failure
+		if ret, _, _ := procSetClipboardData.Call(entry.format, hMem); ret == 0 {
+			procGlobalFree.Call(hMem)
+			return errors.New("failed to set clipboard data")
+		}
+	}
+	return nil
+}
 
-	// Height can be negative (top-down DIB) or positive (bottom-up DIB)
-	bottomUp := height > 0
-	if height < 0 {
-		height = -height
+// globalAllocBytes copies data into a movable global memory block
+func globalAllocBytes(data []byte) (uintptr, error) {
+	if len(data) == 0 {
+		return 0, errors.New("empty clipboard data")
 	}
 
-	if width <= 0 || height <= 0 {
-		return nil, errors.New("invalid image dimensions in clipboard")
+	hMem, _, _ := procGlobalAlloc.Call(GMEM_MOVEABLE, uintptr(len(data)))
+	if hMem == 0 {
+		return 0, errors.New("failed to allocate clipboard memory")
 	}
+	ptr, _, _ := procGlobalLock.Call(hMem)
+	if ptr == 0 {
+		procGlobalFree.Call(hMem)
+		return 0, errors.New("failed to lock clipboard memory")
+	}
+	procMoveMemory.Call(ptr, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)))
+	procGlobalUnlock.Call(hMem)
 
-	// Calculate pixel data offset (header + color table if applicable)
-	pixelOffset := uintptr(header.BiSize)
-	if bitCount <= 8 {
-		// For 8-bit or less, there's a color table
-		colorTableSize := uintptr(1 << bitCount * 4) // RGBQUAD entries
-		if header.BiClrUsed > 0 {
-			colorTableSize = uintptr(header.BiClrUsed * 4)
-		}
-		pixelOffset += colorTableSize
+	return hMem, nil
+}
+
+// SetClipboardText replaces the clipboard contents with Unicode text
+func SetClipboardText(text string) error {
+	utf16, err := windows.UTF16FromString(text)
+	if err != nil {
+		return err
 	}
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&utf16[0])), len(utf16)*2)
+	return setClipboard(clipboardEntry{format: CF_UNICODETEXT, data: data})
+}
 
-	// Get pixel data pointer
-	pixelPtr := ptr + pixelOffset
+// SetClipboardPNG replaces the clipboard contents with encoded PNG bytes
+// using the registered "PNG" format understood by browsers and Office
+func SetClipboardPNG(pngData []byte) error {
+	cfPNG := getPNGClipboardFormat()
+	if cfPNG == 0 {
+		return errors.New("PNG clipboard format not available")
+	}
+	return setClipboard(clipboardEntry{format: cfPNG, data: pngData})
+}
 
-	// Create RGBA image
-	img := image.NewRGBA(image.Rect(0, 0, width, height))
+// DIB constants for reading and writing clipboard images
+const (
+	biRGB         = 0
+	biBitfields   = 3
+	lcsSRGB       = 0x73524742 // 'sRGB'
+	lcsGMImages   = 4
+	bitmapV5Size  = 124
+	bitmapInfoLen = 40
+)
 
-	// Calculate row stride (must be aligned to 4 bytes)
-	rowSize := ((width*bitCount + 31) / 32) * 4
+// bitmapV5Header mirrors BITMAPV5HEADER
+type bitmapV5Header struct {
+	BITMAPINFOHEADER
+	RedMask, GreenMask, BlueMask, AlphaMask uint32
+	CSType                                  uint32
+	Endpoints                               [9]int32
+	GammaRed, GammaGreen, GammaBlue         uint32
+	Intent                                  uint32
+	ProfileData, ProfileSize                uint32
+	Reserved                                uint32
+}
 
-	// Validate pixel data bounds to prevent buffer overflow
-	expectedSize := uintptr(rowSize * height)
-	dataSize := size - pixelOffset
-	if dataSize < expectedSize {
-		return nil, errors.New("invalid clipboard data: pixel data smaller than expected")
+// SetClipboardImage replaces the clipboard contents with img as PNG,
+// CF_DIBV5 and CF_DIB at once. Apps that read PNG or DIBV5, such as
+// browsers, Office and image editors, keep transparency. Older apps get a
+// DIB with transparent areas over white
+func SetClipboardImage(img *image.RGBA) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	entries := []clipboardEntry{
+		{format: CF_DIBV5, data: encodeDIBV5(img)},
+		{format: CF_DIB, data: encodeDIB(img)},
+	}
+	// Offer PNG first, apps that list formats in order prefer it
+	if cfPNG := getPNGClipboardFormat(); cfPNG != 0 {
+		entries = append([]clipboardEntry{{format: cfPNG, data: buf.Bytes()}}, entries...)
 	}
+	return setClipboard(entries...)
+}
 
-	// Convert DIB to RGBA
-	switch bitCount {
-	case 24:
-		// 24-bit BGR
-		for y := 0; y < height; y++ {
-			srcY := y
-			if bottomUp {
-				srcY = height - 1 - y
-			}
-			rowPtr := pixelPtr + uintptr(srcY*rowSize)
-			for x := 0; x < width; x++ {
-				pixelAddr := rowPtr + uintptr(x*3)
-				b := *(*byte)(unsafe.Pointer(pixelAddr))
-				g := *(*byte)(unsafe.Pointer(pixelAddr + 1))
-				r := *(*byte)(unsafe.Pointer(pixelAddr + 2))
-				img.Pix[(y*width+x)*4+0] = r
-				img.Pix[(y*width+x)*4+1] = g
-				img.Pix[(y*width+x)*4+2] = b
-				img.Pix[(y*width+x)*4+3] = 255
-			}
-		}
-	case 32:
-		// 32-bit BGRA
-		for y := 0; y < height; y++ {
-			srcY := y
-			if bottomUp {
-				srcY = height - 1 - y
-			}
-			rowPtr := pixelPtr + uintptr(srcY*rowSize)
-			for x := 0; x < width; x++ {
-				pixelAddr := rowPtr + uintptr(x*4)
-				b := *(*byte)(unsafe.Pointer(pixelAddr))
-				g := *(*byte)(unsafe.Pointer(pixelAddr + 1))
-				r := *(*byte)(unsafe.Pointer(pixelAddr + 2))
-				a := *(*byte)(unsafe.Pointer(pixelAddr + 3))
-				// Some apps set alpha to 0 for opaque pixels, handle this
-				if a == 0 {
-					a = 255
-				}
-				img.Pix[(y*width+x)*4+0] = r
-				img.Pix[(y*width+x)*4+1] = g
-				img.Pix[(y*width+x)*4+2] = b
-				img.Pix[(y*width+x)*4+3] = a
+// encodeDIBV5 packs img as a bottom-up 32-bit BITMAPV5HEADER DIB with
+// straight alpha in sRGB
+func encodeDIBV5(img *image.RGBA) []byte {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	header := bitmapV5Header{
+		BITMAPINFOHEADER: BITMAPINFOHEADER{
+			BiSize:        bitmapV5Size,
+			BiWidth:       int32(w),
+			BiHeight:      int32(h),
+			BiPlanes:      1,
+			BiBitCount:    32,
+			BiCompression: biBitfields,
+			BiSizeImage:   uint32(w * h * 4),
+		},
+		RedMask:   0x00ff0000,
+		GreenMask: 0x0000ff00,
+		BlueMask:  0x000000ff,
+		AlphaMask: 0xff000000,
+		CSType:    lcsSRGB,
+		Intent:    lcsGMImages,
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(bitmapV5Size + w*h*4)
+	binary.Write(&buf, binary.LittleEndian, header)
+	row := make([]byte, w*4)
+	for y := b.Max.Y - 1; y >= b.Min.Y; y-- {
+		src := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := 0; x < w; x++ {
+			i := x * 4
+			r, g, bl, a := src[i], src[i+1], src[i+2], src[i+3]
+			if a != 0 && a != 255 {
+				// RGBA is premultiplied, DIBV5 readers expect straight alpha
+				r, g, bl = unpremultiply(r, a), unpremultiply(g, a), unpremultiply(bl, a)
 			}
+			row[i], row[i+1], row[i+2], row[i+3] = bl, g, r, a
 		}
-	default:
-		return nil, errors.New("unsupported bit depth: only 24-bit and 32-bit images are supported")
+		buf.Write(row)
+	}
+	return buf.Bytes()
+}
+
+func unpremultiply(c, a uint8) uint8 {
+	return uint8((uint32(c)*255 + uint32(a)/2) / uint32(a))
+}
+
+// encodeDIB packs img as a bottom-up 24-bit DIB, blending transparent
+// pixels over white since CF_DIB has no alpha
+func encodeDIB(img *image.RGBA) []byte {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	stride := (w*3 + 3) &^ 3
+	header := BITMAPINFOHEADER{
+		BiSize:        bitmapInfoLen,
+		BiWidth:       int32(w),
+		BiHeight:      int32(h),
+		BiPlanes:      1,
+		BiBitCount:    24,
+		BiCompression: biRGB,
+		BiSizeImage:   uint32(stride * h),
 	}
 
-	// Encode to PNG and return as CaptureResult
 	var buf bytes.Buffer
-	if err := png.Encode(&buf, img); err != nil {
-		return nil, err
+	buf.Grow(bitmapInfoLen + stride*h)
+	binary.Write(&buf, binary.LittleEndian, header)
+	row := make([]byte, stride)
+	for y := b.Max.Y - 1; y >= b.Min.Y; y-- {
+		src := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := 0; x < w; x++ {
+			// RGBA is premultiplied, so adding the uncovered share of white
+			// composites the pixel over white
+			white := 255 - src[x*4+3]
+			row[x*3], row[x*3+1], row[x*3+2] = src[x*4+2]+white, src[x*4+1]+white, src[x*4]+white
+		}
+		buf.Write(row)
 	}
+	return buf.Bytes()
+}
 
-	return &CaptureResult{
-		Width:  width,
-		Height: height,
-		Data:   base64.StdEncoding.EncodeToString(buf.Bytes()),
-	}, nil
+// dropFilesSize is the size of DROPFILES, which precedes the file list in
+// CF_HDROP data
+const dropFilesSize = 20
+
+// dropEffectCopy is DROPEFFECT_COPY for the "Preferred DropEffect" format
+const dropEffectCopy = 1
+
+// SetClipboardFiles replaces the clipboard contents with a file drop list
+// of paths and their text, one per line. Pasting into Explorer copies the
+// files, chat and mail apps attach them, and text fields get the paths
+func SetClipboardFiles(paths ...string) error {
+	if len(paths) == 0 {
+		return errors.New("no files to copy")
+	}
+	drop, err := encodeDropFiles(paths)
+	if err != nil {
+		return err
+	}
+	text, err := windows.UTF16FromString(strings.Join(paths, "\r\n"))
+	if err != nil {
+		return err
+	}
+	entries := []clipboardEntry{
+		{format: CF_HDROP, data: drop},
+		{format: CF_UNICODETEXT, data: unsafe.Slice((*byte)(unsafe.Pointer(&text[0])), len(text)*2)},
+	}
+
+	// Tells Explorer to copy rather than move the files on paste
+	name, _ := windows.UTF16PtrFromString("Preferred DropEffect")
+	if cf, _, _ := procRegisterClipboardFormat.Call(uintptr(unsafe.Pointer(name))); cf != 0 {
+		entries = append(entries, clipboardEntry{format: cf, data: binary.LittleEndian.AppendUint32(nil, dropEffectCopy)})
+	}
+	return setClipboard(entries...)
+}
+
+// encodeDropFiles packs paths as CF_HDROP data: a DROPFILES header followed
+// by NUL-terminated UTF-16 paths and a final NUL
+func encodeDropFiles(paths []string) ([]byte, error) {
+	data := make([]byte, dropFilesSize)
+	binary.LittleEndian.PutUint32(data[0:], dropFilesSize) // pFiles
+	binary.LittleEndian.PutUint32(data[16:], 1)            // fWide
+	for _, p := range paths {
+		u, err := windows.UTF16FromString(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range u {
+			data = binary.LittleEndian.AppendUint16(data, c)
+		}
+	}
+	return binary.LittleEndian.AppendUint16(data, 0), nil
 }
@@ -0,0 +1,89 @@
+package screenshot
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+	"unicode/utf16"
+)
+
+// clipboardTestImage is 2x2: opaque red top left, half transparent white top
+// right, fully transparent below
+func clipboardTestImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	img.SetRGBA(1, 0, color.RGBA{128, 128, 128, 128})
+	return img
+}
+
+func TestEncodeDIBV5(t *testing.T) {
+	data := encodeDIBV5(clipboardTestImage())
+	if len(data) != bitmapV5Size+2*2*4 {
+		t.Fatalf("len = %d, want %d", len(data), bitmapV5Size+16)
+	}
+	if size := binary.LittleEndian.Uint32(data); size != bitmapV5Size {
+		t.Errorf("header size = %d, want %d", size, bitmapV5Size)
+	}
+	if mask := binary.LittleEndian.Uint32(data[52:]); mask != 0xff000000 {
+		t.Errorf("alpha mask = 0x%08x, want 0xff000000", mask)
+	}
+
+	// Bottom-up BGRA rows: the image's top row comes last
+	top := data[bitmapV5Size+8:]
+	if got := [4]byte(top[0:4]); got != [4]byte{0, 0, 255, 255} {
+		t.Errorf("red pixel = %v, want BGRA 0 0 255 255", got)
+	}
+	if got := [4]byte(top[4:8]); got != [4]byte{255, 255, 255, 128} {
+		t.Errorf("translucent pixel = %v, want straight alpha white", got)
+	}
+}
+
+func TestEncodeDIB(t *testing.T) {
+	data := encodeDIB(clipboardTestImage())
+	const stride = 8 // 2 pixels * 3 bytes, padded to 4
+	if len(data) != bitmapInfoLen+2*stride {
+		t.Fatalf("len = %d, want %d", len(data), bitmapInfoLen+2*stride)
+	}
+	if bits := binary.LittleEndian.Uint16(data[14:]); bits != 24 {
+		t.Errorf("bit count = %d, want 24", bits)
+	}
+
+	bottom, top := data[bitmapInfoLen:], data[bitmapInfoLen+stride:]
+	if got := [3]byte(bottom[0:3]); got != [3]byte{255, 255, 255} {
+		t.Errorf("transparent pixel = %v, want white", got)
+	}
+	if got := [3]byte(top[0:3]); got != [3]byte{0, 0, 255} {
+		t.Errorf("red pixel = %v, want BGR 0 0 255", got)
+	}
+	if got := [3]byte(top[3:6]); got != [3]byte{255, 255, 255} {
+		t.Errorf("translucent white = %v, want white", got)
+	}
+}
+
+func TestEncodeDropFiles(t *testing.T) {
+	data, err := encodeDropFiles([]string{`C:\a.png`, `D:\b`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off := binary.LittleEndian.Uint32(data); off != dropFilesSize {
+		t.Errorf("pFiles = %d, want %d", off, dropFilesSize)
+	}
+	if wide := binary.LittleEndian.Uint32(data[16:]); wide != 1 {
+		t.Errorf("fWide = %d, want 1", wide)
+	}
+
+	var list []uint16
+	for i := dropFilesSize; i < len(data); i += 2 {
+		list = append(list, binary.LittleEndian.Uint16(data[i:]))
+	}
+	want := append(append(utf16.Encode([]rune(`C:\a.png`)), 0), append(utf16.Encode([]rune(`D:\b`)), 0, 0)...)
+	if len(list) != len(want) {
+		t.Fatalf("file list = %v, want %v", list, want)
+	}
+	for i := range want {
+		if list[i] != want[i] {
+			t.Fatalf("file list = %v, want %v", list, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package screenshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/url"
+	"strings"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	winEnum "winshot/internal/windows"
+)
+
+var procGetClipboardOwner = user32Clip.NewProc("GetClipboardOwner")
+
+// metersPerInch converts DIB pixels per meter to DPI
+const metersPerInch = 0.0254
+
+// ClipboardMetadata describes where a pasted image came from, as far as the
+// clipboard tells
+type ClipboardMetadata struct {
+	SourceURL string `json:"sourceUrl,omitempty"` // Page the image was copied from, from CF_HTML or a URL copied with it
+	SourceApp string `json:"sourceApp,omitempty"` // Executable that owns the clipboard, e.g. "chrome.exe"
+	DPI       int    `json:"dpi,omitempty"`       // Horizontal resolution from the DIB header, 0 if unset
+}
+
+// readClipboardMetadata collects the metadata offered next to the image.
+// The clipboard must be open. dib is the CF_DIB or CF_DIBV5 block the image
+// was read from, or nil for other formats. It returns nil when nothing is
+// known
+func readClipboardMetadata(dib []byte) *ClipboardMetadata {
+	meta := ClipboardMetadata{DPI: dibDPI(dib)}
+	if owner, _, _ := procGetClipboardOwner.Call(); owner != 0 {
+		meta.SourceApp = winEnum.ProcessImageName(winEnum.WindowProcessID(owner))
+	}
+	if cfHTML := getHTMLClipboardFormat(); cfHTML != 0 {
+		if data := clipboardFormatBytes(cfHTML); data != nil {
+			meta.SourceURL = htmlSourceURL(data)
+		}
+	}
+	if meta.SourceURL == "" {
+		if data := clipboardFormatBytes(CF_UNICODETEXT); data != nil {
+			meta.SourceURL = textURL(utf16Text(data))
+		}
+	}
+	if meta == (ClipboardMetadata{}) {
+		return nil
+	}
+	return &meta
+}
+
+// getHTMLClipboardFormat registers and returns the CF_HTML clipboard format ID
+func getHTMLClipboardFormat() uintptr {
+	name, _ := windows.UTF16PtrFromString("HTML Format")
+	cfHTML, _, _ := procRegisterClipboardFormat.Call(uintptr(unsafe.Pointer(name)))
+	return cfHTML
+}
+
+// clipboardFormatBytes copies a format from the open clipboard, or returns
+// nil if it is not there
+func clipboardFormatBytes(format uintptr) []byte {
+	if available, _, _ := procIsClipboardFormatAvailable.Call(format); available == 0 {
+		return nil
+	}
+	hData, _, _ := procGetClipboardData.Call(format)
+	if hData == 0 {
+		return nil
+	}
+	data, err := clipboardBytes(hData)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// htmlSourceURL returns the SourceURL field of a CF_HTML block. The fields
+// are "Name:value" lines before the HTML itself
+func htmlSourceURL(data []byte) string {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "<") {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(name, "SourceURL") {
+			return textURL(value)
+		}
+	}
+	return ""
+}
+
+// textURL returns s if it is a single absolute http or https URL, as copied
+// from an address bar, or "" otherwise
+func textURL(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, " \t\r\n") {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return s
+}
+
+// utf16Text decodes CF_UNICODETEXT, which ends at the first NUL
+func utf16Text(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		u := binary.LittleEndian.Uint16(data[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+// dibDPI returns the horizontal resolution a DIB header declares, or 0 if it
+// declares none. biXPelsPerMeter is at offset 24 in every header version
+func dibDPI(dib []byte) int {
+	if len(dib) < bitmapInfoLen {
+		return 0
+	}
+	ppm := int32(binary.LittleEndian.Uint32(dib[24:]))
+	if ppm <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(ppm) * metersPerInch))
+}
@@ -0,0 +1,79 @@
+package screenshot
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestHTMLSourceURL(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			"chrome",
+			"Version:0.9\r\nStartHTML:0000000105\r\nEndHTML:0000000300\r\nSourceURL:https://example.com/a/b.png?x=1\r\n<html><body>",
+			"https://example.com/a/b.png?x=1",
+		},
+		{"no source", "Version:0.9\r\nStartHTML:0000000105\r\n<html>SourceURL:https://late.example</html>", ""},
+		{"not http", "Version:0.9\r\nSourceURL:file:///C:/a.html\r\n<html>", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		if got := htmlSourceURL([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: htmlSourceURL = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTextURL(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"  https://example.com/page \r\n", "https://example.com/page"},
+		{"http://localhost:8080", "http://localhost:8080"},
+		{"see https://example.com", ""},
+		{"example.com", ""},
+		{"ftp://example.com/file", ""},
+		{"https://", ""},
+	}
+	for _, tt := range tests {
+		if got := textURL(tt.text); got != tt.want {
+			t.Errorf("textURL(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestUTF16Text(t *testing.T) {
+	var data []byte
+	for _, u := range append(utf16.Encode([]rune("héllo 📷")), 0, 'x') {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	if got := utf16Text(data); got != "héllo 📷" {
+		t.Errorf("utf16Text = %q, want text up to the NUL", got)
+	}
+	if got := utf16Text([]byte{'a'}); got != "" {
+		t.Errorf("utf16Text of an odd byte = %q, want empty", got)
+	}
+}
+
+func TestDIBDPI(t *testing.T) {
+	data := encodeDIB(clipboardTestImage())
+	if got := dibDPI(data); got != 0 {
+		t.Errorf("DPI without resolution = %d, want 0", got)
+	}
+	binary.LittleEndian.PutUint32(data[24:], 5906) // 150 DPI
+	if got := dibDPI(data); got != 150 {
+		t.Errorf("DPI = %d, want 150", got)
+	}
+	binary.LittleEndian.PutUint32(data[24:], 0xffffffff)
+	if got := dibDPI(data); got != 0 {
+		t.Errorf("DPI with negative resolution = %d, want 0", got)
+	}
+	if got := dibDPI(data[:10]); got != 0 {
+		t.Errorf("DPI of a truncated header = %d, want 0", got)
+	}
+}
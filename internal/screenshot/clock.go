@@ -0,0 +1,51 @@
+package screenshot
+
+import (
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32Clock                 = windows.NewLazySystemDLL("kernel32.dll")
+	procQueryPerformanceCounter   = kernel32Clock.NewProc("QueryPerformanceCounter")
+	procQueryPerformanceFrequency = kernel32Clock.NewProc("QueryPerformanceFrequency")
+)
+
+// FrameTime is when pixels were read from the screen, in ticks of the
+// QueryPerformanceCounter clock that DXGI, Media Foundation and other Windows
+// media APIs stamp frames with, so captures can be lined up with recordings
+// and other capture sequences
+type FrameTime struct {
+	Start     int64 // Counter when the read began
+	End       int64 // Counter when it finished
+	Frequency int64 // Counter ticks per second
+}
+
+// lastFrame is the latest screen read, guarded by captureMu
+var lastFrame FrameTime
+
+// qpcFrequency is fixed at boot, so it is read once
+var qpcFrequency = sync.OnceValue(func() int64 {
+	var f int64
+	procQueryPerformanceFrequency.Call(uintptr(unsafe.Pointer(&f)))
+	return f
+})
+
+// qpcNow returns the current QueryPerformanceCounter value
+func qpcNow() int64 {
+	var c int64
+	procQueryPerformanceCounter.Call(uintptr(unsafe.Pointer(&c)))
+	return c
+}
+
+// LastFrameTime returns when the latest display, region or window capture
+// read the screen, or a zero FrameTime before the first one. Call it right
+// after a capture to stamp that capture; PrintWindow captures don't read the
+// screen and leave it unchanged
+func LastFrameTime() FrameTime {
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	return lastFrame
+}
@@ -0,0 +1,231 @@
+package screenshot
+
+import (
+	"image"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	gdi32Cursor = windows.NewLazySystemDLL("gdi32.dll")
+
+	procGetCursorInfo          = user32Win.NewProc("GetCursorInfo")
+	procGetIconInfo            = user32Win.NewProc("GetIconInfo")
+	procDrawIconEx             = user32Win.NewProc("DrawIconEx")
+	procGetDCCursor            = user32Win.NewProc("GetDC")
+	procReleaseDCCursor        = user32Win.NewProc("ReleaseDC")
+	procCreateCompatibleDC     = gdi32Cursor.NewProc("CreateCompatibleDC")
+	procCreateCompatibleBitmap = gdi32Cursor.NewProc("CreateCompatibleBitmap")
+	procSelectObject           = gdi32Cursor.NewProc("SelectObject")
+	procDeleteObject           = gdi32Cursor.NewProc("DeleteObject")
+	procDeleteDC               = gdi32Cursor.NewProc("DeleteDC")
+	procGetDIBits              = gdi32Cursor.NewProc("GetDIBits")
+	procGetObjectW             = gdi32Cursor.NewProc("GetObjectW")
+	procPatBlt                 = gdi32Cursor.NewProc("PatBlt")
+)
+
+const (
+	CURSOR_SHOWING = 0x00000001
+	DI_NORMAL      = 0x0003
+	BLACKNESS      = 0x00000042
+	WHITENESS      = 0x00FF0062
+	DIB_RGB_COLORS = 0
+	BI_RGB         = 0
+)
+
+// CURSORINFO structure
+type CURSORINFO struct {
+	CbSize      uint32
+	Flags       uint32
+	HCursor     uintptr
+	PtScreenPos POINT
+}
+
+// ICONINFO structure
+type ICONINFO struct {
+	FIcon    int32
+	XHotspot uint32
+	YHotspot uint32
+	HbmMask  uintptr
+	HbmColor uintptr
+}
+
+// BITMAP structure (GetObject result for bitmaps)
+type BITMAP struct {
+	BmType       int32
+	BmWidth      int32
+	BmHeight     int32
+	BmWidthBytes int32
+	BmPlanes     uint16
+	BmBitsPixel  uint16
+	BmBits       uintptr
+}
+
+// CursorSnapshot is the mouse cursor image at the time it was taken
+type CursorSnapshot struct {
+	Image *image.RGBA
+	Pos   image.Point // Top-left in screen coordinates
+}
+
+// SnapshotCursor captures the current mouse cursor image and position.
+// Returns nil if the cursor is hidden or cannot be read.
+func SnapshotCursor() *CursorSnapshot {
+	ci := CURSORINFO{CbSize: uint32(unsafe.Sizeof(CURSORINFO{}))}
+	ret, _, _ := procGetCursorInfo.Call(uintptr(unsafe.Pointer(&ci)))
+	if ret == 0 || ci.Flags&CURSOR_SHOWING == 0 || ci.HCursor == 0 {
+		return nil
+	}
+
+	var ii ICONINFO
+	ret, _, _ = procGetIconInfo.Call(ci.HCursor, uintptr(unsafe.Pointer(&ii)))
+	if ret == 0 {
+		return nil
+	}
+	// GetIconInfo hands ownership of the bitmaps to the caller
+	defer procDeleteObject.Call(ii.HbmMask)
+	if ii.HbmColor != 0 {
+		defer procDeleteObject.Call(ii.HbmColor)
+	}
+
+	var bm BITMAP
+	procGetObjectW.Call(ii.HbmMask, unsafe.Sizeof(bm), uintptr(unsafe.Pointer(&bm)))
+	width, height := int(bm.BmWidth), int(bm.BmHeight)
+	if ii.HbmColor == 0 {
+		// Monochrome cursors stack the AND and XOR masks vertically
+		height /= 2
+	}
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	cursor := renderCursor(ci.HCursor, width, height)
+	if cursor == nil {
+		return nil
+	}
+
+	return &CursorSnapshot{
+		Image: cursor,
+		Pos: image.Pt(
+			int(ci.PtScreenPos.X)-int(ii.XHotspot),
+			int(ci.PtScreenPos.Y)-int(ii.YHotspot),
+		),
+	}
+}
+
+// DrawOn composites the cursor onto img, which was captured from the screen
+// rectangle starting at origin. Safe to call on a nil snapshot.
+func (c *CursorSnapshot) DrawOn(img *image.RGBA, origin image.Point) {
+	if c == nil {
+		return
+	}
+	compositeOver(img, c.Image, c.Pos.Sub(origin))
+}
+
+// includeCursor is set by SetIncludeCursor
+var includeCursor atomic.Bool
+
+// SetIncludeCursor sets whether display, region and window captures include
+// the mouse cursor even when CaptureOptions.IncludeCursor isn't set, for
+// tutorials that need the pointer visible. CaptureVirtualScreenRaw, which
+// backs the selection overlay and color picker, and PrintWindow captures are
+// never affected
+func SetIncludeCursor(on bool) {
+	includeCursor.Store(on)
+}
+
+// drawCursor composites the current mouse cursor onto img, which was captured
+// from the screen rectangle starting at origin. Does nothing if the cursor is hidden.
+func drawCursor(img *image.RGBA, origin image.Point) {
+	SnapshotCursor().DrawOn(img, origin)
+}
+
+// renderCursor draws the cursor over black and white backgrounds and derives
+// per-pixel alpha from the difference, which works for both color and
+// monochrome cursors (DrawIconEx leaves alpha undefined for the latter)
+func renderCursor(hCursor uintptr, width, height int) *image.RGBA {
+	hdcScreen, _, _ := procGetDCCursor.Call(0)
+	if hdcScreen == 0 {
+		return nil
+	}
+	defer procReleaseDCCursor.Call(0, hdcScreen)
+
+	hdcMem, _, _ := procCreateCompatibleDC.Call(hdcScreen)
+	if hdcMem == 0 {
+		return nil
+	}
+	defer procDeleteDC.Call(hdcMem)
+
+	hBitmap, _, _ := procCreateCompatibleBitmap.Call(hdcScreen, uintptr(width), uintptr(height))
+	if hBitmap == 0 {
+		return nil
+	}
+	defer procDeleteObject.Call(hBitmap)
+
+	oldBitmap, _, _ := procSelectObject.Call(hdcMem, hBitmap)
+	defer procSelectObject.Call(hdcMem, oldBitmap)
+
+	render := func(rop uintptr) []byte {
+		procPatBlt.Call(hdcMem, 0, 0, uintptr(width), uintptr(height), rop)
+		procDrawIconEx.Call(hdcMem, 0, 0, hCursor, uintptr(width), uintptr(height), 0, 0, DI_NORMAL)
+
+		bmi := BITMAPINFOHEADER{
+			BiSize:        uint32(unsafe.Sizeof(BITMAPINFOHEADER{})),
+			BiWidth:       int32(width),
+			BiHeight:      -int32(height), // Negative for top-down
+			BiPlanes:      1,
+			BiBitCount:    32,
+			BiCompression: BI_RGB,
+		}
+		pix := make([]byte, width*height*4)
+		procGetDIBits.Call(hdcMem, hBitmap, 0, uintptr(height),
+			uintptr(unsafe.Pointer(&pix[0])),
+			uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS)
+		return pix
+	}
+
+	onBlack := render(BLACKNESS)
+	onWhite := render(WHITENESS)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(onBlack); i += 4 {
+		// BGRA order from GDI; use the green channel to derive coverage
+		alpha := 255 - (int(onWhite[i+1]) - int(onBlack[i+1]))
+		if alpha <= 0 {
+			continue
+		}
+		if alpha > 255 {
+			alpha = 255
+		}
+		// Colors drawn over black are premultiplied by alpha
+		img.Pix[i+0] = uint8(min(255, int(onBlack[i+2])*255/alpha))
+		img.Pix[i+1] = uint8(min(255, int(onBlack[i+1])*255/alpha))
+		img.Pix[i+2] = uint8(min(255, int(onBlack[i+0])*255/alpha))
+		img.Pix[i+3] = uint8(alpha)
+	}
+	return img
+}
+
+// compositeOver alpha-blends src (straight alpha) onto dst with its top-left at pos
+func compositeOver(dst, src *image.RGBA, pos image.Point) {
+	sb := src.Bounds()
+	for y := 0; y < sb.Dy(); y++ {
+		for x := 0; x < sb.Dx(); x++ {
+			dx, dy := pos.X+x+dst.Rect.Min.X, pos.Y+y+dst.Rect.Min.Y
+			if !(image.Point{X: dx, Y: dy}.In(dst.Rect)) {
+				continue
+			}
+			si := src.PixOffset(sb.Min.X+x, sb.Min.Y+y)
+			a := int(src.Pix[si+3])
+			if a == 0 {
+				continue
+			}
+			di := dst.PixOffset(dx, dy)
+			for c := 0; c < 3; c++ {
+				dst.Pix[di+c] = uint8((int(src.Pix[si+c])*a + int(dst.Pix[di+c])*(255-a)) / 255)
+			}
+			dst.Pix[di+3] = uint8(a + int(dst.Pix[di+3])*(255-a)/255)
+		}
+	}
+}
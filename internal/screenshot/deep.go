@@ -0,0 +1,124 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// CaptureRectDeep captures a rectangle of the virtual screen with 16 bits
+// per channel through DXGI Desktop Duplication. On displays composed in
+// high precision, such as wide-gamut and HDR outputs, this keeps the steps
+// between colors that an 8-bit capture rounds away. Other displays give
+// 8-bit values widened to 16 bits. Colors are sRGB encoded, and HDR
+// highlights brighter than SDR white are clipped. The region is clipped to
+// the virtual screen. PostCapture filters do not run on deep captures
+func CaptureRectDeep(r image.Rectangle) (*image.RGBA64, error) {
+	r, err := ClampRect(r, VirtualScreenRect())
+	if err != nil {
+		return nil, err
+	}
+	if !(dxgiBackend{}).Available() {
+		return nil, fmt.Errorf("%w: %q", ErrCaptureBackendUnavailable, BackendDXGI)
+	}
+	if err := checkInputDesktop(); err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA64(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for i := 6; i < len(img.Pix); i += 8 {
+		img.Pix[i], img.Pix[i+1] = 0xff, 0xff // Opaque black between monitors
+	}
+
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	start := qpcNow()
+	if err := duplicateRect(r, rgba64Sink{img}, true); err != nil {
+		return nil, err
+	}
+	lastFrame = FrameTime{Start: start, End: qpcNow(), Frequency: qpcFrequency()}
+	return img, nil
+}
+
+// rgba64Sink collects a 16-bit capture from float or 8-bit frames
+type rgba64Sink struct{ img *image.RGBA64 }
+
+func (s rgba64Sink) put(format uint32, data []byte, pitch int, src image.Rectangle, dst image.Point) error {
+	switch format {
+	case dxgiFormatR16G16B16A16Float:
+		copyFloat16(s.img, dst, data, pitch, src)
+	case dxgiFormatB8G8R8A8:
+		copyBGRA64(s.img, dst, data, pitch, src)
+	default:
+		return fmt.Errorf("unsupported desktop format %d", format)
+	}
+	return nil
+}
+
+// copyFloat16 copies src from a scRGB surface of half floats (linear light,
+// 1.0 is SDR white) into img at dst as opaque sRGB encoded 16-bit values
+func copyFloat16(img *image.RGBA64, dst image.Point, data []byte, pitch int, src image.Rectangle) {
+	for y := 0; y < src.Dy(); y++ {
+		in := data[(src.Min.Y+y)*pitch+src.Min.X*8:]
+		out := img.Pix[img.PixOffset(dst.X, dst.Y+y):]
+		for x := 0; x < src.Dx(); x++ {
+			for c := 0; c < 3; c++ {
+				i := x*8 + c*2
+				v := linearToSRGB16(halfToFloat(uint16(in[i]) | uint16(in[i+1])<<8))
+				out[i], out[i+1] = byte(v>>8), byte(v)
+			}
+			out[x*8+6], out[x*8+7] = 0xff, 0xff
+		}
+	}
+}
+
+// copyBGRA64 copies src from a BGRA surface into img at dst, widening each
+// channel to 16 bits
+func copyBGRA64(img *image.RGBA64, dst image.Point, data []byte, pitch int, src image.Rectangle) {
+	for y := 0; y < src.Dy(); y++ {
+		in := data[(src.Min.Y+y)*pitch+src.Min.X*4:]
+		out := img.Pix[img.PixOffset(dst.X, dst.Y+y):]
+		for x := 0; x < src.Dx(); x++ {
+			b, g, r := in[x*4], in[x*4+1], in[x*4+2]
+			o := out[x*8 : x*8+8]
+			o[0], o[1], o[2], o[3], o[4], o[5], o[6], o[7] = r, r, g, g, b, b, 0xff, 0xff
+		}
+	}
+}
+
+// halfToFloat decodes an IEEE 754 half precision float
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch exp {
+	case 0:
+		// Zero or subnormal: frac * 2^-24
+		v := float32(frac) / (1 << 24)
+		if sign != 0 {
+			v = -v
+		}
+		return v
+	case 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13) // Infinity or NaN
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+}
+
+// linearToSRGB16 applies the sRGB transfer function to a linear value,
+// clipped to the SDR range, and scales it to 16 bits
+func linearToSRGB16(v float32) uint16 {
+	if !(v > 0) {
+		return 0 // Also NaN
+	}
+	if v >= 1 {
+		return 0xffff
+	}
+	var e float64
+	if v <= 0.0031308 {
+		e = 12.92 * float64(v)
+	} else {
+		e = 1.055*math.Pow(float64(v), 1/2.4) - 0.055
+	}
+	return uint16(math.Round(e * 0xffff))
+}
@@ -0,0 +1,62 @@
+package screenshot
+
+import (
+	"image"
+	"testing"
+)
+
+func TestHalfToFloat(t *testing.T) {
+	tests := []struct {
+		h    uint16
+		want float32
+	}{
+		{0x0000, 0},
+		{0x3c00, 1},
+		{0xbc00, -1},
+		{0x3800, 0.5},
+		{0x4000, 2},
+		{0x0001, 1.0 / (1 << 24)}, // Smallest subnormal
+	}
+
+	for _, tt := range tests {
+		if got := halfToFloat(tt.h); got != tt.want {
+			t.Errorf("halfToFloat(0x%04x) = %v, want %v", tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestLinearToSRGB16(t *testing.T) {
+	tests := []struct {
+		v    float32
+		want uint16
+	}{
+		{-0.5, 0},
+		{0, 0},
+		{0.18, 30235}, // Middle gray
+		{1, 0xffff},
+		{4, 0xffff}, // HDR highlight clipped
+	}
+
+	for _, tt := range tests {
+		if got := linearToSRGB16(tt.v); got != tt.want {
+			t.Errorf("linearToSRGB16(%v) = %d, want %d", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCopyFloat16(t *testing.T) {
+	// One row of two pixels: white, then half-intensity linear red
+	data := []byte{
+		0x00, 0x3c, 0x00, 0x3c, 0x00, 0x3c, 0x00, 0x3c,
+		0x00, 0x38, 0x00, 0x00, 0x00, 0x00, 0x00, 0x3c,
+	}
+	img := image.NewRGBA64(image.Rect(0, 0, 2, 1))
+	copyFloat16(img, image.Point{}, data, len(data), image.Rect(0, 0, 2, 1))
+
+	if c := img.RGBA64At(0, 0); c.R != 0xffff || c.G != 0xffff || c.B != 0xffff || c.A != 0xffff {
+		t.Errorf("white = %v", c)
+	}
+	if c := img.RGBA64At(1, 0); c.R != linearToSRGB16(0.5) || c.G != 0 || c.B != 0 || c.A != 0xffff {
+		t.Errorf("red = %v", c)
+	}
+}
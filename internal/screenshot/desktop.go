@@ -0,0 +1,78 @@
+package screenshot
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procOpenInputDesktop          = user32Win.NewProc("OpenInputDesktop")
+	procCloseDesktop              = user32Win.NewProc("CloseDesktop")
+	procGetUserObjectInformationW = user32Win.NewProc("GetUserObjectInformationW")
+)
+
+// Desktop object constants
+const (
+	DESKTOP_READOBJECTS = 0x0001
+	UOI_NAME            = 2
+)
+
+// userDesktop is the name of the interactive desktop of a user session
+const userDesktop = "Default"
+
+// InputDesktop returns the name of the desktop currently receiving input:
+// "Default" for the user's desktop, "Winlogon" for UAC prompts, the lock
+// screen and Ctrl+Alt+Del, or "Screen-saver". Secure desktops can't be opened
+// from a user session at all, in which case an error is returned.
+func InputDesktop() (string, error) {
+	hDesk, _, callErr := procOpenInputDesktop.Call(0, 0, DESKTOP_READOBJECTS)
+	if hDesk == 0 {
+		return "", callErr
+	}
+	defer procCloseDesktop.Call(hDesk)
+
+	var buf [64]uint16
+	var needed uint32
+	ret, _, callErr := procGetUserObjectInformationW.Call(
+		hDesk,
+		UOI_NAME,
+		uintptr(unsafe.Pointer(&buf[0])),
+		unsafe.Sizeof(buf),
+		uintptr(unsafe.Pointer(&needed)),
+	)
+	if ret == 0 {
+		return "", callErr
+	}
+	return windows.UTF16ToString(buf[:]), nil
+}
+
+// SecureDesktopActive reports whether input has moved to a desktop the screen
+// can't be read from, such as a UAC prompt or the lock screen
+func SecureDesktopActive() bool {
+	return desktopError(InputDesktop()) != nil
+}
+
+// checkInputDesktop returns ErrSecureDesktop when screen reads would only
+// produce a black image.
+//
+// Capturing the secure desktop would need a service running as SYSTEM on the
+// Winlogon desktop. WinShot deliberately doesn't ship one, so captures are
+// refused with a clear error instead of failing silently.
+func checkInputDesktop() error {
+	return desktopError(InputDesktop())
+}
+
+// desktopError maps the input desktop name, or the error opening it, to
+// ErrSecureDesktop unless it is the user's own desktop
+func desktopError(name string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%w: input desktop is not accessible (%v)", ErrSecureDesktop, err)
+	}
+	if !strings.EqualFold(name, userDesktop) {
+		return fmt.Errorf("%w: input is on the %q desktop", ErrSecureDesktop, name)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package screenshot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDesktopError(t *testing.T) {
+	tests := []struct {
+		name       string
+		desktop    string
+		openErr    error
+		wantSecure bool
+	}{
+		{"user desktop", "Default", nil, false},
+		{"case insensitive", "default", nil, false},
+		{"uac or lock screen", "Winlogon", nil, true},
+		{"screen saver", "Screen-saver", nil, true},
+		{"access denied", "", errors.New("Access is denied."), true},
+	}
+
+	for _, tt := range tests {
+		err := desktopError(tt.desktop, tt.openErr)
+		if got := errors.Is(err, ErrSecureDesktop); got != tt.wantSecure {
+			t.Errorf("%s: desktopError() = %v, want secure %v", tt.name, err, tt.wantSecure)
+		}
+	}
+}
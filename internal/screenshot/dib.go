@@ -0,0 +1,84 @@
+package screenshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+)
+
+// decodeDIB converts a CF_DIB or CF_DIBV5 clipboard block, copied out of
+// global memory, to RGBA. Every size and offset is checked against len(data)
+// rather than trusted from the header, since any program can put a DIB on
+// the clipboard. Only uncompressed 24 and 32-bit images are supported
+func decodeDIB(data []byte) (*image.RGBA, error) {
+	if len(data) < bitmapInfoLen {
+		return nil, errors.New("invalid clipboard data: bitmap header truncated")
+	}
+	var header BITMAPINFOHEADER
+	if err := binary.Read(bytes.NewReader(data[:bitmapInfoLen]), binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.BiSize < bitmapInfoLen || uint64(header.BiSize) > uint64(len(data)) {
+		return nil, errors.New("invalid clipboard data: bad bitmap header size")
+	}
+
+	bitCount := int(header.BiBitCount)
+	if bitCount != 24 && bitCount != 32 {
+		return nil, errors.New("unsupported bit depth: only 24-bit and 32-bit images are supported")
+	}
+	if header.BiCompression != biRGB && header.BiCompression != biBitfields {
+		return nil, errors.New("unsupported clipboard bitmap compression")
+	}
+
+	// Height can be negative (top-down DIB) or positive (bottom-up DIB)
+	width := int64(header.BiWidth)
+	height := int64(header.BiHeight)
+	bottomUp := height > 0
+	if height < 0 {
+		height = -height
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("invalid image dimensions in clipboard")
+	}
+
+	// Pixels follow the header, the three color masks that a plain
+	// BITMAPINFOHEADER carries for BI_BITFIELDS, and an optional color table
+	// that is unused at these depths. biSizeImage is often wrong, so the
+	// pixel size comes from the dimensions
+	offset := uint64(header.BiSize)
+	if header.BiCompression == biBitfields && header.BiSize == bitmapInfoLen {
+		offset += 12
+	}
+	offset += uint64(header.BiClrUsed) * 4
+	rowSize := uint64((width*int64(bitCount) + 31) / 32 * 4)
+	avail := uint64(len(data))
+	if offset > avail || rowSize > avail || rowSize*uint64(height) > avail-offset {
+		return nil, errors.New("invalid clipboard data: pixel data smaller than expected")
+	}
+	pixels := data[offset:]
+
+	w, h, stride := int(width), int(height), int(rowSize)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	bpp := bitCount / 8
+	for y := 0; y < h; y++ {
+		srcY := y
+		if bottomUp {
+			srcY = h - 1 - y
+		}
+		row := pixels[srcY*stride : srcY*stride+w*bpp]
+		dst := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		for x := 0; x < w; x++ {
+			src := row[x*bpp:]
+			dst[x*4+0] = src[2]
+			dst[x*4+1] = src[1]
+			dst[x*4+2] = src[0]
+			dst[x*4+3] = 255
+			// Some apps set alpha to 0 for opaque pixels, handle this
+			if bpp == 4 && src[3] != 0 {
+				dst[x*4+3] = src[3]
+			}
+		}
+	}
+	return img, nil
+}
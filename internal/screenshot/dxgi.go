@@ -0,0 +1,358 @@
+package screenshot
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"winshot/internal/com"
+)
+
+var (
+	dxgi  = windows.NewLazySystemDLL("dxgi.dll")
+	d3d11 = windows.NewLazySystemDLL("d3d11.dll")
+
+	procCreateDXGIFactory1 = dxgi.NewProc("CreateDXGIFactory1")
+	procD3D11CreateDevice  = d3d11.NewProc("D3D11CreateDevice")
+)
+
+var (
+	iidIDXGIFactory1   = windows.GUID{Data1: 0x770aae78, Data2: 0xf26f, Data3: 0x4dba, Data4: [8]byte{0xa8, 0x29, 0x25, 0x3c, 0x83, 0xd1, 0xb3, 0x87}}
+	iidIDXGIOutput1    = windows.GUID{Data1: 0x00cddea8, Data2: 0x939b, Data3: 0x4b83, Data4: [8]byte{0xa3, 0x40, 0xa6, 0x85, 0x22, 0x66, 0x66, 0xcc}}
+	iidIDXGIOutput5    = windows.GUID{Data1: 0x80a07424, Data2: 0xab52, Data3: 0x42eb, Data4: [8]byte{0x83, 0x3c, 0x0c, 0x42, 0xfd, 0x28, 0x2d, 0x98}}
+	iidID3D11Texture2D = windows.GUID{Data1: 0x6f15aaf2, Data2: 0xd208, Data3: 0x4e89, Data4: [8]byte{0x9a, 0xb4, 0x48, 0x95, 0x35, 0xd3, 0x4f, 0x9c}}
+)
+
+// DXGI and Direct3D 11 constants from dxgi.h, dxgi1_2.h and d3d11.h
+const (
+	d3dDriverTypeUnknown = 0
+	d3d11SDKVersion      = 7
+
+	d3d11UsageStaging           = 3
+	d3d11CPUAccessRead          = 0x20000
+	d3d11MapRead                = 1
+	dxgiFormatB8G8R8A8          = 87
+	dxgiFormatR16G16B16A16Float = 10
+	dxgiModeRotationId          = 1
+	dxgiErrorNotFound           = 0x887A0002
+	dxgiErrorWaitTimeout        = 0x887A0027
+)
+
+// COM vtable slots used below, counted from the start of each interface
+const (
+	vtblQueryInterface = 0
+
+	// IDXGIFactory
+	vtblEnumAdapters = 7
+	// IDXGIAdapter
+	vtblEnumOutputs = 7
+	// IDXGIOutput
+	vtblOutputGetDesc = 7
+	// IDXGIOutput1
+	vtblDuplicateOutput = 22
+	// IDXGIOutput5
+	vtblDuplicateOutput1 = 26
+	// IDXGIOutputDuplication
+	vtblDuplGetDesc      = 7
+	vtblAcquireNextFrame = 8
+	vtblReleaseFrame     = 14
+	// ID3D11Device
+	vtblCreateTexture2D = 5
+	// ID3D11DeviceContext
+	vtblMap          = 14
+	vtblUnmap        = 15
+	vtblCopyResource = 47
+	// ID3D11Texture2D
+	vtblTextureGetDesc = 10
+)
+
+// frameTimeout bounds the wait for the first frame of a new duplication,
+// which normally arrives at once with the current desktop image
+const frameTimeout = 500
+
+type dxgiOutputDesc struct {
+	DeviceName         [32]uint16
+	DesktopCoordinates RECT
+	AttachedToDesktop  int32
+	Rotation           uint32
+	Monitor            uintptr
+}
+
+type dxgiModeDesc struct {
+	Width, Height    uint32
+	RefreshRate      [2]uint32
+	Format           uint32
+	ScanlineOrdering uint32
+	Scaling          uint32
+}
+
+type dxgiOutduplDesc struct {
+	ModeDesc                   dxgiModeDesc
+	Rotation                   uint32
+	DesktopImageInSystemMemory int32
+}
+
+type dxgiOutduplFrameInfo struct {
+	LastPresentTime           int64
+	LastMouseUpdateTime       int64
+	AccumulatedFrames         uint32
+	RectsCoalesced            int32
+	ProtectedContentMaskedOut int32
+	PointerPosition           struct {
+		Position POINT
+		Visible  int32
+	}
+	TotalMetadataBufferSize uint32
+	PointerShapeBufferSize  uint32
+}
+
+type d3d11Texture2DDesc struct {
+	Width, Height  uint32
+	MipLevels      uint32
+	ArraySize      uint32
+	Format         uint32
+	SampleDesc     [2]uint32 // Count, Quality
+	Usage          uint32
+	BindFlags      uint32
+	CPUAccessFlags uint32
+	MiscFlags      uint32
+}
+
+type d3d11MappedSubresource struct {
+	Data       uintptr
+	RowPitch   uint32
+	DepthPitch uint32
+}
+
+// dxgiBackend reads the composed desktop with DXGI Desktop Duplication. It
+// is much faster than GDI on large desktops and sees exclusive fullscreen
+// games, but needs Windows 8 or later and a display driver that supports it
+type dxgiBackend struct{}
+
+func (dxgiBackend) Name() Backend { return BackendDXGI }
+
+func (dxgiBackend) Available() bool {
+	return procCreateDXGIFactory1.Find() == nil && procD3D11CreateDevice.Find() == nil
+}
+
+// Capture duplicates every output the rectangle touches and copies its part
+// of the rectangle from a fresh frame. Outputs are on the adapter driving
+// them, so each gets a device on that adapter
+func (dxgiBackend) Capture(r image.Rectangle) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255 // Gaps between monitors are black, as with GDI
+	}
+	if err := duplicateRect(r, rgbaSink{img}, false); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// frameSink receives the parts of duplicated frames that a capture covers
+type frameSink interface {
+	// put copies src from a mapped frame of the given DXGI format into the
+	// capture at dst
+	put(format uint32, data []byte, pitch int, src image.Rectangle, dst image.Point) error
+}
+
+// rgbaSink collects an 8-bit capture
+type rgbaSink struct{ img *image.RGBA }
+
+func (s rgbaSink) put(format uint32, data []byte, pitch int, src image.Rectangle, dst image.Point) error {
+	if format != dxgiFormatB8G8R8A8 {
+		return fmt.Errorf("unsupported desktop format %d", format)
+	}
+	copyBGRA(s.img, dst, data, pitch, src)
+	return nil
+}
+
+// duplicateRect duplicates every output r touches into sink. deep asks
+// outputs for 16-bit float frames where the system supports them
+func duplicateRect(r image.Rectangle, sink frameSink, deep bool) error {
+	var factory uintptr
+	if hr, _, _ := procCreateDXGIFactory1.Call(uintptr(unsafe.Pointer(&iidIDXGIFactory1)), uintptr(unsafe.Pointer(&factory))); com.Failed(hr) {
+		return fmt.Errorf("failed to create DXGI factory: 0x%08x", uint32(hr))
+	}
+	defer com.Release(factory)
+
+	covered := 0
+	for a := uintptr(0); ; a++ {
+		var adapter uintptr
+		hr := com.Call(factory, vtblEnumAdapters, a, uintptr(unsafe.Pointer(&adapter)))
+		if uint32(hr) == dxgiErrorNotFound {
+			break
+		}
+		if com.Failed(hr) {
+			return fmt.Errorf("failed to enumerate adapters: 0x%08x", uint32(hr))
+		}
+		n, err := captureAdapter(adapter, r, sink, deep)
+		com.Release(adapter)
+		if err != nil {
+			return err
+		}
+		covered += n
+	}
+	if covered == 0 {
+		return errors.New("no duplicated output covers the region")
+	}
+	return nil
+}
+
+// captureAdapter copies the parts of r on the outputs of one adapter into
+// sink and returns how many outputs it read
+func captureAdapter(adapter uintptr, r image.Rectangle, sink frameSink, deep bool) (int, error) {
+	var device, context uintptr
+	covered := 0
+	defer func() {
+		if context != 0 {
+			com.Release(context)
+		}
+		if device != 0 {
+			com.Release(device)
+		}
+	}()
+
+	for o := uintptr(0); ; o++ {
+		var output uintptr
+		hr := com.Call(adapter, vtblEnumOutputs, o, uintptr(unsafe.Pointer(&output)))
+		if uint32(hr) == dxgiErrorNotFound {
+			return covered, nil
+		}
+		if com.Failed(hr) {
+			return covered, fmt.Errorf("failed to enumerate outputs: 0x%08x", uint32(hr))
+		}
+
+		var desc dxgiOutputDesc
+		com.Call(output, vtblOutputGetDesc, uintptr(unsafe.Pointer(&desc)))
+		c := desc.DesktopCoordinates
+		part := image.Rect(int(c.Left), int(c.Top), int(c.Right), int(c.Bottom)).Intersect(r)
+		if desc.AttachedToDesktop == 0 || part.Empty() {
+			com.Release(output)
+			continue
+		}
+
+		if device == 0 {
+			hr, _, _ := procD3D11CreateDevice.Call(adapter, d3dDriverTypeUnknown, 0, 0, 0, 0, d3d11SDKVersion,
+				uintptr(unsafe.Pointer(&device)), 0, uintptr(unsafe.Pointer(&context)))
+			if com.Failed(hr) {
+				com.Release(output)
+				return covered, fmt.Errorf("failed to create Direct3D device: 0x%08x", uint32(hr))
+			}
+		}
+
+		err := captureOutput(output, device, context, part.Sub(image.Pt(int(c.Left), int(c.Top))), sink, part.Min.Sub(r.Min), deep)
+		com.Release(output)
+		if err != nil {
+			return covered, fmt.Errorf("%s: %w", windows.UTF16ToString(desc.DeviceName[:]), err)
+		}
+		covered++
+	}
+}
+
+// captureOutput copies src (output coordinates) of one output's desktop image
+// into sink at dst
+func captureOutput(output, device, context uintptr, src image.Rectangle, sink frameSink, dst image.Point, deep bool) error {
+	dupl, err := duplicateOutput(output, device, deep)
+	if err != nil {
+		return err
+	}
+	defer com.Release(dupl)
+
+	// Rotated outputs hand out the unrotated image, leave them to GDI
+	var duplDesc dxgiOutduplDesc
+	com.Call(dupl, vtblDuplGetDesc, uintptr(unsafe.Pointer(&duplDesc)))
+	if duplDesc.Rotation > dxgiModeRotationId {
+		return errors.New("rotated output")
+	}
+
+	var info dxgiOutduplFrameInfo
+	var resource uintptr
+	hr := com.Call(dupl, vtblAcquireNextFrame, frameTimeout, uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&resource)))
+	if uint32(hr) == dxgiErrorWaitTimeout {
+		return errors.New("timed out waiting for a frame")
+	}
+	if com.Failed(hr) {
+		return fmt.Errorf("failed to acquire frame: 0x%08x", uint32(hr))
+	}
+	defer com.Call(dupl, vtblReleaseFrame)
+	defer com.Release(resource)
+
+	var frame uintptr
+	if hr := com.Call(resource, vtblQueryInterface, uintptr(unsafe.Pointer(&iidID3D11Texture2D)), uintptr(unsafe.Pointer(&frame))); com.Failed(hr) {
+		return fmt.Errorf("frame is not a texture: 0x%08x", uint32(hr))
+	}
+	defer com.Release(frame)
+
+	// The frame lives on the GPU, so copy it to a texture the CPU can read
+	var texDesc d3d11Texture2DDesc
+	com.Call(frame, vtblTextureGetDesc, uintptr(unsafe.Pointer(&texDesc)))
+	texDesc.Usage = d3d11UsageStaging
+	texDesc.CPUAccessFlags = d3d11CPUAccessRead
+	texDesc.BindFlags = 0
+	texDesc.MiscFlags = 0
+	texDesc.MipLevels = 1
+	texDesc.ArraySize = 1
+	texDesc.SampleDesc = [2]uint32{1, 0}
+	var staging uintptr
+	if hr := com.Call(device, vtblCreateTexture2D, uintptr(unsafe.Pointer(&texDesc)), 0, uintptr(unsafe.Pointer(&staging))); com.Failed(hr) {
+		return fmt.Errorf("failed to create staging texture: 0x%08x", uint32(hr))
+	}
+	defer com.Release(staging)
+	com.Call(context, vtblCopyResource, staging, frame)
+
+	var mapped d3d11MappedSubresource
+	if hr := com.Call(context, vtblMap, staging, 0, d3d11MapRead, 0, uintptr(unsafe.Pointer(&mapped))); com.Failed(hr) {
+		return fmt.Errorf("failed to map frame: 0x%08x", uint32(hr))
+	}
+	defer com.Call(context, vtblUnmap, staging, 0)
+
+	src = src.Intersect(image.Rect(0, 0, int(texDesc.Width), int(texDesc.Height)))
+	pitch := int(mapped.RowPitch)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(mapped.Data)), pitch*int(texDesc.Height))
+	return sink.put(texDesc.Format, data, pitch, src, dst)
+}
+
+// duplicateOutput starts duplicating an output. deep asks for 16-bit float
+// frames, which keep wide-gamut and HDR colors, through IDXGIOutput5 on
+// Windows 10 1803 and later, and falls back to 8-bit frames elsewhere
+func duplicateOutput(output, device uintptr, deep bool) (uintptr, error) {
+	var dupl uintptr
+	if deep {
+		var output5 uintptr
+		if hr := com.Call(output, vtblQueryInterface, uintptr(unsafe.Pointer(&iidIDXGIOutput5)), uintptr(unsafe.Pointer(&output5))); !com.Failed(hr) {
+			formats := [...]uint32{dxgiFormatR16G16B16A16Float, dxgiFormatB8G8R8A8}
+			hr := com.Call(output5, vtblDuplicateOutput1, device, 0, uintptr(len(formats)), uintptr(unsafe.Pointer(&formats[0])), uintptr(unsafe.Pointer(&dupl)))
+			com.Release(output5)
+			if !com.Failed(hr) {
+				return dupl, nil
+			}
+		}
+	}
+
+	var output1 uintptr
+	if hr := com.Call(output, vtblQueryInterface, uintptr(unsafe.Pointer(&iidIDXGIOutput1)), uintptr(unsafe.Pointer(&output1))); com.Failed(hr) {
+		return 0, fmt.Errorf("desktop duplication not supported: 0x%08x", uint32(hr))
+	}
+	defer com.Release(output1)
+	if hr := com.Call(output1, vtblDuplicateOutput, device, uintptr(unsafe.Pointer(&dupl))); com.Failed(hr) {
+		return 0, fmt.Errorf("failed to duplicate output: 0x%08x", uint32(hr))
+	}
+	return dupl, nil
+}
+
+// copyBGRA copies src from a BGRA surface with the given row pitch into img
+// at dst, converting to opaque RGBA
+func copyBGRA(img *image.RGBA, dst image.Point, data []byte, pitch int, src image.Rectangle) {
+	for y := 0; y < src.Dy(); y++ {
+		in := data[(src.Min.Y+y)*pitch+src.Min.X*4:]
+		out := img.Pix[img.PixOffset(dst.X, dst.Y+y):]
+		for x := 0; x < src.Dx(); x++ {
+			i := x * 4
+			out[i], out[i+1], out[i+2], out[i+3] = in[i+2], in[i+1], in[i], 255
+		}
+	}
+}
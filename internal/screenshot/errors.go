@@ -0,0 +1,26 @@
+package screenshot
+
+import "errors"
+
+// Sentinel errors returned by capture functions. They are wrapped with
+// details via %w, so callers should branch on them with errors.Is.
+var (
+	// ErrNoImageInClipboard is returned when clipboard has no image
+	ErrNoImageInClipboard = errors.New("no image in clipboard")
+
+	// ErrDisplayNotFound is returned when a display index does not exist
+	ErrDisplayNotFound = errors.New("display not found")
+
+	// ErrWindowNotFound is returned when a window handle is invalid or has no visible area
+	ErrWindowNotFound = errors.New("window not found")
+
+	// ErrCaptureBackendUnavailable is returned when the requested capture backend cannot be used
+	ErrCaptureBackendUnavailable = errors.New("capture backend unavailable")
+
+	// ErrRegionOutOfBounds is returned when a capture region is empty or outside the virtual screen
+	ErrRegionOutOfBounds = errors.New("capture region out of bounds")
+
+	// ErrSecureDesktop is returned when input is on a secure desktop, such as a
+	// UAC prompt or the lock screen, whose pixels can't be read
+	ErrSecureDesktop = errors.New("secure desktop is active")
+)
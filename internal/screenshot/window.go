@@ -1,6 +1,9 @@
 package screenshot
 
 import (
+	"errors"
+	"fmt"
+	"image"
 	"time"
 	"unsafe"
 
@@ -21,10 +24,13 @@ var (
 	procShowWindow             = user32Win.NewProc("ShowWindow")
 	procIsIconic               = user32Win.NewProc("IsIconic")
 	procGetCursorPos           = user32Win.NewProc("GetCursorPos")
+	procIsWindow               = user32Win.NewProc("IsWindow")
+	procPrintWindow            = user32Win.NewProc("PrintWindow")
 )
 
 const (
 	DWMWA_EXTENDED_FRAME_BOUNDS   = 9
+	PW_RENDERFULLCONTENT          = 0x00000002
 	PROCESS_PER_MONITOR_DPI_AWARE = 2
 	SW_RESTORE                    = 9
 )
@@ -70,41 +76,148 @@ func bringWindowToForeground(hwnd uintptr) {
 // CaptureWindowByCoords captures a window by capturing the screen region at window coordinates
 // This approach is more reliable than direct GDI capture for hardware-accelerated windows
 func CaptureWindowByCoords(hwnd uintptr) (*CaptureResult, error) {
+	img, err := CaptureWindowRaw(hwnd)
+	if err != nil {
+		return nil, err
+	}
+	return encodeImage(img)
+}
+
+// CaptureWindowRaw is CaptureWindowByCoords without PostCapture filters and
+// encoding
+func CaptureWindowRaw(hwnd uintptr) (*image.RGBA, error) {
+	if isWindow, _, _ := procIsWindow.Call(hwnd); isWindow == 0 {
+		return nil, fmt.Errorf("%w: handle 0x%x", ErrWindowNotFound, hwnd)
+	}
+
 	// Bring window to foreground before capture to ensure it's visible
 	bringWindowToForeground(hwnd)
 
-	var rect RECT
+	bounds := windowFrameBounds(hwnd)
+	if bounds.Empty() {
+		return nil, fmt.Errorf("%w: handle 0x%x", ErrWindowNotFound, hwnd)
+	}
+
+	// Capture the screen region at window coordinates
+	// Clamp so maximized or partially off-screen windows capture their visible part
+	return CaptureRectRaw(bounds, CaptureOptions{Clamp: true})
+}
 
-	// Try DWM extended frame bounds first (more accurate for modern windows)
-	// This accounts for window shadows and DPI scaling
+// windowFrameBounds returns the visible bounds of a window in screen
+// coordinates. DWM extended frame bounds leave out the invisible resize
+// borders and shadow, and account for DPI scaling
+func windowFrameBounds(hwnd uintptr) image.Rectangle {
+	var rect RECT
+	ret := uintptr(1)
 	if dwmapi.Load() == nil && procDwmGetWindowAttribute.Find() == nil {
-		ret, _, _ := procDwmGetWindowAttribute.Call(
+		ret, _, _ = procDwmGetWindowAttribute.Call(
 			hwnd,
 			uintptr(DWMWA_EXTENDED_FRAME_BOUNDS),
 			uintptr(unsafe.Pointer(&rect)),
 			unsafe.Sizeof(rect),
 		)
-		if ret != 0 {
-			// DWM failed, fall back to GetWindowRect
-			procGetWindowRectSS.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
-		}
-	} else {
-		// DWM not available, use GetWindowRect
+	}
+	if ret != 0 {
+		// DWM not available or failed, use GetWindowRect
 		procGetWindowRectSS.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
 	}
+	return image.Rect(int(rect.Left), int(rect.Top), int(rect.Right), int(rect.Bottom))
+}
 
-	x := int(rect.Left)
-	y := int(rect.Top)
-	width := int(rect.Right - rect.Left)
-	height := int(rect.Bottom - rect.Top)
+// CaptureWindowDirect captures a window with PrintWindow instead of reading
+// the screen, so it works for windows that are covered or partly off screen
+// and leaves the focus alone. PW_RENDERFULLCONTENT makes DWM render
+// hardware-accelerated content such as browsers and video, which plain
+// PrintWindow draws black. Minimized windows have nothing to render
+func CaptureWindowDirect(hwnd uintptr) (*CaptureResult, error) {
+	img, err := CaptureWindowDirectRaw(hwnd)
+	if err != nil {
+		return nil, err
+	}
+	return encodeImage(img)
+}
 
-	// Ensure valid dimensions
-	if width <= 0 || height <= 0 {
-		return nil, nil
+// CaptureWindowDirectRaw is CaptureWindowDirect without PostCapture filters
+// and encoding
+func CaptureWindowDirectRaw(hwnd uintptr) (*image.RGBA, error) {
+	if isWindow, _, _ := procIsWindow.Call(hwnd); isWindow == 0 {
+		return nil, fmt.Errorf("%w: handle 0x%x", ErrWindowNotFound, hwnd)
+	}
+	if minimized, _, _ := procIsIconic.Call(hwnd); minimized != 0 {
+		return nil, fmt.Errorf("%w: window 0x%x is minimized", ErrWindowNotFound, hwnd)
 	}
 
-	// Capture the screen region at window coordinates
-	return CaptureRegion(x, y, width, height)
+	// PrintWindow draws the whole window rect, including the invisible
+	// borders that are cropped off below
+	var rect RECT
+	procGetWindowRectSS.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
+	window := image.Rect(int(rect.Left), int(rect.Top), int(rect.Right), int(rect.Bottom))
+	if window.Empty() {
+		return nil, fmt.Errorf("%w: handle 0x%x", ErrWindowNotFound, hwnd)
+	}
+	width, height := window.Dx(), window.Dy()
+
+	hdcScreen, _, _ := procGetDCCursor.Call(0)
+	if hdcScreen == 0 {
+		return nil, errors.New("failed to get screen DC")
+	}
+	defer procReleaseDCCursor.Call(0, hdcScreen)
+
+	hdcMem, _, _ := procCreateCompatibleDC.Call(hdcScreen)
+	if hdcMem == 0 {
+		return nil, errors.New("failed to create memory DC")
+	}
+	defer procDeleteDC.Call(hdcMem)
+
+	hBitmap, _, _ := procCreateCompatibleBitmap.Call(hdcScreen, uintptr(width), uintptr(height))
+	if hBitmap == 0 {
+		return nil, fmt.Errorf("failed to create %dx%d bitmap", width, height)
+	}
+	defer procDeleteObject.Call(hBitmap)
+
+	oldBitmap, _, _ := procSelectObject.Call(hdcMem, hBitmap)
+	defer procSelectObject.Call(hdcMem, oldBitmap)
+
+	if ret, _, _ := procPrintWindow.Call(hwnd, hdcMem, PW_RENDERFULLCONTENT); ret == 0 {
+		return nil, fmt.Errorf("PrintWindow failed for window 0x%x", hwnd)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	bmi := BITMAPINFOHEADER{
+		BiSize:        uint32(unsafe.Sizeof(BITMAPINFOHEADER{})),
+		BiWidth:       int32(width),
+		BiHeight:      -int32(height), // Negative for top-down
+		BiPlanes:      1,
+		BiBitCount:    32,
+		BiCompression: BI_RGB,
+	}
+	if lines, _, _ := procGetDIBits.Call(hdcMem, hBitmap, 0, uintptr(height),
+		uintptr(unsafe.Pointer(&img.Pix[0])),
+		uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS); lines == 0 {
+		return nil, errors.New("failed to read window pixels")
+	}
+
+	// GDI gives BGRA with an undefined alpha byte
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+2] = img.Pix[i+2], img.Pix[i]
+		img.Pix[i+3] = 0xFF
+	}
+	return cropToFrame(img, window, windowFrameBounds(hwnd)), nil
+}
+
+// cropToFrame crops a capture of the window rect to the visible frame, both
+// in screen coordinates. The result's bounds start at the origin
+func cropToFrame(img *image.RGBA, window, frame image.Rectangle) *image.RGBA {
+	frame = frame.Intersect(window)
+	if frame.Empty() || frame == window {
+		return img
+	}
+	r := frame.Sub(window.Min)
+	out := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for y := 0; y < r.Dy(); y++ {
+		copy(out.Pix[y*out.Stride:(y+1)*out.Stride], img.Pix[img.PixOffset(r.Min.X, r.Min.Y+y):])
+	}
+	return out
 }
 
 // GetCursorPosition returns the current cursor position in screen coordinates
@@ -118,13 +231,18 @@ func GetCursorPosition() (x, y int) {
 // Returns 0 (primary display) if cursor position cannot be determined
 func GetMonitorAtCursor() int {
 	cursorX, cursorY := GetCursorPosition()
+	return GetMonitorAtPoint(cursorX, cursorY)
+}
 
+// GetMonitorAtPoint returns the display index containing the given screen point
+// Returns 0 (primary display) if no display contains the point
+func GetMonitorAtPoint(x, y int) int {
 	numDisplays := GetDisplayCount()
 	for i := 0; i < numDisplays; i++ {
 		bounds := GetDisplayBounds(i)
-		// Check if cursor is within this display's bounds
-		if cursorX >= bounds.Min.X && cursorX < bounds.Max.X &&
-			cursorY >= bounds.Min.Y && cursorY < bounds.Max.Y {
+		// Check if point is within this display's bounds
+		if x >= bounds.Min.X && x < bounds.Max.X &&
+			y >= bounds.Min.Y && y < bounds.Max.Y {
 			return i
 		}
 	}
@@ -0,0 +1,32 @@
+package screenshot
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCropToFrame(t *testing.T) {
+	// A window rect with 7px invisible borders around the visible frame
+	window := image.Rect(93, 193, 314, 314)
+	frame := image.Rect(100, 200, 307, 307)
+	img := image.NewRGBA(image.Rect(0, 0, window.Dx(), window.Dy()))
+	img.SetRGBA(7, 7, color.RGBA{255, 0, 0, 255})                         // Frame's top-left pixel
+	img.SetRGBA(window.Dx()-8, window.Dy()-8, color.RGBA{0, 0, 255, 255}) // Frame's bottom-right pixel
+
+	got := cropToFrame(img, window, frame)
+	if got.Bounds() != image.Rect(0, 0, frame.Dx(), frame.Dy()) {
+		t.Fatalf("cropToFrame() bounds = %v, want the frame size at the origin", got.Bounds())
+	}
+	if c := got.RGBAAt(0, 0); c.R != 255 {
+		t.Errorf("top-left = %v, want the frame's first pixel", c)
+	}
+	if c := got.RGBAAt(frame.Dx()-1, frame.Dy()-1); c.B != 255 {
+		t.Errorf("bottom-right = %v, want the frame's last pixel", c)
+	}
+
+	// Without DWM the frame is the window rect and nothing is cropped
+	if got := cropToFrame(img, window, window); got != img {
+		t.Error("cropToFrame() with frame == window should return the image as is")
+	}
+}
@@ -0,0 +1,313 @@
+// Package script runs user workflows written in Lua. Scripts see a small
+// winshot API (capture, crop, annotate, redact, stitch, save, upload, clipboard)
+// and a trimmed standard library without file, process or module loading
+// access, so everything a script does goes through the Host.
+//
+// A workflow that captures both monitors side by side, stamps the time,
+// uploads the result and copies a Markdown link:
+//
+//	local shot = winshot.stitch({winshot.capture(0), winshot.capture(1)})
+//	shot:label(os.date("%Y-%m-%d %H:%M"), 10, 10)
+//	local url = winshot.upload(shot)
+//	winshot.copy("![screenshot](" .. url .. ")")
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"winshot/internal/annotate"
+	"winshot/internal/imaging"
+)
+
+// Host carries out the actions a script asks for.
+type Host interface {
+	// Capture captures "fullscreen", "region", "window", "repeat" (the last
+	// selected region again) or "display:N". It returns a nil image when the
+	// user cancels a region selection.
+	Capture(target string) (image.Image, error)
+	// Save writes the image to the screenshot folder and returns its path.
+	// An empty format uses the export default.
+	Save(img image.Image, format string) (string, error)
+	// Upload uploads the image and returns its public URL. An empty
+	// provider or format uses the configured default.
+	Upload(img image.Image, provider, format string) (string, error)
+	CopyImage(img image.Image) error
+	CopyText(text string) error
+	Notify(message string)
+	Log(line string) // Receives print output
+}
+
+// imageType names the metatable of image userdata.
+const imageType = "winshot.image"
+
+// Label and rectangle defaults.
+var (
+	defaultLabelFG = color.RGBA{255, 255, 255, 255}
+	defaultLabelBG = color.RGBA{0, 0, 0, 176}
+	defaultStroke  = color.RGBA{255, 0, 0, 255}
+)
+
+// Run executes source, stopping it when ctx is done. name identifies the
+// script in error messages.
+func Run(ctx context.Context, name, source string, host Host) error {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+	L.SetContext(ctx)
+
+	openSandbox(L, host)
+	registerAPI(L, host)
+
+	fn, err := L.Load(strings.NewReader(source), name)
+	if err != nil {
+		return err
+	}
+	L.Push(fn)
+	if err := L.PCall(0, 0, nil); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("%s: %w", name, ctxErr)
+		}
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			return fmt.Errorf("%s", apiErr.Object.String())
+		}
+		return err
+	}
+	return nil
+}
+
+// openSandbox opens the safe parts of the standard library: no io, no
+// package loading, and only the clock functions of os.
+func openSandbox(L *lua.LState, host Host) {
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.OsLibName, lua.OpenOs},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	for _, name := range []string{"dofile", "loadfile", "require", "module", "collectgarbage"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	full := L.GetGlobal("os").(*lua.LTable)
+	os := L.NewTable()
+	for _, name := range []string{"clock", "date", "difftime", "time"} {
+		os.RawSetString(name, full.RawGetString(name))
+	}
+	L.SetGlobal("os", os)
+
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		host.Log(strings.Join(parts, "\t"))
+		return 0
+	}))
+}
+
+// registerAPI installs the winshot table and the image methods.
+func registerAPI(L *lua.LState, host Host) {
+	mt := L.NewTypeMetatable(imageType)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"width":  func(L *lua.LState) int { L.Push(lua.LNumber(checkImage(L, 1).Bounds().Dx())); return 1 },
+		"height": func(L *lua.LState) int { L.Push(lua.LNumber(checkImage(L, 1).Bounds().Dy())); return 1 },
+		"crop":   imageCrop,
+		"label":  imageLabel,
+		"rect":   imageRect,
+		"blur": func(L *lua.LState) int {
+			return imageRedact(L, annotate.RedactBlur)
+		},
+		"pixelate": func(L *lua.LState) int {
+			return imageRedact(L, annotate.RedactPixelate)
+		},
+	}))
+
+	L.SetGlobal("winshot", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"capture": func(L *lua.LState) int {
+			target := "fullscreen"
+			switch v := L.Get(1).(type) {
+			case lua.LNumber:
+				target = "display:" + strconv.Itoa(int(v))
+			case lua.LString:
+				target = string(v)
+			}
+			img, err := host.Capture(target)
+			if err != nil {
+				L.RaiseError("capture %s: %v", target, err)
+			}
+			if img == nil {
+				L.Push(lua.LNil) // Cancelled
+				return 1
+			}
+			L.Push(newImage(L, imaging.ToRGBA(img)))
+			return 1
+		},
+		"stitch": stitch,
+		"save": func(L *lua.LState) int {
+			path, err := host.Save(checkImage(L, 1), L.OptString(2, ""))
+			if err != nil {
+				L.RaiseError("save: %v", err)
+			}
+			L.Push(lua.LString(path))
+			return 1
+		},
+		"upload": func(L *lua.LState) int {
+			url, err := host.Upload(checkImage(L, 1), L.OptString(2, ""), L.OptString(3, ""))
+			if err != nil {
+				L.RaiseError("upload: %v", err)
+			}
+			L.Push(lua.LString(url))
+			return 1
+		},
+		"copy": func(L *lua.LState) int {
+			var err error
+			if s, ok := L.Get(1).(lua.LString); ok {
+				err = host.CopyText(string(s))
+			} else {
+				err = host.CopyImage(checkImage(L, 1))
+			}
+			if err != nil {
+				L.RaiseError("copy: %v", err)
+			}
+			return 0
+		},
+		"notify": func(L *lua.LState) int {
+			host.Notify(L.CheckString(1))
+			return 0
+		},
+	}))
+}
+
+func newImage(L *lua.LState, img *image.RGBA) *lua.LUserData {
+	ud := L.NewUserData()
+	ud.Value = img
+	L.SetMetatable(ud, L.GetTypeMetatable(imageType))
+	return ud
+}
+
+func checkImage(L *lua.LState, n int) *image.RGBA {
+	if img, ok := L.CheckUserData(n).Value.(*image.RGBA); ok {
+		return img
+	}
+	L.ArgError(n, "image expected")
+	return nil
+}
+
+// optColor reads an optional "#RRGGBB[AA]" argument.
+func optColor(L *lua.LState, n int, def color.RGBA) color.RGBA {
+	if L.Get(n) == lua.LNil {
+		return def
+	}
+	c, err := imaging.ParseColor(L.CheckString(n))
+	if err != nil {
+		L.ArgError(n, err.Error())
+	}
+	return c
+}
+
+// checkRect reads x, y, width, height starting at argument n, relative to
+// the image's top-left corner.
+func checkRect(L *lua.LState, img *image.RGBA, n int) image.Rectangle {
+	x, y := L.CheckInt(n), L.CheckInt(n+1)
+	w, h := L.CheckInt(n+2), L.CheckInt(n+3)
+	return image.Rect(x, y, x+w, y+h).Add(img.Bounds().Min)
+}
+
+// imageCrop implements img:crop(x, y, w, h), returning a new image.
+func imageCrop(L *lua.LState) int {
+	img := checkImage(L, 1)
+	r := checkRect(L, img, 2).Intersect(img.Bounds())
+	if r.Empty() {
+		L.RaiseError("crop: rectangle is outside the image")
+	}
+	out := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(out, out.Bounds(), img, r.Min, draw.Src)
+	L.Push(newImage(L, out))
+	return 1
+}
+
+// imageLabel implements img:label(text, x, y [, fg [, bg]]), drawing in
+// place and returning the image for chaining.
+func imageLabel(L *lua.LState) int {
+	img := checkImage(L, 1)
+	text := L.CheckString(2)
+	pt := image.Pt(L.CheckInt(3), L.CheckInt(4)).Add(img.Bounds().Min)
+	imaging.DrawLabel(img, text, pt, optColor(L, 5, defaultLabelFG), optColor(L, 6, defaultLabelBG))
+	L.Push(L.Get(1))
+	return 1
+}
+
+// imageRect implements img:rect(x, y, w, h [, color [, thickness]]),
+// drawing in place and returning the image for chaining.
+func imageRect(L *lua.LState) int {
+	img := checkImage(L, 1)
+	r := checkRect(L, img, 2)
+	imaging.StrokeRect(img, r, L.OptInt(7, 3), optColor(L, 6, defaultStroke))
+	L.Push(L.Get(1))
+	return 1
+}
+
+// imageRedact implements img:blur(x, y, w, h) and img:pixelate(x, y, w, h),
+// redacting in place and returning the image for chaining.
+func imageRedact(L *lua.LState, mode annotate.RedactMode) int {
+	img := checkImage(L, 1)
+	annotate.Redact(img, []image.Rectangle{checkRect(L, img, 2)}, mode)
+	L.Push(L.Get(1))
+	return 1
+}
+
+// stitch implements winshot.stitch(images [, {vertical=, gap=, background=}]).
+func stitch(L *lua.LState) int {
+	list := L.CheckTable(1)
+	var imgs []image.Image
+	for i := 1; i <= list.Len(); i++ {
+		ud, ok := list.RawGetInt(i).(*lua.LUserData)
+		if !ok {
+			L.ArgError(1, fmt.Sprintf("item %d is not an image", i))
+		}
+		img, ok := ud.Value.(*image.RGBA)
+		if !ok {
+			L.ArgError(1, fmt.Sprintf("item %d is not an image", i))
+		}
+		imgs = append(imgs, img)
+	}
+	if len(imgs) == 0 {
+		L.ArgError(1, "no images to stitch")
+	}
+
+	vertical, gap, bg := false, 0, color.RGBA{}
+	if opts, ok := L.Get(2).(*lua.LTable); ok {
+		vertical = lua.LVAsBool(opts.RawGetString("vertical"))
+		if v, ok := opts.RawGetString("gap").(lua.LNumber); ok {
+			gap = int(v)
+		}
+		if v, ok := opts.RawGetString("background").(lua.LString); ok {
+			c, err := imaging.ParseColor(string(v))
+			if err != nil {
+				L.ArgError(2, err.Error())
+			}
+			bg = c
+		}
+	}
+
+	L.Push(newImage(L, imaging.Stitch(imgs, vertical, gap, bg)))
+	return 1
+}
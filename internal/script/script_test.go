@@ -0,0 +1,171 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeHost records what a script asked for.
+type fakeHost struct {
+	captures []string
+	saved    []image.Image
+	uploaded []image.Image
+	copied   []string
+	notices  []string
+	log      []string
+	cancel   bool // Capture returns no image, as for a cancelled selection
+}
+
+func (h *fakeHost) Capture(target string) (image.Image, error) {
+	h.captures = append(h.captures, target)
+	if h.cancel {
+		return nil, nil
+	}
+	if target == "window" {
+		return nil, errors.New("no foreground window")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return img, nil
+}
+
+func (h *fakeHost) Save(img image.Image, format string) (string, error) {
+	h.saved = append(h.saved, img)
+	return `C:\shots\winshot.` + format, nil
+}
+
+func (h *fakeHost) Upload(img image.Image, provider, format string) (string, error) {
+	h.uploaded = append(h.uploaded, img)
+	return "https://cdn.example.com/winshot.png", nil
+}
+
+func (h *fakeHost) CopyImage(img image.Image) error {
+	h.copied = append(h.copied, "<image>")
+	return nil
+}
+
+func (h *fakeHost) CopyText(text string) error { h.copied = append(h.copied, text); return nil }
+func (h *fakeHost) Notify(message string)      { h.notices = append(h.notices, message) }
+func (h *fakeHost) Log(line string)            { h.log = append(h.log, line) }
+
+func TestRun_Workflow(t *testing.T) {
+	host := &fakeHost{}
+	src := `
+		local shot = winshot.stitch({winshot.capture(0), winshot.capture(1)}, {gap = 4, background = "#000000"})
+		print(shot:width(), shot:height())
+		shot:label("12:00", 2, 2):rect(0, 0, 10, 10, "#00FF00", 2)
+		shot:blur(60, 0, 20, 10):pixelate(60, 10, 20, 20)
+		local part = shot:crop(40, 0, 4, 30)
+		winshot.save(part, "png")
+		local url = winshot.upload(shot)
+		winshot.copy("![screenshot](" .. url .. ")")
+		winshot.notify("done")
+	`
+	if err := Run(context.Background(), "stitch.lua", src, host); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := []string{"display:0", "display:1"}; !reflect.DeepEqual(host.captures, want) {
+		t.Errorf("captures = %v, want %v", host.captures, want)
+	}
+	if want := []string{"84\t30"}; !reflect.DeepEqual(host.log, want) {
+		t.Errorf("print output = %v, want %v", host.log, want)
+	}
+	if want := []string{"![screenshot](https://cdn.example.com/winshot.png)"}; !reflect.DeepEqual(host.copied, want) {
+		t.Errorf("copied = %v, want %v", host.copied, want)
+	}
+	if want := []string{"done"}; !reflect.DeepEqual(host.notices, want) {
+		t.Errorf("notices = %v, want %v", host.notices, want)
+	}
+
+	// The crop covers the gap, so it holds the black background
+	if len(host.saved) != 1 {
+		t.Fatalf("saved %d images, want 1", len(host.saved))
+	}
+	part := host.saved[0].(*image.RGBA)
+	if got := part.Bounds().Size(); got != image.Pt(4, 30) {
+		t.Errorf("cropped size = %v, want 4x30", got)
+	}
+	if got := part.RGBAAt(0, 15); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("cropped pixel = %v, want the stitch background", got)
+	}
+
+	shot := host.uploaded[0].(*image.RGBA)
+	if got := shot.RGBAAt(0, 0); got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("rect corner = %v, want green", got)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"syntax", "winshot.capture(", "syntax.lua"},
+		{"host error", `winshot.capture("window")`, "no foreground window"},
+		{"bad color", `winshot.capture():rect(0, 0, 5, 5, "red")`, "invalid color"},
+		{"crop outside", `winshot.capture():crop(100, 100, 5, 5)`, "outside the image"},
+		{"stitch nothing", `winshot.stitch({})`, "no images"},
+		{"script error", `error("stop here")`, "stop here"},
+	}
+	for _, tt := range tests {
+		err := Run(context.Background(), strings.ReplaceAll(tt.name, " ", "_")+".lua", tt.src, &fakeHost{})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Run() error = %v, want it to mention %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRun_Sandbox(t *testing.T) {
+	// Nothing outside the winshot API can reach files or processes
+	for _, expr := range []string{"io", "dofile", "loadfile", "require", "os.execute", "os.remove", "os.getenv"} {
+		host := &fakeHost{}
+		src := "print(" + expr + " == nil)"
+		if err := Run(context.Background(), "sandbox.lua", src, host); err != nil {
+			t.Errorf("%s: Run() error = %v", expr, err)
+			continue
+		}
+		if len(host.log) != 1 || host.log[0] != "true" {
+			t.Errorf("%s is available to scripts", expr)
+		}
+	}
+
+	host := &fakeHost{}
+	if err := Run(context.Background(), "date.lua", `print(type(os.date("%Y")))`, host); err != nil || host.log[0] != "string" {
+		t.Errorf("os.date should stay available, got %v, %v", host.log, err)
+	}
+}
+
+func TestRun_CancelledCapture(t *testing.T) {
+	host := &fakeHost{cancel: true}
+	src := `
+		local shot = winshot.capture("region")
+		if shot == nil then return end
+		winshot.save(shot)
+	`
+	if err := Run(context.Background(), "cancel.lua", src, host); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(host.saved) != 0 {
+		t.Error("a cancelled capture should leave nothing to save")
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := Run(ctx, "loop.lua", "while true do end", &fakeHost{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want a deadline error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package scrollcapture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"winshot/internal/screenshot"
+)
+
+var (
+	user32 = windows.NewLazySystemDLL("user32.dll")
+
+	procGetWindowRect   = user32.NewProc("GetWindowRect")
+	procWindowFromPoint = user32.NewProc("WindowFromPoint")
+	procGetAncestor     = user32.NewProc("GetAncestor")
+	procGetScrollInfo   = user32.NewProc("GetScrollInfo")
+	procSendMessageW    = user32.NewProc("SendMessageW")
+	procPostMessageW    = user32.NewProc("PostMessageW")
+)
+
+const (
+	wmVScroll    = 0x0115
+	wmMouseWheel = 0x020A
+	sbVert       = 1
+	sbLineDown   = 1
+	sifAll       = 0x17
+	gaRoot       = 2
+	wheelDelta   = 120
+)
+
+const (
+	maxFrames      = 60
+	maxHeight      = 30000                  // Larger images are slow to encode and edit
+	linesPerStep   = 5                      // WM_VSCROLL lines scrolled between frames
+	notchesPerStep = 3                      // Wheel notches scrolled between frames
+	settleDelay    = 250 * time.Millisecond // Smooth scrolling animates before it settles
+)
+
+// scrollInfo mirrors SCROLLINFO
+type scrollInfo struct {
+	size, mask    uint32
+	min, max      int32
+	page          uint32
+	pos, trackPos int32
+}
+
+// CaptureScrolling captures a window from its current scroll position to the
+// bottom, or until the image reaches maxHeight, as one tall image. Controls
+// with a standard scroll bar are scrolled with WM_VSCROLL and everything else,
+// such as browsers, with mouse wheel messages. Frames are rendered with
+// PrintWindow, so the window may stay covered
+func CaptureScrolling(hwnd uintptr) (*screenshot.CaptureResult, error) {
+	img, err := CaptureScrollingRaw(hwnd)
+	if err != nil {
+		return nil, err
+	}
+	return screenshot.Encode(img)
+}
+
+// CaptureScrollingRaw is CaptureScrolling without PostCapture filters and
+// encoding
+func CaptureScrollingRaw(hwnd uintptr) (*image.RGBA, error) {
+	first, err := screenshot.CaptureWindowDirectRaw(hwnd)
+	if err != nil {
+		return nil, err
+	}
+	var st Stitcher
+	st.Add(first)
+
+	scroll := scroller(hwnd)
+	for i := 1; i < maxFrames && st.Height() < maxHeight; i++ {
+		scroll()
+		time.Sleep(settleDelay)
+
+		frame, err := screenshot.CaptureWindowDirectRaw(hwnd)
+		if err != nil {
+			return nil, err
+		}
+		moved, err := st.Add(frame)
+		if errors.Is(err, ErrNoOverlap) && i == 1 {
+			return nil, fmt.Errorf("window did not scroll cleanly: %w", err)
+		}
+		if err != nil || !moved {
+			break // Keep what was stitched so far
+		}
+	}
+	return st.Image(), nil
+}
+
+// scroller returns a function that scrolls the window one step down. The
+// target is the control under the middle of the window, which is what the
+// user would scroll with the wheel
+func scroller(hwnd uintptr) func() {
+	var rect struct{ left, top, right, bottom int32 }
+	procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
+	center := image.Pt(int(rect.left+rect.right)/2, int(rect.top+rect.bottom)/2)
+
+	target := hwnd
+	point := uintptr(uint32(center.X)) | uintptr(uint32(center.Y))<<32
+	if child, _, _ := procWindowFromPoint.Call(point); child != 0 {
+		if root, _, _ := procGetAncestor.Call(child, gaRoot); root == hwnd {
+			target = child
+		}
+	}
+
+	info := scrollInfo{mask: sifAll}
+	info.size = uint32(unsafe.Sizeof(info))
+	if ok, _, _ := procGetScrollInfo.Call(target, sbVert, uintptr(unsafe.Pointer(&info))); ok != 0 && info.max > info.min {
+		return func() {
+			for range linesPerStep {
+				procSendMessageW.Call(target, wmVScroll, sbLineDown, 0)
+			}
+		}
+	}
+
+	// The wheel delta is the high word of wParam, negative to scroll down,
+	// and lParam holds the cursor position in screen coordinates
+	delta := -wheelDelta * notchesPerStep
+	wParam := uintptr(uint16(int16(delta))) << 16
+	lParam := uintptr(uint16(int16(center.X))) | uintptr(uint16(int16(center.Y)))<<16
+	return func() {
+		procPostMessageW.Call(target, wmMouseWheel, wParam, lParam)
+	}
+}
@@ -0,0 +1,169 @@
+// Package scrollcapture captures windows taller than the screen by
+// scrolling them and stitching the frames into one long screenshot.
+package scrollcapture
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+)
+
+const (
+	// minOverlap is the fewest rows two frames must share to be stitched.
+	// Fewer matching rows are too likely to be a coincidence
+	minOverlap = 16
+	// maxEdgeInset leaves the sides out of row comparisons, where scroll bar
+	// thumbs move between frames while the content does not
+	maxEdgeInset = 32
+)
+
+// ErrNoOverlap is returned when a frame shares no content with the previous
+// one, so the window scrolled too far or its content changed
+var ErrNoOverlap = errors.New("frame does not overlap the previous one")
+
+// Stitcher joins successive frames of a scrolling window into one tall
+// image. Rows that stay put in every frame, such as toolbars and status
+// bars, appear once at the top and bottom of the result
+type Stitcher struct {
+	first, last *image.RGBA
+	lastRows    []uint64
+	top, bottom int           // Static rows at the top and bottom of each frame
+	banded      bool          // top and bottom are known
+	slices      []*image.RGBA // New rows scrolled into view by each frame
+	height      int
+}
+
+// Height returns the height of the stitched image so far
+func (s *Stitcher) Height() int {
+	return s.height
+}
+
+// Add appends a frame captured after scrolling down. It reports whether the
+// content moved. A frame that did not move means the end was reached and is
+// not added. All frames must be the same size
+func (s *Stitcher) Add(frame *image.RGBA) (bool, error) {
+	if s.first == nil {
+		s.first, s.last = frame, frame
+		s.lastRows = rowHashes(frame)
+		s.height = frame.Bounds().Dy()
+		return true, nil
+	}
+	if frame.Bounds().Size() != s.first.Bounds().Size() {
+		return false, errors.New("frame size changed while scrolling")
+	}
+
+	rows := rowHashes(frame)
+	top, bottom := s.top, s.bottom
+	if !s.banded {
+		top, bottom = staticBands(s.lastRows, rows)
+	}
+	shift := scrollShift(s.lastRows, rows, top, bottom)
+	if shift == 0 {
+		return false, nil
+	}
+	if shift < 0 {
+		return false, ErrNoOverlap
+	}
+	s.top, s.bottom, s.banded = top, bottom, true
+
+	b := frame.Bounds()
+	end := b.Max.Y - bottom
+	s.slices = append(s.slices, frame.SubImage(image.Rect(b.Min.X, end-shift, b.Max.X, end)).(*image.RGBA))
+	s.last, s.lastRows = frame, rows
+	s.height += shift
+	return true, nil
+}
+
+// Image returns the stitched image: the first frame down to its footer, the
+// rows each later frame scrolled into view, then the last frame's footer
+func (s *Stitcher) Image() *image.RGBA {
+	if s.first == nil {
+		return nil
+	}
+	b := s.first.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), s.height))
+
+	y := b.Dy() - s.bottom
+	draw.Draw(out, image.Rect(0, 0, b.Dx(), y), s.first, b.Min, draw.Src)
+	for _, slice := range s.slices {
+		h := slice.Bounds().Dy()
+		draw.Draw(out, image.Rect(0, y, b.Dx(), y+h), slice, slice.Bounds().Min, draw.Src)
+		y += h
+	}
+	lb := s.last.Bounds()
+	draw.Draw(out, image.Rect(0, y, b.Dx(), y+s.bottom), s.last, image.Pt(lb.Min.X, lb.Max.Y-s.bottom), draw.Src)
+	return out
+}
+
+// rowHashes hashes each row of img with FNV-1a, leaving out the sides
+func rowHashes(img *image.RGBA) []uint64 {
+	b := img.Bounds()
+	inset := min(maxEdgeInset, b.Dx()/8)
+	hashes := make([]uint64, b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		start := img.PixOffset(b.Min.X+inset, y)
+		end := img.PixOffset(b.Max.X-inset, y)
+		h := uint64(14695981039346656037)
+		for _, c := range img.Pix[start:end] {
+			h ^= uint64(c)
+			h *= 1099511628211
+		}
+		hashes[y-b.Min.Y] = h
+	}
+	return hashes
+}
+
+// staticBands returns how many rows at the top and bottom are the same in
+// both frames, leaving at least minOverlap rows between them to scroll
+func staticBands(a, b []uint64) (top, bottom int) {
+	n := len(a)
+	for top < n && a[top] == b[top] {
+		top++
+	}
+	if top == n {
+		return 0, 0 // Nothing moved, the whole frame is content
+	}
+	for bottom < n-top && a[n-1-bottom] == b[n-1-bottom] {
+		bottom++
+	}
+	if n-top-bottom < 2*minOverlap {
+		return 0, 0
+	}
+	return top, bottom
+}
+
+// scrollShift returns how many rows the content between the static bands
+// moved up from frame a to frame b: 0 if it did not move, -1 if no shift
+// leaves at least minOverlap matching rows. The smallest matching shift
+// wins, and overlaps of one repeated row are ambiguous and skipped
+func scrollShift(a, b []uint64, top, bottom int) int {
+	end := len(a) - bottom
+	if equalRows(a[top:end], b[top:end]) {
+		return 0
+	}
+	for shift := 1; end-top-shift >= minOverlap; shift++ {
+		prev, next := a[top+shift:end], b[top:end-shift]
+		if equalRows(prev, next) && !uniform(next) {
+			return shift
+		}
+	}
+	return -1
+}
+
+func equalRows(a, b []uint64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func uniform(rows []uint64) bool {
+	for _, r := range rows {
+		if r != rows[0] {
+			return false
+		}
+	}
+	return true
+}
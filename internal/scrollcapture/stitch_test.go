@@ -0,0 +1,123 @@
+package scrollcapture
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+const (
+	testWidth  = 120
+	testHeader = 20
+	testFooter = 10
+	testView   = 100 // Content rows visible in one frame
+)
+
+// page returns a long page of random rows so every row is distinct
+func page(rows int) *image.RGBA {
+	r := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, testWidth, rows))
+	r.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// frame renders the window scrolled to offset: a fixed header and footer
+// around the visible content, with a scroll bar thumb on the right that
+// moves with the offset
+func frame(content *image.RGBA, offset int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, testWidth, testHeader+testView+testFooter))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < testWidth; x++ {
+			switch {
+			case y < testHeader:
+				img.Set(x, y, color.RGBA{200, 200, 200, 255})
+			case y >= testHeader+testView:
+				img.Set(x, y, color.RGBA{50, 50, 50, 255})
+			case x >= testWidth-8:
+				thumb := y-testHeader >= offset/4 && y-testHeader < offset/4+10
+				if thumb {
+					img.Set(x, y, color.Black)
+				} else {
+					img.Set(x, y, color.White)
+				}
+			default:
+				img.Set(x, y, content.At(x, y-testHeader+offset))
+			}
+		}
+	}
+	return img
+}
+
+func TestStitcher(t *testing.T) {
+	content := page(300)
+	var st Stitcher
+	for _, offset := range []int{0, 37, 80, 150} {
+		if moved, err := st.Add(frame(content, offset)); err != nil || !moved {
+			t.Fatalf("Add(offset %d) = %v, %v, want true, nil", offset, moved, err)
+		}
+	}
+	if moved, err := st.Add(frame(content, 150)); err != nil || moved {
+		t.Fatalf("Add(unchanged) = %v, %v, want false, nil", moved, err)
+	}
+
+	got := st.Image()
+	want := testHeader + 150 + testView + testFooter
+	if got.Bounds().Dy() != want {
+		t.Fatalf("height = %d, want %d", got.Bounds().Dy(), want)
+	}
+	for y := 0; y < 150+testView; y++ {
+		for x := 0; x < testWidth-8; x++ {
+			if got.RGBAAt(x, testHeader+y) != content.RGBAAt(x, y) {
+				t.Fatalf("content pixel (%d, %d) = %v, want %v", x, y, got.RGBAAt(x, testHeader+y), content.RGBAAt(x, y))
+			}
+		}
+	}
+	if c := got.RGBAAt(0, 0); c != (color.RGBA{200, 200, 200, 255}) {
+		t.Errorf("header = %v", c)
+	}
+	if c := got.RGBAAt(0, want-1); c != (color.RGBA{50, 50, 50, 255}) {
+		t.Errorf("footer = %v", c)
+	}
+}
+
+func TestStitcher_NoOverlap(t *testing.T) {
+	content := page(400)
+	var st Stitcher
+	st.Add(frame(content, 0))
+	if _, err := st.Add(frame(content, 250)); !errors.Is(err, ErrNoOverlap) {
+		t.Errorf("Add(jumped) error = %v, want ErrNoOverlap", err)
+	}
+}
+
+func TestScrollShift(t *testing.T) {
+	rows := func(vals ...uint64) []uint64 { return vals }
+	seq := func(from, n int) []uint64 {
+		var out []uint64
+		for i := range n {
+			out = append(out, uint64(from+i))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		a, b []uint64
+		want int
+	}{
+		{"unchanged", seq(0, 40), seq(0, 40), 0},
+		{"moved 5", seq(0, 40), seq(5, 40), 5},
+		{"too far", seq(0, 40), seq(30, 40), -1},
+		{"uniform", append(rows(1), make([]uint64, 39)...), make([]uint64, 40), -1},
+	}
+
+	for _, tt := range tests {
+		if got := scrollShift(tt.a, tt.b, 0, 0); got != tt.want {
+			t.Errorf("%s: scrollShift() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
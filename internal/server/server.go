@@ -0,0 +1,140 @@
+// Package server runs WinShot's local HTTP server. It is started on demand and
+// currently serves captures shared by link to other machines on the LAN.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Server is the local HTTP server. The zero value is not usable; use New.
+type Server struct {
+	addr string
+	mux  *http.ServeMux
+	now  func() time.Time
+
+	mu     sync.Mutex
+	ln     net.Listener
+	srv    *http.Server
+	shares map[string]*share
+}
+
+// New returns a server that will listen on addr, e.g. ":8765" or ":0" for any
+// free port. Nothing is opened until Start.
+func New(addr string) *Server {
+	s := &Server{
+		addr:   addr,
+		mux:    http.NewServeMux(),
+		now:    time.Now,
+		shares: make(map[string]*share),
+	}
+	s.mux.HandleFunc("GET /s/{token}", s.handleShare)
+	s.mux.HandleFunc("GET /s/{token}/image", s.handleShareImage)
+	return s
+}
+
+// Handler returns the server's routes, for serving them without listening.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start begins listening if the server isn't already. On Windows the first
+// listen on a LAN interface may show a firewall prompt.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln != nil {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to start local server on %s: %w", s.addr, err)
+	}
+	s.ln = ln
+	s.srv = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func(srv *http.Server, ln net.Listener) {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			println("Warning: local server stopped:", err.Error())
+		}
+	}(s.srv, ln)
+	return nil
+}
+
+// Running reports whether the server is listening.
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ln != nil
+}
+
+// Port returns the port being listened on, or 0 when stopped.
+func (s *Server) Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return 0
+	}
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// Close stops listening and forgets every share.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shares = make(map[string]*share)
+	if s.srv == nil {
+		return nil
+	}
+	err := s.srv.Close()
+	s.srv, s.ln = nil, nil
+	return err
+}
+
+// baseURL returns the address other machines on the LAN can reach the server
+// at, falling back to localhost when no LAN interface is up.
+func (s *Server) baseURL() string {
+	host := "localhost"
+	if ip := lanAddress(); ip != nil {
+		host = ip.String()
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(s.Port()))
+}
+
+// lanAddress returns the first private IPv4 address of an interface that is
+// up, which is the one a colleague's browser is most likely to reach.
+func lanAddress() net.IP {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+				return ip
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultShareTTL is how long a share link works when no TTL is given.
+const DefaultShareTTL = 15 * time.Minute
+
+// ShareOptions configures a shared capture.
+type ShareOptions struct {
+	Name        string        // File name shown in the viewer and used for downloads
+	ContentType string        // Defaults to image/png
+	TTL         time.Duration // Defaults to DefaultShareTTL
+	Reusable    bool          // Keep serving after the first view instead of expiring
+	Viewer      bool          // Link opens a page around the image rather than the image itself
+	Note        string        // Shown above the image in the viewer
+}
+
+// Share is a link handed out for a capture.
+type Share struct {
+	Token   string    `json:"token"`
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+// share is a capture being served.
+type share struct {
+	data    []byte
+	opts    ShareOptions
+	expires time.Time
+}
+
+// Share serves data under a new unguessable link, starting the server if
+// needed. Unless opts.Reusable is set the link stops working once the image
+// has been fetched.
+func (s *Server) Share(data []byte, opts ShareOptions) (Share, error) {
+	if len(data) == 0 {
+		return Share{}, errors.New("nothing to share")
+	}
+	if err := s.Start(); err != nil {
+		return Share{}, err
+	}
+	token, err := s.add(data, opts)
+	if err != nil {
+		return Share{}, err
+	}
+
+	s.mu.Lock()
+	expires := s.shares[token].expires
+	s.mu.Unlock()
+	return Share{
+		Token:   token,
+		URL:     s.baseURL() + "/s/" + token,
+		Expires: expires,
+	}, nil
+}
+
+// Revoke stops serving a share early. It reports whether the share existed.
+func (s *Server) Revoke(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.shares[token]
+	delete(s.shares, token)
+	return ok
+}
+
+// add registers a share and returns its token.
+func (s *Server) add(data []byte, opts ShareOptions) (string, error) {
+	if opts.ContentType == "" {
+		opts.ContentType = "image/png"
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultShareTTL
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	s.shares[token] = &share{data: data, opts: opts, expires: s.now().Add(opts.TTL)}
+	return token, nil
+}
+
+// lookup returns a live share, consuming it when consume is set and the share
+// is one-time. Must not be called with s.mu held.
+func (s *Server) lookup(token string, consume bool) *share {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	sh := s.shares[token]
+	if sh != nil && consume && !sh.opts.Reusable {
+		delete(s.shares, token)
+	}
+	return sh
+}
+
+// prune drops expired shares. Must be called with s.mu held.
+func (s *Server) prune() {
+	now := s.now()
+	for token, sh := range s.shares {
+		if !now.Before(sh.expires) {
+			delete(s.shares, token)
+		}
+	}
+}
+
+// handleShare serves a share link: the viewer page, or the image itself.
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	sh := s.lookup(token, false)
+	if sh == nil {
+		http.Error(w, "This link has expired.", http.StatusGone)
+		return
+	}
+	if !sh.opts.Viewer {
+		s.handleShareImage(w, r)
+		return
+	}
+
+	setShareHeaders(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	viewerPage.Execute(w, struct {
+		Name, Note, Image string
+		Expires           time.Time
+		Reusable          bool
+	}{sh.opts.Name, sh.opts.Note, "/s/" + token + "/image", sh.expires, sh.opts.Reusable})
+}
+
+// handleShareImage serves the shared image, consuming one-time shares.
+func (s *Server) handleShareImage(w http.ResponseWriter, r *http.Request) {
+	sh := s.lookup(r.PathValue("token"), true)
+	if sh == nil {
+		http.Error(w, "This link has expired.", http.StatusGone)
+		return
+	}
+
+	setShareHeaders(w)
+	w.Header().Set("Content-Type", sh.opts.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(sh.data)))
+	if sh.opts.Name != "" {
+		w.Header().Set("Content-Disposition", "inline; filename="+strconv.Quote(sh.opts.Name))
+	}
+	w.Write(sh.data)
+}
+
+// setShareHeaders keeps shared captures out of caches and keeps the token from
+// leaking through the Referer header.
+func setShareHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+var viewerPage = template.Must(template.New("viewer").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Name}}{{.Name}}{{else}}Screenshot{{end}} - WinShot</title>
+<style>
+body { margin: 0; padding: 24px; background: #1e1e2e; color: #cdd6f4; font-family: system-ui, sans-serif; text-align: center; }
+p { max-width: 60em; margin: 0 auto 16px; white-space: pre-wrap; }
+img { max-width: 100%; box-shadow: 0 4px 24px rgba(0, 0, 0, 0.5); }
+small { display: block; margin-top: 16px; color: #7f849c; }
+</style>
+</head>
+<body>
+{{if .Note}}<p>{{.Note}}</p>{{end}}
+<img src="{{.Image}}" alt="{{.Name}}">
+<small>Shared from WinShot{{if not .Reusable}}, this link works once{{end}}, expires {{.Expires.Format "15:04"}}</small>
+</body>
+</html>
+`))
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(s *Server, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestShare_OneTime(t *testing.T) {
+	s := New("127.0.0.1:0")
+	token, err := s.add([]byte("png bytes"), ShareOptions{Name: "shot.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := get(s, "/s/"+token)
+	if rec.Code != http.StatusOK || rec.Body.String() != "png bytes" {
+		t.Fatalf("first fetch = %d %q, want 200 with the image", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+	if rec := get(s, "/s/"+token); rec.Code != http.StatusGone {
+		t.Errorf("second fetch = %d, want %d", rec.Code, http.StatusGone)
+	}
+	if rec := get(s, "/s/unknown"); rec.Code != http.StatusGone {
+		t.Errorf("unknown token = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestShare_ViewerAndExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	s := New("127.0.0.1:0")
+	s.now = func() time.Time { return now }
+
+	token, _ := s.add([]byte("img"), ShareOptions{
+		Viewer:   true,
+		Reusable: true,
+		TTL:      time.Minute,
+		Note:     "<script>alert(1)</script>",
+	})
+
+	rec := get(s, "/s/"+token)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `src="/s/`+token+`/image"`) {
+		t.Fatalf("viewer = %d %q, want page referencing the image", rec.Code, body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Error("viewer must escape the note")
+	}
+
+	// Reusable shares survive fetches until they expire
+	for i := 0; i < 2; i++ {
+		if rec := get(s, "/s/"+token+"/image"); rec.Code != http.StatusOK {
+			t.Errorf("image fetch %d = %d, want 200", i+1, rec.Code)
+		}
+	}
+	now = now.Add(time.Minute)
+	if rec := get(s, "/s/"+token+"/image"); rec.Code != http.StatusGone {
+		t.Errorf("fetch after expiry = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestShare_StartsServer(t *testing.T) {
+	s := New("127.0.0.1:0")
+	defer s.Close()
+
+	if _, err := s.Share(nil, ShareOptions{}); err == nil {
+		t.Error("sharing nothing should fail")
+	}
+	share, err := s.Share([]byte("img"), ShareOptions{})
+	if err != nil {
+		t.Fatalf("Share() error = %v", err)
+	}
+	if !s.Running() || s.Port() == 0 {
+		t.Error("Share() should start the server")
+	}
+	if !strings.HasSuffix(share.URL, "/s/"+share.Token) {
+		t.Errorf("URL = %q, want it to end with the token", share.URL)
+	}
+	if !s.Revoke(share.Token) || s.Revoke(share.Token) {
+		t.Error("Revoke() should report the share only once")
+	}
+}
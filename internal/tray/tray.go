@@ -43,6 +43,8 @@ const (
 	NIF_TIP     = 0x00000004
 	NIF_INFO    = 0x00000010
 
+	NIIF_INFO = 0x00000001
+
 	WM_USER          = 0x0400
 	WM_TRAYICON      = WM_USER + 1
 	WM_LBUTTONUP     = 0x0202
@@ -80,6 +82,8 @@ const (
 	MenuSettings   = 1005
 	MenuQuit       = 1006
 	MenuLibrary    = 1007 // Library window trigger (left-click on tray)
+	MenuGuideSet   = 1008
+	MenuGuide      = 1009
 )
 
 // NOTIFYICONDATAW structure
@@ -327,6 +331,9 @@ func (t *TrayIcon) showMenu() {
 	appendMenu(hMenu, MF_STRING, MenuRegion, "Capture Region")
 	appendMenu(hMenu, MF_STRING, MenuWindow, "Capture Window")
 	appendMenu(hMenu, MF_SEPARATOR, 0, "")
+	appendMenu(hMenu, MF_STRING, MenuGuideSet, "Set Crop Guide...")
+	appendMenu(hMenu, MF_STRING, MenuGuide, "Toggle Crop Guide")
+	appendMenu(hMenu, MF_SEPARATOR, 0, "")
 	appendMenu(hMenu, MF_STRING, MenuQuit, "Quit")
 
 	// Get cursor position
@@ -406,6 +413,26 @@ func (t *TrayIcon) SetTooltip(tooltip string) {
 	}
 }
 
+// ShowBalloon shows a balloon notification from the tray icon
+func (t *TrayIcon) ShowBalloon(title, message string) {
+	if !t.visible {
+		return
+	}
+
+	nid := t.nid
+	nid.UFlags |= NIF_INFO
+	nid.DwInfoFlags = NIIF_INFO
+	titleW := syscall.StringToUTF16(title)
+	for i := 0; i < len(titleW) && i < 63; i++ {
+		nid.SzInfoTitle[i] = titleW[i]
+	}
+	msgW := syscall.StringToUTF16(message)
+	for i := 0; i < len(msgW) && i < 255; i++ {
+		nid.SzInfo[i] = msgW[i]
+	}
+	procShell_NotifyIconW.Call(NIM_MODIFY, uintptr(unsafe.Pointer(&nid)))
+}
+
 // Stop removes the tray icon and stops the message loop
 func (t *TrayIcon) Stop() error {
 	if t.running {
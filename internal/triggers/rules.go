@@ -0,0 +1,146 @@
+package triggers
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"regexp"
+	"time"
+)
+
+// Event is a window lifecycle change that can fire a rule
+type Event int
+
+const (
+	EventOpen  Event = iota + 1 // Window shown
+	EventClose                  // Window destroyed
+	EventFocus                  // Window brought to the foreground
+)
+
+// DefaultCooldown is how long a rule waits before it can fire again, so a
+// window that flickers or reopens in a loop doesn't flood the disk
+const DefaultCooldown = 5 * time.Second
+
+// ParseEvent parses "open", "close" or "focus"
+func ParseEvent(s string) (Event, error) {
+	switch s {
+	case "open":
+		return EventOpen, nil
+	case "close":
+		return EventClose, nil
+	case "focus":
+		return EventFocus, nil
+	}
+	return 0, fmt.Errorf("unknown trigger event %q", s)
+}
+
+// String returns the config name of the event
+func (e Event) String() string {
+	switch e {
+	case EventOpen:
+		return "open"
+	case EventClose:
+		return "close"
+	case EventFocus:
+		return "focus"
+	}
+	return "unknown"
+}
+
+// Window describes the top-level window an event happened to
+type Window struct {
+	Handle uintptr
+	Title  string
+	Class  string
+	Bounds image.Rectangle // Screen coordinates, last known for closed windows
+}
+
+// Rule fires when a window matching its patterns sees its event. A nil
+// pattern matches anything.
+type Rule struct {
+	Name  string
+	Event Event
+	Title *regexp.Regexp
+	Class *regexp.Regexp
+}
+
+// NewRule compiles a rule. Patterns are case-insensitive regular expressions,
+// so a plain word matches any title containing it. At least one pattern is
+// required to keep a rule from firing on every window.
+func NewRule(name, event, title, class string) (Rule, error) {
+	ev, err := ParseEvent(event)
+	if err != nil {
+		return Rule{}, err
+	}
+	if title == "" && class == "" {
+		return Rule{}, errors.New("trigger rule needs a title or class pattern")
+	}
+
+	rule := Rule{Name: name, Event: ev}
+	if rule.Title, err = compilePattern(title); err != nil {
+		return Rule{}, fmt.Errorf("invalid title pattern: %w", err)
+	}
+	if rule.Class, err = compilePattern(class); err != nil {
+		return Rule{}, fmt.Errorf("invalid class pattern: %w", err)
+	}
+	return rule, nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Matches reports whether the rule applies to an event on w
+func (r Rule) Matches(ev Event, w Window) bool {
+	if ev != r.Event {
+		return false
+	}
+	if r.Title != nil && !r.Title.MatchString(w.Title) {
+		return false
+	}
+	if r.Class != nil && !r.Class.MatchString(w.Class) {
+		return false
+	}
+	return true
+}
+
+// Match is a rule firing for a window
+type Match struct {
+	Rule   int // Index into the rules passed to the matcher
+	Event  Event
+	Window Window
+}
+
+// matcher applies rules with a per-rule cooldown
+type matcher struct {
+	rules    []Rule
+	cooldown time.Duration
+	last     map[int]time.Time // Last time each rule fired
+}
+
+func newMatcher(rules []Rule, cooldown time.Duration) *matcher {
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	return &matcher{rules: rules, cooldown: cooldown, last: make(map[int]time.Time)}
+}
+
+// match returns the rules firing for an event at now, skipping rules that
+// fired within the cooldown
+func (m *matcher) match(ev Event, w Window, now time.Time) []Match {
+	var matches []Match
+	for i, rule := range m.rules {
+		if !rule.Matches(ev, w) {
+			continue
+		}
+		if last, ok := m.last[i]; ok && now.Sub(last) < m.cooldown {
+			continue
+		}
+		m.last[i] = now
+		matches = append(matches, Match{Rule: i, Event: ev, Window: w})
+	}
+	return matches
+}
@@ -0,0 +1,75 @@
+package triggers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		event        string
+		title, class string
+		wantErr      bool
+	}{
+		{"title only", "open", "Error", "", false},
+		{"class only", "focus", "", "#32770", false},
+		{"no pattern", "open", "", "", true},
+		{"bad event", "minimize", "Error", "", true},
+		{"bad regexp", "close", "Error(", "", true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewRule(tt.name, tt.event, tt.title, tt.class)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: NewRule() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	rule, err := NewRule("errors", "open", "error", `^#32770$`)
+	if err != nil {
+		t.Fatalf("NewRule() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ev   Event
+		win  Window
+		want bool
+	}{
+		{"matching dialog", EventOpen, Window{Title: "Fatal Error", Class: "#32770"}, true},
+		{"wrong event", EventClose, Window{Title: "Fatal Error", Class: "#32770"}, false},
+		{"wrong title", EventOpen, Window{Title: "Save As", Class: "#32770"}, false},
+		{"wrong class", EventOpen, Window{Title: "Error log - Notepad", Class: "Notepad"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := rule.Matches(tt.ev, tt.win); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_Cooldown(t *testing.T) {
+	open, _ := NewRule("open", "open", "Error", "")
+	focus, _ := NewRule("focus", "focus", "Error", "")
+	m := newMatcher([]Rule{open, focus}, 0)
+	win := Window{Title: "Error"}
+	start := time.Now()
+
+	if got := m.match(EventOpen, win, start); len(got) != 1 || got[0].Rule != 0 {
+		t.Fatalf("first match = %+v, want rule 0", got)
+	}
+	if got := m.match(EventOpen, win, start.Add(time.Second)); len(got) != 0 {
+		t.Errorf("match within cooldown = %+v, want none", got)
+	}
+	// Cooldowns are per rule
+	if got := m.match(EventFocus, win, start.Add(time.Second)); len(got) != 1 || got[0].Rule != 1 {
+		t.Errorf("other rule within cooldown = %+v, want rule 1", got)
+	}
+	if got := m.match(EventOpen, win, start.Add(DefaultCooldown)); len(got) != 1 {
+		t.Errorf("match after cooldown = %+v, want rule 0", got)
+	}
+}
@@ -0,0 +1,242 @@
+package triggers
+
+import (
+	"errors"
+	"image"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	winEnum "winshot/internal/windows"
+)
+
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	procSetWinEventHook  = user32.NewProc("SetWinEventHook")
+	procUnhookWinEvent   = user32.NewProc("UnhookWinEvent")
+	procGetAncestor      = user32.NewProc("GetAncestor")
+	procPeekMessageW     = user32.NewProc("PeekMessageW")
+	procTranslateMessage = user32.NewProc("TranslateMessage")
+	procDispatchMessageW = user32.NewProc("DispatchMessageW")
+)
+
+// WinEvent constants
+const (
+	EVENT_SYSTEM_FOREGROUND = 0x0003
+	EVENT_OBJECT_DESTROY    = 0x8001
+	EVENT_OBJECT_SHOW       = 0x8002
+	WINEVENT_OUTOFCONTEXT   = 0x0000
+	WINEVENT_SKIPOWNPROCESS = 0x0002
+	OBJID_WINDOW            = 0
+	CHILDID_SELF            = 0
+	GA_ROOT                 = 2
+	PM_REMOVE               = 0x0001
+)
+
+// MSG structure for Windows messages
+type MSG struct {
+	HWND    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      struct{ X, Y int32 }
+}
+
+// Watcher listens for top-level windows opening, closing and coming to the
+// foreground in other processes and reports the ones matching its rules.
+// Only one Watcher may run at a time.
+type Watcher struct {
+	onMatch func(Match)
+	matcher *matcher
+	known   map[uintptr]Window // Windows seen open, to describe them once destroyed
+	running bool
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+	mu      sync.Mutex
+}
+
+var winEventCallback = syscall.NewCallback(winEventProc)
+var watcherInstance *Watcher
+
+// NewWatcher creates a stopped watcher. onMatch is called on its own goroutine
+// for every rule that fires.
+func NewWatcher(rules []Rule, cooldown time.Duration, onMatch func(Match)) *Watcher {
+	return &Watcher{
+		onMatch: onMatch,
+		matcher: newMatcher(rules, cooldown),
+		known:   make(map[uintptr]Window),
+	}
+}
+
+// Start installs the event hooks on a dedicated thread
+func (w *Watcher) Start() error {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return nil
+	}
+	if watcherInstance != nil {
+		w.mu.Unlock()
+		return errors.New("another trigger watcher is already running")
+	}
+	w.running = true
+	w.stopCh = make(chan struct{})
+	w.doneCh = make(chan struct{})
+	watcherInstance = w
+	w.mu.Unlock()
+
+	readyCh := make(chan error, 1)
+	go w.messageLoop(readyCh)
+
+	if err := <-readyCh; err != nil {
+		w.mu.Lock()
+		w.running = false
+		watcherInstance = nil
+		w.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Stop removes the hooks and waits for the watcher thread to exit
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	close(w.stopCh)
+	doneCh := w.doneCh
+	w.mu.Unlock()
+
+	select {
+	case <-doneCh:
+	case <-time.After(2 * time.Second):
+	}
+}
+
+func (w *Watcher) messageLoop(readyCh chan<- error) {
+	// Out-of-context hooks call back on the thread that installed them, and
+	// only while it pumps messages
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(w.doneCh)
+	defer func() {
+		w.mu.Lock()
+		if watcherInstance == w {
+			watcherInstance = nil
+		}
+		w.mu.Unlock()
+	}()
+
+	var hooks []uintptr
+	defer func() {
+		for _, h := range hooks {
+			procUnhookWinEvent.Call(h)
+		}
+	}()
+
+	// Windows are reported when shown rather than created, since most are
+	// created hidden and get their title and size before showing
+	for _, r := range [][2]uintptr{
+		{EVENT_SYSTEM_FOREGROUND, EVENT_SYSTEM_FOREGROUND},
+		{EVENT_OBJECT_DESTROY, EVENT_OBJECT_SHOW},
+	} {
+		h, _, _ := procSetWinEventHook.Call(
+			r[0], r[1],
+			0,
+			winEventCallback,
+			0, 0,
+			WINEVENT_OUTOFCONTEXT|WINEVENT_SKIPOWNPROCESS,
+		)
+		if h == 0 {
+			readyCh <- errors.New("failed to install window event hook")
+			return
+		}
+		hooks = append(hooks, h)
+	}
+	readyCh <- nil
+
+	var msg MSG
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		default:
+			for {
+				ret, _, _ := procPeekMessageW.Call(
+					uintptr(unsafe.Pointer(&msg)),
+					0, 0, 0, PM_REMOVE,
+				)
+				if ret == 0 {
+					break
+				}
+				procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+				procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+// winEventProc receives hook events on the watcher thread
+func winEventProc(hook, event, hwnd, idObject, idChild, eventThread, eventTime uintptr) uintptr {
+	w := watcherInstance
+	if w == nil || hwnd == 0 || int32(idObject) != OBJID_WINDOW || int32(idChild) != CHILDID_SELF {
+		return 0
+	}
+
+	// Only top-level windows, not the controls inside them
+	root, _, _ := procGetAncestor.Call(hwnd, GA_ROOT)
+	if root != hwnd {
+		return 0
+	}
+
+	switch event {
+	case EVENT_OBJECT_SHOW:
+		if win, ok := describeWindow(hwnd); ok {
+			w.known[hwnd] = win
+			w.dispatch(EventOpen, win)
+		}
+	case EVENT_SYSTEM_FOREGROUND:
+		if win, ok := describeWindow(hwnd); ok {
+			w.known[hwnd] = win
+			w.dispatch(EventFocus, win)
+		}
+	case EVENT_OBJECT_DESTROY:
+		// The window is gone, so describe it as it was last seen
+		if win, ok := w.known[hwnd]; ok {
+			delete(w.known, hwnd)
+			w.dispatch(EventClose, win)
+		}
+	}
+	return 0
+}
+
+// dispatch runs matching rules off the hook thread so slow captures don't
+// stall event delivery
+func (w *Watcher) dispatch(ev Event, win Window) {
+	for _, m := range w.matcher.match(ev, win, time.Now()) {
+		if w.onMatch != nil {
+			go w.onMatch(m)
+		}
+	}
+}
+
+// describeWindow reads the title, class and bounds of a visible window
+func describeWindow(hwnd uintptr) (Window, bool) {
+	info, _ := winEnum.GetWindowInfo(hwnd)
+	if info == nil {
+		return Window{}, false
+	}
+	return Window{
+		Handle: hwnd,
+		Title:  info.Title,
+		Class:  info.ClassName,
+		Bounds: image.Rect(info.X, info.Y, info.X+info.Width, info.Y+info.Height),
+	}, true
+}
@@ -0,0 +1,285 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	confluenceUploadTimeout = 60 * time.Second
+	confluenceTestTimeout   = 10 * time.Second
+	confluenceMaxFileSize   = 100 * 1024 * 1024 // Confluence's default attachment limit
+	confluenceEditRetries   = 3                 // Page updates retried when someone else saved first
+)
+
+// ConfluenceConfig holds configuration for Confluence.
+type ConfluenceConfig struct {
+	BaseURL string `json:"baseUrl"`          // e.g. https://example.atlassian.net/wiki
+	PageID  string `json:"pageId"`           // Page the captures are attached to
+	Marker  string `json:"marker,omitempty"` // Text in the page body to insert images before, empty only attaches
+}
+
+// ConfluenceUploader implements Uploader for Confluence page attachments.
+// With a marker set it also inserts an image macro for each capture just
+// before the marker text, so captures appear in the page in the order they
+// were taken while the marker stays in place for the next one.
+type ConfluenceUploader struct {
+	creds     *CredentialManager
+	config    *ConfluenceConfig
+	transport http.RoundTripper // nil uses http.DefaultTransport, tests use a local server
+}
+
+// NewConfluenceUploader creates a new ConfluenceUploader instance.
+func NewConfluenceUploader(creds *CredentialManager, cfg *ConfluenceConfig) *ConfluenceUploader {
+	return &ConfluenceUploader{creds: creds, config: cfg}
+}
+
+// IsConfigured returns true if the site, page and API token are set.
+func (c *ConfluenceUploader) IsConfigured() bool {
+	if c.config == nil || c.config.BaseURL == "" || c.config.PageID == "" {
+		return false
+	}
+	return c.creds.Exists(CredConfluenceToken)
+}
+
+// confluenceStatusError is a failed REST call, classified by its status code.
+type confluenceStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *confluenceStatusError) Error() string {
+	if e.msg == "" {
+		return fmt.Sprintf("Confluence returned %d", e.status)
+	}
+	return fmt.Sprintf("Confluence returned %d: %s", e.status, e.msg)
+}
+
+func (e *confluenceStatusError) HTTPStatusCode() int { return e.status }
+
+// confluencePage is the part of a page the macro insertion reads and writes.
+type confluencePage struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Version struct {
+		Number    int  `json:"number"`
+		MinorEdit bool `json:"minorEdit"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+// Upload attaches the image to the configured page, inserts an image macro
+// at the marker if one is set, and returns the attachment's download URL.
+func (c *ConfluenceUploader) Upload(ctx context.Context, data []byte, filename string) (*UploadResult, error) {
+	// Check context before starting
+	if err := ctx.Err(); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+
+	// Validate file size
+	if len(data) == 0 {
+		return &UploadResult{Success: false, Error: "empty file data"}, errors.New("empty file data")
+	}
+	if len(data) > confluenceMaxFileSize {
+		errMsg := fmt.Sprintf("file size %d exceeds maximum %d bytes", len(data), confluenceMaxFileSize)
+		return &UploadResult{Success: false, Error: errMsg}, errors.New(errMsg)
+	}
+	if !c.IsConfigured() {
+		err := newAuthError("Confluence is not configured. Please set the site, page and API token in Settings", nil)
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+
+	uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout(confluenceUploadTimeout, len(data)))
+	defer cancel()
+
+	link, err := c.attach(uploadCtx, data, filename)
+	if err != nil {
+		return &UploadResult{Success: false, Error: fmt.Sprintf("upload failed: %v", err)}, classifyError(err)
+	}
+
+	if c.config.Marker != "" {
+		if err := c.insertImage(uploadCtx, filename); err != nil {
+			return &UploadResult{
+				Success: false,
+				Error:   fmt.Sprintf("attached but failed to insert image: %v", err),
+			}, classifyError(err)
+		}
+	}
+
+	return &UploadResult{Success: true, PublicURL: link}, nil
+}
+
+// attach uploads data as an attachment of the page, replacing any attachment
+// with the same name, and returns its download URL.
+func (c *ConfluenceUploader) attach(ctx context.Context, data []byte, filename string) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+	header.Set("Content-Type", detectContentType(filename))
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+	mw.WriteField("minorEdit", "true") // Don't notify page watchers about every capture
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	var res struct {
+		Results []struct {
+			Links struct {
+				Download string `json:"download"`
+			} `json:"_links"`
+		} `json:"results"`
+		Links struct {
+			Base string `json:"base"`
+		} `json:"_links"`
+	}
+	path := "/rest/api/content/" + url.PathEscape(c.config.PageID) + "/child/attachment"
+	if err := c.do(ctx, http.MethodPut, path, mw.FormDataContentType(), &body, &res); err != nil {
+		return "", err
+	}
+	if len(res.Results) == 0 || res.Results[0].Links.Download == "" {
+		return "", errors.New("Confluence did not return the attachment")
+	}
+
+	base := res.Links.Base
+	if base == "" {
+		base = c.baseURL()
+	}
+	return base + res.Results[0].Links.Download, nil
+}
+
+// insertImage adds an image macro showing the attachment filename just
+// before the marker in the page body. Saves that lose a race with another
+// edit are retried on the new version.
+func (c *ConfluenceUploader) insertImage(ctx context.Context, filename string) error {
+	path := "/rest/api/content/" + url.PathEscape(c.config.PageID)
+	for attempt := 1; ; attempt++ {
+		var page confluencePage
+		if err := c.do(ctx, http.MethodGet, path+"?expand=body.storage,version", "", nil, &page); err != nil {
+			return err
+		}
+		body, err := insertImageMacro(page.Body.Storage.Value, c.config.Marker, filename)
+		if err != nil {
+			return err
+		}
+
+		page.Version.Number++
+		page.Version.MinorEdit = true
+		page.Body.Storage.Value = body
+		page.Body.Storage.Representation = "storage"
+		data, err := json.Marshal(page)
+		if err != nil {
+			return err
+		}
+		err = c.do(ctx, http.MethodPut, path, "application/json", bytes.NewReader(data), nil)
+		var statusErr *confluenceStatusError
+		if errors.As(err, &statusErr) && statusErr.status == http.StatusConflict && attempt < confluenceEditRetries {
+			continue
+		}
+		return err
+	}
+}
+
+// insertImageMacro returns the storage format body with an image macro for
+// the attachment filename inserted before the first occurrence of marker.
+func insertImageMacro(body, marker, filename string) (string, error) {
+	// Storage format is XHTML, so the marker appears with its entities escaped
+	i := strings.Index(body, html.EscapeString(marker))
+	if i < 0 {
+		i = strings.Index(body, marker)
+	}
+	if i < 0 {
+		return "", fmt.Errorf("marker %q not found in the page", marker)
+	}
+	macro := `<ac:image><ri:attachment ri:filename="` + html.EscapeString(filename) + `" /></ac:image>`
+	return body[:i] + macro + body[i:], nil
+}
+
+// TestConnection verifies the credentials can read the configured page.
+func (c *ConfluenceUploader) TestConnection() error {
+	if !c.IsConfigured() {
+		return newAuthError("Confluence is not configured", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), confluenceTestTimeout)
+	defer cancel()
+
+	var page confluencePage
+	if err := c.do(ctx, http.MethodGet, "/rest/api/content/"+url.PathEscape(c.config.PageID), "", nil, &page); err != nil {
+		return fmt.Errorf("Confluence connection test failed: %w", classifyError(err))
+	}
+	return nil
+}
+
+// baseURL returns the configured site without a trailing slash.
+func (c *ConfluenceUploader) baseURL() string {
+	return strings.TrimRight(c.config.BaseURL, "/")
+}
+
+// do sends a REST request and decodes a JSON response into out if it is not
+// nil. Atlassian Cloud signs in with the account email and an API token;
+// without a user the token is sent as a Data Center personal access token.
+func (c *ConfluenceUploader) do(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	token, err := c.creds.Get(CredConfluenceToken)
+	if err != nil {
+		return newAuthError("missing Confluence API token", err)
+	}
+	user, _ := c.creds.Get(CredConfluenceUser)
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL()+path, body)
+	if err != nil {
+		return err
+	}
+	if user != "" {
+		req.SetBasicAuth(user, token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Atlassian-Token", "no-check") // Required for attachment uploads
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := throttledClient(c.transport).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var problem struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&problem)
+		return &confluenceStatusError{status: resp.StatusCode, msg: problem.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid Confluence response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeConfluence serves the attachment and page endpoints for page 42,
+// recording what was sent
+type fakeConfluence struct {
+	body      string // Page storage body
+	version   int
+	conflicts int // PUTs to the page answered 409 before one succeeds
+	auth      string
+	file      string
+	filename  string
+	minorEdit string
+}
+
+func (f *fakeConfluence) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.auth = r.Header.Get("Authorization")
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/wiki/rest/api/content/42/child/attachment":
+		if r.Header.Get("X-Atlassian-Token") != "no-check" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		f.file, f.filename, f.minorEdit = string(data), header.Filename, r.FormValue("minorEdit")
+		io.WriteString(w, `{"results":[{"id":"att7","_links":{"download":"/download/attachments/42/`+header.Filename+`"}}],"_links":{"base":"https://docs.example.com/wiki"}}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content/42":
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": "42", "type": "page", "title": "Setup guide",
+			"version": map[string]any{"number": f.version},
+			"body":    map[string]any{"storage": map[string]any{"value": f.body, "representation": "storage"}},
+		})
+	case r.Method == http.MethodPut && r.URL.Path == "/wiki/rest/api/content/42":
+		if f.conflicts > 0 {
+			f.conflicts--
+			f.version++ // Someone else saved
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var page confluencePage
+		json.NewDecoder(r.Body).Decode(&page)
+		if page.Version.Number != f.version+1 || page.Title != "Setup guide" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.body, f.version = page.Body.Storage.Value, page.Version.Number
+		io.WriteString(w, `{}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testConfluenceUploader(t *testing.T, handler http.Handler, creds map[string]string, marker string) *ConfluenceUploader {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewConfluenceUploader(NewMemoryCredentialManager(creds), &ConfluenceConfig{
+		BaseURL: server.URL + "/wiki/",
+		PageID:  "42",
+		Marker:  marker,
+	})
+}
+
+func TestConfluenceUploader_IsConfigured(t *testing.T) {
+	creds := NewMemoryCredentialManager(map[string]string{CredConfluenceToken: "token"})
+	tests := []struct {
+		name string
+		cfg  *ConfluenceConfig
+		want bool
+	}{
+		{"nil config", nil, false},
+		{"no site", &ConfluenceConfig{PageID: "42"}, false},
+		{"no page", &ConfluenceConfig{BaseURL: "https://docs.example.com/wiki"}, false},
+		{"complete", &ConfluenceConfig{BaseURL: "https://docs.example.com/wiki", PageID: "42"}, true},
+	}
+	for _, tt := range tests {
+		if got := NewConfluenceUploader(creds, tt.cfg).IsConfigured(); got != tt.want {
+			t.Errorf("%s: IsConfigured() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if NewConfluenceUploader(NewMemoryCredentialManager(nil), tests[3].cfg).IsConfigured() {
+		t.Error("IsConfigured() = true without an API token")
+	}
+}
+
+func TestConfluenceUploader_UploadAttachesOnly(t *testing.T) {
+	fake := &fakeConfluence{body: "<p>[[shots]]</p>", version: 3}
+	u := testConfluenceUploader(t, fake, map[string]string{
+		CredConfluenceUser:  "writer@example.com",
+		CredConfluenceToken: "api-token",
+	}, "")
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot.png")
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if want := "https://docs.example.com/wiki/download/attachments/42/shot.png"; !result.Success || result.PublicURL != want {
+		t.Errorf("Upload() = %+v, want success with URL %s", result, want)
+	}
+	if fake.file != "png data" || fake.filename != "shot.png" || fake.minorEdit != "true" {
+		t.Errorf("attachment = %q named %q (minorEdit %q)", fake.file, fake.filename, fake.minorEdit)
+	}
+	if !strings.HasPrefix(fake.auth, "Basic ") {
+		t.Errorf("Authorization = %q, want basic auth for a Cloud account", fake.auth)
+	}
+	if fake.version != 3 {
+		t.Error("page was edited without a marker")
+	}
+}
+
+func TestConfluenceUploader_UploadInsertsMacroAtMarker(t *testing.T) {
+	fake := &fakeConfluence{body: "<p>Step 1</p><p>[[shots]]</p>", version: 3, conflicts: 1}
+	u := testConfluenceUploader(t, fake, map[string]string{CredConfluenceToken: "pat"}, "[[shots]]")
+
+	if _, err := u.Upload(context.Background(), []byte("png data"), "a&b.png"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	want := `<p>Step 1</p><p><ac:image><ri:attachment ri:filename="a&amp;b.png" /></ac:image>[[shots]]</p>`
+	if fake.body != want {
+		t.Errorf("page body = %s, want %s", fake.body, want)
+	}
+	if fake.auth != "Bearer pat" {
+		t.Errorf("Authorization = %q, want a bearer personal access token", fake.auth)
+	}
+}
+
+func TestConfluenceUploader_UploadMissingMarker(t *testing.T) {
+	fake := &fakeConfluence{body: "<p>No marker here</p>", version: 1}
+	u := testConfluenceUploader(t, fake, map[string]string{CredConfluenceToken: "pat"}, "[[shots]]")
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot.png")
+	if err == nil || result.Success || !strings.Contains(result.Error, "attached") {
+		t.Errorf("Upload() = %+v, %v, want an error saying the image was attached", result, err)
+	}
+}
+
+func TestConfluenceUploader_UploadRejectedToken(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	u := testConfluenceUploader(t, handler, map[string]string{CredConfluenceToken: "expired"}, "")
+
+	if _, err := u.Upload(context.Background(), []byte("png data"), "shot.png"); !errors.Is(err, ErrUploadAuth) {
+		t.Errorf("Upload() error = %v, want ErrUploadAuth", err)
+	}
+	if err := u.TestConnection(); !errors.Is(err, ErrUploadAuth) {
+		t.Errorf("TestConnection() error = %v, want ErrUploadAuth", err)
+	}
+}
+
+func TestInsertImageMacro_EscapedMarker(t *testing.T) {
+	got, err := insertImageMacro("<p>&lt;shots&gt;</p>", "<shots>", "x.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p><ac:image><ri:attachment ri:filename="x.png" /></ac:image>&lt;shots&gt;</p>`; got != want {
+		t.Errorf("insertImageMacro() = %s, want %s", got, want)
+	}
+}
@@ -4,6 +4,7 @@ package upload
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/danieljoos/wincred"
 )
@@ -22,26 +23,73 @@ const (
 	CredR2BucketName = credentialPrefix + "R2_BucketName"
 	// CredR2PublicURL is the key for R2 public URL base
 	CredR2PublicURL = credentialPrefix + "R2_PublicURL"
+	// CredS3AccessKeyID is the key for S3-compatible access key ID
+	CredS3AccessKeyID = credentialPrefix + "S3_AccessKeyID"
+	// CredS3SecretAccessKey is the key for S3-compatible secret access key
+	CredS3SecretAccessKey = credentialPrefix + "S3_SecretAccessKey"
 	// CredGDriveToken is the key for Google Drive OAuth token JSON
 	CredGDriveToken = credentialPrefix + "GDrive_Token"
 	// CredGDriveClientID is the key for user-provided OAuth client ID
 	CredGDriveClientID = credentialPrefix + "GDrive_ClientID"
 	// CredGDriveClientSecret is the key for user-provided OAuth client secret
 	CredGDriveClientSecret = credentialPrefix + "GDrive_ClientSecret"
+	// CredOneNoteToken is the key for the Microsoft OAuth token JSON used for OneNote
+	CredOneNoteToken = credentialPrefix + "OneNote_Token"
+	// CredOneNoteClientID is the key for the user's Microsoft app client ID
+	CredOneNoteClientID = credentialPrefix + "OneNote_ClientID"
+	// CredConfluenceUser is the key for the Atlassian account email, empty for Data Center tokens
+	CredConfluenceUser = credentialPrefix + "Confluence_User"
+	// CredConfluenceToken is the key for the Confluence API or personal access token
+	CredConfluenceToken = credentialPrefix + "Confluence_Token"
+	// CredEncryptPassphrase is the key for the passphrase encrypted uploads are locked with
+	CredEncryptPassphrase = credentialPrefix + "Encrypt_Passphrase"
 )
 
+// AllCredentialKeys lists every credential WinShot stores, so uninstall
+// cleanup can remove them.
+var AllCredentialKeys = []string{
+	CredR2AccessKeyID,
+	CredR2SecretAccessKey,
+	CredR2Endpoint,
+	CredR2BucketName,
+	CredR2PublicURL,
+	CredS3AccessKeyID,
+	CredS3SecretAccessKey,
+	CredGDriveToken,
+	CredGDriveClientID,
+	CredGDriveClientSecret,
+	CredOneNoteToken,
+	CredOneNoteClientID,
+	CredConfluenceUser,
+	CredConfluenceToken,
+	CredEncryptPassphrase,
+}
+
 // ErrCredentialNotFound is returned when a credential does not exist
 var ErrCredentialNotFound = errors.New("credential not found")
 
 // CredentialManager provides methods to store and retrieve credentials
 // using Windows Credential Manager (DPAPI encrypted storage).
-type CredentialManager struct{}
+type CredentialManager struct {
+	memory map[string]string // Set by NewMemoryCredentialManager instead of using Windows
+	mu     sync.Mutex
+}
 
 // NewCredentialManager creates a new CredentialManager instance.
 func NewCredentialManager() *CredentialManager {
 	return &CredentialManager{}
 }
 
+// NewMemoryCredentialManager creates a CredentialManager holding values in
+// memory only, for tests that must not touch the user's stored credentials.
+func NewMemoryCredentialManager(values map[string]string) *CredentialManager {
+	memory := make(map[string]string, len(values))
+	for k, v := range values {
+		memory[k] = v
+	}
+	return &CredentialManager{memory: memory}
+}
+
 // Set stores a credential value in Windows Credential Manager.
 // If the credential already exists, it will be overwritten.
 // Credentials are stored per-user (not machine-wide) to avoid requiring admin.
@@ -49,6 +97,12 @@ func (cm *CredentialManager) Set(key, value string) error {
 	if key == "" {
 		return errors.New("credential key cannot be empty")
 	}
+	if cm.memory != nil {
+		cm.mu.Lock()
+		defer cm.mu.Unlock()
+		cm.memory[key] = value
+		return nil
+	}
 	cred := wincred.NewGenericCredential(key)
 	cred.CredentialBlob = []byte(value)
 	cred.Persist = wincred.PersistEnterprise // Per-user, roaming-profile compatible
@@ -59,6 +113,15 @@ func (cm *CredentialManager) Set(key, value string) error {
 // Returns ErrCredentialNotFound if the credential does not exist.
 // Note: Error string matching based on wincred v1.2.3 behavior.
 func (cm *CredentialManager) Get(key string) (string, error) {
+	if cm.memory != nil {
+		cm.mu.Lock()
+		defer cm.mu.Unlock()
+		value, ok := cm.memory[key]
+		if !ok {
+			return "", ErrCredentialNotFound
+		}
+		return value, nil
+	}
 	cred, err := wincred.GetGenericCredential(key)
 	if err != nil {
 		// Check if credential not found (wincred v1.2.3 error message)
@@ -74,6 +137,12 @@ func (cm *CredentialManager) Get(key string) (string, error) {
 // Returns nil if the credential doesn't exist.
 // Note: Error string matching based on wincred v1.2.3 behavior.
 func (cm *CredentialManager) Delete(key string) error {
+	if cm.memory != nil {
+		cm.mu.Lock()
+		defer cm.mu.Unlock()
+		delete(cm.memory, key)
+		return nil
+	}
 	cred, err := wincred.GetGenericCredential(key)
 	if err != nil {
 		// Credential doesn't exist, nothing to delete (wincred v1.2.3 error message)
@@ -87,6 +156,10 @@ func (cm *CredentialManager) Delete(key string) error {
 
 // Exists checks if a credential exists in Windows Credential Manager.
 func (cm *CredentialManager) Exists(key string) bool {
+	if cm.memory != nil {
+		_, err := cm.Get(key)
+		return err == nil
+	}
 	_, err := wincred.GetGenericCredential(key)
 	return err == nil
 }
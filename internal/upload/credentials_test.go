@@ -88,6 +88,9 @@ func TestCredentialManager_CredentialConstants(t *testing.T) {
 		CredGDriveToken,
 		CredGDriveClientID,
 		CredGDriveClientSecret,
+		CredConfluenceUser,
+		CredConfluenceToken,
+		CredEncryptPassphrase,
 	}
 
 	prefix := "WinShot_"
@@ -0,0 +1,255 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Encrypted uploads are AES-256-GCM. The blob is a header followed by the
+// nonce and the sealed image, with the header authenticated as additional
+// data:
+//
+//	"WSE1" | kdf (1 byte) | for kdfPBKDF2: iterations (uint32 BE) and salt (16 bytes)
+//
+// With kdfNone the key is random and travels in the share link's fragment,
+// which browsers never send to the server. With kdfPBKDF2 it is derived from
+// a passphrase the recipient is told separately.
+const (
+	encryptMagic     = "WSE1"
+	kdfNone          = 0
+	kdfPBKDF2        = 1
+	pbkdf2Iterations = 600_000 // OWASP's recommendation for PBKDF2-HMAC-SHA256
+	encryptKeySize   = 32
+	encryptSaltSize  = 16
+)
+
+// Iteration counts Decrypt accepts. Fewer would make a weak file look like
+// one of ours, and more would let a crafted file tie up the CPU.
+const (
+	minPBKDF2Iterations = 100_000
+	maxPBKDF2Iterations = 10_000_000
+)
+
+// ErrDecrypt is returned when a blob can't be decrypted with the given key
+// or passphrase, or was modified.
+var ErrDecrypt = errors.New("wrong key or passphrase, or the file was modified")
+
+//go:embed viewer.html
+var viewerTemplate string
+
+// EncryptOptions configures an EncryptingUploader.
+type EncryptOptions struct {
+	Passphrase string // Derive the key from this instead of putting a random key in the link
+	Viewer     bool   // Upload a page that decrypts in the browser instead of the bare blob
+}
+
+// EncryptingUploader wraps another Uploader so captures are encrypted before
+// they leave the machine and the provider only ever stores ciphertext.
+type EncryptingUploader struct {
+	inner Uploader
+	opts  EncryptOptions
+}
+
+// NewEncryptingUploader creates an EncryptingUploader sending to inner.
+func NewEncryptingUploader(inner Uploader, opts EncryptOptions) *EncryptingUploader {
+	return &EncryptingUploader{inner: inner, opts: opts}
+}
+
+// IsConfigured returns true if the wrapped provider is configured.
+func (e *EncryptingUploader) IsConfigured() bool {
+	return e.inner.IsConfigured()
+}
+
+// TestConnection tests the wrapped provider.
+func (e *EncryptingUploader) TestConnection() error {
+	return e.inner.TestConnection()
+}
+
+// Upload encrypts the image and uploads it as filename.enc, or as a viewer
+// page named after it. Without a passphrase the returned link carries the key
+// in its fragment.
+func (e *EncryptingUploader) Upload(ctx context.Context, data []byte, filename string) (*UploadResult, error) {
+	blob, key, err := Encrypt(data, e.opts.Passphrase)
+	if err != nil {
+		return &UploadResult{Success: false, Error: fmt.Sprintf("encryption failed: %v", err)}, err
+	}
+
+	name, payload := filename+".enc", blob
+	if e.opts.Viewer {
+		name = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".html"
+		payload = ViewerPage(blob, detectContentType(filename))
+	}
+
+	result, err := e.inner.Upload(ctx, payload, name)
+	if err != nil || !result.Success {
+		return result, err
+	}
+	if key != nil {
+		result.PublicURL += "#" + base64.RawURLEncoding.EncodeToString(key)
+	}
+	return result, nil
+}
+
+// Encrypt seals data with a new random key, returned for the share link, or
+// with a key derived from passphrase, in which case the returned key is nil.
+func Encrypt(data []byte, passphrase string) (blob, key []byte, err error) {
+	header := []byte(encryptMagic)
+	if passphrase == "" {
+		key = make([]byte, encryptKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, nil, err
+		}
+		header = append(header, kdfNone)
+	} else {
+		salt := make([]byte, encryptSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		if key, err = pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, encryptKeySize); err != nil {
+			return nil, nil, err
+		}
+		header = append(header, kdfPBKDF2)
+		header = binary.BigEndian.AppendUint32(header, pbkdf2Iterations)
+		header = append(header, salt...)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	blob = gcm.Seal(append(header[:len(header):len(header)], nonce...), nonce, data, header)
+
+	if passphrase != "" {
+		return blob, nil, nil
+	}
+	return blob, key, nil
+}
+
+// Decrypt opens a blob made by Encrypt, or the blob inside a viewer page,
+// with the key from the share link or the passphrase it was locked with.
+func Decrypt(data, key []byte, passphrase string) ([]byte, error) {
+	blob, err := viewerBlob(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < len(encryptMagic)+1 || string(blob[:len(encryptMagic)]) != encryptMagic {
+		return nil, errors.New("not a WinShot encrypted file")
+	}
+
+	headerLen := len(encryptMagic) + 1
+	switch blob[len(encryptMagic)] {
+	case kdfNone:
+		if len(key) != encryptKeySize {
+			return nil, errors.New("the link is missing its key")
+		}
+	case kdfPBKDF2:
+		headerLen += 4 + encryptSaltSize
+		if len(blob) < headerLen {
+			return nil, errors.New("truncated encrypted file")
+		}
+		if passphrase == "" {
+			return nil, errors.New("this file needs a passphrase")
+		}
+		iterations := binary.BigEndian.Uint32(blob[len(encryptMagic)+1:])
+		if iterations < minPBKDF2Iterations || iterations > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("unsupported key derivation: %d iterations", iterations)
+		}
+		salt := blob[headerLen-encryptSaltSize : headerLen]
+		if key, err = pbkdf2.Key(sha256.New, passphrase, salt, int(iterations), encryptKeySize); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown key derivation %d", blob[len(encryptMagic)])
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < headerLen+gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("truncated encrypted file")
+	}
+	nonce := blob[headerLen : headerLen+gcm.NonceSize()]
+	plain, err := gcm.Open(nil, nonce, blob[headerLen+gcm.NonceSize():], blob[:headerLen])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// ParseShareLink splits an encrypted share link into the address to download
+// and the key from its fragment, nil if it has none.
+func ParseShareLink(link string) (string, []byte, error) {
+	address, fragment, found := strings.Cut(link, "#")
+	if !found || fragment == "" {
+		return address, nil, nil
+	}
+	key, err := base64.RawURLEncoding.DecodeString(fragment)
+	if err != nil || len(key) != encryptKeySize {
+		return "", nil, errors.New("the link's key is malformed")
+	}
+	return address, key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Placeholders in viewer.html
+const (
+	viewerDataMarker = "{{DATA}}"
+	viewerTypeMarker = "{{TYPE}}"
+)
+
+// ViewerPage returns a self-contained HTML page holding blob that decrypts
+// and shows the image in the browser with the key from its own fragment, or
+// asks for the passphrase. contentType is the image's MIME type.
+func ViewerPage(blob []byte, contentType string) []byte {
+	// Both values only contain characters that need no escaping in a script
+	page := strings.NewReplacer(
+		viewerDataMarker, base64.StdEncoding.EncodeToString(blob),
+		viewerTypeMarker, contentType,
+	).Replace(viewerTemplate)
+	return []byte(page)
+}
+
+// viewerBlob returns the blob embedded in a viewer page, or data itself if
+// it is not one.
+func viewerBlob(data []byte) ([]byte, error) {
+	const open = `<script id="blob" type="application/octet-stream">`
+	i := bytes.Index(data, []byte(open))
+	if i < 0 {
+		return data, nil
+	}
+	rest := data[i+len(open):]
+	end := bytes.Index(rest, []byte("</script>"))
+	if end < 0 {
+		return nil, errors.New("truncated viewer page")
+	}
+	blob, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(bytes.TrimSpace(rest[:end]))))
+	if err != nil {
+		return nil, fmt.Errorf("malformed viewer page: %w", err)
+	}
+	return blob, nil
+}
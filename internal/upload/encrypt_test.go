@@ -0,0 +1,156 @@
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"winshot/internal/upload"
+	"winshot/internal/upload/uploadtest"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plain := []byte("\x89PNG screenshot")
+
+	blob, key, err := upload.Encrypt(plain, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 || bytes.Contains(blob, plain) {
+		t.Fatalf("Encrypt returned a %d byte key, blob %q", len(key), blob)
+	}
+	got, err := upload.Decrypt(blob, key, "")
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+
+	wrong := bytes.Clone(key)
+	wrong[0] ^= 1
+	if _, err := upload.Decrypt(blob, wrong, ""); !errors.Is(err, upload.ErrDecrypt) {
+		t.Errorf("Decrypt with the wrong key = %v, want ErrDecrypt", err)
+	}
+	tampered := bytes.Clone(blob)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := upload.Decrypt(tampered, key, ""); !errors.Is(err, upload.ErrDecrypt) {
+		t.Errorf("Decrypt of a modified blob = %v, want ErrDecrypt", err)
+	}
+	if _, err := upload.Decrypt(blob, nil, ""); err == nil {
+		t.Error("Decrypt succeeded without the key")
+	}
+}
+
+func TestEncryptDecrypt_Passphrase(t *testing.T) {
+	plain := []byte("\x89PNG screenshot")
+
+	blob, key, err := upload.Encrypt(plain, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != nil {
+		t.Error("Encrypt returned a key for the link in passphrase mode")
+	}
+	got, err := upload.Decrypt(blob, nil, "correct horse")
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+	if _, err := upload.Decrypt(blob, nil, "battery staple"); !errors.Is(err, upload.ErrDecrypt) {
+		t.Errorf("Decrypt with the wrong passphrase = %v, want ErrDecrypt", err)
+	}
+	if _, err := upload.Decrypt(blob, nil, ""); err == nil {
+		t.Error("Decrypt succeeded without the passphrase")
+	}
+}
+
+func TestDecrypt_Iterations(t *testing.T) {
+	blob, _, err := upload.Encrypt([]byte("\x89PNG screenshot"), "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The count follows the 4 byte magic and the key derivation byte
+	for _, tc := range []struct {
+		iterations uint32
+		refused    bool
+	}{
+		{0, true},
+		{99_999, true},
+		{100_000, false},
+		{10_000_000, false},
+		{10_000_001, true},
+		{1<<32 - 1, true},
+	} {
+		tampered := bytes.Clone(blob)
+		binary.BigEndian.PutUint32(tampered[5:], tc.iterations)
+		_, err := upload.Decrypt(tampered, nil, "correct horse")
+		// An accepted count derives a different key, so the header check fails
+		if refused := err != nil && !errors.Is(err, upload.ErrDecrypt); refused != tc.refused {
+			t.Errorf("%d iterations: Decrypt = %v, refused %v, want %v", tc.iterations, err, refused, tc.refused)
+		}
+	}
+}
+
+func TestEncryptingUploader(t *testing.T) {
+	plain := []byte("\x89PNG screenshot")
+	inner := uploadtest.New()
+
+	result, err := upload.NewEncryptingUploader(inner, upload.EncryptOptions{}).Upload(context.Background(), plain, "shot.png")
+	if err != nil || !result.Success {
+		t.Fatalf("Upload = %+v, %v", result, err)
+	}
+	stored, ok := inner.File("shot.png.enc")
+	if !ok || bytes.Contains(stored, plain) {
+		t.Fatalf("provider got %v, want only ciphertext in shot.png.enc", inner.Filenames())
+	}
+	address, key, err := upload.ParseShareLink(result.PublicURL)
+	if err != nil || address != "https://uploads.example.com/shot.png.enc" {
+		t.Fatalf("ParseShareLink(%q) = %q, %v", result.PublicURL, address, err)
+	}
+	if got, err := upload.Decrypt(stored, key, ""); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Decrypt with the link's key = %q, %v", got, err)
+	}
+}
+
+func TestEncryptingUploader_Viewer(t *testing.T) {
+	plain := []byte("\x89PNG screenshot")
+	inner := uploadtest.New()
+
+	opts := upload.EncryptOptions{Passphrase: "correct horse", Viewer: true}
+	result, err := upload.NewEncryptingUploader(inner, opts).Upload(context.Background(), plain, "shot.png")
+	if err != nil || !result.Success {
+		t.Fatalf("Upload = %+v, %v", result, err)
+	}
+	if strings.Contains(result.PublicURL, "#") {
+		t.Errorf("PublicURL = %q, want no key in passphrase mode", result.PublicURL)
+	}
+	page, ok := inner.File("shot.html")
+	if !ok {
+		t.Fatalf("provider got %v, want shot.html", inner.Filenames())
+	}
+	if !bytes.Contains(page, []byte(`type: "image/png"`)) || bytes.Contains(page, []byte("{{")) {
+		t.Error("viewer page placeholders were not filled in")
+	}
+	if got, err := upload.Decrypt(page, nil, "correct horse"); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Decrypt of the viewer page = %q, %v", got, err)
+	}
+}
+
+func TestEncryptingUploader_Failure(t *testing.T) {
+	inner := uploadtest.New()
+	inner.Err = errors.New("bucket is gone")
+
+	result, err := upload.NewEncryptingUploader(inner, upload.EncryptOptions{}).Upload(context.Background(), []byte("png"), "shot.png")
+	if err == nil || result.Success || strings.Contains(result.PublicURL, "#") {
+		t.Errorf("Upload = %+v, %v, want the provider's error", result, err)
+	}
+}
+
+func TestParseShareLink(t *testing.T) {
+	if address, key, err := upload.ParseShareLink("https://x.example/a.enc"); err != nil || key != nil || address != "https://x.example/a.enc" {
+		t.Errorf("link without a key = %q, %v, %v", address, key, err)
+	}
+	if _, _, err := upload.ParseShareLink("https://x.example/a.enc#short"); err == nil {
+		t.Error("ParseShareLink accepted a malformed key")
+	}
+}
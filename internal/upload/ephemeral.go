@@ -0,0 +1,193 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEphemeralURL is the public 0x0.st instance.
+	DefaultEphemeralURL = "https://0x0.st"
+
+	ephemeralUploadTimeout = 60 * time.Second
+	ephemeralTestTimeout   = 10 * time.Second
+	ephemeralMaxFileSize   = 512 * 1024 * 1024 // 0x0.st's limit; smaller instances reject larger files themselves
+	ephemeralUserAgent     = "WinShot"         // 0x0.st refuses some generic HTTP client agents
+)
+
+// EphemeralConfig holds configuration for a 0x0-style ephemeral file host.
+type EphemeralConfig struct {
+	Enabled     bool   `json:"enabled"`
+	URL         string `json:"url,omitempty"`         // Instance to upload to, empty uses DefaultEphemeralURL
+	ExpiryHours int    `json:"expiryHours,omitempty"` // Delete after this many hours, 0 keeps the host's size based retention
+	Secret      bool   `json:"secret,omitempty"`      // Ask for a hard to guess link
+}
+
+// EphemeralUploader implements Uploader for hosts speaking the 0x0.st API,
+// such as 0x0.st itself, envs.sh and self-hosted instances. Files are posted
+// anonymously and deleted by the host once they expire, so nothing needs to
+// be cleaned up afterwards.
+type EphemeralUploader struct {
+	config    *EphemeralConfig
+	transport http.RoundTripper // nil uses http.DefaultTransport, tests use a local server
+}
+
+// NewEphemeralUploader creates a new EphemeralUploader instance.
+func NewEphemeralUploader(cfg *EphemeralConfig) *EphemeralUploader {
+	return &EphemeralUploader{config: cfg}
+}
+
+// IsConfigured returns true once the host has been enabled. Uploads need no
+// account, so this only keeps captures from going to a public host by accident.
+func (e *EphemeralUploader) IsConfigured() bool {
+	return e.config != nil && e.config.Enabled
+}
+
+// Upload posts the image to the host and returns its link, with the time the
+// host will delete it when known.
+func (e *EphemeralUploader) Upload(ctx context.Context, data []byte, filename string) (*UploadResult, error) {
+	// Check context before starting
+	if err := ctx.Err(); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+
+	// Validate file size
+	if len(data) == 0 {
+		return &UploadResult{Success: false, Error: "empty file data"}, errors.New("empty file data")
+	}
+	if len(data) > ephemeralMaxFileSize {
+		errMsg := fmt.Sprintf("file size %d exceeds maximum %d bytes", len(data), ephemeralMaxFileSize)
+		return &UploadResult{Success: false, Error: errMsg}, errors.New(errMsg)
+	}
+	if !e.IsConfigured() {
+		err := errors.New("ephemeral host is not enabled. Please enable it in Settings")
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+
+	uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout(ephemeralUploadTimeout, len(data)))
+	defer cancel()
+
+	link, expires, err := e.post(uploadCtx, data, filename)
+	if err != nil {
+		return &UploadResult{Success: false, Error: fmt.Sprintf("upload failed: %v", err)}, err
+	}
+
+	result := &UploadResult{Success: true, PublicURL: link}
+	if !expires.IsZero() {
+		result.ExpiresAt = expires.UTC().Format(time.RFC3339)
+	}
+	return result, nil
+}
+
+// post sends the multipart upload and returns the link and expiry time. The
+// host answers with the link as plain text and the expiry in X-Expires, in
+// milliseconds since the epoch. Without that header the configured expiry is
+// assumed.
+func (e *EphemeralUploader) post(ctx context.Context, data []byte, filename string) (string, time.Time, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+	header.Set("Content-Type", detectContentType(filename))
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	part.Write(data)
+	if e.config.ExpiryHours > 0 {
+		mw.WriteField("expires", strconv.Itoa(e.config.ExpiryHours))
+	}
+	if e.config.Secret {
+		mw.WriteField("secret", "1")
+	}
+	if err := mw.Close(); err != nil {
+		return "", time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL()+"/", &body)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("User-Agent", ephemeralUserAgent)
+
+	sent := time.Now()
+	resp, err := throttledClient(e.transport).Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	text, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	reply := strings.TrimSpace(string(text))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if reply == "" {
+			return "", time.Time{}, fmt.Errorf("host returned %d", resp.StatusCode)
+		}
+		return "", time.Time{}, fmt.Errorf("host returned %d: %s", resp.StatusCode, firstLine(reply))
+	}
+	if !strings.HasPrefix(reply, "https://") && !strings.HasPrefix(reply, "http://") {
+		return "", time.Time{}, fmt.Errorf("host did not return a link: %q", firstLine(reply))
+	}
+
+	var expires time.Time
+	if ms, err := strconv.ParseInt(resp.Header.Get("X-Expires"), 10, 64); err == nil && ms > 0 {
+		expires = time.UnixMilli(ms)
+	} else if e.config.ExpiryHours > 0 {
+		expires = sent.Add(time.Duration(e.config.ExpiryHours) * time.Hour)
+	}
+	return firstLine(reply), expires, nil
+}
+
+// TestConnection verifies the host is reachable.
+func (e *EphemeralUploader) TestConnection() error {
+	if !e.IsConfigured() {
+		return errors.New("ephemeral host is not enabled")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ephemeralTestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.baseURL()+"/", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", ephemeralUserAgent)
+	resp, err := throttledClient(e.transport).Do(req)
+	if err != nil {
+		return fmt.Errorf("ephemeral host connection test failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ephemeral host connection test failed: host returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// baseURL returns the configured instance without a trailing slash.
+func (e *EphemeralUploader) baseURL() string {
+	if e.config.URL == "" {
+		return DefaultEphemeralURL
+	}
+	return strings.TrimRight(e.config.URL, "/")
+}
+
+// firstLine returns s up to its first line break.
+func firstLine(s string) string {
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
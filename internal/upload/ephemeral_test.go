@@ -0,0 +1,137 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEphemeralUploader(t *testing.T, handler http.HandlerFunc, cfg EphemeralConfig) *EphemeralUploader {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	cfg.Enabled = true
+	cfg.URL = server.URL + "/"
+	return NewEphemeralUploader(&cfg)
+}
+
+func TestEphemeralUploader_IsConfigured(t *testing.T) {
+	if NewEphemeralUploader(nil).IsConfigured() {
+		t.Error("IsConfigured() = true without a config")
+	}
+	if NewEphemeralUploader(&EphemeralConfig{URL: DefaultEphemeralURL}).IsConfigured() {
+		t.Error("IsConfigured() = true before the host is enabled")
+	}
+	if !NewEphemeralUploader(&EphemeralConfig{Enabled: true}).IsConfigured() {
+		t.Error("IsConfigured() = false with the host enabled")
+	}
+}
+
+func TestEphemeralUploader_Upload(t *testing.T) {
+	var gotFile, gotName, gotExpires, gotSecret, gotAgent string
+	u := testEphemeralUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		gotFile, gotName = string(data), header.Filename
+		gotExpires, gotSecret, gotAgent = r.FormValue("expires"), r.FormValue("secret"), r.UserAgent()
+		w.Header().Set("X-Expires", "1705329045000")
+		io.WriteString(w, "https://0x0.example/s/abc123/x.png\n")
+	}, EphemeralConfig{ExpiryHours: 24, Secret: true})
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.PublicURL != "https://0x0.example/s/abc123/x.png" {
+		t.Errorf("result = %+v", result)
+	}
+	if result.ExpiresAt != "2024-01-15T14:30:45Z" {
+		t.Errorf("ExpiresAt = %q, want the X-Expires time", result.ExpiresAt)
+	}
+	if gotFile != "png data" || gotName != "shot.png" {
+		t.Errorf("uploaded %q as %q", gotFile, gotName)
+	}
+	if gotExpires != "24" || gotSecret == "" {
+		t.Errorf("expires = %q, secret = %q, want 24 hours and a secret link", gotExpires, gotSecret)
+	}
+	if gotAgent != ephemeralUserAgent {
+		t.Errorf("User-Agent = %q", gotAgent)
+	}
+}
+
+func TestEphemeralUploader_UploadWithoutExpiryHeader(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("expires") != "" || r.FormValue("secret") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, "https://0x0.example/abc.png")
+	}
+	result, err := testEphemeralUploader(t, handler, EphemeralConfig{}).Upload(context.Background(), []byte("png data"), "shot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExpiresAt != "" {
+		t.Errorf("ExpiresAt = %q, want none when neither the host nor the config sets one", result.ExpiresAt)
+	}
+
+	before := time.Now()
+	result, err = testEphemeralUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "https://0x0.example/abc.png")
+	}, EphemeralConfig{ExpiryHours: 2}).Upload(context.Background(), []byte("png data"), "shot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, err := time.Parse(time.RFC3339, result.ExpiresAt)
+	if err != nil || expires.Before(before.Add(2*time.Hour-time.Second)) || expires.After(time.Now().Add(2*time.Hour)) {
+		t.Errorf("ExpiresAt = %q, want two hours from now", result.ExpiresAt)
+	}
+}
+
+func TestEphemeralUploader_UploadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		reply   string
+		wantErr string
+	}{
+		{"rejected", http.StatusUnsupportedMediaType, "File type not allowed\n", "415: File type not allowed"},
+		{"not a link", http.StatusOK, "<html>maintenance</html>", "did not return a link"},
+	}
+	for _, tt := range tests {
+		u := testEphemeralUploader(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.reply)
+		}, EphemeralConfig{})
+		result, err := u.Upload(context.Background(), []byte("png data"), "shot.png")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) || result.Success {
+			t.Errorf("%s: Upload = %+v, %v, want an error containing %q", tt.name, result, err, tt.wantErr)
+		}
+	}
+
+	disabled := NewEphemeralUploader(&EphemeralConfig{})
+	if _, err := disabled.Upload(context.Background(), []byte("png data"), "shot.png"); err == nil {
+		t.Error("Upload succeeded with the host disabled")
+	}
+}
+
+func TestEphemeralUploader_TestConnection(t *testing.T) {
+	u := testEphemeralUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "THE NULL POINTER")
+	}, EphemeralConfig{})
+	if err := u.TestConnection(); err != nil {
+		t.Errorf("TestConnection() = %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"winshot/internal/upload/httpfixture"
+)
+
+// Provider tests replaying recorded HTTP exchanges from testdata. See package
+// httpfixture for recording new fixtures.
+
+func testR2Uploader(t *testing.T, fixture string, cfg *R2Config) *R2Uploader {
+	creds := NewMemoryCredentialManager(map[string]string{
+		CredR2AccessKeyID:     "test-access-key",
+		CredR2SecretAccessKey: "test-secret-key",
+	})
+	u := NewR2Uploader(creds, cfg)
+	u.transport = httpfixture.Transport(t, fixture)
+	return u
+}
+
+func TestR2Uploader_Fixture_Upload(t *testing.T) {
+	u := testR2Uploader(t, "r2_put_object", &R2Config{
+		AccountID: "test-account",
+		Bucket:    "screenshots",
+		PublicURL: "https://shots.example.com/",
+		Directory: "/2024/",
+	})
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot 1.png")
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if want := "https://shots.example.com/2024/shot%201.png"; !result.Success || result.PublicURL != want {
+		t.Errorf("Upload() = %+v, want success with URL %s", result, want)
+	}
+}
+
+func TestR2Uploader_Fixture_Forbidden(t *testing.T) {
+	u := testR2Uploader(t, "r2_put_object_forbidden", &R2Config{
+		AccountID: "test-account",
+		Bucket:    "screenshots",
+		PublicURL: "https://shots.example.com",
+	})
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot.png")
+	if !errors.Is(err, ErrUploadAuth) {
+		t.Fatalf("Upload() error = %v, want ErrUploadAuth", err)
+	}
+	if result.Success {
+		t.Error("Upload() should fail when access is denied")
+	}
+}
+
+func TestGDriveUploader_Fixture_Upload(t *testing.T) {
+	creds := NewMemoryCredentialManager(map[string]string{
+		CredGDriveClientID:     "test-client.apps.googleusercontent.com",
+		CredGDriveClientSecret: "test-client-secret",
+		CredGDriveToken:        `{"access_token":"test-token","token_type":"Bearer","expiry":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+	})
+	u := NewGDriveUploader(creds, &GDriveConfig{FolderID: "folder-1"})
+	u.transport = httpfixture.Transport(t, "gdrive_upload")
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot.png")
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if want := "https://drive.google.com/file/d/1AbCdEfGhIjKlMnOp/view"; !result.Success || result.PublicURL != want {
+		t.Errorf("Upload() = %+v, want success with URL %s", result, want)
+	}
+}
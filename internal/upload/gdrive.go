@@ -38,6 +38,7 @@ type GDriveUploader struct {
 	authDone  chan string
 	authErr   chan error
 	server    *http.Server
+	transport http.RoundTripper // nil uses http.DefaultTransport, tests replay fixtures
 	mu        sync.Mutex
 }
 
@@ -62,7 +63,7 @@ func (g *GDriveUploader) getOAuthConfig() (*oauth2.Config, error) {
 	}
 
 	if clientID == "" || clientSecret == "" {
-		return nil, errors.New("Google OAuth credentials not configured. Please set Client ID and Client Secret in Settings")
+		return nil, newAuthError("Google OAuth credentials not configured. Please set Client ID and Client Secret in Settings", nil)
 	}
 
 	return &oauth2.Config{
@@ -250,12 +251,12 @@ func (g *GDriveUploader) Disconnect() error {
 func (g *GDriveUploader) getService() (*drive.Service, error) {
 	tokenJSON, err := g.creds.Get(CredGDriveToken)
 	if err != nil {
-		return nil, errors.New("not authenticated - please connect your Google account")
+		return nil, newAuthError("not authenticated - please connect your Google account", nil)
 	}
 
 	var token oauth2.Token
 	if err := json.Unmarshal([]byte(tokenJSON), &token); err != nil {
-		return nil, errors.New("invalid stored token - please reconnect your Google account")
+		return nil, newAuthError("invalid stored token - please reconnect your Google account", nil)
 	}
 
 	cfg, err := g.getOAuthConfig()
@@ -263,13 +264,15 @@ func (g *GDriveUploader) getService() (*drive.Service, error) {
 		return nil, err
 	}
 
-	// Create token source that auto-refreshes
-	tokenSource := cfg.TokenSource(context.Background(), &token)
+	// Create token source that auto-refreshes, sending uploads through the
+	// rate limit
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, throttledClient(g.transport))
+	tokenSource := cfg.TokenSource(ctx, &token)
 
 	// Check if token was refreshed and save new token
 	newToken, err := tokenSource.Token()
 	if err != nil {
-		return nil, fmt.Errorf("token refresh failed - please reconnect: %w", err)
+		return nil, newAuthError("token refresh failed - please reconnect", err)
 	}
 
 	// Save refreshed token if it changed
@@ -279,8 +282,8 @@ func (g *GDriveUploader) getService() (*drive.Service, error) {
 		}
 	}
 
-	return drive.NewService(context.Background(),
-		option.WithTokenSource(tokenSource))
+	return drive.NewService(ctx,
+		option.WithHTTPClient(oauth2.NewClient(ctx, tokenSource)))
 }
 
 // Upload uploads image data to Google Drive and returns public URL.
@@ -318,7 +321,7 @@ func (g *GDriveUploader) Upload(ctx context.Context, data []byte, filename strin
 	}
 
 	// Upload with timeout
-	uploadCtx, cancel := context.WithTimeout(ctx, gdriveUploadTimeout)
+	uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout(gdriveUploadTimeout, len(data)))
 	defer cancel()
 
 	res, err := svc.Files.Create(file).
@@ -326,7 +329,7 @@ func (g *GDriveUploader) Upload(ctx context.Context, data []byte, filename strin
 		Context(uploadCtx).
 		Do()
 	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("upload failed: %v", err)}, err
+		return &UploadResult{Success: false, Error: fmt.Sprintf("upload failed: %v", err)}, classifyError(err)
 	}
 
 	// Set public permission
@@ -339,7 +342,7 @@ func (g *GDriveUploader) Upload(ctx context.Context, data []byte, filename strin
 		return &UploadResult{
 			Success: false,
 			Error:   fmt.Sprintf("uploaded but failed to share: %v", err),
-		}, err
+		}, classifyError(err)
 	}
 
 	publicURL := "https://drive.google.com/file/d/" + res.Id + "/view"
@@ -359,7 +362,7 @@ func (g *GDriveUploader) TestConnection() error {
 
 	_, err = svc.About.Get().Fields("user").Context(ctx).Do()
 	if err != nil {
-		return fmt.Errorf("GDrive connection test failed: %w", err)
+		return fmt.Errorf("GDrive connection test failed: %w", classifyError(err))
 	}
 
 	return nil
@@ -0,0 +1,201 @@
+// Package httpfixture records HTTP exchanges to JSON files and replays them,
+// so upload provider tests run without network access or real credentials.
+//
+// Tests call Transport, which replays testdata/<name>.json by default. Run
+// the tests with WINSHOT_RECORD_FIXTURES=1 and real credentials to record
+// fresh fixtures instead. Request headers are never written, so signatures
+// and tokens stay out of the files, but response bodies are written as is:
+// review recorded fixtures before committing them.
+package httpfixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// RecordEnv is the environment variable that switches Transport to recording.
+const RecordEnv = "WINSHOT_RECORD_FIXTURES"
+
+// Exchange is one recorded request and its response.
+type Exchange struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"` // URL path, matched on replay
+	Query  string      `json:"query,omitempty"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Transport returns a replaying transport for testdata/<name>.json, or a
+// recording one when RecordEnv is set. Recordings are saved when the test
+// ends; a replay fails the test if any exchange was left unused.
+func Transport(t testing.TB, name string) http.RoundTripper {
+	t.Helper()
+	path := filepath.Join("testdata", name+".json")
+
+	if os.Getenv(RecordEnv) != "" {
+		rec := NewRecorder(http.DefaultTransport)
+		t.Cleanup(func() {
+			if err := rec.Save(path); err != nil {
+				t.Errorf("failed to save fixture: %v", err)
+			}
+		})
+		return rec
+	}
+
+	rp, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	t.Cleanup(func() {
+		if n := rp.Unused(); n > 0 && !t.Failed() {
+			t.Errorf("%s: %d recorded exchanges were not requested", path, n)
+		}
+	})
+	return rp
+}
+
+// Recorder passes requests to a real transport and keeps the exchanges.
+type Recorder struct {
+	base      http.RoundTripper
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecorder returns a Recorder sending requests through base.
+func NewRecorder(base http.RoundTripper) *Recorder {
+	return &Recorder{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, Exchange{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Status: resp.StatusCode,
+		Header: header,
+		Body:   string(body),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes the recorded exchanges to path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.exchanges, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Replayer answers requests from recorded exchanges. Each request is matched
+// to the first unused exchange with the same method and path, so repeated
+// calls to one endpoint are answered in recorded order.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	used      []bool
+	requests  []*http.Request
+}
+
+// Load reads a fixture file written by Recorder.Save.
+func Load(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var exchanges []Exchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return NewReplayer(exchanges), nil
+}
+
+// NewReplayer returns a Replayer answering from exchanges.
+func NewReplayer(exchanges []Exchange) *Replayer {
+	return &Replayer{exchanges: exchanges, used: make([]bool, len(exchanges))}
+}
+
+// RoundTrip implements http.RoundTripper. Requests with no matching exchange
+// fail, as a real server would never have been asked.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	for i, ex := range r.exchanges {
+		if r.used[i] || ex.Method != req.Method || ex.Path != req.URL.Path {
+			continue
+		}
+		r.used[i] = true
+		header := ex.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", ex.Status, http.StatusText(ex.Status)),
+			StatusCode:    ex.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(ex.Body))),
+			ContentLength: int64(len(ex.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("httpfixture: no recorded response for %s %s", req.Method, req.URL.Path)
+}
+
+// Requests returns the requests received so far, for assertions on what a
+// provider sent.
+func (r *Replayer) Requests() []*http.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*http.Request(nil), r.requests...)
+}
+
+// Unused returns how many recorded exchanges were never requested.
+func (r *Replayer) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
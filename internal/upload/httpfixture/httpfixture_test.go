@@ -0,0 +1,67 @@
+package httpfixture
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Call", strings.Repeat("i", calls))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, r.Method+" "+r.URL.Path)
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(http.DefaultTransport)
+	client := &http.Client{Transport: rec}
+	for _, path := range []string{"/a", "/a", "/b"} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader("body"))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	rp, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	client = &http.Client{Transport: rp}
+
+	// Exchanges for the same path are replayed in recorded order
+	for _, want := range []string{"i", "ii"} {
+		resp, err := client.Post("http://replayed.invalid/a", "text/plain", nil)
+		if err != nil {
+			t.Fatalf("replay error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated || string(body) != "POST /a" || resp.Header.Get("X-Call") != want {
+			t.Errorf("replay = %d %q X-Call %q, want 201 \"POST /a\" X-Call %q", resp.StatusCode, body, resp.Header.Get("X-Call"), want)
+		}
+		if resp.Header.Get("Set-Cookie") != "" {
+			t.Error("cookies should not be recorded")
+		}
+	}
+
+	if _, err := client.Get("http://replayed.invalid/b"); err == nil {
+		t.Error("a request with no matching method should fail")
+	}
+	if rp.Unused() != 1 || len(rp.Requests()) != 3 {
+		t.Errorf("Unused() = %d, Requests() = %d, want 1 and 3", rp.Unused(), len(rp.Requests()))
+	}
+}
@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// Files above this size are sent as a multipart upload, so a dropped
+	// connection only costs the part in flight.
+	r2MultipartThreshold = 16 * 1024 * 1024
+	r2PartSize           = 8 * 1024 * 1024 // S3 requires at least 5MB for all but the last part
+	r2PartTimeout        = 60 * time.Second
+	r2MaxMultipartSize   = 1024 * 1024 * 1024 // 1GB, uploads are held in memory
+)
+
+// multipartAPI is the subset of the S3 client used for multipart uploads.
+type multipartAPI interface {
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// multipartState is an unfinished multipart upload and the parts sent so
+// far, in order.
+type multipartState struct {
+	uploadID *string
+	parts    []types.CompletedPart
+}
+
+// pendingUploads holds multipart uploads that stopped on an error worth
+// retrying, keyed by pendingKey, so the next attempt at the same object
+// resumes them.
+var pendingUploads = struct {
+	sync.Mutex
+	uploads map[string]*multipartState
+}{uploads: map[string]*multipartState{}}
+
+// pendingKey identifies an upload of data to an object.
+func pendingKey(obj objectInput, data []byte) string {
+	sum := sha256.Sum256(data)
+	return obj.Bucket + "/" + obj.Key + "#" + hex.EncodeToString(sum[:])
+}
+
+// uploadMultipart sends data as a multipart upload of r2PartSize parts. Each
+// part is retried on its own, and an upload that still fails is kept so the
+// next call for the same data and object resumes it: parts already sent are
+// never sent again. Only rejected credentials or a cancelled context abort
+// the upload, so the bucket isn't billed for parts nobody will complete.
+func uploadMultipart(ctx context.Context, api multipartAPI, obj objectInput, data []byte) error {
+	key := pendingKey(obj, data)
+	pendingUploads.Lock()
+	state := pendingUploads.uploads[key]
+	delete(pendingUploads.uploads, key)
+	pendingUploads.Unlock()
+
+	if state == nil {
+		created, err := api.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:      aws.String(obj.Bucket),
+			Key:         aws.String(obj.Key),
+			ContentType: aws.String(obj.ContentType),
+			ACL:         obj.ACL,
+		})
+		if err != nil {
+			return classifyError(err)
+		}
+		state = &multipartState{uploadID: created.UploadId}
+	}
+
+	total := (len(data) + r2PartSize - 1) / r2PartSize
+	for number := int32(len(state.parts)) + 1; int(number) <= total; number++ {
+		offset := int(number-1) * r2PartSize
+		end := min(offset+r2PartSize, len(data))
+		etag, err := uploadPart(ctx, api, obj.Bucket, obj.Key, state.uploadID, number, data[offset:end])
+		if err != nil {
+			return keepOrAbort(ctx, api, obj, key, state, fmt.Errorf("part %d of %d: %w", number, total, err))
+		}
+		state.parts = append(state.parts, types.CompletedPart{ETag: etag, PartNumber: aws.Int32(number)})
+	}
+
+	_, err := api.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(obj.Bucket),
+		Key:             aws.String(obj.Key),
+		UploadId:        state.uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: state.parts},
+	})
+	if err != nil {
+		return keepOrAbort(ctx, api, obj, key, state, classifyError(err))
+	}
+	return nil
+}
+
+// keepOrAbort returns err after keeping the upload for the next attempt, or
+// aborting it when retrying can't help. An upload the server no longer knows
+// is forgotten, so the next attempt starts over.
+func keepOrAbort(ctx context.Context, api multipartAPI, obj objectInput, key string, state *multipartState, err error) error {
+	var statusErr httpStatusError
+	switch {
+	case errors.Is(err, ErrUploadAuth) || ctx.Err() != nil:
+		abortMultipart(api, obj.Bucket, obj.Key, state.uploadID)
+	case errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusNotFound:
+		// Expired or aborted on the server, nothing to resume
+	default:
+		pendingUploads.Lock()
+		pendingUploads.uploads[key] = state
+		pendingUploads.Unlock()
+	}
+	return err
+}
+
+// uploadPart sends one part with the same retry and backoff as a single
+// upload and returns its ETag.
+func uploadPart(ctx context.Context, api multipartAPI, bucket, key string, uploadID *string, number int32, part []byte) (*string, error) {
+	var lastErr error
+	for attempt := 0; attempt < r2MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := r2RetryBaseDelay * time.Duration(1<<attempt)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		partCtx, cancel := context.WithTimeout(ctx, uploadTimeout(r2PartTimeout, len(part)))
+		out, err := api.UploadPart(partCtx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			UploadId:   uploadID,
+			PartNumber: aws.Int32(number),
+			Body:       bytes.NewReader(part),
+		})
+		cancel()
+		if err == nil {
+			return out.ETag, nil
+		}
+
+		lastErr = classifyError(err)
+		if errors.Is(lastErr, ErrUploadAuth) || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// abortMultipart discards the parts of an unfinished upload. It runs on its
+// own context because the upload's context may be what was cancelled.
+func abortMultipart(api multipartAPI, bucket, key string, uploadID *string) {
+	ctx, cancel := context.WithTimeout(context.Background(), r2TestTimeout)
+	defer cancel()
+	api.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+}
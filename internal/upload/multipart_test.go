@@ -0,0 +1,138 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeMultipart records multipart calls and fails parts as scripted.
+type fakeMultipart struct {
+	failures  map[int32][]error // Errors returned by successive attempts of a part
+	sent      map[int32]int     // Attempts per part
+	sizes     map[int32]int
+	created   int
+	completed []int32
+	aborted   bool
+}
+
+func newFakeMultipart() *fakeMultipart {
+	return &fakeMultipart{failures: map[int32][]error{}, sent: map[int32]int{}, sizes: map[int32]int{}}
+}
+
+func (f *fakeMultipart) CreateMultipartUpload(ctx context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.created++
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (f *fakeMultipart) UploadPart(ctx context.Context, in *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	n := *in.PartNumber
+	attempt := f.sent[n]
+	f.sent[n]++
+	if attempt < len(f.failures[n]) {
+		return nil, f.failures[n][attempt]
+	}
+	body, _ := io.ReadAll(in.Body)
+	f.sizes[n] = len(body)
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", n))}, nil
+}
+
+func (f *fakeMultipart) CompleteMultipartUpload(ctx context.Context, in *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	for _, p := range in.MultipartUpload.Parts {
+		f.completed = append(f.completed, *p.PartNumber)
+	}
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeMultipart) AbortMultipartUpload(ctx context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func TestUploadMultipart_RetriesOnlyFailedPart(t *testing.T) {
+	api := newFakeMultipart()
+	api.failures[3] = []error{errors.New("connection reset")}
+	data := make([]byte, 2*r2PartSize+100)
+
+	if err := uploadMultipart(context.Background(), api, objectInput{Bucket: "bucket", Key: "key", ContentType: "video/mp4"}, data); err != nil {
+		t.Fatalf("uploadMultipart() error = %v", err)
+	}
+
+	wantSent := map[int32]int{1: 1, 2: 1, 3: 2}
+	for n, want := range wantSent {
+		if api.sent[n] != want {
+			t.Errorf("part %d sent %d times, want %d", n, api.sent[n], want)
+		}
+	}
+	if api.sizes[1] != r2PartSize || api.sizes[3] != 100 {
+		t.Errorf("part sizes = %v, want %d, %d, 100", api.sizes, r2PartSize, r2PartSize)
+	}
+	if fmt.Sprint(api.completed) != "[1 2 3]" {
+		t.Errorf("completed parts = %v, want [1 2 3]", api.completed)
+	}
+	if api.aborted {
+		t.Error("successful upload should not be aborted")
+	}
+}
+
+func TestUploadMultipart_ResumesAfterFailure(t *testing.T) {
+	api := newFakeMultipart()
+	reset := errors.New("connection reset")
+	api.failures[2] = make([]error, r2MaxRetries)
+	for i := range api.failures[2] {
+		api.failures[2][i] = reset
+	}
+	data := make([]byte, 3*r2PartSize)
+	data[0] = 1 // Not the data of other tests, which share the pending uploads
+	obj := objectInput{Bucket: "bucket", Key: "resume", ContentType: "video/mp4"}
+
+	if err := uploadMultipart(context.Background(), api, obj, data); !errors.Is(err, reset) {
+		t.Fatalf("uploadMultipart() error = %v, want the part's error", err)
+	}
+	if api.aborted {
+		t.Fatal("upload aborted on an error worth retrying")
+	}
+
+	// A retry of the same upload, by the caller or the queue, sends only the
+	// parts that are missing
+	if err := uploadMultipart(context.Background(), api, obj, data); err != nil {
+		t.Fatalf("resumed uploadMultipart() error = %v", err)
+	}
+	if api.created != 1 {
+		t.Errorf("created %d multipart uploads, want 1", api.created)
+	}
+	wantSent := map[int32]int{1: 1, 2: r2MaxRetries + 1, 3: 1}
+	for n, want := range wantSent {
+		if api.sent[n] != want {
+			t.Errorf("part %d sent %d times, want %d", n, api.sent[n], want)
+		}
+	}
+	if fmt.Sprint(api.completed) != "[1 2 3]" {
+		t.Errorf("completed parts = %v, want [1 2 3]", api.completed)
+	}
+}
+
+func TestUploadMultipart_AbortsOnAuthError(t *testing.T) {
+	api := newFakeMultipart()
+	api.failures[2] = []error{newAuthError("token expired", nil)}
+	data := make([]byte, 3*r2PartSize)
+
+	err := uploadMultipart(context.Background(), api, objectInput{Bucket: "bucket", Key: "key", ContentType: "image/gif"}, data)
+	if !errors.Is(err, ErrUploadAuth) {
+		t.Fatalf("uploadMultipart() error = %v, want ErrUploadAuth", err)
+	}
+	if api.sent[2] != 1 {
+		t.Errorf("rejected part sent %d times, want no retries", api.sent[2])
+	}
+	if api.sent[3] != 0 {
+		t.Error("parts after a failed one should not be sent")
+	}
+	if !api.aborted {
+		t.Error("failed upload should be aborted")
+	}
+}
@@ -0,0 +1,412 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Queue defaults, used where QueueOptions leaves a field zero.
+const (
+	DefaultQueueWorkers  = 2
+	DefaultMaxAttempts   = 4
+	DefaultRetryDelay    = 2 * time.Second
+	DefaultMaxRetryDelay = time.Minute
+)
+
+// ErrQueueClosed is returned when adding a job to a closed Queue.
+var ErrQueueClosed = errors.New("upload queue is closed")
+
+// JobState is where a queued upload is in its life.
+type JobState string
+
+const (
+	// JobQueued is waiting for a free worker.
+	JobQueued JobState = "queued"
+	// JobUploading is being sent.
+	JobUploading JobState = "uploading"
+	// JobRetrying failed and waits to be tried again.
+	JobRetrying JobState = "retrying"
+	// JobDone was uploaded.
+	JobDone JobState = "done"
+	// JobFailed failed for good, either on an error that won't go away or
+	// after the last attempt.
+	JobFailed JobState = "failed"
+	// JobCancelled was cancelled or dropped when the queue closed.
+	JobCancelled JobState = "cancelled"
+)
+
+// Job is an upload to run on a Queue. Any Uploader can be queued, so new
+// providers plug in by implementing that interface.
+type Job struct {
+	Provider UploadProvider // Reported in progress only
+	Uploader Uploader
+	Data     []byte
+	Filename string
+	// Done is called once when the job finishes, fails for good or is
+	// cancelled, with the error of the last attempt.
+	Done func(*UploadResult, error)
+}
+
+// Progress reports the state of a queued upload.
+type Progress struct {
+	ID        string         `json:"id"`
+	Provider  UploadProvider `json:"provider"`
+	Filename  string         `json:"filename"`
+	State     JobState       `json:"state"`
+	Attempt   int            `json:"attempt"` // 1 on the first try, 0 while first queued
+	Sent      int64          `json:"sent"`    // Bytes sent in this attempt
+	Total     int64          `json:"total"`
+	URL       string         `json:"url,omitempty"`
+	ExpiresAt string         `json:"expiresAt,omitempty"` // RFC 3339 time the link stops working, for ephemeral hosts
+	Error     string         `json:"error,omitempty"`     // Why the last attempt failed
+	RetryAt   string         `json:"retryAt,omitempty"`   // RFC 3339 time of the next attempt while retrying
+}
+
+// QueueOptions configures a Queue. Zero fields take the defaults.
+type QueueOptions struct {
+	Workers     int           // Uploads sent at once
+	MaxAttempts int           // Tries per job, including the first
+	RetryDelay  time.Duration // Wait before the first retry, doubled for each one after
+	MaxDelay    time.Duration // Longest wait between retries
+	// OnProgress is called on every change of a job: state changes and each
+	// percent sent. It runs with the queue locked, in order, so it must not
+	// call back into the queue.
+	OnProgress func(Progress)
+}
+
+// Queue uploads jobs in the background with a pool of workers, retrying
+// failures with exponential backoff. It is safe for concurrent use.
+type Queue struct {
+	opts QueueOptions
+
+	ctx    context.Context // Parent of every job, cancelled by Close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	ready   *sync.Cond   // Signalled when pending grows or the queue closes
+	pending []*queuedJob // Waiting for a worker, oldest first
+	jobs    map[string]*queuedJob
+	nextID  int
+	closed  bool
+}
+
+// queuedJob is a job with its state, guarded by Queue.mu.
+type queuedJob struct {
+	job      Job
+	progress Progress
+	ctx      context.Context
+	cancel   context.CancelFunc
+	timer    *time.Timer // Pending retry
+}
+
+// NewQueue starts a queue with its workers. Close stops it.
+func NewQueue(opts QueueOptions) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultQueueWorkers
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = DefaultRetryDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = DefaultMaxRetryDelay
+	}
+
+	q := &Queue{opts: opts, jobs: make(map[string]*queuedJob)}
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	q.ready = sync.NewCond(&q.mu)
+	for range opts.Workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Add queues a job and returns its ID.
+func (q *Queue) Add(job Job) (string, error) {
+	if job.Uploader == nil {
+		return "", errors.New("upload job has no uploader")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return "", ErrQueueClosed
+	}
+
+	q.nextID++
+	j := &queuedJob{
+		job: job,
+		progress: Progress{
+			ID:       strconv.Itoa(q.nextID),
+			Provider: job.Provider,
+			Filename: job.Filename,
+			State:    JobQueued,
+			Total:    int64(len(job.Data)),
+		},
+	}
+	j.ctx, j.cancel = context.WithCancel(q.ctx)
+	q.jobs[j.progress.ID] = j
+	q.pending = append(q.pending, j)
+	q.emit(j)
+	q.ready.Signal()
+	return j.progress.ID, nil
+}
+
+// Cancel stops a job, aborting it if it is being sent. It returns false if
+// the job isn't in the queue, such as when it has already finished.
+func (q *Queue) Cancel(id string) bool {
+	q.mu.Lock()
+	j, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return false
+	}
+	if j.progress.State == JobUploading {
+		// The worker sees the cancelled context and finishes the job
+		j.cancel()
+		q.mu.Unlock()
+		return true
+	}
+	q.drop(j)
+	q.mu.Unlock()
+	q.finish(j, JobCancelled, nil, context.Canceled)
+	return true
+}
+
+// Jobs returns the progress of every job not yet finished, oldest first.
+func (q *Queue) Jobs() []Progress {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]Progress, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		list = append(list, j.progress)
+	}
+	slices.SortFunc(list, func(a, b Progress) int {
+		x, _ := strconv.Atoi(a.ID)
+		y, _ := strconv.Atoi(b.ID)
+		return x - y
+	})
+	return list
+}
+
+// Close cancels every job, aborting those being sent, and waits for the
+// workers to stop.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	var waiting []*queuedJob
+	for _, j := range q.jobs {
+		if j.progress.State != JobUploading {
+			waiting = append(waiting, j)
+		}
+	}
+	for _, j := range waiting {
+		q.drop(j)
+	}
+	q.cancel()
+	q.ready.Broadcast()
+	q.mu.Unlock()
+
+	for _, j := range waiting {
+		q.finish(j, JobCancelled, nil, context.Canceled)
+	}
+	q.wg.Wait()
+}
+
+// work sends pending jobs until the queue closes.
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.ready.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+		j := q.pending[0]
+		q.pending = q.pending[1:]
+		j.progress.State = JobUploading
+		j.progress.Attempt++
+		j.progress.Sent = 0
+		j.progress.RetryAt = ""
+		q.emit(j)
+		q.mu.Unlock()
+
+		q.send(j)
+	}
+}
+
+// send makes one attempt at a job, then finishes it or schedules a retry.
+func (q *Queue) send(j *queuedJob) {
+	ctx := withProgress(j.ctx, func(n int) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		p := &j.progress
+		if p.State != JobUploading || p.Total == 0 {
+			return
+		}
+		// Encryption and SDK retries can send more than the data itself
+		sent := min(p.Sent+int64(n), p.Total)
+		percent := sent * 100 / p.Total
+		changed := percent != p.Sent*100/p.Total
+		p.Sent = sent
+		if changed {
+			q.emit(j)
+		}
+	})
+	result, err := j.job.Uploader.Upload(ctx, j.job.Data, j.job.Filename)
+	if err == nil && result != nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	switch {
+	case err == nil:
+		q.mu.Lock()
+		q.drop(j)
+		q.mu.Unlock()
+		q.finish(j, JobDone, result, nil)
+		return
+	case j.ctx.Err() != nil:
+		q.mu.Lock()
+		q.drop(j)
+		q.mu.Unlock()
+		q.finish(j, JobCancelled, result, context.Canceled)
+		return
+	}
+
+	q.mu.Lock()
+	if j.progress.Attempt >= q.opts.MaxAttempts || !retryable(err) || q.closed {
+		q.drop(j)
+		q.mu.Unlock()
+		q.finish(j, JobFailed, result, err)
+		return
+	}
+	delay := q.backoff(j.progress.Attempt)
+	j.progress.State = JobRetrying
+	j.progress.Error = err.Error()
+	j.progress.RetryAt = time.Now().Add(delay).Format(time.RFC3339)
+	j.timer = time.AfterFunc(delay, func() { q.requeue(j) })
+	q.emit(j)
+	q.mu.Unlock()
+}
+
+// requeue puts a job waiting to retry back in line.
+func (q *Queue) requeue(j *queuedJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.jobs[j.progress.ID] != j || j.progress.State != JobRetrying {
+		return
+	}
+	j.timer = nil
+	j.progress.State = JobQueued
+	q.pending = append(q.pending, j)
+	q.emit(j)
+	q.ready.Signal()
+}
+
+// backoff returns the wait after the given failed attempt.
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.RetryDelay
+	for i := 1; i < attempt && delay < q.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, q.opts.MaxDelay)
+}
+
+// drop removes a job from the queue. Callers hold q.mu.
+func (q *Queue) drop(j *queuedJob) {
+	delete(q.jobs, j.progress.ID)
+	if i := slices.Index(q.pending, j); i >= 0 {
+		q.pending = slices.Delete(q.pending, i, i+1)
+	}
+	if j.timer != nil {
+		j.timer.Stop()
+		j.timer = nil
+	}
+}
+
+// finish reports the outcome of a dropped job.
+func (q *Queue) finish(j *queuedJob, state JobState, result *UploadResult, err error) {
+	j.cancel()
+	q.mu.Lock()
+	j.progress.State = state
+	j.progress.RetryAt = ""
+	if err != nil {
+		j.progress.Error = err.Error()
+	} else {
+		j.progress.Error = ""
+	}
+	if result != nil {
+		j.progress.URL = result.PublicURL
+		j.progress.ExpiresAt = result.ExpiresAt
+	}
+	if state == JobDone {
+		j.progress.Sent = j.progress.Total
+	}
+	q.emit(j)
+	q.mu.Unlock()
+
+	// The data isn't needed anymore
+	j.job.Data = nil
+	if j.job.Done != nil {
+		if result == nil && err != nil {
+			result = &UploadResult{Success: false, Error: err.Error()}
+		}
+		j.job.Done(result, err)
+	}
+}
+
+// emit reports a job's progress. Callers hold q.mu.
+func (q *Queue) emit(j *queuedJob) {
+	if q.opts.OnProgress != nil {
+		q.opts.OnProgress(j.progress)
+	}
+}
+
+// retryable reports whether an upload error may go away on its own, unlike
+// rejected credentials or a request the provider refuses.
+func retryable(err error) bool {
+	if errors.Is(err, ErrUploadAuth) {
+		return false
+	}
+	var statusErr httpStatusError
+	if errors.As(err, &statusErr) {
+		status := statusErr.HTTPStatusCode()
+		if status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+			return false
+		}
+	}
+	return true
+}
+
+// progressKey is the context key of a progressFunc.
+type progressKey struct{}
+
+// progressFunc is told how many bytes of a request body were just sent.
+type progressFunc func(n int)
+
+// withProgress returns a context whose uploads report the bytes they send to
+// fn, counted by throttledBody.
+func withProgress(ctx context.Context, fn progressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress tells the progressFunc of ctx, if any, that n bytes were sent.
+func reportProgress(ctx context.Context, n int) {
+	if fn, ok := ctx.Value(progressKey{}).(progressFunc); ok {
+		fn(n)
+	}
+}
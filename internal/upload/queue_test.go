@@ -0,0 +1,169 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyUploader fails as many uploads as failures with err, then succeeds.
+// With block set, uploads wait for their context to end.
+type flakyUploader struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	block    bool
+	calls    int
+}
+
+func (u *flakyUploader) Upload(ctx context.Context, data []byte, filename string) (*UploadResult, error) {
+	u.mu.Lock()
+	u.calls++
+	fail := u.calls <= u.failures
+	u.mu.Unlock()
+	if u.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if fail {
+		return &UploadResult{Success: false, Error: u.err.Error()}, u.err
+	}
+	return &UploadResult{Success: true, PublicURL: "https://example.com/" + filename}, nil
+}
+
+func (u *flakyUploader) IsConfigured() bool    { return true }
+func (u *flakyUploader) TestConnection() error { return nil }
+
+// runJob queues one job on a fast retrying queue and waits for it to end
+func runJob(t *testing.T, q *Queue, u Uploader) (*UploadResult, error) {
+	t.Helper()
+	type outcome struct {
+		result *UploadResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	_, err := q.Add(Job{Provider: ProviderR2, Uploader: u, Data: []byte("png"), Filename: "shot.png",
+		Done: func(r *UploadResult, err error) { done <- outcome{r, err} }})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not finish")
+		return nil, nil
+	}
+}
+
+func TestQueue_RetriesTransientErrors(t *testing.T) {
+	var mu sync.Mutex
+	var states []JobState
+	q := NewQueue(QueueOptions{RetryDelay: time.Millisecond, OnProgress: func(p Progress) {
+		mu.Lock()
+		states = append(states, p.State)
+		mu.Unlock()
+	}})
+	defer q.Close()
+
+	u := &flakyUploader{failures: 2, err: &statusError{code: 503}}
+	result, err := runJob(t, q, u)
+	if err != nil || result.PublicURL != "https://example.com/shot.png" {
+		t.Fatalf("job = %+v, %v, want uploaded", result, err)
+	}
+	if u.calls != 3 {
+		t.Errorf("uploads = %d, want 3", u.calls)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []JobState{JobQueued, JobUploading, JobRetrying, JobQueued, JobUploading, JobRetrying, JobQueued, JobUploading, JobDone}
+	if len(states) != len(want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %v, want %v", states, want)
+		}
+	}
+}
+
+func TestQueue_GivesUp(t *testing.T) {
+	q := NewQueue(QueueOptions{RetryDelay: time.Millisecond, MaxAttempts: 3})
+	defer q.Close()
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"after the last attempt", errors.New("connection reset"), 3},
+		{"on rejected credentials", newAuthError("missing R2 access key", nil), 1},
+		{"on a refused request", &statusError{code: 413}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &flakyUploader{failures: 10, err: tt.err}
+			if _, err := runJob(t, q, u); !errors.Is(err, tt.err) {
+				t.Errorf("job error = %v, want %v", err, tt.err)
+			}
+			if u.calls != tt.wantCalls {
+				t.Errorf("uploads = %d, want %d", u.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := NewQueue(QueueOptions{Workers: 1})
+	defer q.Close()
+
+	blocked := &flakyUploader{block: true}
+	done := make(chan error, 2)
+	first, _ := q.Add(Job{Uploader: blocked, Data: []byte("a"), Done: func(_ *UploadResult, err error) { done <- err }})
+	second, _ := q.Add(Job{Uploader: blocked, Data: []byte("b"), Done: func(_ *UploadResult, err error) { done <- err }})
+
+	// The second job waits behind the first on the only worker
+	if !q.Cancel(second) {
+		t.Fatal("Cancel() of a queued job = false")
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("queued job error = %v, want context.Canceled", err)
+	}
+	for len(q.Jobs()) != 1 || q.Jobs()[0].State != JobUploading {
+		time.Sleep(time.Millisecond)
+	}
+	if !q.Cancel(first) {
+		t.Fatal("Cancel() of a running job = false")
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("running job error = %v, want context.Canceled", err)
+	}
+	if q.Cancel(first) {
+		t.Error("Cancel() of a finished job = true")
+	}
+}
+
+func TestQueue_Close(t *testing.T) {
+	q := NewQueue(QueueOptions{})
+	q.Close()
+	if _, err := q.Add(Job{Uploader: &flakyUploader{}}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Add() after Close() error = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestThrottledBody_ReportsProgress(t *testing.T) {
+	var sent int
+	ctx := withProgress(context.Background(), func(n int) { sent += n })
+	data := bytes.Repeat([]byte("x"), 3*throttleChunk+10)
+	body := &throttledBody{ctx: ctx, body: io.NopCloser(bytes.NewReader(data)), throttle: newThrottle()}
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		t.Fatal(err)
+	}
+	if sent != len(data) {
+		t.Errorf("progress = %d bytes, want %d", sent, len(data))
+	}
+}
@@ -1,11 +1,10 @@
 package upload
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"net/url"
+	"net/http"
 	"strings"
 	"time"
 
@@ -19,7 +18,6 @@ const (
 	r2TestTimeout    = 10 * time.Second
 	r2MaxRetries     = 3
 	r2RetryBaseDelay = 500 * time.Millisecond
-	r2MaxFileSize    = 50 * 1024 * 1024 // 50MB max file size
 )
 
 // R2Config holds configuration for Cloudflare R2.
@@ -32,8 +30,9 @@ type R2Config struct {
 
 // R2Uploader implements Uploader for Cloudflare R2.
 type R2Uploader struct {
-	creds  *CredentialManager
-	config *R2Config
+	creds     *CredentialManager
+	config    *R2Config
+	transport http.RoundTripper // nil uses http.DefaultTransport, tests replay fixtures
 }
 
 // NewR2Uploader creates a new R2Uploader instance.
@@ -53,11 +52,11 @@ func (r *R2Uploader) IsConfigured() bool {
 func (r *R2Uploader) getClient() (*s3.Client, error) {
 	accessKey, err := r.creds.Get(CredR2AccessKeyID)
 	if err != nil {
-		return nil, fmt.Errorf("missing R2 access key: %w", err)
+		return nil, newAuthError("missing R2 access key", err)
 	}
 	secretKey, err := r.creds.Get(CredR2SecretAccessKey)
 	if err != nil {
-		return nil, fmt.Errorf("missing R2 secret key: %w", err)
+		return nil, newAuthError("missing R2 secret key", err)
 	}
 
 	endpoint := fmt.Sprintf("https://%s.r2.cloudflarestorage.com", r.config.AccountID)
@@ -66,6 +65,7 @@ func (r *R2Uploader) getClient() (*s3.Client, error) {
 		Region:       "auto",
 		BaseEndpoint: aws.String(endpoint),
 		Credentials:  credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
+		HTTPClient:   throttledClient(r.transport),
 	})
 
 	return client, nil
@@ -83,12 +83,16 @@ func detectContentType(filename string) string {
 		return "image/gif"
 	case strings.HasSuffix(lower, ".webp"):
 		return "image/webp"
+	case strings.HasSuffix(lower, ".avif"):
+		return "image/avif"
 	case strings.HasSuffix(lower, ".bmp"):
 		return "image/bmp"
 	case strings.HasSuffix(lower, ".svg"):
 		return "image/svg+xml"
 	case strings.HasSuffix(lower, ".tiff"), strings.HasSuffix(lower, ".tif"):
 		return "image/tiff"
+	case strings.HasSuffix(lower, ".html"):
+		return "text/html; charset=utf-8"
 	default:
 		return "application/octet-stream"
 	}
@@ -105,8 +109,8 @@ func (r *R2Uploader) Upload(ctx context.Context, data []byte, filename string) (
 	if len(data) == 0 {
 		return &UploadResult{Success: false, Error: "empty file data"}, errors.New("empty file data")
 	}
-	if len(data) > r2MaxFileSize {
-		errMsg := fmt.Sprintf("file size %d exceeds maximum %d bytes", len(data), r2MaxFileSize)
+	if len(data) > r2MaxMultipartSize {
+		errMsg := fmt.Sprintf("file size %d exceeds maximum %d bytes", len(data), r2MaxMultipartSize)
 		return &UploadResult{Success: false, Error: errMsg}, errors.New(errMsg)
 	}
 
@@ -115,55 +119,23 @@ func (r *R2Uploader) Upload(ctx context.Context, data []byte, filename string) (
 		return &UploadResult{Success: false, Error: err.Error()}, err
 	}
 
-	contentType := detectContentType(filename)
-
-	var lastErr error
-	for attempt := 0; attempt < r2MaxRetries; attempt++ {
-		// Check context before each retry
-		if err := ctx.Err(); err != nil {
-			return &UploadResult{Success: false, Error: err.Error()}, err
-		}
-
-		if attempt > 0 {
-			// Exponential backoff: 1s, 2s (attempt 1: 1<<1=2*500ms=1s, attempt 2: 1<<2=4*500ms=2s)
-			delay := r2RetryBaseDelay * time.Duration(1<<attempt)
-			select {
-			case <-ctx.Done():
-				return &UploadResult{Success: false, Error: ctx.Err().Error()}, ctx.Err()
-			case <-time.After(delay):
-			}
-		}
-
-		// Build object key with optional directory prefix
-		objectKey := filename
-		if r.config.Directory != "" {
-			dir := strings.Trim(r.config.Directory, "/")
-			objectKey = dir + "/" + filename
-		}
-
-		uploadCtx, cancel := context.WithTimeout(ctx, r2UploadTimeout)
-		_, err = client.PutObject(uploadCtx, &s3.PutObjectInput{
-			Bucket:      aws.String(r.config.Bucket),
-			Key:         aws.String(objectKey),
-			Body:        bytes.NewReader(data),
-			ContentType: aws.String(contentType),
-		})
-		cancel()
-
-		if err == nil {
-			// Success - construct public URL with proper encoding
-			encodedKey := url.PathEscape(objectKey)
-			// Replace encoded slashes back to regular slashes for URL
-			encodedKey = strings.ReplaceAll(encodedKey, "%2F", "/")
-			publicURL := strings.TrimSuffix(r.config.PublicURL, "/") + "/" + encodedKey
-			return &UploadResult{Success: true, PublicURL: publicURL}, nil
-		}
-		lastErr = err
+	// Build object key with optional directory prefix
+	objectKey := filename
+	if r.config.Directory != "" {
+		dir := strings.Trim(r.config.Directory, "/")
+		objectKey = dir + "/" + filename
 	}
 
-	// All retries failed
-	errMsg := fmt.Sprintf("upload failed after %d attempts: %v", r2MaxRetries, lastErr)
-	return &UploadResult{Success: false, Error: errMsg}, lastErr
+	obj := objectInput{Bucket: r.config.Bucket, Key: objectKey, ContentType: detectContentType(filename)}
+	if err := putObject(ctx, client, obj, data); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+	return &UploadResult{Success: true, PublicURL: r.publicURL(objectKey)}, nil
+}
+
+// publicURL returns the public URL of an uploaded object.
+func (r *R2Uploader) publicURL(objectKey string) string {
+	return strings.TrimSuffix(r.config.PublicURL, "/") + "/" + escapeKey(objectKey)
 }
 
 // TestConnection verifies R2 credentials and bucket access.
@@ -180,7 +152,7 @@ func (r *R2Uploader) TestConnection() error {
 		Bucket: aws.String(r.config.Bucket),
 	})
 	if err != nil {
-		return fmt.Errorf("R2 connection test failed: %w", err)
+		return fmt.Errorf("R2 connection test failed: %w", classifyError(err))
 	}
 
 	return nil
@@ -70,6 +70,8 @@ func TestR2Uploader_DetectContentType(t *testing.T) {
 		{"vector.svg", "image/svg+xml"},
 		{"scan.tiff", "image/tiff"},
 		{"scan.tif", "image/tiff"},
+		{"viewer.html", "text/html; charset=utf-8"},
+		{"shot.png.enc", "application/octet-stream"},
 		{"unknown.xyz", "application/octet-stream"},
 		{"noextension", "application/octet-stream"},
 	}
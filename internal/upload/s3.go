@@ -0,0 +1,280 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3DefaultRegion is used when S3Config leaves the region empty. MinIO and
+// most S3-compatible services accept it whatever their own region is.
+const s3DefaultRegion = "us-east-1"
+
+// S3Config holds configuration for AWS S3 or an S3-compatible service such as
+// MinIO or Backblaze B2.
+type S3Config struct {
+	Endpoint    string `json:"endpoint,omitempty"` // Service URL, e.g. https://s3.us-west-004.backblazeb2.com, empty for AWS
+	Region      string `json:"region,omitempty"`   // us-east-1 if empty
+	Bucket      string `json:"bucket"`
+	PublicURL   string `json:"publicUrl,omitempty"`   // Base of shared links, the bucket's own URL if empty
+	KeyTemplate string `json:"keyTemplate,omitempty"` // Object key with {{filename}} and friends, the file name if empty
+	ACL         string `json:"acl,omitempty"`         // Canned ACL such as public-read, none sent if empty
+	PathStyle   bool   `json:"pathStyle,omitempty"`   // Address the bucket in the URL path, as MinIO needs
+}
+
+// S3Uploader implements Uploader for S3-compatible services.
+type S3Uploader struct {
+	creds     *CredentialManager
+	config    *S3Config
+	transport http.RoundTripper // nil uses http.DefaultTransport, tests replay fixtures
+	now       func() time.Time
+}
+
+// NewS3Uploader creates a new S3Uploader instance.
+func NewS3Uploader(creds *CredentialManager, cfg *S3Config) *S3Uploader {
+	return &S3Uploader{creds: creds, config: cfg, now: time.Now}
+}
+
+// IsConfigured returns true if S3 credentials and a bucket are set.
+func (u *S3Uploader) IsConfigured() bool {
+	if u.config == nil || u.config.Bucket == "" {
+		return false
+	}
+	return u.creds.Exists(CredS3AccessKeyID) && u.creds.Exists(CredS3SecretAccessKey)
+}
+
+// region returns the configured region or the default.
+func (u *S3Uploader) region() string {
+	if u.config.Region != "" {
+		return u.config.Region
+	}
+	return s3DefaultRegion
+}
+
+// getClient creates an S3 client for the configured service.
+func (u *S3Uploader) getClient() (*s3.Client, error) {
+	accessKey, err := u.creds.Get(CredS3AccessKeyID)
+	if err != nil {
+		return nil, newAuthError("missing S3 access key", err)
+	}
+	secretKey, err := u.creds.Get(CredS3SecretAccessKey)
+	if err != nil {
+		return nil, newAuthError("missing S3 secret key", err)
+	}
+
+	opts := s3.Options{
+		Region:       u.region(),
+		Credentials:  credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
+		HTTPClient:   throttledClient(u.transport),
+		UsePathStyle: u.config.PathStyle,
+	}
+	if u.config.Endpoint != "" {
+		opts.BaseEndpoint = aws.String(strings.TrimSuffix(u.config.Endpoint, "/"))
+	}
+	return s3.New(opts), nil
+}
+
+// Upload uploads image data to the bucket under the key template.
+func (u *S3Uploader) Upload(ctx context.Context, data []byte, filename string) (*UploadResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+	if len(data) == 0 {
+		return &UploadResult{Success: false, Error: "empty file data"}, errors.New("empty file data")
+	}
+	if len(data) > r2MaxMultipartSize {
+		errMsg := fmt.Sprintf("file size %d exceeds maximum %d bytes", len(data), r2MaxMultipartSize)
+		return &UploadResult{Success: false, Error: errMsg}, errors.New(errMsg)
+	}
+
+	objectKey, err := expandKeyTemplate(u.config.KeyTemplate, filename, u.now())
+	if err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+	client, err := u.getClient()
+	if err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+
+	obj := objectInput{
+		Bucket:      u.config.Bucket,
+		Key:         objectKey,
+		ContentType: detectContentType(filename),
+		ACL:         types.ObjectCannedACL(u.config.ACL),
+	}
+	if err := putObject(ctx, client, obj, data); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, err
+	}
+	return &UploadResult{Success: true, PublicURL: u.publicURL(objectKey)}, nil
+}
+
+// publicURL returns the link to an uploaded object: under PublicURL when
+// set, otherwise the bucket's own address at the service.
+func (u *S3Uploader) publicURL(objectKey string) string {
+	key := escapeKey(objectKey)
+	if u.config.PublicURL != "" {
+		return strings.TrimSuffix(u.config.PublicURL, "/") + "/" + key
+	}
+	if u.config.Endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.config.Bucket, u.region(), key)
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(u.config.Endpoint, "/"))
+	if err != nil || u.config.PathStyle || endpoint.Host == "" {
+		return strings.TrimSuffix(u.config.Endpoint, "/") + "/" + u.config.Bucket + "/" + key
+	}
+	endpoint.Host = u.config.Bucket + "." + endpoint.Host
+	return strings.TrimSuffix(endpoint.String(), "/") + "/" + key
+}
+
+// TestConnection verifies S3 credentials and bucket access.
+func (u *S3Uploader) TestConnection() error {
+	if _, err := expandKeyTemplate(u.config.KeyTemplate, "test.png", u.now()); err != nil {
+		return err
+	}
+	client, err := u.getClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r2TestTimeout)
+	defer cancel()
+
+	_, err = client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(u.config.Bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("S3 connection test failed: %w", classifyError(err))
+	}
+	return nil
+}
+
+var keyVarPattern = regexp.MustCompile(`\{\{\s*([a-z]+)\s*\}\}`)
+
+// expandKeyTemplate returns the object key for filename: {{filename}},
+// {{name}} and {{ext}} come from the file name, {{date}}, {{year}},
+// {{month}} and {{day}} from now, and {{random}} is 8 random hex digits that
+// make links hard to guess. An empty template is the file name. Unknown
+// names are an error, so a typo doesn't end up in every key.
+func expandKeyTemplate(template, filename string, now time.Time) (string, error) {
+	if strings.TrimSpace(template) == "" {
+		return filename, nil
+	}
+	ext := path.Ext(filename)
+	var unknown []string
+	key := keyVarPattern.ReplaceAllStringFunc(template, func(m string) string {
+		switch name := keyVarPattern.FindStringSubmatch(m)[1]; name {
+		case "filename":
+			return filename
+		case "name":
+			return strings.TrimSuffix(filename, ext)
+		case "ext":
+			return strings.TrimPrefix(ext, ".")
+		case "date":
+			return now.Format("2006-01-02")
+		case "year":
+			return now.Format("2006")
+		case "month":
+			return now.Format("01")
+		case "day":
+			return now.Format("02")
+		case "random":
+			b := make([]byte, 4)
+			rand.Read(b)
+			return hex.EncodeToString(b)
+		default:
+			unknown = append(unknown, name)
+			return m
+		}
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown key template variable %s", strings.Join(unknown, ", "))
+	}
+	key = strings.TrimLeft(key, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("key template %q gives no file name", template)
+	}
+	return key, nil
+}
+
+// escapeKey escapes an object key for use in a URL, keeping its slashes.
+func escapeKey(objectKey string) string {
+	return strings.ReplaceAll(url.PathEscape(objectKey), "%2F", "/")
+}
+
+// objectInput describes an object to put into an S3-compatible bucket.
+type objectInput struct {
+	Bucket      string
+	Key         string
+	ContentType string
+	ACL         types.ObjectCannedACL // None sent if empty
+}
+
+// putObject sends data to a bucket: as a multipart upload when it is large,
+// otherwise in one request retried with backoff. The error describes the
+// failure for UploadResult.Error and wraps the cause.
+func putObject(ctx context.Context, client *s3.Client, obj objectInput, data []byte) error {
+	if len(data) > r2MultipartThreshold {
+		if err := uploadMultipart(ctx, client, obj, data); err != nil {
+			if errors.Is(err, ErrUploadAuth) {
+				return fmt.Errorf("upload rejected: %w", err)
+			}
+			return fmt.Errorf("upload failed: %w", err)
+		}
+		return nil
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < r2MaxRetries; attempt++ {
+		// Check context before each retry
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if attempt > 0 {
+			// Exponential backoff: 1s, 2s (attempt 1: 1<<1=2*500ms=1s, attempt 2: 1<<2=4*500ms=2s)
+			delay := r2RetryBaseDelay * time.Duration(1<<attempt)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout(r2UploadTimeout, len(data)))
+		_, err := client.PutObject(uploadCtx, &s3.PutObjectInput{
+			Bucket:      aws.String(obj.Bucket),
+			Key:         aws.String(obj.Key),
+			Body:        bytes.NewReader(data),
+			ContentType: aws.String(obj.ContentType),
+			ACL:         obj.ACL,
+		})
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		lastErr = classifyError(err)
+
+		// Rejected credentials will not succeed on retry
+		if errors.Is(lastErr, ErrUploadAuth) {
+			return fmt.Errorf("upload rejected: %w", lastErr)
+		}
+	}
+
+	// All retries failed
+	return fmt.Errorf("upload failed after %d attempts: %w", r2MaxRetries, lastErr)
+}
@@ -0,0 +1,107 @@
+package upload
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"winshot/internal/upload/httpfixture"
+)
+
+func TestS3Uploader_Fixture_Upload(t *testing.T) {
+	creds := NewMemoryCredentialManager(map[string]string{
+		CredS3AccessKeyID:     "test-access-key",
+		CredS3SecretAccessKey: "test-secret-key",
+	})
+	u := NewS3Uploader(creds, &S3Config{
+		Endpoint:    "http://minio.local:9000",
+		Bucket:      "screenshots",
+		KeyTemplate: "{{year}}/{{month}}/{{filename}}",
+		ACL:         "public-read",
+		PathStyle:   true,
+	})
+	u.now = func() time.Time { return time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC) }
+	u.transport = httpfixture.Transport(t, "s3_put_object")
+
+	result, err := u.Upload(context.Background(), []byte("png data"), "shot 1.png")
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if want := "http://minio.local:9000/screenshots/2024/05/shot%201.png"; !result.Success || result.PublicURL != want {
+		t.Errorf("Upload() = %+v, want success with URL %s", result, want)
+	}
+	if rp, ok := u.transport.(*httpfixture.Replayer); ok {
+		req := rp.Requests()[0]
+		if req.URL.Host != "minio.local:9000" || req.Header.Get("X-Amz-Acl") != "public-read" {
+			t.Errorf("request to %s with ACL %q, want minio.local:9000 with public-read", req.URL.Host, req.Header.Get("X-Amz-Acl"))
+		}
+	}
+}
+
+func TestS3Uploader_IsConfigured(t *testing.T) {
+	creds := NewMemoryCredentialManager(map[string]string{
+		CredS3AccessKeyID:     "key",
+		CredS3SecretAccessKey: "secret",
+	})
+	if !NewS3Uploader(creds, &S3Config{Bucket: "shots"}).IsConfigured() {
+		t.Error("IsConfigured() = false with a bucket and credentials")
+	}
+	if NewS3Uploader(creds, &S3Config{}).IsConfigured() {
+		t.Error("IsConfigured() = true without a bucket")
+	}
+	if NewS3Uploader(NewMemoryCredentialManager(nil), &S3Config{Bucket: "shots"}).IsConfigured() {
+		t.Error("IsConfigured() = true without credentials")
+	}
+}
+
+func TestS3Uploader_PublicURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  S3Config
+		want string
+	}{
+		{"AWS", S3Config{Bucket: "shots", Region: "eu-west-1"}, "https://shots.s3.eu-west-1.amazonaws.com/a/b%20c.png"},
+		{"AWS default region", S3Config{Bucket: "shots"}, "https://shots.s3.us-east-1.amazonaws.com/a/b%20c.png"},
+		{"virtual hosted", S3Config{Endpoint: "https://s3.us-west-004.backblazeb2.com/", Bucket: "shots"}, "https://shots.s3.us-west-004.backblazeb2.com/a/b%20c.png"},
+		{"path style", S3Config{Endpoint: "http://localhost:9000", Bucket: "shots", PathStyle: true}, "http://localhost:9000/shots/a/b%20c.png"},
+		{"public URL", S3Config{Endpoint: "http://localhost:9000", Bucket: "shots", PublicURL: "https://cdn.example.com/"}, "https://cdn.example.com/a/b%20c.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := NewS3Uploader(NewMemoryCredentialManager(nil), &tt.cfg)
+			if got := u.publicURL("a/b c.png"); got != tt.want {
+				t.Errorf("publicURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandKeyTemplate(t *testing.T) {
+	now := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "shot.png"},
+		{"{{filename}}", "shot.png"},
+		{"/shots/{{date}}/{{name}}-v2.{{ext}}", "shots/2024-05-07/shot-v2.png"},
+		{"{{ year }}/{{month}}/{{day}}/{{filename}}", "2024/05/07/shot.png"},
+	}
+	for _, tt := range tests {
+		if got, err := expandKeyTemplate(tt.template, "shot.png", now); err != nil || got != tt.want {
+			t.Errorf("expandKeyTemplate(%q) = %q, %v, want %q", tt.template, got, err, tt.want)
+		}
+	}
+
+	got, err := expandKeyTemplate("{{random}}/{{filename}}", "shot.png", now)
+	if err != nil || len(got) != len("01234567/shot.png") || !strings.HasSuffix(got, "/shot.png") {
+		t.Errorf("expandKeyTemplate() with {{random}} = %q, %v", got, err)
+	}
+
+	for _, bad := range []string{"{{filname}}", "{{date}}/"} {
+		if _, err := expandKeyTemplate(bad, "shot.png", now); err == nil {
+			t.Errorf("expandKeyTemplate(%q) succeeded", bad)
+		}
+	}
+}
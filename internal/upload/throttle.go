@@ -0,0 +1,256 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// throttleChunk is the most a throttled body reads before waiting for tokens,
+// which keeps the transfer smooth instead of bursty.
+const throttleChunk = 16 * 1024
+
+// Schedule lists the times a rate limit applies, e.g. "Mon-Fri 09:00-17:00".
+// An empty schedule applies all the time.
+type Schedule []scheduleWindow
+
+type scheduleWindow struct {
+	days       [7]bool // Indexed by time.Weekday
+	start, end int     // Minutes since midnight, end <= start wraps past midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseSchedule parses comma-separated windows of an optional day or day
+// range followed by a time range, such as "Mon-Fri 09:00-17:00, Sat
+// 10:00-12:00" or "22:00-06:00". Times past midnight belong to the day the
+// window started on.
+func ParseSchedule(s string) (Schedule, error) {
+	var schedule Schedule
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid schedule window %q, use e.g. Mon-Fri 09:00-17:00", strings.TrimSpace(part))
+		}
+
+		var w scheduleWindow
+		if len(fields) == 1 {
+			w.days = [7]bool{true, true, true, true, true, true, true}
+		} else {
+			days, err := parseDays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			w.days = days
+		}
+
+		times := fields[len(fields)-1]
+		from, to, ok := strings.Cut(times, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time range %q, use e.g. 09:00-17:00", times)
+		}
+		var err error
+		if w.start, err = parseClock(from); err != nil {
+			return nil, err
+		}
+		if w.end, err = parseClock(to); err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, w)
+	}
+	return schedule, nil
+}
+
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	from, to, isRange := strings.Cut(strings.ToLower(s), "-")
+	if !isRange {
+		to = from
+	}
+	first, okFirst := weekdays[from]
+	last, okLast := weekdays[to]
+	if !okFirst || !okLast {
+		return days, fmt.Errorf("invalid days %q, use e.g. Mon-Fri", s)
+	}
+	for d := first; ; d = (d + 1) % 7 {
+		days[d] = true
+		if d == last {
+			break
+		}
+	}
+	return days, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use e.g. 09:00", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Active reports whether t falls inside the schedule.
+func (s Schedule) Active(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	for _, w := range s {
+		if w.start < w.end {
+			if w.days[today] && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// Wraps past midnight
+		if (w.days[today] && minute >= w.start) || (w.days[yesterday] && minute < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Throttle is a token bucket limiting the upload rate of every provider.
+type Throttle struct {
+	mu       sync.Mutex
+	rate     float64 // Bytes per second, 0 is unlimited
+	schedule Schedule
+	tokens   float64
+	last     time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// uploadThrottle is shared by all providers so the limit covers their total.
+var uploadThrottle = newThrottle()
+
+func newThrottle() *Throttle {
+	return &Throttle{
+		now: time.Now,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+				return nil
+			}
+		},
+	}
+}
+
+// SetRateLimit limits uploads of all providers to kbps kilobytes per second
+// while the schedule is active. A kbps of 0 removes the limit. Transfers in
+// progress pick up the change.
+func SetRateLimit(kbps int, schedule Schedule) {
+	uploadThrottle.set(kbps, schedule)
+}
+
+func (t *Throttle) set(kbps int, schedule Schedule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rate = float64(max(kbps, 0)) * 1024
+	t.schedule = schedule
+	t.tokens = 0
+	t.last = t.now()
+}
+
+// uploadTimeout stretches a transfer timeout by the time n bytes take at the
+// configured limit, so throttled uploads aren't cut off.
+func uploadTimeout(base time.Duration, n int) time.Duration {
+	uploadThrottle.mu.Lock()
+	rate := uploadThrottle.rate
+	uploadThrottle.mu.Unlock()
+	if rate == 0 {
+		return base
+	}
+	return base + time.Duration(float64(n)/rate*float64(time.Second))
+}
+
+// wait blocks until n bytes may be sent.
+func (t *Throttle) wait(ctx context.Context, n int) error {
+	t.mu.Lock()
+	now := t.now()
+	if t.rate == 0 || !t.schedule.Active(now) {
+		t.mu.Unlock()
+		return nil
+	}
+
+	// Refill, allowing at most one second of burst
+	t.tokens = min(t.tokens+now.Sub(t.last).Seconds()*t.rate, t.rate)
+	t.last = now
+	t.tokens -= float64(n)
+	var delay time.Duration
+	if t.tokens < 0 {
+		delay = time.Duration(-t.tokens / t.rate * float64(time.Second))
+	}
+	t.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	return t.sleep(ctx, delay)
+}
+
+// throttledBody paces reads of a request body through the throttle.
+type throttledBody struct {
+	ctx      context.Context
+	body     io.ReadCloser
+	throttle *Throttle
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := b.body.Read(p)
+	if n > 0 {
+		reportProgress(b.ctx, n)
+		if werr := b.throttle.wait(b.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (b *throttledBody) Close() error {
+	return b.body.Close()
+}
+
+// throttledTransport paces request bodies sent through base.
+type throttledTransport struct {
+	base     http.RoundTripper
+	throttle *Throttle
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.Body = &throttledBody{ctx: req.Context(), body: req.Body, throttle: t.throttle}
+	return t.base.RoundTrip(r)
+}
+
+// throttledClient returns an HTTP client sending through base, or
+// http.DefaultTransport when nil, whose uploads obey SetRateLimit.
+func throttledClient(base http.RoundTripper) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &throttledTransport{base: base, throttle: uploadThrottle},
+	}
+}
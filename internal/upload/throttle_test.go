@@ -0,0 +1,95 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Active(t *testing.T) {
+	// 2024-01-15 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		schedule string
+		at       time.Time
+		want     bool
+	}{
+		{"", at(15, 3, 0), true},
+		{"Mon-Fri 09:00-17:00", at(15, 9, 0), true},
+		{"Mon-Fri 09:00-17:00", at(15, 17, 0), false},
+		{"Mon-Fri 09:00-17:00", at(20, 12, 0), false}, // Saturday
+		{"mon-fri 09:00-17:00, Sat 10:00-12:00", at(20, 11, 0), true},
+		{"Fri-Mon 09:00-17:00", at(21, 12, 0), true}, // Range wraps through Sunday
+		{"Fri-Mon 09:00-17:00", at(17, 12, 0), false},
+		{"22:00-06:00", at(15, 23, 30), true},
+		{"22:00-06:00", at(15, 5, 59), true},
+		{"22:00-06:00", at(15, 12, 0), false},
+		{"Fri 22:00-06:00", at(20, 2, 0), true}, // Saturday morning belongs to Friday night
+		{"Fri 22:00-06:00", at(21, 2, 0), false},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.schedule)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error = %v", tt.schedule, err)
+			continue
+		}
+		if got := s.Active(tt.at); got != tt.want {
+			t.Errorf("ParseSchedule(%q).Active(%s) = %v, want %v", tt.schedule, tt.at.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	for _, s := range []string{"weekdays 09:00-17:00", "Mon-Fri", "Mon 9-5", "Mon 09:00-25:00", "Mon Tue 09:00-17:00"} {
+		if _, err := ParseSchedule(s); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", s)
+		}
+	}
+}
+
+func TestThrottle_LimitsRate(t *testing.T) {
+	clock := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	th := newThrottle()
+	th.now = func() time.Time { return clock }
+	th.sleep = func(ctx context.Context, d time.Duration) error {
+		slept += d
+		clock = clock.Add(d)
+		return nil
+	}
+
+	data := make([]byte, 100*1024)
+	read := func() {
+		body := &throttledBody{ctx: context.Background(), body: io.NopCloser(bytes.NewReader(data)), throttle: th}
+		if n, _ := io.Copy(io.Discard, body); n != int64(len(data)) {
+			t.Fatalf("read %d bytes, want %d", n, len(data))
+		}
+	}
+
+	// Unlimited by default
+	read()
+	if slept != 0 {
+		t.Errorf("unlimited read slept %v", slept)
+	}
+
+	th.set(50, nil)
+	read()
+	if want := 2 * time.Second; slept < want-10*time.Millisecond || slept > want+10*time.Millisecond {
+		t.Errorf("100KB at 50KB/s slept %v, want about %v", slept, want)
+	}
+
+	// Outside the schedule the limit doesn't apply
+	slept = 0
+	night, _ := ParseSchedule("00:00-06:00")
+	th.set(50, night)
+	read()
+	if slept != 0 {
+		t.Errorf("read outside the schedule slept %v", slept)
+	}
+}
@@ -1,7 +1,13 @@
 // Package upload provides cloud upload functionality for screenshots.
 package upload
 
-import "context"
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+)
 
 // UploadProvider identifies the cloud storage provider.
 type UploadProvider string
@@ -9,8 +15,14 @@ type UploadProvider string
 const (
 	// ProviderR2 is Cloudflare R2 storage.
 	ProviderR2 UploadProvider = "r2"
+	// ProviderS3 is AWS S3 or an S3-compatible service such as MinIO or B2.
+	ProviderS3 UploadProvider = "s3"
 	// ProviderGDrive is Google Drive storage.
 	ProviderGDrive UploadProvider = "gdrive"
+	// ProviderConfluence is a Confluence page attachment.
+	ProviderConfluence UploadProvider = "confluence"
+	// ProviderEphemeral is a 0x0-style host that deletes files after a while.
+	ProviderEphemeral UploadProvider = "ephemeral"
 )
 
 // UploadResult contains the result of an upload operation.
@@ -18,6 +30,7 @@ type UploadResult struct {
 	Success   bool   `json:"success"`
 	PublicURL string `json:"publicUrl"`
 	Error     string `json:"error,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"` // RFC 3339 time the host deletes the file, empty if it is kept
 }
 
 // Uploader defines the interface for cloud upload providers.
@@ -29,3 +42,58 @@ type Uploader interface {
 	// TestConnection tests if the credentials are valid and bucket is accessible.
 	TestConnection() error
 }
+
+// ErrUploadAuth is returned when credentials are missing, expired, or rejected
+// by the provider. Match it with errors.Is; the original cause stays wrapped.
+var ErrUploadAuth = errors.New("upload authentication failed")
+
+// authError keeps a user-facing message while matching ErrUploadAuth.
+type authError struct {
+	msg   string
+	cause error
+}
+
+func (e *authError) Error() string { return e.msg }
+
+func (e *authError) Unwrap() []error {
+	if e.cause == nil {
+		return []error{ErrUploadAuth}
+	}
+	return []error{ErrUploadAuth, e.cause}
+}
+
+// newAuthError returns an error with msg that matches ErrUploadAuth and wraps cause.
+func newAuthError(msg string, cause error) error {
+	if cause != nil {
+		msg += ": " + cause.Error()
+	}
+	return &authError{msg: msg, cause: cause}
+}
+
+// httpStatusError is implemented by SDK errors that carry an HTTP status code.
+type httpStatusError interface {
+	HTTPStatusCode() int
+}
+
+// classifyError marks provider errors caused by rejected credentials
+// (HTTP 401/403) with ErrUploadAuth. Other errors are returned unchanged.
+func classifyError(err error) error {
+	if err == nil || errors.Is(err, ErrUploadAuth) {
+		return err
+	}
+
+	status := 0
+	var statusErr httpStatusError
+	var googleErr *googleapi.Error
+	switch {
+	case errors.As(err, &statusErr):
+		status = statusErr.HTTPStatusCode()
+	case errors.As(err, &googleErr):
+		status = googleErr.Code
+	}
+
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return &authError{msg: err.Error(), cause: err}
+	}
+	return err
+}
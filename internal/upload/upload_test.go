@@ -0,0 +1,68 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+type statusError struct{ code int }
+
+func (e *statusError) Error() string       { return fmt.Sprintf("status %d", e.code) }
+func (e *statusError) HTTPStatusCode() int { return e.code }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantAuth bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("boom"), false},
+		{"s3 forbidden", &statusError{code: 403}, true},
+		{"s3 unauthorized wrapped", fmt.Errorf("put object: %w", &statusError{code: 401}), true},
+		{"s3 server error", &statusError{code: 500}, false},
+		{"google unauthorized", &googleapi.Error{Code: 401}, true},
+		{"google not found", &googleapi.Error{Code: 404}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			if errors.Is(got, ErrUploadAuth) != tt.wantAuth {
+				t.Errorf("errors.Is(classifyError(%v), ErrUploadAuth) = %v, want %v", tt.err, !tt.wantAuth, tt.wantAuth)
+			}
+			if tt.err != nil && !errors.Is(got, tt.err) {
+				t.Error("classified error should still wrap the original cause")
+			}
+		})
+	}
+}
+
+func TestNewAuthError(t *testing.T) {
+	cause := errors.New("credential not found")
+	err := newAuthError("missing R2 access key", cause)
+
+	if err.Error() != "missing R2 access key: credential not found" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, ErrUploadAuth) || !errors.Is(err, cause) {
+		t.Error("auth error should match ErrUploadAuth and its cause")
+	}
+}
+
+func TestGDriveUploader_UploadMissingAuthIsAuthError(t *testing.T) {
+	uploader := NewGDriveUploader(NewCredentialManager(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := uploader.Upload(ctx, []byte("test data"), "test.png")
+	if !errors.Is(err, ErrUploadAuth) {
+		t.Errorf("Upload() error = %v, want ErrUploadAuth", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Package uploadtest provides an in-memory upload.Uploader for testing code
+// that uploads captures, without credentials or network access.
+package uploadtest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+
+	"winshot/internal/upload"
+)
+
+// Uploader keeps uploaded files in memory. Set Err to make uploads and
+// connection tests fail, or Unconfigured to report missing setup.
+type Uploader struct {
+	BaseURL      string // Public URLs are BaseURL + "/" + filename
+	Err          error
+	Unconfigured bool
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+var _ upload.Uploader = (*Uploader)(nil)
+
+// New returns an empty, configured Uploader.
+func New() *Uploader {
+	return &Uploader{
+		BaseURL: "https://uploads.example.com",
+		files:   make(map[string][]byte),
+	}
+}
+
+// Upload stores a copy of data under filename.
+func (u *Uploader) Upload(ctx context.Context, data []byte, filename string) (*upload.UploadResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &upload.UploadResult{Success: false, Error: err.Error()}, err
+	}
+	if u.Err != nil {
+		return &upload.UploadResult{Success: false, Error: u.Err.Error()}, u.Err
+	}
+	if len(data) == 0 {
+		return &upload.UploadResult{Success: false, Error: "empty file data"}, errors.New("empty file data")
+	}
+
+	u.mu.Lock()
+	u.files[filename] = append([]byte(nil), data...)
+	u.mu.Unlock()
+	return &upload.UploadResult{Success: true, PublicURL: u.BaseURL + "/" + url.PathEscape(filename)}, nil
+}
+
+// IsConfigured implements upload.Uploader.
+func (u *Uploader) IsConfigured() bool {
+	return !u.Unconfigured
+}
+
+// TestConnection implements upload.Uploader.
+func (u *Uploader) TestConnection() error {
+	return u.Err
+}
+
+// File returns an uploaded file's content.
+func (u *Uploader) File(filename string) ([]byte, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	data, ok := u.files[filename]
+	return data, ok
+}
+
+// Filenames returns the names of all uploaded files, sorted.
+func (u *Uploader) Filenames() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	names := make([]string, 0, len(u.files))
+	for name := range u.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
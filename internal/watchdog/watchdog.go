@@ -0,0 +1,181 @@
+// Package watchdog watches another application for crashes and hangs and
+// writes incident reports around the captures taken at that moment.
+package watchdog
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Reason describes why an incident was raised.
+type Reason string
+
+const (
+	ReasonCrashed Reason = "crashed"        // Exited with a non-zero code
+	ReasonHung    Reason = "not responding" // Main window stopped processing messages
+)
+
+// Sample is one observation of the watched process.
+type Sample struct {
+	PID      uint32
+	Running  bool
+	ExitCode uint32 // Valid once Running is false
+	Hung     bool   // Main window reported hung by the system
+	Window   string // Main window title, empty when it has none
+}
+
+// Incident is a crash or hang of the watched process along with the files
+// captured for it.
+type Incident struct {
+	Process       string        `json:"process"`
+	PID           uint32        `json:"pid"`
+	Reason        Reason        `json:"reason"`
+	ExitCode      uint32        `json:"exitCode,omitempty"`
+	HungFor       time.Duration `json:"hungFor,omitempty"`
+	Window        string        `json:"window,omitempty"` // Last known main window title
+	Time          time.Time     `json:"time"`
+	Desktop       string        `json:"desktop,omitempty"`       // Path of the full desktop capture
+	WindowCapture string        `json:"windowCapture,omitempty"` // Path of the window capture, empty for crashes
+	Report        string        `json:"report,omitempty"`        // Path of the written report
+}
+
+// Monitor turns samples into incidents. Exits are only reported for a process
+// that was seen running and ended with a non-zero code. A hang is reported
+// once it has lasted HangAfter, and not again until the window responds.
+type Monitor struct {
+	Process   string
+	HangAfter time.Duration // Extra time past the system's own hang detection
+
+	seen       bool // Process observed running since the last exit
+	hungSince  time.Time
+	reported   bool // Current hang already reported
+	lastWindow string
+}
+
+// Observe feeds one sample taken at now and returns an incident if one
+// started.
+func (m *Monitor) Observe(s Sample, now time.Time) (Incident, bool) {
+	if s.Window != "" {
+		m.lastWindow = s.Window
+	}
+
+	if !s.Running {
+		seen, window := m.seen, m.lastWindow
+		*m = Monitor{Process: m.Process, HangAfter: m.HangAfter}
+		if !seen || s.ExitCode == 0 {
+			return Incident{}, false
+		}
+		return Incident{
+			Process:  m.Process,
+			PID:      s.PID,
+			Reason:   ReasonCrashed,
+			ExitCode: s.ExitCode,
+			Window:   window,
+			Time:     now,
+		}, true
+	}
+
+	m.seen = true
+	if !s.Hung {
+		m.hungSince = time.Time{}
+		m.reported = false
+		return Incident{}, false
+	}
+	if m.hungSince.IsZero() {
+		m.hungSince = now
+	}
+	if m.reported || now.Sub(m.hungSince) < m.HangAfter {
+		return Incident{}, false
+	}
+	m.reported = true
+	return Incident{
+		Process: m.Process,
+		PID:     s.PID,
+		Reason:  ReasonHung,
+		HungFor: now.Sub(m.hungSince),
+		Window:  m.lastWindow,
+		Time:    now,
+	}, true
+}
+
+// FileStem returns a file name prefix for the incident's captures and report,
+// such as "crash_app_2024-01-15_14-30-45".
+func (inc Incident) FileStem() string {
+	kind := "crash"
+	if inc.Reason == ReasonHung {
+		kind = "hang"
+	}
+	name := strings.TrimSuffix(strings.ToLower(inc.Process), ".exe")
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	return kind + "_" + name + "_" + inc.Time.Format("2006-01-02_15-04-05")
+}
+
+// FormatExitCode formats exit codes in the NTSTATUS range as hex, which is how
+// crash codes like 0xC0000005 are usually looked up, and others as decimal.
+func FormatExitCode(code uint32) string {
+	if code >= 0xC0000000 {
+		return fmt.Sprintf("0x%08X", code)
+	}
+	return fmt.Sprintf("%d", code)
+}
+
+// DefaultReportTemplate is used when no report template is configured. It is
+// Markdown so it can be pasted into most bug trackers as is.
+const DefaultReportTemplate = `# {{.Process}} {{.Reason}}
+
+- Time: {{.Time.Format "2006-01-02 15:04:05 MST"}}
+- Process: {{.Process}} (PID {{.PID}})
+{{- if .ExitCode}}
+- Exit code: {{exitCode .ExitCode}}
+{{- end}}
+{{- if .HungFor}}
+- Not responding for: {{.HungFor}}
+{{- end}}
+{{- if .Window}}
+- Window: {{.Window}}
+{{- end}}
+
+## Captures
+
+- Desktop: {{.Desktop}}
+{{- if .WindowCapture}}
+- Window: {{.WindowCapture}}
+{{- end}}
+`
+
+// ParseReportTemplate parses a report template, or the default when tmpl is
+// empty. Templates use text/template with the Incident fields and an exitCode
+// function wrapping FormatExitCode.
+func ParseReportTemplate(tmpl string) (*template.Template, error) {
+	if tmpl == "" {
+		tmpl = DefaultReportTemplate
+	}
+	t, err := template.New("report").
+		Funcs(template.FuncMap{"exitCode": FormatExitCode}).
+		Option("missingkey=error").
+		Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report template: %w", err)
+	}
+	return t, nil
+}
+
+// RenderReport renders the report for an incident.
+func RenderReport(tmpl string, inc Incident) (string, error) {
+	t, err := ParseReportTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, inc); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,122 @@
+package watchdog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Crash(t *testing.T) {
+	m := &Monitor{Process: "app.exe"}
+	now := time.Now()
+
+	// An exit without having seen the process run is not reported
+	if _, ok := m.Observe(Sample{ExitCode: 1}, now); ok {
+		t.Error("exit of a process never seen running should not be reported")
+	}
+
+	m.Observe(Sample{PID: 42, Running: true, Window: "Editor"}, now)
+	inc, ok := m.Observe(Sample{PID: 42, ExitCode: 0xC0000005}, now.Add(time.Second))
+	if !ok {
+		t.Fatal("non-zero exit should be reported")
+	}
+	if inc.Reason != ReasonCrashed || inc.ExitCode != 0xC0000005 || inc.Window != "Editor" {
+		t.Errorf("incident = %+v, want crash with code 0xC0000005 in window Editor", inc)
+	}
+
+	m.Observe(Sample{PID: 43, Running: true}, now)
+	if _, ok := m.Observe(Sample{PID: 43, ExitCode: 0}, now); ok {
+		t.Error("clean exit should not be reported")
+	}
+}
+
+func TestMonitor_HangReportedOncePerEpisode(t *testing.T) {
+	m := &Monitor{Process: "app.exe", HangAfter: 3 * time.Second}
+	start := time.Now()
+	hung := Sample{PID: 7, Running: true, Hung: true}
+
+	tests := []struct {
+		offset time.Duration
+		sample Sample
+		want   bool
+	}{
+		{0, hung, false},
+		{2 * time.Second, hung, false},
+		{3 * time.Second, hung, true},
+		{4 * time.Second, hung, false}, // Same episode
+		{5 * time.Second, Sample{PID: 7, Running: true}, false},
+		{6 * time.Second, hung, false},
+		{9 * time.Second, hung, true}, // New episode
+	}
+
+	for _, tt := range tests {
+		inc, ok := m.Observe(tt.sample, start.Add(tt.offset))
+		if ok != tt.want {
+			t.Errorf("Observe() at %v reported = %v, want %v", tt.offset, ok, tt.want)
+		}
+		if ok && (inc.Reason != ReasonHung || inc.HungFor != 3*time.Second) {
+			t.Errorf("incident at %v = %+v, want hang of 3s", tt.offset, inc)
+		}
+	}
+}
+
+func TestFormatExitCode(t *testing.T) {
+	tests := []struct {
+		code uint32
+		want string
+	}{
+		{1, "1"},
+		{0xC0000005, "0xC0000005"},
+		{0xC0000409, "0xC0000409"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatExitCode(tt.code); got != tt.want {
+			t.Errorf("FormatExitCode(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestIncident_FileStem(t *testing.T) {
+	at := time.Date(2024, 1, 15, 14, 30, 45, 0, time.UTC)
+	inc := Incident{Process: "My App.EXE", Reason: ReasonHung, Time: at}
+	if got, want := inc.FileStem(), "hang_my_app_2024-01-15_14-30-45"; got != want {
+		t.Errorf("FileStem() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderReport(t *testing.T) {
+	inc := Incident{
+		Process:  "app.exe",
+		PID:      42,
+		Reason:   ReasonCrashed,
+		ExitCode: 0xC0000005,
+		Time:     time.Date(2024, 1, 15, 14, 30, 45, 0, time.UTC),
+		Desktop:  `C:\shots\crash_desktop.png`,
+	}
+
+	report, err := RenderReport("", inc)
+	if err != nil {
+		t.Fatalf("RenderReport() error = %v", err)
+	}
+	for _, want := range []string{"# app.exe crashed", "PID 42", "Exit code: 0xC0000005", `Desktop: C:\shots\crash_desktop.png`} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "Not responding") {
+		t.Errorf("crash report should not include hang duration:\n%s", report)
+	}
+
+	custom, err := RenderReport("{{.Process}} {{exitCode .ExitCode}}", inc)
+	if err != nil || custom != "app.exe 0xC0000005" {
+		t.Errorf("RenderReport(custom) = %q, %v", custom, err)
+	}
+
+	if _, err := RenderReport("{{.Missing}}", inc); err == nil {
+		t.Error("RenderReport() with an unknown field should fail")
+	}
+	if _, err := ParseReportTemplate("{{.Process"); err == nil {
+		t.Error("ParseReportTemplate() with bad syntax should fail")
+	}
+}
@@ -0,0 +1,48 @@
+package windows
+
+import (
+	"errors"
+	"image"
+	"unsafe"
+)
+
+var (
+	procGetClientRect   = user32.NewProc("GetClientRect")
+	procClientToScreen  = user32.NewProc("ClientToScreen")
+	procGetDpiForWindow = user32.NewProc("GetDpiForWindow")
+)
+
+// DefaultDPI is the DPI of a display at 100% scaling
+const DefaultDPI = 96
+
+// ClientBounds returns the client area of a window in screen coordinates:
+// the part inside its frame, title bar and menu, which stays put relative to
+// the window's content when it is moved
+func ClientBounds(hwnd uintptr) (image.Rectangle, error) {
+	var rect RECT
+	if ret, _, err := procGetClientRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); ret == 0 {
+		return image.Rectangle{}, err
+	}
+	var origin struct{ X, Y int32 }
+	if ret, _, err := procClientToScreen.Call(hwnd, uintptr(unsafe.Pointer(&origin))); ret == 0 {
+		return image.Rectangle{}, err
+	}
+	if rect.Right <= rect.Left || rect.Bottom <= rect.Top {
+		return image.Rectangle{}, errors.New("window has no client area")
+	}
+	return image.Rect(0, 0, int(rect.Right-rect.Left), int(rect.Bottom-rect.Top)).
+		Add(image.Pt(int(origin.X), int(origin.Y))), nil
+}
+
+// WindowDPI returns the DPI a window is drawn at, DefaultDPI at 100%
+// scaling or on Windows versions before 10 1607
+func WindowDPI(hwnd uintptr) int {
+	if procGetDpiForWindow.Find() != nil {
+		return DefaultDPI
+	}
+	dpi, _, _ := procGetDpiForWindow.Call(hwnd)
+	if dpi == 0 {
+		return DefaultDPI
+	}
+	return int(dpi)
+}
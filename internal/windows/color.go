@@ -0,0 +1,65 @@
+package windows
+
+import (
+	"errors"
+	"image"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procMonitorFromPoint = user32.NewProc("MonitorFromPoint")
+	procGetMonitorInfoW  = user32.NewProc("GetMonitorInfoW")
+	procCreateDCW        = gdi32.NewProc("CreateDCW")
+	procGetICMProfileW   = gdi32.NewProc("GetICMProfileW")
+)
+
+const monitorDefaultToNearest = 2
+
+// monitorInfoEx mirrors MONITORINFOEXW
+type monitorInfoEx struct {
+	size    uint32
+	monitor RECT
+	work    RECT
+	flags   uint32
+	device  [32]uint16
+}
+
+// DisplayDevice returns the device name, such as \\.\DISPLAY1, of the
+// monitor showing most of r
+func DisplayDevice(r image.Rectangle) (string, error) {
+	center := r.Min.Add(r.Max).Div(2)
+	point := uintptr(uint32(int32(center.X))) | uintptr(uint32(int32(center.Y)))<<32
+	monitor, _, _ := procMonitorFromPoint.Call(point, monitorDefaultToNearest)
+	if monitor == 0 {
+		return "", errors.New("no monitor found")
+	}
+	info := monitorInfoEx{}
+	info.size = uint32(unsafe.Sizeof(info))
+	if ret, _, _ := procGetMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return "", errors.New("GetMonitorInfo failed")
+	}
+	return windows.UTF16ToString(info.device[:]), nil
+}
+
+// DisplayColorProfile returns the path of the ICC profile Windows color
+// management assigns to a display device
+func DisplayColorProfile(device string) (string, error) {
+	name, err := windows.UTF16PtrFromString(device)
+	if err != nil {
+		return "", err
+	}
+	hdc, _, _ := procCreateDCW.Call(uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(name)), 0, 0)
+	if hdc == 0 {
+		return "", errors.New("CreateDC failed for " + device)
+	}
+	defer procDeleteDC.Call(hdc)
+
+	buf := make([]uint16, windows.MAX_PATH)
+	size := uint32(len(buf))
+	if ret, _, _ := procGetICMProfileW.Call(hdc, uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&buf[0]))); ret == 0 {
+		return "", errors.New("no color profile for " + device)
+	}
+	return windows.UTF16ToString(buf), nil
+}
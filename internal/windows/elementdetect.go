@@ -0,0 +1,107 @@
+package windows
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+
+	"winshot/internal/com"
+)
+
+// UI Automation members used to list element bounds, counted as in uia.go
+const (
+	treeScopeSubtree = 0x7
+
+	uiaBoundingRectanglePropertyId = 30001
+
+	// IUIAutomation
+	vtblCreateCacheRequest      = 20
+	vtblGetControlViewCondition = 18
+
+	// IUIAutomationCacheRequest
+	vtblAddProperty = 3
+
+	// IUIAutomationElement
+	vtblFindAllBuildCache          = 8
+	vtblGetCachedBoundingRectangle = 75
+
+	// IUIAutomationElementArray
+	vtblGetLength  = 3
+	vtblGetElement = 4
+)
+
+// minElementSide is the smallest element side, in pixels, worth snapping to.
+// Smaller ones are mostly glyphs and icons inside larger controls.
+const minElementSide = 8
+
+// ElementBounds returns the screen bounds of a window and the UI Automation
+// elements in it, such as buttons, panes, lists and text fields, for
+// snapping selections to. At most limit elements are read, outermost first.
+// Elements are clipped to the window, and scrolled out and tiny ones are
+// left out.
+func ElementBounds(hwnd uintptr, limit int) ([]image.Rectangle, error) {
+	automation, done, err := newAutomation()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	var root uintptr
+	if hr := com.Call(automation, vtblElementFromHandle, hwnd, uintptr(unsafe.Pointer(&root))); com.Failed(hr) || root == 0 {
+		return nil, fmt.Errorf("window is not accessible: 0x%08x", uint32(hr))
+	}
+	defer com.Release(root)
+
+	// Fetch every bounding rectangle in one cross-process call instead of
+	// asking each element in turn
+	var request uintptr
+	if hr := com.Call(automation, vtblCreateCacheRequest, uintptr(unsafe.Pointer(&request))); com.Failed(hr) {
+		return nil, fmt.Errorf("failed to create cache request: 0x%08x", uint32(hr))
+	}
+	defer com.Release(request)
+	if hr := com.Call(request, vtblAddProperty, uiaBoundingRectanglePropertyId); com.Failed(hr) {
+		return nil, fmt.Errorf("failed to cache bounds: 0x%08x", uint32(hr))
+	}
+
+	var cond uintptr
+	if hr := com.Call(automation, vtblGetControlViewCondition, uintptr(unsafe.Pointer(&cond))); com.Failed(hr) {
+		return nil, fmt.Errorf("failed to create condition: 0x%08x", uint32(hr))
+	}
+	defer com.Release(cond)
+
+	var found uintptr
+	if hr := com.Call(root, vtblFindAllBuildCache, treeScopeSubtree, cond, request, uintptr(unsafe.Pointer(&found))); com.Failed(hr) || found == 0 {
+		return nil, fmt.Errorf("failed to search window: 0x%08x", uint32(hr))
+	}
+	defer com.Release(found)
+
+	var length int32
+	com.Call(found, vtblGetLength, uintptr(unsafe.Pointer(&length)))
+	count := min(int(length), limit)
+
+	// The subtree starts with the window itself
+	var window image.Rectangle
+	rects := make([]image.Rectangle, 0, count)
+	for i := 0; i < count; i++ {
+		var element uintptr
+		if hr := com.Call(found, vtblGetElement, uintptr(i), uintptr(unsafe.Pointer(&element))); com.Failed(hr) || element == 0 {
+			continue
+		}
+		var r RECT
+		hr := com.Call(element, vtblGetCachedBoundingRectangle, uintptr(unsafe.Pointer(&r)))
+		com.Release(element)
+		if com.Failed(hr) {
+			continue
+		}
+		b := image.Rect(int(r.Left), int(r.Top), int(r.Right), int(r.Bottom))
+		if i == 0 {
+			window = b
+		} else {
+			b = b.Intersect(window)
+		}
+		if b.Dx() >= minElementSide && b.Dy() >= minElementSide {
+			rects = append(rects, b)
+		}
+	}
+	return rects, nil
+}
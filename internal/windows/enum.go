@@ -3,8 +3,11 @@ package windows
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"image"
 	"image/png"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -16,17 +19,20 @@ var (
 	user32 = windows.NewLazySystemDLL("user32.dll")
 	gdi32  = windows.NewLazySystemDLL("gdi32.dll")
 
-	procEnumWindows          = user32.NewProc("EnumWindows")
-	procGetWindowTextW       = user32.NewProc("GetWindowTextW")
-	procGetWindowTextLengthW = user32.NewProc("GetWindowTextLengthW")
-	procGetClassNameW        = user32.NewProc("GetClassNameW")
-	procGetWindowRect        = user32.NewProc("GetWindowRect")
-	procIsWindowVisible      = user32.NewProc("IsWindowVisible")
-	procGetWindowLongW       = user32.NewProc("GetWindowLongW")
-	procPrintWindow          = user32.NewProc("PrintWindow")
-	procGetWindowDC          = user32.NewProc("GetWindowDC")
-	procReleaseDC            = user32.NewProc("ReleaseDC")
-	procGetDC                = user32.NewProc("GetDC")
+	procEnumWindows              = user32.NewProc("EnumWindows")
+	procGetWindowTextW           = user32.NewProc("GetWindowTextW")
+	procGetWindowTextLengthW     = user32.NewProc("GetWindowTextLengthW")
+	procGetClassNameW            = user32.NewProc("GetClassNameW")
+	procGetWindowRect            = user32.NewProc("GetWindowRect")
+	procIsWindowVisible          = user32.NewProc("IsWindowVisible")
+	procGetWindowLongW           = user32.NewProc("GetWindowLongW")
+	procPrintWindow              = user32.NewProc("PrintWindow")
+	procGetWindowDC              = user32.NewProc("GetWindowDC")
+	procReleaseDC                = user32.NewProc("ReleaseDC")
+	procGetDC                    = user32.NewProc("GetDC")
+	procGetForegroundWindow      = user32.NewProc("GetForegroundWindow")
+	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
+	procIsIconic                 = user32.NewProc("IsIconic")
 
 	procCreateCompatibleDC     = gdi32.NewProc("CreateCompatibleDC")
 	procCreateCompatibleBitmap = gdi32.NewProc("CreateCompatibleBitmap")
@@ -194,9 +200,104 @@ func GetWindowInfo(hwnd uintptr) (*WindowInfo, error) {
 	}, nil
 }
 
+// GetForegroundWindow returns the handle of the window the user is working in
+func GetForegroundWindow() uintptr {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	return hwnd
+}
+
+// FindProcessWindow returns the first visible, titled window owned by the
+// process, or nil if it has none
+func FindProcessWindow(pid uint32) *WindowInfo {
+	list, _ := EnumWindows()
+	for i := range list {
+		if WindowProcessID(list[i].Handle) == pid {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// WindowProcessID returns the ID of the process that owns a window
+func WindowProcessID(hwnd uintptr) uint32 {
+	var pid uint32
+	procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	return pid
+}
+
+// ProcessImageName returns the executable name of a process, e.g.
+// "chrome.exe", or "" if the process can't be queried
+func ProcessImageName(pid uint32) string {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return ""
+	}
+	defer windows.CloseHandle(h)
+
+	buf := make([]uint16, windows.MAX_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err != nil {
+		return ""
+	}
+	path := windows.UTF16ToString(buf[:size])
+	if i := strings.LastIndexByte(path, '\\'); i >= 0 {
+		path = path[i+1:]
+	}
+	return path
+}
+
+// IsMinimized reports whether a window is minimized
+func IsMinimized(hwnd uintptr) bool {
+	iconic, _, _ := procIsIconic.Call(hwnd)
+	return iconic != 0
+}
+
 // CaptureWindowThumbnail captures a thumbnail of a window
 // Returns base64 encoded PNG image scaled to specified max dimensions
 func CaptureWindowThumbnail(hwnd uintptr, maxWidth, maxHeight int) string {
+	img, err := CaptureWindowContent(hwnd)
+	if err != nil {
+		return ""
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	// Calculate thumbnail size maintaining aspect ratio
+	thumbWidth := maxWidth
+	thumbHeight := maxHeight
+	aspectRatio := float64(width) / float64(height)
+
+	if float64(thumbWidth)/float64(thumbHeight) > aspectRatio {
+		thumbWidth = int(float64(thumbHeight) * aspectRatio)
+	} else {
+		thumbHeight = int(float64(thumbWidth) / aspectRatio)
+	}
+
+	if thumbWidth < 1 {
+		thumbWidth = 1
+	}
+	if thumbHeight < 1 {
+		thumbHeight = 1
+	}
+
+	// Scale image
+	thumbnail := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+	// Encode to PNG
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, thumbnail); err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// CaptureWindowContent captures a window at full size straight from the
+// compositor with PrintWindow. Unlike screen captures it works for covered
+// windows and never includes other windows or WinShot's overlay, and
+// PW_RENDERFULLCONTENT includes hardware-accelerated content such as video.
+// Falls back to BitBlt from the window DC, which needs the window visible.
+func CaptureWindowContent(hwnd uintptr) (*image.RGBA, error) {
 	var rect RECT
 	procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect)))
 
@@ -204,26 +305,26 @@ func CaptureWindowThumbnail(hwnd uintptr, maxWidth, maxHeight int) string {
 	height := int(rect.Bottom - rect.Top)
 
 	if width <= 0 || height <= 0 {
-		return ""
+		return nil, fmt.Errorf("window 0x%x has no area", hwnd)
 	}
 
 	// Get window DC
 	hdcWindow, _, _ := procGetWindowDC.Call(hwnd)
 	if hdcWindow == 0 {
-		return ""
+		return nil, fmt.Errorf("failed to get DC of window 0x%x", hwnd)
 	}
 	defer procReleaseDC.Call(hwnd, hdcWindow)
 
 	// Create compatible DC and bitmap
 	hdcMem, _, _ := procCreateCompatibleDC.Call(hdcWindow)
 	if hdcMem == 0 {
-		return ""
+		return nil, errors.New("failed to create memory DC")
 	}
 	defer procDeleteDC.Call(hdcMem)
 
 	hBitmap, _, _ := procCreateCompatibleBitmap.Call(hdcWindow, uintptr(width), uintptr(height))
 	if hBitmap == 0 {
-		return ""
+		return nil, errors.New("failed to create bitmap")
 	}
 	defer procDeleteObject.Call(hBitmap)
 
@@ -253,44 +354,20 @@ func CaptureWindowThumbnail(hwnd uintptr, maxWidth, maxHeight int) string {
 		},
 	}
 
-	procGetDIBits.Call(hdcMem, hBitmap, 0, uintptr(height),
+	lines, _, _ := procGetDIBits.Call(hdcMem, hBitmap, 0, uintptr(height),
 		uintptr(unsafe.Pointer(&img.Pix[0])),
 		uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS)
+	if lines == 0 {
+		return nil, errors.New("failed to read window pixels")
+	}
 
-	// Convert BGRA to RGBA
+	// Convert BGRA to RGBA. GDI leaves the alpha byte undefined, so make
+	// every pixel opaque.
 	for i := 0; i < len(img.Pix); i += 4 {
 		img.Pix[i], img.Pix[i+2] = img.Pix[i+2], img.Pix[i]
+		img.Pix[i+3] = 0xFF
 	}
-
-	// Calculate thumbnail size maintaining aspect ratio
-	thumbWidth := maxWidth
-	thumbHeight := maxHeight
-	aspectRatio := float64(width) / float64(height)
-
-	if float64(thumbWidth)/float64(thumbHeight) > aspectRatio {
-		thumbWidth = int(float64(thumbHeight) * aspectRatio)
-	} else {
-		thumbHeight = int(float64(thumbWidth) / aspectRatio)
-	}
-
-	if thumbWidth < 1 {
-		thumbWidth = 1
-	}
-	if thumbHeight < 1 {
-		thumbHeight = 1
-	}
-
-	// Scale image
-	thumbnail := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
-	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, img.Bounds(), draw.Over, nil)
-
-	// Encode to PNG
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, thumbnail); err != nil {
-		return ""
-	}
-
-	return base64.StdEncoding.EncodeToString(buf.Bytes())
+	return img, nil
 }
 
 // EnumWindowsWithThumbnails returns a list of all visible windows with thumbnails
@@ -0,0 +1,84 @@
+package windows
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+)
+
+var (
+	procSendInput           = user32.NewProc("SendInput")
+	procSetForegroundWindow = user32.NewProc("SetForegroundWindow")
+)
+
+const (
+	inputKeyboard  = 1
+	keyeventfKeyUp = 0x0002
+
+	vkShift   = 0x10
+	vkControl = 0x11
+	vkMenu    = 0x12 // Alt
+	vkLWin    = 0x5B
+)
+
+// RegisterHotKey modifier flags, which the hotkeys package uses as well
+const (
+	modAlt     = 0x0001
+	modControl = 0x0002
+	modShift   = 0x0004
+	modWin     = 0x0008
+)
+
+// keyboardInput mirrors INPUT holding a KEYBDINPUT. The union is as large as
+// its biggest member, MOUSEINPUT, which is 8 bytes more on every
+// architecture.
+type keyboardInput struct {
+	inputType uint32
+	ki        keybdInput
+	_         [8]byte
+}
+
+type keybdInput struct {
+	vk, scan    uint16
+	flags, time uint32
+	extra       uintptr
+}
+
+// SendKey activates a window and types a key into it, as if pressed on the
+// keyboard, holding the modifiers given as RegisterHotKey MOD_ flags.
+// Players react to their shortcuts only while focused, so the window is
+// brought to the front first.
+func SendKey(hwnd uintptr, modifiers, keyCode uint) error {
+	if hwnd != 0 {
+		procSetForegroundWindow.Call(hwnd)
+		time.Sleep(50 * time.Millisecond) // Let focus settle before typing
+	}
+
+	var mods []uint16
+	for _, m := range []struct {
+		flag uint
+		vk   uint16
+	}{{modControl, vkControl}, {modAlt, vkMenu}, {modShift, vkShift}, {modWin, vkLWin}} {
+		if modifiers&m.flag != 0 {
+			mods = append(mods, m.vk)
+		}
+	}
+
+	// Press modifiers, tap the key, release modifiers in reverse
+	var inputs []keyboardInput
+	for _, vk := range mods {
+		inputs = append(inputs, keyboardInput{inputType: inputKeyboard, ki: keybdInput{vk: vk}})
+	}
+	inputs = append(inputs,
+		keyboardInput{inputType: inputKeyboard, ki: keybdInput{vk: uint16(keyCode)}},
+		keyboardInput{inputType: inputKeyboard, ki: keybdInput{vk: uint16(keyCode), flags: keyeventfKeyUp}})
+	for i := len(mods) - 1; i >= 0; i-- {
+		inputs = append(inputs, keyboardInput{inputType: inputKeyboard, ki: keybdInput{vk: mods[i], flags: keyeventfKeyUp}})
+	}
+
+	sent, _, _ := procSendInput.Call(uintptr(len(inputs)), uintptr(unsafe.Pointer(&inputs[0])), unsafe.Sizeof(inputs[0]))
+	if int(sent) != len(inputs) {
+		return errors.New("key input was blocked, the target may be running elevated")
+	}
+	return nil
+}
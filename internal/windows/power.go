@@ -0,0 +1,71 @@
+package windows
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procGetLastInputInfo     = user32.NewProc("GetLastInputInfo")
+	procGetTickCount         = kernel32.NewProc("GetTickCount")
+	procGetSystemPowerStatus = kernel32.NewProc("GetSystemPowerStatus")
+)
+
+// lastInputInfo mirrors LASTINPUTINFO
+type lastInputInfo struct {
+	size uint32
+	time uint32 // Tick count of the last input event
+}
+
+// systemPowerStatus mirrors SYSTEM_POWER_STATUS
+type systemPowerStatus struct {
+	acLineStatus        uint8 // 0 offline, 1 online, 255 unknown
+	batteryFlag         uint8 // 128 no system battery
+	batteryLifePercent  uint8 // 255 unknown
+	systemStatusFlag    uint8 // 1 battery saver on
+	batteryLifeTime     uint32
+	batteryFullLifeTime uint32
+}
+
+// PowerStatus is the machine's power source
+type PowerStatus struct {
+	OnBattery      bool // Running on battery rather than AC power
+	BatterySaver   bool // Windows battery saver is on
+	BatteryPercent int  // Remaining charge, -1 if unknown or no battery
+}
+
+// IdleTime returns how long ago the user last pressed a key or moved the
+// mouse in this session
+func IdleTime() (time.Duration, error) {
+	info := lastInputInfo{size: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if ret, _, _ := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return 0, errors.New("GetLastInputInfo failed")
+	}
+	// Both ticks wrap every 49.7 days, so subtract unsigned
+	now, _, _ := procGetTickCount.Call()
+	elapsed := uint32(now) - info.time
+	return time.Duration(elapsed) * time.Millisecond, nil
+}
+
+// GetPowerStatus reads whether the machine runs on battery and whether
+// battery saver is on
+func GetPowerStatus() (PowerStatus, error) {
+	var s systemPowerStatus
+	if ret, _, _ := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&s))); ret == 0 {
+		return PowerStatus{}, errors.New("GetSystemPowerStatus failed")
+	}
+	status := PowerStatus{
+		OnBattery:      s.acLineStatus == 0,
+		BatterySaver:   s.systemStatusFlag&1 != 0,
+		BatteryPercent: -1,
+	}
+	if s.batteryFlag&128 == 0 && s.batteryLifePercent <= 100 {
+		status.BatteryPercent = int(s.batteryLifePercent)
+	}
+	return status, nil
+}
@@ -0,0 +1,260 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"winshot/internal/com"
+)
+
+var (
+	iidIUnknown                      = windows.GUID{Data1: 0x00000000, Data2: 0x0000, Data3: 0x0000, Data4: [8]byte{0xc0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidIAgileObject                  = windows.GUID{Data1: 0x94ea2b94, Data2: 0xe9cc, Data3: 0x49e0, Data4: [8]byte{0xc0, 0xff, 0xee, 0x64, 0xca, 0x8f, 0x5b, 0x90}}
+	iidIStorageFileStatics           = windows.GUID{Data1: 0x5984c710, Data2: 0xdaf2, Data3: 0x43c8, Data4: [8]byte{0x8b, 0xb4, 0xa4, 0xd3, 0xea, 0xcf, 0xd0, 0x3f}}
+	iidIRandomAccessStreamRefStatics = windows.GUID{Data1: 0x857309dc, Data2: 0x3fbf, Data3: 0x4e7d, Data4: [8]byte{0x98, 0x6f, 0xef, 0x3b, 0x1a, 0x07, 0xa9, 0x64}}
+	iidIDataTransferManagerInterop   = windows.GUID{Data1: 0x3a3dcd6c, Data2: 0x3eab, Data3: 0x43dc, Data4: [8]byte{0xbc, 0xde, 0x45, 0x67, 0x1c, 0xe8, 0x00, 0xc8}}
+	iidIDataTransferManager          = windows.GUID{Data1: 0xa5caee9b, Data2: 0x8708, Data3: 0x49d1, Data4: [8]byte{0x8d, 0x36, 0x67, 0xd2, 0x5a, 0x8d, 0xa0, 0x0c}}
+	iidDataRequestedHandler          = windows.GUID{Data1: 0xec6f9cc8, Data2: 0x46d0, Data3: 0x5e0e, Data4: [8]byte{0xb4, 0xd2, 0x7d, 0x77, 0x73, 0xae, 0x37, 0xa0}} // TypedEventHandler<DataTransferManager, DataRequestedEventArgs>
+)
+
+// WinRT classes activated for sharing
+const (
+	classStorageFile         = "Windows.Storage.StorageFile"
+	classStreamReference     = "Windows.Storage.Streams.RandomAccessStreamReference"
+	classDataTransferManager = "Windows.ApplicationModel.DataTransfer.DataTransferManager"
+)
+
+const eNoInterface = 0x80004002
+
+// COM vtable slots of the WinRT interfaces used for sharing. WinRT
+// interfaces start after the 6 IInspectable methods
+const (
+	vtblAsyncGetResults = 8 // IAsyncOperation<T>.GetResults
+
+	vtblGetFileFromPathAsync = 6 // IStorageFileStatics
+	vtblCreateFromFile       = 6 // IRandomAccessStreamReferenceStatics
+
+	vtblGetForWindow         = 3 // IDataTransferManagerInterop
+	vtblShowShareUIForWindow = 4
+	vtblAddDataRequested     = 6 // IDataTransferManager
+	vtblRemoveDataRequested  = 7
+
+	vtblGetRequest    = 6  // IDataRequestedEventArgs
+	vtblGetData       = 6  // IDataRequest
+	vtblGetProperties = 7  // IDataPackage
+	vtblSetBitmap     = 21 // IDataPackage
+	vtblPutTitle      = 7  // IDataPackagePropertySet
+)
+
+// Share timeouts: loading the file and waiting for the share sheet to ask
+// for the data once it is shown
+const (
+	shareLoadTimeout    = 10 * time.Second
+	shareRequestTimeout = 10 * time.Second
+)
+
+// ErrShareBusy is returned by ShareImageFile while a share is being set up
+var ErrShareBusy = errors.New("a share is already in progress")
+
+// shareMu allows one share at a time, since the handler is a single object
+var shareMu sync.Mutex
+
+// ShareImageFile opens the Windows share sheet for an image file so it can
+// be sent to Mail, OneNote, Nearby Share or any other share target. The
+// sheet is placed over hwnd. It returns once the sheet has taken the image;
+// the file must stay in place until the target app has read it
+func ShareImageFile(hwnd uintptr, path, title string) error {
+	if !shareMu.TryLock() {
+		return ErrShareBusy
+	}
+	defer shareMu.Unlock()
+
+	// COM state belongs to the thread, so keep the goroutine on it
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	switch err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED); {
+	case err == nil, err == syscall.Errno(1): // S_FALSE: already initialized
+		defer windows.CoUninitialize()
+	case err == syscall.Errno(rpcEChangedMode):
+		// Already initialized for another apartment, which works as well
+	default:
+		return fmt.Errorf("failed to initialize COM: %w", err)
+	}
+
+	file, err := storageFileFromPath(path)
+	if err != nil {
+		return err
+	}
+	defer com.Release(file)
+
+	refStatics, err := com.ActivationFactory(classStreamReference, &iidIRandomAccessStreamRefStatics)
+	if err != nil {
+		return err
+	}
+	defer com.Release(refStatics)
+	var bitmap uintptr
+	if hr := com.Call(refStatics, vtblCreateFromFile, file, uintptr(unsafe.Pointer(&bitmap))); com.Failed(hr) {
+		return fmt.Errorf("failed to open the image for sharing: 0x%08x", uint32(hr))
+	}
+	defer com.Release(bitmap)
+
+	interop, err := com.ActivationFactory(classDataTransferManager, &iidIDataTransferManagerInterop)
+	if err != nil {
+		return err
+	}
+	defer com.Release(interop)
+	var manager uintptr
+	if hr := com.Call(interop, vtblGetForWindow, hwnd, uintptr(unsafe.Pointer(&iidIDataTransferManager)), uintptr(unsafe.Pointer(&manager))); com.Failed(hr) {
+		return fmt.Errorf("sharing is not available for this window: 0x%08x", uint32(hr))
+	}
+	defer com.Release(manager)
+
+	titleStr, err := com.NewHString(title)
+	if err != nil {
+		return err
+	}
+	defer com.DeleteHString(titleStr)
+
+	handler := newShareHandler(titleStr, bitmap)
+	var token int64
+	if hr := com.Call(manager, vtblAddDataRequested, uintptr(unsafe.Pointer(handler)), uintptr(unsafe.Pointer(&token))); com.Failed(hr) {
+		return fmt.Errorf("failed to register for share requests: 0x%08x", uint32(hr))
+	}
+	defer com.Call(manager, vtblRemoveDataRequested, uintptr(token))
+
+	if hr := com.Call(interop, vtblShowShareUIForWindow, hwnd); com.Failed(hr) {
+		return fmt.Errorf("failed to show the share sheet: 0x%08x", uint32(hr))
+	}
+
+	select {
+	case err := <-handler.done:
+		return err
+	case <-time.After(shareRequestTimeout):
+		return errors.New("the share sheet did not request the image")
+	}
+}
+
+// storageFileFromPath loads a StorageFile, waiting for the async operation
+func storageFileFromPath(path string) (uintptr, error) {
+	statics, err := com.ActivationFactory(classStorageFile, &iidIStorageFileStatics)
+	if err != nil {
+		return 0, err
+	}
+	defer com.Release(statics)
+
+	pathStr, err := com.NewHString(path)
+	if err != nil {
+		return 0, err
+	}
+	defer com.DeleteHString(pathStr)
+
+	var op uintptr
+	if hr := com.Call(statics, vtblGetFileFromPathAsync, pathStr, uintptr(unsafe.Pointer(&op))); com.Failed(hr) {
+		return 0, fmt.Errorf("failed to open %s: 0x%08x", path, uint32(hr))
+	}
+	defer com.Release(op)
+	if err := com.AwaitAsync(op, shareLoadTimeout); err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	var file uintptr
+	if hr := com.Call(op, vtblAsyncGetResults, uintptr(unsafe.Pointer(&file))); com.Failed(hr) || file == 0 {
+		return 0, fmt.Errorf("failed to open %s: 0x%08x", path, uint32(hr))
+	}
+	return file, nil
+}
+
+// shareHandler is a COM object implementing the DataRequested event handler.
+// It is agile, so the share broker may call it from any thread
+type shareHandler struct {
+	vtbl   *shareHandlerVtbl
+	refs   int32
+	title  uintptr // HSTRING
+	bitmap uintptr // IRandomAccessStreamReference
+	done   chan error
+}
+
+type shareHandlerVtbl struct {
+	queryInterface, addRef, release, invoke uintptr
+}
+
+var (
+	shareVtbl = &shareHandlerVtbl{
+		queryInterface: syscall.NewCallback(shareQueryInterface),
+		addRef:         syscall.NewCallback(shareAddRef),
+		release:        syscall.NewCallback(shareRelease),
+		invoke:         syscall.NewCallback(shareInvoke),
+	}
+	// activeHandler keeps the handler reachable while COM holds it
+	activeHandler *shareHandler
+)
+
+func newShareHandler(title, bitmap uintptr) *shareHandler {
+	h := &shareHandler{vtbl: shareVtbl, refs: 1, title: title, bitmap: bitmap, done: make(chan error, 1)}
+	activeHandler = h
+	return h
+}
+
+func shareQueryInterface(this, riid, ppv uintptr) uintptr {
+	iid := *(*windows.GUID)(unsafe.Pointer(riid))
+	if iid != iidIUnknown && iid != iidIAgileObject && iid != iidDataRequestedHandler {
+		*(*uintptr)(unsafe.Pointer(ppv)) = 0
+		return eNoInterface
+	}
+	*(*uintptr)(unsafe.Pointer(ppv)) = this
+	shareAddRef(this)
+	return 0
+}
+
+func shareAddRef(this uintptr) uintptr {
+	return uintptr(atomic.AddInt32(&(*shareHandler)(unsafe.Pointer(this)).refs, 1))
+}
+
+func shareRelease(this uintptr) uintptr {
+	return uintptr(atomic.AddInt32(&(*shareHandler)(unsafe.Pointer(this)).refs, -1))
+}
+
+// shareInvoke fills the share request with the image and its title
+func shareInvoke(this, sender, args uintptr) uintptr {
+	h := (*shareHandler)(unsafe.Pointer(this))
+	hr := h.fill(args)
+	var err error
+	if com.Failed(hr) {
+		err = fmt.Errorf("failed to hand the image to the share sheet: 0x%08x", uint32(hr))
+	}
+	select {
+	case h.done <- err:
+	default:
+	}
+	return hr
+}
+
+func (h *shareHandler) fill(args uintptr) uintptr {
+	var request, data, props uintptr
+	if hr := com.Call(args, vtblGetRequest, uintptr(unsafe.Pointer(&request))); com.Failed(hr) {
+		return hr
+	}
+	defer com.Release(request)
+	if hr := com.Call(request, vtblGetData, uintptr(unsafe.Pointer(&data))); com.Failed(hr) {
+		return hr
+	}
+	defer com.Release(data)
+	if hr := com.Call(data, vtblGetProperties, uintptr(unsafe.Pointer(&props))); com.Failed(hr) {
+		return hr
+	}
+	defer com.Release(props)
+
+	// The sheet refuses data without a title
+	if hr := com.Call(props, vtblPutTitle, h.title); com.Failed(hr) {
+		return hr
+	}
+	return com.Call(data, vtblSetBitmap, h.bitmap)
+}
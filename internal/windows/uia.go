@@ -0,0 +1,181 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"winshot/internal/com"
+)
+
+var (
+	ole32    = windows.NewLazySystemDLL("ole32.dll")
+	oleaut32 = windows.NewLazySystemDLL("oleaut32.dll")
+
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+	procVariantClear     = oleaut32.NewProc("VariantClear")
+)
+
+var (
+	clsidCUIAutomation = windows.GUID{Data1: 0xff48dba4, Data2: 0x60ef, Data3: 0x4201, Data4: [8]byte{0xaa, 0x87, 0x54, 0x10, 0x3e, 0xef, 0x59, 0x4e}}
+	iidIUIAutomation   = windows.GUID{Data1: 0x30cbe57d, Data2: 0xd9d0, Data3: 0x452a, Data4: [8]byte{0xab, 0x13, 0x7a, 0xc5, 0xac, 0x48, 0x25, 0xee}}
+)
+
+// UI Automation constants from UIAutomationClient.h
+const (
+	clsctxInprocServer = 0x1
+
+	treeScopeDescendants = 0x4
+
+	uiaControlTypePropertyId = 30003
+	uiaValueValuePropertyId  = 30045
+	uiaEditControlTypeId     = 50004
+
+	vtI4   = 3
+	vtBSTR = 8
+
+	rpcEChangedMode = 0x80010106
+)
+
+// COM vtable slots used below, counted from the start of each interface
+const (
+	// IUIAutomation
+	vtblElementFromHandle       = 6
+	vtblCreatePropertyCondition = 23
+
+	// IUIAutomationElement
+	vtblFindFirst               = 5
+	vtblGetCurrentPropertyValue = 10
+)
+
+// variant mirrors the COM VARIANT. The union is two pointers wide, making it
+// 16 bytes on 32-bit and 24 bytes on 64-bit Windows.
+type variant struct {
+	vt       uint16
+	reserved [3]uint16
+	val      [2]uintptr
+}
+
+// browserApps are the executables whose first edit field is the address bar
+var browserApps = map[string]bool{
+	"chrome.exe":  true,
+	"msedge.exe":  true,
+	"firefox.exe": true,
+	"brave.exe":   true,
+	"opera.exe":   true,
+	"vivaldi.exe": true,
+}
+
+// IsBrowser reports whether an executable name is a known browser
+func IsBrowser(app string) bool {
+	return browserApps[strings.ToLower(app)]
+}
+
+// BrowserURL reads the address bar of a browser window through UI
+// Automation. Browsers hide the scheme of secure pages, so addresses without
+// one are returned with https:// in front.
+func BrowserURL(hwnd uintptr) (string, error) {
+	automation, done, err := newAutomation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	var root uintptr
+	if hr := com.Call(automation, vtblElementFromHandle, hwnd, uintptr(unsafe.Pointer(&root))); com.Failed(hr) || root == 0 {
+		return "", fmt.Errorf("window is not accessible: 0x%08x", uint32(hr))
+	}
+	defer com.Release(root)
+
+	// The address bar is the first edit field in the browser's tree
+	editType := variant{vt: vtI4}
+	editType.val[0] = uiaEditControlTypeId
+	var cond uintptr
+	args := append([]uintptr{uiaControlTypePropertyId}, variantArgs(&editType)...)
+	if hr := com.Call(automation, vtblCreatePropertyCondition, append(args, uintptr(unsafe.Pointer(&cond)))...); com.Failed(hr) {
+		return "", fmt.Errorf("failed to create condition: 0x%08x", uint32(hr))
+	}
+	defer com.Release(cond)
+
+	var edit uintptr
+	if hr := com.Call(root, vtblFindFirst, treeScopeDescendants, cond, uintptr(unsafe.Pointer(&edit))); com.Failed(hr) {
+		return "", fmt.Errorf("failed to search window: 0x%08x", uint32(hr))
+	}
+	if edit == 0 {
+		return "", errors.New("no address bar found")
+	}
+	defer com.Release(edit)
+
+	var value variant
+	if hr := com.Call(edit, vtblGetCurrentPropertyValue, uiaValueValuePropertyId, uintptr(unsafe.Pointer(&value))); com.Failed(hr) {
+		return "", fmt.Errorf("failed to read address bar: 0x%08x", uint32(hr))
+	}
+	defer procVariantClear.Call(uintptr(unsafe.Pointer(&value)))
+	if value.vt != vtBSTR || value.val[0] == 0 {
+		return "", errors.New("address bar has no value")
+	}
+	return normalizeURL(windows.UTF16PtrToString((*uint16)(unsafe.Pointer(value.val[0])))), nil
+}
+
+// newAutomation initializes COM on the calling thread and creates a UI
+// Automation client. The goroutine stays on the thread until done is called,
+// which releases the client.
+func newAutomation() (automation uintptr, done func(), err error) {
+	// COM state belongs to the thread, so keep the goroutine on it
+	runtime.LockOSThread()
+
+	uninit := func() {}
+	switch err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED); {
+	case err == nil, err == syscall.Errno(1): // S_FALSE: already initialized
+		uninit = windows.CoUninitialize
+	case err == syscall.Errno(rpcEChangedMode):
+		// Already initialized for another apartment, which works as well
+	default:
+		runtime.UnlockOSThread()
+		return 0, nil, fmt.Errorf("failed to initialize COM: %w", err)
+	}
+
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidCUIAutomation)), 0, clsctxInprocServer,
+		uintptr(unsafe.Pointer(&iidIUIAutomation)), uintptr(unsafe.Pointer(&automation)))
+	if com.Failed(hr) {
+		uninit()
+		runtime.UnlockOSThread()
+		return 0, nil, fmt.Errorf("failed to create UI Automation: 0x%08x", uint32(hr))
+	}
+	return automation, func() {
+		com.Release(automation)
+		uninit()
+		runtime.UnlockOSThread()
+	}, nil
+}
+
+// normalizeURL adds the scheme browsers leave out of the address bar.
+// Search text typed into the bar is returned as is.
+func normalizeURL(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "://") || strings.HasPrefix(s, "about:") || strings.ContainsAny(s, " \t") {
+		return s
+	}
+	host, _, _ := strings.Cut(s, "/")
+	if !strings.Contains(host, ".") && !strings.HasPrefix(host, "localhost") {
+		return s
+	}
+	return "https://" + s
+}
+
+// variantArgs returns a VARIANT passed by value. The 64-bit calling
+// conventions pass structs this size by reference, while 32-bit code
+// pushes its 16 bytes onto the stack.
+func variantArgs(v *variant) []uintptr {
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		return []uintptr{uintptr(unsafe.Pointer(v))}
+	}
+	words := (*[4]uintptr)(unsafe.Pointer(v))
+	return words[:]
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"embed"
+	"os"
+	"slices"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -14,12 +16,35 @@ import (
 //go:embed all:frontend/dist
 var assets embed.FS
 
-// Single instance mutex name
+// Single instance mutex name, qualified per user by singleInstanceName
 const singleInstanceMutex = "WinShot-SingleInstance-Mutex-7F3A9B2E"
 
+// singleInstanceName scopes the mutex to the current session and user. The
+// Local\ namespace keeps fast user switching and terminal server sessions
+// apart, and the SID lets a second account run WinShot in the same session
+func singleInstanceName() string {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return `Local\` + singleInstanceMutex
+	}
+	return `Local\` + singleInstanceMutex + "-" + user.User.Sid.String()
+}
+
 func main() {
+	// Command-line mode (diagnostics) runs without the GUI or single instance lock
+	if handled, code := runCLI(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
+	safeMode := slices.Contains(os.Args[1:], safeModeFlag)
+	if safeMode {
+		if _, err := startSafeModeLog(); err != nil {
+			println("Warning: failed to start safe mode log:", err.Error())
+		}
+	}
+
 	// Single instance check using Windows mutex
-	mutexName, _ := windows.UTF16PtrFromString(singleInstanceMutex)
+	mutexName, _ := windows.UTF16PtrFromString(singleInstanceName())
 	handle, err := windows.CreateMutex(nil, false, mutexName)
 	if err != nil {
 		// Failed to create mutex - another instance likely running
@@ -35,12 +60,24 @@ func main() {
 	}
 
 	// Load config to get saved window size and startup settings
-	cfg, _ := config.Load()
+	var cfg *config.Config
+	if safeMode {
+		// Defaults only, and never written over the user's settings
+		cfg = safeModeConfig()
+		config.SetReadOnly(true)
+	} else {
+		cfg, _ = config.Load()
+
+		// Sync startup registry path if startup is enabled (fixes duplicate app issue #61)
+		if cfg != nil && cfg.Startup.LaunchOnStartup {
+			_ = config.SyncStartupPath()
+		}
 
-	// Sync startup registry path if startup is enabled (fixes duplicate app issue #61)
-	if cfg != nil && cfg.Startup.LaunchOnStartup {
-		_ = config.SyncStartupPath()
+		// Register the winshot:// protocol and context menu, repointing them
+		// after an upgrade moves the executable
+		_ = config.SyncShellIntegration()
 	}
+
 	width := cfg.Window.Width
 	height := cfg.Window.Height
 	if width < 800 {
@@ -54,9 +91,14 @@ func main() {
 	startHidden := cfg != nil && cfg.Startup.MinimizeToTray
 
 	app := NewApp()
+	app.safeMode = safeMode
+	title := "WinShot"
+	if safeMode {
+		title = "WinShot (Safe Mode)"
+	}
 
 	err = wails.Run(&options.App{
-		Title:            "WinShot",
+		Title:            title,
 		Width:            width,
 		Height:           height,
 		MinWidth:         800,
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"winshot/internal/config"
+	"winshot/internal/imaging"
+	"winshot/internal/screenshot"
+)
+
+// Outcomes of refreshing one anchor's baseline
+const (
+	refreshUnchanged = "unchanged" // Within the threshold, the baseline was left alone
+	refreshUpdated   = "updated"   // Changed beyond the threshold and rewritten
+	refreshNew       = "new"       // There was no baseline yet
+	refreshFailed    = "failed"    // The window or the baseline couldn't be read
+)
+
+// refreshResult is one anchor's line in a "winshot refresh" report
+type refreshResult struct {
+	Anchor  string  `json:"anchor"`
+	File    string  `json:"file"`
+	Status  string  `json:"status"`
+	Changed float64 `json:"changedPercent"`
+	Region  string  `json:"region,omitempty"` // x,y,w,h around the changed pixels
+	Error   string  `json:"error,omitempty"`
+}
+
+// refreshReport is the outcome of a "winshot refresh" run
+type refreshReport struct {
+	Time      time.Time       `json:"time"`
+	Threshold float64         `json:"thresholdPercent"`
+	DryRun    bool            `json:"dryRun,omitempty"` // Nothing was written
+	Results   []refreshResult `json:"results"`
+}
+
+// refreshAnchor captures an anchor and compares it with the baseline PNG at
+// file, which is rewritten when more than threshold percent of its pixels
+// changed by more than tolerance, or created when missing. dryRun only
+// reports what would be written
+func refreshAnchor(a config.AnchorConfig, file string, threshold float64, tolerance int, dryRun bool) refreshResult {
+	result := refreshResult{Anchor: a.Name, File: file}
+	fail := func(err error) refreshResult {
+		result.Status = refreshFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	r, err := anchorRect(a)
+	if err != nil {
+		return fail(err)
+	}
+	img, err := filtered(screenshot.CaptureRectRaw(r, screenshot.CaptureOptions{Clamp: true}))
+	if err != nil {
+		return fail(err)
+	}
+
+	baseline, err := readBaseline(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		result.Status = refreshNew
+		result.Changed = 100
+	case err != nil:
+		return fail(err)
+	default:
+		diff := imaging.Compare(baseline, img, tolerance)
+		result.Changed = diff.Percent()
+		if diff.Pixels > 0 || diff.Resized {
+			b := diff.Bounds
+			result.Region = fmt.Sprintf("%d,%d,%d,%d", b.Min.X, b.Min.Y, b.Dx(), b.Dy())
+		}
+		if !diff.Resized && result.Changed <= threshold {
+			result.Status = refreshUnchanged
+			return result
+		}
+		result.Status = refreshUpdated
+	}
+
+	if dryRun {
+		return result
+	}
+	data, err := encodeImageData(img, imaging.FormatPNG, 0)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err == nil {
+			err = os.WriteFile(file, data, 0644)
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return result
+}
+
+// readBaseline decodes a baseline PNG
+func readBaseline(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return img, nil
+}
+
+// write saves the report to file, as JSON for a .json file and as a
+// Markdown table otherwise, ready to paste into a pull request
+func (r refreshReport) write(file string) error {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, append(data, '\n'), 0644)
+	}
+	return os.WriteFile(file, []byte(r.markdown()), 0644)
+}
+
+// markdown returns the report as a Markdown table
+func (r refreshReport) markdown() string {
+	var b strings.Builder
+	b.WriteString("# Screenshot refresh\n\n")
+	fmt.Fprintf(&b, "%s, threshold %g%%", r.Time.Format("2006-01-02 15:04"), r.Threshold)
+	if r.DryRun {
+		b.WriteString(", dry run: nothing was written")
+	}
+	b.WriteString("\n\n| Anchor | File | Status | Changed | Region |\n|---|---|---|---|---|\n")
+	for _, res := range r.Results {
+		status := res.Status
+		if res.Error != "" {
+			status += ": " + res.Error
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %.2f%% | %s |\n",
+			markdownCell(res.Anchor), markdownCell(filepath.ToSlash(res.File)), markdownCell(status), res.Changed, res.Region)
+	}
+	return b.String()
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
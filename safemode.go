@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"winshot/internal/config"
+)
+
+// safeModeFlag starts WinShot with default settings and every integration
+// off, for diagnosing startup crashes caused by a broken config or extension
+const safeModeFlag = "--safe-mode"
+
+// safeModeConfig is the default config cut down to one hotkey and no update
+// check. It is never saved
+func safeModeConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Hotkeys.Region = ""
+	cfg.Hotkeys.Window = ""
+	cfg.Update.CheckOnStartup = false
+	return cfg
+}
+
+// startSafeModeLog sends log output, println warnings and panic traces to
+// safe-mode.log in the data folder. A GUI process has no console, so
+// without this they are lost
+func startSafeModeLog() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "safe-mode.log")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	// The runtime looks up the standard handles on every write, so this also
+	// catches println and fatal errors
+	windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(f.Fd()))
+	windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(f.Fd()))
+	os.Stdout, os.Stderr = f, f
+	debug.SetTraceback("all")
+
+	log.SetOutput(f)
+	log.SetFlags(log.Ldate | log.Lmicroseconds)
+	log.Printf("WinShot v%s safe mode, started %s", Version, time.Now().Format(time.RFC3339))
+	log.Printf("Args: %q", os.Args)
+	if exe, err := os.Executable(); err == nil {
+		log.Printf("Executable: %s", exe)
+	}
+	log.Printf("Data folder: %s", dir)
+	return path, nil
+}
+
+// verbosef logs a startup or event detail in safe mode only
+func (a *App) verbosef(format string, args ...any) {
+	if a.safeMode {
+		log.Output(2, fmt.Sprintf(format, args...))
+	}
+}
+
+// IsSafeMode reports whether WinShot was started with --safe-mode, so the
+// frontend can say why settings and uploads are unavailable
+func (a *App) IsSafeMode() bool {
+	return a.safeMode
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"winshot/internal/watchdog"
+	winEnum "winshot/internal/windows"
+)
+
+var procIsHungAppWindow = user32Diag.NewProc("IsHungAppWindow")
+
+// watchdogPollInterval is how often the watched process is sampled
+const watchdogPollInterval = time.Second
+
+// findProcess returns the ID of a running process with the given executable
+// name, compared case-insensitively
+func findProcess(name string) (uint32, bool) {
+	pids := findProcesses(name)
+	if len(pids) == 0 {
+		return 0, false
+	}
+	return pids[0], true
+}
+
+// findProcesses returns the IDs of every running process with the given
+// executable name
+func findProcesses(name string) []uint32 {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var pids []uint32
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		if strings.EqualFold(windows.UTF16ToString(entry.ExeFile[:]), name) {
+			pids = append(pids, entry.ProcessID)
+		}
+	}
+	return pids
+}
+
+// watchProcess samples the named process until stop is closed and calls
+// onIncident when it crashes or hangs. hwnd is the hung window, 0 for crashes.
+// A process that isn't running yet is waited for, and watched again if it
+// restarts after exiting.
+func watchProcess(name string, hangAfter time.Duration, stop <-chan struct{}, onIncident func(inc watchdog.Incident, hwnd uintptr)) {
+	monitor := &watchdog.Monitor{Process: name, HangAfter: hangAfter}
+	var process windows.Handle
+	var pid uint32
+	defer func() {
+		if process != 0 {
+			windows.CloseHandle(process)
+		}
+	}()
+
+	ticker := time.NewTicker(watchdogPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if process == 0 {
+			var ok bool
+			if pid, ok = findProcess(name); !ok {
+				continue
+			}
+			h, err := windows.OpenProcess(windows.SYNCHRONIZE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+			if err != nil {
+				continue
+			}
+			process = h
+		}
+
+		sample := watchdog.Sample{PID: pid, Running: true}
+		var hwnd uintptr
+		if event, _ := windows.WaitForSingleObject(process, 0); event == windows.WAIT_OBJECT_0 {
+			windows.GetExitCodeProcess(process, &sample.ExitCode)
+			sample.Running = false
+			windows.CloseHandle(process)
+			process = 0
+		} else if win := winEnum.FindProcessWindow(pid); win != nil {
+			hwnd = win.Handle
+			sample.Window = win.Title
+			hung, _, _ := procIsHungAppWindow.Call(hwnd)
+			sample.Hung = hung != 0
+		}
+
+		if inc, ok := monitor.Observe(sample, time.Now()); ok {
+			onIncident(inc, hwnd)
+		}
+	}
+}